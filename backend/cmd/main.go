@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"log/slog"
 	"net/http"
@@ -14,20 +15,47 @@ import (
 
 	"postgresus-backend/internal/config"
 	"postgresus-backend/internal/downdetect"
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/backups/backups"
 	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	backups_wal "postgresus-backend/internal/features/backups/wal"
+	"postgresus-backend/internal/features/configuration"
 	"postgresus-backend/internal/features/databases"
+	"postgresus-backend/internal/features/databases/databases/mongodb"
+	"postgresus-backend/internal/features/databases/databases/mysql"
+	"postgresus-backend/internal/features/databases/databases/postgresql"
 	"postgresus-backend/internal/features/disk"
+	"postgresus-backend/internal/features/encryption_keys"
 	healthcheck_attempt "postgresus-backend/internal/features/healthcheck/attempt"
 	healthcheck_config "postgresus-backend/internal/features/healthcheck/config"
 	"postgresus-backend/internal/features/notifiers"
+	discord_notifier "postgresus-backend/internal/features/notifiers/models/discord"
+	"postgresus-backend/internal/features/notifiers/models/email_notifier"
+	slack_notifier "postgresus-backend/internal/features/notifiers/models/slack"
+	telegram_notifier "postgresus-backend/internal/features/notifiers/models/telegram"
+	"postgresus-backend/internal/features/oidc"
 	"postgresus-backend/internal/features/restores"
+	"postgresus-backend/internal/features/secrets"
 	"postgresus-backend/internal/features/storages"
+	azure_blob_storage "postgresus-backend/internal/features/storages/models/azure_blob"
+	dropbox_storage "postgresus-backend/internal/features/storages/models/dropbox"
+	ftp_storage "postgresus-backend/internal/features/storages/models/ftp"
+	google_drive_storage "postgresus-backend/internal/features/storages/models/google_drive"
+	nas_storage "postgresus-backend/internal/features/storages/models/nas"
+	onedrive_storage "postgresus-backend/internal/features/storages/models/onedrive"
+	s3_storage "postgresus-backend/internal/features/storages/models/s3"
+	sftp_storage "postgresus-backend/internal/features/storages/models/sftp"
+	webdav_storage "postgresus-backend/internal/features/storages/models/webdav"
 	system_healthcheck "postgresus-backend/internal/features/system/healthcheck"
+	system_metrics "postgresus-backend/internal/features/system/metrics"
 	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/features/workspaces"
+	"postgresus-backend/internal/util/encryption"
 	env_utils "postgresus-backend/internal/util/env"
 	files_utils "postgresus-backend/internal/util/files"
 	"postgresus-backend/internal/util/logger"
+	"postgresus-backend/internal/util/tracing"
 	_ "postgresus-backend/swagger" // swagger docs
 
 	"github.com/gin-contrib/cors"
@@ -49,14 +77,50 @@ func main() {
 	log := logger.GetLogger()
 
 	runMigrations(log)
+	loadMasterKey(log)
+	encryptPlaintextSecrets(log)
 
 	// Handle password reset if flag is provided
 	newPassword := flag.String("new-password", "", "Set a new password for the user")
+	resetTwoFactorEmail := flag.String(
+		"reset-2fa",
+		"",
+		"Disable two-factor authentication of the user with the email",
+	)
+	isRotateSecretsKey := flag.Bool(
+		"rotate-secrets-key",
+		false,
+		"Re-encrypt stored secrets with a new encryption key",
+	)
+	isRotateMasterKey := flag.Bool(
+		"rotate-master-key",
+		false,
+		"Re-wrap encryption keys with the master key from NEW_MASTER_KEY",
+	)
+	applyConfigPath := flag.String(
+		"apply-config",
+		"",
+		"Apply YAML configuration file and exit",
+	)
 	flag.Parse()
 	if *newPassword != "" {
 		resetPassword(*newPassword, log)
 	}
 
+	if *resetTwoFactorEmail != "" {
+		resetTwoFactor(*resetTwoFactorEmail, log)
+	}
+
+	if *isRotateSecretsKey {
+		rotateSecretsKey(log)
+	}
+
+	if *isRotateMasterKey {
+		rotateMasterKey(log)
+	}
+
+	shutdownTracing := setUpTracing(log)
+
 	go generateSwaggerDocs(log)
 
 	gin.SetMode(gin.ReleaseMode)
@@ -74,10 +138,20 @@ func main() {
 	enableCors(ginApp)
 	setUpRoutes(ginApp)
 	setUpDependencies()
+
+	if *applyConfigPath != "" {
+		applyConfigurationAndExit(*applyConfigPath, log, shutdownTracing)
+	}
+
+	if config.GetEnv().ConfigFile != "" {
+		applyConfiguration(config.GetEnv().ConfigFile, log)
+	}
+
 	runBackgroundTasks(log)
 	mountFrontend(ginApp)
 
 	startServerWithGracefulShutdown(log, ginApp)
+	shutdownTracing()
 }
 
 func resetPassword(newPassword string, log *slog.Logger) {
@@ -94,6 +168,159 @@ func resetPassword(newPassword string, log *slog.Logger) {
 	os.Exit(0)
 }
 
+func resetTwoFactor(email string, log *slog.Logger) {
+	log.Info("Resetting two-factor authentication...", "email", email)
+
+	userService := users.GetUserService()
+	err := userService.ResetTwoFactor(email)
+	if err != nil {
+		log.Error("Failed to reset two-factor authentication", "error", err)
+		os.Exit(1)
+	}
+
+	log.Info("Two-factor authentication reset successfully")
+	os.Exit(0)
+}
+
+func rotateSecretsKey(log *slog.Logger) {
+	log.Info("Rotating secrets encryption key...")
+
+	key, err := secrets.GetSecretService().RotateKey(getModelsWithSecrets()...)
+	if err != nil {
+		log.Error("Failed to rotate secrets encryption key", "error", err)
+		os.Exit(1)
+	}
+
+	log.Info("Secrets encryption key rotated successfully", "keyId", key.ID)
+	os.Exit(0)
+}
+
+func rotateMasterKey(log *slog.Logger) {
+	log.Info("Rotating master key...")
+
+	var newMasterKey []byte
+	var err error
+
+	if config.GetEnv().NewMasterKey != "" {
+		newMasterKey, err = encryption_keys.DecodeMasterKey(config.GetEnv().NewMasterKey)
+	} else if config.GetEnv().MasterKey == "" {
+		// the generated key is written to the key file
+		newMasterKey, err = encryption.GenerateKey()
+	} else {
+		err = errors.New("NEW_MASTER_KEY is required when the master key is set by MASTER_KEY")
+	}
+
+	if err != nil {
+		log.Error("Failed to rotate master key", "error", err)
+		os.Exit(1)
+	}
+
+	if err := encryption_keys.GetMasterKeyService().RotateMasterKey(newMasterKey); err != nil {
+		log.Error("Failed to rotate master key", "error", err)
+		os.Exit(1)
+	}
+
+	log.Info("Master key rotated successfully, restart Postgresus to use it")
+	os.Exit(0)
+}
+
+// loadMasterKey fails the startup if the master key is lost, otherwise
+// stored secrets and encrypted backups could not be decrypted later
+func loadMasterKey(log *slog.Logger) {
+	if _, err := encryption_keys.GetMasterKeyProvider().GetMasterKey(); err != nil {
+		log.Error("Failed to load master key", "error", err)
+		os.Exit(1)
+	}
+}
+
+// encryptPlaintextSecrets encrypts secrets stored
+// before encryption at rest was introduced
+func encryptPlaintextSecrets(log *slog.Logger) {
+	err := secrets.GetSecretService().EncryptPlaintextSecrets(getModelsWithSecrets()...)
+	if err != nil {
+		log.Error("Failed to encrypt stored secrets", "error", err)
+		os.Exit(1)
+	}
+}
+
+// getModelsWithSecrets returns models which encrypt
+// their passwords and tokens before saving
+func getModelsWithSecrets() []any {
+	return []any{
+		&postgresql.PostgresqlDatabase{},
+		&mysql.MysqlDatabase{},
+		&mongodb.MongodbDatabase{},
+		&s3_storage.S3Storage{},
+		&google_drive_storage.GoogleDriveStorage{},
+		&nas_storage.NASStorage{},
+		&sftp_storage.SFTPStorage{},
+		&azure_blob_storage.AzureBlobStorage{},
+		&ftp_storage.FTPStorage{},
+		&webdav_storage.WebDAVStorage{},
+		&dropbox_storage.DropboxStorage{},
+		&onedrive_storage.OneDriveStorage{},
+		&email_notifier.EmailNotifier{},
+		&telegram_notifier.TelegramNotifier{},
+		&slack_notifier.SlackNotifier{},
+		&discord_notifier.DiscordNotifier{},
+	}
+}
+
+// applyConfigurationAndExit flushes spans before exit, since
+// os.Exit skips deferred calls and the shutdown of the server
+func applyConfigurationAndExit(path string, log *slog.Logger, shutdownTracing func()) {
+	err := applyConfiguration(path, log)
+	shutdownTracing()
+
+	if err != nil {
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+// applyConfiguration reconciles resources with the file. Failure is
+// logged but does not stop the server, so the setup can be fixed via UI
+func applyConfiguration(path string, log *slog.Logger) error {
+	log.Info("Applying configuration...", "path", path)
+
+	response, err := configuration.GetConfigurationService().ApplyConfigurationFile(path)
+	if err != nil {
+		log.Error("Failed to apply configuration", "error", err)
+		return err
+	}
+
+	log.Info("Configuration applied successfully", "changesCount", len(response.Changes))
+
+	return nil
+}
+
+// setUpTracing enables export of spans if OTLP endpoint is configured
+// and returns function flushing spans which are not exported yet
+func setUpTracing(log *slog.Logger) func() {
+	if config.GetEnv().OtelExporterOtlpEndpoint == "" &&
+		config.GetEnv().OtelExporterOtlpTracesEndpoint == "" {
+		return func() {}
+	}
+
+	shutdown, err := tracing.Setup(context.Background())
+	if err != nil {
+		log.Error("Failed to set up tracing", "error", err)
+		return func() {}
+	}
+
+	log.Info("Tracing enabled")
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := shutdown(ctx); err != nil {
+			log.Error("Failed to flush traces", "error", err)
+		}
+	}
+}
+
 func startServerWithGracefulShutdown(log *slog.Logger, app *gin.Engine) {
 	host := ""
 	if config.GetEnv().EnvMode == env_utils.EnvModeDevelopment {
@@ -131,12 +358,15 @@ func startServerWithGracefulShutdown(log *slog.Logger, app *gin.Engine) {
 
 func setUpRoutes(r *gin.Engine) {
 	v1 := r.Group("/api/v1")
+	v1.Use(users.GetUserService().ApiTokenScopeMiddleware())
 
 	// Mount Swagger UI
 	v1.GET("/docs/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	downdetectContoller := downdetect.GetDowndetectController()
 	userController := users.GetUserController()
+	apiTokenController := users.GetApiTokenController()
+	twoFactorController := users.GetTwoFactorController()
 	notifierController := notifiers.GetNotifierController()
 	storageController := storages.GetStorageController()
 	databaseController := databases.GetDatabaseController()
@@ -147,9 +377,16 @@ func setUpRoutes(r *gin.Engine) {
 	healthcheckAttemptController := healthcheck_attempt.GetHealthcheckAttemptController()
 	diskController := disk.GetDiskController()
 	backupConfigController := backups_config.GetBackupConfigController()
+	backupEncryptionKeyController := backups_encryption.GetBackupEncryptionKeyController()
+	workspaceController := workspaces.GetWorkspaceController()
+	oidcController := oidc.GetOidcController()
+	auditLogController := audit_logs.GetAuditLogController()
+	configurationController := configuration.GetConfigurationController()
 
 	downdetectContoller.RegisterRoutes(v1)
 	userController.RegisterRoutes(v1)
+	apiTokenController.RegisterRoutes(v1)
+	twoFactorController.RegisterRoutes(v1)
 	notifierController.RegisterRoutes(v1)
 	storageController.RegisterRoutes(v1)
 	databaseController.RegisterRoutes(v1)
@@ -160,6 +397,14 @@ func setUpRoutes(r *gin.Engine) {
 	healthcheckConfigController.RegisterRoutes(v1)
 	healthcheckAttemptController.RegisterRoutes(v1)
 	backupConfigController.RegisterRoutes(v1)
+	backupEncryptionKeyController.RegisterRoutes(v1)
+	workspaceController.RegisterRoutes(v1)
+	oidcController.RegisterRoutes(v1)
+	auditLogController.RegisterRoutes(v1)
+	configurationController.RegisterRoutes(v1)
+
+	metricsController := system_metrics.GetMetricsController()
+	metricsController.RegisterRoutes(&r.RouterGroup)
 }
 
 func setUpDependencies() {
@@ -167,6 +412,9 @@ func setUpDependencies() {
 	backups.SetupDependencies()
 	restores.SetupDependencies()
 	healthcheck_config.SetupDependencies()
+	workspaces.SetupDependencies()
+	audit_logs.SetupDependencies()
+	system_metrics.SetupDependencies()
 }
 
 func runBackgroundTasks(log *slog.Logger) {
@@ -181,6 +429,10 @@ func runBackgroundTasks(log *slog.Logger) {
 		backups.GetBackupBackgroundService().Run()
 	})
 
+	go runWithPanicLogging(log, "WAL receiver background service", func() {
+		backups_wal.GetWalReceiverBackgroundService().Run()
+	})
+
 	go runWithPanicLogging(log, "restore background service", func() {
 		restores.GetRestoreBackgroundService().Run()
 	})
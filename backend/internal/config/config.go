@@ -1,8 +1,10 @@
 package config
 
 import (
+	"encoding/base64"
 	"os"
 	"path/filepath"
+	"postgresus-backend/internal/util/encryption"
 	env_utils "postgresus-backend/internal/util/env"
 	"postgresus-backend/internal/util/logger"
 	"postgresus-backend/internal/util/tools"
@@ -25,24 +27,83 @@ type EnvVariables struct {
 	DatabaseDsn          string            `env:"DATABASE_DSN"         required:"true"`
 	EnvMode              env_utils.EnvMode `env:"ENV_MODE"             required:"true"`
 	PostgresesInstallDir string            `env:"POSTGRES_INSTALL_DIR"`
+	MysqlInstallDir      string            `env:"MYSQL_INSTALL_DIR"`
+	MongodbInstallDir    string            `env:"MONGODB_INSTALL_DIR"`
 
-	DataFolder string
-	TempFolder string
+	// OpenID Connect login, enabled when issuer is set
+	OidcIssuerUrl        string `env:"OIDC_ISSUER_URL"`
+	OidcClientID         string `env:"OIDC_CLIENT_ID"`
+	OidcClientSecret     string `env:"OIDC_CLIENT_SECRET"`
+	OidcRedirectUrl      string `env:"OIDC_REDIRECT_URL"`
+	OidcProviderName     string `env:"OIDC_PROVIDER_NAME"      env-default:"SSO"`
+	OidcScopes           string `env:"OIDC_SCOPES"             env-default:"openid email profile"`
+	OidcGroupsClaim      string `env:"OIDC_GROUPS_CLAIM"       env-default:"groups"`
+	OidcGroupRoleMapping string `env:"OIDC_GROUP_ROLE_MAPPING"`
+	OidcDefaultRole      string `env:"OIDC_DEFAULT_ROLE"`
+
+	IsPasswordLoginDisabled bool `env:"IS_PASSWORD_LOGIN_DISABLED"`
+	// users without TOTP have no access to workspaces until they enable it
+	IsTwoFactorRequired bool `env:"IS_TWO_FACTOR_REQUIRED"`
+
+	DataFolder       string
+	TempFolder       string
+	WalFolder        string
+	RecoveriesFolder string
+
+	// base64 encoded master key. If it is empty, the key is read from
+	// the key file, which is generated on the first start
+	MasterKey     string `env:"MASTER_KEY"`
+	MasterKeyFile string `env:"MASTER_KEY_FILE"`
+
+	// base64 encoded key the master key is rotated to by --rotate-master-key.
+	// If it is empty and the key is read from the file, a new key is generated
+	NewMasterKey string `env:"NEW_MASTER_KEY"`
+
+	// bearer token required by /metrics if set
+	MetricsToken string `env:"METRICS_TOKEN"`
+
+	// YAML configuration of workspaces, storages, notifiers and
+	// databases applied on each start if set
+	ConfigFile string `env:"CONFIG_FILE"`
+
+	// tracing is enabled if any of OTLP endpoints is set, the
+	// exporter reads the rest of OTEL_EXPORTER_OTLP_* by itself
+	OtelExporterOtlpEndpoint       string `env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	OtelExporterOtlpTracesEndpoint string `env:"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"`
 
 	TestGoogleDriveClientID     string `env:"TEST_GOOGLE_DRIVE_CLIENT_ID"`
 	TestGoogleDriveClientSecret string `env:"TEST_GOOGLE_DRIVE_CLIENT_SECRET"`
 	TestGoogleDriveTokenJSON    string `env:"TEST_GOOGLE_DRIVE_TOKEN_JSON"`
 
+	TestDropboxClientID     string `env:"TEST_DROPBOX_CLIENT_ID"`
+	TestDropboxClientSecret string `env:"TEST_DROPBOX_CLIENT_SECRET"`
+	TestDropboxTokenJSON    string `env:"TEST_DROPBOX_TOKEN_JSON"`
+
+	TestOneDriveClientID     string `env:"TEST_ONEDRIVE_CLIENT_ID"`
+	TestOneDriveClientSecret string `env:"TEST_ONEDRIVE_CLIENT_SECRET"`
+	TestOneDriveTokenJSON    string `env:"TEST_ONEDRIVE_TOKEN_JSON"`
+
 	TestPostgres13Port string `env:"TEST_POSTGRES_13_PORT"`
 	TestPostgres14Port string `env:"TEST_POSTGRES_14_PORT"`
 	TestPostgres15Port string `env:"TEST_POSTGRES_15_PORT"`
 	TestPostgres16Port string `env:"TEST_POSTGRES_16_PORT"`
 	TestPostgres17Port string `env:"TEST_POSTGRES_17_PORT"`
 
+	TestMysqlPort   string `env:"TEST_MYSQL_PORT"`
+	TestMariadbPort string `env:"TEST_MARIADB_PORT"`
+
 	TestMinioPort        string `env:"TEST_MINIO_PORT"`
 	TestMinioConsolePort string `env:"TEST_MINIO_CONSOLE_PORT"`
 
 	TestNASPort string `env:"TEST_NAS_PORT"`
+
+	TestAzuriteBlobPort string `env:"TEST_AZURITE_BLOB_PORT"`
+
+	TestFTPPort string `env:"TEST_FTP_PORT"`
+
+	TestSFTPPort string `env:"TEST_SFTP_PORT"`
+
+	TestWebDAVPort string `env:"TEST_WEBDAV_PORT"`
 }
 
 var (
@@ -125,13 +186,61 @@ func loadEnvVariables() {
 	}
 	log.Info("ENV_MODE loaded", "mode", env.EnvMode)
 
+	if env.OidcIssuerUrl != "" {
+		if env.OidcClientID == "" {
+			log.Error("OIDC_CLIENT_ID is empty")
+			os.Exit(1)
+		}
+
+		if env.OidcRedirectUrl == "" {
+			log.Error("OIDC_REDIRECT_URL is empty")
+			os.Exit(1)
+		}
+	}
+
+	// otherwise nobody could sign in
+	if env.IsPasswordLoginDisabled && env.OidcIssuerUrl == "" {
+		log.Error("IS_PASSWORD_LOGIN_DISABLED requires OIDC_ISSUER_URL")
+		os.Exit(1)
+	}
+
 	env.PostgresesInstallDir = filepath.Join(backendRoot, "tools", "postgresql")
 	tools.VerifyPostgresesInstallation(log, env.EnvMode, env.PostgresesInstallDir)
 
+	env.MysqlInstallDir = filepath.Join(backendRoot, "tools", "mysql")
+	tools.VerifyMysqlInstallation(log, env.EnvMode, env.MysqlInstallDir)
+
+	env.MongodbInstallDir = filepath.Join(backendRoot, "tools", "mongodb")
+	tools.VerifyMongodbInstallation(log, env.EnvMode, env.MongodbInstallDir)
+
 	// Store the data and temp folders one level below the root
 	// (projectRoot/postgresus-data -> /postgresus-data)
 	env.DataFolder = filepath.Join(filepath.Dir(backendRoot), "postgresus-data", "backups")
 	env.TempFolder = filepath.Join(filepath.Dir(backendRoot), "postgresus-data", "temp")
+	env.WalFolder = filepath.Join(filepath.Dir(backendRoot), "postgresus-data", "wal")
+	env.RecoveriesFolder = filepath.Join(filepath.Dir(backendRoot), "postgresus-data", "recoveries")
+
+	// The master key lives outside of the DB, so a leaked DB dump
+	// is not enough to decrypt backup encryption keys
+	if env.MasterKeyFile == "" {
+		env.MasterKeyFile = filepath.Join(filepath.Dir(backendRoot), "postgresus-data", "master.key")
+	}
+
+	if env.MasterKey != "" {
+		masterKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(env.MasterKey))
+		if err != nil || len(masterKey) != encryption.KeySize {
+			log.Error("MASTER_KEY must be base64 encoded 32 bytes key")
+			os.Exit(1)
+		}
+	}
+
+	if env.NewMasterKey != "" {
+		newMasterKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(env.NewMasterKey))
+		if err != nil || len(newMasterKey) != encryption.KeySize {
+			log.Error("NEW_MASTER_KEY must be base64 encoded 32 bytes key")
+			os.Exit(1)
+		}
+	}
 
 	if env.IsTesting {
 		if env.TestPostgres13Port == "" {
@@ -155,6 +264,15 @@ func loadEnvVariables() {
 			os.Exit(1)
 		}
 
+		if env.TestMysqlPort == "" {
+			log.Error("TEST_MYSQL_PORT is empty")
+			os.Exit(1)
+		}
+		if env.TestMariadbPort == "" {
+			log.Error("TEST_MARIADB_PORT is empty")
+			os.Exit(1)
+		}
+
 		if env.TestMinioPort == "" {
 			log.Error("TEST_MINIO_PORT is empty")
 			os.Exit(1)
@@ -168,6 +286,26 @@ func loadEnvVariables() {
 			log.Error("TEST_NAS_PORT is empty")
 			os.Exit(1)
 		}
+
+		if env.TestAzuriteBlobPort == "" {
+			log.Error("TEST_AZURITE_BLOB_PORT is empty")
+			os.Exit(1)
+		}
+
+		if env.TestFTPPort == "" {
+			log.Error("TEST_FTP_PORT is empty")
+			os.Exit(1)
+		}
+
+		if env.TestSFTPPort == "" {
+			log.Error("TEST_SFTP_PORT is empty")
+			os.Exit(1)
+		}
+
+		if env.TestWebDAVPort == "" {
+			log.Error("TEST_WEBDAV_PORT is empty")
+			os.Exit(1)
+		}
 	}
 
 	log.Info("Environment variables loaded successfully!")
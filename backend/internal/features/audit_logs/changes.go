@@ -0,0 +1,131 @@
+package audit_logs
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const maskedValue = "******"
+
+// sensitiveFieldNames are parts of JSON names of secret fields. The log
+// shows only that a secret was changed, never its value
+var sensitiveFieldNames = []string{
+	"password",
+	"secret",
+	"token",
+	"webhookurl",
+	"privatekey",
+	"accountkey",
+	"connectionstring",
+}
+
+// GetChanges compares JSON representation of the configs and returns
+// changed fields named by their path, e.g. "s3Storage.s3Bucket". Before
+// is nil for created entities and after is nil for deleted ones
+func GetChanges(before any, after any) (map[string]AuditChange, error) {
+	beforeFields, err := flattenFields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterFields, err := flattenFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]AuditChange)
+
+	for name, oldValue := range beforeFields {
+		newValue := afterFields[name]
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes[name] = newAuditChange(name, oldValue, newValue)
+		}
+	}
+
+	for name, newValue := range afterFields {
+		if _, ok := beforeFields[name]; !ok && newValue != nil {
+			changes[name] = newAuditChange(name, nil, newValue)
+		}
+	}
+
+	return changes, nil
+}
+
+func newAuditChange(name string, oldValue any, newValue any) AuditChange {
+	if !isSensitiveField(name) {
+		return AuditChange{Old: oldValue, New: newValue}
+	}
+
+	return AuditChange{Old: maskValue(oldValue), New: maskValue(newValue)}
+}
+
+func maskValue(value any) any {
+	if value == nil || value == "" {
+		return value
+	}
+
+	return maskedValue
+}
+
+func isSensitiveField(path string) bool {
+	name := strings.ToLower(path[strings.LastIndex(path, ".")+1:])
+
+	for _, sensitiveName := range sensitiveFieldNames {
+		if strings.Contains(name, sensitiveName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// flattenFields converts the value to map of leaf JSON fields by path
+func flattenFields(value any) (map[string]any, error) {
+	fields := make(map[string]any)
+	if value == nil || reflect.ValueOf(value).IsZero() {
+		return fields, nil
+	}
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded any
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		return nil, err
+	}
+
+	flattenValue("", decoded, fields)
+
+	return fields, nil
+}
+
+func flattenValue(path string, value any, fields map[string]any) {
+	switch typedValue := value.(type) {
+	case map[string]any:
+		for name, nestedValue := range typedValue {
+			flattenValue(joinPath(path, name), nestedValue, fields)
+		}
+	case []any:
+		if len(typedValue) == 0 {
+			fields[path] = typedValue
+		}
+
+		for i, nestedValue := range typedValue {
+			flattenValue(joinPath(path, strconv.Itoa(i)), nestedValue, fields)
+		}
+	default:
+		fields[path] = typedValue
+	}
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
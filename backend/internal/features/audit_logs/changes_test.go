@@ -0,0 +1,63 @@
+package audit_logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testStorage struct {
+	Name      string         `json:"name"`
+	S3Storage *testS3Storage `json:"s3Storage"`
+}
+
+type testS3Storage struct {
+	Bucket    string `json:"s3Bucket"`
+	SecretKey string `json:"s3SecretKey"`
+}
+
+func Test_GetChanges_WhenNestedFieldChanged_ReturnsFieldByPath(t *testing.T) {
+	before := &testStorage{Name: "backups", S3Storage: &testS3Storage{Bucket: "old"}}
+	after := &testStorage{Name: "backups", S3Storage: &testS3Storage{Bucket: "new"}}
+
+	changes, err := GetChanges(before, after)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]AuditChange{
+		"s3Storage.s3Bucket": {Old: "old", New: "new"},
+	}, changes)
+}
+
+func Test_GetChanges_WhenSecretChanged_MasksValues(t *testing.T) {
+	before := &testStorage{S3Storage: &testS3Storage{SecretKey: "old-secret"}}
+	after := &testStorage{S3Storage: &testS3Storage{SecretKey: "new-secret"}}
+
+	changes, err := GetChanges(before, after)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]AuditChange{
+		"s3Storage.s3SecretKey": {Old: maskedValue, New: maskedValue},
+	}, changes)
+}
+
+func Test_GetChanges_WhenEntityCreated_ReturnsAllFields(t *testing.T) {
+	after := &testStorage{Name: "backups", S3Storage: &testS3Storage{SecretKey: "secret"}}
+
+	changes, err := GetChanges(nil, after)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]AuditChange{
+		"name":                  {Old: nil, New: "backups"},
+		"s3Storage.s3Bucket":    {Old: nil, New: ""},
+		"s3Storage.s3SecretKey": {Old: nil, New: maskedValue},
+	}, changes)
+}
+
+func Test_GetChanges_WhenNothingChanged_ReturnsEmptyChanges(t *testing.T) {
+	storage := &testStorage{Name: "backups", S3Storage: &testS3Storage{Bucket: "bucket"}}
+
+	changes, err := GetChanges(storage, storage)
+
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
@@ -0,0 +1,194 @@
+package audit_logs
+
+import (
+	"fmt"
+	"net/http"
+	"postgresus-backend/internal/features/users"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AuditLogController struct {
+	auditLogService *AuditLogService
+	userService     *users.UserService
+}
+
+func (c *AuditLogController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/audit-logs", c.GetAuditLogs)
+	router.GET("/audit-logs/export", c.ExportAuditLogs)
+}
+
+// GetAuditLogs
+// @Summary Get audit logs
+// @Description Get page of audit log records, newest first. System admins see all records, workspace admins see records of their workspaces
+// @Tags audit-logs
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param workspace_id query string false "Workspace ID"
+// @Param actor_user_id query string false "User who made the action"
+// @Param actor_type query string false "USER, API_TOKEN or SYSTEM"
+// @Param action query string false "Action, e.g. BACKUP_DOWNLOAD"
+// @Param target_type query string false "Target type, e.g. STORAGE"
+// @Param target_id query string false "Target ID"
+// @Param result query string false "SUCCESS or FAILURE"
+// @Param from query string false "Start time (RFC 3339), inclusive"
+// @Param to query string false "End time (RFC 3339), exclusive"
+// @Param limit query int false "Page size, 50 by default, up to 1000"
+// @Param offset query int false "Offset"
+// @Success 200 {object} GetAuditLogsResponse
+// @Failure 400
+// @Failure 401
+// @Router /audit-logs [get]
+func (c *AuditLogController) GetAuditLogs(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := getAuditLogFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, err := getIntQuery(ctx, "limit")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	offset, err := getIntQuery(ctx, "offset")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return
+	}
+
+	response, err := c.auditLogService.GetAuditLogs(user, filter, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// ExportAuditLogs
+// @Summary Export audit logs
+// @Description Download all matching audit log records as JSON lines, newest first
+// @Tags audit-logs
+// @Produce application/x-ndjson
+// @Param Authorization header string true "JWT token"
+// @Param workspace_id query string false "Workspace ID"
+// @Param actor_user_id query string false "User who made the action"
+// @Param actor_type query string false "USER, API_TOKEN or SYSTEM"
+// @Param action query string false "Action, e.g. BACKUP_DOWNLOAD"
+// @Param target_type query string false "Target type, e.g. STORAGE"
+// @Param target_id query string false "Target ID"
+// @Param result query string false "SUCCESS or FAILURE"
+// @Param from query string false "Start time (RFC 3339), inclusive"
+// @Param to query string false "End time (RFC 3339), exclusive"
+// @Success 200 {file} file
+// @Failure 400
+// @Failure 401
+// @Router /audit-logs/export [get]
+func (c *AuditLogController) ExportAuditLogs(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := getAuditLogFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// check access before the response is started
+	if _, err := c.auditLogService.getVisibleWorkspaceIDs(user, filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Type", "application/x-ndjson")
+	ctx.Header("Content-Disposition", "attachment; filename=\"audit_logs.jsonl\"")
+
+	if err := c.auditLogService.ExportAuditLogs(user, filter, ctx.Writer); err != nil {
+		// headers are already sent, so the error cannot be returned as JSON
+		c.auditLogService.logger.Error("Failed to export audit logs", "error", err)
+	}
+}
+
+func getAuditLogFilter(ctx *gin.Context) (*AuditLogFilter, error) {
+	filter := &AuditLogFilter{
+		ActorType:  AuditActorType(ctx.Query("actor_type")),
+		Action:     AuditAction(ctx.Query("action")),
+		TargetType: AuditTargetType(ctx.Query("target_type")),
+		Result:     AuditResult(ctx.Query("result")),
+	}
+
+	var err error
+
+	if filter.WorkspaceID, err = getUUIDQuery(ctx, "workspace_id"); err != nil {
+		return nil, err
+	}
+
+	if filter.ActorUserID, err = getUUIDQuery(ctx, "actor_user_id"); err != nil {
+		return nil, err
+	}
+
+	if filter.TargetID, err = getUUIDQuery(ctx, "target_id"); err != nil {
+		return nil, err
+	}
+
+	if filter.From, err = getTimeQuery(ctx, "from"); err != nil {
+		return nil, err
+	}
+
+	if filter.To, err = getTimeQuery(ctx, "to"); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+func getUUIDQuery(ctx *gin.Context, name string) (*uuid.UUID, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+
+	return &id, nil
+}
+
+func getTimeQuery(ctx *gin.Context, name string) (*time.Time, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsedTime, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s, RFC 3339 time is expected", name)
+	}
+
+	return &parsedTime, nil
+}
+
+func getIntQuery(ctx *gin.Context, name string) (int, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,30 @@
+package audit_logs
+
+import (
+	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/features/workspaces"
+	"postgresus-backend/internal/util/logger"
+)
+
+var auditLogRepository = &AuditLogRepository{}
+var auditLogService = &AuditLogService{
+	auditLogRepository,
+	workspaces.GetWorkspaceService(),
+	logger.GetLogger(),
+}
+var auditLogController = &AuditLogController{
+	auditLogService,
+	users.GetUserService(),
+}
+
+func SetupDependencies() {
+	users.GetUserService().SetUserActionListener(auditLogService)
+}
+
+func GetAuditLogService() *AuditLogService {
+	return auditLogService
+}
+
+func GetAuditLogController() *AuditLogController {
+	return auditLogController
+}
@@ -0,0 +1,42 @@
+package audit_logs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEvent is an action to record. Before and After are configs of
+// the target to log changed fields; secrets in them are masked
+type AuditEvent struct {
+	Action      AuditAction
+	TargetType  AuditTargetType
+	TargetID    *uuid.UUID
+	TargetName  string
+	WorkspaceID *uuid.UUID
+
+	Before any
+	After  any
+
+	Err error
+}
+
+// AuditLogFilter selects records by all specified fields
+type AuditLogFilter struct {
+	WorkspaceID *uuid.UUID
+	ActorUserID *uuid.UUID
+	ActorType   AuditActorType
+	Action      AuditAction
+	TargetType  AuditTargetType
+	TargetID    *uuid.UUID
+	Result      AuditResult
+	From        *time.Time
+	To          *time.Time
+}
+
+type GetAuditLogsResponse struct {
+	AuditLogs []*AuditLog `json:"auditLogs"`
+	Total     int64       `json:"total"`
+	Limit     int         `json:"limit"`
+	Offset    int         `json:"offset"`
+}
@@ -0,0 +1,57 @@
+package audit_logs
+
+type AuditActorType string
+
+const (
+	AuditActorTypeUser     AuditActorType = "USER"
+	AuditActorTypeApiToken AuditActorType = "API_TOKEN"
+	// background workers: scheduled backups, retention, healthchecks
+	AuditActorTypeSystem AuditActorType = "SYSTEM"
+)
+
+type AuditResult string
+
+const (
+	AuditResultSuccess AuditResult = "SUCCESS"
+	AuditResultFailure AuditResult = "FAILURE"
+)
+
+type AuditTargetType string
+
+const (
+	AuditTargetTypeUser         AuditTargetType = "USER"
+	AuditTargetTypeDatabase     AuditTargetType = "DATABASE"
+	AuditTargetTypeBackup       AuditTargetType = "BACKUP"
+	AuditTargetTypeBackupConfig AuditTargetType = "BACKUP_CONFIG"
+	AuditTargetTypeRestore      AuditTargetType = "RESTORE"
+	AuditTargetTypeStorage      AuditTargetType = "STORAGE"
+	AuditTargetTypeNotifier     AuditTargetType = "NOTIFIER"
+)
+
+// AuditAction values of actions with user accounts (sign in, API tokens,
+// etc.) are taken from user_enums.UserAction
+type AuditAction string
+
+const (
+	AuditActionDatabaseCreate AuditAction = "DATABASE_CREATE"
+	AuditActionDatabaseUpdate AuditAction = "DATABASE_UPDATE"
+	AuditActionDatabaseDelete AuditAction = "DATABASE_DELETE"
+	// health status is changed by healthcheck worker
+	AuditActionDatabaseHealthChange AuditAction = "DATABASE_HEALTH_CHANGE"
+
+	AuditActionBackupCreate   AuditAction = "BACKUP_CREATE"
+	AuditActionBackupDelete   AuditAction = "BACKUP_DELETE"
+	AuditActionBackupDownload AuditAction = "BACKUP_DOWNLOAD"
+
+	AuditActionBackupConfigUpdate AuditAction = "BACKUP_CONFIG_UPDATE"
+
+	AuditActionRestoreCreate AuditAction = "RESTORE_CREATE"
+
+	AuditActionStorageCreate AuditAction = "STORAGE_CREATE"
+	AuditActionStorageUpdate AuditAction = "STORAGE_UPDATE"
+	AuditActionStorageDelete AuditAction = "STORAGE_DELETE"
+
+	AuditActionNotifierCreate AuditAction = "NOTIFIER_CREATE"
+	AuditActionNotifierUpdate AuditAction = "NOTIFIER_UPDATE"
+	AuditActionNotifierDelete AuditAction = "NOTIFIER_DELETE"
+)
@@ -0,0 +1,43 @@
+package audit_logs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLog is a record of user or system action. Records are never
+// updated or deleted, it is enforced by trigger in DB
+type AuditLog struct {
+	ID uuid.UUID `json:"id" gorm:"column:id;type:uuid;primaryKey;default:gen_random_uuid()"`
+
+	ActorType AuditActorType `json:"actorType" gorm:"column:actor_type;type:text;not null"`
+	// empty for system actions
+	ActorUserID *uuid.UUID `json:"actorUserId" gorm:"column:actor_user_id;type:uuid"`
+	ActorEmail  *string    `json:"actorEmail"  gorm:"column:actor_email;type:text"`
+	ApiTokenID  *uuid.UUID `json:"apiTokenId"  gorm:"column:api_token_id;type:uuid"`
+
+	Action      AuditAction     `json:"action"      gorm:"column:action;type:text;not null"`
+	TargetType  AuditTargetType `json:"targetType"  gorm:"column:target_type;type:text;not null"`
+	TargetID    *uuid.UUID      `json:"targetId"    gorm:"column:target_id;type:uuid"`
+	TargetName  *string         `json:"targetName"  gorm:"column:target_name;type:text"`
+	WorkspaceID *uuid.UUID      `json:"workspaceId" gorm:"column:workspace_id;type:uuid"`
+
+	// changed fields of config with values before and after the change
+	Changes map[string]AuditChange `json:"changes,omitempty" gorm:"column:changes;type:jsonb;serializer:json"`
+
+	SourceIP     *string     `json:"sourceIp"     gorm:"column:source_ip;type:text"`
+	Result       AuditResult `json:"result"       gorm:"column:result;type:text;not null"`
+	ErrorMessage *string     `json:"errorMessage" gorm:"column:error_message;type:text"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at;not null"`
+}
+
+func (a *AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+type AuditChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
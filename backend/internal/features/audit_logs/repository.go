@@ -0,0 +1,95 @@
+package audit_logs
+
+import (
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLogRepository only appends and reads records, the log is immutable
+type AuditLogRepository struct{}
+
+func (r *AuditLogRepository) Create(auditLog *AuditLog) error {
+	if auditLog.ID == uuid.Nil {
+		auditLog.ID = uuid.New()
+	}
+
+	return storage.GetDb().Create(auditLog).Error
+}
+
+// FindByFilter returns page of matching records, newest first, and count
+// of all matching records. Nil workspace IDs mean records of any workspace
+func (r *AuditLogRepository) FindByFilter(
+	filter *AuditLogFilter,
+	workspaceIDs []uuid.UUID,
+	limit int,
+	offset int,
+) ([]*AuditLog, int64, error) {
+	var total int64
+	if err := r.buildFilterQuery(filter, workspaceIDs).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var auditLogs []*AuditLog
+
+	if err := r.buildFilterQuery(filter, workspaceIDs).
+		Order("created_at DESC").
+		Order("id").
+		Limit(limit).
+		Offset(offset).
+		Find(&auditLogs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return auditLogs, total, nil
+}
+
+func (r *AuditLogRepository) buildFilterQuery(
+	filter *AuditLogFilter,
+	workspaceIDs []uuid.UUID,
+) *gorm.DB {
+	query := storage.GetDb().Model(&AuditLog{})
+
+	if workspaceIDs != nil {
+		query = query.Where("workspace_id IN ?", workspaceIDs)
+	}
+
+	if filter.WorkspaceID != nil {
+		query = query.Where("workspace_id = ?", *filter.WorkspaceID)
+	}
+
+	if filter.ActorUserID != nil {
+		query = query.Where("actor_user_id = ?", *filter.ActorUserID)
+	}
+
+	if filter.ActorType != "" {
+		query = query.Where("actor_type = ?", filter.ActorType)
+	}
+
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+
+	if filter.TargetID != nil {
+		query = query.Where("target_id = ?", *filter.TargetID)
+	}
+
+	if filter.Result != "" {
+		query = query.Where("result = ?", filter.Result)
+	}
+
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	return query
+}
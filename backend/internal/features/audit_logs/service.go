@@ -0,0 +1,266 @@
+package audit_logs
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"postgresus-backend/internal/features/users"
+	user_enums "postgresus-backend/internal/features/users/enums"
+	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/features/workspaces"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAuditLogsLimit = 50
+	maxAuditLogsLimit     = 1000
+	exportBatchSize       = 1000
+)
+
+type AuditLogService struct {
+	auditLogRepository *AuditLogRepository
+	workspaceService   *workspaces.WorkspaceService
+	logger             *slog.Logger
+}
+
+// LogUserAction records action made by the user or by API token of the
+// user. Failure to write the record is logged and does not fail the action
+func (s *AuditLogService) LogUserAction(
+	user *users_models.User,
+	sourceIP string,
+	event *AuditEvent,
+) {
+	auditLog := s.newAuditLog(event)
+	auditLog.ActorType = AuditActorTypeUser
+	auditLog.ActorUserID = &user.ID
+	auditLog.ActorEmail = &user.Email
+
+	if user.ApiToken != nil {
+		auditLog.ActorType = AuditActorTypeApiToken
+		auditLog.ApiTokenID = &user.ApiToken.ID
+	}
+
+	if sourceIP != "" {
+		auditLog.SourceIP = &sourceIP
+	}
+
+	s.save(auditLog)
+}
+
+// LogSystemAction records action of background worker
+func (s *AuditLogService) LogSystemAction(event *AuditEvent) {
+	auditLog := s.newAuditLog(event)
+	auditLog.ActorType = AuditActorTypeSystem
+
+	s.save(auditLog)
+}
+
+// OnUserAction records sign ins and changes of user accounts
+func (s *AuditLogService) OnUserAction(event *users.UserActionEvent) {
+	auditLog := s.newAuditLog(&AuditEvent{
+		Action:     AuditAction(event.Action),
+		TargetType: AuditTargetTypeUser,
+		TargetID:   event.UserID,
+		TargetName: event.Email,
+		Err:        event.Err,
+	})
+	auditLog.ActorType = AuditActorTypeUser
+	auditLog.ActorUserID = event.UserID
+	auditLog.ActorEmail = &event.Email
+
+	if event.TargetID != nil {
+		auditLog.TargetID = event.TargetID
+	}
+
+	if event.SourceIP != "" {
+		auditLog.SourceIP = &event.SourceIP
+	}
+
+	s.save(auditLog)
+}
+
+// GetAuditLogs returns page of records visible to the user: all records
+// for system admins, records of managed workspaces for workspace admins
+func (s *AuditLogService) GetAuditLogs(
+	user *users_models.User,
+	filter *AuditLogFilter,
+	limit int,
+	offset int,
+) (*GetAuditLogsResponse, error) {
+	if limit <= 0 {
+		limit = defaultAuditLogsLimit
+	}
+
+	if limit > maxAuditLogsLimit {
+		limit = maxAuditLogsLimit
+	}
+
+	if offset < 0 {
+		return nil, errors.New("offset must not be negative")
+	}
+
+	workspaceIDs, err := s.getVisibleWorkspaceIDs(user, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	auditLogs, total, err := s.auditLogRepository.FindByFilter(
+		filter,
+		workspaceIDs,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetAuditLogsResponse{
+		AuditLogs: auditLogs,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	}, nil
+}
+
+// ExportAuditLogs writes all records visible to the user as JSON lines,
+// newest first. Records written after the export started are skipped
+func (s *AuditLogService) ExportAuditLogs(
+	user *users_models.User,
+	filter *AuditLogFilter,
+	writer io.Writer,
+) error {
+	workspaceIDs, err := s.getVisibleWorkspaceIDs(user, filter)
+	if err != nil {
+		return err
+	}
+
+	if filter.To == nil {
+		now := time.Now().UTC()
+		filter.To = &now
+	}
+
+	encoder := json.NewEncoder(writer)
+
+	for offset := 0; ; offset += exportBatchSize {
+		auditLogs, _, err := s.auditLogRepository.FindByFilter(
+			filter,
+			workspaceIDs,
+			exportBatchSize,
+			offset,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, auditLog := range auditLogs {
+			if err := encoder.Encode(auditLog); err != nil {
+				return err
+			}
+		}
+
+		if len(auditLogs) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+// getVisibleWorkspaceIDs returns workspaces the user may read the log of.
+// Nil means all records, including ones without workspace
+func (s *AuditLogService) getVisibleWorkspaceIDs(
+	user *users_models.User,
+	filter *AuditLogFilter,
+) ([]uuid.UUID, error) {
+	if user.Role == user_enums.UserRoleAdmin {
+		return nil, nil
+	}
+
+	if filter.WorkspaceID != nil {
+		if err := s.workspaceService.CheckRole(
+			user,
+			*filter.WorkspaceID,
+			workspaces.WorkspaceRoleAdmin,
+		); err != nil {
+			return nil, err
+		}
+
+		return []uuid.UUID{*filter.WorkspaceID}, nil
+	}
+
+	userWorkspaces, err := s.workspaceService.GetWorkspaces(user)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceIDs := make([]uuid.UUID, 0, len(userWorkspaces))
+	for _, workspace := range userWorkspaces {
+		if !workspace.Role.IsAtLeast(workspaces.WorkspaceRoleAdmin) ||
+			workspaces.IsTwoFactorMissing(user, workspace.IsTwoFactorRequired) {
+			continue
+		}
+
+		workspaceIDs = append(workspaceIDs, workspace.ID)
+	}
+
+	if len(workspaceIDs) == 0 {
+		return nil, errors.New("audit log is available only to admins")
+	}
+
+	return workspaceIDs, nil
+}
+
+func (s *AuditLogService) newAuditLog(event *AuditEvent) *AuditLog {
+	auditLog := &AuditLog{
+		ID:          uuid.New(),
+		Action:      event.Action,
+		TargetType:  event.TargetType,
+		TargetID:    event.TargetID,
+		WorkspaceID: event.WorkspaceID,
+		Result:      AuditResultSuccess,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	// IDs are empty if creation failed
+	if auditLog.TargetID != nil && *auditLog.TargetID == uuid.Nil {
+		auditLog.TargetID = nil
+	}
+
+	if auditLog.WorkspaceID != nil && *auditLog.WorkspaceID == uuid.Nil {
+		auditLog.WorkspaceID = nil
+	}
+
+	if event.TargetName != "" {
+		auditLog.TargetName = &event.TargetName
+	}
+
+	if event.Err != nil {
+		errorMessage := event.Err.Error()
+		auditLog.Result = AuditResultFailure
+		auditLog.ErrorMessage = &errorMessage
+	}
+
+	if event.Before != nil || event.After != nil {
+		changes, err := GetChanges(event.Before, event.After)
+		if err != nil {
+			s.logger.Error("Failed to get changes for audit log", "error", err)
+		}
+
+		auditLog.Changes = changes
+	}
+
+	return auditLog
+}
+
+func (s *AuditLogService) save(auditLog *AuditLog) {
+	if err := s.auditLogRepository.Create(auditLog); err != nil {
+		s.logger.Error(
+			"Failed to write audit log",
+			"action",
+			auditLog.Action,
+			"error",
+			err,
+		)
+	}
+}
@@ -3,10 +3,15 @@ package backups
 import (
 	"log/slog"
 	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/audit_logs"
 	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_wal "postgresus-backend/internal/features/backups/wal"
 	"postgresus-backend/internal/features/storages"
-	"postgresus-backend/internal/util/period"
+	"slices"
+	"sync/atomic"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type BackupBackgroundService struct {
@@ -14,9 +19,14 @@ type BackupBackgroundService struct {
 	backupRepository    *BackupRepository
 	backupConfigService *backups_config.BackupConfigService
 	storageService      *storages.StorageService
+	walSegmentService   *backups_wal.WalSegmentService
+	auditLogService     *audit_logs.AuditLogService
 
 	lastBackupTime time.Time
 	logger         *slog.Logger
+
+	isReplicating atomic.Bool
+	isVerifying   atomic.Bool
 }
 
 func (s *BackupBackgroundService) Run() {
@@ -27,6 +37,11 @@ func (s *BackupBackgroundService) Run() {
 		panic(err)
 	}
 
+	if err := s.backupService.ResetBackupCopiesInProgress(); err != nil {
+		s.logger.Error("Failed to reset backup copies in progress", "error", err)
+		panic(err)
+	}
+
 	if config.IsShouldShutdown() {
 		return
 	}
@@ -44,6 +59,29 @@ func (s *BackupBackgroundService) Run() {
 			s.logger.Error("Failed to run pending backups", "error", err)
 		}
 
+		// replication of big backups may take hours, so it
+		// should not block scheduling of the next backups
+		if s.isReplicating.CompareAndSwap(false, true) {
+			go func() {
+				defer s.isReplicating.Store(false)
+
+				if err := s.backupService.ReplicateBackupCopies(); err != nil {
+					s.logger.Error("Failed to replicate backups", "error", err)
+				}
+			}()
+		}
+
+		// verification re-reads whole backups and may take hours too
+		if s.isVerifying.CompareAndSwap(false, true) {
+			go func() {
+				defer s.isVerifying.Store(false)
+
+				if err := s.backupService.VerifyBackups(); err != nil {
+					s.logger.Error("Failed to verify backups", "error", err)
+				}
+			}()
+		}
+
 		s.lastBackupTime = time.Now().UTC()
 		time.Sleep(1 * time.Minute)
 	}
@@ -94,22 +132,10 @@ func (s *BackupBackgroundService) cleanOldBackups() error {
 	}
 
 	for _, backupConfig := range enabledBackupConfigs {
-		backupStorePeriod := backupConfig.StorePeriod
-
-		if backupStorePeriod == period.PeriodForever {
-			continue
-		}
-
-		storeDuration := backupStorePeriod.ToDuration()
-		dateBeforeBackupsShouldBeDeleted := time.Now().UTC().Add(-storeDuration)
-
-		oldBackups, err := s.backupRepository.FindBackupsBeforeDate(
-			backupConfig.DatabaseID,
-			dateBeforeBackupsShouldBeDeleted,
-		)
+		dbBackups, err := s.backupRepository.FindByDatabaseID(backupConfig.DatabaseID)
 		if err != nil {
 			s.logger.Error(
-				"Failed to find old backups for database",
+				"Failed to find backups for database",
 				"databaseId",
 				backupConfig.DatabaseID,
 				"error",
@@ -118,7 +144,15 @@ func (s *BackupBackgroundService) cleanOldBackups() error {
 			continue
 		}
 
+		now := time.Now().UTC()
+		oldBackups := getBackupsToPrune(backupConfig, dbBackups, now)
+		backupsRetainedByCopies := s.cleanOldBackupCopies(backupConfig, dbBackups, now)
+
 		for _, backup := range oldBackups {
+			if backupsRetainedByCopies[backup.ID] {
+				continue
+			}
+
 			storage, err := s.storageService.GetStorageByID(backup.StorageID)
 			if err != nil {
 				s.logger.Error(
@@ -131,12 +165,37 @@ func (s *BackupBackgroundService) cleanOldBackups() error {
 				continue
 			}
 
-			err = storage.DeleteFile(backup.ID)
-			if err != nil {
-				s.logger.Error("Failed to delete backup file", "backupId", backup.ID, "error", err)
+			for _, fileID := range backup.GetFileIDs() {
+				if err := storage.DeleteFile(fileID); err != nil {
+					s.logger.Error(
+						"Failed to delete backup file",
+						"backupId",
+						backup.ID,
+						"fileId",
+						fileID,
+						"error",
+						err,
+					)
+				}
 			}
 
-			if err := s.backupRepository.DeleteByID(backup.ID); err != nil {
+			s.backupService.deleteBackupCopies(backup)
+
+			err = s.backupRepository.DeleteByID(backup.ID)
+
+			auditEvent := &audit_logs.AuditEvent{
+				Action:     audit_logs.AuditActionBackupDelete,
+				TargetType: audit_logs.AuditTargetTypeBackup,
+				TargetID:   &backup.ID,
+				Err:        err,
+			}
+			if backup.Database != nil {
+				auditEvent.TargetName = backup.Database.Name
+				auditEvent.WorkspaceID = &backup.Database.WorkspaceID
+			}
+			s.auditLogService.LogSystemAction(auditEvent)
+
+			if err != nil {
 				s.logger.Error("Failed to delete old backup", "backupId", backup.ID, "error", err)
 				continue
 			}
@@ -149,11 +208,109 @@ func (s *BackupBackgroundService) cleanOldBackups() error {
 				backupConfig.DatabaseID,
 			)
 		}
+
+		if err := s.cleanOldWalSegments(backupConfig); err != nil {
+			s.logger.Error(
+				"Failed to clean old WAL segments",
+				"databaseId",
+				backupConfig.DatabaseID,
+				"error",
+				err,
+			)
+		}
 	}
 
 	return nil
 }
 
+// cleanOldBackupCopies applies retention to each secondary storage
+// separately, considering only backups replicated to it. So a storage
+// replication to which was failing keeps its own newest copies. Returns
+// IDs of backups which still have retained copies: such backups are kept
+// on the primary storage too
+func (s *BackupBackgroundService) cleanOldBackupCopies(
+	backupConfig *backups_config.BackupConfig,
+	dbBackups []*Backup,
+	now time.Time,
+) map[uuid.UUID]bool {
+	backupsRetainedByCopies := make(map[uuid.UUID]bool)
+
+	for _, secondaryStorage := range backupConfig.SecondaryStorages {
+		storageBackups := make([]*Backup, 0)
+		storageCopies := make(map[uuid.UUID]*BackupCopy)
+
+		for _, backup := range dbBackups {
+			for _, backupCopy := range backup.Copies {
+				if backupCopy.StorageID == secondaryStorage.ID &&
+					backupCopy.Status == BackupCopyStatusCompleted {
+					storageBackups = append(storageBackups, backup)
+					storageCopies[backup.ID] = backupCopy
+				}
+			}
+		}
+
+		prunedBackups := make(map[uuid.UUID]bool)
+		for _, backup := range getBackupsToPrune(backupConfig, storageBackups, now) {
+			prunedBackups[backup.ID] = true
+
+			backupCopy := storageCopies[backup.ID]
+			s.backupService.deleteBackupCopy(backup, backupCopy)
+			backup.Copies = slices.DeleteFunc(backup.Copies, func(c *BackupCopy) bool {
+				return c == backupCopy
+			})
+
+			s.logger.Info(
+				"Deleted old backup copy",
+				"backupId",
+				backup.ID,
+				"storageId",
+				secondaryStorage.ID,
+			)
+		}
+
+		for _, backup := range storageBackups {
+			if !prunedBackups[backup.ID] {
+				backupsRetainedByCopies[backup.ID] = true
+			}
+		}
+	}
+
+	return backupsRetainedByCopies
+}
+
+// cleanOldWalSegments removes WAL segments older than the oldest retained
+// physical base backup. WAL needed by any retained base backup is never removed
+func (s *BackupBackgroundService) cleanOldWalSegments(
+	backupConfig *backups_config.BackupConfig,
+) error {
+	oldestBaseBackup, err := s.backupRepository.FindOldestCompletedByType(
+		backupConfig.DatabaseID,
+		backups_config.BackupTypePhysical,
+	)
+	if err != nil {
+		return err
+	}
+
+	if oldestBaseBackup == nil {
+		// keep WAL till the first base backup of physical
+		// backups, otherwise WAL archiving is not used anymore
+		if backupConfig.BackupType == backups_config.BackupTypePhysical {
+			return nil
+		}
+
+		return s.walSegmentService.DeleteDatabaseSegments(backupConfig.DatabaseID)
+	}
+
+	if oldestBaseBackup.WalStartSegment == nil {
+		return nil
+	}
+
+	return s.walSegmentService.DeleteSegmentsBefore(
+		backupConfig.DatabaseID,
+		*oldestBaseBackup.WalStartSegment,
+	)
+}
+
 func (s *BackupBackgroundService) runPendingBackups() error {
 	enabledBackupConfigs, err := s.backupConfigService.GetBackupConfigsWithEnabledBackups()
 	if err != nil {
@@ -195,6 +352,7 @@ func (s *BackupBackgroundService) runPendingBackups() error {
 			)
 
 			go s.backupService.MakeBackup(backupConfig.DatabaseID, remainedBackupTryCount == 1)
+			s.logScheduledBackup(backupConfig.DatabaseID)
 			s.logger.Info(
 				"Successfully triggered scheduled backup",
 				"databaseId",
@@ -206,6 +364,22 @@ func (s *BackupBackgroundService) runPendingBackups() error {
 	return nil
 }
 
+func (s *BackupBackgroundService) logScheduledBackup(databaseID uuid.UUID) {
+	auditEvent := &audit_logs.AuditEvent{
+		Action:     audit_logs.AuditActionBackupCreate,
+		TargetType: audit_logs.AuditTargetTypeDatabase,
+		TargetID:   &databaseID,
+	}
+
+	database, err := s.backupService.databaseService.GetDatabaseByID(databaseID)
+	if err == nil {
+		auditEvent.TargetName = database.Name
+		auditEvent.WorkspaceID = &database.WorkspaceID
+	}
+
+	s.auditLogService.LogSystemAction(auditEvent)
+}
+
 // GetRemainedBackupTryCount returns the number of remaining backup tries for a given backup.
 // If the backup is not failed or the backup config does not allow retries, it returns 0.
 // If the backup is failed and the backup config allows retries, it returns the number of remaining tries.
@@ -0,0 +1,63 @@
+package backups
+
+import (
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+type BackupCopyRepository struct{}
+
+func (r *BackupCopyRepository) Save(backupCopy *BackupCopy) error {
+	db := storage.GetDb()
+
+	isNew := backupCopy.ID == uuid.Nil
+	if isNew {
+		backupCopy.ID = uuid.New()
+		return db.Create(backupCopy).
+			Omit("Storage").
+			Error
+	}
+
+	return db.Save(backupCopy).
+		Omit("Storage").
+		Error
+}
+
+// FindToReplicate returns pending copies and failed copies
+// which still have attempts left, oldest first
+func (r *BackupCopyRepository) FindToReplicate(maxAttemptsCount int) ([]*BackupCopy, error) {
+	var backupCopies []*BackupCopy
+
+	if err := storage.
+		GetDb().
+		Where(
+			"status = ? OR (status = ? AND attempts_count < ?)",
+			BackupCopyStatusPending,
+			BackupCopyStatusFailed,
+			maxAttemptsCount,
+		).
+		Order("created_at ASC").
+		Find(&backupCopies).Error; err != nil {
+		return nil, err
+	}
+
+	return backupCopies, nil
+}
+
+func (r *BackupCopyRepository) FindByStatus(status BackupCopyStatus) ([]*BackupCopy, error) {
+	var backupCopies []*BackupCopy
+
+	if err := storage.
+		GetDb().
+		Where("status = ?", status).
+		Find(&backupCopies).Error; err != nil {
+		return nil, err
+	}
+
+	return backupCopies, nil
+}
+
+func (r *BackupCopyRepository) DeleteByID(id uuid.UUID) error {
+	return storage.GetDb().Delete(&BackupCopy{}, "id = ?", id).Error
+}
@@ -4,22 +4,28 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"postgresus-backend/internal/features/audit_logs"
+	backups_config "postgresus-backend/internal/features/backups/config"
 	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/util/period"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 type BackupController struct {
-	backupService *BackupService
-	userService   *users.UserService
+	backupService   *BackupService
+	userService     *users.UserService
+	auditLogService *audit_logs.AuditLogService
 }
 
 func (c *BackupController) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/backups", c.GetBackups)
 	router.POST("/backups", c.MakeBackup)
 	router.GET("/backups/:id/file", c.GetFile)
+	router.GET("/backups/:id/members/:memberId/file", c.GetMemberFile)
 	router.DELETE("/backups/:id", c.DeleteBackup)
+	router.POST("/backups/retention/preview", c.PreviewRetention)
 }
 
 // GetBackups
@@ -64,6 +70,10 @@ func (c *BackupController) GetBackups(ctx *gin.Context) {
 		return
 	}
 
+	for _, backup := range backups {
+		backup.HideSensitiveData()
+	}
+
 	ctx.JSON(http.StatusOK, backups)
 }
 
@@ -98,7 +108,25 @@ func (c *BackupController) MakeBackup(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.backupService.MakeBackupWithAuth(user, request.DatabaseID); err != nil {
+	auditEvent := &audit_logs.AuditEvent{
+		Action:     audit_logs.AuditActionBackupCreate,
+		TargetType: audit_logs.AuditTargetTypeDatabase,
+		TargetID:   &request.DatabaseID,
+	}
+
+	if database, err := c.backupService.databaseService.GetDatabaseByID(
+		request.DatabaseID,
+	); err == nil {
+		auditEvent.TargetName = database.Name
+		auditEvent.WorkspaceID = &database.WorkspaceID
+	}
+
+	err = c.backupService.MakeBackupWithAuth(user, request.DatabaseID)
+
+	auditEvent.Err = err
+	c.auditLogService.LogUserAction(user, ctx.ClientIP(), auditEvent)
+
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -135,7 +163,15 @@ func (c *BackupController) DeleteBackup(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.backupService.DeleteBackup(user, id); err != nil {
+	// the backup is loaded before deletion to know its workspace
+	auditEvent := c.newBackupAuditEvent(audit_logs.AuditActionBackupDelete, id)
+
+	err = c.backupService.DeleteBackup(user, id)
+
+	auditEvent.Err = err
+	c.auditLogService.LogUserAction(user, ctx.ClientIP(), auditEvent)
+
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -173,6 +209,11 @@ func (c *BackupController) GetFile(ctx *gin.Context) {
 	}
 
 	fileReader, err := c.backupService.GetBackupFile(user, id)
+
+	auditEvent := c.newBackupAuditEvent(audit_logs.AuditActionBackupDownload, id)
+	auditEvent.Err = err
+	c.auditLogService.LogUserAction(user, ctx.ClientIP(), auditEvent)
+
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -199,6 +240,149 @@ func (c *BackupController) GetFile(ctx *gin.Context) {
 	}
 }
 
+// GetMemberFile
+// @Summary Download a database file of cluster backup
+// @Description Download the dump of one database of the specified cluster backup
+// @Tags backups
+// @Param id path string true "Backup ID"
+// @Param memberId path string true "Backup member ID"
+// @Success 200 {file} file
+// @Failure 400
+// @Failure 401
+// @Failure 500
+// @Router /backups/{id}/members/{memberId}/file [get]
+func (c *BackupController) GetMemberFile(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid backup ID"})
+		return
+	}
+
+	memberID, err := uuid.Parse(ctx.Param("memberId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid backup member ID"})
+		return
+	}
+
+	authorizationHeader := ctx.GetHeader("Authorization")
+	if authorizationHeader == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
+		return
+	}
+
+	user, err := c.userService.GetUserFromToken(authorizationHeader)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	fileReader, err := c.backupService.GetBackupMemberFile(user, id, memberID)
+
+	auditEvent := c.newBackupAuditEvent(audit_logs.AuditActionBackupDownload, id)
+	auditEvent.Err = err
+	c.auditLogService.LogUserAction(user, ctx.ClientIP(), auditEvent)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := fileReader.Close(); err != nil {
+			// Log the error but don't interrupt the response
+			fmt.Printf("Error closing file reader: %v\n", err)
+		}
+	}()
+
+	// Set headers for file download
+	ctx.Header("Content-Type", "application/octet-stream")
+	ctx.Header(
+		"Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"backup_%s_%s.dump\"", id.String(), memberID.String()),
+	)
+
+	// Stream the file content
+	_, err = io.Copy(ctx.Writer, fileReader)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to stream file"})
+		return
+	}
+}
+
+// PreviewRetention
+// @Summary Preview retention policy
+// @Description Get backups which would be pruned under the proposed retention policy. Nothing is deleted
+// @Tags backups
+// @Accept json
+// @Produce json
+// @Param request body PreviewRetentionRequest true "Database ID and retention policy"
+// @Success 200 {object} PreviewRetentionResponse
+// @Failure 400
+// @Failure 401
+// @Router /backups/retention/preview [post]
+func (c *BackupController) PreviewRetention(ctx *gin.Context) {
+	var request PreviewRetentionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authorizationHeader := ctx.GetHeader("Authorization")
+	if authorizationHeader == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
+		return
+	}
+
+	user, err := c.userService.GetUserFromToken(authorizationHeader)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	backupsToPrune, err := c.backupService.PreviewRetentionWithAuth(user, &request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, backup := range backupsToPrune {
+		backup.HideSensitiveData()
+	}
+
+	ctx.JSON(http.StatusOK, PreviewRetentionResponse{BackupsToPrune: backupsToPrune})
+}
+
+// newBackupAuditEvent returns audit event for the backup
+// with workspace and name of the backed up database
+func (c *BackupController) newBackupAuditEvent(
+	action audit_logs.AuditAction,
+	backupID uuid.UUID,
+) *audit_logs.AuditEvent {
+	auditEvent := &audit_logs.AuditEvent{
+		Action:     action,
+		TargetType: audit_logs.AuditTargetTypeBackup,
+		TargetID:   &backupID,
+	}
+
+	backup, err := c.backupService.GetBackup(backupID)
+	if err == nil && backup.Database != nil {
+		auditEvent.TargetName = backup.Database.Name
+		auditEvent.WorkspaceID = &backup.Database.WorkspaceID
+	}
+
+	return auditEvent
+}
+
 type MakeBackupRequest struct {
 	DatabaseID uuid.UUID `json:"database_id" binding:"required"`
 }
+
+type PreviewRetentionRequest struct {
+	DatabaseID          uuid.UUID                          `json:"database_id"         binding:"required"`
+	RetentionPolicyType backups_config.RetentionPolicyType `json:"retentionPolicyType"`
+	StorePeriod         period.Period                      `json:"storePeriod"`
+	GfsRetentionPolicy  backups_config.GfsRetentionPolicy  `json:"gfsRetentionPolicy"`
+}
+
+type PreviewRetentionResponse struct {
+	BackupsToPrune []*Backup `json:"backupsToPrune"`
+}
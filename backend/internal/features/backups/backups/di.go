@@ -1,25 +1,36 @@
 package backups
 
 import (
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/backups/backups/usecases"
 	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	backups_wal "postgresus-backend/internal/features/backups/wal"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/notifiers"
 	"postgresus-backend/internal/features/storages"
 	"postgresus-backend/internal/features/users"
 	"postgresus-backend/internal/util/logger"
+	"sync/atomic"
 	"time"
 )
 
 var backupRepository = &BackupRepository{}
+var backupCopyRepository = &BackupCopyRepository{}
+var backupMetrics = NewBackupMetrics()
 var backupService = &BackupService{
 	databases.GetDatabaseService(),
 	storages.GetStorageService(),
 	backupRepository,
+	backupCopyRepository,
 	notifiers.GetNotifierService(),
 	notifiers.GetNotifierService(),
 	backups_config.GetBackupConfigService(),
+	backups_encryption.GetBackupEncryptionKeyService(),
+	backups_wal.GetWalSegmentService(),
 	usecases.GetCreateBackupUsecase(),
+	usecases.GetVerifyBackupUsecase(),
+	backupMetrics,
 	logger.GetLogger(),
 	[]BackupRemoveListener{},
 }
@@ -29,13 +40,18 @@ var backupBackgroundService = &BackupBackgroundService{
 	backupRepository,
 	backups_config.GetBackupConfigService(),
 	storages.GetStorageService(),
+	backups_wal.GetWalSegmentService(),
+	audit_logs.GetAuditLogService(),
 	time.Now().UTC(),
 	logger.GetLogger(),
+	atomic.Bool{},
+	atomic.Bool{},
 }
 
 var backupController = &BackupController{
 	backupService,
 	users.GetUserService(),
+	audit_logs.GetAuditLogService(),
 }
 
 func SetupDependencies() {
@@ -57,3 +73,7 @@ func GetBackupController() *BackupController {
 func GetBackupBackgroundService() *BackupBackgroundService {
 	return backupBackgroundService
 }
+
+func GetBackupMetrics() *BackupMetrics {
+	return backupMetrics
+}
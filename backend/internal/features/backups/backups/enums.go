@@ -7,3 +7,21 @@ const (
 	BackupStatusCompleted  BackupStatus = "COMPLETED"
 	BackupStatusFailed     BackupStatus = "FAILED"
 )
+
+// BackupVerificationStatus is kept apart from BackupStatus: corrupted
+// backup is still completed backup, it just cannot be restored from
+type BackupVerificationStatus string
+
+const (
+	BackupVerificationStatusVerified  BackupVerificationStatus = "VERIFIED"
+	BackupVerificationStatusCorrupted BackupVerificationStatus = "CORRUPTED"
+)
+
+type BackupCopyStatus string
+
+const (
+	BackupCopyStatusPending    BackupCopyStatus = "PENDING"
+	BackupCopyStatusInProgress BackupCopyStatus = "IN_PROGRESS"
+	BackupCopyStatusCompleted  BackupCopyStatus = "COMPLETED"
+	BackupCopyStatusFailed     BackupCopyStatus = "FAILED"
+)
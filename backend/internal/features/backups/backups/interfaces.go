@@ -1,7 +1,11 @@
 package backups
 
 import (
+	"context"
+	"io"
+	usecases_common "postgresus-backend/internal/features/backups/backups/usecases/common"
 	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/notifiers"
 	"postgresus-backend/internal/features/storages"
@@ -19,13 +23,26 @@ type NotificationSender interface {
 
 type CreateBackupUsecase interface {
 	Execute(
+		ctx context.Context,
 		backupID uuid.UUID,
 		backupConfig *backups_config.BackupConfig,
 		database *databases.Database,
 		storage *storages.Storage,
+		encryptionKey *backups_encryption.BackupEncryptionKey,
 		backupProgressListener func(
 			completedMBs float64,
 		),
+	) (*usecases_common.BackupMetadata, error)
+}
+
+type VerifyBackupUsecase interface {
+	Execute(
+		backupID uuid.UUID,
+		backupType backups_config.BackupType,
+		backupConfig *backups_config.BackupConfig,
+		database *databases.Database,
+		backupReader io.Reader,
+		isTestRestore bool,
 	) error
 }
 
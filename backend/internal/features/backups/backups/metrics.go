@@ -0,0 +1,57 @@
+package backups
+
+import (
+	"postgresus-backend/internal/features/databases"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// BackupMetrics records each completed backup in process, so the
+// histograms are not affected by retention deleting stored backups
+type BackupMetrics struct {
+	backupDurationSeconds *prometheus.HistogramVec
+	backupSizeBytes       *prometheus.HistogramVec
+}
+
+func NewBackupMetrics() *BackupMetrics {
+	return &BackupMetrics{
+		backupDurationSeconds: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "postgresus_backup_duration_seconds",
+				Help: "Duration of completed backups",
+				// from 1 second to about 9 hours
+				Buckets: prometheus.ExponentialBuckets(1, 2, 16),
+			},
+			[]string{"database_id", "database_name"},
+		),
+		backupSizeBytes: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "postgresus_backup_size_bytes",
+				Help: "Size of completed backups",
+				// from 1 MB to 256 GB
+				Buckets: prometheus.ExponentialBuckets(1024*1024, 4, 10),
+			},
+			[]string{"database_id", "database_name"},
+		),
+	}
+}
+
+func (m *BackupMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.backupDurationSeconds.Describe(ch)
+	m.backupSizeBytes.Describe(ch)
+}
+
+func (m *BackupMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.backupDurationSeconds.Collect(ch)
+	m.backupSizeBytes.Collect(ch)
+}
+
+func (m *BackupMetrics) ObserveBackup(database *databases.Database, backup *Backup) {
+	databaseLabels := prometheus.Labels{
+		"database_id":   database.ID.String(),
+		"database_name": database.Name,
+	}
+
+	m.backupDurationSeconds.With(databaseLabels).Observe(float64(backup.BackupDurationMs) / 1000)
+	m.backupSizeBytes.With(databaseLabels).Observe(backup.BackupSizeMb * 1024 * 1024)
+}
@@ -1,6 +1,7 @@
 package backups
 
 import (
+	backups_config "postgresus-backend/internal/features/backups/config"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/storages"
 	"time"
@@ -17,6 +18,8 @@ type Backup struct {
 	Storage   *storages.Storage `json:"storage"   gorm:"foreignKey:StorageID"`
 	StorageID uuid.UUID         `json:"storageId" gorm:"column:storage_id;type:uuid;not null"`
 
+	Type backups_config.BackupType `json:"type" gorm:"column:type;type:text;not null"`
+
 	Status      BackupStatus `json:"status"      gorm:"column:status;not null"`
 	FailMessage *string      `json:"failMessage" gorm:"column:fail_message"`
 
@@ -24,5 +27,102 @@ type Backup struct {
 
 	BackupDurationMs int64 `json:"backupDurationMs" gorm:"column:backup_duration_ms;default:0"`
 
+	// Encryption and key are kept on the backup itself, so the backup
+	// stays restorable after the config changes or the key is rotated
+	Encryption      backups_config.BackupEncryption `json:"encryption"      gorm:"column:encryption;type:text;not null"`
+	EncryptionKeyID *uuid.UUID                      `json:"encryptionKeyId" gorm:"column:encryption_key_id;type:uuid"`
+
+	// WAL range of physical base backup. Recovery requires all
+	// archived WAL segments from the start segment onwards
+	WalStartLsn     *string `json:"walStartLsn"     gorm:"column:wal_start_lsn;type:text"`
+	WalStopLsn      *string `json:"walStopLsn"      gorm:"column:wal_stop_lsn;type:text"`
+	WalStartSegment *string `json:"walStartSegment" gorm:"column:wal_start_segment;type:text"`
+	WalStopSegment  *string `json:"walStopSegment"  gorm:"column:wal_stop_segment;type:text"`
+
+	// SHA-256 of the stored file computed while it was written. The
+	// verification checks it and readability of the backup by pg tools
+	Checksum            *string                   `json:"checksum"            gorm:"column:checksum;type:text"`
+	VerificationStatus  *BackupVerificationStatus `json:"verificationStatus"  gorm:"column:verification_status;type:text"`
+	VerificationMessage *string                   `json:"verificationMessage" gorm:"column:verification_message;type:text"`
+	VerifiedAt          *time.Time                `json:"verifiedAt"          gorm:"column:verified_at"`
+	// whether the last verification restored the backup into test database
+	IsTestRestored bool `json:"isTestRestored" gorm:"column:is_test_restored;type:boolean;not null"`
+
+	// databases of cluster backup. The backup file contains globals
+	// (roles, tablespaces), each database is stored in its own file
+	Members []*BackupMember `json:"members" gorm:"foreignKey:BackupID"`
+
+	// copies of the backup files on secondary storages
+	Copies []*BackupCopy `json:"copies" gorm:"foreignKey:BackupID"`
+
 	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
 }
+
+func (b *Backup) IsCluster() bool {
+	return len(b.Members) > 0
+}
+
+// HideSensitiveData clears credentials of the loaded database
+// and storages before the backup is returned by the API
+func (b *Backup) HideSensitiveData() {
+	if b.Database != nil {
+		b.Database.HideSensitiveData()
+	}
+
+	if b.Storage != nil {
+		b.Storage.HideSensitiveData()
+	}
+
+	for _, backupCopy := range b.Copies {
+		if backupCopy.Storage != nil {
+			backupCopy.Storage.HideSensitiveData()
+		}
+	}
+}
+
+// GetFileIDs returns IDs of all files of the backup in storage
+func (b *Backup) GetFileIDs() []uuid.UUID {
+	fileIDs := []uuid.UUID{b.ID}
+
+	for _, member := range b.Members {
+		fileIDs = append(fileIDs, member.ID)
+	}
+
+	return fileIDs
+}
+
+// BackupMember is a database of cluster backup. Its ID
+// is the ID of the database dump file in storage
+type BackupMember struct {
+	ID       uuid.UUID `json:"id"       gorm:"column:id;type:uuid;primaryKey"`
+	BackupID uuid.UUID `json:"backupId" gorm:"column:backup_id;type:uuid;not null"`
+
+	DatabaseName string  `json:"databaseName" gorm:"column:database_name;type:text;not null"`
+	BackupSizeMb float64 `json:"backupSizeMb" gorm:"column:backup_size_mb;default:0"`
+	Checksum     *string `json:"checksum"     gorm:"column:checksum;type:text"`
+}
+
+func (m *BackupMember) TableName() string {
+	return "backup_members"
+}
+
+// BackupCopy is replica of backup files on secondary storage. The files are
+// copied as is, so they are encrypted with the same key as the original
+type BackupCopy struct {
+	ID       uuid.UUID `json:"id"       gorm:"column:id;type:uuid;primaryKey"`
+	BackupID uuid.UUID `json:"backupId" gorm:"column:backup_id;type:uuid;not null"`
+
+	Storage   *storages.Storage `json:"storage"   gorm:"foreignKey:StorageID"`
+	StorageID uuid.UUID         `json:"storageId" gorm:"column:storage_id;type:uuid;not null"`
+
+	Status        BackupCopyStatus `json:"status"        gorm:"column:status;type:text;not null"`
+	FailMessage   *string          `json:"failMessage"   gorm:"column:fail_message;type:text"`
+	AttemptsCount int              `json:"attemptsCount" gorm:"column:attempts_count;type:int;not null"`
+
+	CreatedAt   time.Time  `json:"createdAt"   gorm:"column:created_at"`
+	CompletedAt *time.Time `json:"completedAt" gorm:"column:completed_at"`
+}
+
+func (c *BackupCopy) TableName() string {
+	return "backup_copies"
+}
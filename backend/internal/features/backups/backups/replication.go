@@ -0,0 +1,314 @@
+package backups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/util/tracing"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const maxBackupCopyAttemptsCount = 3
+
+// ReplicateBackupCopies copies completed backups to their secondary storages.
+// Failed copies are retried till maxBackupCopyAttemptsCount attempts
+func (s *BackupService) ReplicateBackupCopies() error {
+	backupCopies, err := s.backupCopyRepository.FindToReplicate(maxBackupCopyAttemptsCount)
+	if err != nil {
+		return err
+	}
+
+	for _, backupCopy := range backupCopies {
+		if err := s.replicateBackupCopy(backupCopy); err != nil {
+			s.logger.Error(
+				"Failed to replicate backup",
+				"backupId",
+				backupCopy.BackupID,
+				"storageId",
+				backupCopy.StorageID,
+				"error",
+				err,
+			)
+		}
+	}
+
+	return nil
+}
+
+// ResetBackupCopiesInProgress returns copies interrupted by
+// application restart back to the replication queue
+func (s *BackupService) ResetBackupCopiesInProgress() error {
+	backupCopies, err := s.backupCopyRepository.FindByStatus(BackupCopyStatusInProgress)
+	if err != nil {
+		return err
+	}
+
+	for _, backupCopy := range backupCopies {
+		backupCopy.Status = BackupCopyStatusPending
+
+		if err := s.backupCopyRepository.Save(backupCopy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *BackupService) OnBeforeBackupsSecondaryStoragesRemove(
+	databaseID uuid.UUID,
+	storageIDs []uuid.UUID,
+) error {
+	dbBackups, err := s.backupRepository.FindByDatabaseID(databaseID)
+	if err != nil {
+		return err
+	}
+
+	for _, backup := range dbBackups {
+		for _, backupCopy := range backup.Copies {
+			if !slices.Contains(storageIDs, backupCopy.StorageID) {
+				continue
+			}
+
+			if backupCopy.Status == BackupCopyStatusInProgress {
+				return errors.New("backup replication is in progress, storage cannot be removed")
+			}
+
+			s.deleteBackupCopy(backup, backupCopy)
+		}
+	}
+
+	return nil
+}
+
+// BackupFiles reads files of one backup. Each file is opened from the
+// primary storage or, if it fails, from one of the copies
+type BackupFiles struct {
+	backupService *BackupService
+	backup        *Backup
+}
+
+func (f *BackupFiles) GetFile(ctx context.Context, fileID uuid.UUID) (io.ReadCloser, error) {
+	file, _, err := f.backupService.openBackupFile(ctx, f.backup, fileID)
+	return file, err
+}
+
+// GetBackupFiles returns reader of backup files falling back to the copies.
+// Files are not opened in advance, so restore reads each file only once
+func (s *BackupService) GetBackupFiles(backup *Backup) *BackupFiles {
+	return &BackupFiles{s, backup}
+}
+
+func (s *BackupService) createBackupCopies(
+	backupConfig *backups_config.BackupConfig,
+	backup *Backup,
+) {
+	for _, secondaryStorage := range backupConfig.SecondaryStorages {
+		backupCopy := &BackupCopy{
+			BackupID:  backup.ID,
+			StorageID: secondaryStorage.ID,
+			Status:    BackupCopyStatusPending,
+			CreatedAt: time.Now().UTC(),
+		}
+
+		if err := s.backupCopyRepository.Save(backupCopy); err != nil {
+			s.logger.Error(
+				"Failed to create backup copy",
+				"backupId",
+				backup.ID,
+				"storageId",
+				secondaryStorage.ID,
+				"error",
+				err,
+			)
+		}
+	}
+}
+
+func (s *BackupService) replicateBackupCopy(backupCopy *BackupCopy) (err error) {
+	ctx, span := tracing.StartSpan(
+		context.Background(),
+		"backup.replicate",
+		attribute.String("backup.id", backupCopy.BackupID.String()),
+		attribute.String("storage.id", backupCopy.StorageID.String()),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	backup, err := s.backupRepository.FindByID(backupCopy.BackupID)
+	if err != nil {
+		return err
+	}
+
+	if backup.Status != BackupStatusCompleted {
+		return nil
+	}
+
+	targetStorage, err := s.storageService.GetStorageByID(backupCopy.StorageID)
+	if err != nil {
+		return err
+	}
+
+	backupCopy.Status = BackupCopyStatusInProgress
+	backupCopy.AttemptsCount++
+	if err := s.backupCopyRepository.Save(backupCopy); err != nil {
+		return err
+	}
+
+	copyErr := s.copyBackupFiles(ctx, backup, targetStorage)
+	if copyErr != nil {
+		failMessage := copyErr.Error()
+		backupCopy.Status = BackupCopyStatusFailed
+		backupCopy.FailMessage = &failMessage
+	} else {
+		completedAt := time.Now().UTC()
+		backupCopy.Status = BackupCopyStatusCompleted
+		backupCopy.FailMessage = nil
+		backupCopy.CompletedAt = &completedAt
+	}
+
+	if err := s.backupCopyRepository.Save(backupCopy); err != nil {
+		return err
+	}
+
+	if copyErr == nil {
+		s.logger.Info(
+			"Backup replicated",
+			"backupId",
+			backup.ID,
+			"storageId",
+			targetStorage.ID,
+		)
+	}
+
+	return copyErr
+}
+
+// copyBackupFiles copies the stored files as is, so encrypted backups
+// stay encrypted on the secondary storage
+func (s *BackupService) copyBackupFiles(
+	ctx context.Context,
+	backup *Backup,
+	targetStorage *storages.Storage,
+) error {
+	for _, fileID := range backup.GetFileIDs() {
+		if err := s.copyBackupFile(ctx, backup, fileID, targetStorage); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *BackupService) copyBackupFile(
+	ctx context.Context,
+	backup *Backup,
+	fileID uuid.UUID,
+	targetStorage *storages.Storage,
+) error {
+	file, _, err := s.openBackupFile(ctx, backup, fileID)
+	if err != nil {
+		return fmt.Errorf("failed to read backup file: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			s.logger.Error("Failed to close backup file", "fileId", fileID, "error", err)
+		}
+	}()
+
+	if err := targetStorage.SaveFile(ctx, s.logger, fileID, file); err != nil {
+		return fmt.Errorf("failed to save backup copy: %w", err)
+	}
+
+	return nil
+}
+
+// openBackupFile opens the backup file (the backup itself or one of its
+// members) on the primary storage and falls back to completed copies in
+// order of creation if the primary fails
+func (s *BackupService) openBackupFile(
+	ctx context.Context,
+	backup *Backup,
+	fileID uuid.UUID,
+) (io.ReadCloser, *storages.Storage, error) {
+	storageIDs := []uuid.UUID{backup.StorageID}
+
+	backupCopies := slices.Clone(backup.Copies)
+	slices.SortFunc(backupCopies, func(a, b *BackupCopy) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	for _, backupCopy := range backupCopies {
+		if backupCopy.Status == BackupCopyStatusCompleted {
+			storageIDs = append(storageIDs, backupCopy.StorageID)
+		}
+	}
+
+	var primaryErr error
+
+	for _, storageID := range storageIDs {
+		storage, err := s.storageService.GetStorageByID(storageID)
+		if err == nil {
+			var file io.ReadCloser
+			if file, err = storage.GetFile(ctx, fileID); err == nil {
+				return file, storage, nil
+			}
+		}
+
+		if primaryErr == nil {
+			primaryErr = err
+		}
+
+		s.logger.Warn(
+			"Failed to read backup file from storage, trying next copy",
+			"fileId",
+			fileID,
+			"storageId",
+			storageID,
+			"error",
+			err,
+		)
+	}
+
+	return nil, nil, primaryErr
+}
+
+func (s *BackupService) deleteBackupCopies(backup *Backup) {
+	for _, backupCopy := range backup.Copies {
+		s.deleteBackupCopy(backup, backupCopy)
+	}
+}
+
+// deleteBackupCopy removes the copy even if its files cannot be deleted,
+// unavailable secondary storage should not block backups removal
+func (s *BackupService) deleteBackupCopy(backup *Backup, backupCopy *BackupCopy) {
+	storage, err := s.storageService.GetStorageByID(backupCopy.StorageID)
+	if err == nil {
+		for _, fileID := range backup.GetFileIDs() {
+			if deleteErr := storage.DeleteFile(fileID); deleteErr != nil && err == nil {
+				err = deleteErr
+			}
+		}
+	}
+
+	if err != nil && backupCopy.Status == BackupCopyStatusCompleted {
+		s.logger.Error(
+			"Failed to delete backup copy file",
+			"backupId",
+			backupCopy.BackupID,
+			"storageId",
+			backupCopy.StorageID,
+			"error",
+			err,
+		)
+	}
+
+	if err := s.backupCopyRepository.DeleteByID(backupCopy.ID); err != nil {
+		s.logger.Error("Failed to delete backup copy", "backupCopyId", backupCopy.ID, "error", err)
+	}
+}
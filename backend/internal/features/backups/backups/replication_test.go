@@ -0,0 +1,196 @@
+package backups
+
+import (
+	"bytes"
+	"context"
+	"io"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	"postgresus-backend/internal/features/notifiers"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/util/logger"
+	"postgresus-backend/internal/util/period"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetBackupFiles_PrimaryStorageUnavailable_FileReadFromCopy(t *testing.T) {
+	user := users.GetTestUser()
+	copyStorage := storages.CreateTestStorage(user.UserID)
+	defer storages.RemoveTestStorage(copyStorage.ID)
+
+	backupID := uuid.New()
+	content := []byte("backup content")
+
+	err := copyStorage.SaveFile(
+		context.Background(),
+		logger.GetLogger(),
+		backupID,
+		bytes.NewReader(content),
+	)
+	assert.NoError(t, err)
+	defer func() {
+		_ = copyStorage.DeleteFile(backupID)
+	}()
+
+	t.Run("Completed copy is used", func(t *testing.T) {
+		backup := &Backup{
+			ID: backupID,
+			// primary storage was removed
+			StorageID: uuid.New(),
+			Copies: []*BackupCopy{
+				{
+					StorageID: copyStorage.ID,
+					Status:    BackupCopyStatusCompleted,
+					CreatedAt: time.Now().UTC(),
+				},
+			},
+		}
+
+		file, err := backupService.GetBackupFiles(backup).GetFile(context.Background(), backupID)
+		assert.NoError(t, err)
+		defer func() {
+			_ = file.Close()
+		}()
+
+		readContent, err := io.ReadAll(file)
+		assert.NoError(t, err)
+		assert.Equal(t, content, readContent)
+	})
+
+	t.Run("Not completed copy is skipped", func(t *testing.T) {
+		backup := &Backup{
+			ID:        backupID,
+			StorageID: uuid.New(),
+			Copies: []*BackupCopy{
+				{
+					StorageID: copyStorage.ID,
+					Status:    BackupCopyStatusFailed,
+					CreatedAt: time.Now().UTC(),
+				},
+			},
+		}
+
+		_, err := backupService.GetBackupFiles(backup).GetFile(context.Background(), backupID)
+		assert.Error(t, err)
+	})
+}
+
+func Test_CleanOldBackupCopies_RetentionAppliedPerSecondaryStorage(t *testing.T) {
+	user := users.GetTestUser()
+	firstStorage := storages.CreateTestStorage(user.UserID)
+	secondStorage := storages.CreateTestStorage(user.UserID)
+	defer storages.RemoveTestStorage(firstStorage.ID)
+	defer storages.RemoveTestStorage(secondStorage.ID)
+
+	now := time.Now().UTC()
+
+	// replication to the second storage fails since the old backup
+	oldBackup := &Backup{
+		ID:        uuid.New(),
+		Status:    BackupStatusCompleted,
+		CreatedAt: now.AddDate(0, 0, -30),
+		Copies: []*BackupCopy{
+			{ID: uuid.New(), StorageID: firstStorage.ID, Status: BackupCopyStatusCompleted},
+			{ID: uuid.New(), StorageID: secondStorage.ID, Status: BackupCopyStatusCompleted},
+		},
+	}
+	newBackup := &Backup{
+		ID:        uuid.New(),
+		Status:    BackupStatusCompleted,
+		CreatedAt: now.AddDate(0, 0, -1),
+		Copies: []*BackupCopy{
+			{ID: uuid.New(), StorageID: firstStorage.ID, Status: BackupCopyStatusCompleted},
+			{ID: uuid.New(), StorageID: secondStorage.ID, Status: BackupCopyStatusFailed},
+		},
+	}
+
+	backupConfig := &backups_config.BackupConfig{
+		RetentionPolicyType: backups_config.RetentionPolicyTypeTimePeriod,
+		StorePeriod:         period.PeriodWeek,
+		SecondaryStorages:   []storages.Storage{*firstStorage, *secondStorage},
+	}
+
+	backupsRetainedByCopies := backupBackgroundService.cleanOldBackupCopies(
+		backupConfig,
+		[]*Backup{newBackup, oldBackup},
+		now,
+	)
+
+	// the old copy is the newest one on the second storage, so it is kept
+	assert.Equal(
+		t,
+		map[uuid.UUID]bool{oldBackup.ID: true, newBackup.ID: true},
+		backupsRetainedByCopies,
+	)
+	assert.Len(t, oldBackup.Copies, 1)
+	assert.Equal(t, secondStorage.ID, oldBackup.Copies[0].StorageID)
+	assert.Len(t, newBackup.Copies, 2)
+}
+
+func Test_ReplicateBackupCopy_FailedCopyRetriedTillAttemptsLimit(t *testing.T) {
+	user := users.GetTestUser()
+	storage := storages.CreateTestStorage(user.UserID)
+	secondaryStorage := storages.CreateTestStorage(user.UserID)
+	notifier := notifiers.CreateTestNotifier(user.UserID)
+	database := databases.CreateTestDatabase(user.UserID, storage, notifier)
+
+	defer storages.RemoveTestStorage(storage.ID)
+	defer storages.RemoveTestStorage(secondaryStorage.ID)
+	defer notifiers.RemoveTestNotifier(notifier)
+	defer databases.RemoveTestDatabase(database)
+
+	// backup file is missing, so every replication attempt fails
+	backup := &Backup{
+		DatabaseID: database.ID,
+		StorageID:  storage.ID,
+		Status:     BackupStatusCompleted,
+		CreatedAt:  time.Now().UTC(),
+	}
+	err := backupRepository.Save(backup)
+	assert.NoError(t, err)
+	defer func() {
+		_ = backupRepository.DeleteByID(backup.ID)
+	}()
+
+	backupCopy := &BackupCopy{
+		BackupID:  backup.ID,
+		StorageID: secondaryStorage.ID,
+		Status:    BackupCopyStatusPending,
+		CreatedAt: time.Now().UTC(),
+	}
+	err = backupCopyRepository.Save(backupCopy)
+	assert.NoError(t, err)
+	defer func() {
+		_ = backupCopyRepository.DeleteByID(backupCopy.ID)
+	}()
+
+	for attempt := 1; attempt <= maxBackupCopyAttemptsCount; attempt++ {
+		assert.True(t, isBackupCopyToReplicate(t, backupCopy.ID))
+
+		err = backupService.replicateBackupCopy(backupCopy)
+		assert.Error(t, err)
+		assert.Equal(t, BackupCopyStatusFailed, backupCopy.Status)
+		assert.Equal(t, attempt, backupCopy.AttemptsCount)
+		assert.NotNil(t, backupCopy.FailMessage)
+	}
+
+	assert.False(t, isBackupCopyToReplicate(t, backupCopy.ID))
+}
+
+func isBackupCopyToReplicate(t *testing.T, backupCopyID uuid.UUID) bool {
+	backupCopies, err := backupCopyRepository.FindToReplicate(maxBackupCopyAttemptsCount)
+	assert.NoError(t, err)
+
+	for _, backupCopy := range backupCopies {
+		if backupCopy.ID == backupCopyID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,10 +2,9 @@ package backups
 
 import (
 	"errors"
+	backups_config "postgresus-backend/internal/features/backups/config"
 	"postgresus-backend/internal/storage"
 
-	"time"
-
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -19,15 +18,23 @@ func (r *BackupRepository) Save(backup *Backup) error {
 	if isNew {
 		backup.ID = uuid.New()
 		return db.Create(backup).
-			Omit("Database", "Storage").
+			Omit("Database", "Storage", "Members", "Copies").
 			Error
 	}
 
 	return db.Save(backup).
-		Omit("Database", "Storage").
+		Omit("Database", "Storage", "Members", "Copies").
 		Error
 }
 
+func (r *BackupRepository) CreateMembers(members []*BackupMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+
+	return storage.GetDb().Create(&members).Error
+}
+
 func (r *BackupRepository) FindByDatabaseID(databaseID uuid.UUID) ([]*Backup, error) {
 	var backups []*Backup
 
@@ -35,6 +42,8 @@ func (r *BackupRepository) FindByDatabaseID(databaseID uuid.UUID) ([]*Backup, er
 		GetDb().
 		Preload("Database").
 		Preload("Storage").
+		Preload("Members").
+		Preload("Copies").
 		Where("database_id = ?", databaseID).
 		Order("created_at DESC").
 		Find(&backups).Error; err != nil {
@@ -58,6 +67,8 @@ func (r *BackupRepository) FindByDatabaseIDWithLimit(
 		GetDb().
 		Preload("Database").
 		Preload("Storage").
+		Preload("Members").
+		Preload("Copies").
 		Where("database_id = ?", databaseID).
 		Order("created_at DESC").
 		Limit(limit).
@@ -75,6 +86,8 @@ func (r *BackupRepository) FindByStorageID(storageID uuid.UUID) ([]*Backup, erro
 		GetDb().
 		Preload("Database").
 		Preload("Storage").
+		Preload("Members").
+		Preload("Copies").
 		Where("storage_id = ?", storageID).
 		Order("created_at DESC").
 		Find(&backups).Error; err != nil {
@@ -91,6 +104,8 @@ func (r *BackupRepository) FindLastByDatabaseID(databaseID uuid.UUID) (*Backup,
 		GetDb().
 		Preload("Database").
 		Preload("Storage").
+		Preload("Members").
+		Preload("Copies").
 		Where("database_id = ?", databaseID).
 		Order("created_at DESC").
 		First(&backup).Error; err != nil {
@@ -104,6 +119,82 @@ func (r *BackupRepository) FindLastByDatabaseID(databaseID uuid.UUID) (*Backup,
 	return &backup, nil
 }
 
+func (r *BackupRepository) FindOldestCompletedByType(
+	databaseID uuid.UUID,
+	backupType backups_config.BackupType,
+) (*Backup, error) {
+	var backup Backup
+
+	if err := storage.
+		GetDb().
+		Where(
+			"database_id = ? AND type = ? AND status = ?",
+			databaseID,
+			backupType,
+			BackupStatusCompleted,
+		).
+		Order("created_at ASC").
+		First(&backup).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &backup, nil
+}
+
+func (r *BackupRepository) FindLastCompletedByType(
+	databaseID uuid.UUID,
+	backupType backups_config.BackupType,
+) (*Backup, error) {
+	var backup Backup
+
+	if err := storage.
+		GetDb().
+		Preload("Database").
+		Preload("Storage").
+		Preload("Members").
+		Preload("Copies").
+		Where(
+			"database_id = ? AND type = ? AND status = ?",
+			databaseID,
+			backupType,
+			BackupStatusCompleted,
+		).
+		Order("created_at DESC").
+		First(&backup).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &backup, nil
+}
+
+func (r *BackupRepository) FindLastTestRestoredByDatabaseID(
+	databaseID uuid.UUID,
+) (*Backup, error) {
+	var backup Backup
+
+	if err := storage.
+		GetDb().
+		Where("database_id = ? AND is_test_restored = ?", databaseID, true).
+		Order("verified_at DESC").
+		First(&backup).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &backup, nil
+}
+
 func (r *BackupRepository) FindByID(id uuid.UUID) (*Backup, error) {
 	var backup Backup
 
@@ -111,6 +202,8 @@ func (r *BackupRepository) FindByID(id uuid.UUID) (*Backup, error) {
 		GetDb().
 		Preload("Database").
 		Preload("Storage").
+		Preload("Members").
+		Preload("Copies").
 		Where("id = ?", id).
 		First(&backup).Error; err != nil {
 		return nil, err
@@ -126,6 +219,8 @@ func (r *BackupRepository) FindByStatus(status BackupStatus) ([]*Backup, error)
 		GetDb().
 		Preload("Database").
 		Preload("Storage").
+		Preload("Members").
+		Preload("Copies").
 		Where("status = ?", status).
 		Order("created_at DESC").
 		Find(&backups).Error; err != nil {
@@ -145,6 +240,8 @@ func (r *BackupRepository) FindByStorageIdAndStatus(
 		GetDb().
 		Preload("Database").
 		Preload("Storage").
+		Preload("Members").
+		Preload("Copies").
 		Where("storage_id = ? AND status = ?", storageID, status).
 		Order("created_at DESC").
 		Find(&backups).Error; err != nil {
@@ -164,6 +261,8 @@ func (r *BackupRepository) FindByDatabaseIdAndStatus(
 		GetDb().
 		Preload("Database").
 		Preload("Storage").
+		Preload("Members").
+		Preload("Copies").
 		Where("database_id = ? AND status = ?", databaseID, status).
 		Order("created_at DESC").
 		Find(&backups).Error; err != nil {
@@ -173,25 +272,44 @@ func (r *BackupRepository) FindByDatabaseIdAndStatus(
 	return backups, nil
 }
 
-func (r *BackupRepository) DeleteByID(id uuid.UUID) error {
-	return storage.GetDb().Delete(&Backup{}, "id = ?", id).Error
-}
-
-func (r *BackupRepository) FindBackupsBeforeDate(
-	databaseID uuid.UUID,
-	date time.Time,
-) ([]*Backup, error) {
+// FindNotVerified returns completed backups with checksum
+// which were not verified yet, oldest first
+func (r *BackupRepository) FindNotVerified() ([]*Backup, error) {
 	var backups []*Backup
 
 	if err := storage.
 		GetDb().
 		Preload("Database").
 		Preload("Storage").
-		Where("database_id = ? AND created_at < ?", databaseID, date).
-		Order("created_at DESC").
+		Preload("Members").
+		Preload("Copies").
+		Where(
+			"status = ? AND verification_status IS NULL AND checksum IS NOT NULL",
+			BackupStatusCompleted,
+		).
+		Order("created_at ASC").
 		Find(&backups).Error; err != nil {
 		return nil, err
 	}
 
 	return backups, nil
 }
+
+// UpdateVerification updates only verification fields, so the backup
+// removed by the cleaner while being verified is not saved back
+func (r *BackupRepository) UpdateVerification(backup *Backup) error {
+	return storage.
+		GetDb().
+		Model(&Backup{}).
+		Where("id = ?", backup.ID).
+		Updates(map[string]any{
+			"verification_status":  backup.VerificationStatus,
+			"verification_message": backup.VerificationMessage,
+			"verified_at":          backup.VerifiedAt,
+			"is_test_restored":     backup.IsTestRestored,
+		}).Error
+}
+
+func (r *BackupRepository) DeleteByID(id uuid.UUID) error {
+	return storage.GetDb().Delete(&Backup{}, "id = ?", id).Error
+}
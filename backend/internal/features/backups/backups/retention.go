@@ -0,0 +1,176 @@
+package backups
+
+import (
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/util/period"
+	"slices"
+	"strings"
+	"time"
+)
+
+// getBackupsToPrune returns backups not retained by the retention policy of
+// the config, newest first. The result depends only on passed backups and
+// time, so the dry run shows exactly what the cleaner will remove.
+//
+// Backups in progress and the newest completed backup of each backup type
+// are never pruned, so switching between logical and physical backups keeps
+// the base backup WAL segments depend on. Failed backups have nothing to restore from, so under GFS retention they
+// are kept only while newer than the newest completed backup
+func getBackupsToPrune(
+	backupConfig *backups_config.BackupConfig,
+	backups []*Backup,
+	now time.Time,
+) []*Backup {
+	sortedBackups := slices.Clone(backups)
+	slices.SortStableFunc(sortedBackups, func(a, b *Backup) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+
+		return strings.Compare(b.ID.String(), a.ID.String())
+	})
+
+	newestCompletedBackups := make(map[backups_config.BackupType]*Backup)
+	for _, backup := range sortedBackups {
+		if backup.Status != BackupStatusCompleted {
+			continue
+		}
+
+		if _, ok := newestCompletedBackups[backup.Type]; !ok {
+			newestCompletedBackups[backup.Type] = backup
+		}
+	}
+
+	var keptBackups map[*Backup]bool
+	if backupConfig.RetentionPolicyType == backups_config.RetentionPolicyTypeGFS {
+		keptBackups = getGfsKeptBackups(&backupConfig.GfsRetentionPolicy, sortedBackups, now)
+	} else {
+		keptBackups = getTimePeriodKeptBackups(backupConfig.StorePeriod, sortedBackups, now)
+	}
+
+	backupsToPrune := make([]*Backup, 0)
+
+	for _, backup := range sortedBackups {
+		if backup.Status == BackupStatusInProgress ||
+			backup == newestCompletedBackups[backup.Type] ||
+			keptBackups[backup] {
+			continue
+		}
+
+		backupsToPrune = append(backupsToPrune, backup)
+	}
+
+	return backupsToPrune
+}
+
+func getTimePeriodKeptBackups(
+	storePeriod period.Period,
+	backups []*Backup,
+	now time.Time,
+) map[*Backup]bool {
+	keptBackups := make(map[*Backup]bool)
+
+	for _, backup := range backups {
+		if storePeriod == period.PeriodForever ||
+			!backup.CreatedAt.Before(now.Add(-storePeriod.ToDuration())) {
+			keptBackups[backup] = true
+		}
+	}
+
+	return keptBackups
+}
+
+// getGfsKeptBackups expects backups sorted from newest to oldest, so the
+// first backup of each calendar period is the newest one in it
+func getGfsKeptBackups(
+	policy *backups_config.GfsRetentionPolicy,
+	backups []*Backup,
+	now time.Time,
+) map[*Backup]bool {
+	now = now.UTC()
+	keptBackups := make(map[*Backup]bool)
+
+	completedBackups := make([]*Backup, 0, len(backups))
+	var newestCompletedTime *time.Time
+
+	for _, backup := range backups {
+		if backup.Status != BackupStatusCompleted {
+			continue
+		}
+
+		if newestCompletedTime == nil {
+			newestCompletedTime = &backup.CreatedAt
+		}
+
+		completedBackups = append(completedBackups, backup)
+	}
+
+	for _, backup := range backups {
+		if backup.Status == BackupStatusFailed &&
+			(newestCompletedTime == nil || backup.CreatedAt.After(*newestCompletedTime)) {
+			keptBackups[backup] = true
+		}
+	}
+
+	for i, backup := range completedBackups {
+		if i < policy.KeepLast {
+			keptBackups[backup] = true
+		}
+	}
+
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	keepGfsPeriod(keptBackups, completedBackups, policy.KeepDaily, func(t time.Time) int {
+		dayStart := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return int(todayStart.Sub(dayStart).Hours() / 24)
+	})
+
+	thisWeekStart := getStartOfIsoWeek(now)
+	keepGfsPeriod(keptBackups, completedBackups, policy.KeepWeekly, func(t time.Time) int {
+		return int(thisWeekStart.Sub(getStartOfIsoWeek(t)).Hours() / (24 * 7))
+	})
+
+	keepGfsPeriod(keptBackups, completedBackups, policy.KeepMonthly, func(t time.Time) int {
+		return (now.Year()-t.Year())*12 + int(now.Month()) - int(t.Month())
+	})
+
+	keepGfsPeriod(keptBackups, completedBackups, policy.KeepYearly, func(t time.Time) int {
+		return now.Year() - t.Year()
+	})
+
+	return keptBackups
+}
+
+// keepGfsPeriod keeps the newest backup of each of the last periodsCount
+// periods. getPeriodsAgo returns how many periods ago the time is, 0 is
+// the current period
+func keepGfsPeriod(
+	keptBackups map[*Backup]bool,
+	completedBackups []*Backup,
+	periodsCount int,
+	getPeriodsAgo func(t time.Time) int,
+) {
+	if periodsCount <= 0 {
+		return
+	}
+
+	coveredPeriods := make(map[int]bool)
+
+	for _, backup := range completedBackups {
+		periodsAgo := getPeriodsAgo(backup.CreatedAt.UTC())
+
+		// backups from the future (clock skew) belong to the current period
+		periodsAgo = max(periodsAgo, 0)
+
+		if periodsAgo >= periodsCount || coveredPeriods[periodsAgo] {
+			continue
+		}
+
+		coveredPeriods[periodsAgo] = true
+		keptBackups[backup] = true
+	}
+}
+
+func getStartOfIsoWeek(t time.Time) time.Time {
+	daysFromMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysFromMonday, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,206 @@
+package backups
+
+import (
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/util/period"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetBackupsToPruneByGfs_KeepsOneBackupPerPeriod(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC) // Saturday
+
+	// two backups a day for the last 400 days
+	backups := make([]*Backup, 0)
+	for day := 0; day < 400; day++ {
+		for _, hour := range []int{2, 10} {
+			backups = append(backups, &Backup{
+				ID:        uuid.New(),
+				Status:    BackupStatusCompleted,
+				CreatedAt: time.Date(2024, 6, 15-day, hour, 0, 0, 0, time.UTC),
+			})
+		}
+	}
+
+	backupConfig := &backups_config.BackupConfig{
+		RetentionPolicyType: backups_config.RetentionPolicyTypeGFS,
+		GfsRetentionPolicy: backups_config.GfsRetentionPolicy{
+			KeepLast:    3,
+			KeepDaily:   7,
+			KeepWeekly:  4,
+			KeepMonthly: 6,
+			KeepYearly:  2,
+		},
+	}
+
+	backupsToPrune := getBackupsToPrune(backupConfig, backups, now)
+	keptBackups := getKeptBackups(backups, backupsToPrune)
+
+	expectedKeptTimes := []time.Time{
+		// last 3
+		time.Date(2024, 6, 15, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 15, 2, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 14, 10, 0, 0, 0, time.UTC),
+		// daily (newest of each of 7 days, today is covered above)
+		time.Date(2024, 6, 13, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 12, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 10, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 9, 10, 0, 0, 0, time.UTC),
+		// weekly (Sundays end ISO weeks)
+		time.Date(2024, 6, 2, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 26, 10, 0, 0, 0, time.UTC),
+		// monthly
+		time.Date(2024, 5, 31, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 30, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC),
+		// yearly
+		time.Date(2023, 12, 31, 10, 0, 0, 0, time.UTC),
+	}
+
+	keptTimes := make([]time.Time, 0, len(keptBackups))
+	for _, backup := range keptBackups {
+		keptTimes = append(keptTimes, backup.CreatedAt)
+	}
+
+	assert.ElementsMatch(t, expectedKeptTimes, keptTimes)
+	assert.Len(t, backupsToPrune, len(backups)-len(expectedKeptTimes))
+}
+
+func Test_GetBackupsToPrune_NewestCompletedBackupIsNeverPruned(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	newestCompletedBackup := &Backup{
+		ID:        uuid.New(),
+		Status:    BackupStatusCompleted,
+		CreatedAt: now.AddDate(0, 0, -30),
+	}
+	backups := []*Backup{
+		{ID: uuid.New(), Status: BackupStatusFailed, CreatedAt: now.AddDate(0, 0, -20)},
+		newestCompletedBackup,
+		{ID: uuid.New(), Status: BackupStatusCompleted, CreatedAt: now.AddDate(0, 0, -31)},
+	}
+
+	t.Run("Time period retention", func(t *testing.T) {
+		backupConfig := &backups_config.BackupConfig{
+			RetentionPolicyType: backups_config.RetentionPolicyTypeTimePeriod,
+			StorePeriod:         period.PeriodWeek,
+		}
+
+		backupsToPrune := getBackupsToPrune(backupConfig, backups, now)
+		assert.Equal(t, []*Backup{backups[0], backups[2]}, backupsToPrune)
+	})
+
+	t.Run("GFS retention", func(t *testing.T) {
+		backupConfig := &backups_config.BackupConfig{
+			RetentionPolicyType: backups_config.RetentionPolicyTypeGFS,
+			GfsRetentionPolicy:  backups_config.GfsRetentionPolicy{KeepDaily: 7},
+		}
+
+		// failed backup is newer than the newest completed one, so it is kept
+		backupsToPrune := getBackupsToPrune(backupConfig, backups, now)
+		assert.Equal(t, []*Backup{backups[2]}, backupsToPrune)
+	})
+}
+
+func Test_GetBackupsToPrune_NewestCompletedBackupOfEachTypeIsNeverPruned(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	// database switched from physical to logical backups a month ago
+	backups := []*Backup{
+		{
+			ID:        uuid.New(),
+			Type:      backups_config.BackupTypeLogical,
+			Status:    BackupStatusCompleted,
+			CreatedAt: now.Add(-time.Hour),
+		},
+		{
+			ID:        uuid.New(),
+			Type:      backups_config.BackupTypeLogical,
+			Status:    BackupStatusCompleted,
+			CreatedAt: now.AddDate(0, 0, -1),
+		},
+		{
+			ID:        uuid.New(),
+			Type:      backups_config.BackupTypePhysical,
+			Status:    BackupStatusCompleted,
+			CreatedAt: now.AddDate(0, 0, -30),
+		},
+		{
+			ID:        uuid.New(),
+			Type:      backups_config.BackupTypePhysical,
+			Status:    BackupStatusCompleted,
+			CreatedAt: now.AddDate(0, 0, -31),
+		},
+	}
+
+	backupConfig := &backups_config.BackupConfig{
+		RetentionPolicyType: backups_config.RetentionPolicyTypeGFS,
+		GfsRetentionPolicy:  backups_config.GfsRetentionPolicy{KeepLast: 1},
+	}
+
+	backupsToPrune := getBackupsToPrune(backupConfig, backups, now)
+	assert.Equal(t, []*Backup{backups[1], backups[3]}, backupsToPrune)
+}
+
+func Test_GetBackupsToPrune_InProgressBackupIsNeverPruned(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	backups := []*Backup{
+		{ID: uuid.New(), Status: BackupStatusCompleted, CreatedAt: now.Add(-time.Hour)},
+		{ID: uuid.New(), Status: BackupStatusInProgress, CreatedAt: now.AddDate(0, 0, -10)},
+	}
+
+	backupConfig := &backups_config.BackupConfig{
+		RetentionPolicyType: backups_config.RetentionPolicyTypeGFS,
+		GfsRetentionPolicy:  backups_config.GfsRetentionPolicy{KeepLast: 1},
+	}
+
+	assert.Empty(t, getBackupsToPrune(backupConfig, backups, now))
+}
+
+func Test_GetBackupsToPrune_ResultIsDeterministic(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	createdAt := now.AddDate(0, 0, -3)
+
+	// backups created at the same time are ordered by ID
+	backups := []*Backup{
+		{ID: uuid.New(), Status: BackupStatusCompleted, CreatedAt: createdAt},
+		{ID: uuid.New(), Status: BackupStatusCompleted, CreatedAt: createdAt},
+		{ID: uuid.New(), Status: BackupStatusCompleted, CreatedAt: createdAt},
+	}
+	reversedBackups := []*Backup{backups[2], backups[1], backups[0]}
+
+	backupConfig := &backups_config.BackupConfig{
+		RetentionPolicyType: backups_config.RetentionPolicyTypeGFS,
+		GfsRetentionPolicy:  backups_config.GfsRetentionPolicy{KeepDaily: 7},
+	}
+
+	assert.Equal(
+		t,
+		getBackupsToPrune(backupConfig, backups, now),
+		getBackupsToPrune(backupConfig, reversedBackups, now),
+	)
+	assert.Len(t, getBackupsToPrune(backupConfig, backups, now), 2)
+}
+
+func getKeptBackups(backups []*Backup, backupsToPrune []*Backup) []*Backup {
+	prunedBackups := make(map[*Backup]bool)
+	for _, backup := range backupsToPrune {
+		prunedBackups[backup] = true
+	}
+
+	keptBackups := make([]*Backup, 0)
+	for _, backup := range backups {
+		if !prunedBackups[backup] {
+			keptBackups = append(keptBackups, backup)
+		}
+	}
+
+	return keptBackups
+}
@@ -1,30 +1,44 @@
 package backups
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	backups_wal "postgresus-backend/internal/features/backups/wal"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/notifiers"
 	"postgresus-backend/internal/features/storages"
 	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/features/workspaces"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/tracing"
 	"slices"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type BackupService struct {
-	databaseService     *databases.DatabaseService
-	storageService      *storages.StorageService
-	backupRepository    *BackupRepository
-	notifierService     *notifiers.NotifierService
-	notificationSender  NotificationSender
-	backupConfigService *backups_config.BackupConfigService
+	databaseService      *databases.DatabaseService
+	storageService       *storages.StorageService
+	backupRepository     *BackupRepository
+	backupCopyRepository *BackupCopyRepository
+	notifierService      *notifiers.NotifierService
+	notificationSender   NotificationSender
+	backupConfigService  *backups_config.BackupConfigService
+
+	backupEncryptionKeyService *backups_encryption.BackupEncryptionKeyService
+	walSegmentService          *backups_wal.WalSegmentService
 
 	createBackupUseCase CreateBackupUsecase
+	verifyBackupUseCase VerifyBackupUsecase
+
+	backupMetrics *BackupMetrics
 
 	logger *slog.Logger
 
@@ -62,8 +76,12 @@ func (s *BackupService) MakeBackupWithAuth(
 		return err
 	}
 
-	if database.UserID != user.ID {
-		return errors.New("user does not have access to this database")
+	if err := s.databaseService.CheckAccess(
+		user,
+		database,
+		workspaces.WorkspaceRoleOperator,
+	); err != nil {
+		return err
 	}
 
 	go s.MakeBackup(databaseID, true)
@@ -80,8 +98,12 @@ func (s *BackupService) GetBackups(
 		return nil, err
 	}
 
-	if database.UserID != user.ID {
-		return nil, errors.New("user does not have access to this database")
+	if err := s.databaseService.CheckAccess(
+		user,
+		database,
+		workspaces.WorkspaceRoleViewer,
+	); err != nil {
+		return nil, err
 	}
 
 	backups, err := s.backupRepository.FindByDatabaseID(databaseID)
@@ -92,6 +114,44 @@ func (s *BackupService) GetBackups(
 	return backups, nil
 }
 
+// PreviewRetentionWithAuth returns backups which the cleaner would prune
+// if the proposed retention policy was saved
+func (s *BackupService) PreviewRetentionWithAuth(
+	user *users_models.User,
+	request *PreviewRetentionRequest,
+) ([]*Backup, error) {
+	database, err := s.databaseService.GetDatabaseByID(request.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.databaseService.CheckAccess(
+		user,
+		database,
+		workspaces.WorkspaceRoleViewer,
+	); err != nil {
+		return nil, err
+	}
+
+	proposedConfig := &backups_config.BackupConfig{
+		DatabaseID:          request.DatabaseID,
+		RetentionPolicyType: request.RetentionPolicyType,
+		StorePeriod:         request.StorePeriod,
+		GfsRetentionPolicy:  request.GfsRetentionPolicy,
+	}
+
+	if err := proposedConfig.ValidateRetentionPolicy(); err != nil {
+		return nil, err
+	}
+
+	backups, err := s.backupRepository.FindByDatabaseID(request.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return getBackupsToPrune(proposedConfig, backups, time.Now().UTC()), nil
+}
+
 func (s *BackupService) DeleteBackup(
 	user *users_models.User,
 	backupID uuid.UUID,
@@ -101,8 +161,12 @@ func (s *BackupService) DeleteBackup(
 		return err
 	}
 
-	if backup.Database.UserID != user.ID {
-		return errors.New("user does not have access to this backup")
+	if err := s.databaseService.CheckAccess(
+		user,
+		backup.Database,
+		workspaces.WorkspaceRoleAdmin,
+	); err != nil {
+		return err
 	}
 
 	if backup.Status == BackupStatusInProgress {
@@ -113,26 +177,40 @@ func (s *BackupService) DeleteBackup(
 }
 
 func (s *BackupService) MakeBackup(databaseID uuid.UUID, isLastTry bool) {
+	ctx, span := tracing.StartSpan(
+		context.Background(),
+		"backup.make",
+		attribute.String("database.id", databaseID.String()),
+	)
+
+	// set on every failure path, so the span is not ended with OK status
+	var backupErr error
+	defer func() { tracing.EndSpan(span, backupErr) }()
+
 	database, err := s.databaseService.GetDatabaseByID(databaseID)
 	if err != nil {
 		s.logger.Error("Failed to get database by ID", "error", err)
+		backupErr = err
 		return
 	}
 
 	lastBackup, err := s.backupRepository.FindLastByDatabaseID(databaseID)
 	if err != nil {
 		s.logger.Error("Failed to find last backup by database ID", "error", err)
+		backupErr = err
 		return
 	}
 
 	if lastBackup != nil && lastBackup.Status == BackupStatusInProgress {
 		s.logger.Error("Backup is in progress")
+		backupErr = errors.New("backup is in progress")
 		return
 	}
 
 	backupConfig, err := s.backupConfigService.GetBackupConfigByDbId(databaseID)
 	if err != nil {
 		s.logger.Error("Failed to get backup config by database ID", "error", err)
+		backupErr = err
 		return
 	}
 
@@ -143,15 +221,27 @@ func (s *BackupService) MakeBackup(databaseID uuid.UUID, isLastTry bool) {
 
 	if backupConfig.StorageID == nil {
 		s.logger.Error("Backup config storage ID is not defined")
+		backupErr = errors.New("backup config storage ID is not defined")
 		return
 	}
 
 	storage, err := s.storageService.GetStorageByID(*backupConfig.StorageID)
 	if err != nil {
 		s.logger.Error("Failed to get storage by ID", "error", err)
+		backupErr = err
 		return
 	}
 
+	var encryptionKey *backups_encryption.BackupEncryptionKey
+	if backupConfig.Encryption == backups_config.BackupEncryptionAES256GCM {
+		encryptionKey, err = s.backupEncryptionKeyService.GetActiveKey()
+		if err != nil {
+			s.logger.Error("Failed to get backup encryption key", "error", err)
+			backupErr = err
+			return
+		}
+	}
+
 	backup := &Backup{
 		DatabaseID: databaseID,
 		Database:   database,
@@ -159,18 +249,35 @@ func (s *BackupService) MakeBackup(databaseID uuid.UUID, isLastTry bool) {
 		StorageID: storage.ID,
 		Storage:   storage,
 
+		Type:   backupConfig.BackupType,
 		Status: BackupStatusInProgress,
 
 		BackupSizeMb: 0,
 
+		Encryption: backups_config.BackupEncryptionNone,
+
 		CreatedAt: time.Now().UTC(),
 	}
 
+	if encryptionKey != nil {
+		backup.Encryption = backupConfig.Encryption
+		backup.EncryptionKeyID = &encryptionKey.ID
+	}
+
 	if err := s.backupRepository.Save(backup); err != nil {
 		s.logger.Error("Failed to save backup", "error", err)
+		backupErr = err
 		return
 	}
 
+	span.SetAttributes(
+		attribute.String("backup.id", backup.ID.String()),
+		attribute.String("backup.type", string(backup.Type)),
+		attribute.String("database.type", string(database.Type)),
+		attribute.String("storage.type", string(storage.Type)),
+		attribute.Bool("backup.is_encrypted", encryptionKey != nil),
+	)
+
 	start := time.Now().UTC()
 
 	backupProgressListener := func(
@@ -184,14 +291,18 @@ func (s *BackupService) MakeBackup(databaseID uuid.UUID, isLastTry bool) {
 		}
 	}
 
-	err = s.createBackupUseCase.Execute(
+	backupMetadata, err := s.createBackupUseCase.Execute(
+		ctx,
 		backup.ID,
 		backupConfig,
 		database,
 		storage,
+		encryptionKey,
 		backupProgressListener,
 	)
 	if err != nil {
+		backupErr = err
+
 		errMsg := err.Error()
 		backup.FailMessage = &errMsg
 		backup.Status = BackupStatusFailed
@@ -225,11 +336,50 @@ func (s *BackupService) MakeBackup(databaseID uuid.UUID, isLastTry bool) {
 	backup.Status = BackupStatusCompleted
 	backup.BackupDurationMs = time.Since(start).Milliseconds()
 
+	tracing.SetThroughput(
+		span,
+		int64(backup.BackupSizeMb*1024*1024),
+		time.Duration(backup.BackupDurationMs)*time.Millisecond,
+	)
+
+	if backupMetadata != nil {
+		backup.WalStartLsn = backupMetadata.WalStartLsn
+		backup.WalStopLsn = backupMetadata.WalStopLsn
+		backup.WalStartSegment = backupMetadata.WalStartSegment
+		backup.WalStopSegment = backupMetadata.WalStopSegment
+		backup.Checksum = backupMetadata.Checksum
+
+		for _, memberMetadata := range backupMetadata.Members {
+			checksum := memberMetadata.Checksum
+
+			backup.Members = append(backup.Members, &BackupMember{
+				ID:           memberMetadata.ID,
+				BackupID:     backup.ID,
+				DatabaseName: memberMetadata.DatabaseName,
+				BackupSizeMb: memberMetadata.BackupSizeMb,
+				Checksum:     &checksum,
+			})
+		}
+	}
+
+	// members are created before the backup is completed, so
+	// completed cluster backup is never seen without its members
+	if err := s.backupRepository.CreateMembers(backup.Members); err != nil {
+		s.logger.Error("Failed to save backup members", "error", err)
+		backupErr = err
+		return
+	}
+
 	if err := s.backupRepository.Save(backup); err != nil {
 		s.logger.Error("Failed to save backup", "error", err)
+		backupErr = err
 		return
 	}
 
+	s.backupMetrics.ObserveBackup(database, backup)
+
+	s.createBackupCopies(backupConfig, backup)
+
 	// Update database last backup time
 	now := time.Now().UTC()
 	if updateErr := s.databaseService.SetLastBackupTime(databaseID, now); updateErr != nil {
@@ -279,6 +429,11 @@ func (s *BackupService) SendBackupNotification(
 			title = fmt.Sprintf("❌ Backup failed for database \"%s\"", database.Name)
 		case backups_config.NotificationBackupSuccess:
 			title = fmt.Sprintf("✅ Backup completed for database \"%s\"", database.Name)
+		case backups_config.NotificationBackupVerificationFailed:
+			title = fmt.Sprintf(
+				"⚠️ Backup verification failed for database \"%s\"",
+				database.Name,
+			)
 		}
 
 		message := ""
@@ -328,16 +483,43 @@ func (s *BackupService) GetBackupFile(
 		return nil, err
 	}
 
-	if backup.Database.UserID != user.ID {
-		return nil, errors.New("user does not have access to this backup")
+	if err := s.databaseService.CheckAccess(
+		user,
+		backup.Database,
+		workspaces.WorkspaceRoleOperator,
+	); err != nil {
+		return nil, err
 	}
 
-	storage, err := s.storageService.GetStorageByID(backup.StorageID)
+	return s.openDecryptedBackupFile(backup, backup.ID)
+}
+
+// GetBackupMemberFile returns dump of one database of cluster backup
+func (s *BackupService) GetBackupMemberFile(
+	user *users_models.User,
+	backupID uuid.UUID,
+	memberID uuid.UUID,
+) (io.ReadCloser, error) {
+	backup, err := s.backupRepository.FindByID(backupID)
 	if err != nil {
 		return nil, err
 	}
 
-	return storage.GetFile(backup.ID)
+	if err := s.databaseService.CheckAccess(
+		user,
+		backup.Database,
+		workspaces.WorkspaceRoleOperator,
+	); err != nil {
+		return nil, err
+	}
+
+	for _, member := range backup.Members {
+		if member.ID == memberID {
+			return s.openDecryptedBackupFile(backup, member.ID)
+		}
+	}
+
+	return nil, errors.New("backup member not found")
 }
 
 func (s *BackupService) deleteBackup(backup *Backup) error {
@@ -352,11 +534,14 @@ func (s *BackupService) deleteBackup(backup *Backup) error {
 		return err
 	}
 
-	err = storage.DeleteFile(backup.ID)
-	if err != nil {
-		return err
+	for _, fileID := range backup.GetFileIDs() {
+		if err := storage.DeleteFile(fileID); err != nil {
+			return err
+		}
 	}
 
+	s.deleteBackupCopies(backup)
+
 	return s.backupRepository.DeleteByID(backup.ID)
 }
 
@@ -387,5 +572,28 @@ func (s *BackupService) deleteDbBackups(databaseID uuid.UUID) error {
 		}
 	}
 
-	return nil
+	return s.walSegmentService.DeleteDatabaseSegments(databaseID)
+}
+
+func (s *BackupService) openDecryptedBackupFile(
+	backup *Backup,
+	fileID uuid.UUID,
+) (io.ReadCloser, error) {
+	file, _, err := s.openBackupFile(context.Background(), backup, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if backup.Encryption == backups_config.BackupEncryptionAES256GCM &&
+		backup.EncryptionKeyID != nil {
+		encryptionKey, err := s.backupEncryptionKeyService.GetKeyByID(*backup.EncryptionKeyID)
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+
+		return encryption.NewDecryptingReadCloser(file, encryptionKey.Key)
+	}
+
+	return file, nil
 }
@@ -1,15 +1,23 @@
 package backups
 
 import (
+	"context"
 	"errors"
+	"postgresus-backend/internal/features/backups/backups/usecases"
+	usecases_common "postgresus-backend/internal/features/backups/backups/usecases/common"
 	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	backups_wal "postgresus-backend/internal/features/backups/wal"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/notifiers"
 	"postgresus-backend/internal/features/storages"
 	"postgresus-backend/internal/features/users"
+	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/features/workspaces"
 	"postgresus-backend/internal/util/logger"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -33,10 +41,15 @@ func Test_BackupExecuted_NotificationSent(t *testing.T) {
 			databases.GetDatabaseService(),
 			storages.GetStorageService(),
 			backupRepository,
+			&BackupCopyRepository{},
 			notifiers.GetNotifierService(),
 			mockNotificationSender,
 			backups_config.GetBackupConfigService(),
+			backups_encryption.GetBackupEncryptionKeyService(),
+			backups_wal.GetWalSegmentService(),
 			&CreateFailedBackupUsecase{},
+			usecases.GetVerifyBackupUsecase(),
+			NewBackupMetrics(),
 			logger.GetLogger(),
 			[]BackupRemoveListener{},
 		}
@@ -76,10 +89,15 @@ func Test_BackupExecuted_NotificationSent(t *testing.T) {
 			databases.GetDatabaseService(),
 			storages.GetStorageService(),
 			backupRepository,
+			&BackupCopyRepository{},
 			notifiers.GetNotifierService(),
 			mockNotificationSender,
 			backups_config.GetBackupConfigService(),
+			backups_encryption.GetBackupEncryptionKeyService(),
+			backups_wal.GetWalSegmentService(),
 			&CreateSuccessBackupUsecase{},
+			usecases.GetVerifyBackupUsecase(),
+			NewBackupMetrics(),
 			logger.GetLogger(),
 			[]BackupRemoveListener{},
 		}
@@ -96,10 +114,15 @@ func Test_BackupExecuted_NotificationSent(t *testing.T) {
 			databases.GetDatabaseService(),
 			storages.GetStorageService(),
 			backupRepository,
+			&BackupCopyRepository{},
 			notifiers.GetNotifierService(),
 			mockNotificationSender,
 			backups_config.GetBackupConfigService(),
+			backups_encryption.GetBackupEncryptionKeyService(),
+			backups_wal.GetWalSegmentService(),
 			&CreateSuccessBackupUsecase{},
+			usecases.GetVerifyBackupUsecase(),
+			NewBackupMetrics(),
 			logger.GetLogger(),
 			[]BackupRemoveListener{},
 		}
@@ -133,34 +156,111 @@ func Test_BackupExecuted_NotificationSent(t *testing.T) {
 	})
 }
 
+func Test_MakeAndDownloadBackupByViewer_AccessDenied(t *testing.T) {
+	owner := users.GetTestUser()
+	storage := storages.CreateTestStorage(owner.UserID)
+	notifier := notifiers.CreateTestNotifier(owner.UserID)
+	database := databases.CreateTestDatabase(owner.UserID, storage, notifier)
+	backups_config.EnableBackupsForTestDatabase(database.ID, storage)
+
+	defer storages.RemoveTestStorage(storage.ID)
+	defer notifiers.RemoveTestNotifier(notifier)
+	defer databases.RemoveTestDatabase(database)
+
+	backup := &Backup{
+		DatabaseID: database.ID,
+		StorageID:  storage.ID,
+		Status:     BackupStatusCompleted,
+		CreatedAt:  time.Now().UTC(),
+	}
+	err := backupRepository.Save(backup)
+	assert.NoError(t, err)
+	defer func() {
+		_ = backupRepository.DeleteByID(backup.ID)
+	}()
+
+	viewer := getTestSignedInUser(t, users.CreateTestUser())
+	workspaces.AddTestMember(database.WorkspaceID, viewer.ID, workspaces.WorkspaceRoleViewer)
+
+	// viewer can see backups, but cannot make or download them
+	viewerBackups, err := backupService.GetBackups(viewer, database.ID)
+	assert.NoError(t, err)
+	assert.Len(t, viewerBackups, 1)
+
+	err = backupService.MakeBackupWithAuth(viewer, database.ID)
+	assert.ErrorContains(t, err, "operator role or higher is required")
+
+	_, err = backupService.GetBackupFile(viewer, backup.ID)
+	assert.ErrorContains(t, err, "operator role or higher is required")
+
+	err = backupService.DeleteBackup(viewer, backup.ID)
+	assert.ErrorContains(t, err, "admin role or higher is required")
+
+	savedBackups, err := backupRepository.FindByDatabaseID(database.ID)
+	assert.NoError(t, err)
+	assert.Len(t, savedBackups, 1)
+}
+
+func Test_GetBackupsByMemberOfAnotherWorkspace_AccessDenied(t *testing.T) {
+	owner := users.GetTestUser()
+	storage := storages.CreateTestStorage(owner.UserID)
+	notifier := notifiers.CreateTestNotifier(owner.UserID)
+	database := databases.CreateTestDatabase(owner.UserID, storage, notifier)
+	backups_config.EnableBackupsForTestDatabase(database.ID, storage)
+
+	defer storages.RemoveTestStorage(storage.ID)
+	defer notifiers.RemoveTestNotifier(notifier)
+	defer databases.RemoveTestDatabase(database)
+
+	stranger := getTestSignedInUser(t, users.CreateTestUser())
+	workspaces.CreateTestWorkspace(stranger.ID)
+
+	_, err := backupService.GetBackups(stranger, database.ID)
+	assert.ErrorContains(t, err, "you have not access to this workspace")
+
+	err = backupService.MakeBackupWithAuth(stranger, database.ID)
+	assert.ErrorContains(t, err, "you have not access to this workspace")
+}
+
+func getTestSignedInUser(t *testing.T, signInResponse *users.SignInResponse) *users_models.User {
+	user, err := users.GetUserService().GetUserFromToken(signInResponse.Token)
+	assert.NoError(t, err)
+
+	return user
+}
+
 type CreateFailedBackupUsecase struct {
 }
 
 func (uc *CreateFailedBackupUsecase) Execute(
+	ctx context.Context,
 	backupID uuid.UUID,
 	backupConfig *backups_config.BackupConfig,
 	database *databases.Database,
 	storage *storages.Storage,
+	encryptionKey *backups_encryption.BackupEncryptionKey,
 	backupProgressListener func(
 		completedMBs float64,
 	),
-) error {
+) (*usecases_common.BackupMetadata, error) {
 	backupProgressListener(10) // Assume we completed 10MB
-	return errors.New("backup failed")
+	return nil, errors.New("backup failed")
 }
 
 type CreateSuccessBackupUsecase struct {
 }
 
 func (uc *CreateSuccessBackupUsecase) Execute(
+	ctx context.Context,
 	backupID uuid.UUID,
 	backupConfig *backups_config.BackupConfig,
 	database *databases.Database,
 	storage *storages.Storage,
+	encryptionKey *backups_encryption.BackupEncryptionKey,
 	backupProgressListener func(
 		completedMBs float64,
 	),
-) error {
+) (*usecases_common.BackupMetadata, error) {
 	backupProgressListener(10) // Assume we completed 10MB
-	return nil
+	return &usecases_common.BackupMetadata{}, nil
 }
@@ -0,0 +1,28 @@
+package usecases_common
+
+import "github.com/google/uuid"
+
+// BackupMetadata is data about created backup that use cases
+// return to be stored on the backup
+type BackupMetadata struct {
+	// WAL positions of physical base backup. WAL between start and
+	// stop segments is required to make the base backup consistent
+	WalStartLsn     *string
+	WalStopLsn      *string
+	WalStartSegment *string
+	WalStopSegment  *string
+
+	// SHA-256 of the stored file, hex encoded
+	Checksum *string
+
+	// databases of cluster backup. The backup file contains globals
+	// and each database is stored in its own file named by member ID
+	Members []BackupMemberMetadata
+}
+
+type BackupMemberMetadata struct {
+	ID           uuid.UUID
+	DatabaseName string
+	BackupSizeMb float64
+	Checksum     string
+}
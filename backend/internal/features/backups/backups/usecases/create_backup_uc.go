@@ -1,9 +1,14 @@
 package usecases
 
 import (
+	"context"
 	"errors"
+	usecases_common "postgresus-backend/internal/features/backups/backups/usecases/common"
+	usecases_mongodb "postgresus-backend/internal/features/backups/backups/usecases/mongodb"
+	usecases_mysql "postgresus-backend/internal/features/backups/backups/usecases/mysql"
 	usecases_postgresql "postgresus-backend/internal/features/backups/backups/usecases/postgresql"
 	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/storages"
 
@@ -12,27 +17,57 @@ import (
 
 type CreateBackupUsecase struct {
 	CreatePostgresqlBackupUsecase *usecases_postgresql.CreatePostgresqlBackupUsecase
+	CreateMysqlBackupUsecase      *usecases_mysql.CreateMysqlBackupUsecase
+	CreateMongodbBackupUsecase    *usecases_mongodb.CreateMongodbBackupUsecase
 }
 
-// Execute creates a backup of the database and returns the backup size in MB
+// Execute creates a backup of the database and returns its metadata
 func (uc *CreateBackupUsecase) Execute(
+	ctx context.Context,
 	backupID uuid.UUID,
 	backupConfig *backups_config.BackupConfig,
 	database *databases.Database,
 	storage *storages.Storage,
+	encryptionKey *backups_encryption.BackupEncryptionKey,
 	backupProgressListener func(
 		completedMBs float64,
 	),
-) error {
+) (*usecases_common.BackupMetadata, error) {
 	if database.Type == databases.DatabaseTypePostgres {
 		return uc.CreatePostgresqlBackupUsecase.Execute(
+			ctx,
 			backupID,
 			backupConfig,
 			database,
 			storage,
+			encryptionKey,
 			backupProgressListener,
 		)
 	}
 
-	return errors.New("database type not supported")
+	if database.Type == databases.DatabaseTypeMysql {
+		return uc.CreateMysqlBackupUsecase.Execute(
+			ctx,
+			backupID,
+			backupConfig,
+			database,
+			storage,
+			encryptionKey,
+			backupProgressListener,
+		)
+	}
+
+	if database.Type == databases.DatabaseTypeMongodb {
+		return uc.CreateMongodbBackupUsecase.Execute(
+			ctx,
+			backupID,
+			backupConfig,
+			database,
+			storage,
+			encryptionKey,
+			backupProgressListener,
+		)
+	}
+
+	return nil, errors.New("database type not supported")
 }
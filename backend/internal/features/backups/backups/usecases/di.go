@@ -1,13 +1,27 @@
 package usecases
 
 import (
+	usecases_mongodb "postgresus-backend/internal/features/backups/backups/usecases/mongodb"
+	usecases_mysql "postgresus-backend/internal/features/backups/backups/usecases/mysql"
 	usecases_postgresql "postgresus-backend/internal/features/backups/backups/usecases/postgresql"
 )
 
 var createBackupUsecase = &CreateBackupUsecase{
 	usecases_postgresql.GetCreatePostgresqlBackupUsecase(),
+	usecases_mysql.GetCreateMysqlBackupUsecase(),
+	usecases_mongodb.GetCreateMongodbBackupUsecase(),
+}
+
+var verifyBackupUsecase = &VerifyBackupUsecase{
+	usecases_postgresql.GetVerifyPostgresqlBackupUsecase(),
+	usecases_mysql.GetVerifyMysqlBackupUsecase(),
+	usecases_mongodb.GetVerifyMongodbBackupUsecase(),
 }
 
 func GetCreateBackupUsecase() *CreateBackupUsecase {
 	return createBackupUsecase
 }
+
+func GetVerifyBackupUsecase() *VerifyBackupUsecase {
+	return verifyBackupUsecase
+}
@@ -0,0 +1,325 @@
+package usecases_mongodb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"postgresus-backend/internal/config"
+	usecases_common "postgresus-backend/internal/features/backups/backups/usecases/common"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	"postgresus-backend/internal/features/databases"
+	mongotypes "postgresus-backend/internal/features/databases/databases/mongodb"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/tools"
+	"postgresus-backend/internal/util/tracing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type CreateMongodbBackupUsecase struct {
+	logger *slog.Logger
+}
+
+// Execute creates a backup of the database (or of all databases of the
+// deployment if no database is set) via mongodump. Archive is gzipped by
+// mongodump itself, so it is streamed to storage as is
+func (uc *CreateMongodbBackupUsecase) Execute(
+	ctx context.Context,
+	backupID uuid.UUID,
+	backupConfig *backups_config.BackupConfig,
+	db *databases.Database,
+	storage *storages.Storage,
+	encryptionKey *backups_encryption.BackupEncryptionKey,
+	backupProgressListener func(
+		completedMBs float64,
+	),
+) (*usecases_common.BackupMetadata, error) {
+	if !backupConfig.IsBackupsEnabled {
+		return nil, fmt.Errorf("backups are not enabled for this database: \"%s\"", db.Name)
+	}
+
+	mongo := db.Mongodb
+
+	if mongo == nil {
+		return nil, errors.New("mongodb database configuration is required for backups")
+	}
+
+	if backupConfig.BackupType == backups_config.BackupTypePhysical {
+		return nil, errors.New("physical backups are supported only for PostgreSQL")
+	}
+
+	uc.logger.Info(
+		"Creating MongoDB backup via mongodump",
+		"databaseId",
+		db.ID,
+		"storageId",
+		storage.ID,
+	)
+
+	checksum, err := uc.streamToStorage(
+		ctx,
+		backupID,
+		tools.GetMongodbExecutable(
+			tools.MongodbExecutableDump,
+			config.GetEnv().EnvMode,
+			config.GetEnv().MongodbInstallDir,
+		),
+		mongo,
+		storage,
+		encryptionKey,
+		backupProgressListener,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &usecases_common.BackupMetadata{Checksum: &checksum}, nil
+}
+
+// streamToStorage streams the archive directly to storage and returns
+// SHA-256 checksum of the stored (compressed and, if enabled, encrypted) data
+func (uc *CreateMongodbBackupUsecase) streamToStorage(
+	ctx context.Context,
+	backupID uuid.UUID,
+	dumpBin string,
+	mongo *mongotypes.MongodbDatabase,
+	storage *storages.Storage,
+	encryptionKey *backups_encryption.BackupEncryptionKey,
+	backupProgressListener func(completedMBs float64),
+) (string, error) {
+	// if backup not fit into 23 hours, Postgresus
+	// seems not to work for such database size
+	ctx, cancel := context.WithTimeout(ctx, 23*time.Hour)
+	defer cancel()
+
+	// Monitor for shutdown and cancel context if needed
+	go func() {
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if config.IsShouldShutdown() {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	if _, err := exec.LookPath(dumpBin); err != nil {
+		return "", fmt.Errorf(
+			"MongoDB executable not found or not accessible: %s - %w",
+			dumpBin,
+			err,
+		)
+	}
+
+	configFile, cleanupFunc, err := mongo.CreateTempConfigFile()
+	if err != nil {
+		return "", err
+	}
+	defer cleanupFunc()
+
+	cmd := exec.CommandContext(ctx, dumpBin, uc.getDumpArgs(mongo, configFile)...)
+	cmd.Env = os.Environ()
+	uc.logger.Info("Executing MongoDB backup command", "command", cmd.String())
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	dumpStdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("stdout pipe: %w", err)
+	}
+
+	// A pipe connecting dump output → storage
+	storageReader, storageWriter := io.Pipe()
+
+	// Encrypt the stream (if enabled) before it reaches any storage
+	var dumpWriter io.WriteCloser = storageWriter
+	if encryptionKey != nil {
+		dumpWriter, err = encryption.NewEncryptingWriter(storageWriter, encryptionKey.Key)
+		if err != nil {
+			return "", fmt.Errorf("failed to create encrypting writer: %w", err)
+		}
+
+		uc.logger.Info("Encrypting backup", "encryptionKeyId", encryptionKey.ID)
+	}
+
+	countingWriter := &countingWriter{writer: dumpWriter}
+
+	// Hash exactly the bytes the storage receives, so
+	// the stored file can be verified without decryption
+	checksumHasher := sha256.New()
+
+	saveErrCh := make(chan error, 1)
+	go func() {
+		saveErrCh <- storage.SaveFile(
+			ctx,
+			uc.logger,
+			backupID,
+			io.TeeReader(storageReader, checksumHasher),
+		)
+	}()
+
+	if err = cmd.Start(); err != nil {
+		_ = storageWriter.CloseWithError(err)
+		<-saveErrCh
+		return "", fmt.Errorf("start %s: %w", filepath.Base(dumpBin), err)
+	}
+
+	_, dumpSpan := tracing.StartSpan(
+		ctx,
+		"backup.dump",
+		attribute.String("tool", filepath.Base(dumpBin)),
+	)
+	dumpStartedAt := time.Now().UTC()
+
+	copyErr := uc.copyWithShutdownCheck(
+		ctx,
+		countingWriter,
+		dumpStdout,
+		countingWriter,
+		backupProgressListener,
+	)
+
+	// mongodump blocks on writing output nobody reads anymore
+	if copyErr != nil {
+		cancel()
+	}
+
+	waitErr := cmd.Wait()
+
+	tracing.SetThroughput(dumpSpan, countingWriter.bytesWritten, time.Since(dumpStartedAt))
+	tracing.EndSpan(dumpSpan, waitErr)
+
+	if config.IsShouldShutdown() {
+		_ = storageWriter.CloseWithError(errors.New("backup cancelled due to shutdown"))
+		<-saveErrCh
+		return "", errors.New("backup cancelled due to shutdown")
+	}
+
+	// Flush the last encrypted chunk
+	if dumpWriter != storageWriter {
+		if err := dumpWriter.Close(); err != nil && copyErr == nil {
+			copyErr = err
+		}
+	}
+
+	if err := storageWriter.Close(); err != nil {
+		uc.logger.Error("Failed to close storage writer", "error", err)
+	}
+
+	saveErr := <-saveErrCh
+
+	switch {
+	case waitErr != nil:
+		return "", fmt.Errorf(
+			"%s failed: %v – stderr: %s",
+			filepath.Base(dumpBin),
+			waitErr,
+			stderr.String(),
+		)
+	case copyErr != nil:
+		return "", fmt.Errorf("copy to storage: %w", copyErr)
+	case saveErr != nil:
+		return "", fmt.Errorf("save to storage: %w", saveErr)
+	}
+
+	if backupProgressListener != nil {
+		backupProgressListener(float64(countingWriter.bytesWritten) / (1024 * 1024))
+	}
+
+	return hex.EncodeToString(checksumHasher.Sum(nil)), nil
+}
+
+func (uc *CreateMongodbBackupUsecase) getDumpArgs(
+	mongo *mongotypes.MongodbDatabase,
+	configFile string,
+) []string {
+	args := []string{
+		"--config=" + configFile,
+		"--archive", // write single archive to stdout
+		"--gzip",
+	}
+
+	if mongo.Database != nil && *mongo.Database != "" {
+		args = append(args, "--db="+*mongo.Database)
+	}
+
+	return args
+}
+
+// copyWithShutdownCheck copies data from src to dst while checking for
+// shutdown and reports size of the stored data every 1MB
+func (uc *CreateMongodbBackupUsecase) copyWithShutdownCheck(
+	ctx context.Context,
+	dst io.Writer,
+	src io.Reader,
+	storedWriter *countingWriter,
+	backupProgressListener func(completedMBs float64),
+) error {
+	buf := make([]byte, 32*1024)
+	var lastReportedMB float64
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("copy cancelled: %w", ctx.Err())
+		default:
+		}
+
+		if config.IsShouldShutdown() {
+			return errors.New("copy cancelled due to shutdown")
+		}
+
+		bytesRead, readErr := src.Read(buf)
+		if bytesRead > 0 {
+			if _, err := dst.Write(buf[:bytesRead]); err != nil {
+				return err
+			}
+
+			currentSizeMB := float64(storedWriter.bytesWritten) / (1024 * 1024)
+			if backupProgressListener != nil && currentSizeMB >= lastReportedMB+1 {
+				backupProgressListener(currentSizeMB)
+				lastReportedMB = currentSizeMB
+			}
+		}
+
+		if readErr != nil {
+			if readErr != io.EOF {
+				return readErr
+			}
+
+			return nil
+		}
+	}
+}
+
+type countingWriter struct {
+	writer       io.Writer
+	bytesWritten int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.writer.Write(p)
+	cw.bytesWritten += int64(n)
+	return n, err
+}
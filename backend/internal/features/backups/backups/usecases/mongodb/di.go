@@ -0,0 +1,21 @@
+package usecases_mongodb
+
+import (
+	"postgresus-backend/internal/util/logger"
+)
+
+var createMongodbBackupUsecase = &CreateMongodbBackupUsecase{
+	logger.GetLogger(),
+}
+
+var verifyMongodbBackupUsecase = &VerifyMongodbBackupUsecase{
+	logger.GetLogger(),
+}
+
+func GetCreateMongodbBackupUsecase() *CreateMongodbBackupUsecase {
+	return createMongodbBackupUsecase
+}
+
+func GetVerifyMongodbBackupUsecase() *VerifyMongodbBackupUsecase {
+	return verifyMongodbBackupUsecase
+}
@@ -0,0 +1,75 @@
+package usecases_mongodb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"postgresus-backend/internal/config"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+
+	"github.com/google/uuid"
+)
+
+// mongodump archives start with magic number 0x8199e26d (little endian)
+var archiveMagicNumber = []byte{0x6d, 0xe2, 0x99, 0x81}
+
+type VerifyMongodbBackupUsecase struct {
+	logger *slog.Logger
+}
+
+// Execute reads the whole decrypted archive through gzip, so gzip
+// checksums are checked, and requires archive header at the start
+func (uc *VerifyMongodbBackupUsecase) Execute(
+	backupID uuid.UUID,
+	backupType backups_config.BackupType,
+	backupConfig *backups_config.BackupConfig,
+	db *databases.Database,
+	backupReader io.Reader,
+	isTestRestore bool,
+) error {
+	if isTestRestore {
+		return errors.New("test restore is supported only for PostgreSQL")
+	}
+
+	uc.logger.Info("Verifying MongoDB backup", "backupId", backupID)
+
+	gzipReader, err := gzip.NewReader(backupReader)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	defer func() {
+		_ = gzipReader.Close()
+	}()
+
+	header := make([]byte, len(archiveMagicNumber))
+	if _, err := io.ReadFull(gzipReader, header); err != nil {
+		return fmt.Errorf("failed to read archive header: %w", err)
+	}
+
+	if !bytes.Equal(header, archiveMagicNumber) {
+		return errors.New("backup is not a mongodump archive")
+	}
+
+	buf := make([]byte, 32*1024)
+
+	for {
+		if config.IsShouldShutdown() {
+			return errors.New("verification cancelled due to shutdown")
+		}
+
+		_, readErr := gzipReader.Read(buf)
+
+		if readErr == io.EOF {
+			return nil
+		}
+
+		if readErr != nil {
+			return fmt.Errorf("failed to read backup: %w", readErr)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package usecases_mysql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"postgresus-backend/internal/config"
+	usecases_common "postgresus-backend/internal/features/backups/backups/usecases/common"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	"postgresus-backend/internal/features/databases"
+	mysqltypes "postgresus-backend/internal/features/databases/databases/mysql"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/tools"
+	"postgresus-backend/internal/util/tracing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type CreateMysqlBackupUsecase struct {
+	logger *slog.Logger
+}
+
+// Execute creates a backup of the database via mysqldump (or mariadb-dump
+// for MariaDB). The dump is plain SQL, so it is gzipped before storing
+func (uc *CreateMysqlBackupUsecase) Execute(
+	ctx context.Context,
+	backupID uuid.UUID,
+	backupConfig *backups_config.BackupConfig,
+	db *databases.Database,
+	storage *storages.Storage,
+	encryptionKey *backups_encryption.BackupEncryptionKey,
+	backupProgressListener func(
+		completedMBs float64,
+	),
+) (*usecases_common.BackupMetadata, error) {
+	if !backupConfig.IsBackupsEnabled {
+		return nil, fmt.Errorf("backups are not enabled for this database: \"%s\"", db.Name)
+	}
+
+	my := db.Mysql
+
+	if my == nil {
+		return nil, errors.New("mysql database configuration is required for backups")
+	}
+
+	if backupConfig.BackupType == backups_config.BackupTypePhysical {
+		return nil, errors.New("physical backups are supported only for PostgreSQL")
+	}
+
+	if my.Database == nil || *my.Database == "" {
+		return nil, errors.New("database name is required for mysql backups")
+	}
+
+	uc.logger.Info(
+		"Creating MySQL backup via dump tool",
+		"databaseId",
+		db.ID,
+		"storageId",
+		storage.ID,
+		"flavor",
+		my.Flavor,
+	)
+
+	checksum, err := uc.streamToStorage(
+		ctx,
+		backupID,
+		tools.GetMysqlExecutable(
+			my.Flavor,
+			tools.MysqlExecutableDump,
+			config.GetEnv().EnvMode,
+			config.GetEnv().MysqlInstallDir,
+		),
+		my,
+		storage,
+		encryptionKey,
+		backupProgressListener,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &usecases_common.BackupMetadata{Checksum: &checksum}, nil
+}
+
+// streamToStorage streams gzipped dump directly to storage and returns
+// SHA-256 checksum of the stored (compressed and, if enabled, encrypted) data
+func (uc *CreateMysqlBackupUsecase) streamToStorage(
+	ctx context.Context,
+	backupID uuid.UUID,
+	dumpBin string,
+	my *mysqltypes.MysqlDatabase,
+	storage *storages.Storage,
+	encryptionKey *backups_encryption.BackupEncryptionKey,
+	backupProgressListener func(completedMBs float64),
+) (string, error) {
+	// if backup not fit into 23 hours, Postgresus
+	// seems not to work for such database size
+	ctx, cancel := context.WithTimeout(ctx, 23*time.Hour)
+	defer cancel()
+
+	// Monitor for shutdown and cancel context if needed
+	go func() {
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if config.IsShouldShutdown() {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	if _, err := exec.LookPath(dumpBin); err != nil {
+		return "", fmt.Errorf(
+			"MySQL executable not found or not accessible: %s - %w",
+			dumpBin,
+			err,
+		)
+	}
+
+	defaultsFile, cleanupFunc, err := my.CreateTempDefaultsFile()
+	if err != nil {
+		return "", err
+	}
+	defer cleanupFunc()
+
+	cmd := exec.CommandContext(ctx, dumpBin, uc.getDumpArgs(my, defaultsFile)...)
+	cmd.Env = os.Environ()
+	uc.logger.Info("Executing MySQL backup command", "command", cmd.String())
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	dumpStdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("stdout pipe: %w", err)
+	}
+
+	// A pipe connecting dump output → storage
+	storageReader, storageWriter := io.Pipe()
+
+	// Encrypt the stream (if enabled) before it reaches any storage
+	var dumpWriter io.WriteCloser = storageWriter
+	if encryptionKey != nil {
+		dumpWriter, err = encryption.NewEncryptingWriter(storageWriter, encryptionKey.Key)
+		if err != nil {
+			return "", fmt.Errorf("failed to create encrypting writer: %w", err)
+		}
+
+		uc.logger.Info("Encrypting backup", "encryptionKeyId", encryptionKey.ID)
+	}
+
+	// count compressed bytes, as they are what is stored
+	countingWriter := &countingWriter{writer: dumpWriter}
+
+	gzipWriter, err := gzip.NewWriterLevel(countingWriter, 5)
+	if err != nil {
+		return "", fmt.Errorf("failed to create gzip writer: %w", err)
+	}
+
+	// Hash exactly the bytes the storage receives, so
+	// the stored file can be verified without decryption
+	checksumHasher := sha256.New()
+
+	saveErrCh := make(chan error, 1)
+	go func() {
+		saveErrCh <- storage.SaveFile(
+			ctx,
+			uc.logger,
+			backupID,
+			io.TeeReader(storageReader, checksumHasher),
+		)
+	}()
+
+	if err = cmd.Start(); err != nil {
+		_ = storageWriter.CloseWithError(err)
+		<-saveErrCh
+		return "", fmt.Errorf("start %s: %w", filepath.Base(dumpBin), err)
+	}
+
+	_, dumpSpan := tracing.StartSpan(
+		ctx,
+		"backup.dump",
+		attribute.String("tool", filepath.Base(dumpBin)),
+	)
+	dumpStartedAt := time.Now().UTC()
+
+	copyErr := uc.copyWithShutdownCheck(
+		ctx,
+		gzipWriter,
+		dumpStdout,
+		countingWriter,
+		backupProgressListener,
+	)
+
+	// dump tool blocks on writing output nobody reads anymore
+	if copyErr != nil {
+		cancel()
+	}
+
+	waitErr := cmd.Wait()
+
+	tracing.SetThroughput(dumpSpan, countingWriter.bytesWritten, time.Since(dumpStartedAt))
+	tracing.EndSpan(dumpSpan, waitErr)
+
+	if config.IsShouldShutdown() {
+		_ = storageWriter.CloseWithError(errors.New("backup cancelled due to shutdown"))
+		<-saveErrCh
+		return "", errors.New("backup cancelled due to shutdown")
+	}
+
+	// Flush the gzip footer and the last encrypted chunk
+	if err := gzipWriter.Close(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+
+	if dumpWriter != storageWriter {
+		if err := dumpWriter.Close(); err != nil && copyErr == nil {
+			copyErr = err
+		}
+	}
+
+	if err := storageWriter.Close(); err != nil {
+		uc.logger.Error("Failed to close storage writer", "error", err)
+	}
+
+	saveErr := <-saveErrCh
+
+	switch {
+	case waitErr != nil:
+		return "", fmt.Errorf(
+			"%s failed: %v – stderr: %s",
+			filepath.Base(dumpBin),
+			waitErr,
+			stderr.String(),
+		)
+	case copyErr != nil:
+		return "", fmt.Errorf("copy to storage: %w", copyErr)
+	case saveErr != nil:
+		return "", fmt.Errorf("save to storage: %w", saveErr)
+	}
+
+	if backupProgressListener != nil {
+		backupProgressListener(float64(countingWriter.bytesWritten) / (1024 * 1024))
+	}
+
+	return hex.EncodeToString(checksumHasher.Sum(nil)), nil
+}
+
+func (uc *CreateMysqlBackupUsecase) getDumpArgs(
+	my *mysqltypes.MysqlDatabase,
+	defaultsFile string,
+) []string {
+	// option file must be the first argument
+	args := []string{"--defaults-extra-file=" + defaultsFile}
+	args = append(args, my.GetClientSslArgs()...)
+	args = append(args,
+		"--single-transaction", // consistent snapshot of InnoDB tables without locks
+		"--quick",              // stream rows instead of buffering whole tables
+		"--routines",
+		"--triggers",
+		"--hex-blob",
+		"--no-tablespaces", // does not require PROCESS privilege
+		"--default-character-set=utf8mb4",
+	)
+
+	// GTID statements make restore into other servers fail
+	if my.Flavor == tools.MysqlFlavorMysql {
+		args = append(args, "--set-gtid-purged=OFF")
+	}
+
+	return append(args, *my.Database)
+}
+
+// copyWithShutdownCheck copies data from src to dst while checking for
+// shutdown and reports size of the stored data every 1MB
+func (uc *CreateMysqlBackupUsecase) copyWithShutdownCheck(
+	ctx context.Context,
+	dst io.Writer,
+	src io.Reader,
+	storedWriter *countingWriter,
+	backupProgressListener func(completedMBs float64),
+) error {
+	buf := make([]byte, 32*1024)
+	var lastReportedMB float64
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("copy cancelled: %w", ctx.Err())
+		default:
+		}
+
+		if config.IsShouldShutdown() {
+			return errors.New("copy cancelled due to shutdown")
+		}
+
+		bytesRead, readErr := src.Read(buf)
+		if bytesRead > 0 {
+			if _, err := dst.Write(buf[:bytesRead]); err != nil {
+				return err
+			}
+
+			currentSizeMB := float64(storedWriter.bytesWritten) / (1024 * 1024)
+			if backupProgressListener != nil && currentSizeMB >= lastReportedMB+1 {
+				backupProgressListener(currentSizeMB)
+				lastReportedMB = currentSizeMB
+			}
+		}
+
+		if readErr != nil {
+			if readErr != io.EOF {
+				return readErr
+			}
+
+			return nil
+		}
+	}
+}
+
+type countingWriter struct {
+	writer       io.Writer
+	bytesWritten int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.writer.Write(p)
+	cw.bytesWritten += int64(n)
+	return n, err
+}
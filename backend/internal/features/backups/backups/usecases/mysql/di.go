@@ -0,0 +1,21 @@
+package usecases_mysql
+
+import (
+	"postgresus-backend/internal/util/logger"
+)
+
+var createMysqlBackupUsecase = &CreateMysqlBackupUsecase{
+	logger.GetLogger(),
+}
+
+var verifyMysqlBackupUsecase = &VerifyMysqlBackupUsecase{
+	logger.GetLogger(),
+}
+
+func GetCreateMysqlBackupUsecase() *CreateMysqlBackupUsecase {
+	return createMysqlBackupUsecase
+}
+
+func GetVerifyMysqlBackupUsecase() *VerifyMysqlBackupUsecase {
+	return verifyMysqlBackupUsecase
+}
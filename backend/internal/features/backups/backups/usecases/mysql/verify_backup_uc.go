@@ -0,0 +1,91 @@
+package usecases_mysql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"postgresus-backend/internal/config"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+
+	"github.com/google/uuid"
+)
+
+// dump tools write the marker as the last line of complete dump
+const dumpCompletedMarker = "-- Dump completed"
+
+type VerifyMysqlBackupUsecase struct {
+	logger *slog.Logger
+}
+
+// Execute reads the whole decrypted backup through gzip, so gzip checksums
+// are checked, and requires the completion marker at the end of the dump
+func (uc *VerifyMysqlBackupUsecase) Execute(
+	backupID uuid.UUID,
+	backupType backups_config.BackupType,
+	backupConfig *backups_config.BackupConfig,
+	db *databases.Database,
+	backupReader io.Reader,
+	isTestRestore bool,
+) error {
+	if isTestRestore {
+		return errors.New("test restore is supported only for PostgreSQL")
+	}
+
+	uc.logger.Info("Verifying MySQL backup", "backupId", backupID)
+
+	gzipReader, err := gzip.NewReader(backupReader)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	defer func() {
+		_ = gzipReader.Close()
+	}()
+
+	tail := &tailWriter{size: 1024}
+	buf := make([]byte, 32*1024)
+
+	for {
+		if config.IsShouldShutdown() {
+			return errors.New("verification cancelled due to shutdown")
+		}
+
+		bytesRead, readErr := gzipReader.Read(buf)
+		if bytesRead > 0 {
+			_, _ = tail.Write(buf[:bytesRead])
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+
+		if readErr != nil {
+			return fmt.Errorf("failed to read backup: %w", readErr)
+		}
+	}
+
+	if !bytes.Contains(tail.data, []byte(dumpCompletedMarker)) {
+		return errors.New("backup is incomplete: dump completion marker is not found")
+	}
+
+	return nil
+}
+
+// tailWriter keeps only the last size bytes written
+type tailWriter struct {
+	size int
+	data []byte
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.data = append(w.data, p...)
+	if len(w.data) > w.size {
+		w.data = w.data[len(w.data)-w.size:]
+	}
+
+	return len(p), nil
+}
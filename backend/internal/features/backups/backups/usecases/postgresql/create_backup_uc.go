@@ -2,6 +2,8 @@ package usecases_postgresql
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -9,18 +11,34 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"postgresus-backend/internal/config"
+	usecases_common "postgresus-backend/internal/features/backups/backups/usecases/common"
 	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	backups_wal "postgresus-backend/internal/features/backups/wal"
 	"postgresus-backend/internal/features/databases"
 	pgtypes "postgresus-backend/internal/features/databases/databases/postgresql"
 	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/util/encryption"
 	"postgresus-backend/internal/util/tools"
+	"postgresus-backend/internal/util/tracing"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	walStartPointRegex = regexp.MustCompile(
+		`write-ahead log start point: ([0-9A-Fa-f]+/[0-9A-Fa-f]+) on timeline (\d+)`,
+	)
+	walStopPointRegex = regexp.MustCompile(
+		`write-ahead log end point: ([0-9A-Fa-f]+/[0-9A-Fa-f]+)`,
+	)
 )
 
 type CreatePostgresqlBackupUsecase struct {
@@ -29,14 +47,50 @@ type CreatePostgresqlBackupUsecase struct {
 
 // Execute creates a backup of the database
 func (uc *CreatePostgresqlBackupUsecase) Execute(
+	ctx context.Context,
 	backupID uuid.UUID,
 	backupConfig *backups_config.BackupConfig,
 	db *databases.Database,
 	storage *storages.Storage,
+	encryptionKey *backups_encryption.BackupEncryptionKey,
 	backupProgressListener func(
 		completedMBs float64,
 	),
-) error {
+) (*usecases_common.BackupMetadata, error) {
+	if !backupConfig.IsBackupsEnabled {
+		return nil, fmt.Errorf("backups are not enabled for this database: \"%s\"", db.Name)
+	}
+
+	pg := db.Postgresql
+
+	if pg == nil {
+		return nil, fmt.Errorf("postgresql database configuration is required for backups")
+	}
+
+	if backupConfig.BackupType == backups_config.BackupTypePhysical {
+		return uc.createBaseBackup(
+			ctx,
+			backupID,
+			backupConfig,
+			db,
+			storage,
+			encryptionKey,
+			backupProgressListener,
+		)
+	}
+
+	if pg.IsClusterMode {
+		return uc.createClusterBackup(
+			ctx,
+			backupID,
+			backupConfig,
+			db,
+			storage,
+			encryptionKey,
+			backupProgressListener,
+		)
+	}
+
 	uc.logger.Info(
 		"Creating PostgreSQL backup via pg_dump custom format",
 		"databaseId",
@@ -45,46 +99,93 @@ func (uc *CreatePostgresqlBackupUsecase) Execute(
 		storage.ID,
 	)
 
-	if !backupConfig.IsBackupsEnabled {
-		return fmt.Errorf("backups are not enabled for this database: \"%s\"", db.Name)
+	if pg.Database == nil || *pg.Database == "" {
+		return nil, fmt.Errorf("database name is required for pg_dump backups")
 	}
 
+	args := uc.getPgDumpArgs(pg, *pg.Database)
+	args = append(args, backupConfig.DumpFilters.GetPgDumpArgs()...)
+
+	_, checksum, err := uc.streamToStorage(
+		ctx,
+		backupID,
+		backupConfig,
+		tools.GetPostgresqlExecutable(
+			pg.Version,
+			"pg_dump",
+			config.GetEnv().EnvMode,
+			config.GetEnv().PostgresesInstallDir,
+		),
+		args,
+		pg.Password,
+		storage,
+		db,
+		encryptionKey,
+		backupProgressListener,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &usecases_common.BackupMetadata{Checksum: &checksum}, nil
+}
+
+// createBaseBackup creates physical backup via pg_basebackup. The backup
+// contains no WAL (-X none), WAL is archived by the WAL receiver through
+// replication slot, which is created before the backup starts
+func (uc *CreatePostgresqlBackupUsecase) createBaseBackup(
+	ctx context.Context,
+	backupID uuid.UUID,
+	backupConfig *backups_config.BackupConfig,
+	db *databases.Database,
+	storage *storages.Storage,
+	encryptionKey *backups_encryption.BackupEncryptionKey,
+	backupProgressListener func(completedMBs float64),
+) (*usecases_common.BackupMetadata, error) {
+	uc.logger.Info(
+		"Creating PostgreSQL base backup via pg_basebackup",
+		"databaseId",
+		db.ID,
+		"storageId",
+		storage.ID,
+	)
+
 	pg := db.Postgresql
 
-	if pg == nil {
-		return fmt.Errorf("postgresql database configuration is required for pg_dump backups")
+	if err := backups_wal.EnsureReplicationSlot(
+		uc.logger,
+		pg,
+		backups_wal.GetReplicationSlotName(db.ID),
+	); err != nil {
+		return nil, err
 	}
 
-	if pg.Database == nil || *pg.Database == "" {
-		return fmt.Errorf("database name is required for pg_dump backups")
+	walSegmentSize, err := backups_wal.GetWalSegmentSize(uc.logger, pg)
+	if err != nil {
+		return nil, err
 	}
 
 	args := []string{
-		"-Fc",           // custom format with built-in compression
-		"--no-password", // Use environment variable for password, prevent prompts
+		"-D", "-", // write tar to stdout
+		"-Ft",
+		"-X", "none", // WAL is archived by the WAL receiver
+		"-Z", "5",
+		"--checkpoint=fast",
+		"--no-manifest",
+		"--no-password",
 		"-h", pg.Host,
 		"-p", strconv.Itoa(pg.Port),
 		"-U", pg.Username,
-		"-d", *pg.Database,
-		"--verbose", // Add verbose output to help with debugging
-	}
-
-	// Use zstd compression level 5 for PostgreSQL 15+ (better compression and speed)
-	// Fall back to gzip compression level 5 for older versions
-	if pg.Version == tools.PostgresqlVersion13 || pg.Version == tools.PostgresqlVersion14 || pg.Version == tools.PostgresqlVersion15 {
-		args = append(args, "-Z", "5")
-		uc.logger.Info("Using gzip compression level 5 (zstd not available)", "version", pg.Version)
-	} else {
-		args = append(args, "--compress=zstd:5")
-		uc.logger.Info("Using zstd compression level 5", "version", pg.Version)
+		"--verbose", // required to get WAL start and end points
 	}
 
-	return uc.streamToStorage(
+	stderrOutput, checksum, err := uc.streamToStorage(
+		ctx,
 		backupID,
 		backupConfig,
 		tools.GetPostgresqlExecutable(
 			pg.Version,
-			"pg_dump",
+			tools.PostgresqlExecutablePgBasebackup,
 			config.GetEnv().EnvMode,
 			config.GetEnv().PostgresesInstallDir,
 		),
@@ -92,12 +193,195 @@ func (uc *CreatePostgresqlBackupUsecase) Execute(
 		pg.Password,
 		storage,
 		db,
+		encryptionKey,
 		backupProgressListener,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	startMatches := walStartPointRegex.FindStringSubmatch(stderrOutput)
+	stopMatches := walStopPointRegex.FindStringSubmatch(stderrOutput)
+	if len(startMatches) < 3 || len(stopMatches) < 2 {
+		return nil, fmt.Errorf(
+			"failed to parse WAL start and end points from pg_basebackup output: %s",
+			stderrOutput,
+		)
+	}
+
+	startLsn := startMatches[1]
+	stopLsn := stopMatches[1]
+
+	timeline, err := strconv.Atoi(startMatches[2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeline: %w", err)
+	}
+
+	startLsnPosition, err := backups_wal.ParseLsn(startLsn)
+	if err != nil {
+		return nil, err
+	}
+
+	stopLsnPosition, err := backups_wal.ParseLsn(stopLsn)
+	if err != nil {
+		return nil, err
+	}
+
+	startSegment := backups_wal.GetSegmentFileName(timeline, startLsnPosition, walSegmentSize)
+	stopSegment := backups_wal.GetSegmentFileName(timeline, stopLsnPosition, walSegmentSize)
+
+	uc.logger.Info(
+		"Base backup created",
+		"startLsn",
+		startLsn,
+		"stopLsn",
+		stopLsn,
+		"startSegment",
+		startSegment,
+		"stopSegment",
+		stopSegment,
+	)
+
+	return &usecases_common.BackupMetadata{
+		WalStartLsn:     &startLsn,
+		WalStopLsn:      &stopLsn,
+		WalStartSegment: &startSegment,
+		WalStopSegment:  &stopSegment,
+		Checksum:        &checksum,
+	}, nil
+}
+
+// createClusterBackup dumps globals via pg_dumpall into the backup file
+// and each included database via pg_dump into its own member file. If
+// any dump fails, already stored member files are removed
+func (uc *CreatePostgresqlBackupUsecase) createClusterBackup(
+	ctx context.Context,
+	backupID uuid.UUID,
+	backupConfig *backups_config.BackupConfig,
+	db *databases.Database,
+	storage *storages.Storage,
+	encryptionKey *backups_encryption.BackupEncryptionKey,
+	backupProgressListener func(completedMBs float64),
+) (*usecases_common.BackupMetadata, error) {
+	pg := db.Postgresql
+
+	databaseNames, err := pg.GetClusterDatabaseNames(uc.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(databaseNames) == 0 {
+		return nil, errors.New("no databases match include and exclude patterns")
+	}
+
+	uc.logger.Info(
+		"Creating PostgreSQL cluster backup via pg_dumpall and pg_dump",
+		"databaseId",
+		db.ID,
+		"storageId",
+		storage.ID,
+		"databases",
+		databaseNames,
+	)
+
+	// progress of each dump is reported on top of already dumped ones
+	var completedMBs float64
+	getProgressListener := func() func(float64) {
+		dumpStartMBs := completedMBs
+
+		return func(dumpCompletedMBs float64) {
+			completedMBs = dumpStartMBs + dumpCompletedMBs
+
+			if backupProgressListener != nil {
+				backupProgressListener(completedMBs)
+			}
+		}
+	}
+
+	globalsArgs := []string{
+		"--globals-only",
+		"--no-password",
+		"-h", pg.Host,
+		"-p", strconv.Itoa(pg.Port),
+		"-U", pg.Username,
+	}
+
+	if pg.Database != nil && *pg.Database != "" {
+		globalsArgs = append(globalsArgs, "-l", *pg.Database)
+	}
+
+	_, globalsChecksum, err := uc.streamToStorage(
+		ctx,
+		backupID,
+		backupConfig,
+		tools.GetPostgresqlExecutable(
+			pg.Version,
+			tools.PostgresqlExecutablePgDumpall,
+			config.GetEnv().EnvMode,
+			config.GetEnv().PostgresesInstallDir,
+		),
+		globalsArgs,
+		pg.Password,
+		storage,
+		db,
+		encryptionKey,
+		getProgressListener(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]usecases_common.BackupMemberMetadata, 0, len(databaseNames))
+
+	for _, databaseName := range databaseNames {
+		memberID := uuid.New()
+		dumpStartMBs := completedMBs
+
+		_, checksum, err := uc.streamToStorage(
+			ctx,
+			memberID,
+			backupConfig,
+			tools.GetPostgresqlExecutable(
+				pg.Version,
+				tools.PostgresqlExecutablePgDump,
+				config.GetEnv().EnvMode,
+				config.GetEnv().PostgresesInstallDir,
+			),
+			uc.getPgDumpArgs(pg, databaseName),
+			pg.Password,
+			storage,
+			db,
+			encryptionKey,
+			getProgressListener(),
+		)
+		if err != nil {
+			// the failed dump may be partially stored too
+			uc.deleteMemberFiles(storage, append(members, usecases_common.BackupMemberMetadata{
+				ID: memberID,
+			}))
+
+			return nil, fmt.Errorf("failed to dump database \"%s\": %w", databaseName, err)
+		}
+
+		members = append(members, usecases_common.BackupMemberMetadata{
+			ID:           memberID,
+			DatabaseName: databaseName,
+			BackupSizeMb: completedMBs - dumpStartMBs,
+			Checksum:     checksum,
+		})
+	}
+
+	return &usecases_common.BackupMetadata{
+		Checksum: &globalsChecksum,
+		Members:  members,
+	}, nil
 }
 
-// streamToStorage streams pg_dump output directly to storage
+// streamToStorage streams backup tool output directly to storage and
+// returns its stderr output (e.g. for parsing WAL positions) and SHA-256
+// checksum of the stored (compressed and, if enabled, encrypted) data
 func (uc *CreatePostgresqlBackupUsecase) streamToStorage(
+	ctx context.Context,
 	backupID uuid.UUID,
 	backupConfig *backups_config.BackupConfig,
 	pgBin string,
@@ -105,13 +389,14 @@ func (uc *CreatePostgresqlBackupUsecase) streamToStorage(
 	password string,
 	storage *storages.Storage,
 	db *databases.Database,
+	encryptionKey *backups_encryption.BackupEncryptionKey,
 	backupProgressListener func(completedMBs float64),
-) error {
+) (string, string, error) {
 	uc.logger.Info("Streaming PostgreSQL backup to storage", "pgBin", pgBin, "args", args)
 
 	// if backup not fit into 23 hours, Postgresus
 	// seems not to work for such database size
-	ctx, cancel := context.WithTimeout(context.Background(), 23*time.Hour)
+	ctx, cancel := context.WithTimeout(ctx, 23*time.Hour)
 	defer cancel()
 
 	// Monitor for shutdown and cancel context if needed
@@ -133,9 +418,9 @@ func (uc *CreatePostgresqlBackupUsecase) streamToStorage(
 	}()
 
 	// Create temporary .pgpass file as a more reliable alternative to PGPASSWORD
-	pgpassFile, err := uc.createTempPgpassFile(db.Postgresql, password)
+	pgpassFile, err := createTempPgpassFile(db.Postgresql, password)
 	if err != nil {
-		return fmt.Errorf("failed to create temporary .pgpass file: %w", err)
+		return "", "", fmt.Errorf("failed to create temporary .pgpass file: %w", err)
 	}
 	defer func() {
 		if pgpassFile != "" {
@@ -145,7 +430,7 @@ func (uc *CreatePostgresqlBackupUsecase) streamToStorage(
 
 	// Verify .pgpass file was created successfully
 	if pgpassFile == "" {
-		return fmt.Errorf("temporary .pgpass file was not created")
+		return "", "", fmt.Errorf("temporary .pgpass file was not created")
 	}
 
 	// Verify .pgpass file was created correctly
@@ -156,7 +441,7 @@ func (uc *CreatePostgresqlBackupUsecase) streamToStorage(
 			"mode", info.Mode(),
 		)
 	} else {
-		return fmt.Errorf("failed to verify .pgpass file: %w", err)
+		return "", "", fmt.Errorf("failed to verify .pgpass file: %w", err)
 	}
 
 	cmd := exec.CommandContext(ctx, pgBin, args...)
@@ -209,7 +494,7 @@ func (uc *CreatePostgresqlBackupUsecase) streamToStorage(
 
 	// Verify executable exists and is accessible
 	if _, err := exec.LookPath(pgBin); err != nil {
-		return fmt.Errorf(
+		return "", "", fmt.Errorf(
 			"PostgreSQL executable not found or not accessible: %s - %w",
 			pgBin,
 			err,
@@ -218,12 +503,12 @@ func (uc *CreatePostgresqlBackupUsecase) streamToStorage(
 
 	pgStdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return fmt.Errorf("stdout pipe: %w", err)
+		return "", "", fmt.Errorf("stdout pipe: %w", err)
 	}
 
 	pgStderr, err := cmd.StderrPipe()
 	if err != nil {
-		return fmt.Errorf("stderr pipe: %w", err)
+		return "", "", fmt.Errorf("stderr pipe: %w", err)
 	}
 
 	// Capture stderr in a separate goroutine to ensure we don't miss any error output
@@ -236,22 +521,49 @@ func (uc *CreatePostgresqlBackupUsecase) streamToStorage(
 	// A pipe connecting pg_dump output → storage
 	storageReader, storageWriter := io.Pipe()
 
+	// Encrypt the stream (if enabled) before it reaches any storage
+	var dumpWriter io.WriteCloser = storageWriter
+	if encryptionKey != nil {
+		dumpWriter, err = encryption.NewEncryptingWriter(storageWriter, encryptionKey.Key)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to create encrypting writer: %w", err)
+		}
+
+		uc.logger.Info("Encrypting backup", "encryptionKeyId", encryptionKey.ID)
+	}
+
 	// Create a counting writer to track bytes
-	countingWriter := &CountingWriter{writer: storageWriter}
+	countingWriter := &CountingWriter{writer: dumpWriter}
 
 	// The backup ID becomes the object key / filename in storage
 
+	// Hash exactly the bytes the storage receives, so
+	// the stored file can be verified without decryption
+	checksumHasher := sha256.New()
+
 	// Start streaming into storage in its own goroutine
 	saveErrCh := make(chan error, 1)
 	go func() {
-		saveErrCh <- storage.SaveFile(uc.logger, backupID, storageReader)
+		saveErrCh <- storage.SaveFile(
+			ctx,
+			uc.logger,
+			backupID,
+			io.TeeReader(storageReader, checksumHasher),
+		)
 	}()
 
 	// Start pg_dump
 	if err = cmd.Start(); err != nil {
-		return fmt.Errorf("start %s: %w", filepath.Base(pgBin), err)
+		return "", "", fmt.Errorf("start %s: %w", filepath.Base(pgBin), err)
 	}
 
+	_, dumpSpan := tracing.StartSpan(
+		ctx,
+		"backup.dump",
+		attribute.String("tool", filepath.Base(pgBin)),
+	)
+	dumpStartedAt := time.Now().UTC()
+
 	// Copy pg output directly to storage with shutdown checks
 	copyResultCh := make(chan error, 1)
 	bytesWrittenCh := make(chan int64, 1)
@@ -271,25 +583,31 @@ func (uc *CreatePostgresqlBackupUsecase) streamToStorage(
 	bytesWritten := <-bytesWrittenCh
 	waitErr := cmd.Wait()
 
+	tracing.SetThroughput(dumpSpan, bytesWritten, time.Since(dumpStartedAt))
+	tracing.EndSpan(dumpSpan, waitErr)
+
 	// Check for shutdown before finalizing
 	if config.IsShouldShutdown() {
-		if pipeWriter, ok := countingWriter.writer.(*io.PipeWriter); ok {
-			if err := pipeWriter.Close(); err != nil {
-				uc.logger.Error("Failed to close counting writer", "error", err)
-			}
+		if err := storageWriter.Close(); err != nil {
+			uc.logger.Error("Failed to close counting writer", "error", err)
 		}
 
 		<-saveErrCh // Wait for storage to finish
-		return fmt.Errorf("backup cancelled due to shutdown")
+		return "", "", fmt.Errorf("backup cancelled due to shutdown")
 	}
 
-	// Close the pipe writer to signal end of data
-	if pipeWriter, ok := countingWriter.writer.(*io.PipeWriter); ok {
-		if err := pipeWriter.Close(); err != nil {
-			uc.logger.Error("Failed to close counting writer", "error", err)
+	// Flush the last encrypted chunk (no-op for plain pipe)
+	// and close the pipe writer to signal end of data
+	if dumpWriter != storageWriter {
+		if err := dumpWriter.Close(); err != nil && copyErr == nil {
+			copyErr = err
 		}
 	}
 
+	if err := storageWriter.Close(); err != nil {
+		uc.logger.Error("Failed to close counting writer", "error", err)
+	}
+
 	// Wait until storage ends reading
 	saveErr := <-saveErrCh
 	stderrOutput := <-stderrCh
@@ -303,7 +621,7 @@ func (uc *CreatePostgresqlBackupUsecase) streamToStorage(
 	switch {
 	case waitErr != nil:
 		if config.IsShouldShutdown() {
-			return fmt.Errorf("backup cancelled due to shutdown")
+			return "", "", fmt.Errorf("backup cancelled due to shutdown")
 		}
 
 		// Enhanced error handling for PostgreSQL connection and SSL issues
@@ -399,22 +717,60 @@ func (uc *CreatePostgresqlBackupUsecase) streamToStorage(
 			}
 		}
 
-		return errors.New(errorMsg)
+		return "", "", errors.New(errorMsg)
 	case copyErr != nil:
 		if config.IsShouldShutdown() {
-			return fmt.Errorf("backup cancelled due to shutdown")
+			return "", "", fmt.Errorf("backup cancelled due to shutdown")
 		}
 
-		return fmt.Errorf("copy to storage: %w", copyErr)
+		return "", "", fmt.Errorf("copy to storage: %w", copyErr)
 	case saveErr != nil:
 		if config.IsShouldShutdown() {
-			return fmt.Errorf("backup cancelled due to shutdown")
+			return "", "", fmt.Errorf("backup cancelled due to shutdown")
 		}
 
-		return fmt.Errorf("save to storage: %w", saveErr)
+		return "", "", fmt.Errorf("save to storage: %w", saveErr)
 	}
 
-	return nil
+	return string(stderrOutput), hex.EncodeToString(checksumHasher.Sum(nil)), nil
+}
+
+func (uc *CreatePostgresqlBackupUsecase) getPgDumpArgs(
+	pg *pgtypes.PostgresqlDatabase,
+	databaseName string,
+) []string {
+	args := []string{
+		"-Fc",           // custom format with built-in compression
+		"--no-password", // Use environment variable for password, prevent prompts
+		"-h", pg.Host,
+		"-p", strconv.Itoa(pg.Port),
+		"-U", pg.Username,
+		"-d", databaseName,
+		"--verbose", // Add verbose output to help with debugging
+	}
+
+	// Use zstd compression level 5 for PostgreSQL 15+ (better compression and speed)
+	// Fall back to gzip compression level 5 for older versions
+	if pg.Version == tools.PostgresqlVersion13 || pg.Version == tools.PostgresqlVersion14 || pg.Version == tools.PostgresqlVersion15 {
+		args = append(args, "-Z", "5")
+		uc.logger.Info("Using gzip compression level 5 (zstd not available)", "version", pg.Version)
+	} else {
+		args = append(args, "--compress=zstd:5")
+		uc.logger.Info("Using zstd compression level 5", "version", pg.Version)
+	}
+
+	return args
+}
+
+func (uc *CreatePostgresqlBackupUsecase) deleteMemberFiles(
+	storage *storages.Storage,
+	members []usecases_common.BackupMemberMetadata,
+) {
+	for _, member := range members {
+		if err := storage.DeleteFile(member.ID); err != nil {
+			uc.logger.Error("Failed to delete backup member file", "fileId", member.ID, "error", err)
+		}
+	}
 }
 
 // copyWithShutdownCheck copies data from src to dst while checking for shutdown
@@ -492,7 +848,7 @@ func containsIgnoreCase(str, substr string) bool {
 }
 
 // createTempPgpassFile creates a temporary .pgpass file with the given password
-func (uc *CreatePostgresqlBackupUsecase) createTempPgpassFile(
+func createTempPgpassFile(
 	pgConfig *pgtypes.PostgresqlDatabase,
 	password string,
 ) (string, error) {
@@ -8,6 +8,14 @@ var createPostgresqlBackupUsecase = &CreatePostgresqlBackupUsecase{
 	logger.GetLogger(),
 }
 
+var verifyPostgresqlBackupUsecase = &VerifyPostgresqlBackupUsecase{
+	logger.GetLogger(),
+}
+
 func GetCreatePostgresqlBackupUsecase() *CreatePostgresqlBackupUsecase {
 	return createPostgresqlBackupUsecase
 }
+
+func GetVerifyPostgresqlBackupUsecase() *VerifyPostgresqlBackupUsecase {
+	return verifyPostgresqlBackupUsecase
+}
@@ -0,0 +1,349 @@
+package usecases_postgresql
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"postgresus-backend/internal/config"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	pgtypes "postgresus-backend/internal/features/databases/databases/postgresql"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/util/tools"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type VerifyPostgresqlBackupUsecase struct {
+	logger *slog.Logger
+}
+
+// Execute reads the whole decrypted backup data and checks that it is
+// readable: logical backup is listed via pg_restore --list, physical
+// backup is read through as gzipped tar. If isTestRestore is set, logical
+// backup is also restored into the test restore database of the config
+// and the sanity SQL is run over it
+func (uc *VerifyPostgresqlBackupUsecase) Execute(
+	backupID uuid.UUID,
+	backupType backups_config.BackupType,
+	backupConfig *backups_config.BackupConfig,
+	db *databases.Database,
+	backupReader io.Reader,
+	isTestRestore bool,
+) error {
+	if db.Postgresql == nil {
+		return errors.New("postgresql database configuration is required for verification")
+	}
+
+	// if verification not fit into 23 hours, the
+	// backup cannot be restored in reasonable time too
+	ctx, cancel := context.WithTimeout(context.Background(), 23*time.Hour)
+	defer cancel()
+
+	// Monitor for shutdown and cancel context if needed
+	go func() {
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if config.IsShouldShutdown() {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	if backupType == backups_config.BackupTypePhysical {
+		if isTestRestore {
+			return errors.New("test restore is supported only for logical backups")
+		}
+
+		return uc.verifyBaseBackup(backupReader)
+	}
+
+	uc.logger.Info("Verifying PostgreSQL backup via pg_restore --list", "backupId", backupID)
+
+	tempBackupFile, cleanupFunc, err := uc.writeBackupToTempFile(backupReader)
+	if err != nil {
+		return err
+	}
+	defer cleanupFunc()
+
+	pgRestoreBin := tools.GetPostgresqlExecutable(
+		db.Postgresql.Version,
+		"pg_restore",
+		config.GetEnv().EnvMode,
+		config.GetEnv().PostgresesInstallDir,
+	)
+
+	if err := uc.executePgRestore(
+		ctx,
+		pgRestoreBin,
+		[]string{"--list", tempBackupFile},
+		nil,
+	); err != nil {
+		return err
+	}
+
+	if !isTestRestore {
+		return nil
+	}
+
+	return uc.testRestore(ctx, pgRestoreBin, tempBackupFile, backupID, backupConfig)
+}
+
+// verifyBaseBackup reads all tar entries, so both gzip
+// checksums and tar structure of the whole stream are checked
+func (uc *VerifyPostgresqlBackupUsecase) verifyBaseBackup(backupReader io.Reader) error {
+	gzipReader, err := gzip.NewReader(backupReader)
+	if err != nil {
+		return fmt.Errorf("failed to read base backup: %w", err)
+	}
+	defer func() {
+		_ = gzipReader.Close()
+	}()
+
+	tarReader := tar.NewReader(gzipReader)
+
+	for {
+		if config.IsShouldShutdown() {
+			return errors.New("verification cancelled due to shutdown")
+		}
+
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read base backup: %w", err)
+		}
+
+		if _, err := io.Copy(io.Discard, tarReader); err != nil {
+			return fmt.Errorf("failed to read base backup file %s: %w", header.Name, err)
+		}
+	}
+
+	if _, err := io.Copy(io.Discard, gzipReader); err != nil {
+		return fmt.Errorf("failed to read base backup: %w", err)
+	}
+
+	return nil
+}
+
+// testRestore restores the backup into throwaway database (dropping
+// objects restored there previously) and runs the sanity SQL over it
+func (uc *VerifyPostgresqlBackupUsecase) testRestore(
+	ctx context.Context,
+	pgRestoreBin string,
+	tempBackupFile string,
+	backupID uuid.UUID,
+	backupConfig *backups_config.BackupConfig,
+) error {
+	pg := backupConfig.TestRestorePostgresql
+	if pg == nil || pg.Database == nil || *pg.Database == "" {
+		return errors.New("test restore database is not configured")
+	}
+
+	uc.logger.Info(
+		"Test restoring PostgreSQL backup",
+		"backupId",
+		backupID,
+		"host",
+		pg.Host,
+		"database",
+		*pg.Database,
+	)
+
+	// Cap parallel jobs between 1 and 8 as restores do
+	parallelJobs := max(1, min(backupConfig.CpuCount, 8))
+
+	args := []string{
+		"-Fc",
+		"-j", strconv.Itoa(parallelJobs),
+		"--no-password",
+		"-h", pg.Host,
+		"-p", strconv.Itoa(pg.Port),
+		"-U", pg.Username,
+		"-d", *pg.Database,
+		"--clean",
+		"--if-exists",
+		"--no-owner",
+		tempBackupFile,
+	}
+
+	if err := uc.executePgRestore(ctx, pgRestoreBin, args, pg); err != nil {
+		return fmt.Errorf("test restore failed: %w", err)
+	}
+
+	if backupConfig.TestRestoreSanitySql == nil || *backupConfig.TestRestoreSanitySql == "" {
+		return nil
+	}
+
+	if err := uc.runSanitySql(ctx, pg, *backupConfig.TestRestoreSanitySql); err != nil {
+		return fmt.Errorf("sanity SQL failed after test restore: %w", err)
+	}
+
+	return nil
+}
+
+// runSanitySql fails if the SQL fails or its first returned value is false
+func (uc *VerifyPostgresqlBackupUsecase) runSanitySql(
+	ctx context.Context,
+	pg *pgtypes.PostgresqlDatabase,
+	sanitySql string,
+) error {
+	conn, err := pgx.Connect(ctx, pg.GetConnectionString())
+	if err != nil {
+		return fmt.Errorf("failed to connect to test restore database: %w", err)
+	}
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			uc.logger.Error("Failed to close connection", "error", err)
+		}
+	}()
+
+	rows, err := conn.Query(ctx, sanitySql)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return err
+		}
+
+		if len(values) > 0 {
+			if isPassed, ok := values[0].(bool); ok && !isPassed {
+				return errors.New("sanity SQL returned false")
+			}
+		}
+	}
+
+	rows.Close()
+
+	return rows.Err()
+}
+
+// executePgRestore runs pg_restore. Connection config is
+// passed only for commands which connect to a database
+func (uc *VerifyPostgresqlBackupUsecase) executePgRestore(
+	ctx context.Context,
+	pgBin string,
+	args []string,
+	pg *pgtypes.PostgresqlDatabase,
+) error {
+	if _, err := exec.LookPath(pgBin); err != nil {
+		return fmt.Errorf(
+			"PostgreSQL executable not found or not accessible: %s - %w",
+			pgBin,
+			err,
+		)
+	}
+
+	cmd := exec.CommandContext(ctx, pgBin, args...)
+	cmd.Env = os.Environ()
+	// pg_restore --list output is not needed, errors go to stderr
+	var stderr bytes.Buffer
+	cmd.Stdout = io.Discard
+	cmd.Stderr = &stderr
+
+	if pg != nil {
+		pgpassFile, err := createTempPgpassFile(pg, pg.Password)
+		if err != nil {
+			return fmt.Errorf("failed to create temporary .pgpass file: %w", err)
+		}
+
+		if pgpassFile != "" {
+			defer func() {
+				_ = os.RemoveAll(filepath.Dir(pgpassFile))
+			}()
+
+			cmd.Env = append(cmd.Env, "PGPASSFILE="+pgpassFile)
+		}
+
+		cmd.Env = append(cmd.Env, "PGCONNECT_TIMEOUT=30")
+
+		if pg.IsHttps {
+			cmd.Env = append(cmd.Env, "PGSSLMODE=require")
+		} else {
+			cmd.Env = append(cmd.Env, "PGSSLMODE=prefer")
+		}
+	}
+
+	uc.logger.Info("Executing PostgreSQL verification command", "command", cmd.String())
+
+	if err := cmd.Run(); err != nil {
+		if config.IsShouldShutdown() {
+			return errors.New("verification cancelled due to shutdown")
+		}
+
+		return fmt.Errorf(
+			"%s failed: %v – stderr: %s",
+			filepath.Base(pgBin),
+			err,
+			stderr.String(),
+		)
+	}
+
+	return nil
+}
+
+func (uc *VerifyPostgresqlBackupUsecase) writeBackupToTempFile(
+	backupReader io.Reader,
+) (string, func(), error) {
+	if err := storages.EnsureSystemDirectories(); err != nil {
+		return "", nil, fmt.Errorf("failed to ensure system directories: %w", err)
+	}
+
+	tempDir, err := os.MkdirTemp(config.GetEnv().TempFolder, "verify_"+uuid.New().String())
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	cleanupFunc := func() {
+		_ = os.RemoveAll(tempDir)
+	}
+
+	tempBackupFile := filepath.Join(tempDir, "backup.dump")
+
+	tempFile, err := os.Create(tempBackupFile)
+	if err != nil {
+		cleanupFunc()
+		return "", nil, fmt.Errorf("failed to create temporary backup file: %w", err)
+	}
+
+	_, copyErr := io.Copy(tempFile, backupReader)
+	closeErr := tempFile.Close()
+
+	if copyErr != nil {
+		cleanupFunc()
+		return "", nil, fmt.Errorf("failed to read backup: %w", copyErr)
+	}
+
+	if closeErr != nil {
+		cleanupFunc()
+		return "", nil, fmt.Errorf("failed to write temporary backup file: %w", closeErr)
+	}
+
+	return tempBackupFile, cleanupFunc, nil
+}
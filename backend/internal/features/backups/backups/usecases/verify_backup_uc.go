@@ -0,0 +1,64 @@
+package usecases
+
+import (
+	"errors"
+	"io"
+	usecases_mongodb "postgresus-backend/internal/features/backups/backups/usecases/mongodb"
+	usecases_mysql "postgresus-backend/internal/features/backups/backups/usecases/mysql"
+	usecases_postgresql "postgresus-backend/internal/features/backups/backups/usecases/postgresql"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+
+	"github.com/google/uuid"
+)
+
+type VerifyBackupUsecase struct {
+	VerifyPostgresqlBackupUsecase *usecases_postgresql.VerifyPostgresqlBackupUsecase
+	VerifyMysqlBackupUsecase      *usecases_mysql.VerifyMysqlBackupUsecase
+	VerifyMongodbBackupUsecase    *usecases_mongodb.VerifyMongodbBackupUsecase
+}
+
+// Execute checks that the decrypted backup data can be restored from
+func (uc *VerifyBackupUsecase) Execute(
+	backupID uuid.UUID,
+	backupType backups_config.BackupType,
+	backupConfig *backups_config.BackupConfig,
+	database *databases.Database,
+	backupReader io.Reader,
+	isTestRestore bool,
+) error {
+	if database.Type == databases.DatabaseTypePostgres {
+		return uc.VerifyPostgresqlBackupUsecase.Execute(
+			backupID,
+			backupType,
+			backupConfig,
+			database,
+			backupReader,
+			isTestRestore,
+		)
+	}
+
+	if database.Type == databases.DatabaseTypeMysql {
+		return uc.VerifyMysqlBackupUsecase.Execute(
+			backupID,
+			backupType,
+			backupConfig,
+			database,
+			backupReader,
+			isTestRestore,
+		)
+	}
+
+	if database.Type == databases.DatabaseTypeMongodb {
+		return uc.VerifyMongodbBackupUsecase.Execute(
+			backupID,
+			backupType,
+			backupConfig,
+			database,
+			backupReader,
+			isTestRestore,
+		)
+	}
+
+	return errors.New("database type not supported")
+}
@@ -0,0 +1,283 @@
+package backups
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"postgresus-backend/internal/config"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/util/encryption"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VerifyBackups verifies completed backups which were not verified
+// yet and runs test restores which are due by their schedules
+func (s *BackupService) VerifyBackups() error {
+	notVerifiedBackups, err := s.backupRepository.FindNotVerified()
+	if err != nil {
+		return err
+	}
+
+	for _, backup := range notVerifiedBackups {
+		if config.IsShouldShutdown() {
+			return nil
+		}
+
+		backupConfig, err := s.backupConfigService.GetBackupConfigByDbId(backup.DatabaseID)
+		if err != nil {
+			s.logger.Error("Failed to get backup config by database ID", "error", err)
+			continue
+		}
+
+		s.verifyBackup(backupConfig, backup, false)
+	}
+
+	return s.runPendingTestRestores()
+}
+
+func (s *BackupService) runPendingTestRestores() error {
+	enabledBackupConfigs, err := s.backupConfigService.GetBackupConfigsWithEnabledBackups()
+	if err != nil {
+		return err
+	}
+
+	for _, backupConfig := range enabledBackupConfigs {
+		if config.IsShouldShutdown() {
+			return nil
+		}
+
+		if !backupConfig.IsTestRestoreEnabled || backupConfig.TestRestoreInterval == nil {
+			continue
+		}
+
+		lastTestRestoredBackup, err := s.backupRepository.FindLastTestRestoredByDatabaseID(
+			backupConfig.DatabaseID,
+		)
+		if err != nil {
+			s.logger.Error(
+				"Failed to get last test restored backup",
+				"databaseId",
+				backupConfig.DatabaseID,
+				"error",
+				err,
+			)
+			continue
+		}
+
+		var lastTestRestoreTime *time.Time
+		if lastTestRestoredBackup != nil {
+			lastTestRestoreTime = lastTestRestoredBackup.VerifiedAt
+		}
+
+		if !backupConfig.TestRestoreInterval.ShouldTriggerBackup(
+			time.Now().UTC(),
+			lastTestRestoreTime,
+		) {
+			continue
+		}
+
+		backup, err := s.backupRepository.FindLastCompletedByType(
+			backupConfig.DatabaseID,
+			backups_config.BackupTypeLogical,
+		)
+		if err != nil {
+			s.logger.Error(
+				"Failed to get last completed backup",
+				"databaseId",
+				backupConfig.DatabaseID,
+				"error",
+				err,
+			)
+			continue
+		}
+
+		// test restore of cluster backups is not supported
+		if backup == nil || backup.IsCluster() {
+			continue
+		}
+
+		s.verifyBackup(backupConfig, backup, true)
+	}
+
+	return nil
+}
+
+// verifyBackup records verification result on the backup
+// and notifies about corrupted backups
+func (s *BackupService) verifyBackup(
+	backupConfig *backups_config.BackupConfig,
+	backup *Backup,
+	isTestRestore bool,
+) {
+	s.logger.Info("Verifying backup", "backupId", backup.ID, "isTestRestore", isTestRestore)
+
+	verifyErr := s.checkBackupIntegrity(backupConfig, backup, isTestRestore)
+
+	// interrupted verification is repeated after restart
+	if config.IsShouldShutdown() {
+		return
+	}
+
+	verifiedAt := time.Now().UTC()
+	backup.VerifiedAt = &verifiedAt
+	backup.IsTestRestored = isTestRestore
+
+	if verifyErr != nil {
+		verificationMessage := verifyErr.Error()
+		verificationStatus := BackupVerificationStatusCorrupted
+		backup.VerificationStatus = &verificationStatus
+		backup.VerificationMessage = &verificationMessage
+	} else {
+		verificationStatus := BackupVerificationStatusVerified
+		backup.VerificationStatus = &verificationStatus
+		backup.VerificationMessage = nil
+	}
+
+	if err := s.backupRepository.UpdateVerification(backup); err != nil {
+		s.logger.Error("Failed to save backup verification", "backupId", backup.ID, "error", err)
+		return
+	}
+
+	if verifyErr == nil {
+		s.logger.Info("Backup verified", "backupId", backup.ID)
+		return
+	}
+
+	s.logger.Warn("Backup verification failed", "backupId", backup.ID, "error", verifyErr)
+
+	s.SendBackupNotification(
+		backupConfig,
+		backup,
+		backups_config.NotificationBackupVerificationFailed,
+		backup.VerificationMessage,
+	)
+}
+
+// checkBackupIntegrity checks each file of the backup. Globals of cluster
+// backup are plain SQL, so only their checksum is checked
+func (s *BackupService) checkBackupIntegrity(
+	backupConfig *backups_config.BackupConfig,
+	backup *Backup,
+	isTestRestore bool,
+) error {
+	database, err := s.databaseService.GetDatabaseByID(backup.DatabaseID)
+	if err != nil {
+		return fmt.Errorf("failed to get database: %w", err)
+	}
+
+	if !backup.IsCluster() {
+		return s.checkBackupFileIntegrity(
+			backup,
+			backup.ID,
+			backup.Checksum,
+			func(backupDataReader io.Reader) error {
+				return s.verifyBackupUseCase.Execute(
+					backup.ID,
+					backup.Type,
+					backupConfig,
+					database,
+					backupDataReader,
+					isTestRestore,
+				)
+			},
+		)
+	}
+
+	if isTestRestore {
+		return errors.New("test restore is not supported for cluster backups")
+	}
+
+	if err := s.checkBackupFileIntegrity(backup, backup.ID, backup.Checksum, nil); err != nil {
+		return fmt.Errorf("globals: %w", err)
+	}
+
+	for _, member := range backup.Members {
+		if err := s.checkBackupFileIntegrity(
+			backup,
+			member.ID,
+			member.Checksum,
+			func(backupDataReader io.Reader) error {
+				return s.verifyBackupUseCase.Execute(
+					member.ID,
+					backup.Type,
+					backupConfig,
+					database,
+					backupDataReader,
+					false,
+				)
+			},
+		); err != nil {
+			return fmt.Errorf("database \"%s\": %w", member.DatabaseName, err)
+		}
+	}
+
+	return nil
+}
+
+// checkBackupFileIntegrity reads the stored file once: its checksum is
+// computed over the raw data while verifyData checks decrypted data
+func (s *BackupService) checkBackupFileIntegrity(
+	backup *Backup,
+	fileID uuid.UUID,
+	expectedChecksum *string,
+	verifyData func(backupDataReader io.Reader) error,
+) error {
+	file, _, err := s.openBackupFile(context.Background(), backup, fileID)
+	if err != nil {
+		return fmt.Errorf("failed to read backup file: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			s.logger.Error("Failed to close backup file", "fileId", fileID, "error", err)
+		}
+	}()
+
+	checksumHasher := sha256.New()
+	storedDataReader := io.TeeReader(file, checksumHasher)
+
+	if verifyData != nil {
+		backupDataReader := storedDataReader
+		if backup.Encryption == backups_config.BackupEncryptionAES256GCM &&
+			backup.EncryptionKeyID != nil {
+			encryptionKey, err := s.backupEncryptionKeyService.GetKeyByID(*backup.EncryptionKeyID)
+			if err != nil {
+				return fmt.Errorf("failed to get backup encryption key: %w", err)
+			}
+
+			backupDataReader, err = encryption.NewDecryptingReader(
+				storedDataReader,
+				encryptionKey.Key,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt backup: %w", err)
+			}
+		}
+
+		if err := verifyData(backupDataReader); err != nil {
+			return err
+		}
+	}
+
+	// verification may stop reading before the end of the file
+	if _, err := io.Copy(io.Discard, storedDataReader); err != nil {
+		return fmt.Errorf("failed to read backup file: %w", err)
+	}
+
+	if expectedChecksum != nil {
+		checksum := hex.EncodeToString(checksumHasher.Sum(nil))
+		if checksum != *expectedChecksum {
+			return fmt.Errorf(
+				"backup file checksum mismatch: expected %s, got %s",
+				*expectedChecksum,
+				checksum,
+			)
+		}
+	}
+
+	return nil
+}
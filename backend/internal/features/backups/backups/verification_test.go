@@ -0,0 +1,209 @@
+package backups
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	backups_wal "postgresus-backend/internal/features/backups/wal"
+	"postgresus-backend/internal/features/databases"
+	"postgresus-backend/internal/features/notifiers"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/util/logger"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_VerifyBackup_ChecksumChecked(t *testing.T) {
+	user := users.GetTestUser()
+	storage := storages.CreateTestStorage(user.UserID)
+	notifier := notifiers.CreateTestNotifier(user.UserID)
+	database := databases.CreateTestDatabase(user.UserID, storage, notifier)
+	backupConfig := backups_config.EnableBackupsForTestDatabase(database.ID, storage)
+	backupConfig.SendNotificationsOn = append(
+		backupConfig.SendNotificationsOn,
+		backups_config.NotificationBackupVerificationFailed,
+	)
+
+	defer storages.RemoveTestStorage(storage.ID)
+	defer notifiers.RemoveTestNotifier(notifier)
+	defer databases.RemoveTestDatabase(database)
+
+	content := []byte("backup content")
+
+	t.Run("ChecksumMatches_BackupVerified", func(t *testing.T) {
+		mockNotificationSender := &MockNotificationSender{}
+		backupService := createVerificationTestService(mockNotificationSender)
+
+		backup := createTestBackupFile(t, database, storage, content, getTestChecksum(content))
+		defer removeTestBackupFile(storage, backup)
+
+		backupService.verifyBackup(backupConfig, backup, false)
+
+		savedBackup, err := backupRepository.FindByID(backup.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, BackupVerificationStatusVerified, *savedBackup.VerificationStatus)
+		assert.Nil(t, savedBackup.VerificationMessage)
+		assert.NotNil(t, savedBackup.VerifiedAt)
+
+		mockNotificationSender.AssertNotCalled(
+			t,
+			"SendNotification",
+			mock.Anything,
+			mock.Anything,
+			mock.Anything,
+		)
+	})
+
+	t.Run("ChecksumMismatch_BackupCorruptedAndNotificationSent", func(t *testing.T) {
+		mockNotificationSender := &MockNotificationSender{}
+		backupService := createVerificationTestService(mockNotificationSender)
+
+		// stored file was changed after the backup was made
+		backup := createTestBackupFile(
+			t,
+			database,
+			storage,
+			content,
+			getTestChecksum([]byte("original backup content")),
+		)
+		defer removeTestBackupFile(storage, backup)
+
+		mockNotificationSender.On("SendNotification",
+			mock.Anything,
+			mock.MatchedBy(func(title string) bool {
+				return strings.Contains(title, "⚠️ Backup verification failed")
+			}),
+			mock.MatchedBy(func(message string) bool {
+				return strings.Contains(message, "checksum mismatch")
+			}),
+		).Once()
+
+		backupService.verifyBackup(backupConfig, backup, false)
+
+		savedBackup, err := backupRepository.FindByID(backup.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, BackupVerificationStatusCorrupted, *savedBackup.VerificationStatus)
+		assert.Contains(t, *savedBackup.VerificationMessage, "checksum mismatch")
+
+		mockNotificationSender.AssertExpectations(t)
+	})
+}
+
+func Test_FindNotVerified_BackupsWithoutChecksumSkipped(t *testing.T) {
+	user := users.GetTestUser()
+	storage := storages.CreateTestStorage(user.UserID)
+	notifier := notifiers.CreateTestNotifier(user.UserID)
+	database := databases.CreateTestDatabase(user.UserID, storage, notifier)
+
+	defer storages.RemoveTestStorage(storage.ID)
+	defer notifiers.RemoveTestNotifier(notifier)
+	defer databases.RemoveTestDatabase(database)
+
+	content := []byte("backup content")
+
+	// backup made before checksums were introduced
+	oldBackup := createTestBackupFile(t, database, storage, content, nil)
+	defer removeTestBackupFile(storage, oldBackup)
+
+	newBackup := createTestBackupFile(t, database, storage, content, getTestChecksum(content))
+	defer removeTestBackupFile(storage, newBackup)
+
+	notVerifiedBackups, err := backupRepository.FindNotVerified()
+	assert.NoError(t, err)
+
+	notVerifiedBackupIDs := make([]uuid.UUID, 0, len(notVerifiedBackups))
+	for _, backup := range notVerifiedBackups {
+		notVerifiedBackupIDs = append(notVerifiedBackupIDs, backup.ID)
+	}
+
+	assert.Contains(t, notVerifiedBackupIDs, newBackup.ID)
+	assert.NotContains(t, notVerifiedBackupIDs, oldBackup.ID)
+}
+
+func createVerificationTestService(notificationSender NotificationSender) *BackupService {
+	return &BackupService{
+		databases.GetDatabaseService(),
+		storages.GetStorageService(),
+		backupRepository,
+		&BackupCopyRepository{},
+		notifiers.GetNotifierService(),
+		notificationSender,
+		backups_config.GetBackupConfigService(),
+		backups_encryption.GetBackupEncryptionKeyService(),
+		backups_wal.GetWalSegmentService(),
+		&CreateSuccessBackupUsecase{},
+		&VerifySuccessBackupUsecase{},
+		NewBackupMetrics(),
+		logger.GetLogger(),
+		[]BackupRemoveListener{},
+	}
+}
+
+func createTestBackupFile(
+	t *testing.T,
+	database *databases.Database,
+	storage *storages.Storage,
+	content []byte,
+	checksum *string,
+) *Backup {
+	backup := &Backup{
+		DatabaseID: database.ID,
+		StorageID:  storage.ID,
+		Type:       backups_config.BackupTypeLogical,
+		Status:     BackupStatusCompleted,
+		Encryption: backups_config.BackupEncryptionNone,
+		Checksum:   checksum,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	err := backupRepository.Save(backup)
+	assert.NoError(t, err)
+
+	err = storage.SaveFile(
+		context.Background(),
+		logger.GetLogger(),
+		backup.ID,
+		bytes.NewReader(content),
+	)
+	assert.NoError(t, err)
+
+	return backup
+}
+
+func removeTestBackupFile(storage *storages.Storage, backup *Backup) {
+	_ = storage.DeleteFile(backup.ID)
+	_ = backupRepository.DeleteByID(backup.ID)
+}
+
+func getTestChecksum(content []byte) *string {
+	hash := sha256.Sum256(content)
+	checksum := hex.EncodeToString(hash[:])
+	return &checksum
+}
+
+// VerifySuccessBackupUsecase accepts any backup data, so
+// only the checksum decides the verification result
+type VerifySuccessBackupUsecase struct {
+}
+
+func (uc *VerifySuccessBackupUsecase) Execute(
+	backupID uuid.UUID,
+	backupType backups_config.BackupType,
+	backupConfig *backups_config.BackupConfig,
+	database *databases.Database,
+	backupReader io.Reader,
+	isTestRestore bool,
+) error {
+	_, err := io.Copy(io.Discard, backupReader)
+	return err
+}
@@ -2,6 +2,7 @@ package backups_config
 
 import (
 	"net/http"
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/users"
 
 	"github.com/gin-gonic/gin"
@@ -11,12 +12,14 @@ import (
 type BackupConfigController struct {
 	backupConfigService *BackupConfigService
 	userService         *users.UserService
+	auditLogService     *audit_logs.AuditLogService
 }
 
 func (c *BackupConfigController) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/backup-configs/save", c.SaveBackupConfig)
 	router.GET("/backup-configs/database/:id", c.GetBackupConfigByDbID)
 	router.GET("/backup-configs/storage/:id/is-using", c.IsStorageUsing)
+	router.POST("/backup-configs/schedule/preview", c.PreviewSchedule)
 }
 
 // SaveBackupConfig
@@ -53,12 +56,37 @@ func (c *BackupConfigController) SaveBackupConfig(ctx *gin.Context) {
 	// make sure we rely on full .Storage object
 	requestDTO.StorageID = nil
 
+	auditEvent := &audit_logs.AuditEvent{
+		Action:     audit_logs.AuditActionBackupConfigUpdate,
+		TargetType: audit_logs.AuditTargetTypeBackupConfig,
+		TargetID:   &requestDTO.DatabaseID,
+		After:      &requestDTO,
+	}
+
+	// access is checked on saving, so here are
+	// the database and the old config for audit log only
+	if database, err := c.backupConfigService.databaseService.GetDatabase(
+		user,
+		requestDTO.DatabaseID,
+	); err == nil {
+		auditEvent.TargetName = database.Name
+		auditEvent.WorkspaceID = &database.WorkspaceID
+		auditEvent.Before, _ = c.backupConfigService.GetBackupConfigByDbId(database.ID)
+	}
+
 	savedConfig, err := c.backupConfigService.SaveBackupConfigWithAuth(user, &requestDTO)
+	if savedConfig != nil {
+		auditEvent.After = savedConfig
+	}
+	auditEvent.Err = err
+	c.auditLogService.LogUserAction(user, ctx.ClientIP(), auditEvent)
+
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	savedConfig.HideSensitiveData()
 	ctx.JSON(http.StatusOK, savedConfig)
 }
 
@@ -104,6 +132,7 @@ func (c *BackupConfigController) GetBackupConfigByDbID(ctx *gin.Context) {
 		return
 	}
 
+	backupConfig.HideSensitiveData()
 	ctx.JSON(http.StatusOK, backupConfig)
 }
 
@@ -145,3 +174,42 @@ func (c *BackupConfigController) IsStorageUsing(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"isUsing": isUsing})
 }
+
+// PreviewSchedule
+// @Summary Preview backup schedule
+// @Description Get the next run times of a backup interval
+// @Tags backup-configs
+// @Accept json
+// @Produce json
+// @Param request body PreviewScheduleRequest true "Interval and count of run times"
+// @Success 200 {object} PreviewScheduleResponse
+// @Failure 400
+// @Failure 401
+// @Router /backup-configs/schedule/preview [post]
+func (c *BackupConfigController) PreviewSchedule(ctx *gin.Context) {
+	var request PreviewScheduleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authorizationHeader := ctx.GetHeader("Authorization")
+	if authorizationHeader == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
+		return
+	}
+
+	_, err := c.userService.GetUserFromToken(authorizationHeader)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	response, err := c.backupConfigService.PreviewSchedule(&request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
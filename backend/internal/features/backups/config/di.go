@@ -1,9 +1,11 @@
 package backups_config
 
 import (
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/storages"
 	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/util/logger"
 )
 
 var backupConfigRepository = &BackupConfigRepository{}
@@ -11,11 +13,13 @@ var backupConfigService = &BackupConfigService{
 	backupConfigRepository,
 	databases.GetDatabaseService(),
 	storages.GetStorageService(),
+	logger.GetLogger(),
 	nil,
 }
 var backupConfigController = &BackupConfigController{
 	backupConfigService,
 	users.GetUserService(),
+	audit_logs.GetAuditLogService(),
 }
 
 func GetBackupConfigController() *BackupConfigController {
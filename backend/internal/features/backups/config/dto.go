@@ -0,0 +1,16 @@
+package backups_config
+
+import (
+	"postgresus-backend/internal/features/intervals"
+	"time"
+)
+
+type PreviewScheduleRequest struct {
+	Interval intervals.Interval `json:"interval"`
+	// defaults to 5, at most 100
+	Count int `json:"count"`
+}
+
+type PreviewScheduleResponse struct {
+	NextRunTimes []time.Time `json:"nextRunTimes"`
+}
@@ -0,0 +1,209 @@
+package backups_config
+
+import (
+	"errors"
+	"fmt"
+	"postgresus-backend/internal/features/databases/databases/postgresql"
+	"regexp"
+	"strings"
+)
+
+// DumpFilters limits logical backups to a part of the database. Patterns
+// follow pg_dump rules: * and ? are wildcards, names are lowercased unless
+// double-quoted and table patterns may be qualified as schema.table
+type DumpFilters struct {
+	IncludeSchemas []string `json:"includeSchemas" gorm:"column:include_schemas;type:jsonb;serializer:json"`
+	ExcludeSchemas []string `json:"excludeSchemas" gorm:"column:exclude_schemas;type:jsonb;serializer:json"`
+	IncludeTables  []string `json:"includeTables"  gorm:"column:include_tables;type:jsonb;serializer:json"`
+	ExcludeTables  []string `json:"excludeTables"  gorm:"column:exclude_tables;type:jsonb;serializer:json"`
+	// definitions of these tables are dumped, but not their data
+	ExcludeTableData []string `json:"excludeTableData" gorm:"column:exclude_table_data;type:jsonb;serializer:json"`
+}
+
+func (f *DumpFilters) IsEmpty() bool {
+	return len(f.IncludeSchemas) == 0 &&
+		len(f.ExcludeSchemas) == 0 &&
+		len(f.IncludeTables) == 0 &&
+		len(f.ExcludeTables) == 0 &&
+		len(f.ExcludeTableData) == 0
+}
+
+func (f *DumpFilters) Validate() error {
+	for _, pattern := range f.getSchemaPatterns() {
+		schemaRegexp, _, err := parseNamePattern(pattern)
+		if err != nil {
+			return err
+		}
+
+		if schemaRegexp != nil {
+			return fmt.Errorf("schema pattern \"%s\" cannot be qualified", pattern)
+		}
+	}
+
+	for _, pattern := range f.getTablePatterns() {
+		if _, _, err := parseNamePattern(pattern); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateAgainstCatalog requires each pattern to match at least one
+// object of the database, so misspelled names are not silently ignored
+func (f *DumpFilters) ValidateAgainstCatalog(catalog *postgresql.Catalog) error {
+	for _, pattern := range f.getSchemaPatterns() {
+		_, nameRegexp, err := parseNamePattern(pattern)
+		if err != nil {
+			return err
+		}
+
+		isMatched := false
+		for _, schemaName := range catalog.SchemaNames {
+			if nameRegexp.MatchString(schemaName) {
+				isMatched = true
+				break
+			}
+		}
+
+		if !isMatched {
+			return fmt.Errorf("schema pattern \"%s\" matches no schemas of the database", pattern)
+		}
+	}
+
+	for _, pattern := range f.getTablePatterns() {
+		schemaRegexp, nameRegexp, err := parseNamePattern(pattern)
+		if err != nil {
+			return err
+		}
+
+		isMatched := false
+		for _, table := range catalog.Tables {
+			// unqualified patterns match only tables visible via search_path
+			if schemaRegexp == nil && !table.IsVisible ||
+				schemaRegexp != nil && !schemaRegexp.MatchString(table.Schema) {
+				continue
+			}
+
+			if nameRegexp.MatchString(table.Name) {
+				isMatched = true
+				break
+			}
+		}
+
+		if !isMatched {
+			return fmt.Errorf("table pattern \"%s\" matches no tables of the database", pattern)
+		}
+	}
+
+	return nil
+}
+
+func (f *DumpFilters) GetPgDumpArgs() []string {
+	args := make([]string, 0)
+
+	for _, pattern := range f.IncludeSchemas {
+		args = append(args, "-n", pattern)
+	}
+
+	for _, pattern := range f.ExcludeSchemas {
+		args = append(args, "-N", pattern)
+	}
+
+	for _, pattern := range f.IncludeTables {
+		args = append(args, "-t", pattern)
+	}
+
+	for _, pattern := range f.ExcludeTables {
+		args = append(args, "-T", pattern)
+	}
+
+	for _, pattern := range f.ExcludeTableData {
+		args = append(args, "--exclude-table-data", pattern)
+	}
+
+	return args
+}
+
+func (f *DumpFilters) getSchemaPatterns() []string {
+	schemaPatterns := make([]string, 0)
+	schemaPatterns = append(schemaPatterns, f.IncludeSchemas...)
+	schemaPatterns = append(schemaPatterns, f.ExcludeSchemas...)
+
+	return schemaPatterns
+}
+
+func (f *DumpFilters) getTablePatterns() []string {
+	tablePatterns := make([]string, 0)
+	tablePatterns = append(tablePatterns, f.IncludeTables...)
+	tablePatterns = append(tablePatterns, f.ExcludeTables...)
+	tablePatterns = append(tablePatterns, f.ExcludeTableData...)
+
+	return tablePatterns
+}
+
+// parseNamePattern converts pg_dump pattern into regexps matching whole
+// names. Schema regexp is nil for unqualified patterns
+func parseNamePattern(pattern string) (*regexp.Regexp, *regexp.Regexp, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, nil, errors.New("filter pattern cannot be empty")
+	}
+
+	parts := []*strings.Builder{{}}
+	isQuoted := false
+	runes := []rune(pattern)
+
+	for i := 0; i < len(runes); i++ {
+		current := parts[len(parts)-1]
+		r := runes[i]
+
+		switch {
+		case r == '"':
+			// doubled quote inside quotes is a literal quote
+			if isQuoted && i+1 < len(runes) && runes[i+1] == '"' {
+				current.WriteString(regexp.QuoteMeta(`"`))
+				i++
+			} else {
+				isQuoted = !isQuoted
+			}
+		case isQuoted:
+			current.WriteString(regexp.QuoteMeta(string(r)))
+		case r == '.':
+			parts = append(parts, &strings.Builder{})
+		case r == '*':
+			current.WriteString(".*")
+		case r == '?':
+			current.WriteString(".")
+		default:
+			current.WriteString(regexp.QuoteMeta(strings.ToLower(string(r))))
+		}
+	}
+
+	if isQuoted {
+		return nil, nil, fmt.Errorf("filter pattern \"%s\" has unclosed quote", pattern)
+	}
+
+	if len(parts) > 2 {
+		return nil, nil, fmt.Errorf("filter pattern \"%s\" has too many dotted names", pattern)
+	}
+
+	regexps := make([]*regexp.Regexp, 0, len(parts))
+	for _, part := range parts {
+		if part.Len() == 0 {
+			return nil, nil, fmt.Errorf("filter pattern \"%s\" has empty name", pattern)
+		}
+
+		partRegexp, err := regexp.Compile("^(?:" + part.String() + ")$")
+		if err != nil {
+			return nil, nil, fmt.Errorf("filter pattern \"%s\" is invalid: %w", pattern, err)
+		}
+
+		regexps = append(regexps, partRegexp)
+	}
+
+	if len(regexps) == 1 {
+		return nil, regexps[0], nil
+	}
+
+	return regexps[0], regexps[1], nil
+}
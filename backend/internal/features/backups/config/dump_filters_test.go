@@ -0,0 +1,77 @@
+package backups_config
+
+import (
+	"postgresus-backend/internal/features/databases/databases/postgresql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetPgDumpArgs_WithAllFilters_MapsToPgDumpOptions(t *testing.T) {
+	filters := &DumpFilters{
+		IncludeSchemas:   []string{"public"},
+		ExcludeSchemas:   []string{"audit"},
+		IncludeTables:    []string{"public.orders"},
+		ExcludeTables:    []string{"public.tmp_*"},
+		ExcludeTableData: []string{"public.logs"},
+	}
+
+	assert.Equal(t, []string{
+		"-n", "public",
+		"-N", "audit",
+		"-t", "public.orders",
+		"-T", "public.tmp_*",
+		"--exclude-table-data", "public.logs",
+	}, filters.GetPgDumpArgs())
+}
+
+func Test_Validate_WithQualifiedSchemaPattern_ReturnsError(t *testing.T) {
+	filters := &DumpFilters{IncludeSchemas: []string{"public.orders"}}
+
+	assert.Error(t, filters.Validate())
+}
+
+func Test_Validate_WithUnclosedQuote_ReturnsError(t *testing.T) {
+	filters := &DumpFilters{IncludeTables: []string{`public."Orders`}}
+
+	assert.Error(t, filters.Validate())
+}
+
+func Test_ValidateAgainstCatalog_WithMatchingPatterns_Passes(t *testing.T) {
+	filters := &DumpFilters{
+		ExcludeSchemas:   []string{"AUDIT"},
+		IncludeTables:    []string{`public."Orders"`, "order_item?"},
+		ExcludeTableData: []string{"*.logs_*"},
+	}
+
+	assert.NoError(t, filters.ValidateAgainstCatalog(getTestCatalog()))
+}
+
+func Test_ValidateAgainstCatalog_WithMissingTable_ReturnsError(t *testing.T) {
+	// quoted names are case sensitive
+	filters := &DumpFilters{IncludeTables: []string{`public."orders"`}}
+
+	assert.Error(t, filters.ValidateAgainstCatalog(getTestCatalog()))
+}
+
+func Test_ValidateAgainstCatalog_WithUnqualifiedNotVisibleTable_ReturnsError(t *testing.T) {
+	filters := &DumpFilters{ExcludeTables: []string{"events"}}
+
+	assert.Error(t, filters.ValidateAgainstCatalog(getTestCatalog()))
+
+	filters = &DumpFilters{ExcludeTables: []string{"audit.events"}}
+
+	assert.NoError(t, filters.ValidateAgainstCatalog(getTestCatalog()))
+}
+
+func getTestCatalog() *postgresql.Catalog {
+	return &postgresql.Catalog{
+		SchemaNames: []string{"public", "audit", "pg_catalog"},
+		Tables: []postgresql.CatalogTable{
+			{Schema: "public", Name: "Orders", IsVisible: true},
+			{Schema: "public", Name: "order_items", IsVisible: true},
+			{Schema: "public", Name: "logs_2025", IsVisible: true},
+			{Schema: "audit", Name: "events", IsVisible: false},
+		},
+	}
+}
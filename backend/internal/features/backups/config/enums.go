@@ -5,4 +5,33 @@ type BackupNotificationType string
 const (
 	NotificationBackupFailed  BackupNotificationType = "BACKUP_FAILED"
 	NotificationBackupSuccess BackupNotificationType = "BACKUP_SUCCESS"
+	// NotificationBackupVerificationFailed is sent when stored backup
+	// is corrupted or its test restore fails
+	NotificationBackupVerificationFailed BackupNotificationType = "BACKUP_VERIFICATION_FAILED"
+)
+
+type BackupEncryption string
+
+const (
+	BackupEncryptionNone      BackupEncryption = "NONE"
+	BackupEncryptionAES256GCM BackupEncryption = "AES_256_GCM"
+)
+
+type BackupType string
+
+const (
+	// BackupTypeLogical is pg_dump snapshot made by schedule
+	BackupTypeLogical BackupType = "LOGICAL"
+	// BackupTypePhysical is pg_basebackup made by schedule plus
+	// continuous WAL archiving for point-in-time recovery
+	BackupTypePhysical BackupType = "PHYSICAL"
+)
+
+type RetentionPolicyType string
+
+const (
+	// RetentionPolicyTypeTimePeriod keeps backups younger than StorePeriod
+	RetentionPolicyTypeTimePeriod RetentionPolicyType = "TIME_PERIOD"
+	// RetentionPolicyTypeGFS keeps backups by grandfather-father-son rules
+	RetentionPolicyTypeGFS RetentionPolicyType = "GFS"
 )
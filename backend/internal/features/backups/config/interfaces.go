@@ -4,4 +4,5 @@ import "github.com/google/uuid"
 
 type BackupConfigStorageChangeListener interface {
 	OnBeforeBackupsStorageChange(dbID uuid.UUID) error
+	OnBeforeBackupsSecondaryStoragesRemove(dbID uuid.UUID, storageIDs []uuid.UUID) error
 }
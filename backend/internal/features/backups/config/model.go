@@ -2,6 +2,7 @@ package backups_config
 
 import (
 	"errors"
+	"postgresus-backend/internal/features/databases/databases/postgresql"
 	"postgresus-backend/internal/features/intervals"
 	"postgresus-backend/internal/features/storages"
 	"postgresus-backend/internal/util/period"
@@ -16,7 +17,15 @@ type BackupConfig struct {
 
 	IsBackupsEnabled bool `json:"isBackupsEnabled" gorm:"column:is_backups_enabled;type:boolean;not null"`
 
+	BackupType BackupType `json:"backupType" gorm:"column:backup_type;type:text;not null"`
+	// only for logical backups of single database
+	DumpFilters DumpFilters `json:"dumpFilters" gorm:"embedded"`
+
+	RetentionPolicyType RetentionPolicyType `json:"retentionPolicyType" gorm:"column:retention_policy_type;type:text;not null"`
+	// only for TIME_PERIOD retention
 	StorePeriod period.Period `json:"storePeriod" gorm:"column:store_period;type:text;not null"`
+	// only for GFS retention
+	GfsRetentionPolicy GfsRetentionPolicy `json:"gfsRetentionPolicy" gorm:"embedded"`
 
 	BackupIntervalID uuid.UUID           `json:"backupIntervalId"         gorm:"column:backup_interval_id;type:uuid;not null"`
 	BackupInterval   *intervals.Interval `json:"backupInterval,omitempty" gorm:"foreignKey:BackupIntervalID"`
@@ -24,6 +33,10 @@ type BackupConfig struct {
 	Storage   *storages.Storage `json:"storage"   gorm:"foreignKey:StorageID"`
 	StorageID *uuid.UUID        `json:"storageId" gorm:"column:storage_id;type:uuid;"`
 
+	// backups are written to the primary storage and then
+	// asynchronously replicated to each of secondary storages
+	SecondaryStorages []storages.Storage `json:"secondaryStorages" gorm:"many2many:backup_config_secondary_storages;foreignKey:DatabaseID;joinForeignKey:DatabaseID;references:ID;joinReferences:StorageID"`
+
 	SendNotificationsOn       []BackupNotificationType `json:"sendNotificationsOn" gorm:"-"`
 	SendNotificationsOnString string                   `json:"-"                   gorm:"column:send_notifications_on;type:text;not null"`
 
@@ -31,13 +44,52 @@ type BackupConfig struct {
 	MaxFailedTriesCount int  `json:"maxFailedTriesCount" gorm:"column:max_failed_tries_count;type:int;not null"`
 
 	CpuCount int `json:"cpuCount" gorm:"type:int;not null"`
+
+	Encryption BackupEncryption `json:"encryption" gorm:"column:encryption;type:text;not null"`
+
+	// test restore periodically restores the latest logical backup into
+	// throwaway database and runs sanity SQL over it. Sanity SQL passes
+	// when it runs without errors and its first returned value is not false
+	IsTestRestoreEnabled  bool                           `json:"isTestRestoreEnabled"            gorm:"column:is_test_restore_enabled;type:boolean;not null"`
+	TestRestoreIntervalID *uuid.UUID                     `json:"testRestoreIntervalId"           gorm:"column:test_restore_interval_id;type:uuid"`
+	TestRestoreInterval   *intervals.Interval            `json:"testRestoreInterval,omitempty"   gorm:"foreignKey:TestRestoreIntervalID"`
+	TestRestorePostgresql *postgresql.PostgresqlDatabase `json:"testRestorePostgresql,omitempty" gorm:"foreignKey:BackupConfigID"`
+	TestRestoreSanitySql  *string                        `json:"testRestoreSanitySql"            gorm:"column:test_restore_sanity_sql;type:text"`
 }
 
 func (h *BackupConfig) TableName() string {
 	return "backup_configs"
 }
 
+// HideSensitiveData clears the password of test restore target and
+// credentials of storages before the config is returned by the API
+func (b *BackupConfig) HideSensitiveData() {
+	if b.TestRestorePostgresql != nil {
+		b.TestRestorePostgresql.HideSensitiveData()
+	}
+
+	if b.Storage != nil {
+		b.Storage.HideSensitiveData()
+	}
+
+	for i := range b.SecondaryStorages {
+		b.SecondaryStorages[i].HideSensitiveData()
+	}
+}
+
 func (b *BackupConfig) BeforeSave(tx *gorm.DB) error {
+	if b.Encryption == "" {
+		b.Encryption = BackupEncryptionNone
+	}
+
+	if b.BackupType == "" {
+		b.BackupType = BackupTypeLogical
+	}
+
+	if b.RetentionPolicyType == "" {
+		b.RetentionPolicyType = RetentionPolicyTypeTimePeriod
+	}
+
 	// Convert SendNotificationsOn array to string
 	if len(b.SendNotificationsOn) > 0 {
 		notificationTypes := make([]string, len(b.SendNotificationsOn))
@@ -76,8 +128,20 @@ func (b *BackupConfig) Validate() error {
 		return errors.New("backup interval is required")
 	}
 
-	if b.StorePeriod == "" {
-		return errors.New("store period is required")
+	if err := b.ValidateRetentionPolicy(); err != nil {
+		return err
+	}
+
+	if err := b.validateSecondaryStorages(); err != nil {
+		return err
+	}
+
+	if err := b.validateTestRestore(); err != nil {
+		return err
+	}
+
+	if err := b.validateDumpFilters(); err != nil {
+		return err
 	}
 
 	if b.CpuCount == 0 {
@@ -88,5 +152,117 @@ func (b *BackupConfig) Validate() error {
 		return errors.New("max failed tries count must be greater than 0")
 	}
 
+	if b.BackupType != "" &&
+		b.BackupType != BackupTypeLogical &&
+		b.BackupType != BackupTypePhysical {
+		return errors.New("backup type is invalid")
+	}
+
+	if b.Encryption != "" &&
+		b.Encryption != BackupEncryptionNone &&
+		b.Encryption != BackupEncryptionAES256GCM {
+		return errors.New("encryption is invalid")
+	}
+
+	return nil
+}
+
+func (b *BackupConfig) ValidateRetentionPolicy() error {
+	switch b.RetentionPolicyType {
+	case "", RetentionPolicyTypeTimePeriod:
+		if b.StorePeriod == "" {
+			return errors.New("store period is required")
+		}
+
+		if !b.StorePeriod.IsValid() {
+			return errors.New("store period is invalid")
+		}
+	case RetentionPolicyTypeGFS:
+		if err := b.GfsRetentionPolicy.Validate(); err != nil {
+			return err
+		}
+	default:
+		return errors.New("retention policy type is invalid")
+	}
+
+	return nil
+}
+
+func (b *BackupConfig) GetSecondaryStorageIDs() []uuid.UUID {
+	storageIDs := make([]uuid.UUID, 0, len(b.SecondaryStorages))
+
+	for _, secondaryStorage := range b.SecondaryStorages {
+		storageIDs = append(storageIDs, secondaryStorage.ID)
+	}
+
+	return storageIDs
+}
+
+func (b *BackupConfig) validateSecondaryStorages() error {
+	storageIDs := make(map[uuid.UUID]bool)
+
+	for _, secondaryStorage := range b.SecondaryStorages {
+		if secondaryStorage.ID == uuid.Nil {
+			return errors.New("secondary storage ID is required")
+		}
+
+		if b.StorageID != nil && secondaryStorage.ID == *b.StorageID ||
+			b.Storage != nil && secondaryStorage.ID == b.Storage.ID {
+			return errors.New("primary storage cannot be used as secondary storage")
+		}
+
+		if storageIDs[secondaryStorage.ID] {
+			return errors.New("secondary storages must be unique")
+		}
+
+		storageIDs[secondaryStorage.ID] = true
+	}
+
+	return nil
+}
+
+func (b *BackupConfig) validateDumpFilters() error {
+	if b.DumpFilters.IsEmpty() {
+		return nil
+	}
+
+	if b.BackupType == BackupTypePhysical {
+		return errors.New("dump filters are supported only for logical backups")
+	}
+
+	return b.DumpFilters.Validate()
+}
+
+func (b *BackupConfig) validateTestRestore() error {
+	if !b.IsTestRestoreEnabled {
+		return nil
+	}
+
+	if b.BackupType == BackupTypePhysical {
+		return errors.New("test restore is supported only for logical backups")
+	}
+
+	if b.TestRestoreIntervalID == nil && b.TestRestoreInterval == nil {
+		return errors.New("test restore interval is required")
+	}
+
+	if b.TestRestoreInterval != nil {
+		if err := b.TestRestoreInterval.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if b.TestRestorePostgresql == nil {
+		return errors.New("test restore database is required")
+	}
+
+	if err := b.TestRestorePostgresql.Validate(); err != nil {
+		return err
+	}
+
+	if b.TestRestorePostgresql.Database == nil || *b.TestRestorePostgresql.Database == "" {
+		return errors.New("test restore database name is required")
+	}
+
 	return nil
 }
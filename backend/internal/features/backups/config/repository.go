@@ -2,6 +2,7 @@ package backups_config
 
 import (
 	"errors"
+	"postgresus-backend/internal/features/databases/databases/postgresql"
 	"postgresus-backend/internal/storage"
 
 	"github.com/google/uuid"
@@ -33,6 +34,20 @@ func (r *BackupConfigRepository) Save(
 			}
 		}
 
+		if backupConfig.TestRestoreInterval != nil {
+			if backupConfig.TestRestoreInterval.ID == uuid.Nil {
+				if err := tx.Create(backupConfig.TestRestoreInterval).Error; err != nil {
+					return err
+				}
+			} else {
+				if err := tx.Save(backupConfig.TestRestoreInterval).Error; err != nil {
+					return err
+				}
+			}
+
+			backupConfig.TestRestoreIntervalID = &backupConfig.TestRestoreInterval.ID
+		}
+
 		// Set storage ID
 		if backupConfig.Storage != nil && backupConfig.Storage.ID != uuid.Nil {
 			backupConfig.StorageID = &backupConfig.Storage.ID
@@ -40,11 +55,28 @@ func (r *BackupConfigRepository) Save(
 
 		// Use Save which handles both create and update based on primary key
 		if err := tx.Save(backupConfig).
-			Omit("BackupInterval", "Storage").
+			Omit(
+				"BackupInterval",
+				"Storage",
+				"SecondaryStorages",
+				"TestRestoreInterval",
+				"TestRestorePostgresql",
+			).
 			Error; err != nil {
 			return err
 		}
 
+		if err := r.saveTestRestorePostgresql(tx, backupConfig); err != nil {
+			return err
+		}
+
+		if err := tx.
+			Model(backupConfig).
+			Association("SecondaryStorages").
+			Replace(backupConfig.SecondaryStorages); err != nil {
+			return err
+		}
+
 		return nil
 	})
 
@@ -62,6 +94,9 @@ func (r *BackupConfigRepository) FindByDatabaseID(databaseID uuid.UUID) (*Backup
 		GetDb().
 		Preload("BackupInterval").
 		Preload("Storage").
+		Preload("SecondaryStorages").
+		Preload("TestRestoreInterval").
+		Preload("TestRestorePostgresql").
 		Where("database_id = ?", databaseID).
 		First(&backupConfig).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -81,6 +116,9 @@ func (r *BackupConfigRepository) GetWithEnabledBackups() ([]*BackupConfig, error
 		GetDb().
 		Preload("BackupInterval").
 		Preload("Storage").
+		Preload("SecondaryStorages").
+		Preload("TestRestoreInterval").
+		Preload("TestRestorePostgresql").
 		Where("is_backups_enabled = ?", true).
 		Find(&backupConfigs).Error; err != nil {
 		return nil, err
@@ -100,5 +138,50 @@ func (r *BackupConfigRepository) IsStorageUsing(storageID uuid.UUID) (bool, erro
 		return false, err
 	}
 
+	if count > 0 {
+		return true, nil
+	}
+
+	if err := storage.
+		GetDb().
+		Table("backup_config_secondary_storages").
+		Where("storage_id = ?", storageID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
 	return count > 0, nil
 }
+
+// saveTestRestorePostgresql keeps at most one test restore
+// database per config, previous one is removed on change
+func (r *BackupConfigRepository) saveTestRestorePostgresql(
+	tx *gorm.DB,
+	backupConfig *BackupConfig,
+) error {
+	testRestorePostgresql := backupConfig.TestRestorePostgresql
+
+	staleQuery := tx.Where("backup_config_id = ?", backupConfig.DatabaseID)
+
+	if testRestorePostgresql != nil {
+		testRestorePostgresql.BackupConfigID = &backupConfig.DatabaseID
+		testRestorePostgresql.DatabaseID = nil
+		testRestorePostgresql.RestoreID = nil
+
+		if testRestorePostgresql.ID == uuid.Nil {
+			testRestorePostgresql.ID = uuid.New()
+
+			if err := tx.Create(testRestorePostgresql).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Save(testRestorePostgresql).Error; err != nil {
+				return err
+			}
+		}
+
+		staleQuery = staleQuery.Where("id <> ?", testRestorePostgresql.ID)
+	}
+
+	return staleQuery.Delete(&postgresql.PostgresqlDatabase{}).Error
+}
@@ -0,0 +1,35 @@
+package backups_config
+
+import "errors"
+
+// GfsRetentionPolicy describes grandfather-father-son retention. Besides the
+// last KeepLast backups, the newest backup of each of the last KeepDaily days,
+// KeepWeekly weeks (ISO), KeepMonthly months and KeepYearly years is kept.
+// Periods are calendar periods in UTC counted back from the current one
+type GfsRetentionPolicy struct {
+	KeepLast    int `json:"keepLast"    gorm:"column:gfs_keep_last;type:int;not null"`
+	KeepDaily   int `json:"keepDaily"   gorm:"column:gfs_keep_daily;type:int;not null"`
+	KeepWeekly  int `json:"keepWeekly"  gorm:"column:gfs_keep_weekly;type:int;not null"`
+	KeepMonthly int `json:"keepMonthly" gorm:"column:gfs_keep_monthly;type:int;not null"`
+	KeepYearly  int `json:"keepYearly"  gorm:"column:gfs_keep_yearly;type:int;not null"`
+}
+
+func (p *GfsRetentionPolicy) Validate() error {
+	if p.KeepLast < 0 ||
+		p.KeepDaily < 0 ||
+		p.KeepWeekly < 0 ||
+		p.KeepMonthly < 0 ||
+		p.KeepYearly < 0 {
+		return errors.New("GFS retention counts cannot be negative")
+	}
+
+	if p.KeepLast == 0 &&
+		p.KeepDaily == 0 &&
+		p.KeepWeekly == 0 &&
+		p.KeepMonthly == 0 &&
+		p.KeepYearly == 0 {
+		return errors.New("at least one GFS retention count is required")
+	}
+
+	return nil
+}
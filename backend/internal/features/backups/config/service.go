@@ -1,19 +1,32 @@
 package backups_config
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/intervals"
 	"postgresus-backend/internal/features/storages"
 	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/features/workspaces"
 	"postgresus-backend/internal/util/period"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	defaultPreviewRunTimesCount = 5
+	maxPreviewRunTimesCount     = 100
+)
+
 type BackupConfigService struct {
 	backupConfigRepository *BackupConfigRepository
 	databaseService        *databases.DatabaseService
 	storageService         *storages.StorageService
+	logger                 *slog.Logger
 
 	dbStorageChangeListener BackupConfigStorageChangeListener
 }
@@ -32,11 +45,37 @@ func (s *BackupConfigService) SaveBackupConfigWithAuth(
 		return nil, err
 	}
 
-	_, err := s.databaseService.GetDatabase(user, backupConfig.DatabaseID)
+	database, err := s.databaseService.GetDatabase(user, backupConfig.DatabaseID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.databaseService.CheckAccess(
+		user,
+		database,
+		workspaces.WorkspaceRoleAdmin,
+	); err != nil {
+		return nil, err
+	}
+
+	storageIDs := backupConfig.GetSecondaryStorageIDs()
+	if backupConfig.StorageID != nil {
+		storageIDs = append(storageIDs, *backupConfig.StorageID)
+	}
+
+	// backups of the database may be kept only
+	// in storages of the same workspace
+	for _, storageID := range storageIDs {
+		storage, err := s.storageService.GetStorage(user, storageID)
+		if err != nil {
+			return nil, err
+		}
+
+		if storage.WorkspaceID != database.WorkspaceID {
+			return nil, errors.New("storage belongs to another workspace than the database")
+		}
+	}
+
 	return s.SaveBackupConfig(backupConfig)
 }
 
@@ -53,7 +92,27 @@ func (s *BackupConfigService) SaveBackupConfig(
 		return nil, err
 	}
 
+	if err := s.validateNonPostgresqlBackupConfig(backupConfig); err != nil {
+		return nil, err
+	}
+
+	if err := s.validateTestRestoreTarget(backupConfig); err != nil {
+		return nil, err
+	}
+
+	if err := s.validateDumpFiltersOverCatalog(backupConfig); err != nil {
+		return nil, err
+	}
+
+	resetTestRestoreIDs(existingConfig, backupConfig)
+
 	if existingConfig != nil {
+		if backupConfig.TestRestorePostgresql != nil {
+			backupConfig.TestRestorePostgresql.FillSensitiveData(
+				existingConfig.TestRestorePostgresql,
+			)
+		}
+
 		// If storage is changing, notify the listener
 		if s.dbStorageChangeListener != nil &&
 			!storageIDsEqual(existingConfig.StorageID, backupConfig.StorageID) {
@@ -76,6 +135,23 @@ func (s *BackupConfigService) SaveBackupConfig(
 		// storage removal for unused storages
 		backupConfig.Storage = nil
 		backupConfig.StorageID = nil
+		backupConfig.SecondaryStorages = []storages.Storage{}
+	}
+
+	if existingConfig != nil && s.dbStorageChangeListener != nil {
+		removedStorageIDs := getRemovedStorageIDs(
+			existingConfig.GetSecondaryStorageIDs(),
+			backupConfig.GetSecondaryStorageIDs(),
+		)
+
+		if len(removedStorageIDs) > 0 {
+			if err := s.dbStorageChangeListener.OnBeforeBackupsSecondaryStoragesRemove(
+				backupConfig.DatabaseID,
+				removedStorageIDs,
+			); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return s.backupConfigRepository.Save(backupConfig)
@@ -125,6 +201,26 @@ func (s *BackupConfigService) IsStorageUsing(
 	return s.backupConfigRepository.IsStorageUsing(storageID)
 }
 
+func (s *BackupConfigService) PreviewSchedule(
+	request *PreviewScheduleRequest,
+) (*PreviewScheduleResponse, error) {
+	count := request.Count
+	if count == 0 {
+		count = defaultPreviewRunTimesCount
+	}
+
+	if count < 0 || count > maxPreviewRunTimesCount {
+		return nil, errors.New("count must be between 1 and 100")
+	}
+
+	nextRunTimes, err := request.Interval.GetNextRunTimes(time.Now().UTC(), count)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PreviewScheduleResponse{NextRunTimes: nextRunTimes}, nil
+}
+
 func (s *BackupConfigService) GetBackupConfigsWithEnabledBackups() ([]*BackupConfig, error) {
 	return s.backupConfigRepository.GetWithEnabledBackups()
 }
@@ -135,9 +231,11 @@ func (s *BackupConfigService) initializeDefaultConfig(
 	timeOfDay := "04:00"
 
 	_, err := s.backupConfigRepository.Save(&BackupConfig{
-		DatabaseID:       databaseID,
-		IsBackupsEnabled: false,
-		StorePeriod:      period.PeriodWeek,
+		DatabaseID:          databaseID,
+		IsBackupsEnabled:    false,
+		BackupType:          BackupTypeLogical,
+		RetentionPolicyType: RetentionPolicyTypeTimePeriod,
+		StorePeriod:         period.PeriodWeek,
 		BackupInterval: &intervals.Interval{
 			Interval:  intervals.IntervalDaily,
 			TimeOfDay: &timeOfDay,
@@ -145,15 +243,129 @@ func (s *BackupConfigService) initializeDefaultConfig(
 		SendNotificationsOn: []BackupNotificationType{
 			NotificationBackupFailed,
 			NotificationBackupSuccess,
+			NotificationBackupVerificationFailed,
 		},
 		CpuCount:            1,
 		IsRetryIfFailed:     true,
 		MaxFailedTriesCount: 3,
+		Encryption:          BackupEncryptionNone,
 	})
 
 	return err
 }
 
+// validateNonPostgresqlBackupConfig rejects features which rely on
+// PostgreSQL tooling: WAL archiving and test restores via pg_restore
+func (s *BackupConfigService) validateNonPostgresqlBackupConfig(backupConfig *BackupConfig) error {
+	database, err := s.databaseService.GetDatabaseByID(backupConfig.DatabaseID)
+	if err != nil {
+		return err
+	}
+
+	if database.Type == databases.DatabaseTypePostgres {
+		return nil
+	}
+
+	if backupConfig.BackupType == BackupTypePhysical {
+		return errors.New("physical backups are supported only for PostgreSQL databases")
+	}
+
+	if backupConfig.IsTestRestoreEnabled {
+		return errors.New("test restores are supported only for PostgreSQL databases")
+	}
+
+	return nil
+}
+
+// validateTestRestoreTarget prevents test restore into the backed up
+// database itself: restore drops existing objects of the target
+func (s *BackupConfigService) validateTestRestoreTarget(backupConfig *BackupConfig) error {
+	if !backupConfig.IsTestRestoreEnabled || backupConfig.TestRestorePostgresql == nil {
+		return nil
+	}
+
+	database, err := s.databaseService.GetDatabaseByID(backupConfig.DatabaseID)
+	if err != nil {
+		return err
+	}
+
+	source := database.Postgresql
+	target := backupConfig.TestRestorePostgresql
+
+	if source != nil && source.IsClusterMode {
+		return errors.New("test restore is not supported for cluster backups")
+	}
+
+	if source != nil &&
+		strings.EqualFold(source.Host, target.Host) &&
+		source.Port == target.Port &&
+		source.Database != nil &&
+		target.Database != nil &&
+		*source.Database == *target.Database {
+		return errors.New("test restore database must differ from the backed up database")
+	}
+
+	return nil
+}
+
+// validateDumpFiltersOverCatalog checks filters against the live database,
+// so the config cannot be saved with patterns which would dump nothing
+func (s *BackupConfigService) validateDumpFiltersOverCatalog(backupConfig *BackupConfig) error {
+	if backupConfig.DumpFilters.IsEmpty() {
+		return nil
+	}
+
+	database, err := s.databaseService.GetDatabaseByID(backupConfig.DatabaseID)
+	if err != nil {
+		return err
+	}
+
+	if database.Postgresql == nil {
+		return errors.New("dump filters are supported only for PostgreSQL databases")
+	}
+
+	if database.Postgresql.IsClusterMode {
+		return errors.New("dump filters are not supported for cluster backups")
+	}
+
+	catalog, err := database.Postgresql.GetCatalog(s.logger)
+	if err != nil {
+		return fmt.Errorf("failed to validate dump filters: %w", err)
+	}
+
+	return backupConfig.DumpFilters.ValidateAgainstCatalog(catalog)
+}
+
+// resetTestRestoreIDs makes test restore interval and database to be
+// created anew unless they are the ones already attached to the config
+func resetTestRestoreIDs(existingConfig, backupConfig *BackupConfig) {
+	if backupConfig.TestRestoreInterval != nil &&
+		(existingConfig == nil ||
+			existingConfig.TestRestoreIntervalID == nil ||
+			*existingConfig.TestRestoreIntervalID != backupConfig.TestRestoreInterval.ID) {
+		backupConfig.TestRestoreInterval.ID = uuid.Nil
+	}
+
+	if backupConfig.TestRestorePostgresql != nil &&
+		(existingConfig == nil ||
+			existingConfig.TestRestorePostgresql == nil ||
+			existingConfig.TestRestorePostgresql.ID != backupConfig.TestRestorePostgresql.ID) {
+		backupConfig.TestRestorePostgresql.ID = uuid.Nil
+	}
+}
+
+func getRemovedStorageIDs(oldStorageIDs, newStorageIDs []uuid.UUID) []uuid.UUID {
+	removedStorageIDs := make([]uuid.UUID, 0)
+
+	for _, oldStorageID := range oldStorageIDs {
+		if !slices.Contains(newStorageIDs, oldStorageID) {
+			removedStorageIDs = append(removedStorageIDs, oldStorageID)
+		}
+	}
+
+	return removedStorageIDs
+}
+
 func storageIDsEqual(id1, id2 *uuid.UUID) bool {
 	if id1 == nil && id2 == nil {
 		return true
@@ -0,0 +1,105 @@
+package backups_config
+
+import (
+	"postgresus-backend/internal/features/databases"
+	"postgresus-backend/internal/features/notifiers"
+	"postgresus-backend/internal/features/storages"
+	local_storage "postgresus-backend/internal/features/storages/models/local"
+	"postgresus-backend/internal/features/users"
+	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/features/workspaces"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SaveBackupConfigWithStorageOfAnotherWorkspace_StorageRejected(t *testing.T) {
+	owner := getTestSignedInUser(t, users.GetTestUser())
+	storage := storages.CreateTestStorage(owner.ID)
+	notifier := notifiers.CreateTestNotifier(owner.ID)
+	database := databases.CreateTestDatabase(owner.ID, storage, notifier)
+	EnableBackupsForTestDatabase(database.ID, storage)
+
+	defer storages.RemoveTestStorage(storage.ID)
+	defer notifiers.RemoveTestNotifier(notifier)
+	defer databases.RemoveTestDatabase(database)
+
+	// the owner of the database is the owner of the other workspace too
+	otherWorkspaceStorage := createTestWorkspaceStorage(
+		t,
+		owner,
+		workspaces.CreateTestWorkspace(owner.ID),
+	)
+	defer storages.RemoveTestStorage(otherWorkspaceStorage.ID)
+
+	stranger := getTestSignedInUser(t, users.CreateTestUser())
+	strangerStorage := createTestWorkspaceStorage(
+		t,
+		stranger,
+		workspaces.CreateTestWorkspace(stranger.ID),
+	)
+	defer storages.RemoveTestStorage(strangerStorage.ID)
+
+	t.Run("Primary storage", func(t *testing.T) {
+		backupConfig, err := backupConfigService.GetBackupConfigByDbId(database.ID)
+		assert.NoError(t, err)
+
+		backupConfig.Storage = otherWorkspaceStorage
+		backupConfig.StorageID = &otherWorkspaceStorage.ID
+
+		_, err = backupConfigService.SaveBackupConfigWithAuth(owner, backupConfig)
+		assert.ErrorContains(t, err, "storage belongs to another workspace than the database")
+	})
+
+	t.Run("Secondary storage", func(t *testing.T) {
+		backupConfig, err := backupConfigService.GetBackupConfigByDbId(database.ID)
+		assert.NoError(t, err)
+
+		backupConfig.SecondaryStorages = []storages.Storage{*otherWorkspaceStorage}
+
+		_, err = backupConfigService.SaveBackupConfigWithAuth(owner, backupConfig)
+		assert.ErrorContains(t, err, "storage belongs to another workspace than the database")
+	})
+
+	t.Run("Storage of workspace the user is not a member of", func(t *testing.T) {
+		backupConfig, err := backupConfigService.GetBackupConfigByDbId(database.ID)
+		assert.NoError(t, err)
+
+		backupConfig.Storage = strangerStorage
+		backupConfig.StorageID = &strangerStorage.ID
+
+		_, err = backupConfigService.SaveBackupConfigWithAuth(owner, backupConfig)
+		assert.ErrorContains(t, err, "you have not access to this workspace")
+	})
+
+	savedBackupConfig, err := backupConfigService.GetBackupConfigByDbId(database.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, storage.ID, *savedBackupConfig.StorageID)
+	assert.Empty(t, savedBackupConfig.SecondaryStorages)
+}
+
+func createTestWorkspaceStorage(
+	t *testing.T,
+	user *users_models.User,
+	workspaceID uuid.UUID,
+) *storages.Storage {
+	storage := &storages.Storage{
+		WorkspaceID:  workspaceID,
+		Type:         storages.StorageTypeLocal,
+		Name:         "Test Storage " + uuid.New().String(),
+		LocalStorage: &local_storage.LocalStorage{},
+	}
+
+	err := storages.GetStorageService().SaveStorage(user, storage)
+	assert.NoError(t, err)
+
+	return storage
+}
+
+func getTestSignedInUser(t *testing.T, signInResponse *users.SignInResponse) *users_models.User {
+	user, err := users.GetUserService().GetUserFromToken(signInResponse.Token)
+	assert.NoError(t, err)
+
+	return user
+}
@@ -0,0 +1,97 @@
+package backups_encryption
+
+import (
+	"net/http"
+	"postgresus-backend/internal/features/users"
+	user_enums "postgresus-backend/internal/features/users/enums"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BackupEncryptionKeyController struct {
+	keyService  *BackupEncryptionKeyService
+	userService *users.UserService
+}
+
+func (c *BackupEncryptionKeyController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/backup-encryption-keys", c.GetKeys)
+	router.POST("/backup-encryption-keys/rotate", c.RotateKey)
+}
+
+// GetKeys
+// @Summary Get backup encryption keys
+// @Description Get all backup encryption keys (without key material)
+// @Tags backup-encryption-keys
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Success 200 {array} BackupEncryptionKey
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /backup-encryption-keys [get]
+func (c *BackupEncryptionKeyController) GetKeys(ctx *gin.Context) {
+	authorizationHeader := ctx.GetHeader("Authorization")
+	if authorizationHeader == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
+		return
+	}
+
+	user, err := c.userService.GetUserFromToken(authorizationHeader)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	// keys encrypt backups of all workspaces
+	if user.Role != user_enums.UserRoleAdmin {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only admin can manage encryption keys"})
+		return
+	}
+
+	keys, err := c.keyService.GetKeys()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, keys)
+}
+
+// RotateKey
+// @Summary Rotate backup encryption key
+// @Description Create new active key for encrypting backups. Previous keys are kept to restore old backups
+// @Tags backup-encryption-keys
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Success 200 {object} BackupEncryptionKey
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /backup-encryption-keys/rotate [post]
+func (c *BackupEncryptionKeyController) RotateKey(ctx *gin.Context) {
+	authorizationHeader := ctx.GetHeader("Authorization")
+	if authorizationHeader == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
+		return
+	}
+
+	user, err := c.userService.GetUserFromToken(authorizationHeader)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	// keys encrypt backups of all workspaces
+	if user.Role != user_enums.UserRoleAdmin {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only admin can manage encryption keys"})
+		return
+	}
+
+	key, err := c.keyService.RotateKey()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
@@ -0,0 +1,24 @@
+package backups_encryption
+
+import (
+	"postgresus-backend/internal/features/encryption_keys"
+	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/util/logger"
+)
+
+var backupEncryptionKeyService = &BackupEncryptionKeyService{
+	encryption_keys.GetBackupKeyStore(),
+	logger.GetLogger(),
+}
+var backupEncryptionKeyController = &BackupEncryptionKeyController{
+	backupEncryptionKeyService,
+	users.GetUserService(),
+}
+
+func GetBackupEncryptionKeyService() *BackupEncryptionKeyService {
+	return backupEncryptionKeyService
+}
+
+func GetBackupEncryptionKeyController() *BackupEncryptionKeyController {
+	return backupEncryptionKeyController
+}
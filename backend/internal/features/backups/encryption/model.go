@@ -0,0 +1,7 @@
+package backups_encryption
+
+import "postgresus-backend/internal/features/encryption_keys"
+
+// BackupEncryptionKey is a data key used to encrypt backup files. Keys
+// are kept in backup_encryption_keys table by the shared key store
+type BackupEncryptionKey = encryption_keys.DataKey
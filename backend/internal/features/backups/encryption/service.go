@@ -0,0 +1,42 @@
+package backups_encryption
+
+import (
+	"log/slog"
+	"postgresus-backend/internal/features/encryption_keys"
+
+	"github.com/google/uuid"
+)
+
+type BackupEncryptionKeyService struct {
+	keyStore *encryption_keys.KeyStore
+	logger   *slog.Logger
+}
+
+// GetActiveKey returns the key new backups should be encrypted
+// with. The first key is created on demand
+func (s *BackupEncryptionKeyService) GetActiveKey() (*BackupEncryptionKey, error) {
+	return s.keyStore.GetActiveKey()
+}
+
+// GetKeyByID returns any key (including rotated ones), so
+// old backups can be decrypted after rotation
+func (s *BackupEncryptionKeyService) GetKeyByID(id uuid.UUID) (*BackupEncryptionKey, error) {
+	return s.keyStore.GetKeyByID(id)
+}
+
+func (s *BackupEncryptionKeyService) GetKeys() ([]*BackupEncryptionKey, error) {
+	return s.keyStore.GetKeys()
+}
+
+// RotateKey creates new active key. Previous keys are kept
+// to decrypt backups made with them
+func (s *BackupEncryptionKeyService) RotateKey() (*BackupEncryptionKey, error) {
+	key, err := s.keyStore.RotateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Backup encryption key rotated", "keyId", key.ID)
+
+	return key, nil
+}
@@ -0,0 +1,438 @@
+package backups_wal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"postgresus-backend/internal/config"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	"postgresus-backend/internal/features/databases"
+	"postgresus-backend/internal/features/databases/databases/postgresql"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/tools"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WalReceiverBackgroundService runs pg_receivewal for each database with
+// physical backups and archives completed WAL segments into the storage
+type WalReceiverBackgroundService struct {
+	backupConfigService        *backups_config.BackupConfigService
+	databaseService            *databases.DatabaseService
+	storageService             *storages.StorageService
+	walSegmentRepository       *WalSegmentRepository
+	backupEncryptionKeyService *backups_encryption.BackupEncryptionKeyService
+	logger                     *slog.Logger
+
+	receivers map[uuid.UUID]*walReceiver
+}
+
+type walReceiver struct {
+	databaseID uuid.UUID
+	postgresql *postgresql.PostgresqlDatabase
+	walDir     string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+func (s *WalReceiverBackgroundService) Run() {
+	for {
+		if config.IsShouldShutdown() {
+			s.stopReceivers()
+			return
+		}
+
+		for _, receiver := range s.receivers {
+			if err := s.archiveCompletedSegments(receiver); err != nil {
+				s.logger.Error(
+					"Failed to archive WAL segments",
+					"databaseId",
+					receiver.databaseID,
+					"error",
+					err,
+				)
+			}
+		}
+
+		if err := s.syncReceivers(); err != nil {
+			s.logger.Error("Failed to sync WAL receivers", "error", err)
+		}
+
+		time.Sleep(10 * time.Second)
+	}
+}
+
+// syncReceivers starts receivers for databases with physical
+// backups, restarts exited ones and stops not needed anymore
+func (s *WalReceiverBackgroundService) syncReceivers() error {
+	enabledBackupConfigs, err := s.backupConfigService.GetBackupConfigsWithEnabledBackups()
+	if err != nil {
+		return err
+	}
+
+	physicalBackupConfigs := make(map[uuid.UUID]*backups_config.BackupConfig)
+	for _, backupConfig := range enabledBackupConfigs {
+		if backupConfig.BackupType == backups_config.BackupTypePhysical &&
+			backupConfig.StorageID != nil {
+			physicalBackupConfigs[backupConfig.DatabaseID] = backupConfig
+		}
+	}
+
+	for databaseID, receiver := range s.receivers {
+		if _, ok := physicalBackupConfigs[databaseID]; !ok {
+			s.stopReceiver(receiver)
+			delete(s.receivers, databaseID)
+
+			if err := DropReplicationSlot(
+				s.logger,
+				receiver.postgresql,
+				GetReplicationSlotName(databaseID),
+			); err != nil {
+				s.logger.Error(
+					"Failed to drop replication slot",
+					"databaseId",
+					databaseID,
+					"error",
+					err,
+				)
+			}
+
+			continue
+		}
+
+		select {
+		case <-receiver.done:
+			s.logger.Error(
+				"WAL receiver stopped, restarting",
+				"databaseId",
+				databaseID,
+				"error",
+				receiver.err,
+			)
+			delete(s.receivers, databaseID)
+		default:
+		}
+	}
+
+	for databaseID := range physicalBackupConfigs {
+		if _, ok := s.receivers[databaseID]; ok {
+			continue
+		}
+
+		database, err := s.databaseService.GetDatabaseByID(databaseID)
+		if err != nil {
+			s.logger.Error("Failed to get database by ID", "databaseId", databaseID, "error", err)
+			continue
+		}
+
+		receiver, err := s.startReceiver(database)
+		if err != nil {
+			s.logger.Error(
+				"Failed to start WAL receiver",
+				"databaseId",
+				databaseID,
+				"error",
+				err,
+			)
+			continue
+		}
+
+		s.receivers[databaseID] = receiver
+	}
+
+	return nil
+}
+
+func (s *WalReceiverBackgroundService) startReceiver(
+	database *databases.Database,
+) (*walReceiver, error) {
+	pg := database.Postgresql
+	if pg == nil {
+		return nil, errors.New("WAL archiving is supported only for PostgreSQL")
+	}
+
+	slotName := GetReplicationSlotName(database.ID)
+	if err := EnsureReplicationSlot(s.logger, pg, slotName); err != nil {
+		return nil, err
+	}
+
+	walDir := filepath.Join(config.GetEnv().WalFolder, database.ID.String())
+	if err := os.MkdirAll(walDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create WAL directory: %w", err)
+	}
+
+	pgpassFile, err := s.createTempPgpassFile(pg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary .pgpass file: %w", err)
+	}
+
+	pgBin := tools.GetPostgresqlExecutable(
+		pg.Version,
+		tools.PostgresqlExecutablePgReceivewal,
+		config.GetEnv().EnvMode,
+		config.GetEnv().PostgresesInstallDir,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cmd := exec.CommandContext(
+		ctx,
+		pgBin,
+		"-D", walDir,
+		"-S", slotName,
+		"-h", pg.Host,
+		"-p", strconv.Itoa(pg.Port),
+		"-U", pg.Username,
+		"--no-password",
+		"--no-loop", // exit on connection loss, the service restarts it
+	)
+
+	cmd.Env = os.Environ()
+	cmd.Env = append(cmd.Env, "PGPASSFILE="+pgpassFile)
+	cmd.Env = append(cmd.Env, "PGCONNECT_TIMEOUT=30")
+	if pg.IsHttps {
+		cmd.Env = append(cmd.Env, "PGSSLMODE=require")
+	} else {
+		cmd.Env = append(cmd.Env, "PGSSLMODE=prefer")
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		cancel()
+		_ = os.RemoveAll(filepath.Dir(pgpassFile))
+		return nil, fmt.Errorf("start %s: %w", filepath.Base(pgBin), err)
+	}
+
+	receiver := &walReceiver{
+		databaseID: database.ID,
+		postgresql: pg,
+		walDir:     walDir,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+
+	go func() {
+		waitErr := cmd.Wait()
+		_ = os.RemoveAll(filepath.Dir(pgpassFile))
+
+		receiver.err = fmt.Errorf(
+			"%s exited: %v – stderr: %s",
+			filepath.Base(pgBin),
+			waitErr,
+			stderr.String(),
+		)
+		close(receiver.done)
+	}()
+
+	s.logger.Info("WAL receiver started", "databaseId", database.ID, "walDir", walDir)
+
+	return receiver, nil
+}
+
+func (s *WalReceiverBackgroundService) stopReceiver(receiver *walReceiver) {
+	receiver.cancel()
+	<-receiver.done
+
+	s.logger.Info("WAL receiver stopped", "databaseId", receiver.databaseID)
+}
+
+func (s *WalReceiverBackgroundService) stopReceivers() {
+	for databaseID, receiver := range s.receivers {
+		s.stopReceiver(receiver)
+		delete(s.receivers, databaseID)
+	}
+}
+
+// archiveCompletedSegments uploads completed segments and timeline history
+// files to the storage. pg_receivewal writes in-progress segment as .partial,
+// so such files are skipped until they are completed
+func (s *WalReceiverBackgroundService) archiveCompletedSegments(receiver *walReceiver) error {
+	entries, err := os.ReadDir(receiver.walDir)
+	if err != nil {
+		return err
+	}
+
+	fileNames := make([]string, 0)
+	lastSegmentFileName := ""
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		if IsSegmentFileName(entry.Name()) {
+			fileNames = append(fileNames, entry.Name())
+			lastSegmentFileName = max(lastSegmentFileName, entry.Name())
+		} else if IsHistoryFileName(entry.Name()) {
+			fileNames = append(fileNames, entry.Name())
+		}
+	}
+
+	if len(fileNames) == 0 {
+		return nil
+	}
+
+	slices.Sort(fileNames)
+
+	backupConfig, err := s.backupConfigService.GetBackupConfigByDbId(receiver.databaseID)
+	if err != nil {
+		return err
+	}
+
+	if backupConfig.StorageID == nil {
+		return errors.New("backup config storage ID is not defined")
+	}
+
+	storage, err := s.storageService.GetStorageByID(*backupConfig.StorageID)
+	if err != nil {
+		return err
+	}
+
+	var encryptionKey *backups_encryption.BackupEncryptionKey
+	if backupConfig.Encryption == backups_config.BackupEncryptionAES256GCM {
+		encryptionKey, err = s.backupEncryptionKeyService.GetActiveKey()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, fileName := range fileNames {
+		existingSegment, err := s.walSegmentRepository.FindByDatabaseIDAndFileName(
+			receiver.databaseID,
+			fileName,
+		)
+		if err != nil {
+			return err
+		}
+
+		if existingSegment == nil {
+			if err := s.archiveFile(receiver, storage, encryptionKey, fileName); err != nil {
+				return fmt.Errorf("failed to archive %s: %w", fileName, err)
+			}
+		}
+
+		// the last completed segment is kept locally, so pg_receivewal
+		// continues after it on restart instead of the server current position
+		if fileName == lastSegmentFileName {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(receiver.walDir, fileName)); err != nil {
+			s.logger.Error("Failed to remove archived WAL file", "file", fileName, "error", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *WalReceiverBackgroundService) archiveFile(
+	receiver *walReceiver,
+	storage *storages.Storage,
+	encryptionKey *backups_encryption.BackupEncryptionKey,
+	fileName string,
+) error {
+	filePath := filepath.Join(receiver.walDir, fileName)
+
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	timeline, err := strconv.ParseInt(fileName[0:8], 16, 32)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			s.logger.Error("Failed to close WAL file", "error", err)
+		}
+	}()
+
+	segment := &WalSegment{
+		ID:         uuid.New(),
+		DatabaseID: receiver.databaseID,
+		StorageID:  storage.ID,
+		FileName:   fileName,
+		Timeline:   int(timeline),
+		IsHistory:  IsHistoryFileName(fileName),
+		SizeBytes:  fileInfo.Size(),
+		Encryption: backups_config.BackupEncryptionNone,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	var reader io.Reader = file
+	if encryptionKey != nil {
+		pipeReader, pipeWriter := io.Pipe()
+		defer func() {
+			_ = pipeReader.Close()
+		}()
+
+		go func() {
+			encryptingWriter, err := encryption.NewEncryptingWriter(pipeWriter, encryptionKey.Key)
+			if err == nil {
+				_, err = io.Copy(encryptingWriter, file)
+			}
+			if err == nil {
+				err = encryptingWriter.Close()
+			}
+
+			_ = pipeWriter.CloseWithError(err)
+		}()
+
+		reader = pipeReader
+		segment.Encryption = backups_config.BackupEncryptionAES256GCM
+		segment.EncryptionKeyID = &encryptionKey.ID
+	}
+
+	if err := storage.SaveFile(context.Background(), s.logger, segment.ID, reader); err != nil {
+		return err
+	}
+
+	return s.walSegmentRepository.Create(segment)
+}
+
+// createTempPgpassFile creates a temporary .pgpass file for replication connection
+func (s *WalReceiverBackgroundService) createTempPgpassFile(
+	pgConfig *postgresql.PostgresqlDatabase,
+) (string, error) {
+	// replication connections match only "replication" database
+	// in .pgpass, so the wildcard is used
+	pgpassContent := fmt.Sprintf("%s:%d:*:%s:%s",
+		pgConfig.Host,
+		pgConfig.Port,
+		pgConfig.Username,
+		pgConfig.Password,
+	)
+
+	tempDir, err := os.MkdirTemp("", "pgpass")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	pgpassFile := filepath.Join(tempDir, ".pgpass")
+	err = os.WriteFile(pgpassFile, []byte(pgpassContent), 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to write temporary .pgpass file: %w", err)
+	}
+
+	return pgpassFile, nil
+}
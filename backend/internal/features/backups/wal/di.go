@@ -0,0 +1,36 @@
+package backups_wal
+
+import (
+	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	"postgresus-backend/internal/features/databases"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/util/logger"
+
+	"github.com/google/uuid"
+)
+
+var walSegmentRepository = &WalSegmentRepository{}
+var walSegmentService = &WalSegmentService{
+	walSegmentRepository,
+	storages.GetStorageService(),
+	backups_encryption.GetBackupEncryptionKeyService(),
+	logger.GetLogger(),
+}
+var walReceiverBackgroundService = &WalReceiverBackgroundService{
+	backups_config.GetBackupConfigService(),
+	databases.GetDatabaseService(),
+	storages.GetStorageService(),
+	walSegmentRepository,
+	backups_encryption.GetBackupEncryptionKeyService(),
+	logger.GetLogger(),
+	map[uuid.UUID]*walReceiver{},
+}
+
+func GetWalSegmentService() *WalSegmentService {
+	return walSegmentService
+}
+
+func GetWalReceiverBackgroundService() *WalReceiverBackgroundService {
+	return walReceiverBackgroundService
+}
@@ -0,0 +1,32 @@
+package backups_wal
+
+import (
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WalSegment is a WAL segment (or timeline history file) archived
+// by the WAL receiver. Together with a physical base backup it
+// allows point-in-time recovery
+type WalSegment struct {
+	ID uuid.UUID `json:"id" gorm:"column:id;type:uuid;primaryKey"`
+
+	DatabaseID uuid.UUID `json:"databaseId" gorm:"column:database_id;type:uuid;not null"`
+	StorageID  uuid.UUID `json:"storageId"  gorm:"column:storage_id;type:uuid;not null"`
+
+	FileName  string `json:"fileName"  gorm:"column:file_name;type:text;not null"`
+	Timeline  int    `json:"timeline"  gorm:"column:timeline;type:int;not null"`
+	IsHistory bool   `json:"isHistory" gorm:"column:is_history;type:boolean;not null"`
+	SizeBytes int64  `json:"sizeBytes" gorm:"column:size_bytes;type:bigint;not null"`
+
+	Encryption      backups_config.BackupEncryption `json:"encryption"      gorm:"column:encryption;type:text;not null"`
+	EncryptionKeyID *uuid.UUID                      `json:"encryptionKeyId" gorm:"column:encryption_key_id;type:uuid"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+}
+
+func (w *WalSegment) TableName() string {
+	return "wal_segments"
+}
@@ -0,0 +1,111 @@
+package backups_wal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"postgresus-backend/internal/features/databases/databases/postgresql"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EnsureReplicationSlot creates physical replication slot (if missing) and
+// reserves WAL immediately, so the server keeps WAL until it is archived
+func EnsureReplicationSlot(
+	logger *slog.Logger,
+	pg *postgresql.PostgresqlDatabase,
+	slotName string,
+) error {
+	return withConnection(logger, pg, func(ctx context.Context, conn *pgx.Conn) error {
+		var isExists bool
+		if err := conn.QueryRow(
+			ctx,
+			"SELECT EXISTS (SELECT 1 FROM pg_replication_slots WHERE slot_name = $1)",
+			slotName,
+		).Scan(&isExists); err != nil {
+			return fmt.Errorf("failed to check replication slot: %w", err)
+		}
+
+		if isExists {
+			return nil
+		}
+
+		if _, err := conn.Exec(
+			ctx,
+			"SELECT pg_create_physical_replication_slot($1, true)",
+			slotName,
+		); err != nil {
+			return fmt.Errorf(
+				"failed to create replication slot (user needs REPLICATION role "+
+					"and max_replication_slots > 0): %w",
+				err,
+			)
+		}
+
+		logger.Info("Replication slot created", "slotName", slotName)
+
+		return nil
+	})
+}
+
+// DropReplicationSlot drops the slot, otherwise the server
+// would retain WAL for it forever
+func DropReplicationSlot(
+	logger *slog.Logger,
+	pg *postgresql.PostgresqlDatabase,
+	slotName string,
+) error {
+	return withConnection(logger, pg, func(ctx context.Context, conn *pgx.Conn) error {
+		if _, err := conn.Exec(
+			ctx,
+			"SELECT pg_drop_replication_slot(slot_name) FROM pg_replication_slots WHERE slot_name = $1",
+			slotName,
+		); err != nil {
+			return fmt.Errorf("failed to drop replication slot: %w", err)
+		}
+
+		logger.Info("Replication slot dropped", "slotName", slotName)
+
+		return nil
+	})
+}
+
+// GetWalSegmentSize returns WAL segment size of the server in bytes
+func GetWalSegmentSize(logger *slog.Logger, pg *postgresql.PostgresqlDatabase) (int64, error) {
+	var segmentSize int64
+
+	err := withConnection(logger, pg, func(ctx context.Context, conn *pgx.Conn) error {
+		if err := conn.QueryRow(
+			ctx,
+			"SELECT setting::bigint FROM pg_settings WHERE name = 'wal_segment_size'",
+		).Scan(&segmentSize); err != nil {
+			return fmt.Errorf("failed to get WAL segment size: %w", err)
+		}
+
+		return nil
+	})
+
+	return segmentSize, err
+}
+
+func withConnection(
+	logger *slog.Logger,
+	pg *postgresql.PostgresqlDatabase,
+	fn func(ctx context.Context, conn *pgx.Conn) error,
+) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, pg.GetConnectionString())
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			logger.Error("Failed to close connection", "error", err)
+		}
+	}()
+
+	return fn(ctx, conn)
+}
@@ -0,0 +1,81 @@
+package backups_wal
+
+import (
+	"errors"
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WalSegmentRepository struct{}
+
+func (r *WalSegmentRepository) Create(segment *WalSegment) error {
+	if segment.ID == uuid.Nil {
+		segment.ID = uuid.New()
+	}
+
+	return storage.GetDb().Create(segment).Error
+}
+
+func (r *WalSegmentRepository) FindByDatabaseIDAndFileName(
+	databaseID uuid.UUID,
+	fileName string,
+) (*WalSegment, error) {
+	var segment WalSegment
+
+	if err := storage.
+		GetDb().
+		Where("database_id = ? AND file_name = ?", databaseID, fileName).
+		First(&segment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &segment, nil
+}
+
+func (r *WalSegmentRepository) FindByDatabaseID(databaseID uuid.UUID) ([]*WalSegment, error) {
+	var segments []*WalSegment
+
+	if err := storage.
+		GetDb().
+		Where("database_id = ?", databaseID).
+		Order("file_name ASC").
+		Find(&segments).Error; err != nil {
+		return nil, err
+	}
+
+	return segments, nil
+}
+
+// FindSegmentsBeforePosition returns WAL segments (not history files)
+// located before the position regardless of timeline
+func (r *WalSegmentRepository) FindSegmentsBeforePosition(
+	databaseID uuid.UUID,
+	position string,
+) ([]*WalSegment, error) {
+	var segments []*WalSegment
+
+	if err := storage.
+		GetDb().
+		Where(
+			"database_id = ? AND is_history = ? AND SUBSTRING(file_name, 9, 16) < ?",
+			databaseID,
+			false,
+			position,
+		).
+		Order("file_name ASC").
+		Find(&segments).Error; err != nil {
+		return nil, err
+	}
+
+	return segments, nil
+}
+
+func (r *WalSegmentRepository) DeleteByID(id uuid.UUID) error {
+	return storage.GetDb().Delete(&WalSegment{}, "id = ?", id).Error
+}
@@ -0,0 +1,122 @@
+package backups_wal
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/util/encryption"
+
+	"github.com/google/uuid"
+)
+
+type WalSegmentService struct {
+	walSegmentRepository       *WalSegmentRepository
+	storageService             *storages.StorageService
+	backupEncryptionKeyService *backups_encryption.BackupEncryptionKeyService
+	logger                     *slog.Logger
+}
+
+func (s *WalSegmentService) GetSegments(databaseID uuid.UUID) ([]*WalSegment, error) {
+	return s.walSegmentRepository.FindByDatabaseID(databaseID)
+}
+
+// GetSegmentFile returns decrypted content of archived WAL segment
+func (s *WalSegmentService) GetSegmentFile(
+	ctx context.Context,
+	segment *WalSegment,
+) (io.ReadCloser, error) {
+	storage, err := s.storageService.GetStorageByID(segment.StorageID)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := storage.GetFile(ctx, segment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if segment.Encryption == backups_config.BackupEncryptionAES256GCM &&
+		segment.EncryptionKeyID != nil {
+		encryptionKey, err := s.backupEncryptionKeyService.GetKeyByID(*segment.EncryptionKeyID)
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+
+		return encryption.NewDecryptingReadCloser(file, encryptionKey.Key)
+	}
+
+	return file, nil
+}
+
+// DeleteSegmentsBefore removes WAL segments which are older than the segment.
+// It is used to remove WAL not needed by any retained base backup
+func (s *WalSegmentService) DeleteSegmentsBefore(
+	databaseID uuid.UUID,
+	segmentFileName string,
+) error {
+	segments, err := s.walSegmentRepository.FindSegmentsBeforePosition(
+		databaseID,
+		GetSegmentPosition(segmentFileName),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		if err := s.deleteSegment(segment); err != nil {
+			return err
+		}
+	}
+
+	if len(segments) > 0 {
+		s.logger.Info(
+			"Deleted old WAL segments",
+			"databaseId",
+			databaseID,
+			"count",
+			len(segments),
+			"beforeSegment",
+			segmentFileName,
+		)
+	}
+
+	return nil
+}
+
+func (s *WalSegmentService) DeleteDatabaseSegments(databaseID uuid.UUID) error {
+	segments, err := s.walSegmentRepository.FindByDatabaseID(databaseID)
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		if err := s.deleteSegment(segment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *WalSegmentService) deleteSegment(segment *WalSegment) error {
+	storage, err := s.storageService.GetStorageByID(segment.StorageID)
+	if err != nil {
+		s.logger.Error(
+			"Failed to get storage of WAL segment",
+			"segmentId",
+			segment.ID,
+			"storageId",
+			segment.StorageID,
+			"error",
+			err,
+		)
+	} else if err := storage.DeleteFile(segment.ID); err != nil {
+		s.logger.Error("Failed to delete WAL segment file", "segmentId", segment.ID, "error", err)
+	}
+
+	return s.walSegmentRepository.DeleteByID(segment.ID)
+}
@@ -0,0 +1,82 @@
+package backups_wal
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	segmentFileNameRegex = regexp.MustCompile(`^[0-9A-F]{24}$`)
+	historyFileNameRegex = regexp.MustCompile(`^[0-9A-F]{8}\.history$`)
+)
+
+// ParseLsn parses LSN in PostgreSQL text form, e.g. "0/2000028"
+func ParseLsn(lsn string) (uint64, error) {
+	parts := strings.Split(strings.TrimSpace(lsn), "/")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid LSN: %s", lsn)
+	}
+
+	high, err := strconv.ParseUint(parts[0], 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN: %s", lsn)
+	}
+
+	low, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN: %s", lsn)
+	}
+
+	return high<<32 | low, nil
+}
+
+// GetSegmentFileName returns name of WAL segment file containing
+// the LSN, e.g. "000000010000000000000002"
+func GetSegmentFileName(timeline int, lsn uint64, segmentSize int64) string {
+	segmentsPerXLogID := uint64(0x100000000) / uint64(segmentSize)
+	segmentNumber := lsn / uint64(segmentSize)
+
+	return fmt.Sprintf(
+		"%08X%08X%08X",
+		timeline,
+		segmentNumber/segmentsPerXLogID,
+		segmentNumber%segmentsPerXLogID,
+	)
+}
+
+// ParseSegmentFileName returns timeline and segment number of WAL segment file
+func ParseSegmentFileName(fileName string, segmentSize int64) (int, uint64, error) {
+	if !IsSegmentFileName(fileName) {
+		return 0, 0, fmt.Errorf("invalid WAL segment file name: %s", fileName)
+	}
+
+	timeline, _ := strconv.ParseUint(fileName[0:8], 16, 32)
+	xLogID, _ := strconv.ParseUint(fileName[8:16], 16, 32)
+	segmentID, _ := strconv.ParseUint(fileName[16:24], 16, 32)
+
+	segmentsPerXLogID := uint64(0x100000000) / uint64(segmentSize)
+
+	return int(timeline), xLogID*segmentsPerXLogID + segmentID, nil
+}
+
+// GetSegmentPosition returns part of segment file name without timeline.
+// Positions of segments are comparable as strings
+func GetSegmentPosition(fileName string) string {
+	return fileName[8:]
+}
+
+func IsSegmentFileName(fileName string) bool {
+	return segmentFileNameRegex.MatchString(fileName)
+}
+
+func IsHistoryFileName(fileName string) bool {
+	return historyFileNameRegex.MatchString(fileName)
+}
+
+func GetReplicationSlotName(databaseID uuid.UUID) string {
+	return "postgresus_" + strings.ReplaceAll(databaseID.String(), "-", "")
+}
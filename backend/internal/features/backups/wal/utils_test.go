@@ -0,0 +1,65 @@
+package backups_wal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const defaultSegmentSize = 16 * 1024 * 1024
+
+func Test_ParseLsn_ReturnsCorrectPosition(t *testing.T) {
+	lsn, err := ParseLsn("0/2000028")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0x2000028), lsn)
+
+	lsn, err = ParseLsn("1A/FF000000")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0x1AFF000000), lsn)
+
+	_, err = ParseLsn("2000028")
+	assert.Error(t, err)
+
+	_, err = ParseLsn("0/XYZ")
+	assert.Error(t, err)
+}
+
+func Test_GetSegmentFileName_ReturnsPostgresqlName(t *testing.T) {
+	assert.Equal(
+		t,
+		"000000010000000000000002",
+		GetSegmentFileName(1, 0x2000028, defaultSegmentSize),
+	)
+	assert.Equal(
+		t,
+		"00000003000000010000000A",
+		GetSegmentFileName(3, 0x10A000000, defaultSegmentSize),
+	)
+	assert.Equal(
+		t,
+		"000000010000000000000001",
+		GetSegmentFileName(1, 0x4000000, 64*1024*1024),
+	)
+}
+
+func Test_ParseSegmentFileName_ReturnsTimelineAndNumber(t *testing.T) {
+	timeline, segmentNumber, err := ParseSegmentFileName(
+		"00000003000000010000000A",
+		defaultSegmentSize,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, timeline)
+	assert.Equal(t, uint64(0x10A), segmentNumber)
+
+	_, _, err = ParseSegmentFileName("00000003000000010000000A.partial", defaultSegmentSize)
+	assert.Error(t, err)
+}
+
+func Test_IsSegmentFileName_OnlyCompletedSegmentsMatch(t *testing.T) {
+	assert.True(t, IsSegmentFileName("000000010000000000000002"))
+	assert.False(t, IsSegmentFileName("000000010000000000000002.partial"))
+	assert.False(t, IsSegmentFileName("00000002.history"))
+
+	assert.True(t, IsHistoryFileName("00000002.history"))
+	assert.False(t, IsHistoryFileName("000000010000000000000002"))
+}
@@ -0,0 +1,126 @@
+package configuration
+
+import (
+	"net/http"
+	"postgresus-backend/internal/features/audit_logs"
+	"postgresus-backend/internal/features/users"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ConfigurationController struct {
+	configurationService *ConfigurationService
+	userService          *users.UserService
+	auditLogService      *audit_logs.AuditLogService
+}
+
+func (c *ConfigurationController) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/configuration/apply", c.ApplyConfiguration)
+	router.GET("/configuration/export", c.ExportConfiguration)
+}
+
+// ApplyConfiguration
+// @Summary Apply configuration
+// @Description Create or update workspaces, storages, notifiers, databases, backup and healthcheck configs declared in YAML. Resources are matched by names, undeclared ones are kept. Environment references are allowed only in the file applied on startup
+// @Tags configuration
+// @Accept plain
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param configuration body string true "Configuration in YAML"
+// @Success 200 {object} ApplyConfigurationResponse
+// @Failure 400
+// @Failure 401
+// @Router /configuration/apply [post]
+func (c *ConfigurationController) ApplyConfiguration(ctx *gin.Context) {
+	authorizationHeader := ctx.GetHeader("Authorization")
+	if authorizationHeader == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
+		return
+	}
+
+	user, err := c.userService.GetUserFromToken(authorizationHeader)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	data, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// server environment must not leak to API
+	// users, so references are not resolved here
+	file, err := ParseConfiguration(data, nil)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := c.configurationService.ApplyConfiguration(
+		user,
+		file,
+		func(event *audit_logs.AuditEvent) {
+			c.auditLogService.LogUserAction(user, ctx.ClientIP(), event)
+		},
+	)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// ExportConfiguration
+// @Summary Export configuration
+// @Description Export configuration of the workspace or of all workspaces of the current user in YAML accepted by apply. Secrets are left empty
+// @Tags configuration
+// @Produce plain
+// @Param Authorization header string true "JWT token"
+// @Param workspace_id query string false "Workspace ID"
+// @Success 200 {string} string
+// @Failure 400
+// @Failure 401
+// @Router /configuration/export [get]
+func (c *ConfigurationController) ExportConfiguration(ctx *gin.Context) {
+	authorizationHeader := ctx.GetHeader("Authorization")
+	if authorizationHeader == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
+		return
+	}
+
+	user, err := c.userService.GetUserFromToken(authorizationHeader)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	var workspaceID *uuid.UUID
+	if workspaceIDParam := ctx.Query("workspace_id"); workspaceIDParam != "" {
+		id, err := uuid.Parse(workspaceIDParam)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+			return
+		}
+
+		workspaceID = &id
+	}
+
+	file, err := c.configurationService.ExportConfiguration(user, workspaceID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := EncodeConfiguration(file)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="postgresus.yaml"`)
+	ctx.Data(http.StatusOK, "application/yaml", data)
+}
@@ -0,0 +1,38 @@
+package configuration
+
+import (
+	"postgresus-backend/internal/features/audit_logs"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	healthcheck_config "postgresus-backend/internal/features/healthcheck/config"
+	"postgresus-backend/internal/features/notifiers"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/features/workspaces"
+	"postgresus-backend/internal/util/logger"
+)
+
+var configurationService = &ConfigurationService{
+	workspaces.GetWorkspaceService(),
+	storages.GetStorageService(),
+	notifiers.GetNotifierService(),
+	databases.GetDatabaseService(),
+	backups_config.GetBackupConfigService(),
+	healthcheck_config.GetHealthcheckConfigService(),
+	users.GetUserService(),
+	audit_logs.GetAuditLogService(),
+	logger.GetLogger(),
+}
+var configurationController = &ConfigurationController{
+	configurationService,
+	users.GetUserService(),
+	audit_logs.GetAuditLogService(),
+}
+
+func GetConfigurationService() *ConfigurationService {
+	return configurationService
+}
+
+func GetConfigurationController() *ConfigurationController {
+	return configurationController
+}
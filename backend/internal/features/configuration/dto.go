@@ -0,0 +1,90 @@
+package configuration
+
+import (
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	"postgresus-backend/internal/features/databases/databases/mongodb"
+	"postgresus-backend/internal/features/databases/databases/mysql"
+	"postgresus-backend/internal/features/databases/databases/postgresql"
+	healthcheck_config "postgresus-backend/internal/features/healthcheck/config"
+	"postgresus-backend/internal/features/notifiers"
+	"postgresus-backend/internal/features/storages"
+)
+
+// ConfigurationFile is the declared setup of Postgresus. Resources
+// are matched with existing ones by name within the workspace
+type ConfigurationFile struct {
+	// the first user created from the file on a fresh instance,
+	// ignored once the instance has users
+	Admin *AdminConfiguration `json:"admin,omitempty"`
+
+	Workspaces []*WorkspaceConfiguration `json:"workspaces"`
+}
+
+type AdminConfiguration struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type WorkspaceConfiguration struct {
+	Name      string                   `json:"name"`
+	Storages  []*storages.Storage      `json:"storages"`
+	Notifiers []*notifiers.Notifier    `json:"notifiers"`
+	Databases []*DatabaseConfiguration `json:"databases"`
+}
+
+type DatabaseConfiguration struct {
+	Name string                 `json:"name"`
+	Type databases.DatabaseType `json:"type"`
+
+	Postgresql *postgresql.PostgresqlDatabase `json:"postgresql,omitempty"`
+	Mysql      *mysql.MysqlDatabase           `json:"mysql,omitempty"`
+	Mongodb    *mongodb.MongodbDatabase       `json:"mongodb,omitempty"`
+
+	// names of notifiers of the same workspace
+	Notifiers []string `json:"notifiers"`
+
+	BackupConfig      *BackupConfiguration                     `json:"backupConfig,omitempty"`
+	HealthcheckConfig *healthcheck_config.HealthcheckConfigDTO `json:"healthcheckConfig,omitempty"`
+}
+
+// BackupConfiguration refers to storages by names instead of
+// storage objects of the backup config
+type BackupConfiguration struct {
+	backups_config.BackupConfig
+
+	Storage           *string  `json:"storage"`
+	SecondaryStorages []string `json:"secondaryStorages"`
+}
+
+type ChangeAction string
+
+const (
+	ChangeActionCreated ChangeAction = "CREATED"
+	ChangeActionUpdated ChangeAction = "UPDATED"
+)
+
+type ResourceType string
+
+const (
+	ResourceTypeWorkspace         ResourceType = "WORKSPACE"
+	ResourceTypeStorage           ResourceType = "STORAGE"
+	ResourceTypeNotifier          ResourceType = "NOTIFIER"
+	ResourceTypeDatabase          ResourceType = "DATABASE"
+	ResourceTypeBackupConfig      ResourceType = "BACKUP_CONFIG"
+	ResourceTypeHealthcheckConfig ResourceType = "HEALTHCHECK_CONFIG"
+)
+
+type AppliedChange struct {
+	Workspace    string       `json:"workspace"`
+	ResourceType ResourceType `json:"resourceType"`
+	// name of the database for backup and healthcheck configs
+	Name   string       `json:"name"`
+	Action ChangeAction `json:"action"`
+}
+
+// ApplyConfigurationResponse lists changed resources, resources
+// which already match the configuration are not listed
+type ApplyConfigurationResponse struct {
+	Changes []*AppliedChange `json:"changes"`
+}
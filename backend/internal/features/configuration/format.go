@@ -0,0 +1,200 @@
+package configuration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ${NAME} is replaced by value of the environment variable,
+// $${NAME} is kept as literal ${NAME}
+var envReferenceRegexp = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+var literalEnvReferenceRegexp = regexp.MustCompile(`\$\{[A-Za-z_][A-Za-z0-9_]*\}`)
+
+// internalFields are IDs and states of resources. They are not
+// exported, because resources are matched by names
+var internalFields = []string{
+	"id",
+	"userId",
+	"workspaceId",
+	"databaseId",
+	"storageId",
+	"notifierId",
+	"restoreId",
+	"backupConfigId",
+	"backupIntervalId",
+	"testRestoreIntervalId",
+	"lastSaveError",
+	"lastSendError",
+}
+
+// ParseConfiguration reads YAML configuration. Fields have the same names
+// as in JSON of the API. Environment references in values are resolved
+// by lookupEnv, nil lookupEnv means references are not allowed
+func ParseConfiguration(
+	data []byte,
+	lookupEnv func(name string) (string, bool),
+) (*ConfigurationFile, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	if err := expandEnvReferences(&document, lookupEnv); err != nil {
+		return nil, err
+	}
+
+	var value any
+	if err := document.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// typos in field names would silently reset the fields
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.DisallowUnknownFields()
+
+	var file ConfigurationFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &file, nil
+}
+
+// EncodeConfiguration writes value as YAML in the format read by
+// ParseConfiguration. Secrets must be hidden by the caller
+func EncodeConfiguration(value any) ([]byte, error) {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is valid YAML, so parsing it into node
+	// keeps fields in order of struct declaration
+	var document yaml.Node
+	if err := yaml.Unmarshal(jsonData, &document); err != nil {
+		return nil, err
+	}
+
+	cleanNode(&document)
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func expandEnvReferences(
+	node *yaml.Node,
+	lookupEnv func(name string) (string, bool),
+) error {
+	if node.Kind == yaml.ScalarNode {
+		var expandErr error
+
+		node.Value = envReferenceRegexp.ReplaceAllStringFunc(node.Value, func(reference string) string {
+			if reference[1] == '$' {
+				return reference[1:]
+			}
+
+			name := envReferenceRegexp.FindStringSubmatch(reference)[1]
+
+			if lookupEnv == nil {
+				expandErr = errors.New("environment references are not allowed, remove ${" + name + "}")
+				return reference
+			}
+
+			value, ok := lookupEnv(name)
+			if !ok {
+				expandErr = errors.New("environment variable " + name + " is not set")
+				return reference
+			}
+
+			return value
+		})
+
+		return expandErr
+	}
+
+	// keys of mappings are not expanded
+	for i, child := range node.Content {
+		if node.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+
+		if err := expandEnvReferences(child, lookupEnv); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cleanNode resets JSON styles to block ones and removes internal
+// fields and empty values, so exported file contains only the setup.
+// Values looking like environment references are escaped
+func cleanNode(node *yaml.Node) {
+	node.Style = 0
+
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+		node.Value = literalEnvReferenceRegexp.ReplaceAllString(node.Value, "$$$0")
+	}
+
+	if node.Kind != yaml.MappingNode {
+		for _, child := range node.Content {
+			cleanNode(child)
+		}
+
+		return
+	}
+
+	content := make([]*yaml.Node, 0, len(node.Content))
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		if slices.Contains(internalFields, key.Value) {
+			continue
+		}
+
+		key.Style = 0
+		cleanNode(value)
+
+		if isEmptyNode(value) {
+			continue
+		}
+
+		content = append(content, key, value)
+	}
+
+	node.Content = content
+}
+
+func isEmptyNode(node *yaml.Node) bool {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Tag == "!!null"
+	case yaml.MappingNode, yaml.SequenceNode:
+		return len(node.Content) == 0
+	}
+
+	return false
+}
@@ -0,0 +1,186 @@
+package configuration
+
+import (
+	"postgresus-backend/internal/features/storages"
+	s3_storage "postgresus-backend/internal/features/storages/models/s3"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfiguration = `
+workspaces:
+  - name: Production
+    storages:
+      - name: s3-main
+        type: S3
+        s3Storage:
+          s3Bucket: backups
+          s3Region: eu-central-1
+          s3AccessKey: ${S3_ACCESS_KEY}
+          s3SecretKey: pa$${NOT_A_REFERENCE}
+    databases:
+      - name: orders
+        type: POSTGRES
+        notifiers: [ops-telegram]
+        backupConfig:
+          isBackupsEnabled: true
+          storage: s3-main
+          storePeriod: MONTH
+          backupInterval:
+            interval: DAILY
+            timeOfDay: "04:00"
+`
+
+func Test_ParseConfiguration_EnvReferencesResolved(t *testing.T) {
+	file, err := ParseConfiguration([]byte(testConfiguration), func(name string) (string, bool) {
+		if name == "S3_ACCESS_KEY" {
+			return "access-key", true
+		}
+
+		return "", false
+	})
+	require.NoError(t, err)
+
+	require.Len(t, file.Workspaces, 1)
+	workspace := file.Workspaces[0]
+	assert.Equal(t, "Production", workspace.Name)
+
+	require.Len(t, workspace.Storages, 1)
+	assert.Equal(t, storages.StorageTypeS3, workspace.Storages[0].Type)
+	assert.Equal(t, "access-key", workspace.Storages[0].S3Storage.S3AccessKey)
+	assert.Equal(t, "pa${NOT_A_REFERENCE}", workspace.Storages[0].S3Storage.S3SecretKey)
+
+	require.Len(t, workspace.Databases, 1)
+	backupConfig := workspace.Databases[0].BackupConfig
+	require.NotNil(t, backupConfig)
+	assert.Equal(t, []string{"ops-telegram"}, workspace.Databases[0].Notifiers)
+	assert.Equal(t, "s3-main", *backupConfig.Storage)
+	assert.True(t, backupConfig.IsBackupsEnabled)
+	assert.Equal(t, "04:00", *backupConfig.BackupInterval.TimeOfDay)
+}
+
+func Test_ParseConfiguration_MissingEnvVariable_ReturnsError(t *testing.T) {
+	_, err := ParseConfiguration([]byte(testConfiguration), func(name string) (string, bool) {
+		return "", false
+	})
+
+	assert.EqualError(t, err, "environment variable S3_ACCESS_KEY is not set")
+}
+
+func Test_ParseConfiguration_EnvReferencesNotAllowed_ReturnsError(t *testing.T) {
+	_, err := ParseConfiguration([]byte(testConfiguration), nil)
+
+	assert.Error(t, err)
+}
+
+func Test_ParseConfiguration_UnknownField_ReturnsError(t *testing.T) {
+	_, err := ParseConfiguration([]byte(`
+workspaces:
+  - name: Production
+    storages:
+      - name: s3-main
+        s3Bucket: backups
+`), nil)
+
+	assert.ErrorContains(t, err, "s3Bucket")
+}
+
+func Test_EncodeConfiguration_InternalFieldsAndEmptyValuesRemoved(t *testing.T) {
+	storageID := uuid.New()
+	lastSaveError := "connection refused"
+
+	data, err := EncodeConfiguration(&WorkspaceConfiguration{
+		Name: "Production",
+		Storages: []*storages.Storage{
+			{
+				ID:            storageID,
+				WorkspaceID:   uuid.New(),
+				Name:          "s3-main",
+				Type:          storages.StorageTypeS3,
+				LastSaveError: &lastSaveError,
+				S3Storage: &s3_storage.S3Storage{
+					StorageID: storageID,
+					S3Bucket:  "123",
+					S3Region:  "eu-central-1",
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	expected := `name: Production
+storages:
+  - type: S3
+    name: s3-main
+    s3Storage:
+      s3Bucket: "123"
+      s3Region: eu-central-1
+      s3AccessKey: ""
+      s3SecretKey: ""
+      s3Endpoint: ""
+`
+	assert.Equal(t, expected, string(data))
+}
+
+func Test_EncodeConfiguration_ExportedFileParsedBack(t *testing.T) {
+	file, err := ParseConfiguration([]byte(testConfiguration), func(name string) (string, bool) {
+		return "access-key", true
+	})
+	require.NoError(t, err)
+
+	data, err := EncodeConfiguration(file)
+	require.NoError(t, err)
+
+	parsedFile, err := ParseConfiguration(data, func(name string) (string, bool) {
+		return "", false
+	})
+	require.NoError(t, err)
+
+	isSame, err := isSameConfiguration(file, parsedFile)
+	require.NoError(t, err)
+	assert.True(t, isSame)
+}
+
+func Test_ParseConfiguration_AdminPasswordResolvedFromEnv(t *testing.T) {
+	data := []byte(`
+admin:
+  email: admin@example.com
+  password: ${ADMIN_PASSWORD}
+workspaces: []
+`)
+
+	file, err := ParseConfiguration(data, func(name string) (string, bool) {
+		return "s3cr3t-password", name == "ADMIN_PASSWORD"
+	})
+	require.NoError(t, err)
+	require.NotNil(t, file.Admin)
+
+	assert.Equal(t, "admin@example.com", file.Admin.Email)
+	assert.Equal(t, "s3cr3t-password", file.Admin.Password)
+	assert.NoError(t, validateAdmin(file.Admin, false))
+}
+
+func Test_ValidateAdmin_InvalidAdmin_ReturnsError(t *testing.T) {
+	assert.EqualError(
+		t,
+		validateAdmin(&AdminConfiguration{Email: "admin", Password: "s3cr3t-password"}, false),
+		"admin email is invalid",
+	)
+	assert.EqualError(
+		t,
+		validateAdmin(&AdminConfiguration{Email: "admin@example.com", Password: "short"}, false),
+		"admin password must be at least 8 characters",
+	)
+}
+
+func Test_ValidateAdmin_WithPasswordLoginDisabled_PasswordNotRequired(t *testing.T) {
+	assert.NoError(t, validateAdmin(&AdminConfiguration{Email: "admin@example.com"}, true))
+	assert.EqualError(
+		t,
+		validateAdmin(&AdminConfiguration{Email: "admin"}, true),
+		"admin email is invalid",
+	)
+}
@@ -0,0 +1,935 @@
+package configuration
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"os"
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/audit_logs"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	healthcheck_config "postgresus-backend/internal/features/healthcheck/config"
+	"postgresus-backend/internal/features/notifiers"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/features/users"
+	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/features/workspaces"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+type ConfigurationService struct {
+	workspaceService         *workspaces.WorkspaceService
+	storageService           *storages.StorageService
+	notifierService          *notifiers.NotifierService
+	databaseService          *databases.DatabaseService
+	backupConfigService      *backups_config.BackupConfigService
+	healthcheckConfigService *healthcheck_config.HealthcheckConfigService
+	userService              *users.UserService
+	auditLogService          *audit_logs.AuditLogService
+	logger                   *slog.Logger
+}
+
+// workspaceApply is state of applying configuration of a single
+// workspace. Storages and notifiers include both declared and existing
+// ones, so databases may refer to resources created via UI
+type workspaceApply struct {
+	user          *users_models.User
+	workspaceID   uuid.UUID
+	workspaceName string
+	logAuditEvent func(event *audit_logs.AuditEvent)
+	response      *ApplyConfigurationResponse
+
+	storages  *namedItems[storages.Storage]
+	notifiers *namedItems[notifiers.Notifier]
+}
+
+// namedItems indexes resources of the workspace by names. Names
+// are not unique in the UI, so duplicated names are tracked to
+// reject the ones which can not be matched
+type namedItems[T any] struct {
+	itemsByName     map[string]*T
+	duplicatedNames map[string]bool
+}
+
+// ApplyConfigurationFile applies the file on behalf of the first user
+// of the instance. On a fresh instance the first user is created from
+// admin of the file. Secrets in the file may refer to environment variables
+func (s *ConfigurationService) ApplyConfigurationFile(
+	path string,
+) (*ApplyConfigurationResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	file, err := ParseConfiguration(data, os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	isAnyUserExist, err := s.userService.IsAnyUserExist()
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAnyUserExist {
+		if file.Admin == nil {
+			return nil, errors.New(
+				"there are no users yet, declare admin in the file to create the first user",
+			)
+		}
+
+		if err := s.createAdmin(file.Admin); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := s.userService.GetFirstUser()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ApplyConfiguration(user, file, s.auditLogService.LogSystemAction)
+}
+
+// createAdmin signs up the first user, which becomes admin and gets own
+// workspace the same way as on sign up via UI. If password login is
+// disabled, the admin is created as SSO user and signs in via the provider
+func (s *ConfigurationService) createAdmin(admin *AdminConfiguration) error {
+	isPasswordLoginDisabled := config.GetEnv().IsPasswordLoginDisabled
+
+	if err := validateAdmin(admin, isPasswordLoginDisabled); err != nil {
+		return err
+	}
+
+	if isPasswordLoginDisabled {
+		if _, err := s.userService.GetOrCreateExternalUser(admin.Email); err != nil {
+			return fmt.Errorf("failed to create admin: %w", err)
+		}
+	} else if err := s.userService.SignUp(&users.SignUpRequest{
+		Email:    admin.Email,
+		Password: admin.Password,
+	}); err != nil {
+		return fmt.Errorf("failed to create admin: %w", err)
+	}
+
+	s.logger.Info("Admin created from configuration", "email", admin.Email)
+
+	return nil
+}
+
+// ApplyConfiguration creates missing resources and updates changed ones.
+// Resources which are not declared are kept as is. Empty secrets keep
+// stored values, the same way as in the API
+func (s *ConfigurationService) ApplyConfiguration(
+	user *users_models.User,
+	file *ConfigurationFile,
+	logAuditEvent func(event *audit_logs.AuditEvent),
+) (*ApplyConfigurationResponse, error) {
+	if err := validateConfiguration(file); err != nil {
+		return nil, err
+	}
+
+	response := &ApplyConfigurationResponse{Changes: []*AppliedChange{}}
+
+	for _, workspaceConfiguration := range file.Workspaces {
+		if err := s.applyWorkspace(
+			user,
+			workspaceConfiguration,
+			logAuditEvent,
+			response,
+		); err != nil {
+			return response, fmt.Errorf("workspace %s: %w", workspaceConfiguration.Name, err)
+		}
+	}
+
+	for _, change := range response.Changes {
+		s.logger.Info(
+			"Configuration applied",
+			"workspace", change.Workspace,
+			"resourceType", change.ResourceType,
+			"name", change.Name,
+			"action", change.Action,
+		)
+	}
+
+	return response, nil
+}
+
+// ExportConfiguration returns configuration of the workspace or, if
+// workspace is not specified, of all workspaces of the user. Secrets
+// are left empty
+func (s *ConfigurationService) ExportConfiguration(
+	user *users_models.User,
+	workspaceID *uuid.UUID,
+) (*ConfigurationFile, error) {
+	if workspaceID != nil {
+		if err := s.workspaceService.CheckRole(
+			user,
+			*workspaceID,
+			workspaces.WorkspaceRoleViewer,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	accessibleWorkspaceIDs, err := s.workspaceService.GetWorkspaceIDs(user)
+	if err != nil {
+		return nil, err
+	}
+
+	userWorkspaces, err := s.workspaceService.GetWorkspaces(user)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &ConfigurationFile{Workspaces: []*WorkspaceConfiguration{}}
+
+	for _, workspace := range userWorkspaces {
+		if workspaceID != nil && workspace.ID != *workspaceID ||
+			!slices.Contains(accessibleWorkspaceIDs, workspace.ID) {
+			continue
+		}
+
+		workspaceConfiguration, err := s.exportWorkspace(user, workspace.ID, workspace.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		file.Workspaces = append(file.Workspaces, workspaceConfiguration)
+	}
+
+	return file, nil
+}
+
+func (s *ConfigurationService) applyWorkspace(
+	user *users_models.User,
+	workspaceConfiguration *WorkspaceConfiguration,
+	logAuditEvent func(event *audit_logs.AuditEvent),
+	response *ApplyConfigurationResponse,
+) error {
+	apply := &workspaceApply{
+		user:          user,
+		workspaceName: workspaceConfiguration.Name,
+		logAuditEvent: logAuditEvent,
+		response:      response,
+	}
+
+	workspaceID, err := s.getOrCreateWorkspace(apply)
+	if err != nil {
+		return err
+	}
+	apply.workspaceID = workspaceID
+
+	if err := s.applyStorages(apply, workspaceConfiguration.Storages); err != nil {
+		return err
+	}
+
+	if err := s.applyNotifiers(apply, workspaceConfiguration.Notifiers); err != nil {
+		return err
+	}
+
+	existingDatabases, err := s.databaseService.GetDatabasesByUser(user, &workspaceID)
+	if err != nil {
+		return err
+	}
+
+	databasesByName := newNamedItems(existingDatabases, func(database *databases.Database) string {
+		return database.Name
+	})
+
+	for _, databaseConfiguration := range workspaceConfiguration.Databases {
+		existingDatabase, err := databasesByName.get(databaseConfiguration.Name)
+		if err != nil {
+			return err
+		}
+
+		if err := s.applyDatabase(apply, databaseConfiguration, existingDatabase); err != nil {
+			return fmt.Errorf("database %s: %w", databaseConfiguration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *ConfigurationService) getOrCreateWorkspace(apply *workspaceApply) (uuid.UUID, error) {
+	userWorkspaces, err := s.workspaceService.GetWorkspaces(apply.user)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var workspaceIDs []uuid.UUID
+	for _, workspace := range userWorkspaces {
+		if workspace.Name == apply.workspaceName {
+			workspaceIDs = append(workspaceIDs, workspace.ID)
+		}
+	}
+
+	if len(workspaceIDs) > 1 {
+		return uuid.Nil, errors.New("there are several workspaces with this name")
+	}
+
+	if len(workspaceIDs) == 1 {
+		return workspaceIDs[0], nil
+	}
+
+	workspace, err := s.workspaceService.CreateWorkspace(
+		apply.user,
+		&workspaces.SaveWorkspaceRequest{Name: apply.workspaceName},
+	)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	apply.addChange(ResourceTypeWorkspace, workspace.Name, ChangeActionCreated)
+
+	return workspace.ID, nil
+}
+
+func (s *ConfigurationService) applyStorages(
+	apply *workspaceApply,
+	declaredStorages []*storages.Storage,
+) error {
+	existingStorages, err := s.storageService.GetStorages(apply.user, &apply.workspaceID)
+	if err != nil {
+		return err
+	}
+
+	apply.storages = newNamedItems(existingStorages, func(storage *storages.Storage) string {
+		return storage.Name
+	})
+
+	for _, storage := range declaredStorages {
+		storage.WorkspaceID = apply.workspaceID
+
+		auditEvent := &audit_logs.AuditEvent{
+			Action:      audit_logs.AuditActionStorageCreate,
+			TargetType:  audit_logs.AuditTargetTypeStorage,
+			TargetName:  storage.Name,
+			WorkspaceID: &apply.workspaceID,
+			After:       storage,
+		}
+
+		existingStorage, err := apply.storages.get(storage.Name)
+		if err != nil {
+			return err
+		}
+
+		if existingStorage != nil {
+			storage.ID = existingStorage.ID
+			storage.UserID = existingStorage.UserID
+			storage.LastSaveError = existingStorage.LastSaveError
+			storage.FillSensitiveData(existingStorage)
+
+			isSame, err := isSameConfiguration(existingStorage, storage)
+			if err != nil {
+				return err
+			}
+
+			if isSame {
+				continue
+			}
+
+			auditEvent.Action = audit_logs.AuditActionStorageUpdate
+			auditEvent.Before = existingStorage
+		}
+
+		err = s.storageService.SaveStorage(apply.user, storage)
+
+		auditEvent.TargetID = &storage.ID
+		auditEvent.Err = err
+		apply.logAuditEvent(auditEvent)
+
+		if err != nil {
+			return fmt.Errorf("storage %s: %w", storage.Name, err)
+		}
+
+		apply.storages.set(storage.Name, storage)
+		apply.addChange(ResourceTypeStorage, storage.Name, getChangeAction(existingStorage))
+	}
+
+	return nil
+}
+
+func (s *ConfigurationService) applyNotifiers(
+	apply *workspaceApply,
+	declaredNotifiers []*notifiers.Notifier,
+) error {
+	existingNotifiers, err := s.notifierService.GetNotifiers(apply.user, &apply.workspaceID)
+	if err != nil {
+		return err
+	}
+
+	apply.notifiers = newNamedItems(existingNotifiers, func(notifier *notifiers.Notifier) string {
+		return notifier.Name
+	})
+
+	for _, notifier := range declaredNotifiers {
+		notifier.WorkspaceID = apply.workspaceID
+
+		auditEvent := &audit_logs.AuditEvent{
+			Action:      audit_logs.AuditActionNotifierCreate,
+			TargetType:  audit_logs.AuditTargetTypeNotifier,
+			TargetName:  notifier.Name,
+			WorkspaceID: &apply.workspaceID,
+			After:       notifier,
+		}
+
+		existingNotifier, err := apply.notifiers.get(notifier.Name)
+		if err != nil {
+			return err
+		}
+
+		if existingNotifier != nil {
+			notifier.ID = existingNotifier.ID
+			notifier.UserID = existingNotifier.UserID
+			notifier.LastSendError = existingNotifier.LastSendError
+			notifier.FillSensitiveData(existingNotifier)
+
+			isSame, err := isSameConfiguration(existingNotifier, notifier)
+			if err != nil {
+				return err
+			}
+
+			if isSame {
+				continue
+			}
+
+			auditEvent.Action = audit_logs.AuditActionNotifierUpdate
+			auditEvent.Before = existingNotifier
+		}
+
+		err = s.notifierService.SaveNotifier(apply.user, notifier)
+
+		auditEvent.TargetID = &notifier.ID
+		auditEvent.Err = err
+		apply.logAuditEvent(auditEvent)
+
+		if err != nil {
+			return fmt.Errorf("notifier %s: %w", notifier.Name, err)
+		}
+
+		apply.notifiers.set(notifier.Name, notifier)
+		apply.addChange(ResourceTypeNotifier, notifier.Name, getChangeAction(existingNotifier))
+	}
+
+	return nil
+}
+
+func (s *ConfigurationService) applyDatabase(
+	apply *workspaceApply,
+	databaseConfiguration *DatabaseConfiguration,
+	existingDatabase *databases.Database,
+) error {
+	database := &databases.Database{
+		WorkspaceID: apply.workspaceID,
+		Name:        databaseConfiguration.Name,
+		Type:        databaseConfiguration.Type,
+		Postgresql:  databaseConfiguration.Postgresql,
+		Mysql:       databaseConfiguration.Mysql,
+		Mongodb:     databaseConfiguration.Mongodb,
+		Notifiers:   []notifiers.Notifier{},
+	}
+
+	for _, notifierName := range databaseConfiguration.Notifiers {
+		notifier, err := apply.notifiers.get(notifierName)
+		if err != nil {
+			return err
+		}
+
+		if notifier == nil {
+			return fmt.Errorf("notifier %s is not found in the workspace", notifierName)
+		}
+
+		database.Notifiers = append(database.Notifiers, *notifier)
+	}
+
+	database, err := s.saveDatabase(apply, database, existingDatabase)
+	if err != nil {
+		return err
+	}
+
+	if databaseConfiguration.BackupConfig != nil {
+		if err := s.applyBackupConfig(
+			apply,
+			database,
+			databaseConfiguration.BackupConfig,
+		); err != nil {
+			return err
+		}
+	}
+
+	if databaseConfiguration.HealthcheckConfig != nil {
+		if err := s.applyHealthcheckConfig(
+			apply,
+			database,
+			databaseConfiguration.HealthcheckConfig,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ConfigurationService) saveDatabase(
+	apply *workspaceApply,
+	database *databases.Database,
+	existingDatabase *databases.Database,
+) (*databases.Database, error) {
+	auditEvent := &audit_logs.AuditEvent{
+		Action:      audit_logs.AuditActionDatabaseCreate,
+		TargetType:  audit_logs.AuditTargetTypeDatabase,
+		TargetName:  database.Name,
+		WorkspaceID: &apply.workspaceID,
+		After:       database,
+	}
+
+	if existingDatabase == nil {
+		createdDatabase, err := s.databaseService.CreateDatabase(apply.user, database)
+		if createdDatabase != nil {
+			auditEvent.TargetID = &createdDatabase.ID
+			auditEvent.After = createdDatabase
+		}
+		auditEvent.Err = err
+		apply.logAuditEvent(auditEvent)
+
+		if err != nil {
+			return nil, err
+		}
+
+		apply.addChange(ResourceTypeDatabase, database.Name, ChangeActionCreated)
+
+		return createdDatabase, nil
+	}
+
+	// specific databases are updated in place rather than created anew
+	database.ID = existingDatabase.ID
+	if database.Postgresql != nil && existingDatabase.Postgresql != nil {
+		database.Postgresql.ID = existingDatabase.Postgresql.ID
+	}
+
+	if database.Mysql != nil && existingDatabase.Mysql != nil {
+		database.Mysql.ID = existingDatabase.Mysql.ID
+	}
+
+	if database.Mongodb != nil && existingDatabase.Mongodb != nil {
+		database.Mongodb.ID = existingDatabase.Mongodb.ID
+	}
+
+	database.FillSensitiveData(existingDatabase)
+
+	isSame, err := isSameConfiguration(
+		toDatabaseConfiguration(existingDatabase),
+		toDatabaseConfiguration(database),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if isSame {
+		return existingDatabase, nil
+	}
+
+	err = s.databaseService.UpdateDatabase(apply.user, database)
+
+	auditEvent.Action = audit_logs.AuditActionDatabaseUpdate
+	auditEvent.TargetID = &database.ID
+	auditEvent.Before = existingDatabase
+	auditEvent.Err = err
+	apply.logAuditEvent(auditEvent)
+
+	if err != nil {
+		return nil, err
+	}
+
+	apply.addChange(ResourceTypeDatabase, database.Name, ChangeActionUpdated)
+
+	return database, nil
+}
+
+func (s *ConfigurationService) applyBackupConfig(
+	apply *workspaceApply,
+	database *databases.Database,
+	backupConfiguration *BackupConfiguration,
+) error {
+	existingConfig, err := s.backupConfigService.GetBackupConfigByDbId(database.ID)
+	if err != nil {
+		return err
+	}
+
+	backupConfig := backupConfiguration.BackupConfig
+	backupConfig.DatabaseID = database.ID
+	backupConfig.Storage = nil
+	backupConfig.StorageID = nil
+	backupConfig.SecondaryStorages = []storages.Storage{}
+
+	if backupConfiguration.Storage != nil {
+		storage, err := apply.storages.get(*backupConfiguration.Storage)
+		if err != nil {
+			return err
+		}
+
+		if storage == nil {
+			return fmt.Errorf("storage %s is not found in the workspace", *backupConfiguration.Storage)
+		}
+
+		backupConfig.Storage = storage
+		backupConfig.StorageID = &storage.ID
+	}
+
+	for _, storageName := range backupConfiguration.SecondaryStorages {
+		storage, err := apply.storages.get(storageName)
+		if err != nil {
+			return err
+		}
+
+		if storage == nil {
+			return fmt.Errorf("storage %s is not found in the workspace", storageName)
+		}
+
+		backupConfig.SecondaryStorages = append(backupConfig.SecondaryStorages, *storage)
+	}
+
+	// intervals and test restore database are updated in place,
+	// omitted backup interval keeps the current one
+	if backupConfig.BackupInterval == nil {
+		backupConfig.BackupInterval = existingConfig.BackupInterval
+	} else {
+		backupConfig.BackupInterval.ID = existingConfig.BackupIntervalID
+	}
+
+	if backupConfig.TestRestoreInterval != nil && existingConfig.TestRestoreIntervalID != nil {
+		backupConfig.TestRestoreInterval.ID = *existingConfig.TestRestoreIntervalID
+	}
+
+	if backupConfig.TestRestorePostgresql != nil && existingConfig.TestRestorePostgresql != nil {
+		backupConfig.TestRestorePostgresql.ID = existingConfig.TestRestorePostgresql.ID
+		backupConfig.TestRestorePostgresql.FillSensitiveData(existingConfig.TestRestorePostgresql)
+	}
+
+	isSame, err := isSameConfiguration(
+		apply.toBackupConfiguration(existingConfig),
+		apply.toBackupConfiguration(&backupConfig),
+	)
+	if err != nil {
+		return err
+	}
+
+	if isSame {
+		return nil
+	}
+
+	savedConfig, err := s.backupConfigService.SaveBackupConfigWithAuth(apply.user, &backupConfig)
+
+	auditEvent := &audit_logs.AuditEvent{
+		Action:      audit_logs.AuditActionBackupConfigUpdate,
+		TargetType:  audit_logs.AuditTargetTypeBackupConfig,
+		TargetID:    &database.ID,
+		TargetName:  database.Name,
+		WorkspaceID: &apply.workspaceID,
+		Before:      existingConfig,
+		After:       &backupConfig,
+		Err:         err,
+	}
+	if savedConfig != nil {
+		auditEvent.After = savedConfig
+	}
+	apply.logAuditEvent(auditEvent)
+
+	if err != nil {
+		return fmt.Errorf("backup config: %w", err)
+	}
+
+	apply.addChange(ResourceTypeBackupConfig, database.Name, ChangeActionUpdated)
+
+	return nil
+}
+
+func (s *ConfigurationService) applyHealthcheckConfig(
+	apply *workspaceApply,
+	database *databases.Database,
+	healthcheckConfiguration *healthcheck_config.HealthcheckConfigDTO,
+) error {
+	existingConfig, err := s.healthcheckConfigService.GetByDatabaseID(*apply.user, database.ID)
+	if err != nil {
+		return err
+	}
+
+	configDTO := *healthcheckConfiguration
+	configDTO.DatabaseID = database.ID
+
+	if configDTO == toHealthcheckConfigDTO(existingConfig) {
+		return nil
+	}
+
+	if err := s.healthcheckConfigService.Save(*apply.user, configDTO); err != nil {
+		return fmt.Errorf("healthcheck config: %w", err)
+	}
+
+	apply.addChange(ResourceTypeHealthcheckConfig, database.Name, ChangeActionUpdated)
+
+	return nil
+}
+
+func (s *ConfigurationService) exportWorkspace(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+	workspaceName string,
+) (*WorkspaceConfiguration, error) {
+	workspaceStorages, err := s.storageService.GetStorages(user, &workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceNotifiers, err := s.notifierService.GetNotifiers(user, &workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceDatabases, err := s.databaseService.GetDatabasesByUser(user, &workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, storage := range workspaceStorages {
+		storage.HideSensitiveData()
+	}
+
+	apply := &workspaceApply{
+		storages: newNamedItems(workspaceStorages, func(storage *storages.Storage) string {
+			return storage.Name
+		}),
+	}
+
+	for _, notifier := range workspaceNotifiers {
+		notifier.HideSensitiveData()
+	}
+
+	workspaceConfiguration := &WorkspaceConfiguration{
+		Name:      workspaceName,
+		Storages:  workspaceStorages,
+		Notifiers: workspaceNotifiers,
+		Databases: make([]*DatabaseConfiguration, 0, len(workspaceDatabases)),
+	}
+
+	for _, database := range workspaceDatabases {
+		database.HideSensitiveData()
+		databaseConfiguration := toDatabaseConfiguration(database)
+
+		backupConfig, err := s.backupConfigService.GetBackupConfigByDbId(database.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		backupConfig.HideSensitiveData()
+		databaseConfiguration.BackupConfig = apply.toBackupConfiguration(backupConfig)
+
+		healthcheckConfig, err := s.healthcheckConfigService.GetByDatabaseID(*user, database.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		healthcheckConfigDTO := toHealthcheckConfigDTO(healthcheckConfig)
+		databaseConfiguration.HealthcheckConfig = &healthcheckConfigDTO
+
+		workspaceConfiguration.Databases = append(
+			workspaceConfiguration.Databases,
+			databaseConfiguration,
+		)
+	}
+
+	return workspaceConfiguration, nil
+}
+
+func (a *workspaceApply) addChange(
+	resourceType ResourceType,
+	name string,
+	action ChangeAction,
+) {
+	a.response.Changes = append(a.response.Changes, &AppliedChange{
+		Workspace:    a.workspaceName,
+		ResourceType: resourceType,
+		Name:         name,
+		Action:       action,
+	})
+}
+
+func (a *workspaceApply) toBackupConfiguration(
+	backupConfig *backups_config.BackupConfig,
+) *BackupConfiguration {
+	backupConfiguration := &BackupConfiguration{
+		BackupConfig:      *backupConfig,
+		SecondaryStorages: []string{},
+	}
+
+	for _, storage := range a.storages.itemsByName {
+		if backupConfig.StorageID != nil && storage.ID == *backupConfig.StorageID {
+			backupConfiguration.Storage = &storage.Name
+		}
+
+		if slices.Contains(backupConfig.GetSecondaryStorageIDs(), storage.ID) {
+			backupConfiguration.SecondaryStorages = append(
+				backupConfiguration.SecondaryStorages,
+				storage.Name,
+			)
+		}
+	}
+
+	slices.Sort(backupConfiguration.SecondaryStorages)
+
+	return backupConfiguration
+}
+
+func toDatabaseConfiguration(database *databases.Database) *DatabaseConfiguration {
+	notifierNames := make([]string, 0, len(database.Notifiers))
+	for _, notifier := range database.Notifiers {
+		notifierNames = append(notifierNames, notifier.Name)
+	}
+
+	slices.Sort(notifierNames)
+
+	return &DatabaseConfiguration{
+		Name:       database.Name,
+		Type:       database.Type,
+		Postgresql: database.Postgresql,
+		Mysql:      database.Mysql,
+		Mongodb:    database.Mongodb,
+		Notifiers:  notifierNames,
+	}
+}
+
+func toHealthcheckConfigDTO(
+	config *healthcheck_config.HealthcheckConfig,
+) healthcheck_config.HealthcheckConfigDTO {
+	return healthcheck_config.HealthcheckConfigDTO{
+		DatabaseID:                        config.DatabaseID,
+		IsHealthcheckEnabled:              config.IsHealthcheckEnabled,
+		IsSentNotificationWhenUnavailable: config.IsSentNotificationWhenUnavailable,
+		IntervalMinutes:                   config.IntervalMinutes,
+		AttemptsBeforeConcideredAsDown:    config.AttemptsBeforeConcideredAsDown,
+		StoreAttemptsDays:                 config.StoreAttemptsDays,
+	}
+}
+
+// validateConfiguration rejects duplicated names, because
+// resources are matched with existing ones by names
+func validateConfiguration(file *ConfigurationFile) error {
+	workspaceNames := make(map[string]bool)
+
+	for _, workspace := range file.Workspaces {
+		if workspace.Name == "" {
+			return errors.New("workspace name is required")
+		}
+
+		if workspaceNames[workspace.Name] {
+			return fmt.Errorf("workspace %s is declared twice", workspace.Name)
+		}
+		workspaceNames[workspace.Name] = true
+
+		names := make(map[string]bool)
+		for _, storage := range workspace.Storages {
+			if names["storage "+storage.Name] {
+				return fmt.Errorf("storage %s is declared twice", storage.Name)
+			}
+			names["storage "+storage.Name] = true
+		}
+
+		for _, notifier := range workspace.Notifiers {
+			if names["notifier "+notifier.Name] {
+				return fmt.Errorf("notifier %s is declared twice", notifier.Name)
+			}
+			names["notifier "+notifier.Name] = true
+		}
+
+		for _, database := range workspace.Databases {
+			if names["database "+database.Name] {
+				return fmt.Errorf("database %s is declared twice", database.Name)
+			}
+			names["database "+database.Name] = true
+		}
+	}
+
+	return nil
+}
+
+// validateAdmin requires password only if the admin signs in by it
+func validateAdmin(admin *AdminConfiguration, isPasswordLoginDisabled bool) error {
+	if _, err := mail.ParseAddress(admin.Email); err != nil {
+		return errors.New("admin email is invalid")
+	}
+
+	if isPasswordLoginDisabled {
+		return nil
+	}
+
+	// the same rule as on sign up
+	if len(admin.Password) < 8 {
+		return errors.New("admin password must be at least 8 characters")
+	}
+
+	return nil
+}
+
+// isSameConfiguration compares resources as they are
+// exported, ignoring IDs and states
+func isSameConfiguration(existing any, declared any) (bool, error) {
+	existingData, err := EncodeConfiguration(existing)
+	if err != nil {
+		return false, err
+	}
+
+	declaredData, err := EncodeConfiguration(declared)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(existingData, declaredData), nil
+}
+
+func newNamedItems[T any](items []*T, getName func(item *T) string) *namedItems[T] {
+	namedItems := &namedItems[T]{
+		itemsByName:     make(map[string]*T, len(items)),
+		duplicatedNames: make(map[string]bool),
+	}
+
+	for _, item := range items {
+		name := getName(item)
+		if namedItems.itemsByName[name] != nil {
+			namedItems.duplicatedNames[name] = true
+		}
+
+		namedItems.itemsByName[name] = item
+	}
+
+	return namedItems
+}
+
+// get returns nil if there is no resource with the name
+func (n *namedItems[T]) get(name string) (*T, error) {
+	if n.duplicatedNames[name] {
+		return nil, fmt.Errorf(
+			"there are several resources named %s in the workspace, rename them to match by name",
+			name,
+		)
+	}
+
+	return n.itemsByName[name], nil
+}
+
+func (n *namedItems[T]) set(name string, item *T) {
+	n.itemsByName[name] = item
+}
+
+func getChangeAction[T any](existing *T) ChangeAction {
+	if existing == nil {
+		return ChangeActionCreated
+	}
+
+	return ChangeActionUpdated
+}
@@ -2,6 +2,7 @@ package databases
 
 import (
 	"net/http"
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/users"
 
 	"github.com/gin-gonic/gin"
@@ -11,6 +12,7 @@ import (
 type DatabaseController struct {
 	databaseService *DatabaseService
 	userService     *users.UserService
+	auditLogService *audit_logs.AuditLogService
 }
 
 func (c *DatabaseController) RegisterRoutes(router *gin.RouterGroup) {
@@ -57,11 +59,26 @@ func (c *DatabaseController) CreateDatabase(ctx *gin.Context) {
 	}
 
 	database, err := c.databaseService.CreateDatabase(user, &request)
+
+	auditEvent := &audit_logs.AuditEvent{
+		Action:      audit_logs.AuditActionDatabaseCreate,
+		TargetType:  audit_logs.AuditTargetTypeDatabase,
+		TargetName:  request.Name,
+		WorkspaceID: &request.WorkspaceID,
+		After:       &request,
+		Err:         err,
+	}
+	if database != nil {
+		auditEvent.TargetID = &database.ID
+	}
+	c.auditLogService.LogUserAction(user, ctx.ClientIP(), auditEvent)
+
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	database.HideSensitiveData()
 	ctx.JSON(http.StatusCreated, database)
 }
 
@@ -96,11 +113,28 @@ func (c *DatabaseController) UpdateDatabase(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.databaseService.UpdateDatabase(user, &request); err != nil {
+	// access is checked on updating, so here is
+	// the old version for audit log only
+	existingDatabase, _ := c.databaseService.GetDatabase(user, request.ID)
+
+	err = c.databaseService.UpdateDatabase(user, &request)
+	c.auditLogService.LogUserAction(user, ctx.ClientIP(), &audit_logs.AuditEvent{
+		Action:      audit_logs.AuditActionDatabaseUpdate,
+		TargetType:  audit_logs.AuditTargetTypeDatabase,
+		TargetID:    &request.ID,
+		TargetName:  request.Name,
+		WorkspaceID: &request.WorkspaceID,
+		Before:      existingDatabase,
+		After:       &request,
+		Err:         err,
+	})
+
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	request.HideSensitiveData()
 	ctx.JSON(http.StatusOK, request)
 }
 
@@ -133,7 +167,23 @@ func (c *DatabaseController) DeleteDatabase(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.databaseService.DeleteDatabase(user, id); err != nil {
+	auditEvent := &audit_logs.AuditEvent{
+		Action:     audit_logs.AuditActionDatabaseDelete,
+		TargetType: audit_logs.AuditTargetTypeDatabase,
+		TargetID:   &id,
+	}
+
+	if database, err := c.databaseService.GetDatabase(user, id); err == nil {
+		auditEvent.TargetName = database.Name
+		auditEvent.WorkspaceID = &database.WorkspaceID
+	}
+
+	err = c.databaseService.DeleteDatabase(user, id)
+
+	auditEvent.Err = err
+	c.auditLogService.LogUserAction(user, ctx.ClientIP(), auditEvent)
+
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -176,14 +226,16 @@ func (c *DatabaseController) GetDatabase(ctx *gin.Context) {
 		return
 	}
 
+	database.HideSensitiveData()
 	ctx.JSON(http.StatusOK, database)
 }
 
 // GetDatabases
 // @Summary Get databases
-// @Description Get all databases for the authenticated user
+// @Description Get databases of the workspace or of all workspaces of the authenticated user
 // @Tags databases
 // @Produce json
+// @Param workspace_id query string false "Workspace ID"
 // @Success 200 {array} Database
 // @Failure 401
 // @Failure 500
@@ -201,12 +253,27 @@ func (c *DatabaseController) GetDatabases(ctx *gin.Context) {
 		return
 	}
 
-	databases, err := c.databaseService.GetDatabasesByUser(user)
+	var workspaceID *uuid.UUID
+	if workspaceIDParam := ctx.Query("workspace_id"); workspaceIDParam != "" {
+		id, err := uuid.Parse(workspaceIDParam)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+			return
+		}
+
+		workspaceID = &id
+	}
+
+	databases, err := c.databaseService.GetDatabasesByUser(user, workspaceID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	for _, database := range databases {
+		database.HideSensitiveData()
+	}
+
 	ctx.JSON(http.StatusOK, databases)
 }
 
@@ -279,7 +346,7 @@ func (c *DatabaseController) TestDatabaseConnectionDirect(ctx *gin.Context) {
 	// Set user ID for validation purposes
 	request.UserID = user.ID
 
-	if err := c.databaseService.TestDatabaseConnectionDirect(&request); err != nil {
+	if err := c.databaseService.TestDatabaseConnectionFromRequest(user, &request); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
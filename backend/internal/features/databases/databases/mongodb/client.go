@@ -0,0 +1,37 @@
+package mongodb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CreateTempConfigFile writes connection URI into temporary YAML file for
+// --config option of MongoDB tools, so the password is not visible in the
+// process list. Call cleanup func to remove the file
+func (m *MongodbDatabase) CreateTempConfigFile() (string, func(), error) {
+	// it always create unique directory like /tmp/mongocnf-1234567890
+	tempDir, err := os.MkdirTemp("", "mongocnf")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	cleanupFunc := func() {
+		_ = os.RemoveAll(tempDir)
+	}
+
+	content := fmt.Sprintf("uri: \"%s\"\n", escapeYamlValue(m.GetConnectionUri()))
+
+	configFile := filepath.Join(tempDir, "config.yaml")
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		cleanupFunc()
+		return "", nil, fmt.Errorf("failed to write temporary config file: %w", err)
+	}
+
+	return configFile, cleanupFunc, nil
+}
+
+func escapeYamlValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+}
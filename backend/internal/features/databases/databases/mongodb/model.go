@@ -0,0 +1,177 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/secrets"
+	"postgresus-backend/internal/util/tools"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MongodbDatabase struct {
+	ID uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+
+	DatabaseID *uuid.UUID `json:"databaseId" gorm:"type:uuid;column:database_id"`
+	RestoreID  *uuid.UUID `json:"restoreId"  gorm:"type:uuid;column:restore_id"`
+
+	// connection data
+	Host     string `json:"host"     gorm:"type:text;not null"`
+	Port     int    `json:"port"     gorm:"type:int;not null"`
+	Username string `json:"username" gorm:"type:text;not null"`
+	Password string `json:"password" gorm:"type:text;not null"`
+	// if empty, all databases of the deployment are backed up
+	Database     *string `json:"database"     gorm:"type:text"`
+	AuthDatabase string  `json:"authDatabase" gorm:"type:text;not null;default:'admin'"`
+	// set for replica sets, so tools discover members from the host
+	ReplicaSet *string `json:"replicaSet" gorm:"type:text"`
+	IsHttps    bool    `json:"isHttps"    gorm:"type:boolean;default:false"`
+}
+
+func (m *MongodbDatabase) TableName() string {
+	return "mongodb_databases"
+}
+
+func (m *MongodbDatabase) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&m.Password)
+}
+
+func (m *MongodbDatabase) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&m.Password)
+}
+
+func (m *MongodbDatabase) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&m.Password)
+}
+
+func (m *MongodbDatabase) HideSensitiveData() {
+	m.Password = ""
+}
+
+// FillSensitiveData keeps the stored password if the client did
+// not pass a new one and connects to the same server as the same user
+func (m *MongodbDatabase) FillSensitiveData(existing *MongodbDatabase) {
+	if existing == nil || m.Password != "" {
+		return
+	}
+
+	if m.Host == existing.Host && m.Port == existing.Port && m.Username == existing.Username {
+		m.Password = existing.Password
+	}
+}
+
+func (m *MongodbDatabase) Validate() error {
+	if m.Host == "" {
+		return errors.New("host is required")
+	}
+
+	if m.Port == 0 {
+		return errors.New("port is required")
+	}
+
+	if m.Username == "" {
+		return errors.New("username is required")
+	}
+
+	if m.Password == "" {
+		return errors.New("password is required")
+	}
+
+	if m.AuthDatabase == "" {
+		return errors.New("auth database is required")
+	}
+
+	if m.Database != nil && strings.ContainsAny(*m.Database, `/\. "$`) {
+		return errors.New("database name contains invalid characters")
+	}
+
+	return nil
+}
+
+// TestConnection runs mongodump of a collection which does not exist:
+// it connects and authenticates like a real backup, but dumps nothing
+func (m *MongodbDatabase) TestConnection(logger *slog.Logger) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	configFile, cleanupFunc, err := m.CreateTempConfigFile()
+	if err != nil {
+		return err
+	}
+	defer cleanupFunc()
+
+	databaseName := m.AuthDatabase
+	if m.Database != nil && *m.Database != "" {
+		databaseName = *m.Database
+	}
+
+	dumpBin := tools.GetMongodbExecutable(
+		tools.MongodbExecutableDump,
+		config.GetEnv().EnvMode,
+		config.GetEnv().MongodbInstallDir,
+	)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(
+		ctx,
+		dumpBin,
+		"--config="+configFile,
+		"--db="+databaseName,
+		"--collection=postgresus_connection_test",
+		"--archive="+os.DevNull,
+		"--quiet",
+	)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		logger.Error("MongoDB connection test failed", "error", err, "stderr", stderr.String())
+
+		return fmt.Errorf(
+			"failed to connect to database '%s': %s",
+			databaseName,
+			strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	return nil
+}
+
+// GetConnectionUri returns URI of the deployment with credentials.
+// Do not pass it in command line: it would be visible in the process list
+func (m *MongodbDatabase) GetConnectionUri() string {
+	query := url.Values{}
+	query.Set("authSource", m.AuthDatabase)
+
+	if m.ReplicaSet != nil && *m.ReplicaSet != "" {
+		query.Set("replicaSet", *m.ReplicaSet)
+	}
+
+	// the same as sslmode=require of PostgreSQL: encrypted,
+	// but server certificate is not verified
+	if m.IsHttps {
+		query.Set("tls", "true")
+		query.Set("tlsInsecure", "true")
+	}
+
+	connectionUrl := url.URL{
+		Scheme:   "mongodb",
+		User:     url.UserPassword(m.Username, m.Password),
+		Host:     net.JoinHostPort(m.Host, strconv.Itoa(m.Port)),
+		Path:     "/",
+		RawQuery: query.Encode(),
+	}
+
+	return connectionUrl.String()
+}
@@ -0,0 +1,63 @@
+package mysql
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"postgresus-backend/internal/util/tools"
+	"strings"
+)
+
+// CreateTempDefaultsFile writes connection options into temporary option
+// file for --defaults-extra-file, so the password is not visible in the
+// process list. Call cleanup func to remove the file
+func (m *MysqlDatabase) CreateTempDefaultsFile() (string, func(), error) {
+	// it always create unique directory like /tmp/mysqlcnf-1234567890
+	tempDir, err := os.MkdirTemp("", "mysqlcnf")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	cleanupFunc := func() {
+		_ = os.RemoveAll(tempDir)
+	}
+
+	content := fmt.Sprintf(
+		"[client]\nhost=%s\nport=%d\nuser=%s\npassword=\"%s\"\n",
+		m.Host,
+		m.Port,
+		m.Username,
+		escapeOptionValue(m.Password),
+	)
+
+	defaultsFile := filepath.Join(tempDir, "client.cnf")
+	if err := os.WriteFile(defaultsFile, []byte(content), 0600); err != nil {
+		cleanupFunc()
+		return "", nil, fmt.Errorf("failed to write temporary option file: %w", err)
+	}
+
+	return defaultsFile, cleanupFunc, nil
+}
+
+// GetClientSslArgs returns SSL options of the client tools. The same
+// as sslmode=require of PostgreSQL: when enabled, the connection is
+// encrypted, but server certificate is not verified
+func (m *MysqlDatabase) GetClientSslArgs() []string {
+	if m.Flavor == tools.MysqlFlavorMariadb {
+		if m.IsHttps {
+			return []string{"--ssl", "--skip-ssl-verify-server-cert"}
+		}
+
+		return []string{"--skip-ssl"}
+	}
+
+	if m.IsHttps {
+		return []string{"--ssl-mode=REQUIRED"}
+	}
+
+	return []string{"--ssl-mode=PREFERRED"}
+}
+
+func escapeOptionValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+}
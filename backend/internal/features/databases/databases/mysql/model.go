@@ -0,0 +1,166 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"postgresus-backend/internal/features/secrets"
+	"postgresus-backend/internal/util/tools"
+	"strconv"
+	"strings"
+	"time"
+
+	mysql_driver "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MysqlDatabase struct {
+	ID uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+
+	DatabaseID *uuid.UUID `json:"databaseId" gorm:"type:uuid;column:database_id"`
+	RestoreID  *uuid.UUID `json:"restoreId"  gorm:"type:uuid;column:restore_id"`
+
+	// MySQL and MariaDB are backed up by their own client tools
+	Flavor tools.MysqlFlavor `json:"flavor" gorm:"type:text;not null"`
+
+	// connection data
+	Host     string  `json:"host"     gorm:"type:text;not null"`
+	Port     int     `json:"port"     gorm:"type:int;not null"`
+	Username string  `json:"username" gorm:"type:text;not null"`
+	Password string  `json:"password" gorm:"type:text;not null"`
+	Database *string `json:"database" gorm:"type:text"`
+	IsHttps  bool    `json:"isHttps"  gorm:"type:boolean;default:false"`
+}
+
+func (m *MysqlDatabase) TableName() string {
+	return "mysql_databases"
+}
+
+func (m *MysqlDatabase) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&m.Password)
+}
+
+func (m *MysqlDatabase) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&m.Password)
+}
+
+func (m *MysqlDatabase) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&m.Password)
+}
+
+func (m *MysqlDatabase) HideSensitiveData() {
+	m.Password = ""
+}
+
+// FillSensitiveData keeps the stored password if the client did
+// not pass a new one and connects to the same server as the same user
+func (m *MysqlDatabase) FillSensitiveData(existing *MysqlDatabase) {
+	if existing == nil || m.Password != "" {
+		return
+	}
+
+	if m.Host == existing.Host && m.Port == existing.Port && m.Username == existing.Username {
+		m.Password = existing.Password
+	}
+}
+
+func (m *MysqlDatabase) Validate() error {
+	if m.Flavor != tools.MysqlFlavorMysql && m.Flavor != tools.MysqlFlavorMariadb {
+		return errors.New("flavor is invalid")
+	}
+
+	if m.Host == "" {
+		return errors.New("host is required")
+	}
+
+	if m.Port == 0 {
+		return errors.New("port is required")
+	}
+
+	if m.Username == "" {
+		return errors.New("username is required")
+	}
+
+	if m.Password == "" {
+		return errors.New("password is required")
+	}
+
+	if m.Database == nil || *m.Database == "" {
+		return errors.New("database name is required")
+	}
+
+	return nil
+}
+
+func (m *MysqlDatabase) TestConnection(logger *slog.Logger) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if m.Database == nil || *m.Database == "" {
+		return errors.New("database name is required")
+	}
+
+	db, err := sql.Open("mysql", m.GetDsn())
+	if err != nil {
+		return fmt.Errorf("failed to open connection: %w", err)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			logger.Error("Failed to close connection", "error", closeErr)
+		}
+	}()
+
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to connect to database '%s': %w", *m.Database, err)
+	}
+
+	var versionStr string
+	if err := db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&versionStr); err != nil {
+		return fmt.Errorf("failed to query database version: %w", err)
+	}
+
+	// MariaDB versions look like "11.4.2-MariaDB-ubu2404"
+	actualFlavor := tools.MysqlFlavorMysql
+	if strings.Contains(strings.ToLower(versionStr), "mariadb") {
+		actualFlavor = tools.MysqlFlavorMariadb
+	}
+
+	if actualFlavor != m.Flavor {
+		return fmt.Errorf(
+			"you specified wrong flavor. Real server is %s (%s), but you specified %s",
+			actualFlavor,
+			versionStr,
+			m.Flavor,
+		)
+	}
+
+	return nil
+}
+
+// GetDsn returns DSN of the configured database for the MySQL driver
+func (m *MysqlDatabase) GetDsn() string {
+	driverConfig := mysql_driver.NewConfig()
+	driverConfig.User = m.Username
+	driverConfig.Passwd = m.Password
+	driverConfig.Net = "tcp"
+	driverConfig.Addr = net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	driverConfig.Timeout = 30 * time.Second
+
+	if m.Database != nil {
+		driverConfig.DBName = *m.Database
+	}
+
+	// the same as sslmode=require of PostgreSQL: encrypted,
+	// but server certificate is not verified
+	if m.IsHttps {
+		driverConfig.TLSConfig = "skip-verify"
+	} else {
+		driverConfig.TLSConfig = "false"
+	}
+
+	return driverConfig.FormatDSN()
+}
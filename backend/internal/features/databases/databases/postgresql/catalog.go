@@ -0,0 +1,90 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CatalogTable struct {
+	Schema string
+	Name   string
+	// visible tables are found by unqualified name via search_path
+	IsVisible bool
+}
+
+type Catalog struct {
+	SchemaNames []string
+	Tables      []CatalogTable
+}
+
+// GetCatalog returns schemas and tables (including views, sequences and
+// foreign tables, as pg_dump table patterns match them too) of the database
+func (p *PostgresqlDatabase) GetCatalog(logger *slog.Logger) (*Catalog, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, p.GetConnectionString())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer func() {
+		if closeErr := conn.Close(ctx); closeErr != nil {
+			logger.Error("Failed to close connection", "error", closeErr)
+		}
+	}()
+
+	catalog := &Catalog{
+		SchemaNames: make([]string, 0),
+		Tables:      make([]CatalogTable, 0),
+	}
+
+	schemaRows, err := conn.Query(ctx, "SELECT nspname FROM pg_catalog.pg_namespace")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schemas: %w", err)
+	}
+
+	for schemaRows.Next() {
+		var schemaName string
+		if err := schemaRows.Scan(&schemaName); err != nil {
+			schemaRows.Close()
+			return nil, fmt.Errorf("failed to read schema: %w", err)
+		}
+
+		catalog.SchemaNames = append(catalog.SchemaNames, schemaName)
+	}
+
+	schemaRows.Close()
+	if err := schemaRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get schemas: %w", err)
+	}
+
+	tableRows, err := conn.Query(ctx, `
+		SELECT n.nspname, c.relname, pg_catalog.pg_table_is_visible(c.oid)
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f', 'S')`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tables: %w", err)
+	}
+
+	for tableRows.Next() {
+		var table CatalogTable
+		if err := tableRows.Scan(&table.Schema, &table.Name, &table.IsVisible); err != nil {
+			tableRows.Close()
+			return nil, fmt.Errorf("failed to read table: %w", err)
+		}
+
+		catalog.Tables = append(catalog.Tables, table)
+	}
+
+	tableRows.Close()
+	if err := tableRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get tables: %w", err)
+	}
+
+	return catalog, nil
+}
@@ -0,0 +1,141 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetClusterDatabaseNames returns names of the server databases
+// included into cluster backups, sorted by name. Templates and
+// databases which do not allow connections are never included
+func (p *PostgresqlDatabase) GetClusterDatabaseNames(logger *slog.Logger) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, p.GetConnectionString())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database server: %w", err)
+	}
+	defer func() {
+		if closeErr := conn.Close(ctx); closeErr != nil {
+			logger.Error("Failed to close connection", "error", closeErr)
+		}
+	}()
+
+	return getClusterDatabaseNames(ctx, conn, p)
+}
+
+// IsClusterDatabaseIncluded checks the database name against include
+// patterns (all databases if not set) and exclude patterns
+func (p *PostgresqlDatabase) IsClusterDatabaseIncluded(name string) bool {
+	includePatterns := parseDatabasePatterns(p.IncludeDatabases)
+	if len(includePatterns) > 0 && !matchesAnyPattern(name, includePatterns) {
+		return false
+	}
+
+	return !matchesAnyPattern(name, parseDatabasePatterns(p.ExcludeDatabases))
+}
+
+func testClusterConnection(
+	logger *slog.Logger,
+	ctx context.Context,
+	postgresDb *PostgresqlDatabase,
+) error {
+	conn, err := pgx.Connect(ctx, postgresDb.GetConnectionString())
+	if err != nil {
+		return fmt.Errorf("failed to connect to database server: %w", err)
+	}
+	defer func() {
+		if closeErr := conn.Close(ctx); closeErr != nil {
+			logger.Error("Failed to close connection", "error", closeErr)
+		}
+	}()
+
+	if err := verifyDatabaseVersion(ctx, conn, postgresDb.Version); err != nil {
+		return err
+	}
+
+	databaseNames, err := getClusterDatabaseNames(ctx, conn, postgresDb)
+	if err != nil {
+		return err
+	}
+
+	if len(databaseNames) == 0 {
+		return errors.New("no databases match include and exclude patterns")
+	}
+
+	return nil
+}
+
+func getClusterDatabaseNames(
+	ctx context.Context,
+	conn *pgx.Conn,
+	postgresDb *PostgresqlDatabase,
+) ([]string, error) {
+	rows, err := conn.Query(
+		ctx,
+		"SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate ORDER BY datname",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list databases: %w", err)
+	}
+
+	allDatabaseNames, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list databases: %w", err)
+	}
+
+	databaseNames := make([]string, 0, len(allDatabaseNames))
+	for _, databaseName := range allDatabaseNames {
+		if postgresDb.IsClusterDatabaseIncluded(databaseName) {
+			databaseNames = append(databaseNames, databaseName)
+		}
+	}
+
+	return databaseNames, nil
+}
+
+// parseDatabasePatterns splits comma separated patterns
+// like "app_*, analytics" into trimmed non empty patterns
+func parseDatabasePatterns(patterns *string) []string {
+	if patterns == nil {
+		return nil
+	}
+
+	parsedPatterns := make([]string, 0)
+	for _, pattern := range strings.Split(*patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern != "" {
+			parsedPatterns = append(parsedPatterns, pattern)
+		}
+	}
+
+	return parsedPatterns
+}
+
+func validateDatabasePatterns(patterns *string) error {
+	for _, pattern := range parseDatabasePatterns(patterns) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid database pattern: %s", pattern)
+		}
+	}
+
+	return nil
+}
+
+func matchesAnyPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if isMatched, _ := path.Match(pattern, name); isMatched {
+			return true
+		}
+	}
+
+	return false
+}
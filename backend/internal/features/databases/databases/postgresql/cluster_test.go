@@ -0,0 +1,53 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsClusterDatabaseIncluded_WithoutPatterns_AllIncluded(t *testing.T) {
+	pg := &PostgresqlDatabase{IsClusterMode: true}
+
+	assert.True(t, pg.IsClusterDatabaseIncluded("app"))
+	assert.True(t, pg.IsClusterDatabaseIncluded("postgres"))
+}
+
+func Test_IsClusterDatabaseIncluded_WithIncludePatterns_OnlyMatchedIncluded(t *testing.T) {
+	includeDatabases := "app_*, analytics"
+	pg := &PostgresqlDatabase{IsClusterMode: true, IncludeDatabases: &includeDatabases}
+
+	assert.True(t, pg.IsClusterDatabaseIncluded("app_orders"))
+	assert.True(t, pg.IsClusterDatabaseIncluded("analytics"))
+	assert.False(t, pg.IsClusterDatabaseIncluded("analytics_old"))
+	assert.False(t, pg.IsClusterDatabaseIncluded("postgres"))
+}
+
+func Test_IsClusterDatabaseIncluded_WithExcludePatterns_ExcludeWins(t *testing.T) {
+	includeDatabases := "app_*"
+	excludeDatabases := "app_test?,postgres"
+	pg := &PostgresqlDatabase{
+		IsClusterMode:    true,
+		IncludeDatabases: &includeDatabases,
+		ExcludeDatabases: &excludeDatabases,
+	}
+
+	assert.True(t, pg.IsClusterDatabaseIncluded("app_orders"))
+	assert.False(t, pg.IsClusterDatabaseIncluded("app_test1"))
+	assert.True(t, pg.IsClusterDatabaseIncluded("app_test10"))
+}
+
+func Test_Validate_WithInvalidDatabasePattern_ReturnsError(t *testing.T) {
+	excludeDatabases := "app_["
+	pg := &PostgresqlDatabase{
+		Version:          "16",
+		Host:             "localhost",
+		Port:             5432,
+		Username:         "postgres",
+		Password:         "postgres",
+		IsClusterMode:    true,
+		ExcludeDatabases: &excludeDatabases,
+	}
+
+	assert.Error(t, pg.Validate())
+}
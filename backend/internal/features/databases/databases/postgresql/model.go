@@ -5,19 +5,22 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"postgresus-backend/internal/features/secrets"
 	"postgresus-backend/internal/util/tools"
 	"regexp"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
 )
 
 type PostgresqlDatabase struct {
 	ID uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
 
-	DatabaseID *uuid.UUID `json:"databaseId" gorm:"type:uuid;column:database_id"`
-	RestoreID  *uuid.UUID `json:"restoreId"  gorm:"type:uuid;column:restore_id"`
+	DatabaseID     *uuid.UUID `json:"databaseId"     gorm:"type:uuid;column:database_id"`
+	RestoreID      *uuid.UUID `json:"restoreId"      gorm:"type:uuid;column:restore_id"`
+	BackupConfigID *uuid.UUID `json:"backupConfigId" gorm:"type:uuid;column:backup_config_id"`
 
 	Version tools.PostgresqlVersion `json:"version" gorm:"type:text;not null"`
 
@@ -28,12 +31,49 @@ type PostgresqlDatabase struct {
 	Password string  `json:"password" gorm:"type:text;not null"`
 	Database *string `json:"database" gorm:"type:text"`
 	IsHttps  bool    `json:"isHttps"  gorm:"type:boolean;default:false"`
+
+	// cluster mode backs up globals (roles, tablespaces) and every
+	// database of the server matched by include and exclude patterns.
+	// Database is used only to connect to the server then
+	IsClusterMode    bool    `json:"isClusterMode"    gorm:"column:is_cluster_mode;type:boolean;not null;default:false"`
+	IncludeDatabases *string `json:"includeDatabases" gorm:"column:include_databases;type:text"`
+	ExcludeDatabases *string `json:"excludeDatabases" gorm:"column:exclude_databases;type:text"`
 }
 
 func (p *PostgresqlDatabase) TableName() string {
 	return "postgresql_databases"
 }
 
+func (p *PostgresqlDatabase) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&p.Password)
+}
+
+func (p *PostgresqlDatabase) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&p.Password)
+}
+
+func (p *PostgresqlDatabase) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&p.Password)
+}
+
+// HideSensitiveData clears the password before the model is sent to API clients
+func (p *PostgresqlDatabase) HideSensitiveData() {
+	p.Password = ""
+}
+
+// FillSensitiveData takes the hidden password from the stored model when the
+// client left it empty. Changed host or user require the password again, so
+// the stored one cannot be sent to another server
+func (p *PostgresqlDatabase) FillSensitiveData(existing *PostgresqlDatabase) {
+	if existing == nil || p.Password != "" {
+		return
+	}
+
+	if p.Host == existing.Host && p.Port == existing.Port && p.Username == existing.Username {
+		p.Password = existing.Password
+	}
+}
+
 func (p *PostgresqlDatabase) Validate() error {
 	if p.Version == "" {
 		return errors.New("version is required")
@@ -55,6 +95,16 @@ func (p *PostgresqlDatabase) Validate() error {
 		return errors.New("password is required")
 	}
 
+	if p.IsClusterMode {
+		if err := validateDatabasePatterns(p.IncludeDatabases); err != nil {
+			return err
+		}
+
+		if err := validateDatabasePatterns(p.ExcludeDatabases); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -62,9 +112,24 @@ func (p *PostgresqlDatabase) TestConnection(logger *slog.Logger) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
+	if p.IsClusterMode {
+		return testClusterConnection(logger, ctx, p)
+	}
+
 	return testSingleDatabaseConnection(logger, ctx, p)
 }
 
+// GetConnectionString returns connection string for the configured
+// database (or for "postgres" database if it is not set)
+func (p *PostgresqlDatabase) GetConnectionString() string {
+	dbName := "postgres"
+	if p.Database != nil && *p.Database != "" {
+		dbName = *p.Database
+	}
+
+	return buildConnectionStringForDB(p, dbName)
+}
+
 // testSingleDatabaseConnection tests connection to a specific database for pg_dump
 func testSingleDatabaseConnection(
 	logger *slog.Logger,
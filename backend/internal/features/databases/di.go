@@ -1,8 +1,10 @@
 package databases
 
 import (
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/notifiers"
 	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/features/workspaces"
 	"postgresus-backend/internal/util/logger"
 )
 
@@ -11,6 +13,7 @@ var databaseRepository = &DatabaseRepository{}
 var databaseService = &DatabaseService{
 	databaseRepository,
 	notifiers.GetNotifierService(),
+	workspaces.GetWorkspaceService(),
 	logger.GetLogger(),
 	[]DatabaseCreationListener{},
 	[]DatabaseRemoveListener{},
@@ -19,6 +22,7 @@ var databaseService = &DatabaseService{
 var databaseController = &DatabaseController{
 	databaseService,
 	users.GetUserService(),
+	audit_logs.GetAuditLogService(),
 }
 
 func GetDatabaseService() *DatabaseService {
@@ -4,6 +4,8 @@ type DatabaseType string
 
 const (
 	DatabaseTypePostgres DatabaseType = "POSTGRES"
+	DatabaseTypeMysql    DatabaseType = "MYSQL"
+	DatabaseTypeMongodb  DatabaseType = "MONGODB"
 )
 
 type HealthStatus string
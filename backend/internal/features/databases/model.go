@@ -3,6 +3,8 @@ package databases
 import (
 	"errors"
 	"log/slog"
+	"postgresus-backend/internal/features/databases/databases/mongodb"
+	"postgresus-backend/internal/features/databases/databases/mysql"
 	"postgresus-backend/internal/features/databases/databases/postgresql"
 	"postgresus-backend/internal/features/notifiers"
 	"time"
@@ -11,12 +13,15 @@ import (
 )
 
 type Database struct {
-	ID     uuid.UUID    `json:"id"     gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
-	UserID uuid.UUID    `json:"userId" gorm:"column:user_id;type:uuid;not null"`
-	Name   string       `json:"name"   gorm:"column:name;type:text;not null"`
-	Type   DatabaseType `json:"type"   gorm:"column:type;type:text;not null"`
+	ID          uuid.UUID    `json:"id"          gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID      uuid.UUID    `json:"userId"      gorm:"column:user_id;type:uuid;not null"`
+	WorkspaceID uuid.UUID    `json:"workspaceId" gorm:"column:workspace_id;type:uuid;not null"`
+	Name        string       `json:"name"        gorm:"column:name;type:text;not null"`
+	Type        DatabaseType `json:"type"        gorm:"column:type;type:text;not null"`
 
 	Postgresql *postgresql.PostgresqlDatabase `json:"postgresql,omitempty" gorm:"foreignKey:DatabaseID"`
+	Mysql      *mysql.MysqlDatabase           `json:"mysql,omitempty"      gorm:"foreignKey:DatabaseID"`
+	Mongodb    *mongodb.MongodbDatabase       `json:"mongodb,omitempty"    gorm:"foreignKey:DatabaseID"`
 
 	Notifiers []notifiers.Notifier `json:"notifiers" gorm:"many2many:database_notifiers;"`
 
@@ -35,7 +40,23 @@ func (d *Database) Validate() error {
 
 	switch d.Type {
 	case DatabaseTypePostgres:
+		if d.Postgresql == nil {
+			return errors.New("postgresql database is required")
+		}
+
 		return d.Postgresql.Validate()
+	case DatabaseTypeMysql:
+		if d.Mysql == nil {
+			return errors.New("mysql database is required")
+		}
+
+		return d.Mysql.Validate()
+	case DatabaseTypeMongodb:
+		if d.Mongodb == nil {
+			return errors.New("mongodb database is required")
+		}
+
+		return d.Mongodb.Validate()
 	default:
 		return errors.New("invalid database type: " + string(d.Type))
 	}
@@ -49,6 +70,46 @@ func (d *Database) ValidateUpdate(old, new Database) error {
 	return nil
 }
 
+// HideSensitiveData clears connection credentials and secrets of
+// the attached notifiers before the database is returned by the API
+func (d *Database) HideSensitiveData() {
+	if d.Postgresql != nil {
+		d.Postgresql.HideSensitiveData()
+	}
+
+	if d.Mysql != nil {
+		d.Mysql.HideSensitiveData()
+	}
+
+	if d.Mongodb != nil {
+		d.Mongodb.HideSensitiveData()
+	}
+
+	for i := range d.Notifiers {
+		d.Notifiers[i].HideSensitiveData()
+	}
+}
+
+// FillSensitiveData restores the password which the client left empty
+// from the stored database
+func (d *Database) FillSensitiveData(existing *Database) {
+	if existing == nil || d.Type != existing.Type {
+		return
+	}
+
+	if d.Postgresql != nil {
+		d.Postgresql.FillSensitiveData(existing.Postgresql)
+	}
+
+	if d.Mysql != nil {
+		d.Mysql.FillSensitiveData(existing.Mysql)
+	}
+
+	if d.Mongodb != nil {
+		d.Mongodb.FillSensitiveData(existing.Mongodb)
+	}
+}
+
 func (d *Database) TestConnection(logger *slog.Logger) error {
 	return d.getSpecificDatabase().TestConnection(logger)
 }
@@ -57,6 +118,10 @@ func (d *Database) getSpecificDatabase() DatabaseConnector {
 	switch d.Type {
 	case DatabaseTypePostgres:
 		return d.Postgresql
+	case DatabaseTypeMysql:
+		return d.Mysql
+	case DatabaseTypeMongodb:
+		return d.Mongodb
 	}
 
 	panic("invalid database type: " + string(d.Type))
@@ -1,6 +1,8 @@
 package databases
 
 import (
+	"postgresus-backend/internal/features/databases/databases/mongodb"
+	"postgresus-backend/internal/features/databases/databases/mysql"
 	"postgresus-backend/internal/features/databases/databases/postgresql"
 	"postgresus-backend/internal/storage"
 
@@ -24,17 +26,25 @@ func (r *DatabaseRepository) Save(database *Database) (*Database, error) {
 			if database.Postgresql != nil {
 				database.Postgresql.DatabaseID = &database.ID
 			}
+		case DatabaseTypeMysql:
+			if database.Mysql != nil {
+				database.Mysql.DatabaseID = &database.ID
+			}
+		case DatabaseTypeMongodb:
+			if database.Mongodb != nil {
+				database.Mongodb.DatabaseID = &database.ID
+			}
 		}
 
 		if isNew {
 			if err := tx.Create(database).
-				Omit("Postgresql", "Notifiers").
+				Omit("Postgresql", "Mysql", "Mongodb", "Notifiers").
 				Error; err != nil {
 				return err
 			}
 		} else {
 			if err := tx.Save(database).
-				Omit("Postgresql", "Notifiers").
+				Omit("Postgresql", "Mysql", "Mongodb", "Notifiers").
 				Error; err != nil {
 				return err
 			}
@@ -56,6 +66,34 @@ func (r *DatabaseRepository) Save(database *Database) (*Database, error) {
 					}
 				}
 			}
+		case DatabaseTypeMysql:
+			if database.Mysql != nil {
+				database.Mysql.DatabaseID = &database.ID
+				if database.Mysql.ID == uuid.Nil {
+					database.Mysql.ID = uuid.New()
+					if err := tx.Create(database.Mysql).Error; err != nil {
+						return err
+					}
+				} else {
+					if err := tx.Save(database.Mysql).Error; err != nil {
+						return err
+					}
+				}
+			}
+		case DatabaseTypeMongodb:
+			if database.Mongodb != nil {
+				database.Mongodb.DatabaseID = &database.ID
+				if database.Mongodb.ID == uuid.Nil {
+					database.Mongodb.ID = uuid.New()
+					if err := tx.Create(database.Mongodb).Error; err != nil {
+						return err
+					}
+				} else {
+					if err := tx.Save(database.Mongodb).Error; err != nil {
+						return err
+					}
+				}
+			}
 		}
 
 		if err := tx.
@@ -81,6 +119,8 @@ func (r *DatabaseRepository) FindByID(id uuid.UUID) (*Database, error) {
 	if err := storage.
 		GetDb().
 		Preload("Postgresql").
+		Preload("Mysql").
+		Preload("Mongodb").
 		Preload("Notifiers").
 		Where("id = ?", id).
 		First(&database).Error; err != nil {
@@ -90,14 +130,16 @@ func (r *DatabaseRepository) FindByID(id uuid.UUID) (*Database, error) {
 	return &database, nil
 }
 
-func (r *DatabaseRepository) FindByUserID(userID uuid.UUID) ([]*Database, error) {
+func (r *DatabaseRepository) FindByWorkspaceIDs(workspaceIDs []uuid.UUID) ([]*Database, error) {
 	var databases []*Database
 
 	if err := storage.
 		GetDb().
 		Preload("Postgresql").
+		Preload("Mysql").
+		Preload("Mongodb").
 		Preload("Notifiers").
-		Where("user_id = ?", userID).
+		Where("workspace_id IN ?", workspaceIDs).
 		Order("CASE WHEN health_status = 'UNAVAILABLE' THEN 1 WHEN health_status = 'AVAILABLE' THEN 2 WHEN health_status IS NULL THEN 3 ELSE 4 END, name ASC").
 		Find(&databases).Error; err != nil {
 		return nil, err
@@ -126,6 +168,18 @@ func (r *DatabaseRepository) Delete(id uuid.UUID) error {
 				Delete(&postgresql.PostgresqlDatabase{}).Error; err != nil {
 				return err
 			}
+		case DatabaseTypeMysql:
+			if err := tx.
+				Where("database_id = ?", id).
+				Delete(&mysql.MysqlDatabase{}).Error; err != nil {
+				return err
+			}
+		case DatabaseTypeMongodb:
+			if err := tx.
+				Where("database_id = ?", id).
+				Delete(&mongodb.MongodbDatabase{}).Error; err != nil {
+				return err
+			}
 		}
 
 		if err := tx.Delete(&Database{}, id).Error; err != nil {
@@ -156,6 +210,8 @@ func (r *DatabaseRepository) GetAllDatabases() ([]*Database, error) {
 	if err := storage.
 		GetDb().
 		Preload("Postgresql").
+		Preload("Mysql").
+		Preload("Mongodb").
 		Preload("Notifiers").
 		Find(&databases).Error; err != nil {
 		return nil, err
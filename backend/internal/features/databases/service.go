@@ -5,15 +5,17 @@ import (
 	"log/slog"
 	"postgresus-backend/internal/features/notifiers"
 	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/features/workspaces"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type DatabaseService struct {
-	dbRepository    *DatabaseRepository
-	notifierService *notifiers.NotifierService
-	logger          *slog.Logger
+	dbRepository     *DatabaseRepository
+	notifierService  *notifiers.NotifierService
+	workspaceService *workspaces.WorkspaceService
+	logger           *slog.Logger
 
 	dbCreationListener []DatabaseCreationListener
 	dbRemoveListener   []DatabaseRemoveListener
@@ -37,10 +39,27 @@ func (s *DatabaseService) CreateDatabase(
 ) (*Database, error) {
 	database.UserID = user.ID
 
+	if database.WorkspaceID == uuid.Nil {
+		workspaceID, err := s.workspaceService.GetDefaultWorkspaceID(user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		database.WorkspaceID = workspaceID
+	}
+
+	if err := s.CheckAccess(user, database, workspaces.WorkspaceRoleAdmin); err != nil {
+		return nil, err
+	}
+
 	if err := database.Validate(); err != nil {
 		return nil, err
 	}
 
+	if err := s.validateNotifiersWorkspace(user, database); err != nil {
+		return nil, err
+	}
+
 	database, err := s.dbRepository.Save(database)
 	if err != nil {
 		return nil, err
@@ -66,10 +85,14 @@ func (s *DatabaseService) UpdateDatabase(
 		return err
 	}
 
-	if existingDatabase.UserID != user.ID {
-		return errors.New("you have not access to this database")
+	if err := s.CheckAccess(user, existingDatabase, workspaces.WorkspaceRoleAdmin); err != nil {
+		return err
 	}
 
+	database.UserID = existingDatabase.UserID
+	database.WorkspaceID = existingDatabase.WorkspaceID
+	database.FillSensitiveData(existingDatabase)
+
 	// Validate the update
 	if err := database.ValidateUpdate(*existingDatabase, *database); err != nil {
 		return err
@@ -79,6 +102,10 @@ func (s *DatabaseService) UpdateDatabase(
 		return err
 	}
 
+	if err := s.validateNotifiersWorkspace(user, database); err != nil {
+		return err
+	}
+
 	_, err = s.dbRepository.Save(database)
 	if err != nil {
 		return err
@@ -96,8 +123,8 @@ func (s *DatabaseService) DeleteDatabase(
 		return err
 	}
 
-	if existingDatabase.UserID != user.ID {
-		return errors.New("you have not access to this database")
+	if err := s.CheckAccess(user, existingDatabase, workspaces.WorkspaceRoleAdmin); err != nil {
+		return err
 	}
 
 	for _, listener := range s.dbRemoveListener {
@@ -118,17 +145,72 @@ func (s *DatabaseService) GetDatabase(
 		return nil, err
 	}
 
-	if database.UserID != user.ID {
-		return nil, errors.New("you have not access to this database")
+	if err := s.CheckAccess(user, database, workspaces.WorkspaceRoleViewer); err != nil {
+		return nil, err
 	}
 
 	return database, nil
 }
 
+// GetDatabasesByUser returns databases of the workspace or, if workspace
+// is not specified, databases of all workspaces of the user
 func (s *DatabaseService) GetDatabasesByUser(
 	user *users_models.User,
+	workspaceID *uuid.UUID,
 ) ([]*Database, error) {
-	return s.dbRepository.FindByUserID(user.ID)
+	var workspaceIDs []uuid.UUID
+
+	if workspaceID != nil {
+		if err := s.workspaceService.CheckRole(
+			user,
+			*workspaceID,
+			workspaces.WorkspaceRoleViewer,
+		); err != nil {
+			return nil, err
+		}
+
+		workspaceIDs = []uuid.UUID{*workspaceID}
+	} else {
+		userWorkspaceIDs, err := s.workspaceService.GetWorkspaceIDs(user)
+		if err != nil {
+			return nil, err
+		}
+
+		workspaceIDs = userWorkspaceIDs
+	}
+
+	databases, err := s.dbRepository.FindByWorkspaceIDs(workspaceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.ApiToken == nil {
+		return databases, nil
+	}
+
+	allowedDatabases := make([]*Database, 0, len(databases))
+	for _, database := range databases {
+		if user.ApiToken.IsDatabaseAllowed(database.ID) {
+			allowedDatabases = append(allowedDatabases, database)
+		}
+	}
+
+	return allowedDatabases, nil
+}
+
+// CheckAccess returns error unless the user has the role or a higher
+// one in the workspace of the database. API tokens may be restricted
+// to some databases only
+func (s *DatabaseService) CheckAccess(
+	user *users_models.User,
+	database *Database,
+	minRole workspaces.WorkspaceRole,
+) error {
+	if user.ApiToken != nil && !user.ApiToken.IsDatabaseAllowed(database.ID) {
+		return errors.New("API token has no access to this database")
+	}
+
+	return s.workspaceService.CheckRole(user, database.WorkspaceID, minRole)
 }
 
 func (s *DatabaseService) IsNotifierUsing(
@@ -152,8 +234,8 @@ func (s *DatabaseService) TestDatabaseConnection(
 		return err
 	}
 
-	if database.UserID != user.ID {
-		return errors.New("you have not access to this database")
+	if err := s.CheckAccess(user, database, workspaces.WorkspaceRoleOperator); err != nil {
+		return err
 	}
 
 	err = database.TestConnection(s.logger)
@@ -179,6 +261,29 @@ func (s *DatabaseService) TestDatabaseConnectionDirect(
 	return database.TestConnection(s.logger)
 }
 
+// TestDatabaseConnectionFromRequest tests connection settings sent by the
+// client. For saved databases the stored password is used when the client
+// left it empty
+func (s *DatabaseService) TestDatabaseConnectionFromRequest(
+	user *users_models.User,
+	database *Database,
+) error {
+	if database.ID != uuid.Nil {
+		existingDatabase, err := s.dbRepository.FindByID(database.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.CheckAccess(user, existingDatabase, workspaces.WorkspaceRoleOperator); err != nil {
+			return err
+		}
+
+		database.FillSensitiveData(existingDatabase)
+	}
+
+	return s.TestDatabaseConnectionDirect(database)
+}
+
 func (s *DatabaseService) GetDatabaseByID(
 	id uuid.UUID,
 ) (*Database, error) {
@@ -237,3 +342,23 @@ func (s *DatabaseService) SetHealthStatus(
 
 	return nil
 }
+
+// validateNotifiersWorkspace prevents attaching notifiers
+// of another workspace to the database
+func (s *DatabaseService) validateNotifiersWorkspace(
+	user *users_models.User,
+	database *Database,
+) error {
+	for _, databaseNotifier := range database.Notifiers {
+		notifier, err := s.notifierService.GetNotifier(user, databaseNotifier.ID)
+		if err != nil {
+			return err
+		}
+
+		if notifier.WorkspaceID != database.WorkspaceID {
+			return errors.New("notifier belongs to another workspace than the database")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package databases
+
+import (
+	"postgresus-backend/internal/features/notifiers"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/features/users"
+	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/features/workspaces"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_UpdateAndDeleteDatabaseByOperator_AccessDenied(t *testing.T) {
+	owner := users.GetTestUser()
+	storage := storages.CreateTestStorage(owner.UserID)
+	notifier := notifiers.CreateTestNotifier(owner.UserID)
+	database := CreateTestDatabase(owner.UserID, storage, notifier)
+
+	defer storages.RemoveTestStorage(storage.ID)
+	defer notifiers.RemoveTestNotifier(notifier)
+	defer RemoveTestDatabase(database)
+
+	operator := getTestSignedInUser(t, users.CreateTestUser())
+	workspaces.AddTestMember(database.WorkspaceID, operator.ID, workspaces.WorkspaceRoleOperator)
+
+	// operator can see the database, but cannot change it
+	operatorDatabase, err := databaseService.GetDatabase(operator, database.ID)
+	assert.NoError(t, err)
+
+	operatorDatabase.Name = "updated " + database.Name
+	err = databaseService.UpdateDatabase(operator, operatorDatabase)
+	assert.ErrorContains(t, err, "admin role or higher is required")
+
+	err = databaseService.DeleteDatabase(operator, database.ID)
+	assert.ErrorContains(t, err, "admin role or higher is required")
+
+	_, err = databaseService.CreateDatabase(operator, &Database{
+		WorkspaceID: database.WorkspaceID,
+		Name:        "created by operator",
+		Type:        DatabaseTypePostgres,
+	})
+	assert.ErrorContains(t, err, "admin role or higher is required")
+
+	savedDatabase, err := databaseRepository.FindByID(database.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, database.Name, savedDatabase.Name)
+}
+
+func Test_GetDatabaseByMemberOfAnotherWorkspace_AccessDenied(t *testing.T) {
+	owner := users.GetTestUser()
+	storage := storages.CreateTestStorage(owner.UserID)
+	notifier := notifiers.CreateTestNotifier(owner.UserID)
+	database := CreateTestDatabase(owner.UserID, storage, notifier)
+
+	defer storages.RemoveTestStorage(storage.ID)
+	defer notifiers.RemoveTestNotifier(notifier)
+	defer RemoveTestDatabase(database)
+
+	stranger := getTestSignedInUser(t, users.CreateTestUser())
+	workspaces.CreateTestWorkspace(stranger.ID)
+
+	_, err := databaseService.GetDatabase(stranger, database.ID)
+	assert.ErrorContains(t, err, "you have not access to this workspace")
+
+	err = databaseService.DeleteDatabase(stranger, database.ID)
+	assert.ErrorContains(t, err, "you have not access to this workspace")
+
+	strangerDatabases, err := databaseService.GetDatabasesByUser(stranger, nil)
+	assert.NoError(t, err)
+	for _, strangerDatabase := range strangerDatabases {
+		assert.NotEqual(t, database.ID, strangerDatabase.ID)
+	}
+}
+
+func getTestSignedInUser(t *testing.T, signInResponse *users.SignInResponse) *users_models.User {
+	user, err := users.GetUserService().GetUserFromToken(signInResponse.Token)
+	assert.NoError(t, err)
+
+	return user
+}
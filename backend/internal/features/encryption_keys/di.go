@@ -0,0 +1,56 @@
+package encryption_keys
+
+import (
+	"postgresus-backend/internal/util/logger"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var secretKeyRepository = &DataKeyRepository{"secret_encryption_keys"}
+var backupKeyRepository = &DataKeyRepository{"backup_encryption_keys"}
+var masterKeyProvider = &MasterKeyProvider{
+	sync.Mutex{},
+	nil,
+	[]*DataKeyRepository{secretKeyRepository, backupKeyRepository},
+}
+var secretKeyStore = &KeyStore{
+	secretKeyRepository,
+	masterKeyProvider,
+	"secret encryption key",
+	sync.Mutex{},
+	sync.Mutex{},
+	map[uuid.UUID]DataKey{},
+}
+var backupKeyStore = &KeyStore{
+	backupKeyRepository,
+	masterKeyProvider,
+	"backup encryption key",
+	sync.Mutex{},
+	sync.Mutex{},
+	map[uuid.UUID]DataKey{},
+}
+var masterKeyService = &MasterKeyService{
+	masterKeyProvider,
+	[]*KeyStore{secretKeyStore, backupKeyStore},
+	logger.GetLogger(),
+}
+
+func GetMasterKeyProvider() *MasterKeyProvider {
+	return masterKeyProvider
+}
+
+// GetSecretKeyStore returns keys encrypting secrets stored in DB
+// (passwords, tokens)
+func GetSecretKeyStore() *KeyStore {
+	return secretKeyStore
+}
+
+// GetBackupKeyStore returns keys encrypting backup files
+func GetBackupKeyStore() *KeyStore {
+	return backupKeyStore
+}
+
+func GetMasterKeyService() *MasterKeyService {
+	return masterKeyService
+}
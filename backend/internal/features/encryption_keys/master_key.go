@@ -0,0 +1,118 @@
+package encryption_keys
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/util/encryption"
+	"strings"
+	"sync"
+)
+
+type MasterKeyProvider struct {
+	mu        sync.Mutex
+	masterKey []byte
+
+	// repositories of keys wrapped by the master key
+	keyRepositories []*DataKeyRepository
+}
+
+// GetMasterKey returns the key from MASTER_KEY env variable or reads it
+// from the key file. If neither exists, a new key is generated and
+// written to the key file, but only while there are no keys wrapped by
+// the lost one: a new key would not decrypt them anyway
+func (p *MasterKeyProvider) GetMasterKey() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.masterKey != nil {
+		return p.masterKey, nil
+	}
+
+	if envMasterKey := config.GetEnv().MasterKey; envMasterKey != "" {
+		masterKey, err := DecodeMasterKey(envMasterKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode MASTER_KEY: %w", err)
+		}
+
+		p.masterKey = masterKey
+		return p.masterKey, nil
+	}
+
+	keyFile := config.GetEnv().MasterKeyFile
+
+	content, err := os.ReadFile(keyFile)
+	if err == nil {
+		masterKey, err := DecodeMasterKey(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode master key file: %w", err)
+		}
+
+		p.masterKey = masterKey
+		return p.masterKey, nil
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read master key file: %w", err)
+	}
+
+	for _, keyRepository := range p.keyRepositories {
+		isAnyKeyExist, err := keyRepository.IsAnyKeyExist()
+		if err != nil {
+			return nil, fmt.Errorf("failed to check encryption keys: %w", err)
+		}
+
+		if isAnyKeyExist {
+			return nil, fmt.Errorf(
+				"master key is missing: MASTER_KEY is not set and key file %s does not exist, "+
+					"but stored encryption keys are wrapped by it. Restore the key file "+
+					"or set MASTER_KEY to the key used before",
+				keyFile,
+			)
+		}
+	}
+
+	masterKey, err := encryption.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create master key directory: %w", err)
+	}
+
+	if err := os.WriteFile(
+		keyFile,
+		[]byte(base64.StdEncoding.EncodeToString(masterKey)),
+		0600,
+	); err != nil {
+		return nil, fmt.Errorf("failed to write master key file: %w", err)
+	}
+
+	p.masterKey = masterKey
+	return p.masterKey, nil
+}
+
+func (p *MasterKeyProvider) setMasterKey(masterKey []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.masterKey = masterKey
+}
+
+// DecodeMasterKey parses base64 encoded master key
+func DecodeMasterKey(encodedKey string) ([]byte, error) {
+	masterKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(masterKey) != encryption.KeySize {
+		return nil, fmt.Errorf("master key must be %d bytes", encryption.KeySize)
+	}
+
+	return masterKey, nil
+}
@@ -0,0 +1,89 @@
+package encryption_keys
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+type MasterKeyService struct {
+	masterKeyProvider *MasterKeyProvider
+	keyStores         []*KeyStore
+	logger            *slog.Logger
+}
+
+// RotateMasterKey re-wraps data keys of all key stores with the new master
+// key in one transaction, so either all keys or none are re-wrapped. Data
+// keys themselves are not changed, so secrets and backups are not touched.
+// If the master key is read from the key file, the file is replaced. If it
+// is taken from MASTER_KEY, the variable has to be changed before restart
+func (s *MasterKeyService) RotateMasterKey(newMasterKey []byte) error {
+	oldMasterKey, err := s.masterKeyProvider.GetMasterKey()
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(oldMasterKey, newMasterKey) {
+		return errors.New("new master key is the same as the current one")
+	}
+
+	isKeyFromFile := config.GetEnv().MasterKey == ""
+	keyFile := config.GetEnv().MasterKeyFile
+	newKeyFile := keyFile + ".new"
+
+	// the new key is written before the transaction, so the key
+	// is not lost if keys are re-wrapped, but the file is not replaced
+	if isKeyFromFile {
+		if err := os.WriteFile(
+			newKeyFile,
+			[]byte(base64.StdEncoding.EncodeToString(newMasterKey)),
+			0600,
+		); err != nil {
+			return fmt.Errorf("failed to write new master key file: %w", err)
+		}
+	}
+
+	err = storage.GetDb().Transaction(func(tx *gorm.DB) error {
+		for _, keyStore := range s.keyStores {
+			if err := keyStore.rewrapKeysInTx(tx, oldMasterKey, newMasterKey); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		if isKeyFromFile {
+			_ = os.Remove(newKeyFile)
+		}
+
+		return err
+	}
+
+	s.masterKeyProvider.setMasterKey(newMasterKey)
+
+	if !isKeyFromFile {
+		s.logger.Warn("Master key rotated, set MASTER_KEY to the new key before restart")
+		return nil
+	}
+
+	if err := os.Rename(newKeyFile, keyFile); err != nil {
+		return fmt.Errorf(
+			"keys are re-wrapped, but failed to replace master key file, move %s to %s: %w",
+			newKeyFile,
+			keyFile,
+			err,
+		)
+	}
+
+	s.logger.Info("Master key rotated", "keyFile", keyFile)
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package encryption_keys
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DataKey is a key used to encrypt data (secrets or backup files). The
+// key itself is stored encrypted with the master key. Each key store
+// keeps its keys in its own table with the same columns
+type DataKey struct {
+	ID uuid.UUID `json:"id" gorm:"column:id;type:uuid;primaryKey"`
+
+	EncryptedKey string `json:"-" gorm:"column:encrypted_key;type:text;not null"`
+
+	IsActive bool `json:"isActive" gorm:"column:is_active;type:boolean;not null"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+
+	Key []byte `json:"-" gorm:"-"`
+}
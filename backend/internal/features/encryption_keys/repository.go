@@ -0,0 +1,112 @@
+package encryption_keys
+
+import (
+	"errors"
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DataKeyRepository struct {
+	tableName string
+}
+
+// CreateActive creates new active key and deactivates all previous ones
+func (r *DataKeyRepository) CreateActive(key *DataKey) error {
+	return storage.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Table(r.tableName).
+			Where("is_active = ?", true).
+			Update("is_active", false).Error; err != nil {
+			return err
+		}
+
+		if key.ID == uuid.Nil {
+			key.ID = uuid.New()
+		}
+		key.IsActive = true
+
+		return tx.Table(r.tableName).Create(key).Error
+	})
+}
+
+func (r *DataKeyRepository) IsAnyKeyExist() (bool, error) {
+	var count int64
+
+	if err := storage.
+		GetDb().
+		Table(r.tableName).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *DataKeyRepository) FindActive() (*DataKey, error) {
+	var key DataKey
+
+	if err := storage.
+		GetDb().
+		Table(r.tableName).
+		Where("is_active = ?", true).
+		Order("created_at DESC").
+		First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *DataKeyRepository) FindByID(id uuid.UUID) (*DataKey, error) {
+	var key DataKey
+
+	if err := storage.
+		GetDb().
+		Table(r.tableName).
+		Where("id = ?", id).
+		First(&key).Error; err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *DataKeyRepository) FindAll() ([]*DataKey, error) {
+	return r.FindAllInTx(storage.GetDb())
+}
+
+func (r *DataKeyRepository) FindAllInTx(tx *gorm.DB) ([]*DataKey, error) {
+	var keys []*DataKey
+
+	if err := tx.
+		Table(r.tableName).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// LockInTx blocks creating and changing keys by other transactions
+// until the transaction ends, reading keys is still allowed
+func (r *DataKeyRepository) LockInTx(tx *gorm.DB) error {
+	return tx.Exec("LOCK TABLE " + r.tableName + " IN SHARE ROW EXCLUSIVE MODE").Error
+}
+
+func (r *DataKeyRepository) UpdateEncryptedKeyInTx(
+	tx *gorm.DB,
+	id uuid.UUID,
+	encryptedKey string,
+) error {
+	return tx.
+		Table(r.tableName).
+		Where("id = ?", id).
+		Update("encrypted_key", encryptedKey).Error
+}
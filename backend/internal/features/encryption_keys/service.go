@@ -0,0 +1,211 @@
+package encryption_keys
+
+import (
+	"encoding/base64"
+	"fmt"
+	"postgresus-backend/internal/util/encryption"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// KeyStore keeps data keys of one kind (e.g. secret or backup encryption
+// keys) wrapped with the master key. One key is active and used for new
+// data, previous keys are kept to decrypt data encrypted with them
+type KeyStore struct {
+	keyRepository     *DataKeyRepository
+	masterKeyProvider *MasterKeyProvider
+
+	// name of the keys in errors, e.g. "backup encryption key"
+	keyName string
+
+	// serializes creating of keys, so concurrent
+	// callers do not create several active keys
+	createMu sync.Mutex
+
+	// decrypted keys by ID. Key material never changes (the master
+	// key rotation only re-wraps it), so keys are cached
+	cacheMu sync.Mutex
+	keys    map[uuid.UUID]DataKey
+}
+
+// GetActiveKey returns the key new data should be encrypted with. The
+// first key is created on demand. The active key is looked up on each
+// call, so a key rotated by another process is picked up
+func (s *KeyStore) GetActiveKey() (*DataKey, error) {
+	s.createMu.Lock()
+	defer s.createMu.Unlock()
+
+	key, err := s.keyRepository.FindActive()
+	if err != nil {
+		return nil, err
+	}
+
+	if key == nil {
+		return s.createActiveKey()
+	}
+
+	return s.decryptKey(key)
+}
+
+// HasActiveKey reports whether the store has any key yet
+func (s *KeyStore) HasActiveKey() (bool, error) {
+	key, err := s.keyRepository.FindActive()
+	if err != nil {
+		return false, err
+	}
+
+	return key != nil, nil
+}
+
+// GetKeyByID returns any key (including rotated ones), so data encrypted
+// before rotation can be decrypted. Keys are cached, so IsActive of the
+// returned key is not kept up to date
+func (s *KeyStore) GetKeyByID(id uuid.UUID) (*DataKey, error) {
+	s.cacheMu.Lock()
+	cachedKey, ok := s.keys[id]
+	s.cacheMu.Unlock()
+
+	if ok {
+		return &cachedKey, nil
+	}
+
+	key, err := s.keyRepository.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s %s: %w", s.keyName, id, err)
+	}
+
+	return s.decryptKey(key)
+}
+
+// GetKeys returns all keys without key material
+func (s *KeyStore) GetKeys() ([]*DataKey, error) {
+	return s.keyRepository.FindAll()
+}
+
+// RotateKey creates new active key. Previous keys are kept
+// to decrypt data encrypted with them
+func (s *KeyStore) RotateKey() (*DataKey, error) {
+	s.createMu.Lock()
+	defer s.createMu.Unlock()
+
+	return s.createActiveKey()
+}
+
+// rewrapKeysInTx re-wraps all keys with the new master key. Keys are
+// locked, so no key is created with the old master key meanwhile
+func (s *KeyStore) rewrapKeysInTx(tx *gorm.DB, oldMasterKey []byte, newMasterKey []byte) error {
+	if err := s.keyRepository.LockInTx(tx); err != nil {
+		return err
+	}
+
+	keys, err := s.keyRepository.FindAllInTx(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		encryptedKey, err := rewrapKey(oldMasterKey, newMasterKey, key.EncryptedKey)
+		if err != nil {
+			return fmt.Errorf("failed to re-wrap %s %s: %w", s.keyName, key.ID, err)
+		}
+
+		if err := s.keyRepository.UpdateEncryptedKeyInTx(tx, key.ID, encryptedKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *KeyStore) createActiveKey() (*DataKey, error) {
+	masterKey, err := s.masterKeyProvider.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := encryption.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := wrapKey(masterKey, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt %s: %w", s.keyName, err)
+	}
+
+	key := &DataKey{
+		EncryptedKey: encryptedKey,
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	if err := s.keyRepository.CreateActive(key); err != nil {
+		return nil, err
+	}
+
+	key.Key = dataKey
+	s.cacheKey(key)
+
+	return key, nil
+}
+
+func (s *KeyStore) decryptKey(key *DataKey) (*DataKey, error) {
+	masterKey, err := s.masterKeyProvider.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := unwrapKey(masterKey, key.EncryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to decrypt %s %s, check the master key: %w",
+			s.keyName,
+			key.ID,
+			err,
+		)
+	}
+
+	key.Key = dataKey
+	s.cacheKey(key)
+
+	return key, nil
+}
+
+func (s *KeyStore) cacheKey(key *DataKey) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	s.keys[key.ID] = *key
+}
+
+// wrapKey encrypts the data key with the master key for storing in DB
+func wrapKey(masterKey []byte, dataKey []byte) (string, error) {
+	encryptedKey, err := encryption.Encrypt(masterKey, dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(encryptedKey), nil
+}
+
+func unwrapKey(masterKey []byte, encodedKey string) ([]byte, error) {
+	encryptedKey, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key: %w", err)
+	}
+
+	return encryption.Decrypt(masterKey, encryptedKey)
+}
+
+// rewrapKey decrypts the data key with the old master
+// key and encrypts it with the new one
+func rewrapKey(oldMasterKey []byte, newMasterKey []byte, encodedKey string) (string, error) {
+	dataKey, err := unwrapKey(oldMasterKey, encodedKey)
+	if err != nil {
+		return "", err
+	}
+
+	return wrapKey(newMasterKey, dataKey)
+}
@@ -0,0 +1,81 @@
+package encryption_keys
+
+import (
+	"postgresus-backend/internal/util/encryption"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WrapKey_WhenUnwrapped_ReturnsDataKey(t *testing.T) {
+	masterKey, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	dataKey, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	wrappedKey, err := wrapKey(masterKey, dataKey)
+	assert.NoError(t, err)
+
+	unwrappedKey, err := unwrapKey(masterKey, wrappedKey)
+	assert.NoError(t, err)
+	assert.Equal(t, dataKey, unwrappedKey)
+}
+
+func Test_UnwrapKey_WithAnotherMasterKey_ReturnsError(t *testing.T) {
+	masterKey, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	anotherMasterKey, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	dataKey, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	wrappedKey, err := wrapKey(masterKey, dataKey)
+	assert.NoError(t, err)
+
+	_, err = unwrapKey(anotherMasterKey, wrappedKey)
+	assert.Error(t, err)
+}
+
+func Test_RewrapKey_WithNewMasterKey_DataKeyIsNotChanged(t *testing.T) {
+	oldMasterKey, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	newMasterKey, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	dataKey, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	wrappedKey, err := wrapKey(oldMasterKey, dataKey)
+	assert.NoError(t, err)
+
+	rewrappedKey, err := rewrapKey(oldMasterKey, newMasterKey, wrappedKey)
+	assert.NoError(t, err)
+
+	_, err = unwrapKey(oldMasterKey, rewrappedKey)
+	assert.Error(t, err)
+
+	unwrappedKey, err := unwrapKey(newMasterKey, rewrappedKey)
+	assert.NoError(t, err)
+	assert.Equal(t, dataKey, unwrappedKey)
+}
+
+func Test_RewrapKey_WithWrongOldMasterKey_ReturnsError(t *testing.T) {
+	masterKey, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	wrongMasterKey, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	dataKey, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	wrappedKey, err := wrapKey(masterKey, dataKey)
+	assert.NoError(t, err)
+
+	_, err = rewrapKey(wrongMasterKey, masterKey, wrappedKey)
+	assert.Error(t, err)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/databases"
 	healthcheck_config "postgresus-backend/internal/features/healthcheck/config"
 	"postgresus-backend/internal/util/logger"
@@ -17,6 +18,7 @@ type CheckPgHealthUseCase struct {
 	healthcheckAttemptRepository *HealthcheckAttemptRepository
 	healthcheckAttemptSender     HealthcheckAttemptSender
 	databaseService              DatabaseService
+	auditLogWriter               AuditLogWriter
 }
 
 func (uc *CheckPgHealthUseCase) Execute(
@@ -97,6 +99,7 @@ func (uc *CheckPgHealthUseCase) updateDatabaseHealthStatusIfChanged(
 			return err
 		}
 
+		uc.logDbStatusChange(database, heathcheckAttempt.Status)
 		uc.sendDbStatusNotification(
 			healthcheckConfig,
 			database,
@@ -137,6 +140,7 @@ func (uc *CheckPgHealthUseCase) updateDatabaseHealthStatusIfChanged(
 			return err
 		}
 
+		uc.logDbStatusChange(database, databases.HealthStatusUnavailable)
 		uc.sendDbStatusNotification(
 			healthcheckConfig,
 			database,
@@ -147,6 +151,21 @@ func (uc *CheckPgHealthUseCase) updateDatabaseHealthStatusIfChanged(
 	return nil
 }
 
+func (uc *CheckPgHealthUseCase) logDbStatusChange(
+	database *databases.Database,
+	newStatus databases.HealthStatus,
+) {
+	uc.auditLogWriter.LogSystemAction(&audit_logs.AuditEvent{
+		Action:      audit_logs.AuditActionDatabaseHealthChange,
+		TargetType:  audit_logs.AuditTargetTypeDatabase,
+		TargetID:    &database.ID,
+		TargetName:  database.Name,
+		WorkspaceID: &database.WorkspaceID,
+		Before:      map[string]any{"healthStatus": database.HealthStatus},
+		After:       map[string]any{"healthStatus": newStatus},
+	})
+}
+
 func (uc *CheckPgHealthUseCase) healthcheckDatabase(
 	now time.Time,
 	database *databases.Database,
@@ -178,12 +197,21 @@ func (uc *CheckPgHealthUseCase) healthcheckDatabase(
 func (uc *CheckPgHealthUseCase) validateDatabase(
 	database *databases.Database,
 ) error {
-	if database.Type != databases.DatabaseTypePostgres {
-		return errors.New("database type is not postgres")
-	}
-
-	if database.Postgresql == nil {
-		return errors.New("database Postgresql is not set")
+	switch database.Type {
+	case databases.DatabaseTypePostgres:
+		if database.Postgresql == nil {
+			return errors.New("database Postgresql is not set")
+		}
+	case databases.DatabaseTypeMysql:
+		if database.Mysql == nil {
+			return errors.New("database Mysql is not set")
+		}
+	case databases.DatabaseTypeMongodb:
+		if database.Mongodb == nil {
+			return errors.New("database Mongodb is not set")
+		}
+	default:
+		return errors.New("database type is not supported")
 	}
 
 	return nil
@@ -31,6 +31,8 @@ func Test_CheckPgHealthUseCase(t *testing.T) {
 
 		// Setup mock notifier sender
 		mockSender := &MockHealthcheckAttemptSender{}
+		mockAuditLogWriter := &MockAuditLogWriter{}
+		mockAuditLogWriter.On("LogSystemAction", mock.Anything).Return()
 		mockSender.On("SendNotification", mock.Anything, mock.Anything, mock.Anything).Return()
 
 		// Setup mock database service
@@ -58,6 +60,7 @@ func Test_CheckPgHealthUseCase(t *testing.T) {
 			healthcheckAttemptRepository: &HealthcheckAttemptRepository{},
 			healthcheckAttemptSender:     mockSender,
 			databaseService:              mockDatabaseService,
+			auditLogWriter:               mockAuditLogWriter,
 		}
 
 		// Execute healthcheck
@@ -99,6 +102,8 @@ func Test_CheckPgHealthUseCase(t *testing.T) {
 
 			// Setup mock notifier sender
 			mockSender := &MockHealthcheckAttemptSender{}
+			mockAuditLogWriter := &MockAuditLogWriter{}
+			mockAuditLogWriter.On("LogSystemAction", mock.Anything).Return()
 
 			// Setup mock database service - connection fails but SetHealthStatus should not be called
 			mockDatabaseService := &MockDatabaseService{}
@@ -122,6 +127,7 @@ func Test_CheckPgHealthUseCase(t *testing.T) {
 				healthcheckAttemptRepository: &HealthcheckAttemptRepository{},
 				healthcheckAttemptSender:     mockSender,
 				databaseService:              mockDatabaseService,
+				auditLogWriter:               mockAuditLogWriter,
 			}
 
 			// Execute first healthcheck
@@ -172,6 +178,8 @@ func Test_CheckPgHealthUseCase(t *testing.T) {
 
 			// Setup mock notifier sender
 			mockSender := &MockHealthcheckAttemptSender{}
+			mockAuditLogWriter := &MockAuditLogWriter{}
+			mockAuditLogWriter.On("LogSystemAction", mock.Anything).Return()
 			mockSender.On("SendNotification", mock.Anything, mock.Anything, mock.Anything).Return()
 
 			// Setup mock database service
@@ -199,6 +207,7 @@ func Test_CheckPgHealthUseCase(t *testing.T) {
 				healthcheckAttemptRepository: &HealthcheckAttemptRepository{},
 				healthcheckAttemptSender:     mockSender,
 				databaseService:              mockDatabaseService,
+				auditLogWriter:               mockAuditLogWriter,
 			}
 
 			// Execute three failed healthchecks
@@ -249,6 +258,8 @@ func Test_CheckPgHealthUseCase(t *testing.T) {
 
 		// Setup mock notifier sender
 		mockSender := &MockHealthcheckAttemptSender{}
+		mockAuditLogWriter := &MockAuditLogWriter{}
+		mockAuditLogWriter.On("LogSystemAction", mock.Anything).Return()
 		mockSender.On("SendNotification", mock.Anything, mock.Anything, mock.Anything).Return()
 
 		// Setup mock database service - connection succeeds
@@ -275,6 +286,7 @@ func Test_CheckPgHealthUseCase(t *testing.T) {
 			healthcheckAttemptRepository: &HealthcheckAttemptRepository{},
 			healthcheckAttemptSender:     mockSender,
 			databaseService:              mockDatabaseService,
+			auditLogWriter:               mockAuditLogWriter,
 		}
 
 		// Execute healthcheck (should succeed)
@@ -316,6 +328,8 @@ func Test_CheckPgHealthUseCase(t *testing.T) {
 
 			// Setup mock notifier sender
 			mockSender := &MockHealthcheckAttemptSender{}
+			mockAuditLogWriter := &MockAuditLogWriter{}
+			mockAuditLogWriter.On("LogSystemAction", mock.Anything).Return()
 			mockSender.On("SendNotification", mock.Anything, mock.Anything, mock.Anything).Return()
 
 			// Setup mock database service - connection succeeds
@@ -342,6 +356,7 @@ func Test_CheckPgHealthUseCase(t *testing.T) {
 				healthcheckAttemptRepository: &HealthcheckAttemptRepository{},
 				healthcheckAttemptSender:     mockSender,
 				databaseService:              mockDatabaseService,
+				auditLogWriter:               mockAuditLogWriter,
 			}
 
 			// Execute first healthcheck
@@ -1,6 +1,7 @@
 package healthcheck_attempt
 
 import (
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/databases"
 	healthcheck_config "postgresus-backend/internal/features/healthcheck/config"
 	"postgresus-backend/internal/features/notifiers"
@@ -18,6 +19,7 @@ var checkPgHealthUseCase = &CheckPgHealthUseCase{
 	healthcheckAttemptRepository,
 	notifiers.GetNotifierService(),
 	databases.GetDatabaseService(),
+	audit_logs.GetAuditLogService(),
 }
 
 var healthcheckAttemptBackgroundService = &HealthcheckAttemptBackgroundService{
@@ -1,6 +1,7 @@
 package healthcheck_attempt
 
 import (
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/notifiers"
 
@@ -25,3 +26,7 @@ type DatabaseService interface {
 		healthStatus *databases.HealthStatus,
 	) error
 }
+
+type AuditLogWriter interface {
+	LogSystemAction(event *audit_logs.AuditEvent)
+}
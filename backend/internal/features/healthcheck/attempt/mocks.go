@@ -1,6 +1,7 @@
 package healthcheck_attempt
 
 import (
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/notifiers"
 
@@ -53,3 +54,11 @@ func (m *MockDatabaseService) GetDatabaseByID(
 
 	return database, args.Error(1)
 }
+
+type MockAuditLogWriter struct {
+	mock.Mock
+}
+
+func (m *MockAuditLogWriter) LogSystemAction(event *audit_logs.AuditEvent) {
+	m.Called(event)
+}
@@ -1,9 +1,9 @@
 package healthcheck_attempt
 
 import (
-	"errors"
 	"postgresus-backend/internal/features/databases"
 	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/features/workspaces"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,8 +24,12 @@ func (s *HealthcheckAttemptService) GetAttemptsByDatabase(
 		return nil, err
 	}
 
-	if database.UserID != user.ID {
-		return nil, errors.New("forbidden")
+	if err := s.databaseService.CheckAccess(
+		&user,
+		database,
+		workspaces.WorkspaceRoleViewer,
+	); err != nil {
+		return nil, err
 	}
 
 	return s.healthcheckAttemptRepository.FindByDatabaseIdOrderByCreatedAtDesc(
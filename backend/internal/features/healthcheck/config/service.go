@@ -1,10 +1,10 @@
 package healthcheck_config
 
 import (
-	"errors"
 	"log/slog"
 	"postgresus-backend/internal/features/databases"
 	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/features/workspaces"
 
 	"github.com/google/uuid"
 )
@@ -33,8 +33,12 @@ func (s *HealthcheckConfigService) Save(
 		return err
 	}
 
-	if database.UserID != user.ID {
-		return errors.New("user does not have access to this database")
+	if err := s.databaseService.CheckAccess(
+		&user,
+		database,
+		workspaces.WorkspaceRoleAdmin,
+	); err != nil {
+		return err
 	}
 
 	healthcheckConfig := configDTO.ToDTO()
@@ -72,8 +76,12 @@ func (s *HealthcheckConfigService) GetByDatabaseID(
 		return nil, err
 	}
 
-	if database.UserID != user.ID {
-		return nil, errors.New("user does not have access to this database")
+	if err := s.databaseService.CheckAccess(
+		&user,
+		database,
+		workspaces.WorkspaceRoleViewer,
+	); err != nil {
+		return nil, err
 	}
 
 	config, err := s.healthcheckConfigRepository.GetByDatabaseID(database.ID)
@@ -0,0 +1,292 @@
+package intervals
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression. Supported formats:
+//
+//	minute hour day-of-month month day-of-week
+//	second minute hour day-of-month month day-of-week
+//
+// Fields accept "*", "?", lists ("1,15"), ranges ("1-5"), steps ("*/15",
+// "0-30/10", "5/20") and names ("JAN", "MON"). Day of month accepts "L" for
+// the last day of month. Day of week is 0-7 where both 0 and 7 are Sunday.
+// Macros @yearly, @monthly, @weekly, @daily and @hourly are supported too.
+//
+// When both day of month and day of week are restricted, the schedule
+// matches when either of them matches (as classic cron does)
+type CronSchedule struct {
+	seconds     uint64
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+
+	isLastDayOfMonth bool
+	isDayOfMonthStar bool
+	isDayOfWeekStar  bool
+}
+
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+// schedules which do not match within this period (e.g. "0 0 30 2 *")
+// are considered to never run
+const cronSearchYears = 5
+
+var (
+	cronSecondField     = cronField{"second", 0, 59, nil}
+	cronMinuteField     = cronField{"minute", 0, 59, nil}
+	cronHourField       = cronField{"hour", 0, 23, nil}
+	cronDayOfMonthField = cronField{"day of month", 1, 31, nil}
+	cronMonthField      = cronField{"month", 1, 12, map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	cronDayOfWeekField = cronField{"day of week", 0, 7, map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+
+	cronMacros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+func ParseCronExpression(expression string) (*CronSchedule, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return nil, errors.New("cron expression is empty")
+	}
+
+	if strings.HasPrefix(expression, "@") {
+		macroExpression, ok := cronMacros[strings.ToLower(expression)]
+		if !ok {
+			return nil, fmt.Errorf("unknown cron macro: %s", expression)
+		}
+
+		expression = macroExpression
+	}
+
+	fields := strings.Fields(expression)
+
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf(
+			"cron expression must have 5 or 6 fields, got %d",
+			len(fields),
+		)
+	}
+
+	schedule := &CronSchedule{}
+
+	var err error
+	if schedule.seconds, err = parseCronField(fields[0], cronSecondField); err != nil {
+		return nil, err
+	}
+
+	if schedule.minutes, err = parseCronField(fields[1], cronMinuteField); err != nil {
+		return nil, err
+	}
+
+	if schedule.hours, err = parseCronField(fields[2], cronHourField); err != nil {
+		return nil, err
+	}
+
+	dayOfMonth := strings.ToUpper(fields[3])
+	schedule.isDayOfMonthStar = dayOfMonth == "*" || dayOfMonth == "?"
+
+	if dayOfMonth == "L" {
+		schedule.isLastDayOfMonth = true
+	} else if schedule.daysOfMonth, err = parseCronField(dayOfMonth, cronDayOfMonthField); err != nil {
+		return nil, err
+	}
+
+	if schedule.months, err = parseCronField(fields[4], cronMonthField); err != nil {
+		return nil, err
+	}
+
+	dayOfWeek := strings.ToUpper(fields[5])
+	schedule.isDayOfWeekStar = dayOfWeek == "*" || dayOfWeek == "?"
+
+	if schedule.daysOfWeek, err = parseCronField(dayOfWeek, cronDayOfWeekField); err != nil {
+		return nil, err
+	}
+
+	// 7 is an alias of Sunday
+	if schedule.daysOfWeek&(1<<7) != 0 {
+		schedule.daysOfWeek |= 1 << 0
+	}
+
+	return schedule, nil
+}
+
+// Next returns the first scheduled time strictly after t in t's location.
+// Zero time is returned if the schedule never runs
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Second).Add(time.Second)
+	yearLimit := t.Year() + cronSearchYears
+
+	for t.Year() <= yearLimit {
+		if s.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !s.isDayMatched(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		// hours, minutes and seconds are advanced by absolute duration
+		// so that DST transitions never move the time backwards
+		if s.hours&(1<<uint(t.Hour())) == 0 {
+			t = t.Add(
+				time.Hour -
+					time.Duration(t.Minute())*time.Minute -
+					time.Duration(t.Second())*time.Second,
+			)
+			continue
+		}
+
+		if s.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute - time.Duration(t.Second())*time.Second)
+			continue
+		}
+
+		if s.seconds&(1<<uint(t.Second())) == 0 {
+			t = t.Add(time.Second)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *CronSchedule) isDayMatched(t time.Time) bool {
+	isDayOfMonthMatched := s.daysOfMonth&(1<<uint(t.Day())) != 0
+	if s.isLastDayOfMonth {
+		isDayOfMonthMatched = t.AddDate(0, 0, 1).Day() == 1
+	}
+
+	isDayOfWeekMatched := s.daysOfWeek&(1<<uint(t.Weekday())) != 0
+
+	if s.isDayOfMonthStar || s.isDayOfWeekStar {
+		return isDayOfMonthMatched && isDayOfWeekMatched
+	}
+
+	return isDayOfMonthMatched || isDayOfWeekMatched
+}
+
+func parseCronField(value string, field cronField) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(value, ",") {
+		partBits, err := parseCronFieldPart(part, field)
+		if err != nil {
+			return 0, err
+		}
+
+		bits |= partBits
+	}
+
+	return bits, nil
+}
+
+func parseCronFieldPart(part string, field cronField) (uint64, error) {
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+	step := 1
+	if hasStep {
+		parsedStep, err := strconv.Atoi(stepPart)
+		if err != nil || parsedStep <= 0 {
+			return 0, fmt.Errorf("invalid step in %s field: %s", field.name, part)
+		}
+
+		step = parsedStep
+	}
+
+	var start, end int
+
+	switch {
+	case rangePart == "*" || rangePart == "?":
+		start, end = field.min, field.max
+	case strings.Contains(rangePart, "-"):
+		startPart, endPart, _ := strings.Cut(rangePart, "-")
+
+		var err error
+		if start, err = parseCronValue(startPart, field); err != nil {
+			return 0, err
+		}
+
+		if end, err = parseCronValue(endPart, field); err != nil {
+			return 0, err
+		}
+
+		if start > end {
+			return 0, fmt.Errorf("invalid range in %s field: %s", field.name, part)
+		}
+	default:
+		value, err := parseCronValue(rangePart, field)
+		if err != nil {
+			return 0, err
+		}
+
+		start, end = value, value
+
+		// "5/20" means from 5 to the end of the range with step 20
+		if hasStep {
+			end = field.max
+		}
+	}
+
+	var bits uint64
+	for value := start; value <= end; value += step {
+		bits |= 1 << uint(value)
+	}
+
+	return bits, nil
+}
+
+func parseCronValue(value string, field cronField) (int, error) {
+	if namedValue, ok := field.names[strings.ToUpper(value)]; ok {
+		return namedValue, nil
+	}
+
+	parsedValue, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in %s field: %s", field.name, value)
+	}
+
+	if parsedValue < field.min || parsedValue > field.max {
+		return 0, fmt.Errorf(
+			"%s must be between %d and %d, got %d",
+			field.name,
+			field.min,
+			field.max,
+			parsedValue,
+		)
+	}
+
+	return parsedValue, nil
+}
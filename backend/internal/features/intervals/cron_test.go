@@ -0,0 +1,87 @@
+package intervals
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseCronExpression_InvalidExpressionsRejected(t *testing.T) {
+	expressions := []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"@every",
+		"abc * * * *",
+	}
+
+	for _, expression := range expressions {
+		_, err := ParseCronExpression(expression)
+		assert.Error(t, err, "expression %q", expression)
+	}
+}
+
+func Test_CronScheduleNext_ReturnsNextMatchingTime(t *testing.T) {
+	from := time.Date(2024, 1, 15, 10, 7, 30, 0, time.UTC) // Monday
+
+	testCases := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 1, 15, 10, 15, 0, 0, time.UTC)},
+		{"0 2,14 * * MON-FRI", time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)},
+		{"30 */10 * * * *", time.Date(2024, 1, 15, 10, 10, 30, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 L * *", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 3 1 FEB ?", time.Date(2024, 2, 1, 3, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)},
+		// day of month and day of week are OR-ed when both are restricted
+		{"0 0 20 * 3", time.Date(2024, 1, 17, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, testCase := range testCases {
+		schedule, err := ParseCronExpression(testCase.expression)
+		assert.NoError(t, err, "expression %q", testCase.expression)
+
+		assert.Equal(
+			t,
+			testCase.expected,
+			schedule.Next(from),
+			"expression %q",
+			testCase.expression,
+		)
+	}
+}
+
+func Test_CronScheduleNext_TimeExactlyAtSlotIsSkipped(t *testing.T) {
+	schedule, err := ParseCronExpression("0 * * * *")
+	assert.NoError(t, err)
+
+	from := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC), schedule.Next(from))
+}
+
+func Test_CronScheduleNext_DstTransitionHandled(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	schedule, err := ParseCronExpression("30 * * * *")
+	assert.NoError(t, err)
+
+	// clocks jump from 02:00 to 03:00 on March 31, 2024
+	from := time.Date(2024, 3, 31, 1, 45, 0, 0, location)
+	next := schedule.Next(from)
+
+	assert.Equal(t, time.Date(2024, 3, 31, 3, 30, 0, 0, location), next)
+	assert.True(t, next.After(from))
+}
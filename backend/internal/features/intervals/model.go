@@ -2,7 +2,9 @@ package intervals
 
 import (
 	"errors"
+	"fmt"
 	"time"
+	_ "time/tzdata" // timezones must be available in slim images
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -17,6 +19,12 @@ type Interval struct {
 	Weekday *int `json:"weekday,omitempty"    gorm:"type:int"`
 	// only for MONTHLY
 	DayOfMonth *int `json:"dayOfMonth,omitempty" gorm:"type:int"`
+	// only for CRON
+	CronExpression *string `json:"cronExpression,omitempty" gorm:"type:text"`
+
+	// IANA timezone (e.g. "Europe/Berlin") the schedule is evaluated in,
+	// time is compared in location of passed time if empty
+	Timezone *string `json:"timezone,omitempty" gorm:"type:text"`
 }
 
 func (i *Interval) BeforeSave(tx *gorm.DB) error {
@@ -40,6 +48,20 @@ func (i *Interval) Validate() error {
 		return errors.New("day of month is required for monthly intervals")
 	}
 
+	if i.Interval == IntervalCron {
+		if i.CronExpression == nil || *i.CronExpression == "" {
+			return errors.New("cron expression is required for cron intervals")
+		}
+
+		if _, err := ParseCronExpression(*i.CronExpression); err != nil {
+			return fmt.Errorf("invalid cron expression: %w", err)
+		}
+	}
+
+	if _, err := i.getLocation(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -50,20 +72,79 @@ func (i *Interval) ShouldTriggerBackup(now time.Time, lastBackupTime *time.Time)
 		return true
 	}
 
+	lastBackup := *lastBackupTime
+
+	location, err := i.getLocation()
+	if err != nil {
+		return false
+	}
+
+	if location != nil {
+		now = now.In(location)
+		lastBackup = lastBackup.In(location)
+	}
+
 	switch i.Interval {
 	case IntervalHourly:
-		return now.Sub(*lastBackupTime) >= time.Hour
+		return now.Sub(lastBackup) >= time.Hour
 	case IntervalDaily:
-		return i.shouldTriggerDaily(now, *lastBackupTime)
+		return i.shouldTriggerDaily(now, lastBackup)
 	case IntervalWeekly:
-		return i.shouldTriggerWeekly(now, *lastBackupTime)
+		return i.shouldTriggerWeekly(now, lastBackup)
 	case IntervalMonthly:
-		return i.shouldTriggerMonthly(now, *lastBackupTime)
+		return i.shouldTriggerMonthly(now, lastBackup)
+	case IntervalCron:
+		return i.shouldTriggerCron(now, lastBackup)
 	default:
 		return false
 	}
 }
 
+// GetNextRunTimes returns the next count scheduled times after from.
+// For hourly interval times are counted from the passed time, as hourly
+// backups are not bound to a slot
+func (i *Interval) GetNextRunTimes(from time.Time, count int) ([]time.Time, error) {
+	if err := i.Validate(); err != nil {
+		return nil, err
+	}
+
+	location, err := i.getLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	if location != nil {
+		from = from.In(location)
+	}
+
+	runTimes := make([]time.Time, 0, count)
+
+	if i.Interval == IntervalHourly {
+		for n := 1; n <= count; n++ {
+			runTimes = append(runTimes, from.Add(time.Duration(n)*time.Hour))
+		}
+
+		return runTimes, nil
+	}
+
+	schedule, err := i.getCronSchedule()
+	if err != nil {
+		return nil, err
+	}
+
+	runTime := from
+	for len(runTimes) < count {
+		runTime = schedule.Next(runTime)
+		if runTime.IsZero() {
+			break
+		}
+
+		runTimes = append(runTimes, runTime)
+	}
+
+	return runTimes, nil
+}
+
 // daily trigger: honour the TimeOfDay slot and catch up the previous one
 func (i *Interval) shouldTriggerDaily(now, lastBackup time.Time) bool {
 	if i.TimeOfDay == nil {
@@ -177,6 +258,76 @@ func (i *Interval) shouldTriggerMonthly(now, lastBackup time.Time) bool {
 	return lastBackup.Before(getStartOfMonth(now))
 }
 
+// cron trigger: fire if any slot happened after the last backup, so
+// after downtime exactly one missed slot is caught up
+func (i *Interval) shouldTriggerCron(now, lastBackup time.Time) bool {
+	schedule, err := i.getCronSchedule()
+	if err != nil {
+		return false // malformed ⇒ play safe
+	}
+
+	nextRun := schedule.Next(lastBackup)
+	if nextRun.IsZero() {
+		return false
+	}
+
+	return !nextRun.After(now)
+}
+
+func (i *Interval) getLocation() (*time.Location, error) {
+	if i.Timezone == nil || *i.Timezone == "" {
+		return nil, nil
+	}
+
+	location, err := time.LoadLocation(*i.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %s", *i.Timezone)
+	}
+
+	return location, nil
+}
+
+// getCronSchedule returns the schedule of the interval as cron expression,
+// fixed intervals are converted to the equivalent expressions
+func (i *Interval) getCronSchedule() (*CronSchedule, error) {
+	if i.Interval == IntervalCron {
+		if i.CronExpression == nil {
+			return nil, errors.New("cron expression is required for cron intervals")
+		}
+
+		return ParseCronExpression(*i.CronExpression)
+	}
+
+	hour, minute := 0, 0
+	if i.TimeOfDay != nil {
+		t, err := time.Parse("15:04", *i.TimeOfDay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time of day: %s", *i.TimeOfDay)
+		}
+
+		hour, minute = t.Hour(), t.Minute()
+	}
+
+	switch i.Interval {
+	case IntervalDaily:
+		return ParseCronExpression(fmt.Sprintf("%d %d * * *", minute, hour))
+	case IntervalWeekly:
+		if i.Weekday == nil {
+			return nil, errors.New("weekday is required for weekly intervals")
+		}
+
+		return ParseCronExpression(fmt.Sprintf("%d %d * * %d", minute, hour, *i.Weekday))
+	case IntervalMonthly:
+		if i.DayOfMonth == nil {
+			return nil, errors.New("day of month is required for monthly intervals")
+		}
+
+		return ParseCronExpression(fmt.Sprintf("%d %d %d * *", minute, hour, *i.DayOfMonth))
+	default:
+		return nil, fmt.Errorf("interval %s has no schedule", i.Interval)
+	}
+}
+
 func isSameDay(a, b time.Time) bool {
 	y1, m1, d1 := a.Date()
 	y2, m2, d2 := b.Date()
@@ -457,6 +457,144 @@ func TestInterval_ShouldTriggerBackup_Monthly(t *testing.T) {
 	)
 }
 
+func TestInterval_ShouldTriggerBackup_Cron(t *testing.T) {
+	cronExpression := "0 2,14 * * 1-5" // weekdays at 02:00 and 14:00
+	interval := &Interval{
+		ID:             uuid.New(),
+		Interval:       IntervalCron,
+		CronExpression: &cronExpression,
+	}
+
+	t.Run("No previous backup: Trigger backup immediately", func(t *testing.T) {
+		now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+		should := interval.ShouldTriggerBackup(now, nil)
+		assert.True(t, should)
+	})
+
+	t.Run("Before next slot: Do not trigger backup", func(t *testing.T) {
+		now := time.Date(2024, 1, 15, 13, 59, 0, 0, time.UTC)      // Monday
+		lastBackup := time.Date(2024, 1, 15, 2, 0, 0, 0, time.UTC) // Monday 02:00
+		should := interval.ShouldTriggerBackup(now, &lastBackup)
+		assert.False(t, should)
+	})
+
+	t.Run("Exactly at slot: Trigger backup", func(t *testing.T) {
+		now := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+		lastBackup := time.Date(2024, 1, 15, 2, 0, 0, 0, time.UTC)
+		should := interval.ShouldTriggerBackup(now, &lastBackup)
+		assert.True(t, should)
+	})
+
+	t.Run("Backup done at slot: Do not trigger again", func(t *testing.T) {
+		now := time.Date(2024, 1, 15, 20, 0, 0, 0, time.UTC)
+		lastBackup := time.Date(2024, 1, 15, 14, 0, 30, 0, time.UTC)
+		should := interval.ShouldTriggerBackup(now, &lastBackup)
+		assert.False(t, should)
+	})
+
+	t.Run("Weekend: Do not trigger backup", func(t *testing.T) {
+		now := time.Date(2024, 1, 21, 14, 30, 0, 0, time.UTC)       // Sunday
+		lastBackup := time.Date(2024, 1, 19, 14, 0, 0, 0, time.UTC) // Friday 14:00
+		should := interval.ShouldTriggerBackup(now, &lastBackup)
+		assert.False(t, should)
+	})
+
+	t.Run("Catch up exactly one missed slot after downtime", func(t *testing.T) {
+		// several slots were missed while the app was down
+		now := time.Date(2024, 1, 17, 10, 0, 0, 0, time.UTC)
+		lastBackup := time.Date(2024, 1, 15, 2, 0, 0, 0, time.UTC)
+		should := interval.ShouldTriggerBackup(now, &lastBackup)
+		assert.True(t, should)
+
+		// the catch up backup is done, next one waits for the next slot
+		catchUpBackup := now.Add(time.Minute)
+		should = interval.ShouldTriggerBackup(now.Add(2*time.Minute), &catchUpBackup)
+		assert.False(t, should)
+	})
+
+	t.Run("Timezone is used to evaluate schedule", func(t *testing.T) {
+		cronExpression := "0 2 * * *"
+		timezone := "Europe/Berlin"
+		interval := &Interval{
+			ID:             uuid.New(),
+			Interval:       IntervalCron,
+			CronExpression: &cronExpression,
+			Timezone:       &timezone,
+		}
+
+		// 02:00 in Berlin is 01:00 UTC in winter
+		lastBackup := time.Date(2024, 1, 14, 1, 0, 0, 0, time.UTC)
+		assert.False(
+			t,
+			interval.ShouldTriggerBackup(time.Date(2024, 1, 15, 0, 59, 0, 0, time.UTC), &lastBackup),
+		)
+		assert.True(
+			t,
+			interval.ShouldTriggerBackup(time.Date(2024, 1, 15, 1, 0, 0, 0, time.UTC), &lastBackup),
+		)
+	})
+}
+
+func TestInterval_GetNextRunTimes(t *testing.T) {
+	from := time.Date(2024, 1, 30, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Cron interval returns next slots", func(t *testing.T) {
+		cronExpression := "0 0 L * *" // last day of month
+		interval := &Interval{
+			ID:             uuid.New(),
+			Interval:       IntervalCron,
+			CronExpression: &cronExpression,
+		}
+
+		runTimes, err := interval.GetNextRunTimes(from, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Time{
+			time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		}, runTimes)
+	})
+
+	t.Run("Weekly interval returns next slots", func(t *testing.T) {
+		timeOfDay := "15:00"
+		weekday := 3
+		interval := &Interval{
+			ID:        uuid.New(),
+			Interval:  IntervalWeekly,
+			TimeOfDay: &timeOfDay,
+			Weekday:   &weekday,
+		}
+
+		runTimes, err := interval.GetNextRunTimes(from, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Time{
+			time.Date(2024, 1, 31, 15, 0, 0, 0, time.UTC),
+			time.Date(2024, 2, 7, 15, 0, 0, 0, time.UTC),
+		}, runTimes)
+	})
+
+	t.Run("Hourly interval returns times counted from now", func(t *testing.T) {
+		interval := &Interval{ID: uuid.New(), Interval: IntervalHourly}
+
+		runTimes, err := interval.GetNextRunTimes(from, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Time{from.Add(time.Hour), from.Add(2 * time.Hour)}, runTimes)
+	})
+
+	t.Run("Schedule which never runs returns no times", func(t *testing.T) {
+		cronExpression := "0 0 30 2 *"
+		interval := &Interval{
+			ID:             uuid.New(),
+			Interval:       IntervalCron,
+			CronExpression: &cronExpression,
+		}
+
+		runTimes, err := interval.GetNextRunTimes(from, 2)
+		assert.NoError(t, err)
+		assert.Empty(t, runTimes)
+	})
+}
+
 func TestInterval_Validate(t *testing.T) {
 	t.Run("Daily interval requires time of day", func(t *testing.T) {
 		interval := &Interval{
@@ -526,4 +664,31 @@ func TestInterval_Validate(t *testing.T) {
 		err := interval.Validate()
 		assert.NoError(t, err)
 	})
+
+	t.Run("Cron interval without expression", func(t *testing.T) {
+		interval := &Interval{ID: uuid.New(), Interval: IntervalCron}
+		err := interval.Validate()
+		assert.Error(t, err)
+	})
+
+	t.Run("Cron interval with invalid expression", func(t *testing.T) {
+		cronExpression := "0 25 * * *"
+		interval := &Interval{ID: uuid.New(), Interval: IntervalCron, CronExpression: &cronExpression}
+		err := interval.Validate()
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid timezone", func(t *testing.T) {
+		timezone := "Mars/Olympus"
+		interval := &Interval{ID: uuid.New(), Interval: IntervalHourly, Timezone: &timezone}
+		err := interval.Validate()
+		assert.Error(t, err)
+	})
+
+	t.Run("Valid cron interval", func(t *testing.T) {
+		cronExpression := "*/15 * * * *"
+		interval := &Interval{ID: uuid.New(), Interval: IntervalCron, CronExpression: &cronExpression}
+		err := interval.Validate()
+		assert.NoError(t, err)
+	})
 }
@@ -2,6 +2,7 @@ package notifiers
 
 import (
 	"net/http"
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/users"
 
 	"github.com/gin-gonic/gin"
@@ -11,6 +12,7 @@ import (
 type NotifierController struct {
 	notifierService *NotifierService
 	userService     *users.UserService
+	auditLogService *audit_logs.AuditLogService
 }
 
 func (c *NotifierController) RegisterRoutes(router *gin.RouterGroup) {
@@ -47,16 +49,35 @@ func (c *NotifierController) SaveNotifier(ctx *gin.Context) {
 		return
 	}
 
-	if err := notifier.Validate(); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	auditEvent := &audit_logs.AuditEvent{
+		Action:     audit_logs.AuditActionNotifierCreate,
+		TargetType: audit_logs.AuditTargetTypeNotifier,
+		TargetName: notifier.Name,
+	}
+
+	if notifier.ID != uuid.Nil {
+		// access is checked on saving, so here is
+		// the old version for audit log only
+		existingNotifier, _ := c.notifierService.GetNotifier(user, notifier.ID)
+
+		auditEvent.Action = audit_logs.AuditActionNotifierUpdate
+		auditEvent.Before = existingNotifier
 	}
 
-	if err := c.notifierService.SaveNotifier(user, &notifier); err != nil {
+	err = c.notifierService.SaveNotifier(user, &notifier)
+
+	auditEvent.TargetID = &notifier.ID
+	auditEvent.WorkspaceID = &notifier.WorkspaceID
+	auditEvent.After = &notifier
+	auditEvent.Err = err
+	c.auditLogService.LogUserAction(user, ctx.ClientIP(), auditEvent)
+
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	notifier.HideSensitiveData()
 	ctx.JSON(http.StatusOK, notifier)
 }
 
@@ -90,15 +111,17 @@ func (c *NotifierController) GetNotifier(ctx *gin.Context) {
 		return
 	}
 
+	notifier.HideSensitiveData()
 	ctx.JSON(http.StatusOK, notifier)
 }
 
 // GetNotifiers
 // @Summary Get all notifiers
-// @Description Get all notifiers for the current user
+// @Description Get notifiers of the workspace or of all workspaces of the current user
 // @Tags notifiers
 // @Produce json
 // @Param Authorization header string true "JWT token"
+// @Param workspace_id query string false "Workspace ID"
 // @Success 200 {array} Notifier
 // @Failure 401
 // @Router /notifiers [get]
@@ -109,12 +132,27 @@ func (c *NotifierController) GetNotifiers(ctx *gin.Context) {
 		return
 	}
 
-	notifiers, err := c.notifierService.GetNotifiers(user)
+	var workspaceID *uuid.UUID
+	if workspaceIDParam := ctx.Query("workspace_id"); workspaceIDParam != "" {
+		id, err := uuid.Parse(workspaceIDParam)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+			return
+		}
+
+		workspaceID = &id
+	}
+
+	notifiers, err := c.notifierService.GetNotifiers(user, workspaceID)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	for _, notifier := range notifiers {
+		notifier.HideSensitiveData()
+	}
+
 	ctx.JSON(http.StatusOK, notifiers)
 }
 
@@ -148,7 +186,17 @@ func (c *NotifierController) DeleteNotifier(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.notifierService.DeleteNotifier(user, notifier.ID); err != nil {
+	err = c.notifierService.DeleteNotifier(user, notifier.ID)
+	c.auditLogService.LogUserAction(user, ctx.ClientIP(), &audit_logs.AuditEvent{
+		Action:      audit_logs.AuditActionNotifierDelete,
+		TargetType:  audit_logs.AuditTargetTypeNotifier,
+		TargetID:    &notifier.ID,
+		TargetName:  notifier.Name,
+		WorkspaceID: &notifier.WorkspaceID,
+		Err:         err,
+	})
+
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -216,7 +264,7 @@ func (c *NotifierController) SendTestNotificationDirect(ctx *gin.Context) {
 	// For direct test, associate with the current user
 	notifier.UserID = user.ID
 
-	if err := c.notifierService.SendTestNotificationToNotifier(&notifier); err != nil {
+	if err := c.notifierService.SendTestNotificationToNotifier(user, &notifier); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
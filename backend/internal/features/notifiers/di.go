@@ -1,18 +1,22 @@
 package notifiers
 
 import (
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/features/workspaces"
 	"postgresus-backend/internal/util/logger"
 )
 
 var notifierRepository = &NotifierRepository{}
 var notifierService = &NotifierService{
 	notifierRepository,
+	workspaces.GetWorkspaceService(),
 	logger.GetLogger(),
 }
 var notifierController = &NotifierController{
 	notifierService,
 	users.GetUserService(),
+	audit_logs.GetAuditLogService(),
 }
 
 func GetNotifierController() *NotifierController {
@@ -1,6 +1,7 @@
 package notifiers
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	discord_notifier "postgresus-backend/internal/features/notifiers/models/discord"
@@ -8,13 +9,16 @@ import (
 	slack_notifier "postgresus-backend/internal/features/notifiers/models/slack"
 	telegram_notifier "postgresus-backend/internal/features/notifiers/models/telegram"
 	webhook_notifier "postgresus-backend/internal/features/notifiers/models/webhook"
+	"postgresus-backend/internal/util/tracing"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Notifier struct {
 	ID            uuid.UUID    `json:"id"            gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
 	UserID        uuid.UUID    `json:"userId"        gorm:"column:user_id;not null;type:uuid;index"`
+	WorkspaceID   uuid.UUID    `json:"workspaceId"   gorm:"column:workspace_id;not null;type:uuid;index"`
 	Name          string       `json:"name"          gorm:"column:name;not null;type:varchar(255)"`
 	NotifierType  NotifierType `json:"notifierType"  gorm:"column:notifier_type;not null;type:varchar(50)"`
 	LastSendError *string      `json:"lastSendError" gorm:"column:last_send_error;type:text"`
@@ -39,8 +43,62 @@ func (n *Notifier) Validate() error {
 	return n.getSpecificNotifier().Validate()
 }
 
+// HideSensitiveData clears secrets of the specific notifier before it is
+// returned by the API
+func (n *Notifier) HideSensitiveData() {
+	if n.TelegramNotifier != nil {
+		n.TelegramNotifier.HideSensitiveData()
+	}
+
+	if n.EmailNotifier != nil {
+		n.EmailNotifier.HideSensitiveData()
+	}
+
+	if n.SlackNotifier != nil {
+		n.SlackNotifier.HideSensitiveData()
+	}
+
+	if n.DiscordNotifier != nil {
+		n.DiscordNotifier.HideSensitiveData()
+	}
+}
+
+// FillSensitiveData restores secrets which the client left empty
+// from the stored notifier
+func (n *Notifier) FillSensitiveData(existing *Notifier) {
+	if existing == nil || n.NotifierType != existing.NotifierType {
+		return
+	}
+
+	if n.TelegramNotifier != nil {
+		n.TelegramNotifier.FillSensitiveData(existing.TelegramNotifier)
+	}
+
+	if n.EmailNotifier != nil {
+		n.EmailNotifier.FillSensitiveData(existing.EmailNotifier)
+	}
+
+	if n.SlackNotifier != nil {
+		n.SlackNotifier.FillSensitiveData(existing.SlackNotifier)
+	}
+
+	if n.DiscordNotifier != nil {
+		n.DiscordNotifier.FillSensitiveData(existing.DiscordNotifier)
+	}
+}
+
 func (n *Notifier) Send(logger *slog.Logger, heading string, message string) error {
+	// notifications are sent apart from the action they are about,
+	// so each send is a trace of its own
+	_, span := tracing.StartSpan(
+		context.Background(),
+		"notifier.send",
+		attribute.String("notifier.id", n.ID.String()),
+		attribute.String("notifier.type", string(n.NotifierType)),
+	)
+
 	err := n.getSpecificNotifier().Send(logger, heading, message)
+	tracing.EndSpan(span, err)
 
 	if err != nil {
 		lastSendError := err.Error()
@@ -8,8 +8,10 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"postgresus-backend/internal/features/secrets"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type DiscordNotifier struct {
@@ -21,6 +23,30 @@ func (d *DiscordNotifier) TableName() string {
 	return "discord_notifiers"
 }
 
+func (d *DiscordNotifier) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&d.ChannelWebhookURL)
+}
+
+func (d *DiscordNotifier) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&d.ChannelWebhookURL)
+}
+
+func (d *DiscordNotifier) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&d.ChannelWebhookURL)
+}
+
+// HideSensitiveData clears the webhook URL, since its token
+// allows anyone to post to the channel
+func (d *DiscordNotifier) HideSensitiveData() {
+	d.ChannelWebhookURL = ""
+}
+
+func (d *DiscordNotifier) FillSensitiveData(existing *DiscordNotifier) {
+	if existing != nil && d.ChannelWebhookURL == "" {
+		d.ChannelWebhookURL = existing.ChannelWebhookURL
+	}
+}
+
 func (d *DiscordNotifier) Validate() error {
 	if d.ChannelWebhookURL == "" {
 		return errors.New("webhook URL is required")
@@ -7,9 +7,11 @@ import (
 	"log/slog"
 	"net"
 	"net/smtp"
+	"postgresus-backend/internal/features/secrets"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 const (
@@ -26,13 +28,43 @@ type EmailNotifier struct {
 	SMTPHost     string    `json:"smtpHost"     gorm:"not null;type:varchar(255);column:smtp_host"`
 	SMTPPort     int       `json:"smtpPort"     gorm:"not null;column:smtp_port"`
 	SMTPUser     string    `json:"smtpUser"     gorm:"type:varchar(255);column:smtp_user"`
-	SMTPPassword string    `json:"smtpPassword" gorm:"type:varchar(255);column:smtp_password"`
+	SMTPPassword string    `json:"smtpPassword" gorm:"type:text;column:smtp_password"`
 }
 
 func (e *EmailNotifier) TableName() string {
 	return "email_notifiers"
 }
 
+func (e *EmailNotifier) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&e.SMTPPassword)
+}
+
+func (e *EmailNotifier) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&e.SMTPPassword)
+}
+
+func (e *EmailNotifier) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&e.SMTPPassword)
+}
+
+func (e *EmailNotifier) HideSensitiveData() {
+	e.SMTPPassword = ""
+}
+
+// FillSensitiveData keeps the stored SMTP password for the
+// same SMTP server and user
+func (e *EmailNotifier) FillSensitiveData(existing *EmailNotifier) {
+	if existing == nil || e.SMTPPassword != "" {
+		return
+	}
+
+	if e.SMTPHost == existing.SMTPHost &&
+		e.SMTPPort == existing.SMTPPort &&
+		e.SMTPUser == existing.SMTPUser {
+		e.SMTPPassword = existing.SMTPPassword
+	}
+}
+
 func (e *EmailNotifier) Validate() error {
 	if e.TargetEmail == "" {
 		return errors.New("target email is required")
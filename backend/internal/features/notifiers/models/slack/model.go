@@ -8,11 +8,13 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"postgresus-backend/internal/features/secrets"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type SlackNotifier struct {
@@ -23,6 +25,28 @@ type SlackNotifier struct {
 
 func (s *SlackNotifier) TableName() string { return "slack_notifiers" }
 
+func (s *SlackNotifier) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&s.BotToken)
+}
+
+func (s *SlackNotifier) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&s.BotToken)
+}
+
+func (s *SlackNotifier) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&s.BotToken)
+}
+
+func (s *SlackNotifier) HideSensitiveData() {
+	s.BotToken = ""
+}
+
+func (s *SlackNotifier) FillSensitiveData(existing *SlackNotifier) {
+	if existing != nil && s.BotToken == "" {
+		s.BotToken = existing.BotToken
+	}
+}
+
 func (s *SlackNotifier) Validate() error {
 	if s.BotToken == "" {
 		return errors.New("bot token is required")
@@ -7,9 +7,11 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"postgresus-backend/internal/features/secrets"
 	"strings"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type TelegramNotifier struct {
@@ -22,6 +24,30 @@ func (t *TelegramNotifier) TableName() string {
 	return "telegram_notifiers"
 }
 
+func (t *TelegramNotifier) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&t.BotToken)
+}
+
+func (t *TelegramNotifier) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&t.BotToken)
+}
+
+func (t *TelegramNotifier) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&t.BotToken)
+}
+
+func (t *TelegramNotifier) HideSensitiveData() {
+	t.BotToken = ""
+}
+
+// FillSensitiveData keeps the stored bot token. It is sent only
+// to Telegram API, so it is kept even if the chat is changed
+func (t *TelegramNotifier) FillSensitiveData(existing *TelegramNotifier) {
+	if existing != nil && t.BotToken == "" {
+		t.BotToken = existing.BotToken
+	}
+}
+
 func (t *TelegramNotifier) Validate() error {
 	if t.BotToken == "" {
 		return errors.New("bot token is required")
@@ -128,7 +128,7 @@ func (r *NotifierRepository) FindByID(id uuid.UUID) (*Notifier, error) {
 	return &notifier, nil
 }
 
-func (r *NotifierRepository) FindByUserID(userID uuid.UUID) ([]*Notifier, error) {
+func (r *NotifierRepository) FindByWorkspaceIDs(workspaceIDs []uuid.UUID) ([]*Notifier, error) {
 	var notifiers []*Notifier
 
 	if err := storage.
@@ -138,7 +138,7 @@ func (r *NotifierRepository) FindByUserID(userID uuid.UUID) ([]*Notifier, error)
 		Preload("WebhookNotifier").
 		Preload("SlackNotifier").
 		Preload("DiscordNotifier").
-		Where("user_id = ?", userID).
+		Where("workspace_id IN ?", workspaceIDs).
 		Order("name ASC").
 		Find(&notifiers).Error; err != nil {
 		return nil, err
@@ -1,15 +1,16 @@
 package notifiers
 
 import (
-	"errors"
 	"log/slog"
 	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/features/workspaces"
 
 	"github.com/google/uuid"
 )
 
 type NotifierService struct {
 	notifierRepository *NotifierRepository
+	workspaceService   *workspaces.WorkspaceService
 	logger             *slog.Logger
 }
 
@@ -23,15 +24,42 @@ func (s *NotifierService) SaveNotifier(
 			return err
 		}
 
-		if existingNotifier.UserID != user.ID {
-			return errors.New("you have not access to this notifier")
+		if err := s.workspaceService.CheckRole(
+			user,
+			existingNotifier.WorkspaceID,
+			workspaces.WorkspaceRoleAdmin,
+		); err != nil {
+			return err
 		}
 
 		notifier.UserID = existingNotifier.UserID
+		notifier.WorkspaceID = existingNotifier.WorkspaceID
+		notifier.FillSensitiveData(existingNotifier)
 	} else {
+		if notifier.WorkspaceID == uuid.Nil {
+			workspaceID, err := s.workspaceService.GetDefaultWorkspaceID(user.ID)
+			if err != nil {
+				return err
+			}
+
+			notifier.WorkspaceID = workspaceID
+		}
+
+		if err := s.workspaceService.CheckRole(
+			user,
+			notifier.WorkspaceID,
+			workspaces.WorkspaceRoleAdmin,
+		); err != nil {
+			return err
+		}
+
 		notifier.UserID = user.ID
 	}
 
+	if err := notifier.Validate(); err != nil {
+		return err
+	}
+
 	_, err := s.notifierRepository.Save(notifier)
 	if err != nil {
 		return err
@@ -49,8 +77,12 @@ func (s *NotifierService) DeleteNotifier(
 		return err
 	}
 
-	if notifier.UserID != user.ID {
-		return errors.New("you have not access to this notifier")
+	if err := s.workspaceService.CheckRole(
+		user,
+		notifier.WorkspaceID,
+		workspaces.WorkspaceRoleAdmin,
+	); err != nil {
+		return err
 	}
 
 	return s.notifierRepository.Delete(notifier)
@@ -65,17 +97,41 @@ func (s *NotifierService) GetNotifier(
 		return nil, err
 	}
 
-	if notifier.UserID != user.ID {
-		return nil, errors.New("you have not access to this notifier")
+	if err := s.workspaceService.CheckRole(
+		user,
+		notifier.WorkspaceID,
+		workspaces.WorkspaceRoleViewer,
+	); err != nil {
+		return nil, err
 	}
 
 	return notifier, nil
 }
 
+// GetNotifiers returns notifiers of the workspace or, if workspace is
+// not specified, notifiers of all workspaces of the user
 func (s *NotifierService) GetNotifiers(
 	user *users_models.User,
+	workspaceID *uuid.UUID,
 ) ([]*Notifier, error) {
-	return s.notifierRepository.FindByUserID(user.ID)
+	if workspaceID != nil {
+		if err := s.workspaceService.CheckRole(
+			user,
+			*workspaceID,
+			workspaces.WorkspaceRoleViewer,
+		); err != nil {
+			return nil, err
+		}
+
+		return s.notifierRepository.FindByWorkspaceIDs([]uuid.UUID{*workspaceID})
+	}
+
+	workspaceIDs, err := s.workspaceService.GetWorkspaceIDs(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.notifierRepository.FindByWorkspaceIDs(workspaceIDs)
 }
 
 func (s *NotifierService) SendTestNotification(
@@ -87,8 +143,12 @@ func (s *NotifierService) SendTestNotification(
 		return err
 	}
 
-	if notifier.UserID != user.ID {
-		return errors.New("you have not access to this notifier")
+	if err := s.workspaceService.CheckRole(
+		user,
+		notifier.WorkspaceID,
+		workspaces.WorkspaceRoleOperator,
+	); err != nil {
+		return err
 	}
 
 	err = notifier.Send(s.logger, "Test message", "This is a test message")
@@ -104,9 +164,30 @@ func (s *NotifierService) SendTestNotification(
 	return nil
 }
 
+// SendTestNotificationToNotifier sends test message with notifier settings
+// from the request. For saved notifiers the stored secrets are used for the
+// fields the client left empty
 func (s *NotifierService) SendTestNotificationToNotifier(
+	user *users_models.User,
 	notifier *Notifier,
 ) error {
+	if notifier.ID != uuid.Nil {
+		existingNotifier, err := s.notifierRepository.FindByID(notifier.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.workspaceService.CheckRole(
+			user,
+			existingNotifier.WorkspaceID,
+			workspaces.WorkspaceRoleOperator,
+		); err != nil {
+			return err
+		}
+
+		notifier.FillSensitiveData(existingNotifier)
+	}
+
 	return notifier.Send(s.logger, "Test message", "This is a test message")
 }
 
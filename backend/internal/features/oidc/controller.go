@@ -0,0 +1,92 @@
+package oidc
+
+import (
+	"net/http"
+	"net/url"
+	"postgresus-backend/internal/features/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UI page which takes the token from URL fragment. Fragment
+// is not sent to servers, so the token does not reach logs
+const uiCallbackPath = "/auth/oidc"
+
+type OidcController struct {
+	oidcService *OidcService
+}
+
+func (c *OidcController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/users/oidc/settings", c.GetSettings)
+	router.GET("/users/oidc/login", c.Login)
+	router.GET("/users/oidc/callback", c.Callback)
+}
+
+// GetSettings
+// @Summary Get SSO settings
+// @Description Get whether OIDC login is enabled and whether password login is disabled
+// @Tags users
+// @Produce json
+// @Success 200 {object} OidcSettingsResponse
+// @Router /users/oidc/settings [get]
+func (c *OidcController) GetSettings(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.oidcService.GetSettings())
+}
+
+// Login
+// @Summary Start SSO login
+// @Description Redirect to login page of OIDC provider (authorization code flow with PKCE)
+// @Tags users
+// @Success 302
+// @Failure 400
+// @Router /users/oidc/login [get]
+func (c *OidcController) Login(ctx *gin.Context) {
+	authUrl, err := c.oidcService.StartLogin()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Redirect(http.StatusFound, authUrl)
+}
+
+// Callback
+// @Summary Finish SSO login
+// @Description Handle redirect from OIDC provider and redirect to UI with access token in URL fragment
+// @Tags users
+// @Param state query string true "State"
+// @Param code query string true "Authorization code"
+// @Success 302
+// @Router /users/oidc/callback [get]
+func (c *OidcController) Callback(ctx *gin.Context) {
+	if providerError := ctx.Query("error"); providerError != "" {
+		message := providerError
+		if description := ctx.Query("error_description"); description != "" {
+			message = description
+		}
+
+		redirectToUi(ctx, url.Values{"error": {message}})
+		return
+	}
+
+	response, err := c.oidcService.FinishLogin(
+		ctx.Request.Context(),
+		ctx.Query("state"),
+		ctx.Query("code"),
+		users.GetSessionClient(ctx),
+	)
+	if err != nil {
+		redirectToUi(ctx, url.Values{"error": {err.Error()}})
+		return
+	}
+
+	redirectToUi(ctx, url.Values{
+		"token":        {response.Token},
+		"refreshToken": {response.RefreshToken},
+		"userId":       {response.UserID.String()},
+	})
+}
+
+func redirectToUi(ctx *gin.Context, fragment url.Values) {
+	ctx.Redirect(http.StatusFound, uiCallbackPath+"#"+fragment.Encode())
+}
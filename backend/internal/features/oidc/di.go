@@ -0,0 +1,28 @@
+package oidc
+
+import (
+	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/features/workspaces"
+	"postgresus-backend/internal/util/logger"
+	"sync"
+)
+
+var oidcService = &OidcService{
+	users.GetUserService(),
+	workspaces.GetWorkspaceService(),
+	logger.GetLogger(),
+	sync.Mutex{},
+	nil,
+	map[string]*pendingLogin{},
+}
+var oidcController = &OidcController{
+	oidcService,
+}
+
+func GetOidcService() *OidcService {
+	return oidcService
+}
+
+func GetOidcController() *OidcController {
+	return oidcController
+}
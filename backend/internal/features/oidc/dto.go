@@ -0,0 +1,7 @@
+package oidc
+
+type OidcSettingsResponse struct {
+	IsEnabled               bool   `json:"isEnabled"`
+	ProviderName            string `json:"providerName"`
+	IsPasswordLoginDisabled bool   `json:"isPasswordLoginDisabled"`
+}
@@ -0,0 +1,119 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+type jsonWebKey struct {
+	KeyID   string `json:"kid"`
+	KeyType string `json:"kty"`
+	Use     string `json:"use"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// toPublicKeys returns signing keys by their IDs. Encryption
+// keys and keys of unsupported types are skipped
+func (s *jsonWebKeySet) toPublicKeys() (map[string]crypto.PublicKey, error) {
+	keys := make(map[string]crypto.PublicKey)
+
+	for _, key := range s.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		switch key.KeyType {
+		case "RSA":
+			publicKey, err := key.toRsaPublicKey()
+			if err != nil {
+				return nil, err
+			}
+
+			keys[key.KeyID] = publicKey
+		case "EC":
+			publicKey, err := key.toEcdsaPublicKey()
+			if err != nil {
+				return nil, err
+			}
+
+			keys[key.KeyID] = publicKey
+		}
+	}
+
+	return keys, nil
+}
+
+func (k *jsonWebKey) toRsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus of key %s: %w", k.KeyID, err)
+	}
+
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent of key %s: %w", k.KeyID, err)
+	}
+
+	if !e.IsInt64() {
+		return nil, fmt.Errorf("exponent of key %s is too large", k.KeyID)
+	}
+
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k *jsonWebKey) toEcdsaPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+
+	switch k.Curve {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %s of key %s", k.Curve, k.KeyID)
+	}
+
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x of key %s: %w", k.KeyID, err)
+	}
+
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y of key %s: %w", k.KeyID, err)
+	}
+
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("key %s is not on curve %s", k.KeyID, k.Curve)
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(bytes), nil
+}
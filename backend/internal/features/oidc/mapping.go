@@ -0,0 +1,102 @@
+package oidc
+
+import (
+	"errors"
+	"fmt"
+	"postgresus-backend/internal/features/workspaces"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type Identity struct {
+	Email  string
+	Groups []string
+}
+
+// ParseGroupRoleMapping parses mapping like "dba=ADMIN,developers=VIEWER"
+// of provider groups to workspace roles
+func ParseGroupRoleMapping(value string) (map[string]workspaces.WorkspaceRole, error) {
+	mapping := make(map[string]workspaces.WorkspaceRole)
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		group, role, ok := strings.Cut(item, "=")
+		group = strings.TrimSpace(group)
+		workspaceRole := workspaces.WorkspaceRole(strings.ToUpper(strings.TrimSpace(role)))
+
+		if !ok || group == "" {
+			return nil, fmt.Errorf("invalid group mapping %q, expected group=ROLE", item)
+		}
+
+		if !workspaceRole.IsValid() {
+			return nil, fmt.Errorf("invalid role %q of group %s", role, group)
+		}
+
+		mapping[group] = workspaceRole
+	}
+
+	return mapping, nil
+}
+
+// ResolveRole returns the highest role of the groups. Users without
+// mapped groups get the default role, nil means no access
+func ResolveRole(
+	groups []string,
+	mapping map[string]workspaces.WorkspaceRole,
+	defaultRole *workspaces.WorkspaceRole,
+) *workspaces.WorkspaceRole {
+	var resolvedRole *workspaces.WorkspaceRole
+
+	for _, group := range groups {
+		role, ok := mapping[group]
+		if !ok {
+			continue
+		}
+
+		if resolvedRole == nil || !resolvedRole.IsAtLeast(role) {
+			resolvedRole = &role
+		}
+	}
+
+	if resolvedRole == nil {
+		return defaultRole
+	}
+
+	return resolvedRole
+}
+
+// GetIdentity extracts email and groups from verified ID token claims
+func GetIdentity(claims jwt.MapClaims, groupsClaim string) (*Identity, error) {
+	email, _ := claims["email"].(string)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return nil, errors.New("ID token has no email, request the email scope")
+	}
+
+	if isEmailVerified, ok := claims["email_verified"].(bool); ok && !isEmailVerified {
+		return nil, errors.New("email is not verified by the provider")
+	}
+
+	identity := &Identity{
+		Email:  email,
+		Groups: []string{},
+	}
+
+	switch groups := claims[groupsClaim].(type) {
+	case string:
+		identity.Groups = append(identity.Groups, groups)
+	case []interface{}:
+		for _, group := range groups {
+			if groupName, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, groupName)
+			}
+		}
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,71 @@
+package oidc
+
+import (
+	"postgresus-backend/internal/features/workspaces"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseGroupRoleMapping_WithValidMapping_ParsesRoles(t *testing.T) {
+	mapping, err := ParseGroupRoleMapping(" dba=admin, developers=VIEWER ,,ops=OPERATOR")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]workspaces.WorkspaceRole{
+		"dba":        workspaces.WorkspaceRoleAdmin,
+		"developers": workspaces.WorkspaceRoleViewer,
+		"ops":        workspaces.WorkspaceRoleOperator,
+	}, mapping)
+}
+
+func Test_ParseGroupRoleMapping_WithInvalidMapping_ReturnsError(t *testing.T) {
+	_, err := ParseGroupRoleMapping("dba")
+	assert.Error(t, err)
+
+	_, err = ParseGroupRoleMapping("dba=SUPERUSER")
+	assert.Error(t, err)
+
+	_, err = ParseGroupRoleMapping("=ADMIN")
+	assert.Error(t, err)
+}
+
+func Test_ResolveRole_WithSeveralGroups_ReturnsHighestRole(t *testing.T) {
+	mapping := map[string]workspaces.WorkspaceRole{
+		"dba":        workspaces.WorkspaceRoleAdmin,
+		"developers": workspaces.WorkspaceRoleViewer,
+	}
+
+	role := ResolveRole([]string{"developers", "other", "dba"}, mapping, nil)
+
+	assert.Equal(t, workspaces.WorkspaceRoleAdmin, *role)
+}
+
+func Test_ResolveRole_WithoutMappedGroups_ReturnsDefaultRole(t *testing.T) {
+	mapping := map[string]workspaces.WorkspaceRole{"dba": workspaces.WorkspaceRoleAdmin}
+	defaultRole := workspaces.WorkspaceRoleViewer
+
+	assert.Nil(t, ResolveRole([]string{"other"}, mapping, nil))
+	assert.Equal(t, defaultRole, *ResolveRole([]string{"other"}, mapping, &defaultRole))
+}
+
+func Test_GetIdentity_WithUnverifiedEmail_ReturnsError(t *testing.T) {
+	_, err := GetIdentity(jwt.MapClaims{
+		"email":          "user@example.com",
+		"email_verified": false,
+	}, "groups")
+	assert.Error(t, err)
+
+	_, err = GetIdentity(jwt.MapClaims{"sub": "user-1"}, "groups")
+	assert.Error(t, err)
+}
+
+func Test_GetIdentity_WithCustomGroupsClaim_ReadsGroups(t *testing.T) {
+	identity, err := GetIdentity(jwt.MapClaims{
+		"email": "user@example.com",
+		"roles": []interface{}{"dba", "developers"},
+	}, "roles")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dba", "developers"}, identity.Groups)
+}
@@ -0,0 +1,250 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/oauth2"
+)
+
+type ProviderSettings struct {
+	IssuerUrl    string
+	ClientID     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+// Provider talks to OpenID Connect provider: builds authorization URL,
+// exchanges authorization code and verifies ID tokens by provider keys
+type Provider struct {
+	settings   ProviderSettings
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	discovery *discoveryDocument
+	keys      map[string]crypto.PublicKey
+}
+
+func NewProvider(settings ProviderSettings, httpClient *http.Client) *Provider {
+	return &Provider{
+		settings:   settings,
+		httpClient: httpClient,
+	}
+}
+
+// GetAuthCodeUrl returns URL of provider login page. PKCE challenge is
+// derived from the verifier, which is later sent with the code
+func (p *Provider) GetAuthCodeUrl(state, nonce, verifier string) (string, error) {
+	oauthConfig, err := p.getOauthConfig()
+	if err != nil {
+		return "", err
+	}
+
+	return oauthConfig.AuthCodeURL(
+		state,
+		oauth2.S256ChallengeOption(verifier),
+		oauth2.SetAuthURLParam("nonce", nonce),
+	), nil
+}
+
+// ExchangeCode exchanges authorization code for tokens and
+// returns raw ID token. It is not verified yet
+func (p *Provider) ExchangeCode(ctx context.Context, code, verifier string) (string, error) {
+	oauthConfig, err := p.getOauthConfig()
+	if err != nil {
+		return "", err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.httpClient)
+
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	rawIdToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIdToken == "" {
+		return "", errors.New("provider did not return ID token")
+	}
+
+	return rawIdToken, nil
+}
+
+// VerifyIdToken checks signature, issuer, audience, expiration
+// and nonce of the ID token and returns its claims
+func (p *Provider) VerifyIdToken(rawIdToken, nonce string) (jwt.MapClaims, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+
+	_, err = jwt.ParseWithClaims(
+		rawIdToken,
+		claims,
+		func(token *jwt.Token) (interface{}, error) {
+			keyID, _ := token.Header["kid"].(string)
+			return p.getKey(keyID)
+		},
+		jwt.WithValidMethods([]string{
+			"RS256", "RS384", "RS512",
+			"PS256", "PS384", "PS512",
+			"ES256", "ES384", "ES512",
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	if !claims.VerifyIssuer(discovery.Issuer, true) {
+		return nil, errors.New("ID token is issued by another issuer")
+	}
+
+	if !claims.VerifyAudience(p.settings.ClientID, true) {
+		return nil, errors.New("ID token is issued for another client")
+	}
+
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errors.New("ID token is expired")
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, errors.New("ID token nonce does not match")
+	}
+
+	return claims, nil
+}
+
+func (p *Provider) getOauthConfig() (*oauth2.Config, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.settings.ClientID,
+		ClientSecret: p.settings.ClientSecret,
+		RedirectURL:  p.settings.RedirectUrl,
+		Scopes:       p.settings.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+	}, nil
+}
+
+func (p *Provider) getDiscovery() (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	issuerUrl := strings.TrimSuffix(p.settings.IssuerUrl, "/")
+
+	var discovery discoveryDocument
+	if err := p.getJson(issuerUrl+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to load OIDC discovery document: %w", err)
+	}
+
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuerUrl {
+		return nil, fmt.Errorf(
+			"provider issuer %s does not match configured %s",
+			discovery.Issuer,
+			p.settings.IssuerUrl,
+		)
+	}
+
+	if discovery.AuthorizationEndpoint == "" ||
+		discovery.TokenEndpoint == "" ||
+		discovery.JwksUri == "" {
+		return nil, errors.New("OIDC discovery document misses required endpoints")
+	}
+
+	p.discovery = &discovery
+
+	return p.discovery, nil
+}
+
+// getKey returns signing key of the provider. Keys are reloaded when
+// the key is unknown, because providers rotate them
+func (p *Provider) getKey(keyID string) (crypto.PublicKey, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.findKey(keyID); ok {
+		return key, nil
+	}
+
+	var keySet jsonWebKeySet
+	if err := p.getJson(discovery.JwksUri, &keySet); err != nil {
+		return nil, fmt.Errorf("failed to load provider keys: %w", err)
+	}
+
+	keys, err := keySet.toPublicKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	p.keys = keys
+
+	if key, ok := p.findKey(keyID); ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown signing key %s", keyID)
+}
+
+// findKey looks up the key by ID. Tokens without key ID are
+// accepted only when the provider has the single key
+func (p *Provider) findKey(keyID string) (crypto.PublicKey, bool) {
+	if keyID == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+
+	key, ok := p.keys[keyID]
+	return key, ok
+}
+
+func (p *Provider) getJson(url string, target any) error {
+	response, err := p.httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("%s returned %d: %s", url, response.StatusCode, string(body))
+	}
+
+	return json.NewDecoder(response.Body).Decode(target)
+}
@@ -0,0 +1,245 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testClientID = "postgresus"
+	testKeyID    = "test-key"
+	testCode     = "test-code"
+)
+
+// mockProvider is a minimal OIDC provider: discovery, keys
+// and token endpoint which checks PKCE verifier
+type mockProvider struct {
+	server        *httptest.Server
+	privateKey    *rsa.PrivateKey
+	codeChallenge string
+	idTokenClaims jwt.MapClaims
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	mock := &mockProvider{privateKey: privateKey}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", mock.handleDiscovery)
+	mux.HandleFunc("/jwks", mock.handleJwks)
+	mux.HandleFunc("/token", mock.handleToken)
+
+	mock.server = httptest.NewServer(mux)
+	t.Cleanup(mock.server.Close)
+
+	return mock
+}
+
+func (m *mockProvider) newProvider() *Provider {
+	return NewProvider(ProviderSettings{
+		IssuerUrl:   m.server.URL,
+		ClientID:    testClientID,
+		RedirectUrl: "http://localhost:4005/api/v1/users/oidc/callback",
+		Scopes:      []string{"openid", "email"},
+	}, m.server.Client())
+}
+
+func (m *mockProvider) signIdToken(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+
+	rawToken, err := token.SignedString(m.privateKey)
+	assert.NoError(t, err)
+
+	return rawToken
+}
+
+func (m *mockProvider) validClaims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            m.server.URL,
+		"aud":            testClientID,
+		"sub":            "user-1",
+		"email":          "User@Example.com",
+		"email_verified": true,
+		"groups":         []string{"dba"},
+		"nonce":          nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func (m *mockProvider) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 m.server.URL,
+		"authorization_endpoint": m.server.URL + "/authorize",
+		"token_endpoint":         m.server.URL + "/token",
+		"jwks_uri":               m.server.URL + "/jwks",
+	})
+}
+
+func (m *mockProvider) handleJwks(w http.ResponseWriter, r *http.Request) {
+	publicKey := m.privateKey.PublicKey
+
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"keys": []map[string]string{{
+			"kid": testKeyID,
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(
+				big.NewInt(int64(publicKey.E)).Bytes(),
+			),
+		}},
+	})
+}
+
+func (m *mockProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	verifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	challenge := base64.RawURLEncoding.EncodeToString(verifierHash[:])
+
+	if r.PostForm.Get("code") != testCode || challenge != m.codeChallenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, m.idTokenClaims)
+	token.Header["kid"] = testKeyID
+	rawIdToken, _ := token.SignedString(m.privateKey)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     rawIdToken,
+	})
+}
+
+func Test_LoginViaMockProvider_IdTokenVerified(t *testing.T) {
+	mock := newMockProvider(t)
+	provider := mock.newProvider()
+
+	authUrl, err := provider.GetAuthCodeUrl("test-state", "test-nonce", "test-verifier-"+testCode)
+	assert.NoError(t, err)
+
+	parsedUrl, err := url.Parse(authUrl)
+	assert.NoError(t, err)
+	assert.Equal(t, mock.server.URL+"/authorize", parsedUrl.Scheme+"://"+parsedUrl.Host+parsedUrl.Path)
+	assert.Equal(t, "test-state", parsedUrl.Query().Get("state"))
+	assert.Equal(t, "test-nonce", parsedUrl.Query().Get("nonce"))
+	assert.Equal(t, "S256", parsedUrl.Query().Get("code_challenge_method"))
+
+	mock.codeChallenge = parsedUrl.Query().Get("code_challenge")
+	mock.idTokenClaims = mock.validClaims("test-nonce")
+
+	rawIdToken, err := provider.ExchangeCode(
+		context.Background(),
+		testCode,
+		"test-verifier-"+testCode,
+	)
+	assert.NoError(t, err)
+
+	claims, err := provider.VerifyIdToken(rawIdToken, "test-nonce")
+	assert.NoError(t, err)
+
+	identity, err := GetIdentity(claims, "groups")
+	assert.NoError(t, err)
+	assert.Equal(t, "user@example.com", identity.Email)
+	assert.Equal(t, []string{"dba"}, identity.Groups)
+}
+
+func Test_ExchangeCode_WithWrongVerifier_ReturnsError(t *testing.T) {
+	mock := newMockProvider(t)
+	provider := mock.newProvider()
+
+	authUrl, err := provider.GetAuthCodeUrl("test-state", "test-nonce", "test-verifier-"+testCode)
+	assert.NoError(t, err)
+
+	parsedUrl, err := url.Parse(authUrl)
+	assert.NoError(t, err)
+
+	mock.codeChallenge = parsedUrl.Query().Get("code_challenge")
+	mock.idTokenClaims = mock.validClaims("test-nonce")
+
+	_, err = provider.ExchangeCode(context.Background(), testCode, "another-verifier-"+testCode)
+	assert.Error(t, err)
+}
+
+func Test_VerifyIdToken_WithInvalidClaims_ReturnsError(t *testing.T) {
+	mock := newMockProvider(t)
+	provider := mock.newProvider()
+
+	cases := []struct {
+		name   string
+		modify func(claims jwt.MapClaims)
+	}{
+		{"wrong nonce", func(claims jwt.MapClaims) { claims["nonce"] = "another-nonce" }},
+		{"another audience", func(claims jwt.MapClaims) { claims["aud"] = "another-client" }},
+		{"another issuer", func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" }},
+		{"expired", func(claims jwt.MapClaims) {
+			claims["exp"] = time.Now().Add(-time.Minute).Unix()
+		}},
+		{"no expiration", func(claims jwt.MapClaims) { delete(claims, "exp") }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := mock.validClaims("test-nonce")
+			tc.modify(claims)
+
+			_, err := provider.VerifyIdToken(mock.signIdToken(t, claims), "test-nonce")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_VerifyIdToken_SignedByAnotherKey_ReturnsError(t *testing.T) {
+	mock := newMockProvider(t)
+	provider := mock.newProvider()
+
+	anotherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, mock.validClaims("test-nonce"))
+	token.Header["kid"] = testKeyID
+	rawIdToken, err := token.SignedString(anotherKey)
+	assert.NoError(t, err)
+
+	_, err = provider.VerifyIdToken(rawIdToken, "test-nonce")
+	assert.Error(t, err)
+}
+
+func Test_VerifyIdToken_SignedWithHmac_ReturnsError(t *testing.T) {
+	mock := newMockProvider(t)
+	provider := mock.newProvider()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, mock.validClaims("test-nonce"))
+	token.Header["kid"] = testKeyID
+	rawIdToken, err := token.SignedString([]byte("secret"))
+	assert.NoError(t, err)
+
+	_, err = provider.VerifyIdToken(rawIdToken, "test-nonce")
+	assert.Error(t, err)
+}
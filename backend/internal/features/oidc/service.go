@@ -0,0 +1,218 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/features/workspaces"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+const pendingLoginTTL = 10 * time.Minute
+
+type pendingLogin struct {
+	nonce     string
+	verifier  string
+	createdAt time.Time
+}
+
+type OidcService struct {
+	userService      *users.UserService
+	workspaceService *workspaces.WorkspaceService
+	logger           *slog.Logger
+
+	mu            sync.Mutex
+	provider      *Provider
+	pendingLogins map[string]*pendingLogin
+}
+
+func (s *OidcService) GetSettings() *OidcSettingsResponse {
+	env := config.GetEnv()
+
+	return &OidcSettingsResponse{
+		IsEnabled:               env.OidcIssuerUrl != "",
+		ProviderName:            env.OidcProviderName,
+		IsPasswordLoginDisabled: env.IsPasswordLoginDisabled,
+	}
+}
+
+// StartLogin returns URL of the provider login page. State, nonce and
+// PKCE verifier are kept until the provider redirects back
+func (s *OidcService) StartLogin() (string, error) {
+	provider, err := s.getProvider()
+	if err != nil {
+		return "", err
+	}
+
+	state, err := generateRandomString()
+	if err != nil {
+		return "", err
+	}
+
+	nonce, err := generateRandomString()
+	if err != nil {
+		return "", err
+	}
+
+	verifier := oauth2.GenerateVerifier()
+
+	s.mu.Lock()
+	s.removeExpiredPendingLogins()
+	s.pendingLogins[state] = &pendingLogin{
+		nonce:     nonce,
+		verifier:  verifier,
+		createdAt: time.Now().UTC(),
+	}
+	s.mu.Unlock()
+
+	return provider.GetAuthCodeUrl(state, nonce, verifier)
+}
+
+// FinishLogin exchanges the code for ID token, provisions the user,
+// syncs workspace role by provider groups and signs the user in
+func (s *OidcService) FinishLogin(
+	ctx context.Context,
+	state string,
+	code string,
+	client *users.SessionClient,
+) (*users.SignInResponse, error) {
+	provider, err := s.getProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	login, ok := s.pendingLogins[state]
+	delete(s.pendingLogins, state)
+	s.mu.Unlock()
+
+	if !ok || time.Since(login.createdAt) > pendingLoginTTL {
+		return nil, errors.New("login session is expired, please try again")
+	}
+
+	rawIdToken, err := provider.ExchangeCode(ctx, code, login.verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := provider.VerifyIdToken(rawIdToken, login.nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := GetIdentity(claims, config.GetEnv().OidcGroupsClaim)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetOrCreateExternalUser(identity.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.syncWorkspaceRole(user.ID, identity); err != nil {
+		return nil, err
+	}
+
+	// memberships are checked regardless of two-factor authentication,
+	// otherwise users could not sign in to enable it
+	userWorkspaces, err := s.workspaceService.GetWorkspaces(user)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(userWorkspaces) == 0 {
+		return nil, errors.New(
+			"you have no access to any workspace, ask admin to invite you",
+		)
+	}
+
+	s.logger.Info("User signed in via OIDC", "email", identity.Email, "groups", identity.Groups)
+
+	return s.userService.GenerateAccessToken(user, client)
+}
+
+// syncWorkspaceRole applies group mapping. Without mapping and default
+// role, memberships are managed by invitations only
+func (s *OidcService) syncWorkspaceRole(userID uuid.UUID, identity *Identity) error {
+	env := config.GetEnv()
+
+	if env.OidcGroupRoleMapping == "" && env.OidcDefaultRole == "" {
+		return nil
+	}
+
+	mapping, err := ParseGroupRoleMapping(env.OidcGroupRoleMapping)
+	if err != nil {
+		return err
+	}
+
+	var defaultRole *workspaces.WorkspaceRole
+	if env.OidcDefaultRole != "" {
+		role := workspaces.WorkspaceRole(strings.ToUpper(env.OidcDefaultRole))
+		if !role.IsValid() {
+			return fmt.Errorf("invalid OIDC default role: %s", env.OidcDefaultRole)
+		}
+
+		defaultRole = &role
+	}
+
+	return s.workspaceService.SyncMemberRoleInOldestWorkspace(
+		userID,
+		ResolveRole(identity.Groups, mapping, defaultRole),
+	)
+}
+
+func (s *OidcService) getProvider() (*Provider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.provider != nil {
+		return s.provider, nil
+	}
+
+	env := config.GetEnv()
+	if env.OidcIssuerUrl == "" {
+		return nil, errors.New("OIDC login is not configured")
+	}
+
+	s.provider = NewProvider(
+		ProviderSettings{
+			IssuerUrl:    env.OidcIssuerUrl,
+			ClientID:     env.OidcClientID,
+			ClientSecret: env.OidcClientSecret,
+			RedirectUrl:  env.OidcRedirectUrl,
+			Scopes:       strings.Fields(env.OidcScopes),
+		},
+		&http.Client{Timeout: 30 * time.Second},
+	)
+
+	return s.provider, nil
+}
+
+func (s *OidcService) removeExpiredPendingLogins() {
+	for state, login := range s.pendingLogins {
+		if time.Since(login.createdAt) > pendingLoginTTL {
+			delete(s.pendingLogins, state)
+		}
+	}
+}
+
+func generateRandomString() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random string: %w", err)
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
@@ -2,6 +2,7 @@ package restores
 
 import (
 	"net/http"
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/users"
 
 	"github.com/gin-gonic/gin"
@@ -9,8 +10,9 @@ import (
 )
 
 type RestoreController struct {
-	restoreService *RestoreService
-	userService    *users.UserService
+	restoreService  *RestoreService
+	userService     *users.UserService
+	auditLogService *audit_logs.AuditLogService
 }
 
 func (c *RestoreController) RegisterRoutes(router *gin.RouterGroup) {
@@ -53,6 +55,10 @@ func (c *RestoreController) GetRestores(ctx *gin.Context) {
 		return
 	}
 
+	for _, restore := range restores {
+		restore.HideSensitiveData()
+	}
+
 	ctx.JSON(http.StatusOK, restores)
 }
 
@@ -90,7 +96,25 @@ func (c *RestoreController) RestoreBackup(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.restoreService.RestoreBackupWithAuth(user, backupID, requestDTO); err != nil {
+	auditEvent := &audit_logs.AuditEvent{
+		Action:     audit_logs.AuditActionRestoreCreate,
+		TargetType: audit_logs.AuditTargetTypeBackup,
+		TargetID:   &backupID,
+		After:      &requestDTO,
+	}
+
+	backup, err := c.restoreService.backupService.GetBackup(backupID)
+	if err == nil && backup.Database != nil {
+		auditEvent.TargetName = backup.Database.Name
+		auditEvent.WorkspaceID = &backup.Database.WorkspaceID
+	}
+
+	err = c.restoreService.RestoreBackupWithAuth(user, backupID, requestDTO)
+
+	auditEvent.Err = err
+	c.auditLogService.LogUserAction(user, ctx.ClientIP(), auditEvent)
+
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
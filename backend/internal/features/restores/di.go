@@ -1,6 +1,7 @@
 package restores
 
 import (
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/backups/backups"
 	backups_config "postgresus-backend/internal/features/backups/config"
 	"postgresus-backend/internal/features/databases"
@@ -23,6 +24,7 @@ var restoreService = &RestoreService{
 var restoreController = &RestoreController{
 	restoreService,
 	users.GetUserService(),
+	audit_logs.GetAuditLogService(),
 }
 
 var restoreBackgroundService = &RestoreBackgroundService{
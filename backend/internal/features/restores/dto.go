@@ -1,9 +1,29 @@
 package restores
 
 import (
+	"postgresus-backend/internal/features/databases/databases/mongodb"
+	"postgresus-backend/internal/features/databases/databases/mysql"
 	"postgresus-backend/internal/features/databases/databases/postgresql"
+	"time"
 )
 
 type RestoreBackupRequest struct {
 	PostgresqlDatabase *postgresql.PostgresqlDatabase `json:"postgresqlDatabase"`
+	MysqlDatabase      *mysql.MysqlDatabase           `json:"mysqlDatabase"`
+	MongodbDatabase    *mongodb.MongodbDatabase       `json:"mongodbDatabase"`
+
+	// Recovery target for physical backups. If both are empty,
+	// recovery replays all archived WAL
+	TargetTime *time.Time `json:"targetTime"`
+	TargetLsn  *string    `json:"targetLsn"`
+
+	// Parts of cluster backup to restore: globals (roles, tablespaces)
+	// and any subset of member databases, selected by name
+	IsRestoreGlobals bool     `json:"isRestoreGlobals"`
+	ClusterDatabases []string `json:"clusterDatabases"`
+
+	// Objects of logical backup to restore as schema.name (e.g. a single
+	// table with its data, defaults, constraints and triggers). If empty,
+	// the whole backup is restored
+	RestoreObjects []string `json:"restoreObjects"`
 }
@@ -2,6 +2,8 @@ package models
 
 import (
 	"postgresus-backend/internal/features/backups/backups"
+	"postgresus-backend/internal/features/databases/databases/mongodb"
+	"postgresus-backend/internal/features/databases/databases/mysql"
 	"postgresus-backend/internal/features/databases/databases/postgresql"
 	"postgresus-backend/internal/features/restores/enums"
 	"time"
@@ -17,9 +19,43 @@ type Restore struct {
 	Backup   *backups.Backup
 
 	Postgresql *postgresql.PostgresqlDatabase `json:"postgresql,omitempty" gorm:"foreignKey:RestoreID"`
+	Mysql      *mysql.MysqlDatabase           `json:"mysql,omitempty"      gorm:"foreignKey:RestoreID"`
+	Mongodb    *mongodb.MongodbDatabase       `json:"mongodb,omitempty"    gorm:"foreignKey:RestoreID"`
+
+	// Point-in-time recovery of physical backup
+	TargetTime    *time.Time `json:"targetTime"    gorm:"column:target_time"`
+	TargetLsn     *string    `json:"targetLsn"     gorm:"column:target_lsn;type:text"`
+	DataDirectory *string    `json:"dataDirectory" gorm:"column:data_directory;type:text"`
+
+	// Parts of cluster backup to restore: globals and member databases
+	IsRestoreGlobals bool     `json:"isRestoreGlobals" gorm:"column:is_restore_globals;type:boolean;not null"`
+	ClusterDatabases []string `json:"clusterDatabases" gorm:"column:cluster_databases;type:jsonb;serializer:json"`
+
+	// Objects of logical backup to restore selectively as schema.name
+	RestoreObjects []string `json:"restoreObjects" gorm:"column:restore_objects;type:jsonb;serializer:json"`
 
 	FailMessage *string `json:"failMessage" gorm:"column:fail_message"`
 
 	RestoreDurationMs int64     `json:"restoreDurationMs" gorm:"column:restore_duration_ms;default:0"`
 	CreatedAt         time.Time `json:"createdAt"         gorm:"column:created_at;default:now()"`
 }
+
+// HideSensitiveData clears credentials of the target database
+// and of the backup before the restore is returned by the API
+func (r *Restore) HideSensitiveData() {
+	if r.Postgresql != nil {
+		r.Postgresql.HideSensitiveData()
+	}
+
+	if r.Mysql != nil {
+		r.Mysql.HideSensitiveData()
+	}
+
+	if r.Mongodb != nil {
+		r.Mongodb.HideSensitiveData()
+	}
+
+	if r.Backup != nil {
+		r.Backup.HideSensitiveData()
+	}
+}
@@ -33,6 +33,8 @@ func (r *RestoreRepository) FindByBackupID(backupID uuid.UUID) ([]*models.Restor
 		GetDb().
 		Preload("Backup").
 		Preload("Postgresql").
+		Preload("Mysql").
+		Preload("Mongodb").
 		Where("backup_id = ?", backupID).
 		Order("created_at DESC").
 		Find(&restores).Error; err != nil {
@@ -49,6 +51,8 @@ func (r *RestoreRepository) FindByID(id uuid.UUID) (*models.Restore, error) {
 		GetDb().
 		Preload("Backup").
 		Preload("Postgresql").
+		Preload("Mysql").
+		Preload("Mongodb").
 		Where("id = ?", id).
 		First(&restore).Error; err != nil {
 		return nil, err
@@ -66,6 +70,8 @@ func (r *RestoreRepository) FindByStatus(status enums.RestoreStatus) ([]*models.
 		Preload("Backup.Database").
 		Preload("Backup").
 		Preload("Postgresql").
+		Preload("Mysql").
+		Preload("Mongodb").
 		Where("status = ?", status).
 		Order("created_at DESC").
 		Find(&restores).Error; err != nil {
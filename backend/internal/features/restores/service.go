@@ -1,21 +1,30 @@
 package restores
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"path/filepath"
+	"postgresus-backend/internal/config"
 	"postgresus-backend/internal/features/backups/backups"
 	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_wal "postgresus-backend/internal/features/backups/wal"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/restores/enums"
 	"postgresus-backend/internal/features/restores/models"
 	"postgresus-backend/internal/features/restores/usecases"
 	"postgresus-backend/internal/features/storages"
 	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/features/workspaces"
 	"postgresus-backend/internal/util/tools"
+	"postgresus-backend/internal/util/tracing"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type RestoreService struct {
@@ -58,8 +67,12 @@ func (s *RestoreService) GetRestores(
 		return nil, err
 	}
 
-	if backup.Database.UserID != user.ID {
-		return nil, errors.New("user does not have access to this backup")
+	if err := s.databaseService.CheckAccess(
+		user,
+		backup.Database,
+		workspaces.WorkspaceRoleViewer,
+	); err != nil {
+		return nil, err
 	}
 
 	return s.restoreRepository.FindByBackupID(backupID)
@@ -75,8 +88,12 @@ func (s *RestoreService) RestoreBackupWithAuth(
 		return err
 	}
 
-	if backup.Database.UserID != user.ID {
-		return errors.New("user does not have access to this backup")
+	if err := s.databaseService.CheckAccess(
+		user,
+		backup.Database,
+		workspaces.WorkspaceRoleOperator,
+	); err != nil {
+		return err
 	}
 
 	backupDatabase, err := s.databaseService.GetDatabase(user, backup.DatabaseID)
@@ -84,6 +101,56 @@ func (s *RestoreService) RestoreBackupWithAuth(
 		return err
 	}
 
+	// passwords are not sent to clients, so restore into the backed up
+	// server comes without password and the stored one is used
+	fillRestoreTargetPassword(backupDatabase, &requestDTO)
+
+	if backup.Type == backups_config.BackupTypePhysical {
+		if err := s.validatePhysicalRestore(backup, requestDTO); err != nil {
+			return err
+		}
+
+		go func() {
+			if err := s.RestoreBackup(backup, requestDTO); err != nil {
+				s.logger.Error("Failed to restore backup", "error", err)
+			}
+		}()
+
+		return nil
+	}
+
+	if backupDatabase.Type == databases.DatabaseTypeMysql {
+		if err := s.validateMysqlRestore(backupDatabase, requestDTO); err != nil {
+			return err
+		}
+
+		go func() {
+			if err := s.RestoreBackup(backup, requestDTO); err != nil {
+				s.logger.Error("Failed to restore backup", "error", err)
+			}
+		}()
+
+		return nil
+	}
+
+	if backupDatabase.Type == databases.DatabaseTypeMongodb {
+		if err := s.validateMongodbRestore(requestDTO); err != nil {
+			return err
+		}
+
+		go func() {
+			if err := s.RestoreBackup(backup, requestDTO); err != nil {
+				s.logger.Error("Failed to restore backup", "error", err)
+			}
+		}()
+
+		return nil
+	}
+
+	if requestDTO.PostgresqlDatabase == nil {
+		return errors.New("postgresql database is required")
+	}
+
 	fmt.Printf(
 		"restore from %s to %s\n",
 		backupDatabase.Postgresql.Version,
@@ -99,6 +166,16 @@ func (s *RestoreService) RestoreBackupWithAuth(
 			`For example, you can restore PG 15 backup to PG 15, 16 or higher. But cannot restore to 14 and lower`)
 	}
 
+	if backup.IsCluster() {
+		if err := s.validateClusterRestore(backup, requestDTO); err != nil {
+			return err
+		}
+	}
+
+	if err := validateRestoreObjects(requestDTO.RestoreObjects); err != nil {
+		return err
+	}
+
 	go func() {
 		if err := s.RestoreBackup(backup, requestDTO); err != nil {
 			s.logger.Error("Failed to restore backup", "error", err)
@@ -111,17 +188,47 @@ func (s *RestoreService) RestoreBackupWithAuth(
 func (s *RestoreService) RestoreBackup(
 	backup *backups.Backup,
 	requestDTO RestoreBackupRequest,
-) error {
+) (err error) {
+	ctx, span := tracing.StartSpan(
+		context.Background(),
+		"restore.run",
+		attribute.String("backup.id", backup.ID.String()),
+		attribute.String("backup.type", string(backup.Type)),
+		attribute.String("database.type", string(backup.Database.Type)),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
 	if backup.Status != backups.BackupStatusCompleted {
 		return errors.New("backup is not completed")
 	}
 
-	if backup.Database.Type == databases.DatabaseTypePostgres {
+	if backup.Database.Type == databases.DatabaseTypePostgres &&
+		backup.Type != backups_config.BackupTypePhysical {
 		if requestDTO.PostgresqlDatabase == nil {
 			return errors.New("postgresql database is required")
 		}
 	}
 
+	if backup.Database.Type == databases.DatabaseTypeMysql && requestDTO.MysqlDatabase == nil {
+		return errors.New("mysql database is required")
+	}
+
+	if backup.Database.Type == databases.DatabaseTypeMongodb {
+		if requestDTO.MongodbDatabase == nil {
+			return errors.New("mongodb database is required")
+		}
+
+		// namespaces of the backed up database are mapped into the target one
+		if backup.Database.Mongodb == nil {
+			backupDatabase, err := s.databaseService.GetDatabaseByID(backup.DatabaseID)
+			if err != nil {
+				return err
+			}
+
+			backup.Database = backupDatabase
+		}
+	}
+
 	restore := models.Restore{
 		ID:     uuid.New(),
 		Status: enums.RestoreStatusInProgress,
@@ -135,6 +242,22 @@ func (s *RestoreService) RestoreBackup(
 		FailMessage: nil,
 	}
 
+	if backup.Type == backups_config.BackupTypePhysical {
+		dataDirectory := filepath.Join(config.GetEnv().RecoveriesFolder, restore.ID.String())
+
+		restore.TargetTime = requestDTO.TargetTime
+		restore.TargetLsn = requestDTO.TargetLsn
+		restore.DataDirectory = &dataDirectory
+	}
+
+	if backup.IsCluster() {
+		restore.IsRestoreGlobals = requestDTO.IsRestoreGlobals
+		restore.ClusterDatabases = requestDTO.ClusterDatabases
+	} else if backup.Type != backups_config.BackupTypePhysical &&
+		backup.Database.Type == databases.DatabaseTypePostgres {
+		restore.RestoreObjects = requestDTO.RestoreObjects
+	}
+
 	// Save the restore first
 	if err := s.restoreRepository.Save(&restore); err != nil {
 		return err
@@ -151,9 +274,22 @@ func (s *RestoreService) RestoreBackup(
 		}
 	}
 
-	storage, err := s.storageService.GetStorageByID(backup.StorageID)
-	if err != nil {
-		return err
+	if requestDTO.MysqlDatabase != nil {
+		requestDTO.MysqlDatabase.RestoreID = &restore.ID
+		restore.Mysql = requestDTO.MysqlDatabase
+
+		if err := s.restoreRepository.Save(&restore); err != nil {
+			return err
+		}
+	}
+
+	if requestDTO.MongodbDatabase != nil {
+		requestDTO.MongodbDatabase.RestoreID = &restore.ID
+		restore.Mongodb = requestDTO.MongodbDatabase
+
+		if err := s.restoreRepository.Save(&restore); err != nil {
+			return err
+		}
 	}
 
 	backupConfig, err := s.backupConfigService.GetBackupConfigByDbId(
@@ -163,13 +299,18 @@ func (s *RestoreService) RestoreBackup(
 		return err
 	}
 
+	span.SetAttributes(attribute.String("restore.id", restore.ID.String()))
+
 	start := time.Now().UTC()
 
 	err = s.restoreBackupUsecase.Execute(
+		ctx,
 		backupConfig,
 		restore,
 		backup,
-		storage,
+		// each file falls back to the next healthy copy if
+		// the primary storage is unavailable
+		s.backupService.GetBackupFiles(backup),
 	)
 	if err != nil {
 		errMsg := err.Error()
@@ -193,3 +334,147 @@ func (s *RestoreService) RestoreBackup(
 
 	return nil
 }
+
+func (s *RestoreService) validatePhysicalRestore(
+	backup *backups.Backup,
+	requestDTO RestoreBackupRequest,
+) error {
+	if len(requestDTO.RestoreObjects) > 0 {
+		return errors.New("restore of separate objects is not supported for physical backups")
+	}
+
+	if requestDTO.TargetTime != nil && requestDTO.TargetLsn != nil {
+		return errors.New("only one of target time and target LSN can be specified")
+	}
+
+	if requestDTO.TargetTime != nil && requestDTO.TargetTime.Before(backup.CreatedAt) {
+		return errors.New("target time cannot be earlier than the backup time")
+	}
+
+	if requestDTO.TargetLsn != nil {
+		targetLsn, err := backups_wal.ParseLsn(*requestDTO.TargetLsn)
+		if err != nil {
+			return err
+		}
+
+		if backup.WalStopLsn != nil {
+			stopLsn, err := backups_wal.ParseLsn(*backup.WalStopLsn)
+			if err != nil {
+				return err
+			}
+
+			if targetLsn < stopLsn {
+				return errors.New("target LSN cannot be earlier than the end of the backup")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *RestoreService) validateClusterRestore(
+	backup *backups.Backup,
+	requestDTO RestoreBackupRequest,
+) error {
+	if len(requestDTO.RestoreObjects) > 0 {
+		return errors.New("restore of separate objects is not supported for cluster backups")
+	}
+
+	if !requestDTO.IsRestoreGlobals && len(requestDTO.ClusterDatabases) == 0 {
+		return errors.New("globals or at least one database should be selected for restore")
+	}
+
+	selectedDatabases := make(map[string]bool)
+
+	for _, databaseName := range requestDTO.ClusterDatabases {
+		if selectedDatabases[databaseName] {
+			return errors.New("databases to restore must be unique")
+		}
+
+		selectedDatabases[databaseName] = true
+
+		isMember := slices.ContainsFunc(backup.Members, func(member *backups.BackupMember) bool {
+			return member.DatabaseName == databaseName
+		})
+		if !isMember {
+			return fmt.Errorf("database \"%s\" is not in the backup", databaseName)
+		}
+	}
+
+	return nil
+}
+
+// validateMysqlRestore requires target of the same flavor: dumps of MySQL
+// and MariaDB are not fully compatible with each other
+func (s *RestoreService) validateMysqlRestore(
+	backupDatabase *databases.Database,
+	requestDTO RestoreBackupRequest,
+) error {
+	if requestDTO.MysqlDatabase == nil {
+		return errors.New("mysql database is required")
+	}
+
+	if len(requestDTO.RestoreObjects) > 0 {
+		return errors.New("restore of separate objects is supported only for PostgreSQL")
+	}
+
+	if requestDTO.MysqlDatabase.Flavor != backupDatabase.Mysql.Flavor {
+		return fmt.Errorf(
+			"backup of %s cannot be restored to %s",
+			backupDatabase.Mysql.Flavor,
+			requestDTO.MysqlDatabase.Flavor,
+		)
+	}
+
+	return requestDTO.MysqlDatabase.Validate()
+}
+
+func (s *RestoreService) validateMongodbRestore(requestDTO RestoreBackupRequest) error {
+	if requestDTO.MongodbDatabase == nil {
+		return errors.New("mongodb database is required")
+	}
+
+	if len(requestDTO.RestoreObjects) > 0 {
+		return errors.New("restore of separate objects is supported only for PostgreSQL")
+	}
+
+	return requestDTO.MongodbDatabase.Validate()
+}
+
+// validateRestoreObjects requires objects in schema.name form, so
+// they can be matched against the table of contents of the backup
+func validateRestoreObjects(restoreObjects []string) error {
+	selectedObjects := make(map[string]bool)
+
+	for _, restoreObject := range restoreObjects {
+		schemaName, objectName, isQualified := strings.Cut(restoreObject, ".")
+		if !isQualified || schemaName == "" || objectName == "" {
+			return fmt.Errorf("object to restore \"%s\" should be in schema.name form", restoreObject)
+		}
+
+		if selectedObjects[restoreObject] {
+			return errors.New("objects to restore must be unique")
+		}
+
+		selectedObjects[restoreObject] = true
+	}
+
+	return nil
+}
+
+func fillRestoreTargetPassword(
+	backupDatabase *databases.Database,
+	requestDTO *RestoreBackupRequest,
+) {
+	if requestDTO.PostgresqlDatabase != nil {
+		requestDTO.PostgresqlDatabase.FillSensitiveData(backupDatabase.Postgresql)
+	}
+
+	if requestDTO.MysqlDatabase != nil {
+		requestDTO.MysqlDatabase.FillSensitiveData(backupDatabase.Mysql)
+	}
+
+	if requestDTO.MongodbDatabase != nil {
+		requestDTO.MongodbDatabase.FillSensitiveData(backupDatabase.Mongodb)
+	}
+}
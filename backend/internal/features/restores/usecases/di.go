@@ -1,11 +1,16 @@
 package usecases
 
 import (
+	usecases_mongodb "postgresus-backend/internal/features/restores/usecases/mongodb"
+	usecases_mysql "postgresus-backend/internal/features/restores/usecases/mysql"
 	usecases_postgresql "postgresus-backend/internal/features/restores/usecases/postgresql"
 )
 
 var restoreBackupUsecase = &RestoreBackupUsecase{
 	usecases_postgresql.GetRestorePostgresqlBackupUsecase(),
+	usecases_postgresql.GetRestorePostgresqlPhysicalBackupUsecase(),
+	usecases_mysql.GetRestoreMysqlBackupUsecase(),
+	usecases_mongodb.GetRestoreMongodbBackupUsecase(),
 }
 
 func GetRestoreBackupUsecase() *RestoreBackupUsecase {
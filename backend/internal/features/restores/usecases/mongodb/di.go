@@ -0,0 +1,15 @@
+package usecases_mongodb
+
+import (
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	"postgresus-backend/internal/util/logger"
+)
+
+var restoreMongodbBackupUsecase = &RestoreMongodbBackupUsecase{
+	logger.GetLogger(),
+	backups_encryption.GetBackupEncryptionKeyService(),
+}
+
+func GetRestoreMongodbBackupUsecase() *RestoreMongodbBackupUsecase {
+	return restoreMongodbBackupUsecase
+}
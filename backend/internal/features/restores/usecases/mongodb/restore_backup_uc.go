@@ -0,0 +1,192 @@
+package usecases_mongodb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/backups/backups"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	"postgresus-backend/internal/features/databases"
+	mongotypes "postgresus-backend/internal/features/databases/databases/mongodb"
+	"postgresus-backend/internal/features/restores/models"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/tools"
+	"postgresus-backend/internal/util/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type RestoreMongodbBackupUsecase struct {
+	logger                     *slog.Logger
+	backupEncryptionKeyService *backups_encryption.BackupEncryptionKeyService
+}
+
+// Execute restores the archive via mongorestore. The archive is streamed
+// from storage into mongorestore, so no temporary file is needed.
+// Collections of the archive are dropped before restore
+func (uc *RestoreMongodbBackupUsecase) Execute(
+	ctx context.Context,
+	backupConfig *backups_config.BackupConfig,
+	restore models.Restore,
+	backup *backups.Backup,
+	backupFiles *backups.BackupFiles,
+) error {
+	if backup.Database.Type != databases.DatabaseTypeMongodb {
+		return errors.New("database type not supported")
+	}
+
+	uc.logger.Info(
+		"Restoring MongoDB backup via mongorestore",
+		"restoreId",
+		restore.ID,
+		"backupId",
+		backup.ID,
+	)
+
+	mongo := restore.Mongodb
+	if mongo == nil {
+		return errors.New("mongodb configuration is required for restore")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Minute)
+	defer cancel()
+
+	// Monitor for shutdown and cancel context if needed
+	go func() {
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if config.IsShouldShutdown() {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	configFile, cleanupFunc, err := mongo.CreateTempConfigFile()
+	if err != nil {
+		return err
+	}
+	defer cleanupFunc()
+
+	backupReader, err := backupFiles.GetFile(ctx, backup.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get backup file from storage: %w", err)
+	}
+	defer func() {
+		if err := backupReader.Close(); err != nil {
+			uc.logger.Error("Failed to close backup reader", "error", err)
+		}
+	}()
+
+	var backupDataReader io.Reader = backupReader
+	if backup.Encryption == backups_config.BackupEncryptionAES256GCM &&
+		backup.EncryptionKeyID != nil {
+		encryptionKey, err := uc.backupEncryptionKeyService.GetKeyByID(*backup.EncryptionKeyID)
+		if err != nil {
+			return fmt.Errorf("failed to get backup encryption key: %w", err)
+		}
+
+		backupDataReader, err = encryption.NewDecryptingReader(backupReader, encryptionKey.Key)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt backup: %w", err)
+		}
+
+		uc.logger.Info("Decrypting backup", "encryptionKeyId", encryptionKey.ID)
+	}
+
+	restoreBin := tools.GetMongodbExecutable(
+		tools.MongodbExecutableRestore,
+		config.GetEnv().EnvMode,
+		config.GetEnv().MongodbInstallDir,
+	)
+
+	if _, err := exec.LookPath(restoreBin); err != nil {
+		return fmt.Errorf("MongoDB executable not found or not accessible: %s - %w", restoreBin, err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(
+		ctx,
+		restoreBin,
+		getRestoreArgs(configFile, backup.Database.Mongodb, mongo)...,
+	)
+	cmd.Stdin = backupDataReader
+	cmd.Stderr = &stderr
+
+	uc.logger.Info("Executing MongoDB restore command", "command", cmd.String())
+
+	_, applySpan := tracing.StartSpan(
+		ctx,
+		"restore.apply",
+		attribute.String("tool", filepath.Base(restoreBin)),
+	)
+	err = cmd.Run()
+	tracing.EndSpan(applySpan, err)
+
+	if err != nil {
+		if config.IsShouldShutdown() {
+			return errors.New("restore cancelled due to shutdown")
+		}
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return errors.New("restore timed out after 60 minutes")
+		}
+
+		return fmt.Errorf(
+			"%s failed: %v – stderr: %s",
+			filepath.Base(restoreBin),
+			err,
+			strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	return nil
+}
+
+// getRestoreArgs maps collections of the backed up database into the
+// target database, so a database can be restored under other name.
+// Backups of whole deployment are restored into the same databases
+func getRestoreArgs(
+	configFile string,
+	source *mongotypes.MongodbDatabase,
+	target *mongotypes.MongodbDatabase,
+) []string {
+	args := []string{
+		"--config=" + configFile,
+		"--archive", // read single archive from stdin
+		"--gzip",
+		"--drop", // drop collections before restoring them
+	}
+
+	if source == nil || source.Database == nil || *source.Database == "" {
+		return args
+	}
+
+	args = append(args, "--nsInclude="+*source.Database+".*")
+
+	if target.Database != nil && *target.Database != "" && *target.Database != *source.Database {
+		args = append(args,
+			"--nsFrom="+*source.Database+".*",
+			"--nsTo="+*target.Database+".*",
+		)
+	}
+
+	return args
+}
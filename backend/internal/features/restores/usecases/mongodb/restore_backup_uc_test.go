@@ -0,0 +1,52 @@
+package usecases_mongodb
+
+import (
+	"testing"
+
+	mongotypes "postgresus-backend/internal/features/databases/databases/mongodb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetRestoreArgs_WithDeploymentBackup_RestoresAllDatabases(t *testing.T) {
+	args := getRestoreArgs(
+		"/tmp/config.yaml",
+		&mongotypes.MongodbDatabase{},
+		&mongotypes.MongodbDatabase{},
+	)
+
+	assert.Equal(t, []string{
+		"--config=/tmp/config.yaml",
+		"--archive",
+		"--gzip",
+		"--drop",
+	}, args)
+}
+
+func Test_GetRestoreArgs_WithSameDatabaseName_RestoresOnlyDatabase(t *testing.T) {
+	databaseName := "shop"
+
+	args := getRestoreArgs(
+		"/tmp/config.yaml",
+		&mongotypes.MongodbDatabase{Database: &databaseName},
+		&mongotypes.MongodbDatabase{},
+	)
+
+	assert.Contains(t, args, "--nsInclude=shop.*")
+	assert.NotContains(t, args, "--nsFrom=shop.*")
+}
+
+func Test_GetRestoreArgs_WithOtherDatabaseName_MapsNamespaces(t *testing.T) {
+	sourceDatabaseName := "shop"
+	targetDatabaseName := "shop_restored"
+
+	args := getRestoreArgs(
+		"/tmp/config.yaml",
+		&mongotypes.MongodbDatabase{Database: &sourceDatabaseName},
+		&mongotypes.MongodbDatabase{Database: &targetDatabaseName},
+	)
+
+	assert.Contains(t, args, "--nsInclude=shop.*")
+	assert.Contains(t, args, "--nsFrom=shop.*")
+	assert.Contains(t, args, "--nsTo=shop_restored.*")
+}
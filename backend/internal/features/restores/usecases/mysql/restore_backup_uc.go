@@ -0,0 +1,210 @@
+package usecases_mysql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/backups/backups"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	"postgresus-backend/internal/features/databases"
+	mysqltypes "postgresus-backend/internal/features/databases/databases/mysql"
+	"postgresus-backend/internal/features/restores/models"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/tools"
+	"postgresus-backend/internal/util/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type RestoreMysqlBackupUsecase struct {
+	logger                     *slog.Logger
+	backupEncryptionKeyService *backups_encryption.BackupEncryptionKeyService
+}
+
+// Execute restores the backup via mysql (or mariadb for MariaDB) client.
+// The dump is streamed from storage into the client, so no temporary
+// file is needed. Missing target database is created
+func (uc *RestoreMysqlBackupUsecase) Execute(
+	ctx context.Context,
+	backupConfig *backups_config.BackupConfig,
+	restore models.Restore,
+	backup *backups.Backup,
+	backupFiles *backups.BackupFiles,
+) error {
+	if backup.Database.Type != databases.DatabaseTypeMysql {
+		return errors.New("database type not supported")
+	}
+
+	uc.logger.Info(
+		"Restoring MySQL backup via client",
+		"restoreId",
+		restore.ID,
+		"backupId",
+		backup.ID,
+	)
+
+	my := restore.Mysql
+	if my == nil {
+		return errors.New("mysql configuration is required for restore")
+	}
+
+	if my.Database == nil || *my.Database == "" {
+		return errors.New("target database name is required for restore")
+	}
+
+	if err := uc.ensureDatabaseExists(my); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Minute)
+	defer cancel()
+
+	// Monitor for shutdown and cancel context if needed
+	go func() {
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if config.IsShouldShutdown() {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	defaultsFile, cleanupFunc, err := my.CreateTempDefaultsFile()
+	if err != nil {
+		return err
+	}
+	defer cleanupFunc()
+
+	backupReader, err := backupFiles.GetFile(ctx, backup.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get backup file from storage: %w", err)
+	}
+	defer func() {
+		if err := backupReader.Close(); err != nil {
+			uc.logger.Error("Failed to close backup reader", "error", err)
+		}
+	}()
+
+	var backupDataReader io.Reader = backupReader
+	if backup.Encryption == backups_config.BackupEncryptionAES256GCM &&
+		backup.EncryptionKeyID != nil {
+		encryptionKey, err := uc.backupEncryptionKeyService.GetKeyByID(*backup.EncryptionKeyID)
+		if err != nil {
+			return fmt.Errorf("failed to get backup encryption key: %w", err)
+		}
+
+		backupDataReader, err = encryption.NewDecryptingReader(backupReader, encryptionKey.Key)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt backup: %w", err)
+		}
+
+		uc.logger.Info("Decrypting backup", "encryptionKeyId", encryptionKey.ID)
+	}
+
+	gzipReader, err := gzip.NewReader(backupDataReader)
+	if err != nil {
+		return fmt.Errorf("failed to decompress backup: %w", err)
+	}
+	defer func() {
+		_ = gzipReader.Close()
+	}()
+
+	// --defaults-extra-file must be the first option
+	args := []string{"--defaults-extra-file=" + defaultsFile}
+	args = append(args, my.GetClientSslArgs()...)
+	args = append(args, "--default-character-set=utf8mb4", *my.Database)
+
+	clientBin := tools.GetMysqlExecutable(
+		my.Flavor,
+		tools.MysqlExecutableClient,
+		config.GetEnv().EnvMode,
+		config.GetEnv().MysqlInstallDir,
+	)
+
+	if _, err := exec.LookPath(clientBin); err != nil {
+		return fmt.Errorf("MySQL client not found or not accessible: %s - %w", clientBin, err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, clientBin, args...)
+	cmd.Stdin = gzipReader
+	cmd.Stderr = &stderr
+
+	uc.logger.Info("Executing MySQL restore command", "command", cmd.String())
+
+	_, applySpan := tracing.StartSpan(
+		ctx,
+		"restore.apply",
+		attribute.String("tool", filepath.Base(clientBin)),
+	)
+	err = cmd.Run()
+	tracing.EndSpan(applySpan, err)
+
+	if err != nil {
+		if config.IsShouldShutdown() {
+			return errors.New("restore cancelled due to shutdown")
+		}
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return errors.New("restore timed out after 60 minutes")
+		}
+
+		return fmt.Errorf(
+			"%s failed: %v – stderr: %s",
+			filepath.Base(clientBin),
+			err,
+			strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	return nil
+}
+
+func (uc *RestoreMysqlBackupUsecase) ensureDatabaseExists(my *mysqltypes.MysqlDatabase) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// connect without database, as it may not exist yet
+	server := *my
+	server.Database = nil
+
+	db, err := sql.Open("mysql", server.GetDsn())
+	if err != nil {
+		return fmt.Errorf("failed to connect to target server: %w", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			uc.logger.Error("Failed to close connection", "error", err)
+		}
+	}()
+
+	quotedName := "`" + strings.ReplaceAll(*my.Database, "`", "``") + "`"
+	if _, err := db.ExecContext(
+		ctx,
+		"CREATE DATABASE IF NOT EXISTS "+quotedName+" CHARACTER SET utf8mb4",
+	); err != nil {
+		return fmt.Errorf("failed to create database \"%s\": %w", *my.Database, err)
+	}
+
+	return nil
+}
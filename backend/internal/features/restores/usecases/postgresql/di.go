@@ -1,13 +1,26 @@
 package usecases_postgresql
 
 import (
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	backups_wal "postgresus-backend/internal/features/backups/wal"
 	"postgresus-backend/internal/util/logger"
 )
 
 var restorePostgresqlBackupUsecase = &RestorePostgresqlBackupUsecase{
 	logger.GetLogger(),
+	backups_encryption.GetBackupEncryptionKeyService(),
+}
+
+var restorePostgresqlPhysicalBackupUsecase = &RestorePostgresqlPhysicalBackupUsecase{
+	logger.GetLogger(),
+	backups_encryption.GetBackupEncryptionKeyService(),
+	backups_wal.GetWalSegmentService(),
 }
 
 func GetRestorePostgresqlBackupUsecase() *RestorePostgresqlBackupUsecase {
 	return restorePostgresqlBackupUsecase
 }
+
+func GetRestorePostgresqlPhysicalBackupUsecase() *RestorePostgresqlPhysicalBackupUsecase {
+	return restorePostgresqlPhysicalBackupUsecase
+}
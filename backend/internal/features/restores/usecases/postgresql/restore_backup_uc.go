@@ -1,6 +1,7 @@
 package usecases_postgresql
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -16,24 +17,31 @@ import (
 	"postgresus-backend/internal/config"
 	"postgresus-backend/internal/features/backups/backups"
 	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
 	"postgresus-backend/internal/features/databases"
 	pgtypes "postgresus-backend/internal/features/databases/databases/postgresql"
 	"postgresus-backend/internal/features/restores/models"
 	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/util/encryption"
 	"postgresus-backend/internal/util/tools"
+	"postgresus-backend/internal/util/tracing"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type RestorePostgresqlBackupUsecase struct {
-	logger *slog.Logger
+	logger                     *slog.Logger
+	backupEncryptionKeyService *backups_encryption.BackupEncryptionKeyService
 }
 
 func (uc *RestorePostgresqlBackupUsecase) Execute(
+	ctx context.Context,
 	backupConfig *backups_config.BackupConfig,
 	restore models.Restore,
 	backup *backups.Backup,
-	storage *storages.Storage,
+	backupFiles *backups.BackupFiles,
 ) error {
 	if backup.Database.Type != databases.DatabaseTypePostgres {
 		return errors.New("database type not supported")
@@ -52,6 +60,10 @@ func (uc *RestorePostgresqlBackupUsecase) Execute(
 		return fmt.Errorf("postgresql configuration is required for restore")
 	}
 
+	if backup.IsCluster() {
+		return uc.restoreClusterBackup(ctx, backupConfig, restore, backup, backupFiles)
+	}
+
 	if pg.Database == nil || *pg.Database == "" {
 		return fmt.Errorf("target database name is required for pg_restore")
 	}
@@ -75,6 +87,7 @@ func (uc *RestorePostgresqlBackupUsecase) Execute(
 	}
 
 	return uc.restoreFromStorage(
+		ctx,
 		tools.GetPostgresqlExecutable(
 			pg.Version,
 			"pg_restore",
@@ -82,20 +95,176 @@ func (uc *RestorePostgresqlBackupUsecase) Execute(
 			config.GetEnv().PostgresesInstallDir,
 		),
 		args,
+		restore.RestoreObjects,
 		pg.Password,
 		backup,
-		storage,
+		backup.ID,
+		backupFiles,
 		pg,
 	)
 }
 
-// restoreFromStorage restores backup data from storage using pg_restore
+// restoreClusterBackup restores globals via psql and each selected database
+// via pg_restore into the database of the same name on the target server.
+// Missing databases are created, existing ones are cleaned before restore
+func (uc *RestorePostgresqlBackupUsecase) restoreClusterBackup(
+	ctx context.Context,
+	backupConfig *backups_config.BackupConfig,
+	restore models.Restore,
+	backup *backups.Backup,
+	backupFiles *backups.BackupFiles,
+) error {
+	pg := restore.Postgresql
+
+	if restore.IsRestoreGlobals {
+		uc.logger.Info("Restoring globals of cluster backup via psql", "restoreId", restore.ID)
+
+		// psql does not stop on errors like existing roles,
+		// so globals can be restored into non empty cluster
+		args := []string{
+			"--no-password",
+			"-h", pg.Host,
+			"-p", strconv.Itoa(pg.Port),
+			"-U", pg.Username,
+			"-d", getMaintenanceDatabaseName(pg),
+			"-f",
+		}
+
+		if err := uc.restoreFromStorage(
+			ctx,
+			tools.GetPostgresqlExecutable(
+				pg.Version,
+				tools.PostgresqlExecutablePsql,
+				config.GetEnv().EnvMode,
+				config.GetEnv().PostgresesInstallDir,
+			),
+			args,
+			nil,
+			pg.Password,
+			backup,
+			backup.ID,
+			backupFiles,
+			pg,
+		); err != nil {
+			return fmt.Errorf("failed to restore globals: %w", err)
+		}
+	}
+
+	parallelJobs := max(1, min(backupConfig.CpuCount, 8))
+
+	for _, databaseName := range restore.ClusterDatabases {
+		var member *backups.BackupMember
+		for _, backupMember := range backup.Members {
+			if backupMember.DatabaseName == databaseName {
+				member = backupMember
+				break
+			}
+		}
+
+		if member == nil {
+			return fmt.Errorf("database \"%s\" is not in the backup", databaseName)
+		}
+
+		uc.logger.Info(
+			"Restoring database of cluster backup via pg_restore",
+			"restoreId",
+			restore.ID,
+			"database",
+			databaseName,
+		)
+
+		if err := uc.ensureDatabaseExists(pg, databaseName); err != nil {
+			return err
+		}
+
+		args := []string{
+			"-Fc",
+			"-j", strconv.Itoa(parallelJobs),
+			"--no-password",
+			"-h", pg.Host,
+			"-p", strconv.Itoa(pg.Port),
+			"-U", pg.Username,
+			"-d", databaseName,
+			"--verbose",
+			"--clean",
+			"--if-exists",
+			"--no-owner",
+		}
+
+		if err := uc.restoreFromStorage(
+			ctx,
+			tools.GetPostgresqlExecutable(
+				pg.Version,
+				"pg_restore",
+				config.GetEnv().EnvMode,
+				config.GetEnv().PostgresesInstallDir,
+			),
+			args,
+			nil,
+			pg.Password,
+			backup,
+			member.ID,
+			backupFiles,
+			pg,
+		); err != nil {
+			return fmt.Errorf("failed to restore database \"%s\": %w", databaseName, err)
+		}
+	}
+
+	return nil
+}
+
+func (uc *RestorePostgresqlBackupUsecase) ensureDatabaseExists(
+	pg *pgtypes.PostgresqlDatabase,
+	databaseName string,
+) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, pg.GetConnectionString())
+	if err != nil {
+		return fmt.Errorf("failed to connect to target server: %w", err)
+	}
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			uc.logger.Error("Failed to close connection", "error", err)
+		}
+	}()
+
+	var isExists bool
+	if err := conn.QueryRow(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)",
+		databaseName,
+	).Scan(&isExists); err != nil {
+		return fmt.Errorf("failed to check database \"%s\": %w", databaseName, err)
+	}
+
+	if isExists {
+		return nil
+	}
+
+	if _, err := conn.Exec(
+		ctx,
+		"CREATE DATABASE "+pgx.Identifier{databaseName}.Sanitize(),
+	); err != nil {
+		return fmt.Errorf("failed to create database \"%s\": %w", databaseName, err)
+	}
+
+	return nil
+}
+
+// restoreFromStorage restores backup data from storage using pg_restore.
+// If restore objects are given, only they are restored via pg_restore -L
 func (uc *RestorePostgresqlBackupUsecase) restoreFromStorage(
+	ctx context.Context,
 	pgBin string,
 	args []string,
+	restoreObjects []string,
 	password string,
 	backup *backups.Backup,
-	storage *storages.Storage,
+	fileID uuid.UUID,
+	backupFiles *backups.BackupFiles,
 	pgConfig *pgtypes.PostgresqlDatabase,
 ) error {
 	uc.logger.Info(
@@ -106,7 +275,7 @@ func (uc *RestorePostgresqlBackupUsecase) restoreFromStorage(
 		args,
 	)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Minute)
 	defer cancel()
 
 	// Monitor for shutdown and cancel context if needed
@@ -154,23 +323,69 @@ func (uc *RestorePostgresqlBackupUsecase) restoreFromStorage(
 	}
 
 	// Download backup to temporary file
-	tempBackupFile, cleanupFunc, err := uc.downloadBackupToTempFile(ctx, backup, storage)
+	tempBackupFile, cleanupFunc, err := uc.downloadBackupToTempFile(ctx, backup, fileID, backupFiles)
 	if err != nil {
 		return fmt.Errorf("failed to download backup to temporary file: %w", err)
 	}
 	defer cleanupFunc()
 
+	if len(restoreObjects) > 0 {
+		listFile, err := uc.createRestoreListFile(ctx, pgBin, tempBackupFile, restoreObjects)
+		if err != nil {
+			return err
+		}
+
+		args = append(args, "-L", listFile)
+	}
+
 	// Add the temporary backup file as the last argument to pg_restore
 	args = append(args, tempBackupFile)
 
 	return uc.executePgRestore(ctx, pgBin, args, pgpassFile, pgConfig)
 }
 
+// createRestoreListFile writes the table of contents entries of restore
+// objects next to the downloaded backup file for pg_restore -L
+func (uc *RestorePostgresqlBackupUsecase) createRestoreListFile(
+	ctx context.Context,
+	pgBin string,
+	tempBackupFile string,
+	restoreObjects []string,
+) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, pgBin, "--list", tempBackupFile)
+	cmd.Stderr = &stderr
+
+	toc, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf(
+			"failed to list backup contents: %v – stderr: %s",
+			err,
+			stderr.String(),
+		)
+	}
+
+	selectedEntries, err := selectTocEntries(string(toc), restoreObjects)
+	if err != nil {
+		return "", err
+	}
+
+	listFile := filepath.Join(filepath.Dir(tempBackupFile), "restore.list")
+	if err := os.WriteFile(listFile, []byte(selectedEntries), 0600); err != nil {
+		return "", fmt.Errorf("failed to write restore list file: %w", err)
+	}
+
+	uc.logger.Info("Restoring selected objects only", "restoreObjects", restoreObjects)
+
+	return listFile, nil
+}
+
 // downloadBackupToTempFile downloads backup data from storage to a temporary file
 func (uc *RestorePostgresqlBackupUsecase) downloadBackupToTempFile(
 	ctx context.Context,
 	backup *backups.Backup,
-	storage *storages.Storage,
+	fileID uuid.UUID,
+	backupFiles *backups.BackupFiles,
 ) (string, func(), error) {
 	if err := storages.EnsureSystemDirectories(); err != nil {
 		return "", nil, fmt.Errorf("failed to ensure system directories: %w", err)
@@ -195,7 +410,7 @@ func (uc *RestorePostgresqlBackupUsecase) downloadBackupToTempFile(
 		"tempFile",
 		tempBackupFile,
 	)
-	backupReader, err := storage.GetFile(backup.ID)
+	backupReader, err := backupFiles.GetFile(ctx, fileID)
 	if err != nil {
 		cleanupFunc()
 		return "", nil, fmt.Errorf("failed to get backup file from storage: %w", err)
@@ -206,6 +421,24 @@ func (uc *RestorePostgresqlBackupUsecase) downloadBackupToTempFile(
 		}
 	}()
 
+	var backupDataReader io.Reader = backupReader
+	if backup.Encryption == backups_config.BackupEncryptionAES256GCM &&
+		backup.EncryptionKeyID != nil {
+		encryptionKey, err := uc.backupEncryptionKeyService.GetKeyByID(*backup.EncryptionKeyID)
+		if err != nil {
+			cleanupFunc()
+			return "", nil, fmt.Errorf("failed to get backup encryption key: %w", err)
+		}
+
+		backupDataReader, err = encryption.NewDecryptingReader(backupReader, encryptionKey.Key)
+		if err != nil {
+			cleanupFunc()
+			return "", nil, fmt.Errorf("failed to decrypt backup: %w", err)
+		}
+
+		uc.logger.Info("Decrypting backup", "encryptionKeyId", encryptionKey.ID)
+	}
+
 	// Create temporary backup file
 	tempFile, err := os.Create(tempBackupFile)
 	if err != nil {
@@ -219,7 +452,7 @@ func (uc *RestorePostgresqlBackupUsecase) downloadBackupToTempFile(
 	}()
 
 	// Copy backup data to temporary file with shutdown checks
-	_, err = uc.copyWithShutdownCheck(ctx, tempFile, backupReader)
+	_, err = uc.copyWithShutdownCheck(ctx, tempFile, backupDataReader)
 	if err != nil {
 		cleanupFunc()
 		return "", nil, fmt.Errorf("failed to write backup to temporary file: %w", err)
@@ -268,14 +501,22 @@ func (uc *RestorePostgresqlBackupUsecase) executePgRestore(
 		stderrCh <- stderrOutput
 	}()
 
+	_, applySpan := tracing.StartSpan(
+		ctx,
+		"restore.apply",
+		attribute.String("tool", filepath.Base(pgBin)),
+	)
+
 	// Start pg_restore
 	if err = cmd.Start(); err != nil {
+		tracing.EndSpan(applySpan, err)
 		return fmt.Errorf("start %s: %w", filepath.Base(pgBin), err)
 	}
 
 	// Wait for the restore to finish
 	waitErr := cmd.Wait()
 	stderrOutput := <-stderrCh
+	tracing.EndSpan(applySpan, waitErr)
 
 	// Check for shutdown before finalizing
 	if config.IsShouldShutdown() {
@@ -471,6 +712,14 @@ func (uc *RestorePostgresqlBackupUsecase) copyWithShutdownCheck(
 	return totalBytesWritten, nil
 }
 
+func getMaintenanceDatabaseName(pg *pgtypes.PostgresqlDatabase) string {
+	if pg.Database != nil && *pg.Database != "" {
+		return *pg.Database
+	}
+
+	return "postgres"
+}
+
 // containsIgnoreCase checks if a string contains a substring, ignoring case
 func containsIgnoreCase(str, substr string) bool {
 	return strings.Contains(strings.ToLower(str), strings.ToLower(substr))
@@ -0,0 +1,53 @@
+package usecases_postgresql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// selectTocEntries filters pg_restore --list output down to entries of the
+// given schema.name objects. Besides the object itself, entries tagged with
+// its name followed by the entry name are selected: table data, column
+// defaults, constraints, triggers and rules of the table. Each object must
+// be found in the backup
+func selectTocEntries(toc string, restoreObjects []string) (string, error) {
+	objectRegexps := make([]*regexp.Regexp, 0, len(restoreObjects))
+
+	for _, restoreObject := range restoreObjects {
+		schemaName, objectName, isQualified := strings.Cut(restoreObject, ".")
+		if !isQualified {
+			return "", fmt.Errorf("object to restore \"%s\" should be in schema.name form", restoreObject)
+		}
+
+		// entry line: "<dump id>; <table oid> <oid> <TYPE> <schema> <tag> <owner>"
+		objectRegexps = append(objectRegexps, regexp.MustCompile(
+			`^\d+; \d+ \d+ [A-Z ]+ `+
+				regexp.QuoteMeta(schemaName)+` `+
+				regexp.QuoteMeta(objectName)+`( |$)`,
+		))
+	}
+
+	isObjectFound := make([]bool, len(restoreObjects))
+	selectedEntries := make([]string, 0)
+
+	for _, line := range strings.Split(toc, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		for i, objectRegexp := range objectRegexps {
+			if objectRegexp.MatchString(line) {
+				isObjectFound[i] = true
+				selectedEntries = append(selectedEntries, line)
+				break
+			}
+		}
+	}
+
+	for i, restoreObject := range restoreObjects {
+		if !isObjectFound[i] {
+			return "", fmt.Errorf("object \"%s\" is not found in the backup", restoreObject)
+		}
+	}
+
+	return strings.Join(selectedEntries, "\n") + "\n", nil
+}
@@ -0,0 +1,46 @@
+package usecases_postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testToc = `;
+; Archive created at 2025-08-18 09:00:00 UTC
+;
+; Selected TOC Entries:
+;
+215; 1259 16385 TABLE public users postgres
+216; 1259 16390 SEQUENCE public users_id_seq postgres
+3355; 0 16385 TABLE DATA public users postgres
+3201; 2604 16391 DEFAULT public users id postgres
+3205; 2606 16395 CONSTRAINT public users users_pkey postgres
+217; 1259 16400 TABLE public users_archive postgres
+3356; 0 16400 TABLE DATA public users_archive postgres
+218; 1259 16410 TABLE audit users postgres
+`
+
+func Test_SelectTocEntries_WithTable_SelectsTableEntries(t *testing.T) {
+	entries, err := selectTocEntries(testToc, []string{"public.users"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "215; 1259 16385 TABLE public users postgres\n"+
+		"3355; 0 16385 TABLE DATA public users postgres\n"+
+		"3201; 2604 16391 DEFAULT public users id postgres\n"+
+		"3205; 2606 16395 CONSTRAINT public users users_pkey postgres\n", entries)
+}
+
+func Test_SelectTocEntries_WithSeveralObjects_SelectsAll(t *testing.T) {
+	entries, err := selectTocEntries(testToc, []string{"audit.users", "public.users_id_seq"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "216; 1259 16390 SEQUENCE public users_id_seq postgres\n"+
+		"218; 1259 16410 TABLE audit users postgres\n", entries)
+}
+
+func Test_SelectTocEntries_WithMissingObject_ReturnsError(t *testing.T) {
+	_, err := selectTocEntries(testToc, []string{"public.orders"})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,417 @@
+package usecases_postgresql
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/backups/backups"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
+	backups_wal "postgresus-backend/internal/features/backups/wal"
+	"postgresus-backend/internal/features/restores/models"
+	"postgresus-backend/internal/util/encryption"
+)
+
+// RestorePostgresqlPhysicalBackupUsecase produces recovered data directory
+// from physical base backup and archived WAL. The directory is configured
+// for point-in-time recovery, so PostgreSQL started on it replays WAL up to
+// the target and promotes
+type RestorePostgresqlPhysicalBackupUsecase struct {
+	logger                     *slog.Logger
+	backupEncryptionKeyService *backups_encryption.BackupEncryptionKeyService
+	walSegmentService          *backups_wal.WalSegmentService
+}
+
+func (uc *RestorePostgresqlPhysicalBackupUsecase) Execute(
+	ctx context.Context,
+	restore models.Restore,
+	backup *backups.Backup,
+	backupFiles *backups.BackupFiles,
+) error {
+	uc.logger.Info(
+		"Restoring PostgreSQL physical backup",
+		"restoreId",
+		restore.ID,
+		"backupId",
+		backup.ID,
+	)
+
+	if restore.DataDirectory == nil || *restore.DataDirectory == "" {
+		return errors.New("data directory is required for physical restore")
+	}
+
+	if backup.WalStartSegment == nil || backup.WalStopSegment == nil {
+		return errors.New("backup has no WAL positions, it cannot be restored")
+	}
+
+	segments, err := uc.getRequiredSegments(backup)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 23*time.Hour)
+	defer cancel()
+
+	// Monitor for shutdown and cancel context if needed
+	go func() {
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if config.IsShouldShutdown() {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	dataDir := *restore.DataDirectory
+	walArchiveDir := dataDir + "_wal"
+
+	if err := uc.restoreToDirectories(
+		ctx,
+		restore,
+		backup,
+		backupFiles,
+		segments,
+		dataDir,
+		walArchiveDir,
+	); err != nil {
+		_ = os.RemoveAll(dataDir)
+		_ = os.RemoveAll(walArchiveDir)
+
+		if config.IsShouldShutdown() {
+			return fmt.Errorf("restore cancelled due to shutdown")
+		}
+
+		return err
+	}
+
+	uc.logger.Info(
+		"Physical backup restored",
+		"restoreId",
+		restore.ID,
+		"dataDirectory",
+		dataDir,
+		"walSegments",
+		len(segments),
+	)
+
+	return nil
+}
+
+func (uc *RestorePostgresqlPhysicalBackupUsecase) restoreToDirectories(
+	ctx context.Context,
+	restore models.Restore,
+	backup *backups.Backup,
+	backupFiles *backups.BackupFiles,
+	segments []*backups_wal.WalSegment,
+	dataDir string,
+	walArchiveDir string,
+) error {
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	if err := os.MkdirAll(walArchiveDir, 0700); err != nil {
+		return fmt.Errorf("failed to create WAL archive directory: %w", err)
+	}
+
+	if err := uc.extractBaseBackup(ctx, backup, backupFiles, dataDir); err != nil {
+		return fmt.Errorf("failed to extract base backup: %w", err)
+	}
+
+	// WAL is not included into base backup (-X none), but PostgreSQL
+	// expects the directory to exist
+	if err := os.MkdirAll(filepath.Join(dataDir, "pg_wal", "archive_status"), 0700); err != nil {
+		return fmt.Errorf("failed to create pg_wal directory: %w", err)
+	}
+
+	for _, segment := range segments {
+		if err := uc.downloadSegment(ctx, segment, walArchiveDir); err != nil {
+			return fmt.Errorf("failed to download WAL segment %s: %w", segment.FileName, err)
+		}
+	}
+
+	return uc.writeRecoveryConfig(restore, dataDir, walArchiveDir)
+}
+
+// getRequiredSegments returns archived WAL starting from the base backup.
+// WAL between start and stop of the base backup must be complete,
+// otherwise the backup cannot become consistent
+func (uc *RestorePostgresqlPhysicalBackupUsecase) getRequiredSegments(
+	backup *backups.Backup,
+) ([]*backups_wal.WalSegment, error) {
+	allSegments, err := uc.walSegmentService.GetSegments(backup.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	startPosition := backups_wal.GetSegmentPosition(*backup.WalStartSegment)
+
+	requiredSegments := make([]*backups_wal.WalSegment, 0)
+	archivedFileNames := make(map[string]bool)
+	var segmentSize int64
+
+	for _, segment := range allSegments {
+		if segment.IsHistory {
+			requiredSegments = append(requiredSegments, segment)
+			continue
+		}
+
+		if backups_wal.GetSegmentPosition(segment.FileName) < startPosition {
+			continue
+		}
+
+		requiredSegments = append(requiredSegments, segment)
+		archivedFileNames[segment.FileName] = true
+		segmentSize = segment.SizeBytes
+	}
+
+	if segmentSize == 0 {
+		return nil, fmt.Errorf(
+			"WAL segment %s required by the backup is not archived",
+			*backup.WalStartSegment,
+		)
+	}
+
+	timeline, startNumber, err := backups_wal.ParseSegmentFileName(
+		*backup.WalStartSegment,
+		segmentSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, stopNumber, err := backups_wal.ParseSegmentFileName(*backup.WalStopSegment, segmentSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for segmentNumber := startNumber; segmentNumber <= stopNumber; segmentNumber++ {
+		fileName := backups_wal.GetSegmentFileName(
+			timeline,
+			segmentNumber*uint64(segmentSize),
+			segmentSize,
+		)
+
+		if !archivedFileNames[fileName] {
+			return nil, fmt.Errorf(
+				"WAL segment %s required by the backup is not archived",
+				fileName,
+			)
+		}
+	}
+
+	return requiredSegments, nil
+}
+
+func (uc *RestorePostgresqlPhysicalBackupUsecase) extractBaseBackup(
+	ctx context.Context,
+	backup *backups.Backup,
+	backupFiles *backups.BackupFiles,
+	dataDir string,
+) error {
+	backupReader, err := backupFiles.GetFile(ctx, backup.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get backup file from storage: %w", err)
+	}
+	defer func() {
+		if err := backupReader.Close(); err != nil {
+			uc.logger.Error("Failed to close backup reader", "error", err)
+		}
+	}()
+
+	var backupDataReader io.Reader = backupReader
+	if backup.Encryption == backups_config.BackupEncryptionAES256GCM &&
+		backup.EncryptionKeyID != nil {
+		encryptionKey, err := uc.backupEncryptionKeyService.GetKeyByID(*backup.EncryptionKeyID)
+		if err != nil {
+			return fmt.Errorf("failed to get backup encryption key: %w", err)
+		}
+
+		backupDataReader, err = encryption.NewDecryptingReader(backupReader, encryptionKey.Key)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt backup: %w", err)
+		}
+	}
+
+	gzipReader, err := gzip.NewReader(backupDataReader)
+	if err != nil {
+		return fmt.Errorf("failed to read compressed backup: %w", err)
+	}
+	defer func() {
+		_ = gzipReader.Close()
+	}()
+
+	tarReader := tar.NewReader(gzipReader)
+
+	for {
+		if ctx.Err() != nil {
+			return fmt.Errorf("extract cancelled: %w", ctx.Err())
+		}
+
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		targetPath := filepath.Join(dataDir, filepath.FromSlash(header.Name))
+		if targetPath != dataDir &&
+			!strings.HasPrefix(targetPath, dataDir+string(os.PathSeparator)) {
+			return fmt.Errorf("backup contains invalid path: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(targetPath, 0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := uc.extractFile(tarReader, targetPath, header); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(header.Linkname, targetPath); err != nil {
+				return err
+			}
+		default:
+			uc.logger.Warn("Skipping unsupported tar entry", "name", header.Name)
+		}
+	}
+}
+
+func (uc *RestorePostgresqlPhysicalBackupUsecase) extractFile(
+	reader io.Reader,
+	targetPath string,
+	header *tar.Header,
+) error {
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(
+		targetPath,
+		os.O_CREATE|os.O_WRONLY|os.O_TRUNC,
+		os.FileMode(header.Mode)&0700,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, reader); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func (uc *RestorePostgresqlPhysicalBackupUsecase) downloadSegment(
+	ctx context.Context,
+	segment *backups_wal.WalSegment,
+	walArchiveDir string,
+) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("download cancelled: %w", ctx.Err())
+	}
+
+	segmentReader, err := uc.walSegmentService.GetSegmentFile(ctx, segment)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := segmentReader.Close(); err != nil {
+			uc.logger.Error("Failed to close WAL segment reader", "error", err)
+		}
+	}()
+
+	file, err := os.OpenFile(
+		filepath.Join(walArchiveDir, segment.FileName),
+		os.O_CREATE|os.O_WRONLY|os.O_TRUNC,
+		0600,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, segmentReader); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// writeRecoveryConfig makes PostgreSQL start in targeted recovery mode
+// (PostgreSQL 12+ uses recovery.signal instead of recovery.conf)
+func (uc *RestorePostgresqlPhysicalBackupUsecase) writeRecoveryConfig(
+	restore models.Restore,
+	dataDir string,
+	walArchiveDir string,
+) error {
+	settings := []string{
+		"",
+		"# Added by Postgresus for point-in-time recovery",
+		fmt.Sprintf(
+			"restore_command = 'cp \"%s/%%f\" \"%%p\"'",
+			filepath.ToSlash(walArchiveDir),
+		),
+		"recovery_target_timeline = 'latest'",
+	}
+
+	if restore.TargetTime != nil {
+		settings = append(
+			settings,
+			fmt.Sprintf(
+				"recovery_target_time = '%s'",
+				restore.TargetTime.UTC().Format("2006-01-02 15:04:05.999999+00"),
+			),
+			"recovery_target_action = 'promote'",
+		)
+	} else if restore.TargetLsn != nil {
+		settings = append(
+			settings,
+			fmt.Sprintf("recovery_target_lsn = '%s'", *restore.TargetLsn),
+			"recovery_target_action = 'promote'",
+		)
+	}
+
+	autoConf, err := os.OpenFile(
+		filepath.Join(dataDir, "postgresql.auto.conf"),
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND,
+		0600,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to open postgresql.auto.conf: %w", err)
+	}
+
+	if _, err := autoConf.WriteString(strings.Join(settings, "\n") + "\n"); err != nil {
+		_ = autoConf.Close()
+		return fmt.Errorf("failed to write recovery settings: %w", err)
+	}
+
+	if err := autoConf.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dataDir, "recovery.signal"), []byte{}, 0600)
+}
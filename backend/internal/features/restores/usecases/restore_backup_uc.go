@@ -1,31 +1,62 @@
 package usecases
 
 import (
+	"context"
 	"errors"
 	"postgresus-backend/internal/features/backups/backups"
 	backups_config "postgresus-backend/internal/features/backups/config"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/restores/models"
+	usecases_mongodb "postgresus-backend/internal/features/restores/usecases/mongodb"
+	usecases_mysql "postgresus-backend/internal/features/restores/usecases/mysql"
 	usecases_postgresql "postgresus-backend/internal/features/restores/usecases/postgresql"
-	"postgresus-backend/internal/features/storages"
 )
 
 type RestoreBackupUsecase struct {
-	restorePostgresqlBackupUsecase *usecases_postgresql.RestorePostgresqlBackupUsecase
+	restorePostgresqlBackupUsecase         *usecases_postgresql.RestorePostgresqlBackupUsecase
+	restorePostgresqlPhysicalBackupUsecase *usecases_postgresql.RestorePostgresqlPhysicalBackupUsecase
+	restoreMysqlBackupUsecase              *usecases_mysql.RestoreMysqlBackupUsecase
+	restoreMongodbBackupUsecase            *usecases_mongodb.RestoreMongodbBackupUsecase
 }
 
 func (uc *RestoreBackupUsecase) Execute(
+	ctx context.Context,
 	backupConfig *backups_config.BackupConfig,
 	restore models.Restore,
 	backup *backups.Backup,
-	storage *storages.Storage,
+	backupFiles *backups.BackupFiles,
 ) error {
 	if restore.Backup.Database.Type == databases.DatabaseTypePostgres {
+		if backup.Type == backups_config.BackupTypePhysical {
+			return uc.restorePostgresqlPhysicalBackupUsecase.Execute(ctx, restore, backup, backupFiles)
+		}
+
 		return uc.restorePostgresqlBackupUsecase.Execute(
+			ctx,
+			backupConfig,
+			restore,
+			backup,
+			backupFiles,
+		)
+	}
+
+	if restore.Backup.Database.Type == databases.DatabaseTypeMysql {
+		return uc.restoreMysqlBackupUsecase.Execute(
+			ctx,
+			backupConfig,
+			restore,
+			backup,
+			backupFiles,
+		)
+	}
+
+	if restore.Backup.Database.Type == databases.DatabaseTypeMongodb {
+		return uc.restoreMongodbBackupUsecase.Execute(
+			ctx,
 			backupConfig,
 			restore,
 			backup,
-			storage,
+			backupFiles,
 		)
 	}
 
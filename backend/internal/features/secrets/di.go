@@ -0,0 +1,15 @@
+package secrets
+
+import (
+	"postgresus-backend/internal/features/encryption_keys"
+	"postgresus-backend/internal/util/logger"
+)
+
+var secretService = &SecretService{
+	encryption_keys.GetSecretKeyStore(),
+	logger.GetLogger(),
+}
+
+func GetSecretService() *SecretService {
+	return secretService
+}
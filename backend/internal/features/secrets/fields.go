@@ -0,0 +1,32 @@
+package secrets
+
+// EncryptFields encrypts secret fields of a model in place. It is
+// called from BeforeSave hooks of models with secrets
+func EncryptFields(fields ...*string) error {
+	for _, field := range fields {
+		encryptedValue, err := secretService.Encrypt(*field)
+		if err != nil {
+			return err
+		}
+
+		*field = encryptedValue
+	}
+
+	return nil
+}
+
+// DecryptFields decrypts secret fields of a model in place. It is called
+// from AfterFind and AfterSave hooks, so code outside of repositories
+// always sees plain values
+func DecryptFields(fields ...*string) error {
+	for _, field := range fields {
+		plaintext, err := secretService.Decrypt(*field)
+		if err != nil {
+			return err
+		}
+
+		*field = plaintext
+	}
+
+	return nil
+}
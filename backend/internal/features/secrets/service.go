@@ -0,0 +1,176 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"postgresus-backend/internal/features/encryption_keys"
+	"postgresus-backend/internal/storage"
+	"postgresus-backend/internal/util/encryption"
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// encrypted values look like "enc:<key ID>:<base64 of nonce and sealed value>"
+const encryptedValuePrefix = "enc:"
+
+type SecretService struct {
+	keyStore *encryption_keys.KeyStore
+	logger   *slog.Logger
+}
+
+// Encrypt seals the value with the active data key. Empty and
+// already encrypted values are returned as is
+func (s *SecretService) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+
+	key, err := s.keyStore.GetActiveKey()
+	if err != nil {
+		return "", err
+	}
+
+	return encryptValue(key.ID, key.Key, plaintext)
+}
+
+// Decrypt opens the value with the data key it was encrypted with. Values
+// stored before secrets encryption was introduced are returned as is
+func (s *SecretService) Decrypt(value string) (string, error) {
+	keyID, ok := parseKeyID(value)
+	if !ok {
+		return value, nil
+	}
+
+	key, err := s.keyStore.GetKeyByID(keyID)
+	if err != nil {
+		return "", err
+	}
+
+	return decryptValue(key.Key, value)
+}
+
+// EncryptPlaintextSecrets encrypts secrets stored before encryption was
+// introduced. It does nothing if there is an active key already, since
+// all writes since the key was created are encrypted
+func (s *SecretService) EncryptPlaintextSecrets(models ...any) error {
+	hasActiveKey, err := s.keyStore.HasActiveKey()
+	if err != nil {
+		return err
+	}
+
+	if hasActiveKey {
+		return nil
+	}
+
+	if _, err := s.keyStore.GetActiveKey(); err != nil {
+		return err
+	}
+
+	if err := s.resaveRecords(models...); err != nil {
+		return err
+	}
+
+	s.logger.Info("Stored secrets are encrypted")
+
+	return nil
+}
+
+// RotateKey creates new active data key and re-encrypts secrets of
+// all records of the models with it. Previous keys are kept, so values
+// written by running instances with them still can be decrypted
+func (s *SecretService) RotateKey(models ...any) (*encryption_keys.DataKey, error) {
+	key, err := s.keyStore.RotateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.resaveRecords(models...); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Secret encryption key rotated", "keyId", key.ID)
+
+	return key, nil
+}
+
+func IsEncrypted(value string) bool {
+	_, ok := parseKeyID(value)
+	return ok
+}
+
+// resaveRecords loads and saves all records of each model. Records are
+// decrypted on load and encrypted with the active key on save by hooks
+func (s *SecretService) resaveRecords(models ...any) error {
+	return storage.GetDb().Transaction(func(tx *gorm.DB) error {
+		for _, model := range models {
+			records := reflect.New(reflect.SliceOf(reflect.TypeOf(model)))
+
+			if err := tx.Find(records.Interface()).Error; err != nil {
+				return fmt.Errorf("failed to load %T: %w", model, err)
+			}
+
+			for i := range records.Elem().Len() {
+				record := records.Elem().Index(i).Interface()
+
+				if err := tx.Omit(clause.Associations).Save(record).Error; err != nil {
+					return fmt.Errorf("failed to save %T: %w", model, err)
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+func encryptValue(keyID uuid.UUID, key []byte, plaintext string) (string, error) {
+	sealed, err := encryption.Encrypt(key, []byte(plaintext))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	return encryptedValuePrefix + keyID.String() + ":" +
+		base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptValue(key []byte, value string) (string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(value, encryptedValuePrefix), ":", 2)
+	if len(parts) != 2 {
+		return "", errors.New("encrypted secret is malformed")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret: %w", err)
+	}
+
+	plaintext, err := encryption.Decrypt(key, sealed)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+func parseKeyID(value string) (uuid.UUID, bool) {
+	if !strings.HasPrefix(value, encryptedValuePrefix) {
+		return uuid.Nil, false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, encryptedValuePrefix), ":", 2)
+	if len(parts) != 2 {
+		return uuid.Nil, false
+	}
+
+	keyID, err := uuid.Parse(parts[0])
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	return keyID, true
+}
@@ -0,0 +1,52 @@
+package secrets
+
+import (
+	"postgresus-backend/internal/util/encryption"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EncryptValue_WhenDecrypted_ReturnsPlaintext(t *testing.T) {
+	key, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	keyID := uuid.New()
+
+	encryptedValue, err := encryptValue(keyID, key, "s3cr3t-password")
+	assert.NoError(t, err)
+	assert.NotContains(t, encryptedValue, "s3cr3t-password")
+	assert.True(t, strings.HasPrefix(encryptedValue, "enc:"+keyID.String()+":"))
+
+	parsedKeyID, ok := parseKeyID(encryptedValue)
+	assert.True(t, ok)
+	assert.Equal(t, keyID, parsedKeyID)
+
+	plaintext, err := decryptValue(key, encryptedValue)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t-password", plaintext)
+}
+
+func Test_DecryptValue_WithAnotherKey_ReturnsError(t *testing.T) {
+	key, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	anotherKey, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	encryptedValue, err := encryptValue(uuid.New(), key, "s3cr3t-password")
+	assert.NoError(t, err)
+
+	_, err = decryptValue(anotherKey, encryptedValue)
+	assert.Error(t, err)
+}
+
+func Test_IsEncrypted_WithPlaintextValues_ReturnsFalse(t *testing.T) {
+	assert.False(t, IsEncrypted(""))
+	assert.False(t, IsEncrypted("password"))
+	assert.False(t, IsEncrypted("enc:password"))
+	assert.False(t, IsEncrypted("enc:not-uuid:c2VjcmV0"))
+	assert.True(t, IsEncrypted("enc:"+uuid.New().String()+":c2VjcmV0"))
+}
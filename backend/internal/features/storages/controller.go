@@ -2,6 +2,7 @@ package storages
 
 import (
 	"net/http"
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/users"
 
 	"github.com/gin-gonic/gin"
@@ -9,8 +10,9 @@ import (
 )
 
 type StorageController struct {
-	storageService *StorageService
-	userService    *users.UserService
+	storageService  *StorageService
+	userService     *users.UserService
+	auditLogService *audit_logs.AuditLogService
 }
 
 func (c *StorageController) RegisterRoutes(router *gin.RouterGroup) {
@@ -47,16 +49,35 @@ func (c *StorageController) SaveStorage(ctx *gin.Context) {
 		return
 	}
 
-	if err := storage.Validate(); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	auditEvent := &audit_logs.AuditEvent{
+		Action:     audit_logs.AuditActionStorageCreate,
+		TargetType: audit_logs.AuditTargetTypeStorage,
+		TargetName: storage.Name,
+	}
+
+	if storage.ID != uuid.Nil {
+		// access is checked on saving, so here is
+		// the old version for audit log only
+		existingStorage, _ := c.storageService.GetStorage(user, storage.ID)
+
+		auditEvent.Action = audit_logs.AuditActionStorageUpdate
+		auditEvent.Before = existingStorage
 	}
 
-	if err := c.storageService.SaveStorage(user, &storage); err != nil {
+	err = c.storageService.SaveStorage(user, &storage)
+
+	auditEvent.TargetID = &storage.ID
+	auditEvent.WorkspaceID = &storage.WorkspaceID
+	auditEvent.After = &storage
+	auditEvent.Err = err
+	c.auditLogService.LogUserAction(user, ctx.ClientIP(), auditEvent)
+
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	storage.HideSensitiveData()
 	ctx.JSON(http.StatusOK, storage)
 }
 
@@ -90,15 +111,17 @@ func (c *StorageController) GetStorage(ctx *gin.Context) {
 		return
 	}
 
+	storage.HideSensitiveData()
 	ctx.JSON(http.StatusOK, storage)
 }
 
 // GetStorages
 // @Summary Get all storages
-// @Description Get all storages for the current user
+// @Description Get storages of the workspace or of all workspaces of the current user
 // @Tags storages
 // @Produce json
 // @Param Authorization header string true "JWT token"
+// @Param workspace_id query string false "Workspace ID"
 // @Success 200 {array} Storage
 // @Failure 401
 // @Router /storages [get]
@@ -109,12 +132,27 @@ func (c *StorageController) GetStorages(ctx *gin.Context) {
 		return
 	}
 
-	storages, err := c.storageService.GetStorages(user)
+	var workspaceID *uuid.UUID
+	if workspaceIDParam := ctx.Query("workspace_id"); workspaceIDParam != "" {
+		id, err := uuid.Parse(workspaceIDParam)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+			return
+		}
+
+		workspaceID = &id
+	}
+
+	storages, err := c.storageService.GetStorages(user, workspaceID)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	for _, storage := range storages {
+		storage.HideSensitiveData()
+	}
+
 	ctx.JSON(http.StatusOK, storages)
 }
 
@@ -142,7 +180,23 @@ func (c *StorageController) DeleteStorage(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.storageService.DeleteStorage(user, id); err != nil {
+	auditEvent := &audit_logs.AuditEvent{
+		Action:     audit_logs.AuditActionStorageDelete,
+		TargetType: audit_logs.AuditTargetTypeStorage,
+		TargetID:   &id,
+	}
+
+	if storage, err := c.storageService.GetStorage(user, id); err == nil {
+		auditEvent.TargetName = storage.Name
+		auditEvent.WorkspaceID = &storage.WorkspaceID
+	}
+
+	err = c.storageService.DeleteStorage(user, id)
+
+	auditEvent.Err = err
+	c.auditLogService.LogUserAction(user, ctx.ClientIP(), auditEvent)
+
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -210,12 +264,7 @@ func (c *StorageController) TestStorageConnectionDirect(ctx *gin.Context) {
 	// For direct test, associate with the current user
 	storage.UserID = user.ID
 
-	if err := storage.Validate(); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	if err := c.storageService.TestStorageConnectionDirect(&storage); err != nil {
+	if err := c.storageService.TestStorageConnectionDirect(user, &storage); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	local_storage "postgresus-backend/internal/features/storages/models/local"
 	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/features/workspaces"
 	test_utils "postgresus-backend/internal/util/testing"
 	"testing"
 
@@ -130,6 +131,90 @@ func Test_DeleteStorage_StorageNotReturnedViaGet(t *testing.T) {
 	assert.NotContains(t, storages, savedStorage)
 }
 
+func Test_SaveAndDeleteStorageByOperator_AccessDenied(t *testing.T) {
+	owner := users.GetTestUser()
+	operator := users.CreateTestUser()
+	router := createRouter()
+	storage := CreateTestStorage(owner.UserID)
+	defer RemoveTestStorage(storage.ID)
+
+	workspaces.AddTestMember(storage.WorkspaceID, operator.UserID, workspaces.WorkspaceRoleOperator)
+
+	// operator can see the storage, but cannot change it
+	var retrievedStorage Storage
+	test_utils.MakeGetRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/storages/"+storage.ID.String(),
+		operator.Token,
+		http.StatusOK,
+		&retrievedStorage,
+	)
+
+	retrievedStorage.Name = "Updated Storage " + uuid.New().String()
+	response := test_utils.MakePostRequest(
+		t, router, "/api/v1/storages", operator.Token, retrievedStorage, http.StatusBadRequest,
+	)
+	assert.Contains(t, string(response.Body), "admin role or higher is required")
+
+	newStorage := createNewStorage(owner.UserID)
+	response = test_utils.MakePostRequest(
+		t, router, "/api/v1/storages", operator.Token, newStorage, http.StatusBadRequest,
+	)
+	assert.Contains(t, string(response.Body), "admin role or higher is required")
+
+	test_utils.MakeDeleteRequest(
+		t, router, "/api/v1/storages/"+storage.ID.String(), operator.Token, http.StatusBadRequest,
+	)
+
+	var notChangedStorage Storage
+	test_utils.MakeGetRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/storages/"+storage.ID.String(),
+		owner.Token,
+		http.StatusOK,
+		&notChangedStorage,
+	)
+	assert.Equal(t, storage.Name, notChangedStorage.Name)
+}
+
+func Test_GetStorageByMemberOfAnotherWorkspace_AccessDenied(t *testing.T) {
+	owner := users.GetTestUser()
+	stranger := users.CreateTestUser()
+	router := createRouter()
+	storage := CreateTestStorage(owner.UserID)
+	defer RemoveTestStorage(storage.ID)
+
+	strangerWorkspaceID := workspaces.CreateTestWorkspace(stranger.UserID)
+
+	response := test_utils.MakeGetRequest(
+		t, router, "/api/v1/storages/"+storage.ID.String(), stranger.Token, http.StatusBadRequest,
+	)
+	assert.Contains(t, string(response.Body), "you have not access to this workspace")
+
+	var storages []Storage
+	test_utils.MakeGetRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/storages?workspace_id="+strangerWorkspaceID.String(),
+		stranger.Token,
+		http.StatusOK,
+		&storages,
+	)
+	for _, strangerStorage := range storages {
+		assert.NotEqual(t, storage.ID, strangerStorage.ID)
+	}
+
+	test_utils.MakeGetRequest(
+		t,
+		router,
+		"/api/v1/storages?workspace_id="+storage.WorkspaceID.String(),
+		stranger.Token,
+		http.StatusBadRequest,
+	)
+}
+
 func Test_TestDirectStorageConnection_ConnectionEstablished(t *testing.T) {
 	user := users.GetTestUser()
 	router := createRouter()
@@ -169,7 +254,7 @@ func Test_TestExistingStorageConnection_ConnectionEstablished(t *testing.T) {
 
 func Test_CallAllMethodsWithoutAuth_UnauthorizedErrorReturned(t *testing.T) {
 	router := createRouter()
-	storage := createNewStorage(uuid.New())
+	storage := createNewStorage(users.GetTestUser().UserID)
 
 	// Test endpoints without auth
 	endpoints := []struct {
@@ -216,6 +301,7 @@ func createRouter() *gin.Engine {
 func createNewStorage(userID uuid.UUID) *Storage {
 	return &Storage{
 		UserID:       userID,
+		WorkspaceID:  workspaces.GetTestWorkspaceID(userID),
 		Type:         StorageTypeLocal,
 		Name:         "Test Storage " + uuid.New().String(),
 		LocalStorage: &local_storage.LocalStorage{},
@@ -1,16 +1,20 @@
 package storages
 
 import (
+	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/features/workspaces"
 )
 
 var storageRepository = &StorageRepository{}
 var storageService = &StorageService{
 	storageRepository,
+	workspaces.GetWorkspaceService(),
 }
 var storageController = &StorageController{
 	storageService,
 	users.GetUserService(),
+	audit_logs.GetAuditLogService(),
 }
 
 func GetStorageService() *StorageService {
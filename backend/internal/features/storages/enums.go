@@ -7,4 +7,10 @@ const (
 	StorageTypeS3          StorageType = "S3"
 	StorageTypeGoogleDrive StorageType = "GOOGLE_DRIVE"
 	StorageTypeNAS         StorageType = "NAS"
+	StorageTypeSFTP        StorageType = "SFTP"
+	StorageTypeAzureBlob   StorageType = "AZURE_BLOB"
+	StorageTypeFTP         StorageType = "FTP"
+	StorageTypeWebDAV      StorageType = "WEBDAV"
+	StorageTypeDropbox     StorageType = "DROPBOX"
+	StorageTypeOneDrive    StorageType = "ONEDRIVE"
 )
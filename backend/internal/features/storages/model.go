@@ -1,21 +1,32 @@
 package storages
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	azure_blob_storage "postgresus-backend/internal/features/storages/models/azure_blob"
+	dropbox_storage "postgresus-backend/internal/features/storages/models/dropbox"
+	ftp_storage "postgresus-backend/internal/features/storages/models/ftp"
 	google_drive_storage "postgresus-backend/internal/features/storages/models/google_drive"
 	local_storage "postgresus-backend/internal/features/storages/models/local"
 	nas_storage "postgresus-backend/internal/features/storages/models/nas"
+	onedrive_storage "postgresus-backend/internal/features/storages/models/onedrive"
 	s3_storage "postgresus-backend/internal/features/storages/models/s3"
+	sftp_storage "postgresus-backend/internal/features/storages/models/sftp"
+	webdav_storage "postgresus-backend/internal/features/storages/models/webdav"
+	"postgresus-backend/internal/util/tracing"
+	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Storage struct {
 	ID            uuid.UUID   `json:"id"            gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
 	UserID        uuid.UUID   `json:"userId"        gorm:"column:user_id;not null;type:uuid;index"`
+	WorkspaceID   uuid.UUID   `json:"workspaceId"   gorm:"column:workspace_id;not null;type:uuid;index"`
 	Type          StorageType `json:"type"          gorm:"column:type;not null;type:text"`
 	Name          string      `json:"name"          gorm:"column:name;not null;type:text"`
 	LastSaveError *string     `json:"lastSaveError" gorm:"column:last_save_error;type:text"`
@@ -25,15 +36,34 @@ type Storage struct {
 	S3Storage          *s3_storage.S3Storage                    `json:"s3Storage"          gorm:"foreignKey:StorageID"`
 	GoogleDriveStorage *google_drive_storage.GoogleDriveStorage `json:"googleDriveStorage" gorm:"foreignKey:StorageID"`
 	NASStorage         *nas_storage.NASStorage                  `json:"nasStorage"         gorm:"foreignKey:StorageID"`
+	SFTPStorage        *sftp_storage.SFTPStorage                `json:"sftpStorage"        gorm:"foreignKey:StorageID"`
+	AzureBlobStorage   *azure_blob_storage.AzureBlobStorage     `json:"azureBlobStorage"   gorm:"foreignKey:StorageID"`
+	FTPStorage         *ftp_storage.FTPStorage                  `json:"ftpStorage"         gorm:"foreignKey:StorageID"`
+	WebDAVStorage      *webdav_storage.WebDAVStorage            `json:"webdavStorage"      gorm:"foreignKey:StorageID"`
+	DropboxStorage     *dropbox_storage.DropboxStorage          `json:"dropboxStorage"     gorm:"foreignKey:StorageID"`
+	OneDriveStorage    *onedrive_storage.OneDriveStorage        `json:"oneDriveStorage"    gorm:"foreignKey:StorageID"`
 }
 
-func (s *Storage) SaveFile(logger *slog.Logger, fileID uuid.UUID, file io.Reader) error {
+func (s *Storage) SaveFile(
+	ctx context.Context,
+	logger *slog.Logger,
+	fileID uuid.UUID,
+	file io.Reader,
+) (err error) {
+	_, span := tracing.StartSpan(ctx, "storage.save_file", s.getSpanAttributes(fileID)...)
+	defer func() { tracing.EndSpan(span, err) }()
+
 	// Ensure system directories exist before any storage operations
 	if err := EnsureSystemDirectories(); err != nil {
 		return fmt.Errorf("failed to ensure system directories: %w", err)
 	}
 
-	err := s.getSpecificStorage().SaveFile(logger, fileID, file)
+	startedAt := time.Now().UTC()
+	countingReader := &tracing.CountingReader{Reader: file}
+
+	err = s.getSpecificStorage().SaveFile(logger, fileID, countingReader)
+	tracing.SetThroughput(span, countingReader.BytesRead, time.Since(startedAt))
+
 	if err != nil {
 		lastSaveError := err.Error()
 		s.LastSaveError = &lastSaveError
@@ -45,8 +75,18 @@ func (s *Storage) SaveFile(logger *slog.Logger, fileID uuid.UUID, file io.Reader
 	return nil
 }
 
-func (s *Storage) GetFile(fileID uuid.UUID) (io.ReadCloser, error) {
-	return s.getSpecificStorage().GetFile(fileID)
+// GetFile opens the file for reading. The span lasts until the
+// file is closed, so it covers the download itself
+func (s *Storage) GetFile(ctx context.Context, fileID uuid.UUID) (io.ReadCloser, error) {
+	_, span := tracing.StartSpan(ctx, "storage.get_file", s.getSpanAttributes(fileID)...)
+
+	file, err := s.getSpecificStorage().GetFile(fileID)
+	if err != nil {
+		tracing.EndSpan(span, err)
+		return nil, err
+	}
+
+	return tracing.NewSpanReadCloser(file, span), nil
 }
 
 func (s *Storage) DeleteFile(fileID uuid.UUID) error {
@@ -70,6 +110,90 @@ func (s *Storage) Validate() error {
 	return s.getSpecificStorage().Validate()
 }
 
+// HideSensitiveData clears credentials of the specific storage before it
+// is returned by the API
+func (s *Storage) HideSensitiveData() {
+	if s.S3Storage != nil {
+		s.S3Storage.HideSensitiveData()
+	}
+
+	if s.GoogleDriveStorage != nil {
+		s.GoogleDriveStorage.HideSensitiveData()
+	}
+
+	if s.NASStorage != nil {
+		s.NASStorage.HideSensitiveData()
+	}
+
+	if s.SFTPStorage != nil {
+		s.SFTPStorage.HideSensitiveData()
+	}
+
+	if s.AzureBlobStorage != nil {
+		s.AzureBlobStorage.HideSensitiveData()
+	}
+
+	if s.FTPStorage != nil {
+		s.FTPStorage.HideSensitiveData()
+	}
+
+	if s.WebDAVStorage != nil {
+		s.WebDAVStorage.HideSensitiveData()
+	}
+
+	if s.DropboxStorage != nil {
+		s.DropboxStorage.HideSensitiveData()
+	}
+
+	if s.OneDriveStorage != nil {
+		s.OneDriveStorage.HideSensitiveData()
+	}
+}
+
+// FillSensitiveData restores credentials which the client left empty
+// from the stored storage of the same type
+func (s *Storage) FillSensitiveData(existing *Storage) {
+	if existing == nil || s.Type != existing.Type {
+		return
+	}
+
+	if s.S3Storage != nil {
+		s.S3Storage.FillSensitiveData(existing.S3Storage)
+	}
+
+	if s.GoogleDriveStorage != nil {
+		s.GoogleDriveStorage.FillSensitiveData(existing.GoogleDriveStorage)
+	}
+
+	if s.NASStorage != nil {
+		s.NASStorage.FillSensitiveData(existing.NASStorage)
+	}
+
+	if s.SFTPStorage != nil {
+		s.SFTPStorage.FillSensitiveData(existing.SFTPStorage)
+	}
+
+	if s.AzureBlobStorage != nil {
+		s.AzureBlobStorage.FillSensitiveData(existing.AzureBlobStorage)
+	}
+
+	if s.FTPStorage != nil {
+		s.FTPStorage.FillSensitiveData(existing.FTPStorage)
+	}
+
+	if s.WebDAVStorage != nil {
+		s.WebDAVStorage.FillSensitiveData(existing.WebDAVStorage)
+	}
+
+	if s.DropboxStorage != nil {
+		s.DropboxStorage.FillSensitiveData(existing.DropboxStorage)
+	}
+
+	if s.OneDriveStorage != nil {
+		s.OneDriveStorage.FillSensitiveData(existing.OneDriveStorage)
+	}
+}
+
 func (s *Storage) TestConnection() error {
 	// Ensure system directories exist before testing connection
 	if err := EnsureSystemDirectories(); err != nil {
@@ -89,7 +213,27 @@ func (s *Storage) getSpecificStorage() StorageFileSaver {
 		return s.GoogleDriveStorage
 	case StorageTypeNAS:
 		return s.NASStorage
+	case StorageTypeSFTP:
+		return s.SFTPStorage
+	case StorageTypeAzureBlob:
+		return s.AzureBlobStorage
+	case StorageTypeFTP:
+		return s.FTPStorage
+	case StorageTypeWebDAV:
+		return s.WebDAVStorage
+	case StorageTypeDropbox:
+		return s.DropboxStorage
+	case StorageTypeOneDrive:
+		return s.OneDriveStorage
 	default:
 		panic("invalid storage type: " + string(s.Type))
 	}
 }
+
+func (s *Storage) getSpanAttributes(fileID uuid.UUID) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("storage.id", s.ID.String()),
+		attribute.String("storage.type", string(s.Type)),
+		attribute.String("file.id", fileID.String()),
+	}
+}
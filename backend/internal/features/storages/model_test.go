@@ -5,23 +5,34 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"postgresus-backend/internal/config"
+	azure_blob_storage "postgresus-backend/internal/features/storages/models/azure_blob"
+	dropbox_storage "postgresus-backend/internal/features/storages/models/dropbox"
+	ftp_storage "postgresus-backend/internal/features/storages/models/ftp"
 	google_drive_storage "postgresus-backend/internal/features/storages/models/google_drive"
 	local_storage "postgresus-backend/internal/features/storages/models/local"
 	nas_storage "postgresus-backend/internal/features/storages/models/nas"
+	onedrive_storage "postgresus-backend/internal/features/storages/models/onedrive"
 	s3_storage "postgresus-backend/internal/features/storages/models/s3"
+	sftp_storage "postgresus-backend/internal/features/storages/models/sftp"
+	webdav_storage "postgresus-backend/internal/features/storages/models/webdav"
 	"postgresus-backend/internal/util/logger"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
 )
 
 type S3Container struct {
@@ -32,6 +43,15 @@ type S3Container struct {
 	region     string
 }
 
+type AzuriteContainer struct {
+	endpoint         string
+	accountName      string
+	accountKey       string
+	sasToken         string
+	connectionString string
+	containerName    string
+}
+
 func Test_Storage_BasicOperations(t *testing.T) {
 	ctx := context.Background()
 
@@ -41,6 +61,10 @@ func Test_Storage_BasicOperations(t *testing.T) {
 	s3Container, err := setupS3Container(ctx)
 	require.NoError(t, err, "Failed to setup S3 container")
 
+	// Setup Azure Blob connection to docker-compose Azurite
+	azuriteContainer, err := setupAzuriteContainer(ctx)
+	require.NoError(t, err, "Failed to setup Azurite container")
+
 	// Setup test file
 	testFilePath, err := setupTestFile()
 	require.NoError(t, err, "Failed to setup test file")
@@ -54,6 +78,33 @@ func Test_Storage_BasicOperations(t *testing.T) {
 		}
 	}
 
+	// Setup FTP port
+	ftpPort := 21
+	if portStr := config.GetEnv().TestFTPPort; portStr != "" {
+		if port, err := strconv.Atoi(portStr); err == nil {
+			ftpPort = port
+		}
+	}
+
+	// Setup SFTP port and host key
+	sftpPort := 22
+	if portStr := config.GetEnv().TestSFTPPort; portStr != "" {
+		if port, err := strconv.Atoi(portStr); err == nil {
+			sftpPort = port
+		}
+	}
+
+	sftpHostKey, err := getSFTPHostKey(sftpPort)
+	require.NoError(t, err, "Failed to get SFTP host key")
+
+	// Setup WebDAV port
+	webdavPort := 80
+	if portStr := config.GetEnv().TestWebDAVPort; portStr != "" {
+		if port, err := strconv.Atoi(portStr); err == nil {
+			webdavPort = port
+		}
+	}
+
 	// Run tests
 	testCases := []struct {
 		name    string
@@ -83,6 +134,26 @@ func Test_Storage_BasicOperations(t *testing.T) {
 				TokenJSON:    config.GetEnv().TestGoogleDriveTokenJSON,
 			},
 		},
+		{
+			name: "DropboxStorage",
+			storage: &dropbox_storage.DropboxStorage{
+				StorageID:    uuid.New(),
+				ClientID:     config.GetEnv().TestDropboxClientID,
+				ClientSecret: config.GetEnv().TestDropboxClientSecret,
+				TokenJSON:    config.GetEnv().TestDropboxTokenJSON,
+				Path:         "postgresus-test-files",
+			},
+		},
+		{
+			name: "OneDriveStorage",
+			storage: &onedrive_storage.OneDriveStorage{
+				StorageID:    uuid.New(),
+				ClientID:     config.GetEnv().TestOneDriveClientID,
+				ClientSecret: config.GetEnv().TestOneDriveClientSecret,
+				TokenJSON:    config.GetEnv().TestOneDriveTokenJSON,
+				Path:         "postgresus-test-files",
+			},
+		},
 		{
 			name: "NASStorage",
 			storage: &nas_storage.NASStorage{
@@ -97,6 +168,74 @@ func Test_Storage_BasicOperations(t *testing.T) {
 				Path:      "test-files",
 			},
 		},
+		{
+			name: "AzureBlobStorageWithAccountKey",
+			storage: &azure_blob_storage.AzureBlobStorage{
+				StorageID:     uuid.New(),
+				AuthMethod:    azure_blob_storage.AzureBlobAuthMethodAccountKey,
+				AccountName:   azuriteContainer.accountName,
+				AccountKey:    azuriteContainer.accountKey,
+				Endpoint:      azuriteContainer.endpoint,
+				ContainerName: azuriteContainer.containerName,
+				Prefix:        "test-files",
+				AccessTier:    azure_blob_storage.AzureBlobAccessTierCool,
+			},
+		},
+		{
+			name: "AzureBlobStorageWithSASToken",
+			storage: &azure_blob_storage.AzureBlobStorage{
+				StorageID:     uuid.New(),
+				AuthMethod:    azure_blob_storage.AzureBlobAuthMethodSASToken,
+				AccountName:   azuriteContainer.accountName,
+				SASToken:      azuriteContainer.sasToken,
+				Endpoint:      azuriteContainer.endpoint,
+				ContainerName: azuriteContainer.containerName,
+			},
+		},
+		{
+			name: "AzureBlobStorageWithConnectionString",
+			storage: &azure_blob_storage.AzureBlobStorage{
+				StorageID:        uuid.New(),
+				AuthMethod:       azure_blob_storage.AzureBlobAuthMethodConnectionString,
+				ConnectionString: azuriteContainer.connectionString,
+				ContainerName:    azuriteContainer.containerName,
+				Prefix:           "/nested/test-files/",
+			},
+		},
+		{
+			name: "FTPStorage",
+			storage: &ftp_storage.FTPStorage{
+				StorageID: uuid.New(),
+				Host:      "localhost",
+				Port:      ftpPort,
+				Username:  "testuser",
+				Password:  "testpassword",
+				TLSMode:   ftp_storage.FTPTLSModeNone,
+				Path:      "test-files/nested",
+			},
+		},
+		{
+			name: "SFTPStorage",
+			storage: &sftp_storage.SFTPStorage{
+				StorageID: uuid.New(),
+				Host:      "localhost",
+				Port:      sftpPort,
+				Username:  "testuser",
+				Password:  "testpassword",
+				HostKey:   sftpHostKey,
+				Path:      "backups/test-files",
+			},
+		},
+		{
+			name: "WebDAVStorage",
+			storage: &webdav_storage.WebDAVStorage{
+				StorageID: uuid.New(),
+				URL:       "http://localhost:" + strconv.Itoa(webdavPort),
+				Username:  "testuser",
+				Password:  "testpassword",
+				Path:      "test-files/nested",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -219,11 +358,108 @@ func setupS3Container(ctx context.Context) (*S3Container, error) {
 	}, nil
 }
 
+// setupAzuriteContainer connects to the docker-compose Azurite service
+// with its well-known development account
+func setupAzuriteContainer(ctx context.Context) (*AzuriteContainer, error) {
+	env := config.GetEnv()
+
+	accountName := "devstoreaccount1"
+	accountKey := "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+	containerName := "test-container"
+	endpoint := fmt.Sprintf("http://localhost:%s/%s", env.TestAzuriteBlobPort, accountName)
+
+	credential, err := azblob.NewSharedKeyCredential(accountName, accountKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure credential: %w", err)
+	}
+
+	client, err := azblob.NewClientWithSharedKeyCredential(endpoint+"/", credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure client: %w", err)
+	}
+
+	// Create the container
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	_, err = client.CreateContainer(ctx, containerName, nil)
+	if err != nil && !bloberror.HasCode(err, bloberror.ContainerAlreadyExists) {
+		return nil, fmt.Errorf("failed to create container: %w", err)
+	}
+
+	sasQueryParams, err := sas.AccountSignatureValues{
+		Protocol:   sas.ProtocolHTTPSandHTTP,
+		ExpiryTime: time.Now().Add(time.Hour),
+		Permissions: (&sas.AccountPermissions{
+			Read:   true,
+			Write:  true,
+			Delete: true,
+			Create: true,
+		}).String(),
+		ResourceTypes: (&sas.AccountResourceTypes{Container: true, Object: true}).String(),
+	}.SignWithSharedKey(credential)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SAS token: %w", err)
+	}
+
+	connectionString := fmt.Sprintf(
+		"DefaultEndpointsProtocol=http;AccountName=%s;AccountKey=%s;BlobEndpoint=%s;",
+		accountName,
+		accountKey,
+		endpoint,
+	)
+
+	return &AzuriteContainer{
+		endpoint:         endpoint,
+		accountName:      accountName,
+		accountKey:       accountKey,
+		sasToken:         sasQueryParams.Encode(),
+		connectionString: connectionString,
+		containerName:    containerName,
+	}, nil
+}
+
+// getSFTPHostKey reads the host key of the docker-compose SFTP
+// service, because the container generates it on the first start
+func getSFTPHostKey(port int) (string, error) {
+	var hostKey ssh.PublicKey
+
+	client, err := ssh.Dial(
+		"tcp",
+		net.JoinHostPort("localhost", strconv.Itoa(port)),
+		&ssh.ClientConfig{
+			User: "testuser",
+			Auth: []ssh.AuthMethod{ssh.Password("testpassword")},
+			HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+				hostKey = key
+				return nil
+			},
+			Timeout: 10 * time.Second,
+		},
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to SFTP server: %w", err)
+	}
+	defer client.Close()
+
+	return string(ssh.MarshalAuthorizedKey(hostKey)), nil
+}
+
 func validateEnvVariables(t *testing.T) {
 	env := config.GetEnv()
 	assert.NotEmpty(t, env.TestGoogleDriveClientID, "TEST_GOOGLE_DRIVE_CLIENT_ID is empty")
 	assert.NotEmpty(t, env.TestGoogleDriveClientSecret, "TEST_GOOGLE_DRIVE_CLIENT_SECRET is empty")
 	assert.NotEmpty(t, env.TestGoogleDriveTokenJSON, "TEST_GOOGLE_DRIVE_TOKEN_JSON is empty")
+	assert.NotEmpty(t, env.TestDropboxClientID, "TEST_DROPBOX_CLIENT_ID is empty")
+	assert.NotEmpty(t, env.TestDropboxClientSecret, "TEST_DROPBOX_CLIENT_SECRET is empty")
+	assert.NotEmpty(t, env.TestDropboxTokenJSON, "TEST_DROPBOX_TOKEN_JSON is empty")
+	assert.NotEmpty(t, env.TestOneDriveClientID, "TEST_ONEDRIVE_CLIENT_ID is empty")
+	assert.NotEmpty(t, env.TestOneDriveClientSecret, "TEST_ONEDRIVE_CLIENT_SECRET is empty")
+	assert.NotEmpty(t, env.TestOneDriveTokenJSON, "TEST_ONEDRIVE_TOKEN_JSON is empty")
 	assert.NotEmpty(t, env.TestMinioPort, "TEST_MINIO_PORT is empty")
 	assert.NotEmpty(t, env.TestNASPort, "TEST_NAS_PORT is empty")
+	assert.NotEmpty(t, env.TestAzuriteBlobPort, "TEST_AZURITE_BLOB_PORT is empty")
+	assert.NotEmpty(t, env.TestFTPPort, "TEST_FTP_PORT is empty")
+	assert.NotEmpty(t, env.TestSFTPPort, "TEST_SFTP_PORT is empty")
+	assert.NotEmpty(t, env.TestWebDAVPort, "TEST_WEBDAV_PORT is empty")
 }
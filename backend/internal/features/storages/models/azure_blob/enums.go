@@ -0,0 +1,17 @@
+package azure_blob_storage
+
+type AzureBlobAuthMethod string
+
+const (
+	AzureBlobAuthMethodAccountKey       AzureBlobAuthMethod = "ACCOUNT_KEY"
+	AzureBlobAuthMethodSASToken         AzureBlobAuthMethod = "SAS_TOKEN"
+	AzureBlobAuthMethodConnectionString AzureBlobAuthMethod = "CONNECTION_STRING"
+)
+
+type AzureBlobAccessTier string
+
+const (
+	AzureBlobAccessTierHot     AzureBlobAccessTier = "HOT"
+	AzureBlobAccessTierCool    AzureBlobAccessTier = "COOL"
+	AzureBlobAccessTierArchive AzureBlobAccessTier = "ARCHIVE"
+)
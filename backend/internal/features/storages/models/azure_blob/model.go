@@ -0,0 +1,313 @@
+package azure_blob_storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"postgresus-backend/internal/features/secrets"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// blob can have up to 50 000 blocks, so 8 MB blocks
+	// allow backups up to ~390 GB
+	uploadBlockSize   = 8 * 1024 * 1024
+	uploadConcurrency = 2
+)
+
+type AzureBlobStorage struct {
+	StorageID  uuid.UUID           `json:"storageId"  gorm:"primaryKey;type:uuid;column:storage_id"`
+	AuthMethod AzureBlobAuthMethod `json:"authMethod" gorm:"not null;type:text;column:auth_method"`
+
+	// account name and key are used for ACCOUNT_KEY, SAS token is
+	// used for SAS_TOKEN together with the account name
+	AccountName      string `json:"accountName"      gorm:"type:text;column:account_name"`
+	AccountKey       string `json:"accountKey"       gorm:"type:text;column:account_key"`
+	SASToken         string `json:"sasToken"         gorm:"type:text;column:sas_token"`
+	ConnectionString string `json:"connectionString" gorm:"type:text;column:connection_string"`
+
+	// Endpoint overrides https://<account>.blob.core.windows.net,
+	// e.g. for sovereign clouds or Azurite
+	Endpoint string `json:"endpoint" gorm:"type:text;column:endpoint"`
+
+	ContainerName string              `json:"containerName" gorm:"not null;type:text;column:container_name"`
+	Prefix        string              `json:"prefix"        gorm:"type:text;column:prefix"`
+	AccessTier    AzureBlobAccessTier `json:"accessTier"    gorm:"type:text;column:access_tier"`
+}
+
+func (s *AzureBlobStorage) TableName() string {
+	return "azure_blob_storages"
+}
+
+func (s *AzureBlobStorage) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&s.AccountKey, &s.SASToken, &s.ConnectionString)
+}
+
+func (s *AzureBlobStorage) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&s.AccountKey, &s.SASToken, &s.ConnectionString)
+}
+
+func (s *AzureBlobStorage) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&s.AccountKey, &s.SASToken, &s.ConnectionString)
+}
+
+func (s *AzureBlobStorage) HideSensitiveData() {
+	s.AccountKey = ""
+	s.SASToken = ""
+	s.ConnectionString = ""
+}
+
+// FillSensitiveData keeps the stored credential of the same auth
+// method unless the account or the endpoint is changed
+func (s *AzureBlobStorage) FillSensitiveData(existing *AzureBlobStorage) {
+	if existing == nil ||
+		s.AuthMethod != existing.AuthMethod ||
+		s.AccountName != existing.AccountName ||
+		s.Endpoint != existing.Endpoint {
+		return
+	}
+
+	switch s.AuthMethod {
+	case AzureBlobAuthMethodAccountKey:
+		if s.AccountKey == "" {
+			s.AccountKey = existing.AccountKey
+		}
+	case AzureBlobAuthMethodSASToken:
+		if s.SASToken == "" {
+			s.SASToken = existing.SASToken
+		}
+	case AzureBlobAuthMethodConnectionString:
+		if s.ConnectionString == "" {
+			s.ConnectionString = existing.ConnectionString
+		}
+	}
+}
+
+// SaveFile uploads the file as block blob block by block, so
+// only a few blocks of the backup are kept in memory
+func (s *AzureBlobStorage) SaveFile(logger *slog.Logger, fileID uuid.UUID, file io.Reader) error {
+	client, err := s.getClient()
+	if err != nil {
+		return err
+	}
+
+	options := &azblob.UploadStreamOptions{
+		BlockSize:   uploadBlockSize,
+		Concurrency: uploadConcurrency,
+	}
+
+	if s.AccessTier != "" {
+		accessTier := s.getAccessTier()
+		options.AccessTier = &accessTier
+	}
+
+	_, err = client.UploadStream(
+		context.TODO(),
+		s.ContainerName,
+		s.getBlobName(fileID),
+		file,
+		options,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upload file to Azure Blob Storage: %w", err)
+	}
+
+	logger.Info(
+		"Successfully saved file to Azure Blob Storage",
+		"fileId",
+		fileID.String(),
+		"container",
+		s.ContainerName,
+	)
+	return nil
+}
+
+// GetFile streams the blob. Archived blobs must be rehydrated
+// in Azure before they can be restored
+func (s *AzureBlobStorage) GetFile(fileID uuid.UUID) (io.ReadCloser, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := client.DownloadStream(
+		context.TODO(),
+		s.ContainerName,
+		s.getBlobName(fileID),
+		nil,
+	)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, fmt.Errorf("file not found: %s", fileID.String())
+		}
+
+		if bloberror.HasCode(err, bloberror.BlobArchived) {
+			return nil, fmt.Errorf(
+				"file %s is archived, rehydrate it to Hot or Cool tier in Azure to restore it",
+				fileID.String(),
+			)
+		}
+
+		return nil, fmt.Errorf("failed to get file from Azure Blob Storage: %w", err)
+	}
+
+	return response.Body, nil
+}
+
+func (s *AzureBlobStorage) DeleteFile(fileID uuid.UUID) error {
+	client, err := s.getClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.DeleteBlob(context.TODO(), s.ContainerName, s.getBlobName(fileID), nil)
+	if err != nil && !bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return fmt.Errorf("failed to delete file from Azure Blob Storage: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AzureBlobStorage) Validate() error {
+	switch s.AuthMethod {
+	case AzureBlobAuthMethodAccountKey:
+		if s.AccountName == "" {
+			return errors.New("Azure storage account name is required")
+		}
+		if s.AccountKey == "" {
+			return errors.New("Azure storage account key is required")
+		}
+	case AzureBlobAuthMethodSASToken:
+		if s.AccountName == "" && s.Endpoint == "" {
+			return errors.New("Azure storage account name or endpoint is required")
+		}
+		if s.SASToken == "" {
+			return errors.New("Azure SAS token is required")
+		}
+	case AzureBlobAuthMethodConnectionString:
+		if s.ConnectionString == "" {
+			return errors.New("Azure connection string is required")
+		}
+	case "":
+		return errors.New("Azure auth method is required")
+	default:
+		return fmt.Errorf("unsupported Azure auth method: %s", s.AuthMethod)
+	}
+
+	if s.ContainerName == "" {
+		return errors.New("Azure container name is required")
+	}
+
+	switch s.AccessTier {
+	case "", AzureBlobAccessTierHot, AzureBlobAccessTierCool, AzureBlobAccessTierArchive:
+	default:
+		return fmt.Errorf("unsupported Azure access tier: %s", s.AccessTier)
+	}
+
+	// Try to create a client to validate the configuration
+	if _, err := s.getClient(); err != nil {
+		return fmt.Errorf("invalid Azure Blob Storage configuration: %w", err)
+	}
+
+	return nil
+}
+
+// TestConnection uploads and deletes a small blob, because SAS
+// tokens may allow to write blobs without access to the container
+func (s *AzureBlobStorage) TestConnection() error {
+	client, err := s.getClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	testBlobName := s.getBlobName(uuid.New())
+
+	_, err = client.UploadBuffer(ctx, s.ContainerName, testBlobName, []byte("postgresus"), nil)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return errors.New("failed to connect to the container. Please check params")
+		}
+
+		if bloberror.HasCode(err, bloberror.ContainerNotFound) {
+			return fmt.Errorf("container '%s' does not exist", s.ContainerName)
+		}
+
+		return fmt.Errorf("failed to write to Azure Blob Storage: %w", err)
+	}
+
+	_, err = client.DeleteBlob(ctx, s.ContainerName, testBlobName, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete from Azure Blob Storage: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AzureBlobStorage) getClient() (*azblob.Client, error) {
+	var client *azblob.Client
+	var err error
+
+	switch s.AuthMethod {
+	case AzureBlobAuthMethodAccountKey:
+		credential, credentialErr := azblob.NewSharedKeyCredential(s.AccountName, s.AccountKey)
+		if credentialErr != nil {
+			return nil, fmt.Errorf("invalid Azure account key: %w", credentialErr)
+		}
+
+		client, err = azblob.NewClientWithSharedKeyCredential(s.getServiceURL(), credential, nil)
+	case AzureBlobAuthMethodSASToken:
+		sasToken := strings.TrimPrefix(strings.TrimSpace(s.SASToken), "?")
+		client, err = azblob.NewClientWithNoCredential(s.getServiceURL()+"?"+sasToken, nil)
+	case AzureBlobAuthMethodConnectionString:
+		client, err = azblob.NewClientFromConnectionString(s.ConnectionString, nil)
+	default:
+		return nil, fmt.Errorf("unsupported Azure auth method: %s", s.AuthMethod)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Azure Blob client: %w", err)
+	}
+
+	return client, nil
+}
+
+func (s *AzureBlobStorage) getServiceURL() string {
+	if s.Endpoint != "" {
+		return strings.TrimSuffix(s.Endpoint, "/") + "/"
+	}
+
+	return fmt.Sprintf("https://%s.blob.core.windows.net/", s.AccountName)
+}
+
+func (s *AzureBlobStorage) getBlobName(fileID uuid.UUID) string {
+	prefix := strings.Trim(s.Prefix, "/")
+	if prefix == "" {
+		return fileID.String()
+	}
+
+	return path.Join(prefix, fileID.String())
+}
+
+func (s *AzureBlobStorage) getAccessTier() blob.AccessTier {
+	switch s.AccessTier {
+	case AzureBlobAccessTierCool:
+		return blob.AccessTierCool
+	case AzureBlobAccessTierArchive:
+		return blob.AccessTierArchive
+	default:
+		return blob.AccessTierHot
+	}
+}
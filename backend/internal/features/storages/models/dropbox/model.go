@@ -0,0 +1,503 @@
+package dropbox_storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"postgresus-backend/internal/features/secrets"
+	"postgresus-backend/internal/util/logger"
+	"postgresus-backend/internal/util/oauth"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+var (
+	apiURL     = "https://api.dropboxapi.com/2"
+	contentURL = "https://content.dropboxapi.com/2"
+	tokenURL   = "https://api.dropboxapi.com/oauth2/token"
+
+	// chunks are kept in memory while uploaded. Files bigger than one
+	// chunk are uploaded with upload session, because single request
+	// upload is limited to 150 MB
+	uploadChunkSize = 16 * 1024 * 1024
+)
+
+// DropboxStorage keeps backups in the folder of Dropbox account. The
+// token must be issued for offline access, so it has refresh token
+type DropboxStorage struct {
+	StorageID uuid.UUID `json:"storageId" gorm:"primaryKey;type:uuid;column:storage_id"`
+	// app key and app secret of the Dropbox app
+	ClientID     string `json:"clientId"     gorm:"not null;type:text;column:client_id"`
+	ClientSecret string `json:"clientSecret" gorm:"not null;type:text;column:client_secret"`
+	TokenJSON    string `json:"tokenJson"    gorm:"not null;type:text;column:token_json"`
+	// folder of backups, e.g. /postgresus_backups
+	Path string `json:"path" gorm:"type:text;column:path"`
+
+	tokenRefreshListener func() error
+}
+
+func (d *DropboxStorage) TableName() string {
+	return "dropbox_storages"
+}
+
+func (d *DropboxStorage) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&d.ClientSecret, &d.TokenJSON)
+}
+
+func (d *DropboxStorage) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&d.ClientSecret, &d.TokenJSON)
+}
+
+func (d *DropboxStorage) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&d.ClientSecret, &d.TokenJSON)
+}
+
+func (d *DropboxStorage) HideSensitiveData() {
+	d.ClientSecret = ""
+	d.TokenJSON = ""
+}
+
+// FillSensitiveData keeps the stored app secret and token while
+// the Dropbox app stays the same
+func (d *DropboxStorage) FillSensitiveData(existing *DropboxStorage) {
+	if existing == nil || d.ClientID != existing.ClientID {
+		return
+	}
+
+	if d.ClientSecret == "" {
+		d.ClientSecret = existing.ClientSecret
+	}
+
+	if d.TokenJSON == "" {
+		d.TokenJSON = existing.TokenJSON
+	}
+}
+
+// SetTokenRefreshListener sets the function called after the token
+// is refreshed, when TokenJSON already holds the new token
+func (d *DropboxStorage) SetTokenRefreshListener(listener func() error) {
+	d.tokenRefreshListener = listener
+}
+
+func (d *DropboxStorage) SaveFile(logger *slog.Logger, fileID uuid.UUID, file io.Reader) error {
+	logger.Info("Starting to save file to Dropbox storage", "fileId", fileID.String())
+
+	ctx := context.Background()
+	filePath := d.getFilePath(fileID.String())
+
+	buffer := make([]byte, uploadChunkSize)
+
+	size, err := readChunk(file, buffer)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if size < len(buffer) {
+		err = d.upload(ctx, filePath, buffer[:size])
+	} else {
+		err = d.uploadBySession(ctx, filePath, file, buffer)
+	}
+
+	if err != nil {
+		logger.Error("Failed to upload file to Dropbox", "fileId", fileID.String(), "error", err)
+		return err
+	}
+
+	logger.Info(
+		"Successfully saved file to Dropbox storage",
+		"fileId",
+		fileID.String(),
+		"filePath",
+		filePath,
+	)
+	return nil
+}
+
+func (d *DropboxStorage) GetFile(fileID uuid.UUID) (io.ReadCloser, error) {
+	response, err := d.callContent(
+		context.Background(),
+		"/files/download",
+		pathArg{Path: d.getFilePath(fileID.String())},
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file from Dropbox: %w", err)
+	}
+
+	if response.StatusCode == http.StatusConflict {
+		summary := getErrorSummary(response)
+		if isNotFoundError(summary) {
+			return nil, fmt.Errorf("file not found: %s", fileID.String())
+		}
+
+		return nil, fmt.Errorf("failed to download file from Dropbox: %s", summary)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, getResponseError(response, "failed to download file from Dropbox")
+	}
+
+	return response.Body, nil
+}
+
+func (d *DropboxStorage) DeleteFile(fileID uuid.UUID) error {
+	response, err := d.callAPI(
+		context.Background(),
+		"/files/delete_v2",
+		pathArg{Path: d.getFilePath(fileID.String())},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete file from Dropbox: %w", err)
+	}
+
+	if response.StatusCode == http.StatusConflict {
+		summary := getErrorSummary(response)
+
+		// File doesn't exist, consider it already deleted
+		if isNotFoundError(summary) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to delete file from Dropbox: %s", summary)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return getResponseError(response, "failed to delete file from Dropbox")
+	}
+	_ = response.Body.Close()
+
+	return nil
+}
+
+func (d *DropboxStorage) Validate() error {
+	switch {
+	case d.ClientID == "":
+		return errors.New("app key is required")
+	case d.ClientSecret == "":
+		return errors.New("app secret is required")
+	case d.TokenJSON == "":
+		return errors.New("token JSON is required")
+	}
+
+	token, err := oauth.ParseToken(d.TokenJSON)
+	if err != nil {
+		return err
+	}
+
+	if token.RefreshToken == "" {
+		return errors.New(
+			"token JSON must contain a refresh token, authorize with token_access_type=offline",
+		)
+	}
+
+	return nil
+}
+
+func (d *DropboxStorage) TestConnection() error {
+	ctx := context.Background()
+	testFilePath := d.getFilePath("test-connection-" + uuid.New().String())
+
+	if err := d.upload(ctx, testFilePath, []byte("test")); err != nil {
+		return fmt.Errorf("failed to write test file to Dropbox: %w", err)
+	}
+
+	response, err := d.callAPI(ctx, "/files/delete_v2", pathArg{Path: testFilePath})
+	if err != nil {
+		return fmt.Errorf("failed to delete test file from Dropbox: %w", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return getResponseError(response, "failed to delete test file from Dropbox")
+	}
+	_ = response.Body.Close()
+
+	return nil
+}
+
+func (d *DropboxStorage) upload(ctx context.Context, filePath string, data []byte) error {
+	response, err := d.callContent(ctx, "/files/upload", newCommitInfo(filePath), data)
+	if err != nil {
+		return fmt.Errorf("failed to upload file to Dropbox: %w", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return getResponseError(response, "failed to upload file to Dropbox")
+	}
+	_ = response.Body.Close()
+
+	return nil
+}
+
+// uploadBySession uploads the file chunk by chunk, the buffer already
+// holds the first chunk. Unfinished sessions expire on Dropbox side
+func (d *DropboxStorage) uploadBySession(
+	ctx context.Context,
+	filePath string,
+	file io.Reader,
+	buffer []byte,
+) error {
+	response, err := d.callContent(ctx, "/files/upload_session/start", struct{}{}, buffer)
+	if err != nil {
+		return fmt.Errorf("failed to start upload session: %w", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return getResponseError(response, "failed to start upload session")
+	}
+
+	var session struct {
+		SessionID string `json:"session_id"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&session)
+	_ = response.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to decode upload session: %w", err)
+	}
+
+	cursor := uploadSessionCursor{SessionID: session.SessionID, Offset: int64(len(buffer))}
+
+	for {
+		size, err := readChunk(file, buffer)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+
+		// the last chunk is sent together with the commit
+		if size < len(buffer) {
+			return d.finishUploadSession(ctx, cursor, filePath, buffer[:size])
+		}
+
+		response, err := d.callContent(
+			ctx,
+			"/files/upload_session/append_v2",
+			map[string]any{"cursor": cursor},
+			buffer,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to upload chunk at offset %d: %w", cursor.Offset, err)
+		}
+
+		if response.StatusCode != http.StatusOK {
+			return getResponseError(
+				response,
+				fmt.Sprintf("failed to upload chunk at offset %d", cursor.Offset),
+			)
+		}
+		_ = response.Body.Close()
+
+		cursor.Offset += int64(size)
+	}
+}
+
+func (d *DropboxStorage) finishUploadSession(
+	ctx context.Context,
+	cursor uploadSessionCursor,
+	filePath string,
+	data []byte,
+) error {
+	response, err := d.callContent(
+		ctx,
+		"/files/upload_session/finish",
+		map[string]any{"cursor": cursor, "commit": newCommitInfo(filePath)},
+		data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish upload session: %w", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return getResponseError(response, "failed to finish upload session")
+	}
+	_ = response.Body.Close()
+
+	return nil
+}
+
+// callContent calls content endpoint, arguments are passed in
+// Dropbox-API-Arg header and the body holds file data
+func (d *DropboxStorage) callContent(
+	ctx context.Context,
+	endpoint string,
+	arg any,
+	data []byte,
+) (*http.Response, error) {
+	headerArg, err := marshalHeaderArg(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.getTokenRefresher().Do(func() (*http.Request, error) {
+		var body io.Reader
+		if data != nil {
+			body = bytes.NewReader(data)
+		}
+
+		request, err := http.NewRequestWithContext(
+			ctx,
+			http.MethodPost,
+			contentURL+endpoint,
+			body,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		request.Header.Set("Dropbox-API-Arg", headerArg)
+		if data != nil {
+			request.Header.Set("Content-Type", "application/octet-stream")
+		}
+
+		return request, nil
+	})
+}
+
+// callAPI calls RPC endpoint with JSON arguments in the body
+func (d *DropboxStorage) callAPI(
+	ctx context.Context,
+	endpoint string,
+	arg any,
+) (*http.Response, error) {
+	argJSON, err := json.Marshal(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.getTokenRefresher().Do(func() (*http.Request, error) {
+		request, err := http.NewRequestWithContext(
+			ctx,
+			http.MethodPost,
+			apiURL+endpoint,
+			bytes.NewReader(argJSON),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		request.Header.Set("Content-Type", "application/json")
+
+		return request, nil
+	})
+}
+
+// getTokenRefresher returns the refresher updating TokenJSON of the
+// storage. It is created on each call, so changed fields are used
+func (d *DropboxStorage) getTokenRefresher() *oauth.TokenRefresher {
+	return &oauth.TokenRefresher{
+		Config: &oauth2.Config{
+			ClientID:     d.ClientID,
+			ClientSecret: d.ClientSecret,
+			Endpoint:     oauth2.Endpoint{TokenURL: tokenURL, AuthStyle: oauth2.AuthStyleInParams},
+		},
+		TokenJSON:       &d.TokenJSON,
+		RefreshListener: d.tokenRefreshListener,
+		Logger: logger.GetLogger().With(
+			"storage", "Dropbox",
+			"storageId", d.StorageID.String(),
+		),
+	}
+}
+
+func (d *DropboxStorage) getFilePath(filename string) string {
+	return path.Join("/", d.Path, filename)
+}
+
+// readChunk fills the buffer, the chunk is shorter
+// than the buffer only at the end of the file
+func readChunk(file io.Reader, buffer []byte) (int, error) {
+	size, err := io.ReadFull(file, buffer)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return size, nil
+	}
+
+	return size, err
+}
+
+// marshalHeaderArg encodes arguments for Dropbox-API-Arg header,
+// characters outside of ASCII are escaped as the header requires
+func marshalHeaderArg(arg any) (string, error) {
+	argJSON, err := json.Marshal(arg)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	for _, char := range string(argJSON) {
+		if char < 0x7f {
+			builder.WriteRune(char)
+			continue
+		}
+
+		for _, unit := range utf16.Encode([]rune{char}) {
+			fmt.Fprintf(&builder, `\u%04x`, unit)
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// getErrorSummary reads summary of the endpoint error, e.g.
+// "path/not_found/..", and closes the response
+func getErrorSummary(response *http.Response) string {
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+
+	var apiError struct {
+		ErrorSummary string `json:"error_summary"`
+	}
+	if err := json.Unmarshal(body, &apiError); err == nil && apiError.ErrorSummary != "" {
+		return apiError.ErrorSummary
+	}
+
+	return strings.TrimSpace(string(body))
+}
+
+func getResponseError(response *http.Response, message string) error {
+	status := response.Status
+	summary := getErrorSummary(response)
+
+	if response.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%s: access token was rejected: %s", message, summary)
+	}
+
+	if summary == "" {
+		return fmt.Errorf("%s: %s", message, status)
+	}
+
+	return fmt.Errorf("%s: %s: %s", message, status, summary)
+}
+
+func isNotFoundError(summary string) bool {
+	return strings.Contains(summary, "/not_found")
+}
+
+type pathArg struct {
+	Path string `json:"path"`
+}
+
+type commitInfo struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	Mute bool   `json:"mute"`
+}
+
+func newCommitInfo(filePath string) commitInfo {
+	return commitInfo{Path: filePath, Mode: "overwrite", Mute: true}
+}
+
+type uploadSessionCursor struct {
+	SessionID string `json:"session_id"`
+	Offset    int64  `json:"offset"`
+}
@@ -0,0 +1,287 @@
+package dropbox_storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"postgresus-backend/internal/util/logger"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+const (
+	testClientID     = "test-app-key"
+	testClientSecret = "test-app-secret"
+	testRefreshToken = "test-refresh-token"
+)
+
+func Test_SaveFileBySession_FileAssembledFromChunks(t *testing.T) {
+	previousChunkSize := uploadChunkSize
+	uploadChunkSize = 1024
+	t.Cleanup(func() { uploadChunkSize = previousChunkSize })
+
+	server := startTestDropboxServer(t)
+	storage := createTestStorage(t, server, "valid-token", time.Now().Add(time.Hour))
+
+	fileData := bytes.Repeat([]byte("0123456789"), 500)
+	fileID := uuid.New()
+
+	err := storage.SaveFile(logger.GetLogger(), fileID, bytes.NewReader(fileData))
+	require.NoError(t, err)
+
+	assert.Equal(t, fileData, server.files["/backups/postgres/"+fileID.String()])
+	assert.Equal(t, 1, server.sessionsFinished)
+}
+
+func Test_TestConnectionWithExpiredToken_TokenRefreshedAndSaved(t *testing.T) {
+	server := startTestDropboxServer(t)
+	storage := createTestStorage(t, server, "expired-token", time.Now().Add(-time.Hour))
+
+	var savedTokenJSON string
+	storage.SetTokenRefreshListener(func() error {
+		savedTokenJSON = storage.TokenJSON
+		return nil
+	})
+
+	require.NoError(t, storage.TestConnection())
+
+	token := parseToken(t, savedTokenJSON)
+	assert.Equal(t, "valid-token", token.AccessToken)
+	// Dropbox does not return refresh token, the stored one is kept
+	assert.Equal(t, testRefreshToken, token.RefreshToken)
+	assert.Equal(t, 1, server.refreshCount)
+}
+
+func Test_GetFileWithRevokedToken_TokenRefreshedAndRequestRetried(t *testing.T) {
+	server := startTestDropboxServer(t)
+	storage := createTestStorage(t, server, "valid-token", time.Now().Add(time.Hour))
+
+	fileID := uuid.New()
+	err := storage.SaveFile(logger.GetLogger(), fileID, bytes.NewReader([]byte("test")))
+	require.NoError(t, err)
+
+	// token is not expired yet, but the server rejects it
+	storage.TokenJSON = createTokenJSON(t, "revoked-token", time.Now().Add(time.Hour))
+
+	file, err := storage.GetFile(fileID)
+	require.NoError(t, err)
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("test"), content)
+
+	assert.Equal(t, "valid-token", parseToken(t, storage.TokenJSON).AccessToken)
+	assert.Equal(t, 1, server.refreshCount)
+}
+
+func Test_ValidateWithoutRefreshToken_ReturnsError(t *testing.T) {
+	storage := &DropboxStorage{
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		TokenJSON:    `{"access_token":"valid-token"}`,
+	}
+
+	assert.ErrorContains(t, storage.Validate(), "token JSON must contain a refresh token")
+}
+
+func Test_MarshalHeaderArg_NonASCIICharactersEscaped(t *testing.T) {
+	headerArg, err := marshalHeaderArg(pathArg{Path: "/бэкапы/🐘"})
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		`{"path":"/\u0431\u044d\u043a\u0430\u043f\u044b/\ud83d\udc18"}`,
+		headerArg,
+	)
+
+	var decoded pathArg
+	require.NoError(t, json.Unmarshal([]byte(headerArg), &decoded))
+	assert.Equal(t, "/бэкапы/🐘", decoded.Path)
+}
+
+func createTestStorage(
+	t *testing.T,
+	server *testDropboxServer,
+	accessToken string,
+	expiry time.Time,
+) *DropboxStorage {
+	previousAPIURL, previousContentURL, previousTokenURL := apiURL, contentURL, tokenURL
+	apiURL = server.URL + "/2"
+	contentURL = server.URL + "/2"
+	tokenURL = server.URL + "/oauth2/token"
+	t.Cleanup(func() {
+		apiURL, contentURL, tokenURL = previousAPIURL, previousContentURL, previousTokenURL
+	})
+
+	return &DropboxStorage{
+		StorageID:    uuid.New(),
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		TokenJSON:    createTokenJSON(t, accessToken, expiry),
+		Path:         "backups/postgres",
+	}
+}
+
+func createTokenJSON(t *testing.T, accessToken string, expiry time.Time) string {
+	tokenJSON, err := json.Marshal(&oauth2.Token{
+		AccessToken:  accessToken,
+		TokenType:    "bearer",
+		RefreshToken: testRefreshToken,
+		Expiry:       expiry,
+	})
+	require.NoError(t, err)
+
+	return string(tokenJSON)
+}
+
+func parseToken(t *testing.T, tokenJSON string) *oauth2.Token {
+	var token oauth2.Token
+	require.NoError(t, json.Unmarshal([]byte(tokenJSON), &token))
+
+	return &token
+}
+
+// testDropboxServer keeps files in memory and accepts only
+// "valid-token" issued by its token endpoint
+type testDropboxServer struct {
+	*httptest.Server
+
+	mu               sync.Mutex
+	files            map[string][]byte
+	sessions         map[string][]byte
+	sessionsFinished int
+	refreshCount     int
+}
+
+func startTestDropboxServer(t *testing.T) *testDropboxServer {
+	server := &testDropboxServer{
+		files:    make(map[string][]byte),
+		sessions: make(map[string][]byte),
+	}
+
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (s *testDropboxServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/oauth2/token" {
+		s.handleToken(w, r)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer valid-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error_summary": "expired_access_token/"}`))
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+
+	var arg struct {
+		Path   string `json:"path"`
+		Cursor struct {
+			SessionID string `json:"session_id"`
+			Offset    int    `json:"offset"`
+		} `json:"cursor"`
+		Commit struct {
+			Path string `json:"path"`
+		} `json:"commit"`
+	}
+
+	headerArg := r.Header.Get("Dropbox-API-Arg")
+	if headerArg == "" {
+		_ = json.Unmarshal(body, &arg)
+	} else {
+		_ = json.Unmarshal([]byte(headerArg), &arg)
+	}
+
+	switch r.URL.Path {
+	case "/2/files/upload":
+		s.files[arg.Path] = body
+		writeJSON(w, map[string]string{"path_display": arg.Path})
+	case "/2/files/upload_session/start":
+		sessionID := uuid.New().String()
+		s.sessions[sessionID] = body
+		writeJSON(w, map[string]string{"session_id": sessionID})
+	case "/2/files/upload_session/append_v2", "/2/files/upload_session/finish":
+		session, ok := s.sessions[arg.Cursor.SessionID]
+		if !ok || len(session) != arg.Cursor.Offset {
+			writeConflict(w, "incorrect_offset/")
+			return
+		}
+
+		s.sessions[arg.Cursor.SessionID] = append(session, body...)
+
+		if strings.HasSuffix(r.URL.Path, "/finish") {
+			s.files[arg.Commit.Path] = s.sessions[arg.Cursor.SessionID]
+			delete(s.sessions, arg.Cursor.SessionID)
+			s.sessionsFinished++
+		}
+
+		writeJSON(w, map[string]string{})
+	case "/2/files/download":
+		file, ok := s.files[arg.Path]
+		if !ok {
+			writeConflict(w, "path/not_found/..")
+			return
+		}
+
+		_, _ = w.Write(file)
+	case "/2/files/delete_v2":
+		if _, ok := s.files[arg.Path]; !ok {
+			writeConflict(w, "path_lookup/not_found/..")
+			return
+		}
+
+		delete(s.files, arg.Path)
+		writeJSON(w, map[string]string{})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *testDropboxServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("grant_type") != "refresh_token" ||
+		r.FormValue("refresh_token") != testRefreshToken ||
+		r.FormValue("client_id") != testClientID ||
+		r.FormValue("client_secret") != testClientSecret {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	s.refreshCount++
+
+	writeJSON(w, map[string]any{
+		"access_token": "valid-token",
+		"token_type":   "bearer",
+		"expires_in":   14400,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeConflict(w http.ResponseWriter, summary string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	_, _ = fmt.Fprintf(w, `{"error_summary": %q}`, summary)
+}
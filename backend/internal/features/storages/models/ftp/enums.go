@@ -0,0 +1,11 @@
+package ftp_storage
+
+type FTPTLSMode string
+
+const (
+	FTPTLSModeNone FTPTLSMode = "NONE"
+	// AUTH TLS on the plain port, usually 21
+	FTPTLSModeExplicit FTPTLSMode = "EXPLICIT"
+	// TLS from the first byte, usually on port 990
+	FTPTLSModeImplicit FTPTLSMode = "IMPLICIT"
+)
@@ -0,0 +1,301 @@
+package ftp_storage
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/textproto"
+	"path"
+	"postgresus-backend/internal/features/secrets"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jlaffaye/ftp"
+	"gorm.io/gorm"
+)
+
+// FTPStorage keeps backups on FTP or FTPS server. Transfers always
+// use passive mode: EPSV is tried first and PASV is used if the
+// server does not support it
+type FTPStorage struct {
+	StorageID     uuid.UUID  `json:"storageId"     gorm:"primaryKey;type:uuid;column:storage_id"`
+	Host          string     `json:"host"          gorm:"not null;type:text;column:host"`
+	Port          int        `json:"port"          gorm:"not null;default:21;column:port"`
+	Username      string     `json:"username"      gorm:"not null;type:text;column:username"`
+	Password      string     `json:"password"      gorm:"type:text;column:password"`
+	TLSMode       FTPTLSMode `json:"tlsMode"       gorm:"not null;type:text;default:'NONE';column:tls_mode"`
+	SkipTLSVerify bool       `json:"skipTlsVerify" gorm:"not null;default:false;column:skip_tls_verify"`
+	Path          string     `json:"path"          gorm:"type:text;column:path"`
+}
+
+func (f *FTPStorage) TableName() string {
+	return "ftp_storages"
+}
+
+func (f *FTPStorage) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&f.Password)
+}
+
+func (f *FTPStorage) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&f.Password)
+}
+
+func (f *FTPStorage) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&f.Password)
+}
+
+func (f *FTPStorage) HideSensitiveData() {
+	f.Password = ""
+}
+
+// FillSensitiveData keeps the stored password for the same server
+// host and user
+func (f *FTPStorage) FillSensitiveData(existing *FTPStorage) {
+	if existing == nil || f.Password != "" {
+		return
+	}
+
+	if f.Host == existing.Host && f.Port == existing.Port && f.Username == existing.Username {
+		f.Password = existing.Password
+	}
+}
+
+func (f *FTPStorage) SaveFile(logger *slog.Logger, fileID uuid.UUID, file io.Reader) error {
+	logger.Info("Starting to save file to FTP storage", "fileId", fileID.String(), "host", f.Host)
+
+	conn, err := f.connect()
+	if err != nil {
+		logger.Error("Failed to connect to FTP server", "fileId", fileID.String(), "error", err)
+		return err
+	}
+	defer func() {
+		_ = conn.Quit()
+	}()
+
+	if f.Path != "" {
+		if err := f.ensureDirectory(conn); err != nil {
+			logger.Error(
+				"Failed to ensure directory",
+				"fileId",
+				fileID.String(),
+				"path",
+				f.Path,
+				"error",
+				err,
+			)
+			return fmt.Errorf("failed to ensure directory: %w", err)
+		}
+	}
+
+	filePath := f.getFilePath(fileID.String())
+
+	// file is streamed through the data connection
+	// without being buffered
+	if err := conn.Stor(filePath, file); err != nil {
+		logger.Error("Failed to write file to FTP server", "fileId", fileID.String(), "error", err)
+
+		// do not leave partially uploaded file
+		_ = conn.Delete(filePath)
+
+		return fmt.Errorf("failed to write file to FTP server: %w", err)
+	}
+
+	logger.Info(
+		"Successfully saved file to FTP storage",
+		"fileId",
+		fileID.String(),
+		"filePath",
+		filePath,
+	)
+	return nil
+}
+
+func (f *FTPStorage) GetFile(fileID uuid.UUID) (io.ReadCloser, error) {
+	conn, err := f.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := conn.Retr(f.getFilePath(fileID.String()))
+	if err != nil {
+		_ = conn.Quit()
+
+		if isFileUnavailableError(err) {
+			return nil, fmt.Errorf("file not found: %s", fileID.String())
+		}
+
+		return nil, fmt.Errorf("failed to open file from FTP server: %w", err)
+	}
+
+	return &ftpFileReader{response: response, conn: conn}, nil
+}
+
+func (f *FTPStorage) DeleteFile(fileID uuid.UUID) error {
+	conn, err := f.connect()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Quit()
+	}()
+
+	err = conn.Delete(f.getFilePath(fileID.String()))
+	if err != nil {
+		// File doesn't exist, consider it already deleted
+		if isFileUnavailableError(err) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to delete file from FTP server: %w", err)
+	}
+
+	return nil
+}
+
+func (f *FTPStorage) Validate() error {
+	if f.Host == "" {
+		return errors.New("FTP host is required")
+	}
+	if f.Username == "" {
+		return errors.New("FTP username is required")
+	}
+	if f.Port <= 0 || f.Port > 65535 {
+		return errors.New("FTP port must be between 1 and 65535")
+	}
+
+	switch f.TLSMode {
+	case "", FTPTLSModeNone, FTPTLSModeExplicit, FTPTLSModeImplicit:
+	default:
+		return fmt.Errorf("unsupported FTP TLS mode: %s", f.TLSMode)
+	}
+
+	// Test the configuration by logging in
+	return f.TestConnection()
+}
+
+func (f *FTPStorage) TestConnection() error {
+	conn, err := f.connect()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Quit()
+	}()
+
+	// If path is specified, check if it exists or can be created
+	if f.Path != "" {
+		if err := f.ensureDirectory(conn); err != nil {
+			return fmt.Errorf("failed to access or create path '%s': %w", f.Path, err)
+		}
+	}
+
+	return nil
+}
+
+func (f *FTPStorage) connect() (*ftp.ServerConn, error) {
+	address := net.JoinHostPort(f.Host, strconv.Itoa(f.Port))
+
+	options := []ftp.DialOption{ftp.DialWithTimeout(10 * time.Second)}
+
+	tlsConfig := &tls.Config{
+		ServerName:         f.Host,
+		InsecureSkipVerify: f.SkipTLSVerify,
+		// servers often require data connections to
+		// resume TLS session of the control connection
+		ClientSessionCache: tls.NewLRUClientSessionCache(0),
+	}
+
+	switch f.TLSMode {
+	case FTPTLSModeExplicit:
+		options = append(options, ftp.DialWithExplicitTLS(tlsConfig))
+	case FTPTLSModeImplicit:
+		options = append(options, ftp.DialWithTLS(tlsConfig))
+	}
+
+	conn, err := ftp.Dial(address, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to FTP server %s: %w", address, err)
+	}
+
+	if err := conn.Login(f.Username, f.Password); err != nil {
+		_ = conn.Quit()
+		return nil, fmt.Errorf("failed to login to FTP server: %w", err)
+	}
+
+	return conn, nil
+}
+
+// ensureDirectory creates missing directories of the path one by one,
+// because FTP has no command to create the parents
+func (f *FTPStorage) ensureDirectory(conn *ftp.ServerConn) error {
+	cleanPath := path.Clean(f.Path)
+
+	currentDir, err := conn.CurrentDir()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	currentPath := ""
+	if strings.HasPrefix(cleanPath, "/") {
+		currentPath = "/"
+	}
+
+	for _, part := range strings.Split(cleanPath, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+
+		currentPath = path.Join(currentPath, part)
+
+		if err := conn.MakeDir(currentPath); err == nil {
+			continue
+		}
+
+		// MakeDir fails for existing directories as well
+		if err := conn.ChangeDir(currentPath); err != nil {
+			return fmt.Errorf("failed to create directory '%s': %w", currentPath, err)
+		}
+
+		if err := conn.ChangeDir(currentDir); err != nil {
+			return fmt.Errorf("failed to change directory: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (f *FTPStorage) getFilePath(filename string) string {
+	if f.Path == "" {
+		return filename
+	}
+
+	return path.Join(path.Clean(f.Path), filename)
+}
+
+func isFileUnavailableError(err error) bool {
+	var protocolErr *textproto.Error
+
+	return errors.As(err, &protocolErr) && protocolErr.Code == ftp.StatusFileUnavailable
+}
+
+// ftpFileReader ends the FTP session when the file is closed
+type ftpFileReader struct {
+	response *ftp.Response
+	conn     *ftp.ServerConn
+}
+
+func (r *ftpFileReader) Read(p []byte) (int, error) {
+	return r.response.Read(p)
+}
+
+func (r *ftpFileReader) Close() error {
+	err := r.response.Close()
+	_ = r.conn.Quit()
+
+	return err
+}
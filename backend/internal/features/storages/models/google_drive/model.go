@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"postgresus-backend/internal/features/secrets"
+	"postgresus-backend/internal/util/logger"
+	"postgresus-backend/internal/util/oauth"
 	"strings"
-	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
+	"gorm.io/gorm"
 
 	drive "google.golang.org/api/drive/v3"
 	"google.golang.org/api/option"
@@ -23,12 +26,53 @@ type GoogleDriveStorage struct {
 	ClientID     string    `json:"clientId"     gorm:"not null;type:text;column:client_id"`
 	ClientSecret string    `json:"clientSecret" gorm:"not null;type:text;column:client_secret"`
 	TokenJSON    string    `json:"tokenJson"    gorm:"not null;type:text;column:token_json"`
+
+	tokenRefreshListener func() error
 }
 
 func (s *GoogleDriveStorage) TableName() string {
 	return "google_drive_storages"
 }
 
+func (s *GoogleDriveStorage) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&s.ClientSecret, &s.TokenJSON)
+}
+
+func (s *GoogleDriveStorage) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&s.ClientSecret, &s.TokenJSON)
+}
+
+func (s *GoogleDriveStorage) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&s.ClientSecret, &s.TokenJSON)
+}
+
+func (s *GoogleDriveStorage) HideSensitiveData() {
+	s.ClientSecret = ""
+	s.TokenJSON = ""
+}
+
+// FillSensitiveData keeps the stored client secret and token while
+// the OAuth client stays the same
+func (s *GoogleDriveStorage) FillSensitiveData(existing *GoogleDriveStorage) {
+	if existing == nil || s.ClientID != existing.ClientID {
+		return
+	}
+
+	if s.ClientSecret == "" {
+		s.ClientSecret = existing.ClientSecret
+	}
+
+	if s.TokenJSON == "" {
+		s.TokenJSON = existing.TokenJSON
+	}
+}
+
+// SetTokenRefreshListener sets the function called after the token
+// is refreshed, when TokenJSON already holds the new token
+func (s *GoogleDriveStorage) SetTokenRefreshListener(listener func() error) {
+	s.tokenRefreshListener = listener
+}
+
 func (s *GoogleDriveStorage) SaveFile(
 	logger *slog.Logger,
 	fileID uuid.UUID,
@@ -203,7 +247,7 @@ func (s *GoogleDriveStorage) withRetryOnAuth(fn func(*drive.Service) error) erro
 		// Try to refresh token and retry once
 		fmt.Printf("Google Drive auth error detected, attempting token refresh: %v\n", err)
 
-		if refreshErr := s.refreshToken(); refreshErr != nil {
+		if _, refreshErr := s.getTokenRefresher().RefreshToken(); refreshErr != nil {
 			// If refresh fails, return a more helpful error message
 			if strings.Contains(refreshErr.Error(), "invalid_grant") ||
 				strings.Contains(refreshErr.Error(), "refresh token") {
@@ -250,105 +294,23 @@ func (s *GoogleDriveStorage) isAuthError(err error) bool {
 		strings.Contains(errStr, "invalid authentication credentials")
 }
 
-// refreshToken refreshes the OAuth2 token and updates the TokenJSON field
-func (s *GoogleDriveStorage) refreshToken() error {
-	if err := s.Validate(); err != nil {
-		return err
-	}
-
-	var token oauth2.Token
-	if err := json.Unmarshal([]byte(s.TokenJSON), &token); err != nil {
-		return fmt.Errorf("invalid token JSON: %w", err)
-	}
-
-	// Check if we have a refresh token
-	if token.RefreshToken == "" {
-		return fmt.Errorf("no refresh token available in stored token")
-	}
-
-	fmt.Printf("Original token - Access Token: %s..., Refresh Token: %s..., Expiry: %v\n",
-		truncateString(token.AccessToken, 20),
-		truncateString(token.RefreshToken, 20),
-		token.Expiry)
-
-	// Debug: Print the full token JSON structure (sensitive data masked)
-	fmt.Printf("Original token JSON structure: %s\n", maskSensitiveData(s.TokenJSON))
-
-	ctx := context.Background()
-	cfg := &oauth2.Config{
-		ClientID:     s.ClientID,
-		ClientSecret: s.ClientSecret,
-		Endpoint:     google.Endpoint,
-		Scopes:       []string{"https://www.googleapis.com/auth/drive.file"},
-	}
-
-	// Force the token to be expired so refresh is guaranteed
-	token.Expiry = time.Now().Add(-time.Hour)
-	fmt.Printf("Forcing token expiry to trigger refresh: %v\n", token.Expiry)
-
-	tokenSource := cfg.TokenSource(ctx, &token)
-
-	// Force token refresh
-	fmt.Printf("Attempting to refresh Google Drive token...\n")
-	newToken, err := tokenSource.Token()
-	if err != nil {
-		return fmt.Errorf("failed to refresh token: %w", err)
-	}
-
-	fmt.Printf("New token - Access Token: %s..., Refresh Token: %s..., Expiry: %v\n",
-		truncateString(newToken.AccessToken, 20),
-		truncateString(newToken.RefreshToken, 20),
-		newToken.Expiry)
-
-	// Check if we actually got a new token
-	if newToken.AccessToken == token.AccessToken {
-		return fmt.Errorf(
-			"token refresh did not return a new access token - this indicates the refresh token may be invalid",
-		)
-	}
-
-	// Ensure the new token has a refresh token (preserve the original if not returned)
-	if newToken.RefreshToken == "" {
-		fmt.Printf("New token doesn't have refresh token, preserving original\n")
-		newToken.RefreshToken = token.RefreshToken
-	}
-
-	// Update the stored token JSON
-	newTokenJSON, err := json.Marshal(newToken)
-	if err != nil {
-		return fmt.Errorf("failed to marshal refreshed token: %w", err)
-	}
-
-	s.TokenJSON = string(newTokenJSON)
-	fmt.Printf("Token refresh completed successfully with new access token\n")
-	return nil
-}
-
-// maskSensitiveData masks sensitive information in token JSON for logging
-func maskSensitiveData(tokenJSON string) string {
-	// Replace sensitive values with masked versions
-	var data map[string]interface{}
-	if err := json.Unmarshal([]byte(tokenJSON), &data); err != nil {
-		return "invalid JSON"
-	}
-
-	if accessToken, ok := data["access_token"].(string); ok && len(accessToken) > 10 {
-		data["access_token"] = accessToken[:10] + "..."
-	}
-	if refreshToken, ok := data["refresh_token"].(string); ok && len(refreshToken) > 10 {
-		data["refresh_token"] = refreshToken[:10] + "..."
-	}
-
-	masked, _ := json.Marshal(data)
-	return string(masked)
-}
-
-// truncateString safely truncates a string for logging purposes
-func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+// getTokenRefresher returns the refresher updating TokenJSON of the
+// storage. It is created on each call, so changed fields are used
+func (s *GoogleDriveStorage) getTokenRefresher() *oauth.TokenRefresher {
+	return &oauth.TokenRefresher{
+		Config: &oauth2.Config{
+			ClientID:     s.ClientID,
+			ClientSecret: s.ClientSecret,
+			Endpoint:     google.Endpoint,
+			Scopes:       []string{"https://www.googleapis.com/auth/drive.file"},
+		},
+		TokenJSON:       &s.TokenJSON,
+		RefreshListener: s.tokenRefreshListener,
+		Logger: logger.GetLogger().With(
+			"storage", "Google Drive",
+			"storageId", s.StorageID.String(),
+		),
 	}
-	return s[:maxLen]
 }
 
 func (s *GoogleDriveStorage) getDriveService() (*drive.Service, error) {
@@ -356,32 +318,16 @@ func (s *GoogleDriveStorage) getDriveService() (*drive.Service, error) {
 		return nil, err
 	}
 
-	var token oauth2.Token
-	if err := json.Unmarshal([]byte(s.TokenJSON), &token); err != nil {
-		return nil, fmt.Errorf("invalid token JSON: %w", err)
-	}
-
-	ctx := context.Background()
-
-	cfg := &oauth2.Config{
-		ClientID:     s.ClientID,
-		ClientSecret: s.ClientSecret,
-		Endpoint:     google.Endpoint,
-		Scopes:       []string{"https://www.googleapis.com/auth/drive.file"},
-	}
-
-	tokenSource := cfg.TokenSource(ctx, &token)
-
-	// Force token validation to ensure we're using the current token
-	currentToken, err := tokenSource.Token()
+	// expired token is refreshed and saved beforehand
+	accessToken, err := s.getTokenRefresher().GetAccessToken()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get current token: %w", err)
+		return nil, err
 	}
 
-	// Create a new token source with the validated token
-	validatedTokenSource := oauth2.StaticTokenSource(currentToken)
-
-	driveService, err := drive.NewService(ctx, option.WithTokenSource(validatedTokenSource))
+	driveService, err := drive.NewService(
+		context.Background(),
+		option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken})),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create Drive client: %w", err)
 	}
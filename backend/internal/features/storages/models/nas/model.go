@@ -8,11 +8,13 @@ import (
 	"log/slog"
 	"net"
 	"path/filepath"
+	"postgresus-backend/internal/features/secrets"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hirochachacha/go-smb2"
+	"gorm.io/gorm"
 )
 
 type NASStorage struct {
@@ -31,6 +33,34 @@ func (n *NASStorage) TableName() string {
 	return "nas_storages"
 }
 
+func (n *NASStorage) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&n.Password)
+}
+
+func (n *NASStorage) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&n.Password)
+}
+
+func (n *NASStorage) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&n.Password)
+}
+
+func (n *NASStorage) HideSensitiveData() {
+	n.Password = ""
+}
+
+// FillSensitiveData keeps the stored password for the same share
+// host and user
+func (n *NASStorage) FillSensitiveData(existing *NASStorage) {
+	if existing == nil || n.Password != "" {
+		return
+	}
+
+	if n.Host == existing.Host && n.Port == existing.Port && n.Username == existing.Username {
+		n.Password = existing.Password
+	}
+}
+
 func (n *NASStorage) SaveFile(logger *slog.Logger, fileID uuid.UUID, file io.Reader) error {
 	logger.Info("Starting to save file to NAS storage", "fileId", fileID.String(), "host", n.Host)
 
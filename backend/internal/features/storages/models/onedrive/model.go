@@ -0,0 +1,459 @@
+package onedrive_storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/secrets"
+	"postgresus-backend/internal/util/logger"
+	"postgresus-backend/internal/util/oauth"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+var (
+	graphURL = "https://graph.microsoft.com/v1.0"
+	tokenURL = "https://login.microsoftonline.com/common/oauth2/v2.0/token"
+
+	// files up to the limit are uploaded by single request,
+	// bigger ones with upload session
+	simpleUploadLimit int64 = 4 * 1024 * 1024
+
+	// chunks of upload session must be multiple of 320 KiB
+	uploadChunkSize int64 = 32 * 320 * 1024
+
+	getTempFolder = func() string {
+		return config.GetEnv().TempFolder
+	}
+)
+
+// OneDriveStorage keeps backups in the folder of OneDrive of the user
+// who authorized the app. The token must be issued with offline_access
+// scope, so it has refresh token
+type OneDriveStorage struct {
+	StorageID uuid.UUID `json:"storageId" gorm:"primaryKey;type:uuid;column:storage_id"`
+	// application (client) ID and client secret of Microsoft Entra app
+	ClientID     string `json:"clientId"     gorm:"not null;type:text;column:client_id"`
+	ClientSecret string `json:"clientSecret" gorm:"not null;type:text;column:client_secret"`
+	TokenJSON    string `json:"tokenJson"    gorm:"not null;type:text;column:token_json"`
+	// folder of backups relative to the drive root, e.g. postgresus_backups
+	Path string `json:"path" gorm:"type:text;column:path"`
+
+	tokenRefreshListener func() error
+}
+
+func (o *OneDriveStorage) TableName() string {
+	return "onedrive_storages"
+}
+
+func (o *OneDriveStorage) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&o.ClientSecret, &o.TokenJSON)
+}
+
+func (o *OneDriveStorage) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&o.ClientSecret, &o.TokenJSON)
+}
+
+func (o *OneDriveStorage) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&o.ClientSecret, &o.TokenJSON)
+}
+
+func (o *OneDriveStorage) HideSensitiveData() {
+	o.ClientSecret = ""
+	o.TokenJSON = ""
+}
+
+// FillSensitiveData keeps the stored client secret and token while
+// the app stays the same
+func (o *OneDriveStorage) FillSensitiveData(existing *OneDriveStorage) {
+	if existing == nil || o.ClientID != existing.ClientID {
+		return
+	}
+
+	if o.ClientSecret == "" {
+		o.ClientSecret = existing.ClientSecret
+	}
+
+	if o.TokenJSON == "" {
+		o.TokenJSON = existing.TokenJSON
+	}
+}
+
+// SetTokenRefreshListener sets the function called after the token is
+// refreshed. Microsoft rotates refresh tokens, so the listener should
+// save TokenJSON
+func (o *OneDriveStorage) SetTokenRefreshListener(listener func() error) {
+	o.tokenRefreshListener = listener
+}
+
+func (o *OneDriveStorage) SaveFile(logger *slog.Logger, fileID uuid.UUID, file io.Reader) error {
+	logger.Info("Starting to save file to OneDrive storage", "fileId", fileID.String())
+
+	// upload session requires the total size in advance while
+	// the backup is streamed, so it is written to temp file first
+	tempFile, err := os.CreateTemp(getTempFolder(), "onedrive-"+fileID.String()+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		_ = tempFile.Close()
+		_ = os.Remove(tempFile.Name())
+	}()
+
+	size, err := io.Copy(tempFile, file)
+	if err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	if _, err := tempFile.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind temp file: %w", err)
+	}
+
+	ctx := context.Background()
+	itemPath := o.getItemPath(fileID.String())
+
+	if size <= simpleUploadLimit {
+		data, readErr := io.ReadAll(tempFile)
+		if readErr != nil {
+			return fmt.Errorf("failed to read temp file: %w", readErr)
+		}
+
+		err = o.upload(ctx, itemPath, data)
+	} else {
+		err = o.uploadBySession(ctx, itemPath, tempFile, size)
+	}
+
+	if err != nil {
+		logger.Error("Failed to upload file to OneDrive", "fileId", fileID.String(), "error", err)
+		return err
+	}
+
+	logger.Info(
+		"Successfully saved file to OneDrive storage",
+		"fileId",
+		fileID.String(),
+		"size",
+		size,
+	)
+	return nil
+}
+
+// GetFile follows the redirect to the download URL. The URL is
+// pre-authenticated, the client does not pass the token to other host
+func (o *OneDriveStorage) GetFile(fileID uuid.UUID) (io.ReadCloser, error) {
+	response, err := o.callGraph(
+		context.Background(),
+		http.MethodGet,
+		o.getItemPath(fileID.String())+":/content",
+		nil,
+		"",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file from OneDrive: %w", err)
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("file not found: %s", fileID.String())
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, getResponseError(response, "failed to download file from OneDrive")
+	}
+
+	return response.Body, nil
+}
+
+func (o *OneDriveStorage) DeleteFile(fileID uuid.UUID) error {
+	return o.deleteItem(context.Background(), o.getItemPath(fileID.String()))
+}
+
+func (o *OneDriveStorage) Validate() error {
+	switch {
+	case o.ClientID == "":
+		return errors.New("client ID is required")
+	case o.ClientSecret == "":
+		return errors.New("client secret is required")
+	case o.TokenJSON == "":
+		return errors.New("token JSON is required")
+	}
+
+	token, err := oauth.ParseToken(o.TokenJSON)
+	if err != nil {
+		return err
+	}
+
+	if token.RefreshToken == "" {
+		return errors.New(
+			"token JSON must contain a refresh token, request offline_access scope",
+		)
+	}
+
+	return nil
+}
+
+func (o *OneDriveStorage) TestConnection() error {
+	ctx := context.Background()
+	testItemPath := o.getItemPath("test-connection-" + uuid.New().String())
+
+	// missing folders of the path are created by the upload
+	if err := o.upload(ctx, testItemPath, []byte("test")); err != nil {
+		return fmt.Errorf("failed to write test file to OneDrive: %w", err)
+	}
+
+	if err := o.deleteItem(ctx, testItemPath); err != nil {
+		return fmt.Errorf("failed to delete test file from OneDrive: %w", err)
+	}
+
+	return nil
+}
+
+func (o *OneDriveStorage) upload(ctx context.Context, itemPath string, data []byte) error {
+	response, err := o.callGraph(
+		ctx,
+		http.MethodPut,
+		itemPath+":/content",
+		data,
+		"application/octet-stream",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upload file to OneDrive: %w", err)
+	}
+
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusCreated {
+		return getResponseError(response, "failed to upload file to OneDrive")
+	}
+	_ = response.Body.Close()
+
+	return nil
+}
+
+// uploadBySession uploads the file by chunks to the URL of upload
+// session. The URL is pre-authenticated, so the chunks are sent
+// without the token and are not affected by its expiry
+func (o *OneDriveStorage) uploadBySession(
+	ctx context.Context,
+	itemPath string,
+	file io.Reader,
+	size int64,
+) error {
+	sessionRequest, err := json.Marshal(map[string]any{
+		"item": map[string]string{"@microsoft.graph.conflictBehavior": "replace"},
+	})
+	if err != nil {
+		return err
+	}
+
+	response, err := o.callGraph(
+		ctx,
+		http.MethodPost,
+		itemPath+":/createUploadSession",
+		sessionRequest,
+		"application/json",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create upload session: %w", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return getResponseError(response, "failed to create upload session")
+	}
+
+	var session struct {
+		UploadURL string `json:"uploadUrl"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&session)
+	_ = response.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to decode upload session: %w", err)
+	}
+
+	if err := uploadChunks(ctx, session.UploadURL, file, size); err != nil {
+		cancelUpload(session.UploadURL)
+		return err
+	}
+
+	return nil
+}
+
+func (o *OneDriveStorage) deleteItem(ctx context.Context, itemPath string) error {
+	response, err := o.callGraph(ctx, http.MethodDelete, itemPath, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to delete file from OneDrive: %w", err)
+	}
+
+	switch response.StatusCode {
+	// File doesn't exist, consider it already deleted
+	case http.StatusNoContent, http.StatusOK, http.StatusNotFound:
+		_ = response.Body.Close()
+		return nil
+	default:
+		return getResponseError(response, "failed to delete file from OneDrive")
+	}
+}
+
+// callGraph calls Graph API for the item addressed by path relative
+// to the drive root, e.g. /me/drive/root:/folder/file:/content
+func (o *OneDriveStorage) callGraph(
+	ctx context.Context,
+	method string,
+	itemPath string,
+	data []byte,
+	contentType string,
+) (*http.Response, error) {
+	return o.getTokenRefresher().Do(func() (*http.Request, error) {
+		var body io.Reader
+		if data != nil {
+			body = bytes.NewReader(data)
+		}
+
+		request, err := http.NewRequestWithContext(
+			ctx,
+			method,
+			graphURL+"/me/drive/root:"+itemPath,
+			body,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if contentType != "" {
+			request.Header.Set("Content-Type", contentType)
+		}
+
+		return request, nil
+	})
+}
+
+// getTokenRefresher returns the refresher updating TokenJSON of the
+// storage. It is created on each call, so changed fields are used
+func (o *OneDriveStorage) getTokenRefresher() *oauth.TokenRefresher {
+	return &oauth.TokenRefresher{
+		Config: &oauth2.Config{
+			ClientID:     o.ClientID,
+			ClientSecret: o.ClientSecret,
+			Endpoint:     oauth2.Endpoint{TokenURL: tokenURL, AuthStyle: oauth2.AuthStyleInParams},
+		},
+		TokenJSON:       &o.TokenJSON,
+		RefreshListener: o.tokenRefreshListener,
+		Logger: logger.GetLogger().With(
+			"storage", "OneDrive",
+			"storageId", o.StorageID.String(),
+		),
+	}
+}
+
+// getItemPath returns escaped path of the file relative to the drive root
+func (o *OneDriveStorage) getItemPath(filename string) string {
+	itemPath := ""
+
+	for _, part := range strings.Split(o.Path, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+
+		itemPath = itemPath + "/" + url.PathEscape(part)
+	}
+
+	return itemPath + "/" + url.PathEscape(filename)
+}
+
+func uploadChunks(ctx context.Context, uploadURL string, file io.Reader, size int64) error {
+	buffer := make([]byte, uploadChunkSize)
+
+	for offset := int64(0); offset < size; {
+		chunkSize, err := io.ReadFull(file, buffer[:min(uploadChunkSize, size-offset)])
+		if err != nil {
+			return fmt.Errorf("failed to read temp file: %w", err)
+		}
+
+		request, err := http.NewRequestWithContext(
+			ctx,
+			http.MethodPut,
+			uploadURL,
+			bytes.NewReader(buffer[:chunkSize]),
+		)
+		if err != nil {
+			return err
+		}
+
+		request.Header.Set(
+			"Content-Range",
+			fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(chunkSize)-1, size),
+		)
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return fmt.Errorf("failed to upload chunk at offset %d: %w", offset, err)
+		}
+
+		// the server accepts intermediate chunks and
+		// creates the file after the last one
+		switch response.StatusCode {
+		case http.StatusAccepted, http.StatusOK, http.StatusCreated:
+			_ = response.Body.Close()
+		default:
+			return getResponseError(
+				response,
+				fmt.Sprintf("failed to upload chunk at offset %d", offset),
+			)
+		}
+
+		offset += int64(chunkSize)
+	}
+
+	return nil
+}
+
+func cancelUpload(uploadURL string) {
+	request, err := http.NewRequest(http.MethodDelete, uploadURL, nil)
+	if err != nil {
+		return
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err == nil {
+		_ = response.Body.Close()
+	}
+}
+
+func getResponseError(response *http.Response, message string) error {
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+
+	var graphError struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &graphError); err == nil && graphError.Error.Code != "" {
+		return fmt.Errorf(
+			"%s: %s: %s: %s",
+			message,
+			response.Status,
+			graphError.Error.Code,
+			graphError.Error.Message,
+		)
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return fmt.Errorf("%s: %s", message, response.Status)
+	}
+
+	return fmt.Errorf("%s: %s: %s", message, response.Status, strings.TrimSpace(string(body)))
+}
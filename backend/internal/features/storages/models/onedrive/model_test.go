@@ -0,0 +1,310 @@
+package onedrive_storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"postgresus-backend/internal/util/logger"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+const (
+	testClientID     = "test-client-id"
+	testClientSecret = "test-client-secret"
+	testRefreshToken = "test-refresh-token"
+	itemPathPrefix   = "/v1.0/me/drive/root:"
+)
+
+func Test_SaveFileBySession_FileAssembledFromChunks(t *testing.T) {
+	previousUploadLimit, previousChunkSize := simpleUploadLimit, uploadChunkSize
+	simpleUploadLimit = 1024
+	uploadChunkSize = 320 * 1024
+	t.Cleanup(func() {
+		simpleUploadLimit, uploadChunkSize = previousUploadLimit, previousChunkSize
+	})
+
+	server := startTestGraphServer(t)
+	storage := createTestStorage(t, server, "valid-token", time.Now().Add(time.Hour))
+
+	fileData := bytes.Repeat([]byte("0123456789"), 100_000)
+	fileID := uuid.New()
+
+	err := storage.SaveFile(logger.GetLogger(), fileID, bytes.NewReader(fileData))
+	require.NoError(t, err)
+
+	assert.Equal(t, fileData, server.files["/backups/postgres/"+fileID.String()])
+	assert.Equal(t, 4, server.chunksUploaded)
+
+	// temp file is removed after upload
+	tempFiles, err := os.ReadDir(getTempFolder())
+	require.NoError(t, err)
+	assert.Empty(t, tempFiles)
+}
+
+func Test_TestConnectionWithExpiredToken_RotatedTokenSaved(t *testing.T) {
+	server := startTestGraphServer(t)
+	storage := createTestStorage(t, server, "expired-token", time.Now().Add(-time.Hour))
+
+	var savedTokenJSON string
+	storage.SetTokenRefreshListener(func() error {
+		savedTokenJSON = storage.TokenJSON
+		return nil
+	})
+
+	require.NoError(t, storage.TestConnection())
+
+	token := parseToken(t, savedTokenJSON)
+	assert.Equal(t, "valid-token", token.AccessToken)
+	assert.Equal(t, "rotated-refresh-token", token.RefreshToken)
+	assert.Empty(t, server.files)
+}
+
+func Test_DeleteFileWithRevokedToken_TokenRefreshedAndRequestRetried(t *testing.T) {
+	server := startTestGraphServer(t)
+	storage := createTestStorage(t, server, "revoked-token", time.Now().Add(time.Hour))
+
+	fileID := uuid.New()
+	server.files["/backups/postgres/"+fileID.String()] = []byte("test")
+
+	require.NoError(t, storage.DeleteFile(fileID))
+
+	assert.Empty(t, server.files)
+	assert.Equal(t, "valid-token", parseToken(t, storage.TokenJSON).AccessToken)
+}
+
+func Test_RefreshWithRevokedRefreshToken_ReturnsError(t *testing.T) {
+	server := startTestGraphServer(t)
+	storage := createTestStorage(t, server, "expired-token", time.Now().Add(-time.Hour))
+	server.refreshToken = "another-refresh-token"
+
+	err := storage.TestConnection()
+	assert.ErrorContains(t, err, "please authorize the app again")
+}
+
+func Test_ValidateWithoutRefreshToken_ReturnsError(t *testing.T) {
+	storage := &OneDriveStorage{
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		TokenJSON:    `{"access_token":"valid-token"}`,
+	}
+
+	assert.ErrorContains(t, storage.Validate(), "token JSON must contain a refresh token")
+}
+
+func createTestStorage(
+	t *testing.T,
+	server *testGraphServer,
+	accessToken string,
+	expiry time.Time,
+) *OneDriveStorage {
+	previousGraphURL, previousTokenURL, previousGetTempFolder := graphURL, tokenURL, getTempFolder
+	graphURL = server.URL + "/v1.0"
+	tokenURL = server.URL + "/oauth2/v2.0/token"
+	tempFolder := t.TempDir()
+	getTempFolder = func() string { return tempFolder }
+	t.Cleanup(func() {
+		graphURL, tokenURL, getTempFolder = previousGraphURL, previousTokenURL, previousGetTempFolder
+	})
+
+	tokenJSON, err := json.Marshal(&oauth2.Token{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		RefreshToken: testRefreshToken,
+		Expiry:       expiry,
+	})
+	require.NoError(t, err)
+
+	return &OneDriveStorage{
+		StorageID:    uuid.New(),
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		TokenJSON:    string(tokenJSON),
+		Path:         "backups/postgres",
+	}
+}
+
+func parseToken(t *testing.T, tokenJSON string) *oauth2.Token {
+	var token oauth2.Token
+	require.NoError(t, json.Unmarshal([]byte(tokenJSON), &token))
+
+	return &token
+}
+
+// testGraphServer keeps files in memory and accepts only "valid-token".
+// Like Microsoft, its token endpoint rotates refresh token
+type testGraphServer struct {
+	*httptest.Server
+
+	mu             sync.Mutex
+	files          map[string][]byte
+	sessions       map[string]*uploadSession
+	refreshToken   string
+	chunksUploaded int
+}
+
+type uploadSession struct {
+	itemPath string
+	data     []byte
+}
+
+func startTestGraphServer(t *testing.T) *testGraphServer {
+	server := &testGraphServer{
+		files:        make(map[string][]byte),
+		sessions:     make(map[string]*uploadSession),
+		refreshToken: testRefreshToken,
+	}
+
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (s *testGraphServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/oauth2/v2.0/token":
+		s.handleToken(w, r)
+		return
+	case strings.HasPrefix(r.URL.Path, "/upload/"):
+		// upload URL is pre-authenticated
+		s.handleChunk(w, r)
+		return
+	case strings.HasPrefix(r.URL.Path, "/download/"):
+		file, ok := s.files[strings.TrimPrefix(r.URL.Path, "/download")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write(file)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer valid-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error": {"code": "InvalidAuthenticationToken"}}`))
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, itemPathPrefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	itemPath := strings.TrimPrefix(r.URL.Path, itemPathPrefix)
+
+	switch {
+	case r.Method == http.MethodPut && strings.HasSuffix(itemPath, ":/content"):
+		body, _ := io.ReadAll(r.Body)
+		s.files[strings.TrimSuffix(itemPath, ":/content")] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet && strings.HasSuffix(itemPath, ":/content"):
+		itemPath = strings.TrimSuffix(itemPath, ":/content")
+		if _, ok := s.files[itemPath]; !ok {
+			writeItemNotFound(w)
+			return
+		}
+
+		http.Redirect(w, r, s.URL+"/download"+itemPath, http.StatusFound)
+	case r.Method == http.MethodPost && strings.HasSuffix(itemPath, ":/createUploadSession"):
+		sessionID := uuid.New().String()
+		s.sessions[sessionID] = &uploadSession{
+			itemPath: strings.TrimSuffix(itemPath, ":/createUploadSession"),
+		}
+
+		writeJSON(w, http.StatusOK, map[string]string{"uploadUrl": s.URL + "/upload/" + sessionID})
+	case r.Method == http.MethodDelete:
+		if _, ok := s.files[itemPath]; !ok {
+			writeItemNotFound(w)
+			return
+		}
+
+		delete(s.files, itemPath)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (s *testGraphServer) handleChunk(w http.ResponseWriter, r *http.Request) {
+	sessionID := strings.TrimPrefix(r.URL.Path, "/upload/")
+
+	session, ok := s.sessions[sessionID]
+	if !ok || r.Header.Get("Authorization") != "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+
+	var start, end, total int
+	_, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total)
+	if err != nil || start != len(session.data) || end-start+1 != len(body) {
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	// all chunks except the last one must be multiple of 320 KiB
+	if end+1 < total && len(body)%(320*1024) != 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	session.data = append(session.data, body...)
+	s.chunksUploaded++
+
+	if len(session.data) < total {
+		writeJSON(w, http.StatusAccepted, map[string]any{})
+		return
+	}
+
+	s.files[session.itemPath] = session.data
+	delete(s.sessions, sessionID)
+	writeJSON(w, http.StatusCreated, map[string]any{})
+}
+
+func (s *testGraphServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("grant_type") != "refresh_token" ||
+		r.FormValue("refresh_token") != s.refreshToken ||
+		r.FormValue("client_id") != testClientID ||
+		r.FormValue("client_secret") != testClientSecret {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	s.refreshToken = "rotated-refresh-token"
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  "valid-token",
+		"refresh_token": s.refreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeItemNotFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]any{
+		"error": map[string]string{"code": "itemNotFound", "message": "Item not found"},
+	})
+}
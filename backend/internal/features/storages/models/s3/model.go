@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"postgresus-backend/internal/features/secrets"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"gorm.io/gorm"
 )
 
 type S3Storage struct {
@@ -27,6 +29,34 @@ func (s *S3Storage) TableName() string {
 	return "s3_storages"
 }
 
+func (s *S3Storage) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&s.S3SecretKey)
+}
+
+func (s *S3Storage) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&s.S3SecretKey)
+}
+
+func (s *S3Storage) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&s.S3SecretKey)
+}
+
+func (s *S3Storage) HideSensitiveData() {
+	s.S3SecretKey = ""
+}
+
+// FillSensitiveData keeps the stored secret key unless the access
+// key or the endpoint is changed together with the hidden secret
+func (s *S3Storage) FillSensitiveData(existing *S3Storage) {
+	if existing == nil || s.S3SecretKey != "" {
+		return
+	}
+
+	if s.S3AccessKey == existing.S3AccessKey && s.S3Endpoint == existing.S3Endpoint {
+		s.S3SecretKey = existing.S3SecretKey
+	}
+}
+
 func (s *S3Storage) SaveFile(logger *slog.Logger, fileID uuid.UUID, file io.Reader) error {
 	client, err := s.getClient()
 	if err != nil {
@@ -0,0 +1,414 @@
+package sftp_storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path"
+	"postgresus-backend/internal/features/secrets"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+const connectionTimeout = 10 * time.Second
+
+type SFTPStorage struct {
+	StorageID uuid.UUID `json:"storageId" gorm:"primaryKey;type:uuid;column:storage_id"`
+	Host      string    `json:"host"      gorm:"not null;type:text;column:host"`
+	Port      int       `json:"port"      gorm:"not null;default:22;column:port"`
+	Username  string    `json:"username"  gorm:"not null;type:text;column:username"`
+
+	// either password or private key is required
+	Password             string `json:"password"             gorm:"type:text;column:password"`
+	PrivateKey           string `json:"privateKey"           gorm:"type:text;column:private_key"`
+	PrivateKeyPassphrase string `json:"privateKeyPassphrase" gorm:"type:text;column:private_key_passphrase"`
+
+	// public key of the server in authorized_keys format (as printed by
+	// ssh-keyscan) or its SHA256 fingerprint, e.g. "SHA256:..."
+	HostKey string `json:"hostKey" gorm:"not null;type:text;column:host_key"`
+	Path    string `json:"path"    gorm:"type:text;column:path"`
+}
+
+func (s *SFTPStorage) TableName() string {
+	return "sftp_storages"
+}
+
+func (s *SFTPStorage) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&s.Password, &s.PrivateKey, &s.PrivateKeyPassphrase)
+}
+
+func (s *SFTPStorage) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&s.Password, &s.PrivateKey, &s.PrivateKeyPassphrase)
+}
+
+func (s *SFTPStorage) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&s.Password, &s.PrivateKey, &s.PrivateKeyPassphrase)
+}
+
+func (s *SFTPStorage) HideSensitiveData() {
+	s.Password = ""
+	s.PrivateKey = ""
+	s.PrivateKeyPassphrase = ""
+}
+
+// FillSensitiveData keeps the stored credentials for the same server
+// and user. The passphrase is kept only together with its private key
+func (s *SFTPStorage) FillSensitiveData(existing *SFTPStorage) {
+	if existing == nil ||
+		s.Host != existing.Host ||
+		s.Port != existing.Port ||
+		s.Username != existing.Username {
+		return
+	}
+
+	if s.Password == "" {
+		s.Password = existing.Password
+	}
+
+	if s.PrivateKey == "" {
+		s.PrivateKey = existing.PrivateKey
+
+		if s.PrivateKeyPassphrase == "" {
+			s.PrivateKeyPassphrase = existing.PrivateKeyPassphrase
+		}
+	}
+}
+
+// SaveFile uploads the file under temporary name and renames it
+// when the upload is complete, so partially written files never
+// appear under the name of the backup
+func (s *SFTPStorage) SaveFile(logger *slog.Logger, fileID uuid.UUID, file io.Reader) error {
+	logger.Info("Starting to save file to SFTP storage", "fileId", fileID.String(), "host", s.Host)
+
+	connection, err := s.connect()
+	if err != nil {
+		logger.Error("Failed to connect to SFTP server", "fileId", fileID.String(), "error", err)
+		return err
+	}
+	defer connection.close()
+
+	if err := s.ensureDirectory(connection.client); err != nil {
+		logger.Error("Failed to ensure directory", "fileId", fileID.String(), "error", err)
+		return err
+	}
+
+	filePath := s.getFilePath(fileID.String())
+	tempFilePath := s.getFilePath("." + fileID.String() + ".part")
+
+	if err := uploadFile(connection.client, tempFilePath, file); err != nil {
+		logger.Error(
+			"Failed to upload file to SFTP server",
+			"fileId",
+			fileID.String(),
+			"error",
+			err,
+		)
+		_ = connection.client.Remove(tempFilePath)
+		return err
+	}
+
+	if err := renameFile(connection.client, tempFilePath, filePath); err != nil {
+		logger.Error("Failed to rename uploaded file", "fileId", fileID.String(), "error", err)
+		_ = connection.client.Remove(tempFilePath)
+		return err
+	}
+
+	logger.Info(
+		"Successfully saved file to SFTP storage",
+		"fileId",
+		fileID.String(),
+		"filePath",
+		filePath,
+	)
+	return nil
+}
+
+func (s *SFTPStorage) GetFile(fileID uuid.UUID) (io.ReadCloser, error) {
+	connection, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	remoteFile, err := connection.client.Open(s.getFilePath(fileID.String()))
+	if err != nil {
+		connection.close()
+
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("file not found: %s", fileID.String())
+		}
+
+		return nil, fmt.Errorf("failed to open file on SFTP server: %w", err)
+	}
+
+	return &sftpFileReader{file: remoteFile, connection: connection}, nil
+}
+
+func (s *SFTPStorage) DeleteFile(fileID uuid.UUID) error {
+	connection, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer connection.close()
+
+	err = connection.client.Remove(s.getFilePath(fileID.String()))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete file from SFTP server: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SFTPStorage) Validate() error {
+	if s.Host == "" {
+		return errors.New("SFTP host is required")
+	}
+
+	if s.Port <= 0 || s.Port > 65535 {
+		return errors.New("SFTP port must be between 1 and 65535")
+	}
+
+	if s.Username == "" {
+		return errors.New("SFTP username is required")
+	}
+
+	if s.Password == "" && s.PrivateKey == "" {
+		return errors.New("SFTP password or private key is required")
+	}
+
+	if s.HostKey == "" {
+		return errors.New("SFTP host key is required, it can be got by ssh-keyscan")
+	}
+
+	if _, err := s.getAuthMethods(); err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(s.HostKey, "SHA256:") {
+		if _, err := parseHostKey(s.HostKey); err != nil {
+			return err
+		}
+	}
+
+	// Test the configuration by writing a file
+	return s.TestConnection()
+}
+
+// TestConnection writes and removes a file to make sure the
+// user has permissions to save and to delete backups
+func (s *SFTPStorage) TestConnection() error {
+	connection, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer connection.close()
+
+	if err := s.ensureDirectory(connection.client); err != nil {
+		return err
+	}
+
+	testFilePath := s.getFilePath(".postgresus-test-" + uuid.New().String())
+
+	if err := uploadFile(
+		connection.client,
+		testFilePath,
+		strings.NewReader("postgresus connection test"),
+	); err != nil {
+		return fmt.Errorf("no permission to write to SFTP server: %w", err)
+	}
+
+	if err := connection.client.Remove(testFilePath); err != nil {
+		return fmt.Errorf("no permission to delete files on SFTP server: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SFTPStorage) connect() (*sftpConnection, error) {
+	authMethods, err := s.getAuthMethods()
+	if err != nil {
+		return nil, err
+	}
+
+	address := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+
+	sshClient, err := ssh.Dial("tcp", address, &ssh.ClientConfig{
+		User:            s.Username,
+		Auth:            authMethods,
+		HostKeyCallback: s.checkHostKey,
+		Timeout:         connectionTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SFTP server %s: %w", address, err)
+	}
+
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		_ = sshClient.Close()
+		return nil, fmt.Errorf("failed to start SFTP session: %w", err)
+	}
+
+	return &sftpConnection{client: sftpClient, sshClient: sshClient}, nil
+}
+
+func (s *SFTPStorage) getAuthMethods() ([]ssh.AuthMethod, error) {
+	var authMethods []ssh.AuthMethod
+
+	if s.PrivateKey != "" {
+		var signer ssh.Signer
+		var err error
+
+		if s.PrivateKeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(
+				[]byte(s.PrivateKey),
+				[]byte(s.PrivateKeyPassphrase),
+			)
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(s.PrivateKey))
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid SFTP private key: %w", err)
+		}
+
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
+
+	if s.Password != "" {
+		authMethods = append(authMethods, ssh.Password(s.Password))
+	}
+
+	return authMethods, nil
+}
+
+// checkHostKey accepts only the pinned key of the server, so backups
+// are never sent to a server impersonating the storage
+func (s *SFTPStorage) checkHostKey(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	fingerprint := ssh.FingerprintSHA256(key)
+
+	if strings.HasPrefix(s.HostKey, "SHA256:") {
+		if strings.TrimSpace(s.HostKey) == fingerprint {
+			return nil
+		}
+	} else {
+		pinnedKey, err := parseHostKey(s.HostKey)
+		if err != nil {
+			return err
+		}
+
+		if bytes.Equal(pinnedKey.Marshal(), key.Marshal()) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(
+		"host key of SFTP server does not match the pinned one, server presented %s %s",
+		key.Type(),
+		fingerprint,
+	)
+}
+
+func (s *SFTPStorage) ensureDirectory(client *sftp.Client) error {
+	if s.Path == "" {
+		return nil
+	}
+
+	if err := client.MkdirAll(path.Clean(s.Path)); err != nil {
+		return fmt.Errorf("failed to access or create path '%s': %w", s.Path, err)
+	}
+
+	return nil
+}
+
+func (s *SFTPStorage) getFilePath(filename string) string {
+	if s.Path == "" {
+		return filename
+	}
+
+	return path.Join(path.Clean(s.Path), filename)
+}
+
+// parseHostKey reads key in authorized_keys or known_hosts format
+func parseHostKey(hostKey string) (ssh.PublicKey, error) {
+	fields := strings.Fields(hostKey)
+
+	// known_hosts lines start with host names
+	for i := range fields {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.Join(fields[i:], " ")))
+		if err == nil {
+			return key, nil
+		}
+	}
+
+	return nil, errors.New(
+		"invalid SFTP host key, expected line of ssh-keyscan or SHA256 fingerprint",
+	)
+}
+
+func uploadFile(client *sftp.Client, filePath string, file io.Reader) error {
+	remoteFile, err := client.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("failed to create file on SFTP server: %w", err)
+	}
+
+	if _, err := io.Copy(remoteFile, file); err != nil {
+		_ = remoteFile.Close()
+		return fmt.Errorf("failed to write file to SFTP server: %w", err)
+	}
+
+	if err := remoteFile.Close(); err != nil {
+		return fmt.Errorf("failed to close file on SFTP server: %w", err)
+	}
+
+	return nil
+}
+
+// renameFile replaces the destination atomically if the server supports
+// posix-rename extension (OpenSSH does), plain rename is used otherwise
+func renameFile(client *sftp.Client, oldPath string, newPath string) error {
+	if err := client.PosixRename(oldPath, newPath); err == nil {
+		return nil
+	}
+
+	if err := client.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename file on SFTP server: %w", err)
+	}
+
+	return nil
+}
+
+type sftpConnection struct {
+	client    *sftp.Client
+	sshClient *ssh.Client
+}
+
+func (c *sftpConnection) close() {
+	_ = c.client.Close()
+	_ = c.sshClient.Close()
+}
+
+// sftpFileReader closes the connection together with the file
+type sftpFileReader struct {
+	file       *sftp.File
+	connection *sftpConnection
+}
+
+func (r *sftpFileReader) Read(p []byte) (int, error) {
+	return r.file.Read(p)
+}
+
+func (r *sftpFileReader) Close() error {
+	err := r.file.Close()
+	r.connection.close()
+
+	return err
+}
@@ -0,0 +1,233 @@
+package sftp_storage
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"postgresus-backend/internal/util/logger"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	testUsername = "testuser"
+	testPassword = "testpassword"
+)
+
+type testSFTPServer struct {
+	port      int
+	directory string
+	hostKey   ssh.PublicKey
+	clientKey ed25519.PrivateKey
+}
+
+func Test_SaveFile_OnlyRenamedFileLeft(t *testing.T) {
+	server := startTestSFTPServer(t)
+	storage := server.createStorage()
+	storage.Password = testPassword
+
+	fileID := uuid.New()
+
+	err := storage.SaveFile(logger.GetLogger(), fileID, bytes.NewReader([]byte("test")))
+	require.NoError(t, err)
+
+	// file is uploaded under temporary name and renamed after the upload
+	entries, err := os.ReadDir(filepath.Join(server.directory, "backups"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, fileID.String(), entries[0].Name())
+}
+
+func Test_ValidateWithPrivateKey_ValidationSucceeds(t *testing.T) {
+	server := startTestSFTPServer(t)
+	storage := server.createStorage()
+
+	privateKey, err := ssh.MarshalPrivateKey(server.clientKey, "")
+	require.NoError(t, err)
+	storage.PrivateKey = string(pem.EncodeToMemory(privateKey))
+
+	assert.NoError(t, storage.Validate())
+
+	// test file of the connection check is removed
+	entries, err := os.ReadDir(filepath.Join(server.directory, "backups"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func Test_TestConnectionWithFingerprint_ConnectionSucceeds(t *testing.T) {
+	server := startTestSFTPServer(t)
+	storage := server.createStorage()
+	storage.Password = testPassword
+	storage.HostKey = ssh.FingerprintSHA256(server.hostKey)
+
+	assert.NoError(t, storage.TestConnection())
+}
+
+func Test_TestConnectionWithWrongHostKey_ConnectionRejected(t *testing.T) {
+	server := startTestSFTPServer(t)
+	storage := server.createStorage()
+	storage.Password = testPassword
+
+	_, otherPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherSigner, err := ssh.NewSignerFromKey(otherPrivateKey)
+	require.NoError(t, err)
+	storage.HostKey = string(ssh.MarshalAuthorizedKey(otherSigner.PublicKey()))
+
+	err = storage.TestConnection()
+	assert.ErrorContains(t, err, "does not match the pinned one")
+	assert.ErrorContains(t, err, ssh.FingerprintSHA256(server.hostKey))
+}
+
+func Test_TestConnectionWithWrongPassword_ConnectionRejected(t *testing.T) {
+	server := startTestSFTPServer(t)
+	storage := server.createStorage()
+	storage.Password = "wrong-password"
+
+	assert.Error(t, storage.TestConnection())
+}
+
+func Test_FillSensitiveData_CredentialsKeptForSameServer(t *testing.T) {
+	existing := &SFTPStorage{
+		Host:                 "backup.example.com",
+		Port:                 22,
+		Username:             testUsername,
+		PrivateKey:           "private-key",
+		PrivateKeyPassphrase: "passphrase",
+	}
+
+	storage := &SFTPStorage{Host: "backup.example.com", Port: 22, Username: testUsername}
+	storage.FillSensitiveData(existing)
+	assert.Equal(t, "private-key", storage.PrivateKey)
+	assert.Equal(t, "passphrase", storage.PrivateKeyPassphrase)
+
+	otherStorage := &SFTPStorage{Host: "other.example.com", Port: 22, Username: testUsername}
+	otherStorage.FillSensitiveData(existing)
+	assert.Empty(t, otherStorage.PrivateKey)
+	assert.Empty(t, otherStorage.PrivateKeyPassphrase)
+}
+
+func (s *testSFTPServer) createStorage() *SFTPStorage {
+	return &SFTPStorage{
+		StorageID: uuid.New(),
+		Host:      "127.0.0.1",
+		Port:      s.port,
+		Username:  testUsername,
+		HostKey:   string(ssh.MarshalAuthorizedKey(s.hostKey)),
+		Path:      "backups",
+	}
+}
+
+// startTestSFTPServer serves SFTP subsystem on random local port. Paths
+// are resolved relative to the temporary directory of the test
+func startTestSFTPServer(t *testing.T) *testSFTPServer {
+	_, hostPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostPrivateKey)
+	require.NoError(t, err)
+
+	_, clientPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	clientSigner, err := ssh.NewSignerFromKey(clientPrivateKey)
+	require.NoError(t, err)
+
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == testUsername && string(password) == testPassword {
+				return nil, nil
+			}
+
+			return nil, errors.New("invalid password")
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == testUsername &&
+				bytes.Equal(key.Marshal(), clientSigner.PublicKey().Marshal()) {
+				return nil, nil
+			}
+
+			return nil, errors.New("invalid public key")
+		},
+	}
+	serverConfig.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	directory := t.TempDir()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go serveTestSFTPConnection(conn, serverConfig, directory)
+		}
+	}()
+
+	return &testSFTPServer{
+		port:      listener.Addr().(*net.TCPAddr).Port,
+		directory: directory,
+		hostKey:   hostSigner.PublicKey(),
+		clientKey: clientPrivateKey,
+	}
+}
+
+func serveTestSFTPConnection(conn net.Conn, config *ssh.ServerConfig, directory string) {
+	serverConn, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	defer serverConn.Close()
+
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			for request := range channelRequests {
+				isSFTP := request.Type == "subsystem" &&
+					len(request.Payload) > 4 &&
+					string(request.Payload[4:]) == "sftp"
+				_ = request.Reply(isSFTP, nil)
+
+				if !isSFTP {
+					continue
+				}
+
+				server, err := sftp.NewServer(channel, sftp.WithServerWorkingDirectory(directory))
+				if err != nil {
+					_ = channel.Close()
+					return
+				}
+
+				_ = server.Serve()
+				_ = server.Close()
+				_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+				_ = channel.Close()
+			}
+		}()
+	}
+}
@@ -0,0 +1,424 @@
+package webdav_storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"postgresus-backend/internal/features/secrets"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const nextcloudFilesPath = "/remote.php/dav/files/"
+
+// chunks are kept in memory while uploaded, Nextcloud
+// requires chunks of at least 5 MB except the last one
+var uploadChunkSize = 10 * 1024 * 1024
+
+type WebDAVStorage struct {
+	StorageID uuid.UUID `json:"storageId" gorm:"primaryKey;type:uuid;column:storage_id"`
+	// WebDAV root, for Nextcloud and ownCloud
+	// https://<host>/remote.php/dav/files/<user>
+	URL      string `json:"url"      gorm:"not null;type:text;column:url"`
+	Username string `json:"username" gorm:"not null;type:text;column:username"`
+	// password or app password
+	Password string `json:"password" gorm:"not null;type:text;column:password"`
+	Path     string `json:"path"     gorm:"type:text;column:path"`
+
+	// UseChunkedUpload uploads files by chunks with Nextcloud chunked
+	// upload API, so big backups are not limited by upload size and
+	// timeouts of the server. Otherwise file is streamed by single PUT
+	UseChunkedUpload bool `json:"useChunkedUpload" gorm:"not null;default:false;column:use_chunked_upload"`
+}
+
+func (w *WebDAVStorage) TableName() string {
+	return "webdav_storages"
+}
+
+func (w *WebDAVStorage) BeforeSave(tx *gorm.DB) error {
+	return secrets.EncryptFields(&w.Password)
+}
+
+func (w *WebDAVStorage) AfterSave(tx *gorm.DB) error {
+	return secrets.DecryptFields(&w.Password)
+}
+
+func (w *WebDAVStorage) AfterFind(tx *gorm.DB) error {
+	return secrets.DecryptFields(&w.Password)
+}
+
+func (w *WebDAVStorage) HideSensitiveData() {
+	w.Password = ""
+}
+
+// FillSensitiveData keeps the stored password for the same server
+// and user
+func (w *WebDAVStorage) FillSensitiveData(existing *WebDAVStorage) {
+	if existing == nil || w.Password != "" {
+		return
+	}
+
+	if w.URL == existing.URL && w.Username == existing.Username {
+		w.Password = existing.Password
+	}
+}
+
+func (w *WebDAVStorage) SaveFile(logger *slog.Logger, fileID uuid.UUID, file io.Reader) error {
+	logger.Info("Starting to save file to WebDAV storage", "fileId", fileID.String())
+
+	ctx := context.TODO()
+
+	if err := w.ensureDirectory(ctx); err != nil {
+		logger.Error("Failed to ensure directory", "fileId", fileID.String(), "error", err)
+		return fmt.Errorf("failed to ensure directory: %w", err)
+	}
+
+	fileURL := w.getFileURL(fileID.String())
+
+	var err error
+	if w.UseChunkedUpload {
+		err = w.uploadByChunks(ctx, fileURL, file)
+	} else {
+		err = w.upload(ctx, fileURL, file)
+	}
+
+	if err != nil {
+		logger.Error("Failed to upload file to WebDAV", "fileId", fileID.String(), "error", err)
+		return err
+	}
+
+	logger.Info("Successfully saved file to WebDAV storage", "fileId", fileID.String())
+	return nil
+}
+
+// GetFile streams the file, the response is not buffered
+func (w *WebDAVStorage) GetFile(fileID uuid.UUID) (io.ReadCloser, error) {
+	fileURL := w.getFileURL(fileID.String())
+
+	response, err := w.doRequest(context.TODO(), http.MethodGet, fileURL, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file from WebDAV: %w", err)
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("file not found: %s", fileID.String())
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, getResponseError(response, "failed to get file from WebDAV")
+	}
+
+	return response.Body, nil
+}
+
+func (w *WebDAVStorage) DeleteFile(fileID uuid.UUID) error {
+	fileURL := w.getFileURL(fileID.String())
+
+	response, err := w.doRequest(context.TODO(), http.MethodDelete, fileURL, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete file from WebDAV: %w", err)
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		// File doesn't exist, consider it already deleted
+		_ = response.Body.Close()
+		return nil
+	default:
+		return getResponseError(response, "failed to delete file from WebDAV")
+	}
+}
+
+func (w *WebDAVStorage) Validate() error {
+	if w.URL == "" {
+		return errors.New("WebDAV URL is required")
+	}
+
+	baseURL, err := url.Parse(w.URL)
+	if err != nil ||
+		(baseURL.Scheme != "http" && baseURL.Scheme != "https") ||
+		baseURL.Host == "" {
+		return errors.New("WebDAV URL must be an absolute http or https URL")
+	}
+
+	if w.Username == "" {
+		return errors.New("WebDAV username is required")
+	}
+	if w.Password == "" {
+		return errors.New("WebDAV password is required")
+	}
+
+	if w.UseChunkedUpload {
+		if _, err := w.getUploadsURL(); err != nil {
+			return err
+		}
+	}
+
+	// Test the configuration by accessing the path
+	return w.TestConnection()
+}
+
+func (w *WebDAVStorage) TestConnection() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	response, err := w.doRequest(
+		ctx,
+		"PROPFIND",
+		strings.TrimSuffix(w.URL, "/")+"/",
+		nil,
+		map[string]string{"Depth": "0"},
+	)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return errors.New("failed to connect to WebDAV server. Please check params")
+		}
+
+		return fmt.Errorf("failed to connect to WebDAV server: %w", err)
+	}
+
+	if response.StatusCode != http.StatusMultiStatus && response.StatusCode != http.StatusOK {
+		return getResponseError(response, "failed to access WebDAV URL")
+	}
+	_ = response.Body.Close()
+
+	// If path is specified, check if it exists or can be created
+	if w.Path != "" {
+		if err := w.ensureDirectory(ctx); err != nil {
+			return fmt.Errorf("failed to access or create path '%s': %w", w.Path, err)
+		}
+	}
+
+	return nil
+}
+
+func (w *WebDAVStorage) upload(ctx context.Context, fileURL string, file io.Reader) error {
+	// unknown length makes the client to stream body
+	// with chunked transfer encoding
+	response, err := w.doRequest(ctx, http.MethodPut, fileURL, io.NopCloser(file), nil)
+	if err != nil {
+		return fmt.Errorf("failed to upload file to WebDAV: %w", err)
+	}
+
+	if response.StatusCode != http.StatusCreated &&
+		response.StatusCode != http.StatusNoContent &&
+		response.StatusCode != http.StatusOK {
+		return getResponseError(response, "failed to upload file to WebDAV")
+	}
+	_ = response.Body.Close()
+
+	return nil
+}
+
+// uploadByChunks uses Nextcloud chunked upload v2: chunks are uploaded to
+// temporary collection and the server assembles them on MOVE of ".file"
+func (w *WebDAVStorage) uploadByChunks(ctx context.Context, fileURL string, file io.Reader) error {
+	uploadsURL, err := w.getUploadsURL()
+	if err != nil {
+		return err
+	}
+
+	uploadURL := uploadsURL + "postgresus-" + uuid.New().String()
+	destination := map[string]string{"Destination": fileURL}
+
+	if err := w.makeCollection(ctx, uploadURL, destination); err != nil {
+		return fmt.Errorf("failed to start chunked upload: %w", err)
+	}
+
+	if err := w.uploadChunks(ctx, uploadURL, destination, file); err != nil {
+		w.cancelUpload(uploadURL)
+		return err
+	}
+
+	response, err := w.doRequest(
+		ctx,
+		"MOVE",
+		uploadURL+"/.file",
+		nil,
+		map[string]string{"Destination": fileURL, "Overwrite": "T"},
+	)
+	if err != nil {
+		w.cancelUpload(uploadURL)
+		return fmt.Errorf("failed to assemble uploaded chunks: %w", err)
+	}
+
+	if response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusNoContent {
+		w.cancelUpload(uploadURL)
+		return getResponseError(response, "failed to assemble uploaded chunks")
+	}
+	_ = response.Body.Close()
+
+	return nil
+}
+
+func (w *WebDAVStorage) uploadChunks(
+	ctx context.Context,
+	uploadURL string,
+	headers map[string]string,
+	file io.Reader,
+) error {
+	buffer := make([]byte, uploadChunkSize)
+
+	// chunks are numbered from 1, names are padded
+	// because the server sorts them by name
+	for chunkNumber := 1; ; chunkNumber++ {
+		size, readErr := io.ReadFull(file, buffer)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return fmt.Errorf("failed to read file: %w", readErr)
+		}
+
+		// the last chunk is empty only for empty files
+		if size == 0 && chunkNumber > 1 {
+			return nil
+		}
+
+		chunkURL := fmt.Sprintf("%s/%05d", uploadURL, chunkNumber)
+
+		response, err := w.doRequest(
+			ctx,
+			http.MethodPut,
+			chunkURL,
+			bytes.NewReader(buffer[:size]),
+			headers,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to upload chunk %d: %w", chunkNumber, err)
+		}
+
+		if response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusNoContent {
+			return getResponseError(response, fmt.Sprintf("failed to upload chunk %d", chunkNumber))
+		}
+		_ = response.Body.Close()
+
+		if readErr != nil {
+			return nil
+		}
+	}
+}
+
+func (w *WebDAVStorage) cancelUpload(uploadURL string) {
+	response, err := w.doRequest(context.Background(), http.MethodDelete, uploadURL, nil, nil)
+	if err == nil {
+		_ = response.Body.Close()
+	}
+}
+
+// ensureDirectory creates missing collections of the path one by one,
+// because MKCOL fails when the parent does not exist
+func (w *WebDAVStorage) ensureDirectory(ctx context.Context) error {
+	currentURL := strings.TrimSuffix(w.URL, "/")
+
+	for _, part := range strings.Split(w.Path, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+
+		currentURL = currentURL + "/" + url.PathEscape(part)
+
+		if err := w.makeCollection(ctx, currentURL, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// makeCollection creates collection, existing collection is not an error
+func (w *WebDAVStorage) makeCollection(
+	ctx context.Context,
+	collectionURL string,
+	headers map[string]string,
+) error {
+	response, err := w.doRequest(ctx, "MKCOL", collectionURL, nil, headers)
+	if err != nil {
+		return err
+	}
+
+	switch response.StatusCode {
+	case http.StatusCreated, http.StatusMethodNotAllowed:
+		_ = response.Body.Close()
+		return nil
+	default:
+		return getResponseError(response, "failed to create directory")
+	}
+}
+
+func (w *WebDAVStorage) doRequest(
+	ctx context.Context,
+	method string,
+	requestURL string,
+	body io.Reader,
+	headers map[string]string,
+) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, method, requestURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	request.SetBasicAuth(w.Username, w.Password)
+
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	return http.DefaultClient.Do(request)
+}
+
+func (w *WebDAVStorage) getFileURL(filename string) string {
+	fileURL := strings.TrimSuffix(w.URL, "/")
+
+	for _, part := range strings.Split(w.Path, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+
+		fileURL = fileURL + "/" + url.PathEscape(part)
+	}
+
+	return fileURL + "/" + url.PathEscape(filename)
+}
+
+// getUploadsURL returns uploads collection of the user,
+// e.g. https://<host>/remote.php/dav/uploads/<user>/
+func (w *WebDAVStorage) getUploadsURL() (string, error) {
+	index := strings.Index(w.URL, nextcloudFilesPath)
+	if index == -1 {
+		return "", errors.New(
+			"chunked upload requires Nextcloud URL like https://<host>/remote.php/dav/files/<user>",
+		)
+	}
+
+	user := strings.Split(w.URL[index+len(nextcloudFilesPath):], "/")[0]
+	if user == "" {
+		return "", errors.New("WebDAV URL does not contain Nextcloud user")
+	}
+
+	return w.URL[:index] + "/remote.php/dav/uploads/" + user + "/", nil
+}
+
+func getResponseError(response *http.Response, message string) error {
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+
+	if response.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%s: invalid username or password", message)
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return fmt.Errorf("%s: %s", message, response.Status)
+	}
+
+	return fmt.Errorf("%s: %s: %s", message, response.Status, strings.TrimSpace(string(body)))
+}
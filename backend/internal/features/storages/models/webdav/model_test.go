@@ -0,0 +1,179 @@
+package webdav_storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"postgresus-backend/internal/util/logger"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+)
+
+const (
+	testUsername = "testuser"
+	testPassword = "testpassword"
+	davPrefix    = "/remote.php/dav"
+)
+
+func Test_SaveFileByChunks_FileAssembledFromChunks(t *testing.T) {
+	previousChunkSize := uploadChunkSize
+	uploadChunkSize = 1024
+	t.Cleanup(func() { uploadChunkSize = previousChunkSize })
+
+	server, fileSystem := startTestWebDAVServer(t)
+	storage := createTestStorage(server)
+	storage.UseChunkedUpload = true
+
+	fileData := bytes.Repeat([]byte("0123456789"), 500)
+	fileID := uuid.New()
+
+	err := storage.SaveFile(logger.GetLogger(), fileID, bytes.NewReader(fileData))
+	require.NoError(t, err)
+
+	file, err := storage.GetFile(fileID)
+	require.NoError(t, err)
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	assert.Equal(t, fileData, content)
+
+	// temporary upload is removed after assembling
+	uploads, err := readDirNames(fileSystem, "/uploads/testuser")
+	require.NoError(t, err)
+	assert.Empty(t, uploads)
+}
+
+func Test_TestConnectionWithWrongPassword_ConnectionRejected(t *testing.T) {
+	server, _ := startTestWebDAVServer(t)
+	storage := createTestStorage(server)
+	storage.Password = "wrong-password"
+
+	err := storage.TestConnection()
+	assert.ErrorContains(t, err, "invalid username or password")
+}
+
+func Test_ValidateChunkedUploadWithoutNextcloudURL_ReturnsError(t *testing.T) {
+	storage := &WebDAVStorage{
+		URL:              "https://dav.example.com/backups",
+		Username:         testUsername,
+		Password:         testPassword,
+		UseChunkedUpload: true,
+	}
+
+	assert.ErrorContains(t, storage.Validate(), "chunked upload requires Nextcloud URL")
+}
+
+func createTestStorage(server *httptest.Server) *WebDAVStorage {
+	return &WebDAVStorage{
+		StorageID: uuid.New(),
+		URL:       server.URL + davPrefix + "/files/" + testUsername,
+		Username:  testUsername,
+		Password:  testPassword,
+		Path:      "backups/postgres",
+	}
+}
+
+// startTestWebDAVServer serves in-memory WebDAV with layout of Nextcloud.
+// MOVE of ".file" of an upload assembles its chunks like Nextcloud does
+func startTestWebDAVServer(t *testing.T) (*httptest.Server, webdav.FileSystem) {
+	ctx := context.Background()
+	fileSystem := webdav.NewMemFS()
+
+	for _, dir := range []string{"/files", "/files/testuser", "/uploads", "/uploads/testuser"} {
+		require.NoError(t, fileSystem.Mkdir(ctx, dir, 0755))
+	}
+
+	handler := &webdav.Handler{
+		Prefix:     davPrefix,
+		FileSystem: fileSystem,
+		LockSystem: webdav.NewMemLS(),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != testUsername || password != testPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method == "MOVE" && strings.HasSuffix(r.URL.Path, "/.file") {
+			assembleChunks(t, fileSystem, w, r)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, fileSystem
+}
+
+func assembleChunks(
+	t *testing.T,
+	fileSystem webdav.FileSystem,
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := context.Background()
+	uploadDir := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, davPrefix), "/.file")
+
+	destination, err := url.Parse(r.Header.Get("Destination"))
+	require.NoError(t, err)
+
+	chunkNames, err := readDirNames(fileSystem, uploadDir)
+	require.NoError(t, err)
+	sort.Strings(chunkNames)
+
+	target, err := fileSystem.OpenFile(
+		ctx,
+		strings.TrimPrefix(destination.Path, davPrefix),
+		os.O_WRONLY|os.O_CREATE|os.O_TRUNC,
+		0644,
+	)
+	require.NoError(t, err)
+
+	for _, chunkName := range chunkNames {
+		chunk, err := fileSystem.OpenFile(ctx, uploadDir+"/"+chunkName, os.O_RDONLY, 0)
+		require.NoError(t, err)
+
+		_, err = io.Copy(target, chunk)
+		require.NoError(t, err)
+		require.NoError(t, chunk.Close())
+	}
+
+	require.NoError(t, target.Close())
+	require.NoError(t, fileSystem.RemoveAll(ctx, uploadDir))
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func readDirNames(fileSystem webdav.FileSystem, dir string) ([]string, error) {
+	directory, err := fileSystem.OpenFile(context.Background(), dir, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer directory.Close()
+
+	entries, err := directory.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names, nil
+}
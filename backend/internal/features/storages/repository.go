@@ -1,6 +1,7 @@
 package storages
 
 import (
+	"postgresus-backend/internal/features/secrets"
 	db "postgresus-backend/internal/storage"
 
 	"github.com/google/uuid"
@@ -30,17 +31,63 @@ func (r *StorageRepository) Save(storage *Storage) (*Storage, error) {
 			if storage.NASStorage != nil {
 				storage.NASStorage.StorageID = storage.ID
 			}
+		case StorageTypeSFTP:
+			if storage.SFTPStorage != nil {
+				storage.SFTPStorage.StorageID = storage.ID
+			}
+		case StorageTypeAzureBlob:
+			if storage.AzureBlobStorage != nil {
+				storage.AzureBlobStorage.StorageID = storage.ID
+			}
+		case StorageTypeFTP:
+			if storage.FTPStorage != nil {
+				storage.FTPStorage.StorageID = storage.ID
+			}
+		case StorageTypeWebDAV:
+			if storage.WebDAVStorage != nil {
+				storage.WebDAVStorage.StorageID = storage.ID
+			}
+		case StorageTypeDropbox:
+			if storage.DropboxStorage != nil {
+				storage.DropboxStorage.StorageID = storage.ID
+			}
+		case StorageTypeOneDrive:
+			if storage.OneDriveStorage != nil {
+				storage.OneDriveStorage.StorageID = storage.ID
+			}
 		}
 
 		if storage.ID == uuid.Nil {
 			if err := tx.Create(storage).
-				Omit("LocalStorage", "S3Storage", "GoogleDriveStorage", "NASStorage").
+				Omit(
+					"LocalStorage",
+					"S3Storage",
+					"GoogleDriveStorage",
+					"NASStorage",
+					"SFTPStorage",
+					"AzureBlobStorage",
+					"FTPStorage",
+					"WebDAVStorage",
+					"DropboxStorage",
+					"OneDriveStorage",
+				).
 				Error; err != nil {
 				return err
 			}
 		} else {
 			if err := tx.Save(storage).
-				Omit("LocalStorage", "S3Storage", "GoogleDriveStorage", "NASStorage").
+				Omit(
+					"LocalStorage",
+					"S3Storage",
+					"GoogleDriveStorage",
+					"NASStorage",
+					"SFTPStorage",
+					"AzureBlobStorage",
+					"FTPStorage",
+					"WebDAVStorage",
+					"DropboxStorage",
+					"OneDriveStorage",
+				).
 				Error; err != nil {
 				return err
 			}
@@ -75,6 +122,48 @@ func (r *StorageRepository) Save(storage *Storage) (*Storage, error) {
 					return err
 				}
 			}
+		case StorageTypeSFTP:
+			if storage.SFTPStorage != nil {
+				storage.SFTPStorage.StorageID = storage.ID // Ensure ID is set
+				if err := tx.Save(storage.SFTPStorage).Error; err != nil {
+					return err
+				}
+			}
+		case StorageTypeAzureBlob:
+			if storage.AzureBlobStorage != nil {
+				storage.AzureBlobStorage.StorageID = storage.ID // Ensure ID is set
+				if err := tx.Save(storage.AzureBlobStorage).Error; err != nil {
+					return err
+				}
+			}
+		case StorageTypeFTP:
+			if storage.FTPStorage != nil {
+				storage.FTPStorage.StorageID = storage.ID // Ensure ID is set
+				if err := tx.Save(storage.FTPStorage).Error; err != nil {
+					return err
+				}
+			}
+		case StorageTypeWebDAV:
+			if storage.WebDAVStorage != nil {
+				storage.WebDAVStorage.StorageID = storage.ID // Ensure ID is set
+				if err := tx.Save(storage.WebDAVStorage).Error; err != nil {
+					return err
+				}
+			}
+		case StorageTypeDropbox:
+			if storage.DropboxStorage != nil {
+				storage.DropboxStorage.StorageID = storage.ID // Ensure ID is set
+				if err := tx.Save(storage.DropboxStorage).Error; err != nil {
+					return err
+				}
+			}
+		case StorageTypeOneDrive:
+			if storage.OneDriveStorage != nil {
+				storage.OneDriveStorage.StorageID = storage.ID // Ensure ID is set
+				if err := tx.Save(storage.OneDriveStorage).Error; err != nil {
+					return err
+				}
+			}
 		}
 
 		return nil
@@ -96,15 +185,23 @@ func (r *StorageRepository) FindByID(id uuid.UUID) (*Storage, error) {
 		Preload("S3Storage").
 		Preload("GoogleDriveStorage").
 		Preload("NASStorage").
+		Preload("SFTPStorage").
+		Preload("AzureBlobStorage").
+		Preload("FTPStorage").
+		Preload("WebDAVStorage").
+		Preload("DropboxStorage").
+		Preload("OneDriveStorage").
 		Where("id = ?", id).
 		First(&s).Error; err != nil {
 		return nil, err
 	}
 
+	r.setTokenRefreshListeners(&s)
+
 	return &s, nil
 }
 
-func (r *StorageRepository) FindByUserID(userID uuid.UUID) ([]*Storage, error) {
+func (r *StorageRepository) FindByWorkspaceIDs(workspaceIDs []uuid.UUID) ([]*Storage, error) {
 	var storages []*Storage
 
 	if err := db.
@@ -113,7 +210,13 @@ func (r *StorageRepository) FindByUserID(userID uuid.UUID) ([]*Storage, error) {
 		Preload("S3Storage").
 		Preload("GoogleDriveStorage").
 		Preload("NASStorage").
-		Where("user_id = ?", userID).
+		Preload("SFTPStorage").
+		Preload("AzureBlobStorage").
+		Preload("FTPStorage").
+		Preload("WebDAVStorage").
+		Preload("DropboxStorage").
+		Preload("OneDriveStorage").
+		Where("workspace_id IN ?", workspaceIDs).
 		Order("name ASC").
 		Find(&storages).Error; err != nil {
 		return nil, err
@@ -150,9 +253,99 @@ func (r *StorageRepository) Delete(s *Storage) error {
 					return err
 				}
 			}
+		case StorageTypeSFTP:
+			if s.SFTPStorage != nil {
+				if err := tx.Delete(s.SFTPStorage).Error; err != nil {
+					return err
+				}
+			}
+		case StorageTypeAzureBlob:
+			if s.AzureBlobStorage != nil {
+				if err := tx.Delete(s.AzureBlobStorage).Error; err != nil {
+					return err
+				}
+			}
+		case StorageTypeFTP:
+			if s.FTPStorage != nil {
+				if err := tx.Delete(s.FTPStorage).Error; err != nil {
+					return err
+				}
+			}
+		case StorageTypeWebDAV:
+			if s.WebDAVStorage != nil {
+				if err := tx.Delete(s.WebDAVStorage).Error; err != nil {
+					return err
+				}
+			}
+		case StorageTypeDropbox:
+			if s.DropboxStorage != nil {
+				if err := tx.Delete(s.DropboxStorage).Error; err != nil {
+					return err
+				}
+			}
+		case StorageTypeOneDrive:
+			if s.OneDriveStorage != nil {
+				if err := tx.Delete(s.OneDriveStorage).Error; err != nil {
+					return err
+				}
+			}
 		}
 
 		// Delete the main storage
 		return tx.Delete(s).Error
 	})
 }
+
+// setTokenRefreshListeners makes OAuth storages save tokens refreshed while
+// working with files, so the tokens survive restarts. Rotated refresh
+// tokens would be lost otherwise
+func (r *StorageRepository) setTokenRefreshListeners(storage *Storage) {
+	if googleDriveStorage := storage.GoogleDriveStorage; googleDriveStorage != nil {
+		googleDriveStorage.SetTokenRefreshListener(func() error {
+			return r.saveTokenJSON(
+				googleDriveStorage.TableName(),
+				storage.ID,
+				googleDriveStorage.TokenJSON,
+			)
+		})
+	}
+
+	if dropboxStorage := storage.DropboxStorage; dropboxStorage != nil {
+		dropboxStorage.SetTokenRefreshListener(func() error {
+			return r.saveTokenJSON(
+				dropboxStorage.TableName(),
+				storage.ID,
+				dropboxStorage.TokenJSON,
+			)
+		})
+	}
+
+	if oneDriveStorage := storage.OneDriveStorage; oneDriveStorage != nil {
+		oneDriveStorage.SetTokenRefreshListener(func() error {
+			return r.saveTokenJSON(
+				oneDriveStorage.TableName(),
+				storage.ID,
+				oneDriveStorage.TokenJSON,
+			)
+		})
+	}
+}
+
+// saveTokenJSON updates only the token column, so settings changed by
+// the user meanwhile are not overwritten. Hooks are skipped, therefore
+// the token is encrypted here
+func (r *StorageRepository) saveTokenJSON(
+	tableName string,
+	storageID uuid.UUID,
+	tokenJSON string,
+) error {
+	if err := secrets.EncryptFields(&tokenJSON); err != nil {
+		return err
+	}
+
+	return db.GetDb().
+		Table(tableName).
+		Where("storage_id = ?", storageID).
+		UpdateColumn("token_json", tokenJSON).
+		Error
+}
@@ -1,14 +1,15 @@
 package storages
 
 import (
-	"errors"
 	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/features/workspaces"
 
 	"github.com/google/uuid"
 )
 
 type StorageService struct {
 	storageRepository *StorageRepository
+	workspaceService  *workspaces.WorkspaceService
 }
 
 func (s *StorageService) SaveStorage(
@@ -21,15 +22,42 @@ func (s *StorageService) SaveStorage(
 			return err
 		}
 
-		if existingStorage.UserID != user.ID {
-			return errors.New("you have not access to this storage")
+		if err := s.workspaceService.CheckRole(
+			user,
+			existingStorage.WorkspaceID,
+			workspaces.WorkspaceRoleAdmin,
+		); err != nil {
+			return err
 		}
 
 		storage.UserID = existingStorage.UserID
+		storage.WorkspaceID = existingStorage.WorkspaceID
+		storage.FillSensitiveData(existingStorage)
 	} else {
+		if storage.WorkspaceID == uuid.Nil {
+			workspaceID, err := s.workspaceService.GetDefaultWorkspaceID(user.ID)
+			if err != nil {
+				return err
+			}
+
+			storage.WorkspaceID = workspaceID
+		}
+
+		if err := s.workspaceService.CheckRole(
+			user,
+			storage.WorkspaceID,
+			workspaces.WorkspaceRoleAdmin,
+		); err != nil {
+			return err
+		}
+
 		storage.UserID = user.ID
 	}
 
+	if err := storage.Validate(); err != nil {
+		return err
+	}
+
 	_, err := s.storageRepository.Save(storage)
 	if err != nil {
 		return err
@@ -47,8 +75,12 @@ func (s *StorageService) DeleteStorage(
 		return err
 	}
 
-	if storage.UserID != user.ID {
-		return errors.New("you have not access to this storage")
+	if err := s.workspaceService.CheckRole(
+		user,
+		storage.WorkspaceID,
+		workspaces.WorkspaceRoleAdmin,
+	); err != nil {
+		return err
 	}
 
 	return s.storageRepository.Delete(storage)
@@ -63,17 +95,41 @@ func (s *StorageService) GetStorage(
 		return nil, err
 	}
 
-	if storage.UserID != user.ID {
-		return nil, errors.New("you have not access to this storage")
+	if err := s.workspaceService.CheckRole(
+		user,
+		storage.WorkspaceID,
+		workspaces.WorkspaceRoleViewer,
+	); err != nil {
+		return nil, err
 	}
 
 	return storage, nil
 }
 
+// GetStorages returns storages of the workspace or, if workspace is
+// not specified, storages of all workspaces of the user
 func (s *StorageService) GetStorages(
 	user *users_models.User,
+	workspaceID *uuid.UUID,
 ) ([]*Storage, error) {
-	return s.storageRepository.FindByUserID(user.ID)
+	if workspaceID != nil {
+		if err := s.workspaceService.CheckRole(
+			user,
+			*workspaceID,
+			workspaces.WorkspaceRoleViewer,
+		); err != nil {
+			return nil, err
+		}
+
+		return s.storageRepository.FindByWorkspaceIDs([]uuid.UUID{*workspaceID})
+	}
+
+	workspaceIDs, err := s.workspaceService.GetWorkspaceIDs(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storageRepository.FindByWorkspaceIDs(workspaceIDs)
 }
 
 func (s *StorageService) TestStorageConnection(
@@ -85,8 +141,12 @@ func (s *StorageService) TestStorageConnection(
 		return err
 	}
 
-	if storage.UserID != user.ID {
-		return errors.New("you have not access to this storage")
+	if err := s.workspaceService.CheckRole(
+		user,
+		storage.WorkspaceID,
+		workspaces.WorkspaceRoleOperator,
+	); err != nil {
+		return err
 	}
 
 	err = storage.TestConnection()
@@ -105,9 +165,34 @@ func (s *StorageService) TestStorageConnection(
 	return nil
 }
 
+// TestStorageConnectionDirect tests storage settings from the request. For
+// saved storages the stored credentials are used for the fields the client
+// left empty
 func (s *StorageService) TestStorageConnectionDirect(
+	user *users_models.User,
 	storage *Storage,
 ) error {
+	if storage.ID != uuid.Nil {
+		existingStorage, err := s.storageRepository.FindByID(storage.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.workspaceService.CheckRole(
+			user,
+			existingStorage.WorkspaceID,
+			workspaces.WorkspaceRoleOperator,
+		); err != nil {
+			return err
+		}
+
+		storage.FillSensitiveData(existingStorage)
+	}
+
+	if err := storage.Validate(); err != nil {
+		return err
+	}
+
 	return storage.TestConnection()
 }
 
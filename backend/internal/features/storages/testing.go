@@ -2,6 +2,7 @@ package storages
 
 import (
 	local_storage "postgresus-backend/internal/features/storages/models/local"
+	"postgresus-backend/internal/features/workspaces"
 
 	"github.com/google/uuid"
 )
@@ -9,6 +10,7 @@ import (
 func CreateTestStorage(userID uuid.UUID) *Storage {
 	storage := &Storage{
 		UserID:       userID,
+		WorkspaceID:  workspaces.GetTestWorkspaceID(userID),
 		Type:         StorageTypeLocal,
 		Name:         "Test Storage " + uuid.New().String(),
 		LocalStorage: &local_storage.LocalStorage{},
@@ -0,0 +1,49 @@
+package system_metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"postgresus-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type MetricsController struct {
+	metricsHandler http.Handler
+}
+
+// RegisterRoutes expects the root router: Prometheus scrapes /metrics
+// by default
+func (c *MetricsController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/metrics", c.GetMetrics)
+}
+
+// GetMetrics returns metrics in Prometheus format. If METRICS_TOKEN
+// is set, it is required as bearer token
+func (c *MetricsController) GetMetrics(ctx *gin.Context) {
+	metricsToken := config.GetEnv().MetricsToken
+	if metricsToken != "" {
+		authorizationHeader := ctx.GetHeader("Authorization")
+
+		if subtle.ConstantTimeCompare(
+			[]byte(authorizationHeader),
+			[]byte("Bearer "+metricsToken),
+		) != 1 {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid metrics token"})
+			return
+		}
+	}
+
+	c.metricsHandler.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// newMetricsHandler serves metrics of own registry, so Go runtime
+// metrics of the default registry are not exposed
+func newMetricsHandler(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(
+		registry,
+		promhttp.HandlerOpts{ErrorHandling: promhttp.HTTPErrorOnError},
+	)
+}
@@ -0,0 +1,43 @@
+package system_metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"postgresus-backend/internal/features/backups/backups"
+	"postgresus-backend/internal/features/databases"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MetricsHandler_BackupHistogramsExposed(t *testing.T) {
+	backupMetrics := backups.NewBackupMetrics()
+	database := &databases.Database{ID: uuid.New(), Name: "my \"db\""}
+
+	backupMetrics.ObserveBackup(database, &backups.Backup{BackupDurationMs: 1500, BackupSizeMb: 2})
+	backupMetrics.ObserveBackup(database, &backups.Backup{BackupDurationMs: 500, BackupSizeMb: 1})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(backupMetrics)
+
+	recorder := httptest.NewRecorder()
+	newMetricsHandler(registry).ServeHTTP(
+		recorder,
+		httptest.NewRequest(http.MethodGet, "/metrics", nil),
+	)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	body, err := io.ReadAll(recorder.Body)
+	assert.NoError(t, err)
+
+	labels := `database_id="` + database.ID.String() + `",database_name="my \"db\""`
+	assert.Contains(t, string(body), "# TYPE postgresus_backup_duration_seconds histogram")
+	assert.Contains(t, string(body), `postgresus_backup_duration_seconds_bucket{`+labels+`,le="1"} 1`)
+	assert.Contains(t, string(body), "postgresus_backup_duration_seconds_sum{"+labels+"} 2")
+	assert.Contains(t, string(body), "postgresus_backup_duration_seconds_count{"+labels+"} 2")
+	assert.Contains(t, string(body), "# TYPE postgresus_backup_size_bytes histogram")
+	assert.Contains(t, string(body), "postgresus_backup_size_bytes_sum{"+labels+"} 3.145728e+06")
+}
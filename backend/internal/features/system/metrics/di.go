@@ -0,0 +1,25 @@
+package system_metrics
+
+import (
+	"postgresus-backend/internal/features/backups/backups"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metricsRepository = &MetricsRepository{}
+var metricsService = &MetricsService{
+	metricsRepository,
+	backups.GetBackupBackgroundService(),
+}
+var metricsRegistry = prometheus.NewRegistry()
+var metricsController = &MetricsController{
+	newMetricsHandler(metricsRegistry),
+}
+
+func SetupDependencies() {
+	metricsRegistry.MustRegister(metricsService, backups.GetBackupMetrics())
+}
+
+func GetMetricsController() *MetricsController {
+	return metricsController
+}
@@ -0,0 +1,35 @@
+package system_metrics
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type statusCount struct {
+	DatabaseID   uuid.UUID `gorm:"column:database_id"`
+	DatabaseName string    `gorm:"column:database_name"`
+	Status       string    `gorm:"column:status"`
+	Count        int64     `gorm:"column:count"`
+}
+
+type completedBackup struct {
+	DatabaseID       uuid.UUID `gorm:"column:database_id"`
+	DatabaseName     string    `gorm:"column:database_name"`
+	BackupDurationMs int64     `gorm:"column:backup_duration_ms"`
+	BackupSizeMb     float64   `gorm:"column:backup_size_mb"`
+}
+
+type databaseState struct {
+	ID                   uuid.UUID  `gorm:"column:id"`
+	Name                 string     `gorm:"column:name"`
+	LastBackupTime       *time.Time `gorm:"column:last_backup_time"`
+	HealthStatus         *string    `gorm:"column:health_status"`
+	IsHealthcheckEnabled bool       `gorm:"column:is_healthcheck_enabled"`
+}
+
+type errorState struct {
+	ID       uuid.UUID `gorm:"column:id"`
+	Name     string    `gorm:"column:name"`
+	HasError bool      `gorm:"column:has_error"`
+}
@@ -0,0 +1,101 @@
+package system_metrics
+
+import (
+	"postgresus-backend/internal/storage"
+)
+
+type MetricsRepository struct{}
+
+func (r *MetricsRepository) GetBackupCounts() ([]*statusCount, error) {
+	var counts []*statusCount
+
+	err := storage.GetDb().
+		Raw(`
+			SELECT d.id AS database_id, d.name AS database_name, b.status, COUNT(*) AS count
+			FROM backups b
+			JOIN databases d ON d.id = b.database_id
+			GROUP BY d.id, d.name, b.status
+			ORDER BY d.name, d.id, b.status`).
+		Scan(&counts).Error
+
+	return counts, err
+}
+
+// GetLastCompletedBackups returns the last completed backup of each database
+func (r *MetricsRepository) GetLastCompletedBackups() ([]*completedBackup, error) {
+	var backups []*completedBackup
+
+	err := storage.GetDb().
+		Raw(`
+			SELECT database_id, database_name, backup_duration_ms, backup_size_mb
+			FROM (
+				SELECT DISTINCT ON (b.database_id)
+					d.id AS database_id, d.name AS database_name,
+					b.backup_duration_ms, b.backup_size_mb
+				FROM backups b
+				JOIN databases d ON d.id = b.database_id
+				WHERE b.status = 'COMPLETED'
+				ORDER BY b.database_id, b.created_at DESC
+			) last_backups
+			ORDER BY database_name, database_id`).
+		Scan(&backups).Error
+
+	return backups, err
+}
+
+func (r *MetricsRepository) GetRestoreCounts() ([]*statusCount, error) {
+	var counts []*statusCount
+
+	err := storage.GetDb().
+		Raw(`
+			SELECT d.id AS database_id, d.name AS database_name, r.status, COUNT(*) AS count
+			FROM restores r
+			JOIN backups b ON b.id = r.backup_id
+			JOIN databases d ON d.id = b.database_id
+			GROUP BY d.id, d.name, r.status
+			ORDER BY d.name, d.id, r.status`).
+		Scan(&counts).Error
+
+	return counts, err
+}
+
+func (r *MetricsRepository) GetDatabases() ([]*databaseState, error) {
+	var databases []*databaseState
+
+	err := storage.GetDb().
+		Raw(`
+			SELECT d.id, d.name, d.last_backup_time, d.health_status,
+				COALESCE(h.is_healthcheck_enabled, FALSE) AS is_healthcheck_enabled
+			FROM databases d
+			LEFT JOIN healthcheck_configs h ON h.database_id = d.id
+			ORDER BY d.name, d.id`).
+		Scan(&databases).Error
+
+	return databases, err
+}
+
+func (r *MetricsRepository) GetStorages() ([]*errorState, error) {
+	var storages []*errorState
+
+	err := storage.GetDb().
+		Raw(`
+			SELECT id, name, last_save_error IS NOT NULL AS has_error
+			FROM storages
+			ORDER BY name, id`).
+		Scan(&storages).Error
+
+	return storages, err
+}
+
+func (r *MetricsRepository) GetNotifiers() ([]*errorState, error) {
+	var notifiers []*errorState
+
+	err := storage.GetDb().
+		Raw(`
+			SELECT id, name, last_send_error IS NOT NULL AS has_error
+			FROM notifiers
+			ORDER BY name, id`).
+		Scan(&notifiers).Error
+
+	return notifiers, err
+}
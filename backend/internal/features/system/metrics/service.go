@@ -0,0 +1,252 @@
+package system_metrics
+
+import (
+	"postgresus-backend/internal/features/backups/backups"
+	"postgresus-backend/internal/features/databases"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var databaseLabelNames = []string{"database_id", "database_name"}
+
+var (
+	backupsWorkerRunningDesc = prometheus.NewDesc(
+		"postgresus_backups_worker_running",
+		"Whether the backups worker has been running within the last 5 minutes",
+		nil,
+		nil,
+	)
+	backupsDesc = prometheus.NewDesc(
+		"postgresus_backups",
+		"Number of stored backups by status",
+		[]string{"database_id", "database_name", "status"},
+		nil,
+	)
+	lastBackupDurationDesc = prometheus.NewDesc(
+		"postgresus_last_backup_duration_seconds",
+		"Duration of the last completed backup of the database",
+		databaseLabelNames,
+		nil,
+	)
+	lastBackupSizeDesc = prometheus.NewDesc(
+		"postgresus_last_backup_size_bytes",
+		"Size of the last completed backup of the database",
+		databaseLabelNames,
+		nil,
+	)
+	restoresDesc = prometheus.NewDesc(
+		"postgresus_restores",
+		"Number of restores of stored backups by status",
+		[]string{"database_id", "database_name", "status"},
+		nil,
+	)
+	lastSuccessfulBackupTimeDesc = prometheus.NewDesc(
+		"postgresus_database_last_successful_backup_timestamp_seconds",
+		"Unix time of the last successful backup of the database",
+		databaseLabelNames,
+		nil,
+	)
+	databaseAvailableDesc = prometheus.NewDesc(
+		"postgresus_database_available",
+		"Whether the database is available by healthcheck. Only databases with enabled healthcheck are reported",
+		databaseLabelNames,
+		nil,
+	)
+	storageSaveErrorDesc = prometheus.NewDesc(
+		"postgresus_storage_save_error",
+		"Whether the last save of a file to the storage failed",
+		[]string{"storage_id", "storage_name"},
+		nil,
+	)
+	notifierSendErrorDesc = prometheus.NewDesc(
+		"postgresus_notifier_send_error",
+		"Whether the last notification sent by the notifier failed",
+		[]string{"notifier_id", "notifier_name"},
+		nil,
+	)
+)
+
+// MetricsService collects current state from the internal DB on each
+// scrape, so metrics survive restarts of the application
+type MetricsService struct {
+	metricsRepository       *MetricsRepository
+	backupBackgroundService *backups.BackupBackgroundService
+}
+
+func (s *MetricsService) Describe(ch chan<- *prometheus.Desc) {
+	ch <- backupsWorkerRunningDesc
+	ch <- backupsDesc
+	ch <- lastBackupDurationDesc
+	ch <- lastBackupSizeDesc
+	ch <- restoresDesc
+	ch <- lastSuccessfulBackupTimeDesc
+	ch <- databaseAvailableDesc
+	ch <- storageSaveErrorDesc
+	ch <- notifierSendErrorDesc
+}
+
+func (s *MetricsService) Collect(ch chan<- prometheus.Metric) {
+	ch <- gauge(
+		backupsWorkerRunningDesc,
+		boolToFloat(s.backupBackgroundService.IsBackupsWorkerRunning()),
+	)
+
+	if err := s.collectBackupMetrics(ch); err != nil {
+		ch <- prometheus.NewInvalidMetric(backupsDesc, err)
+	}
+
+	if err := s.collectRestoreMetrics(ch); err != nil {
+		ch <- prometheus.NewInvalidMetric(restoresDesc, err)
+	}
+
+	if err := s.collectDatabaseMetrics(ch); err != nil {
+		ch <- prometheus.NewInvalidMetric(lastSuccessfulBackupTimeDesc, err)
+	}
+
+	if err := s.collectStorageMetrics(ch); err != nil {
+		ch <- prometheus.NewInvalidMetric(storageSaveErrorDesc, err)
+	}
+
+	if err := s.collectNotifierMetrics(ch); err != nil {
+		ch <- prometheus.NewInvalidMetric(notifierSendErrorDesc, err)
+	}
+}
+
+func (s *MetricsService) collectBackupMetrics(ch chan<- prometheus.Metric) error {
+	counts, err := s.metricsRepository.GetBackupCounts()
+	if err != nil {
+		return err
+	}
+
+	for _, count := range counts {
+		ch <- gauge(
+			backupsDesc,
+			float64(count.Count),
+			count.DatabaseID.String(),
+			count.DatabaseName,
+			count.Status,
+		)
+	}
+
+	// duration and size of each backup are recorded to histograms when the
+	// backup is made, the last values are kept as gauges for alerts
+	lastBackups, err := s.metricsRepository.GetLastCompletedBackups()
+	if err != nil {
+		return err
+	}
+
+	for _, backup := range lastBackups {
+		ch <- gauge(
+			lastBackupDurationDesc,
+			float64(backup.BackupDurationMs)/1000,
+			backup.DatabaseID.String(),
+			backup.DatabaseName,
+		)
+		ch <- gauge(
+			lastBackupSizeDesc,
+			backup.BackupSizeMb*1024*1024,
+			backup.DatabaseID.String(),
+			backup.DatabaseName,
+		)
+	}
+
+	return nil
+}
+
+func (s *MetricsService) collectRestoreMetrics(ch chan<- prometheus.Metric) error {
+	counts, err := s.metricsRepository.GetRestoreCounts()
+	if err != nil {
+		return err
+	}
+
+	for _, count := range counts {
+		ch <- gauge(
+			restoresDesc,
+			float64(count.Count),
+			count.DatabaseID.String(),
+			count.DatabaseName,
+			count.Status,
+		)
+	}
+
+	return nil
+}
+
+func (s *MetricsService) collectDatabaseMetrics(ch chan<- prometheus.Metric) error {
+	databaseStates, err := s.metricsRepository.GetDatabases()
+	if err != nil {
+		return err
+	}
+
+	for _, database := range databaseStates {
+		if database.LastBackupTime != nil {
+			ch <- gauge(
+				lastSuccessfulBackupTimeDesc,
+				float64(database.LastBackupTime.Unix()),
+				database.ID.String(),
+				database.Name,
+			)
+		}
+
+		if database.IsHealthcheckEnabled && database.HealthStatus != nil {
+			ch <- gauge(
+				databaseAvailableDesc,
+				boolToFloat(
+					databases.HealthStatus(*database.HealthStatus) == databases.HealthStatusAvailable,
+				),
+				database.ID.String(),
+				database.Name,
+			)
+		}
+	}
+
+	return nil
+}
+
+func (s *MetricsService) collectStorageMetrics(ch chan<- prometheus.Metric) error {
+	storageStates, err := s.metricsRepository.GetStorages()
+	if err != nil {
+		return err
+	}
+
+	for _, storage := range storageStates {
+		ch <- gauge(
+			storageSaveErrorDesc,
+			boolToFloat(storage.HasError),
+			storage.ID.String(),
+			storage.Name,
+		)
+	}
+
+	return nil
+}
+
+func (s *MetricsService) collectNotifierMetrics(ch chan<- prometheus.Metric) error {
+	notifierStates, err := s.metricsRepository.GetNotifiers()
+	if err != nil {
+		return err
+	}
+
+	for _, notifier := range notifierStates {
+		ch <- gauge(
+			notifierSendErrorDesc,
+			boolToFloat(notifier.HasError),
+			notifier.ID.String(),
+			notifier.Name,
+		)
+	}
+
+	return nil
+}
+
+func gauge(desc *prometheus.Desc, value float64, labelValues ...string) prometheus.Metric {
+	return prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labelValues...)
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
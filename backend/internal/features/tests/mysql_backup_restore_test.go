@@ -0,0 +1,226 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/backups/backups"
+	usecases_mysql_backup "postgresus-backend/internal/features/backups/backups/usecases/mysql"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	mysqltypes "postgresus-backend/internal/features/databases/databases/mysql"
+	"postgresus-backend/internal/features/intervals"
+	"postgresus-backend/internal/features/restores/models"
+	usecases_mysql_restore "postgresus-backend/internal/features/restores/usecases/mysql"
+	"postgresus-backend/internal/features/storages"
+	local_storage "postgresus-backend/internal/features/storages/models/local"
+	"postgresus-backend/internal/util/period"
+	"postgresus-backend/internal/util/tools"
+	"strconv"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+const createAndFillMysqlTableQuery = `
+DROP TABLE IF EXISTS test_data;
+
+CREATE TABLE test_data (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name TEXT NOT NULL,
+    value INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO test_data (name, value) VALUES
+    ('test1', 100),
+    ('test2', 200),
+    ('test3', 300);
+`
+
+type MysqlContainer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Database string
+	Flavor   tools.MysqlFlavor
+	DB       *sqlx.DB
+}
+
+func Test_BackupAndRestoreMysql_RestoreIsSuccesful(t *testing.T) {
+	env := config.GetEnv()
+	cases := []struct {
+		name   string
+		flavor tools.MysqlFlavor
+		port   string
+	}{
+		{"MySQL", tools.MysqlFlavorMysql, env.TestMysqlPort},
+		{"MariaDB", tools.MysqlFlavorMariadb, env.TestMariadbPort},
+	}
+
+	for _, tc := range cases {
+		tc := tc // capture loop variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			testMysqlBackupRestoreForFlavor(t, tc.flavor, tc.port)
+		})
+	}
+}
+
+func testMysqlBackupRestoreForFlavor(t *testing.T, flavor tools.MysqlFlavor, port string) {
+	container, err := connectToMysqlContainer(flavor, port, "testdb")
+	assert.NoError(t, err)
+	defer func() {
+		if container.DB != nil {
+			container.DB.Close()
+		}
+	}()
+
+	_, err = container.DB.Exec(createAndFillMysqlTableQuery)
+	assert.NoError(t, err)
+
+	backupID := uuid.New()
+
+	backupDb := &databases.Database{
+		ID:   uuid.New(),
+		Type: databases.DatabaseTypeMysql,
+		Name: "Test Database",
+		Mysql: &mysqltypes.MysqlDatabase{
+			Flavor:   flavor,
+			Host:     container.Host,
+			Port:     container.Port,
+			Username: container.Username,
+			Password: container.Password,
+			Database: &container.Database,
+			IsHttps:  false,
+		},
+	}
+
+	storageID := uuid.New()
+	backupConfig := &backups_config.BackupConfig{
+		DatabaseID:       backupDb.ID,
+		IsBackupsEnabled: true,
+		BackupType:       backups_config.BackupTypeLogical,
+		StorePeriod:      period.PeriodDay,
+		BackupInterval:   &intervals.Interval{Interval: intervals.IntervalDaily},
+		StorageID:        &storageID,
+		CpuCount:         1,
+		Encryption:       backups_config.BackupEncryptionNone,
+	}
+
+	storage := &storages.Storage{
+		UserID:       uuid.New(),
+		Type:         storages.StorageTypeLocal,
+		Name:         "Test Storage",
+		LocalStorage: &local_storage.LocalStorage{},
+	}
+
+	// Make backup
+	progressTracker := func(completedMBs float64) {}
+	_, err = usecases_mysql_backup.GetCreateMysqlBackupUsecase().Execute(
+		context.Background(),
+		backupID,
+		backupConfig,
+		backupDb,
+		storage,
+		nil,
+		progressTracker,
+	)
+	assert.NoError(t, err)
+
+	// Restore creates the database if it does not exist
+	newDBName := "restoreddb"
+	_, err = container.DB.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s;", newDBName))
+	assert.NoError(t, err)
+
+	completedBackup := &backups.Backup{
+		ID:         backupID,
+		DatabaseID: backupDb.ID,
+		StorageID:  storage.ID,
+		Status:     backups.BackupStatusCompleted,
+		CreatedAt:  time.Now().UTC(),
+		Storage:    storage,
+		Database:   backupDb,
+		Encryption: backupConfig.Encryption,
+	}
+
+	restore := models.Restore{
+		ID:     uuid.New(),
+		Backup: completedBackup,
+		Mysql: &mysqltypes.MysqlDatabase{
+			Flavor:   flavor,
+			Host:     container.Host,
+			Port:     container.Port,
+			Username: container.Username,
+			Password: container.Password,
+			Database: &newDBName,
+			IsHttps:  false,
+		},
+	}
+
+	restoreBackupUC := usecases_mysql_restore.GetRestoreMysqlBackupUsecase()
+	err = restoreBackupUC.Execute(
+		context.Background(),
+		backupConfig,
+		restore,
+		completedBackup,
+		backups.GetBackupService().GetBackupFiles(completedBackup),
+	)
+	assert.NoError(t, err)
+
+	restoredContainer, err := connectToMysqlContainer(flavor, port, newDBName)
+	assert.NoError(t, err)
+	defer restoredContainer.DB.Close()
+
+	verifyDataIntegrity(t, container.DB, restoredContainer.DB)
+
+	// Clean up the backup file after the test
+	err = os.Remove(filepath.Join(config.GetEnv().DataFolder, backupID.String()))
+	if err != nil {
+		t.Logf("Warning: Failed to delete backup file: %v", err)
+	}
+}
+
+// connectToMysqlContainer connects as root, because
+// restore needs privileges to create databases
+func connectToMysqlContainer(
+	flavor tools.MysqlFlavor,
+	port string,
+	dbName string,
+) (*MysqlContainer, error) {
+	password := "testpassword"
+	username := "root"
+	host := "localhost"
+
+	portInt, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse port: %w", err)
+	}
+
+	dsn := fmt.Sprintf(
+		"%s:%s@tcp(%s:%d)/%s?parseTime=true&multiStatements=true",
+		username, password, host, portInt, dbName,
+	)
+
+	db, err := sqlx.Connect("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return &MysqlContainer{
+		Host:     host,
+		Port:     portInt,
+		Username: username,
+		Password: password,
+		Database: dbName,
+		Flavor:   flavor,
+		DB:       db,
+	}, nil
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,6 +9,7 @@ import (
 	"postgresus-backend/internal/features/backups/backups"
 	usecases_postgresql_backup "postgresus-backend/internal/features/backups/backups/usecases/postgresql"
 	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_encryption "postgresus-backend/internal/features/backups/encryption"
 	"postgresus-backend/internal/features/databases"
 	pgtypes "postgresus-backend/internal/features/databases/databases/postgresql"
 	"postgresus-backend/internal/features/intervals"
@@ -79,13 +81,25 @@ func Test_BackupAndRestorePostgresql_RestoreIsSuccesful(t *testing.T) {
 		tc := tc // capture loop variable
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel() // Enable parallel execution
-			testBackupRestoreForVersion(t, tc.version, tc.port)
+			testBackupRestoreForVersion(t, tc.version, tc.port, nil)
 		})
 	}
 }
 
+func Test_BackupAndRestoreEncryptedPostgresql_RestoreIsSuccesful(t *testing.T) {
+	encryptionKey, err := backups_encryption.GetBackupEncryptionKeyService().GetActiveKey()
+	assert.NoError(t, err)
+
+	testBackupRestoreForVersion(t, "17", config.GetEnv().TestPostgres17Port, encryptionKey)
+}
+
 // Run a test for a specific PostgreSQL version
-func testBackupRestoreForVersion(t *testing.T, pgVersion string, port string) {
+func testBackupRestoreForVersion(
+	t *testing.T,
+	pgVersion string,
+	port string,
+	encryptionKey *backups_encryption.BackupEncryptionKey,
+) {
 	// Connect to pre-configured PostgreSQL container
 	container, err := connectToPostgresContainer(pgVersion, port)
 	assert.NoError(t, err)
@@ -121,10 +135,16 @@ func testBackupRestoreForVersion(t *testing.T, pgVersion string, port string) {
 	backupConfig := &backups_config.BackupConfig{
 		DatabaseID:       backupDb.ID,
 		IsBackupsEnabled: true,
+		BackupType:       backups_config.BackupTypeLogical,
 		StorePeriod:      period.PeriodDay,
 		BackupInterval:   &intervals.Interval{Interval: intervals.IntervalDaily},
 		StorageID:        &storageID,
 		CpuCount:         1,
+		Encryption:       backups_config.BackupEncryptionNone,
+	}
+
+	if encryptionKey != nil {
+		backupConfig.Encryption = backups_config.BackupEncryptionAES256GCM
 	}
 
 	storage := &storages.Storage{
@@ -136,15 +156,24 @@ func testBackupRestoreForVersion(t *testing.T, pgVersion string, port string) {
 
 	// Make backup
 	progressTracker := func(completedMBs float64) {}
-	err = usecases_postgresql_backup.GetCreatePostgresqlBackupUsecase().Execute(
+	_, err = usecases_postgresql_backup.GetCreatePostgresqlBackupUsecase().Execute(
+		context.Background(),
 		backupID,
 		backupConfig,
 		backupDb,
 		storage,
+		encryptionKey,
 		progressTracker,
 	)
 	assert.NoError(t, err)
 
+	if encryptionKey != nil {
+		// pg_dump custom format files start with "PGDMP"
+		backupFile, err := os.ReadFile(filepath.Join(config.GetEnv().DataFolder, backupID.String()))
+		assert.NoError(t, err)
+		assert.NotContains(t, string(backupFile[:min(len(backupFile), 16)]), "PGDMP")
+	}
+
 	// Create new database
 	newDBName := "restoreddb"
 	_, err = container.DB.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s;", newDBName))
@@ -169,6 +198,11 @@ func testBackupRestoreForVersion(t *testing.T, pgVersion string, port string) {
 		CreatedAt:  time.Now().UTC(),
 		Storage:    storage,
 		Database:   backupDb,
+		Encryption: backupConfig.Encryption,
+	}
+
+	if encryptionKey != nil {
+		completedBackup.EncryptionKeyID = &encryptionKey.ID
 	}
 
 	restoreID := uuid.New()
@@ -188,7 +222,13 @@ func testBackupRestoreForVersion(t *testing.T, pgVersion string, port string) {
 
 	// Restore the backup
 	restoreBackupUC := usecases_postgresql_restore.GetRestorePostgresqlBackupUsecase()
-	err = restoreBackupUC.Execute(backupConfig, restore, completedBackup, storage)
+	err = restoreBackupUC.Execute(
+		context.Background(),
+		backupConfig,
+		restore,
+		completedBackup,
+		backups.GetBackupService().GetBackupFiles(completedBackup),
+	)
 	assert.NoError(t, err)
 
 	// Verify restored table exists
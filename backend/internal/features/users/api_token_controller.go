@@ -0,0 +1,125 @@
+package users
+
+import (
+	"net/http"
+	user_enums "postgresus-backend/internal/features/users/enums"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ApiTokenController struct {
+	userService *UserService
+}
+
+func (c *ApiTokenController) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/api-tokens", c.CreateApiToken)
+	router.GET("/api-tokens", c.GetApiTokens)
+	router.DELETE("/api-tokens/:id", c.RevokeApiToken)
+}
+
+// CreateApiToken
+// @Summary Create an API token
+// @Description Create a scoped API token for automation. The token is returned only once
+// @Tags api-tokens
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param request body CreateApiTokenRequest true "API token data"
+// @Success 200 {object} CreateApiTokenResponse
+// @Failure 400
+// @Failure 401
+// @Router /api-tokens [post]
+func (c *ApiTokenController) CreateApiToken(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request CreateApiTokenRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := c.userService.CreateApiToken(user, &request)
+	if err != nil {
+		c.userService.notifyUserAction(
+			newUserActionEvent(ctx, user_enums.UserActionCreateApiToken, user, nil, err),
+		)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.userService.notifyUserAction(newUserActionEvent(
+		ctx,
+		user_enums.UserActionCreateApiToken,
+		user,
+		&response.ApiToken.ID,
+		nil,
+	))
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetApiTokens
+// @Summary Get API tokens
+// @Description Get API tokens of the current user, including expired and revoked ones
+// @Tags api-tokens
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Success 200 {array} users_models.ApiToken
+// @Failure 400
+// @Failure 401
+// @Router /api-tokens [get]
+func (c *ApiTokenController) GetApiTokens(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	apiTokens, err := c.userService.GetApiTokens(user)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, apiTokens)
+}
+
+// RevokeApiToken
+// @Summary Revoke an API token
+// @Description Revoke an API token. Requests with the token are rejected right away
+// @Tags api-tokens
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "API token ID"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Router /api-tokens/{id} [delete]
+func (c *ApiTokenController) RevokeApiToken(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid API token ID"})
+		return
+	}
+
+	err = c.userService.RevokeApiToken(user, id)
+	c.userService.notifyUserAction(
+		newUserActionEvent(ctx, user_enums.UserActionRevokeApiToken, user, &id, err),
+	)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "API token revoked successfully"})
+}
@@ -0,0 +1,65 @@
+package users
+
+import (
+	"net/http"
+	user_enums "postgresus-backend/internal/features/users/enums"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiTokenRouteScopes lists routes available for API tokens. Other
+// routes (settings, users, tokens management) require user JWT
+var apiTokenRouteScopes = map[string]user_enums.ApiTokenScope{
+	"GET /api/v1/databases":                          user_enums.ApiTokenScopeDatabasesRead,
+	"GET /api/v1/databases/:id":                      user_enums.ApiTokenScopeDatabasesRead,
+	"GET /api/v1/backups":                            user_enums.ApiTokenScopeBackupsRead,
+	"GET /api/v1/backups/:id/file":                   user_enums.ApiTokenScopeBackupsRead,
+	"GET /api/v1/backups/:id/members/:memberId/file": user_enums.ApiTokenScopeBackupsRead,
+	"POST /api/v1/backups":                           user_enums.ApiTokenScopeBackupsCreate,
+	"GET /api/v1/restores/:backupId":                 user_enums.ApiTokenScopeRestoresRead,
+	"POST /api/v1/restores/:backupId/restore":        user_enums.ApiTokenScopeRestoresCreate,
+}
+
+// GetApiTokenScopeRequired returns scope required to call the route
+// by API token. False means the route is not available for API tokens
+func GetApiTokenScopeRequired(method string, path string) (user_enums.ApiTokenScope, bool) {
+	scope, ok := apiTokenRouteScopes[method+" "+path]
+	return scope, ok
+}
+
+// ApiTokenScopeMiddleware checks scope of API tokens before the
+// request reaches controllers. Requests with JWT are passed as is
+func (s *UserService) ApiTokenScopeMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token := ctx.GetHeader("Authorization")
+		if !IsApiToken(token) {
+			ctx.Next()
+			return
+		}
+
+		apiToken, err := s.GetActiveApiToken(token)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		scope, ok := GetApiTokenScopeRequired(ctx.Request.Method, ctx.FullPath())
+		if !ok {
+			ctx.AbortWithStatusJSON(
+				http.StatusForbidden,
+				gin.H{"error": "the endpoint is not available for API tokens"},
+			)
+			return
+		}
+
+		if !apiToken.HasScope(scope) {
+			ctx.AbortWithStatusJSON(
+				http.StatusForbidden,
+				gin.H{"error": "API token has no scope " + string(scope)},
+			)
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,141 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	user_models "postgresus-backend/internal/features/users/models"
+)
+
+const (
+	apiTokenPrefix       = "pgs_"
+	apiTokenPrefixLength = len(apiTokenPrefix) + 8
+)
+
+// IsApiToken tells API tokens from user JWTs by the prefix. CI tools
+// usually send tokens with "Bearer" scheme, so it is accepted too
+func IsApiToken(token string) bool {
+	return strings.HasPrefix(trimBearerScheme(token), apiTokenPrefix)
+}
+
+func (s *UserService) CreateApiToken(
+	user *user_models.User,
+	request *CreateApiTokenRequest,
+) (*CreateApiTokenResponse, error) {
+	if user.ApiToken != nil {
+		return nil, errors.New("API tokens cannot be managed by API token")
+	}
+
+	if strings.TrimSpace(request.Name) == "" {
+		return nil, errors.New("name is required")
+	}
+
+	if len(request.Scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+
+	for _, scope := range request.Scopes {
+		if !scope.IsValid() {
+			return nil, fmt.Errorf("invalid scope: %s", scope)
+		}
+	}
+
+	for _, databaseID := range request.DatabaseIDs {
+		if databaseID == uuid.Nil {
+			return nil, errors.New("database ID is invalid")
+		}
+	}
+
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now().UTC()) {
+		return nil, errors.New("expiration time must be in the future")
+	}
+
+	secret, err := generateSecretToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API token: %w", err)
+	}
+
+	token := apiTokenPrefix + secret
+
+	apiToken := &user_models.ApiToken{
+		UserID:      user.ID,
+		Name:        strings.TrimSpace(request.Name),
+		TokenPrefix: token[:apiTokenPrefixLength],
+		TokenHash:   hashToken(token),
+		Scopes:      request.Scopes,
+		DatabaseIDs: request.DatabaseIDs,
+		ExpiresAt:   request.ExpiresAt,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	if err := s.apiTokenRepository.Save(apiToken); err != nil {
+		return nil, err
+	}
+
+	return &CreateApiTokenResponse{
+		ApiToken: apiToken,
+		Token:    token,
+	}, nil
+}
+
+func (s *UserService) GetApiTokens(user *user_models.User) ([]*user_models.ApiToken, error) {
+	if user.ApiToken != nil {
+		return nil, errors.New("API tokens cannot be managed by API token")
+	}
+
+	return s.apiTokenRepository.FindByUserID(user.ID)
+}
+
+// RevokeApiToken disables the token. Revoked tokens are kept
+// to see when they were used last time
+func (s *UserService) RevokeApiToken(user *user_models.User, apiTokenID uuid.UUID) error {
+	if user.ApiToken != nil {
+		return errors.New("API tokens cannot be managed by API token")
+	}
+
+	apiToken, err := s.apiTokenRepository.FindByID(apiTokenID)
+	if err != nil {
+		return err
+	}
+
+	if apiToken.UserID != user.ID {
+		return errors.New("you have not access to this API token")
+	}
+
+	if apiToken.RevokedAt != nil {
+		return nil
+	}
+
+	revokedAt := time.Now().UTC()
+	apiToken.RevokedAt = &revokedAt
+
+	return s.apiTokenRepository.Save(apiToken)
+}
+
+// GetActiveApiToken returns the token unless it is unknown, expired or revoked
+func (s *UserService) GetActiveApiToken(token string) (*user_models.ApiToken, error) {
+	apiToken, err := s.apiTokenRepository.FindByTokenHash(
+		hashToken(trimBearerScheme(token)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if apiToken == nil {
+		return nil, errors.New("invalid API token")
+	}
+
+	if !apiToken.IsActive() {
+		return nil, errors.New("API token is expired or revoked")
+	}
+
+	return apiToken, nil
+}
+
+func trimBearerScheme(token string) string {
+	return strings.TrimPrefix(token, "Bearer ")
+}
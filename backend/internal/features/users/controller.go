@@ -1,9 +1,13 @@
 package users
 
 import (
+	"errors"
 	"net/http"
+	user_enums "postgresus-backend/internal/features/users/enums"
+	user_models "postgresus-backend/internal/features/users/models"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"golang.org/x/time/rate"
 )
 
@@ -16,11 +20,16 @@ func (c *UserController) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/users/signup", c.SignUp)
 	router.POST("/users/signin", c.SignIn)
 	router.GET("/users/is-any-user-exist", c.IsAnyUserExist)
+	router.POST("/users/refresh-token", c.RefreshToken)
+	router.POST("/users/signout", c.SignOut)
+	router.GET("/users/sessions", c.GetSessions)
+	router.DELETE("/users/sessions/:id", c.RevokeSession)
+	router.POST("/users/sessions/revoke-all", c.RevokeAllSessions)
 }
 
 // SignUp
 // @Summary Register a new user
-// @Description Register a new user with email and password
+// @Description Register a new user with email and password. Everyone except the first user signs up by workspace invitation
 // @Tags users
 // @Accept json
 // @Produce json
@@ -36,6 +45,12 @@ func (c *UserController) SignUp(ctx *gin.Context) {
 	}
 
 	err := c.userService.SignUp(&request)
+	c.userService.notifyUserAction(&UserActionEvent{
+		Action:   user_enums.UserActionSignUp,
+		Email:    request.Email,
+		SourceIP: ctx.ClientIP(),
+		Err:      err,
+	})
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -46,7 +61,7 @@ func (c *UserController) SignUp(ctx *gin.Context) {
 
 // SignIn
 // @Summary Authenticate a user
-// @Description Authenticate a user with email and password
+// @Description Authenticate a user with email and password. Users with two-factor authentication pass TOTP or recovery code as well
 // @Tags users
 // @Accept json
 // @Produce json
@@ -71,7 +86,27 @@ func (c *UserController) SignIn(ctx *gin.Context) {
 		return
 	}
 
-	response, err := c.userService.SignIn(&request)
+	response, err := c.userService.SignIn(&request, GetSessionClient(ctx))
+	if errors.Is(err, ErrTwoFactorCodeRequired) {
+		// the client asks for the code and repeats the request
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{"error": err.Error(), "isTwoFactorRequired": true},
+		)
+		return
+	}
+
+	signInEvent := &UserActionEvent{
+		Action:   user_enums.UserActionSignIn,
+		Email:    request.Email,
+		SourceIP: ctx.ClientIP(),
+		Err:      err,
+	}
+	if response != nil {
+		signInEvent.UserID = &response.UserID
+	}
+	c.userService.notifyUserAction(signInEvent)
+
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -96,3 +131,172 @@ func (c *UserController) IsAnyUserExist(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"isExist": isExist})
 }
+
+// RefreshToken
+// @Summary Refresh access token
+// @Description Issue new access token by refresh token. Refresh token is rotated, so the returned one should be used next time
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} SignInResponse
+// @Failure 400
+// @Failure 401
+// @Router /users/refresh-token [post]
+func (c *UserController) RefreshToken(ctx *gin.Context) {
+	var request RefreshTokenRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	response, err := c.userService.RefreshToken(request.RefreshToken, GetSessionClient(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// SignOut
+// @Summary Sign out
+// @Description Revoke the current session
+// @Tags users
+// @Param Authorization header string true "JWT token"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Router /users/signout [post]
+func (c *UserController) SignOut(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = c.userService.SignOut(user)
+	c.userService.notifyUserAction(
+		newUserActionEvent(ctx, user_enums.UserActionSignOut, user, nil, err),
+	)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "signed out successfully"})
+}
+
+// GetSessions
+// @Summary Get sessions
+// @Description Get active sessions of the current user with IP address and user agent
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Success 200 {array} users_models.UserSession
+// @Failure 400
+// @Failure 401
+// @Router /users/sessions [get]
+func (c *UserController) GetSessions(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	sessions, err := c.userService.GetSessions(user)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession
+// @Summary Revoke a session
+// @Description Sign out the user on the device of the session
+// @Tags users
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "Session ID"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Router /users/sessions/{id} [delete]
+func (c *UserController) RevokeSession(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		return
+	}
+
+	err = c.userService.RevokeSession(user, id)
+	c.userService.notifyUserAction(
+		newUserActionEvent(ctx, user_enums.UserActionRevokeSession, user, &id, err),
+	)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "session revoked successfully"})
+}
+
+// RevokeAllSessions
+// @Summary Sign out everywhere
+// @Description Revoke all sessions of the current user, including the current one
+// @Tags users
+// @Param Authorization header string true "JWT token"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Router /users/sessions/revoke-all [post]
+func (c *UserController) RevokeAllSessions(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = c.userService.RevokeAllSessions(user)
+	c.userService.notifyUserAction(
+		newUserActionEvent(ctx, user_enums.UserActionRevokeAllSessions, user, nil, err),
+	)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "all sessions revoked successfully"})
+}
+
+// GetSessionClient returns details of the device to show them in sessions list
+func GetSessionClient(ctx *gin.Context) *SessionClient {
+	return &SessionClient{
+		IPAddress: ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+}
+
+func newUserActionEvent(
+	ctx *gin.Context,
+	action user_enums.UserAction,
+	user *user_models.User,
+	targetID *uuid.UUID,
+	err error,
+) *UserActionEvent {
+	return &UserActionEvent{
+		Action:   action,
+		UserID:   &user.ID,
+		Email:    user.Email,
+		SourceIP: ctx.ClientIP(),
+		TargetID: targetID,
+		Err:      err,
+	}
+}
@@ -8,14 +8,26 @@ import (
 
 var secretKeyRepository = &user_repositories.SecretKeyRepository{}
 var userRepository = &user_repositories.UserRepository{}
+var apiTokenRepository = &user_repositories.ApiTokenRepository{}
+var userSessionRepository = &user_repositories.UserSessionRepository{}
 var userService = &UserService{
 	userRepository,
 	secretKeyRepository,
+	apiTokenRepository,
+	userSessionRepository,
+	nil,
+	nil,
 }
 var userController = &UserController{
 	userService,
 	rate.NewLimiter(rate.Limit(3), 3), // 3 RPS with burst of 3
 }
+var apiTokenController = &ApiTokenController{
+	userService,
+}
+var twoFactorController = &TwoFactorController{
+	userService,
+}
 
 func GetUserService() *UserService {
 	return userService
@@ -24,3 +36,11 @@ func GetUserService() *UserService {
 func GetUserController() *UserController {
 	return userController
 }
+
+func GetApiTokenController() *ApiTokenController {
+	return apiTokenController
+}
+
+func GetTwoFactorController() *TwoFactorController {
+	return twoFactorController
+}
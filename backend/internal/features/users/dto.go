@@ -1,18 +1,88 @@
 package users
 
-import "github.com/google/uuid"
+import (
+	user_enums "postgresus-backend/internal/features/users/enums"
+	user_models "postgresus-backend/internal/features/users/models"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type SignUpRequest struct {
 	Email    string `json:"email"    validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
+	// required for everyone except the first user
+	InvitationToken *string `json:"invitationToken"`
 }
 
 type SignInRequest struct {
 	Email    string `json:"email"    validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	// TOTP or recovery code, required if the user enabled two-factor authentication
+	TwoFactorCode string `json:"twoFactorCode"`
 }
 
 type SignInResponse struct {
 	UserID uuid.UUID `json:"userId"`
 	Token  string    `json:"token"`
+	// access token expiration, after it the token should be refreshed
+	ExpiresAt    time.Time `json:"expiresAt"`
+	RefreshToken string    `json:"refreshToken"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// SessionClient describes device the user signs in from
+type SessionClient struct {
+	IPAddress string
+	UserAgent string
+}
+
+type CreateApiTokenRequest struct {
+	Name        string                     `json:"name"        binding:"required"`
+	Scopes      []user_enums.ApiTokenScope `json:"scopes"      binding:"required"`
+	DatabaseIDs []uuid.UUID                `json:"databaseIds"`
+	ExpiresAt   *time.Time                 `json:"expiresAt"`
+}
+
+type CreateApiTokenResponse struct {
+	ApiToken *user_models.ApiToken `json:"apiToken"`
+	// token is shown once, only its hash is stored
+	Token string `json:"token"`
+}
+
+type TwoFactorStatusResponse struct {
+	IsEnabled bool `json:"isEnabled"`
+	// required for all users by IS_TWO_FACTOR_REQUIRED
+	IsRequired        bool `json:"isRequired"`
+	RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
+
+type SetUpTwoFactorResponse struct {
+	Secret string `json:"secret"`
+	// otpauth:// URI to show as QR code for authenticator app
+	ProvisioningUri string `json:"provisioningUri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	// codes are shown once, only their hashes are stored
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// UserActionEvent is an action of user with own account. UserID is
+// nil if the action failed before the user was identified
+type UserActionEvent struct {
+	Action   user_enums.UserAction
+	UserID   *uuid.UUID
+	Email    string
+	SourceIP string
+	// session or API token the action was applied to
+	TargetID *uuid.UUID
+	Err      error
 }
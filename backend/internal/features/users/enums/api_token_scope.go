@@ -0,0 +1,24 @@
+package user_enums
+
+type ApiTokenScope string
+
+const (
+	ApiTokenScopeDatabasesRead  ApiTokenScope = "databases:read"
+	ApiTokenScopeBackupsRead    ApiTokenScope = "backups:read"
+	ApiTokenScopeBackupsCreate  ApiTokenScope = "backups:create"
+	ApiTokenScopeRestoresRead   ApiTokenScope = "restores:read"
+	ApiTokenScopeRestoresCreate ApiTokenScope = "restores:create"
+)
+
+func (s ApiTokenScope) IsValid() bool {
+	switch s {
+	case ApiTokenScopeDatabasesRead,
+		ApiTokenScopeBackupsRead,
+		ApiTokenScopeBackupsCreate,
+		ApiTokenScopeRestoresRead,
+		ApiTokenScopeRestoresCreate:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,17 @@
+package user_enums
+
+// UserAction is an action of user with own account
+type UserAction string
+
+const (
+	UserActionSignUp                  UserAction = "USER_SIGN_UP"
+	UserActionSignIn                  UserAction = "USER_SIGN_IN"
+	UserActionSignOut                 UserAction = "USER_SIGN_OUT"
+	UserActionRevokeSession           UserAction = "USER_SESSION_REVOKE"
+	UserActionRevokeAllSessions       UserAction = "USER_SESSIONS_REVOKE_ALL"
+	UserActionEnableTwoFactor         UserAction = "USER_TWO_FACTOR_ENABLE"
+	UserActionDisableTwoFactor        UserAction = "USER_TWO_FACTOR_DISABLE"
+	UserActionRegenerateRecoveryCodes UserAction = "USER_RECOVERY_CODES_REGENERATE"
+	UserActionCreateApiToken          UserAction = "API_TOKEN_CREATE"
+	UserActionRevokeApiToken          UserAction = "API_TOKEN_REVOKE"
+)
@@ -4,4 +4,6 @@ type UserRole string
 
 const (
 	UserRoleAdmin UserRole = "ADMIN"
+	// members access only workspaces they are invited to
+	UserRoleMember UserRole = "MEMBER"
 )
@@ -0,0 +1,17 @@
+package users
+
+import user_models "postgresus-backend/internal/features/users/models"
+
+type UserSignUpListener interface {
+	// OnBeforeUserSignUp rejects sign up, for example
+	// because of invalid invitation
+	OnBeforeUserSignUp(email string, invitationToken *string) error
+
+	OnUserSignedUp(user *user_models.User, invitationToken *string) error
+}
+
+// UserActionListener is notified about sign ins and changes
+// of user accounts, e.g. to record them in audit log
+type UserActionListener interface {
+	OnUserAction(event *UserActionEvent)
+}
@@ -0,0 +1,95 @@
+package users_models
+
+import (
+	user_enums "postgresus-backend/internal/features/users/enums"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ApiToken lets automation act on behalf of the user, limited by scopes
+// and optionally by databases. Only hash of the token is stored
+type ApiToken struct {
+	ID     uuid.UUID `json:"id"     gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID uuid.UUID `json:"userId" gorm:"column:user_id;type:uuid;not null"`
+	Name   string    `json:"name"   gorm:"column:name;type:text;not null"`
+
+	// first characters of the token to recognize it in the list
+	TokenPrefix string `json:"tokenPrefix" gorm:"column:token_prefix;type:text;not null"`
+	TokenHash   string `json:"-"           gorm:"column:token_hash;type:text;not null"`
+
+	Scopes       []user_enums.ApiTokenScope `json:"scopes" gorm:"-"`
+	ScopesString string                     `json:"-"      gorm:"column:scopes;type:text;not null"`
+
+	// empty means all databases of the user
+	DatabaseIDs       []uuid.UUID `json:"databaseIds" gorm:"-"`
+	DatabaseIDsString string      `json:"-"           gorm:"column:database_ids;type:text;not null"`
+
+	ExpiresAt  *time.Time `json:"expiresAt"  gorm:"column:expires_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt" gorm:"column:last_used_at"`
+	RevokedAt  *time.Time `json:"revokedAt"  gorm:"column:revoked_at"`
+	CreatedAt  time.Time  `json:"createdAt"  gorm:"column:created_at;not null;default:now()"`
+}
+
+func (ApiToken) TableName() string {
+	return "api_tokens"
+}
+
+func (t *ApiToken) BeforeSave(tx *gorm.DB) error {
+	scopes := make([]string, len(t.Scopes))
+	for i, scope := range t.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	databaseIDs := make([]string, len(t.DatabaseIDs))
+	for i, databaseID := range t.DatabaseIDs {
+		databaseIDs[i] = databaseID.String()
+	}
+
+	t.ScopesString = strings.Join(scopes, ",")
+	t.DatabaseIDsString = strings.Join(databaseIDs, ",")
+
+	return nil
+}
+
+func (t *ApiToken) AfterFind(tx *gorm.DB) error {
+	t.Scopes = []user_enums.ApiTokenScope{}
+	if t.ScopesString != "" {
+		for _, scope := range strings.Split(t.ScopesString, ",") {
+			t.Scopes = append(t.Scopes, user_enums.ApiTokenScope(scope))
+		}
+	}
+
+	t.DatabaseIDs = []uuid.UUID{}
+	if t.DatabaseIDsString != "" {
+		for _, databaseID := range strings.Split(t.DatabaseIDsString, ",") {
+			parsedID, err := uuid.Parse(databaseID)
+			if err != nil {
+				return err
+			}
+
+			t.DatabaseIDs = append(t.DatabaseIDs, parsedID)
+		}
+	}
+
+	return nil
+}
+
+func (t *ApiToken) IsActive() bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+
+	return t.ExpiresAt == nil || time.Now().UTC().Before(*t.ExpiresAt)
+}
+
+func (t *ApiToken) HasScope(scope user_enums.ApiTokenScope) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+func (t *ApiToken) IsDatabaseAllowed(databaseID uuid.UUID) bool {
+	return len(t.DatabaseIDs) == 0 || slices.Contains(t.DatabaseIDs, databaseID)
+}
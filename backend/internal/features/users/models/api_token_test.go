@@ -0,0 +1,60 @@
+package users_models
+
+import (
+	user_enums "postgresus-backend/internal/features/users/enums"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsActive_WithExpiredOrRevokedToken_ReturnsFalse(t *testing.T) {
+	past := time.Now().UTC().Add(-time.Minute)
+	future := time.Now().UTC().Add(time.Hour)
+
+	assert.True(t, (&ApiToken{}).IsActive())
+	assert.True(t, (&ApiToken{ExpiresAt: &future}).IsActive())
+	assert.False(t, (&ApiToken{ExpiresAt: &past}).IsActive())
+	assert.False(t, (&ApiToken{ExpiresAt: &future, RevokedAt: &past}).IsActive())
+}
+
+func Test_HasScope_WithoutScope_ReturnsFalse(t *testing.T) {
+	apiToken := &ApiToken{
+		Scopes: []user_enums.ApiTokenScope{user_enums.ApiTokenScopeBackupsCreate},
+	}
+
+	assert.True(t, apiToken.HasScope(user_enums.ApiTokenScopeBackupsCreate))
+	assert.False(t, apiToken.HasScope(user_enums.ApiTokenScopeRestoresCreate))
+}
+
+func Test_IsDatabaseAllowed_WithDatabaseRestriction_AllowsListedOnly(t *testing.T) {
+	databaseID := uuid.New()
+
+	assert.True(t, (&ApiToken{}).IsDatabaseAllowed(uuid.New()))
+
+	apiToken := &ApiToken{DatabaseIDs: []uuid.UUID{databaseID}}
+	assert.True(t, apiToken.IsDatabaseAllowed(databaseID))
+	assert.False(t, apiToken.IsDatabaseAllowed(uuid.New()))
+}
+
+func Test_BeforeSaveAndAfterFind_KeepScopesAndDatabases(t *testing.T) {
+	apiToken := &ApiToken{
+		Scopes: []user_enums.ApiTokenScope{
+			user_enums.ApiTokenScopeBackupsRead,
+			user_enums.ApiTokenScopeBackupsCreate,
+		},
+		DatabaseIDs: []uuid.UUID{uuid.New(), uuid.New()},
+	}
+
+	assert.NoError(t, apiToken.BeforeSave(nil))
+
+	loaded := &ApiToken{
+		ScopesString:      apiToken.ScopesString,
+		DatabaseIDsString: apiToken.DatabaseIDsString,
+	}
+	assert.NoError(t, loaded.AfterFind(nil))
+
+	assert.Equal(t, apiToken.Scopes, loaded.Scopes)
+	assert.Equal(t, apiToken.DatabaseIDs, loaded.DatabaseIDs)
+}
@@ -14,6 +14,19 @@ type User struct {
 	PasswordCreationTime time.Time           `json:"-"         gorm:"not null"`
 	CreatedAt            time.Time           `json:"createdAt" gorm:"not null;default:now()"`
 	Role                 user_enums.UserRole `json:"role"      gorm:"type:text;not null"`
+
+	// TOTP secret is set on enrollment, but checked only after
+	// the user confirms it with a code from authenticator app
+	IsTotpEnabled    bool    `json:"isTotpEnabled" gorm:"column:is_totp_enabled;not null;default:false"`
+	TotpSecret       *string `json:"-"             gorm:"column:totp_secret;type:text"`
+	TotpLastUsedStep int64   `json:"-"             gorm:"column:totp_last_used_step;not null;default:0"`
+	// comma separated hashes of unused recovery codes
+	TotpRecoveryCodeHashes string `json:"-" gorm:"column:totp_recovery_code_hashes;type:text;not null;default:''"`
+
+	// set when the request is authenticated by API token
+	ApiToken *ApiToken `json:"-" gorm:"-"`
+	// set when the request is authenticated by access token
+	Session *UserSession `json:"-" gorm:"-"`
 }
 
 func (User) TableName() string {
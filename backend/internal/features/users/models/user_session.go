@@ -0,0 +1,40 @@
+package users_models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserSession is a login of the user on some device. Access tokens
+// are short-lived and bound to the session, the session itself is
+// prolonged by rotating refresh token
+type UserSession struct {
+	ID     uuid.UUID `json:"id"     gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID uuid.UUID `json:"userId" gorm:"column:user_id;type:uuid;not null"`
+
+	RefreshTokenHash string `json:"-" gorm:"column:refresh_token_hash;type:text;not null"`
+	// hash of the refresh token before the last rotation, its
+	// reuse means the token was stolen
+	PreviousRefreshTokenHash *string `json:"-" gorm:"column:previous_refresh_token_hash;type:text"`
+
+	IPAddress string `json:"ipAddress" gorm:"column:ip_address;type:text;not null"`
+	UserAgent string `json:"userAgent" gorm:"column:user_agent;type:text;not null"`
+
+	CreatedAt  time.Time  `json:"createdAt"  gorm:"column:created_at;not null;default:now()"`
+	LastUsedAt time.Time  `json:"lastUsedAt" gorm:"column:last_used_at;not null"`
+	ExpiresAt  time.Time  `json:"expiresAt"  gorm:"column:expires_at;not null"`
+	RevokedAt  *time.Time `json:"revokedAt"  gorm:"column:revoked_at"`
+	// time of the last refresh token rotation
+	RefreshedAt *time.Time `json:"-" gorm:"column:refreshed_at"`
+
+	IsCurrent bool `json:"isCurrent" gorm:"-"`
+}
+
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+func (s *UserSession) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().UTC().Before(s.ExpiresAt)
+}
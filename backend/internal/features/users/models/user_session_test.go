@@ -0,0 +1,17 @@
+package users_models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsActive_WithExpiredOrRevokedSession_ReturnsFalse(t *testing.T) {
+	past := time.Now().UTC().Add(-time.Minute)
+	future := time.Now().UTC().Add(time.Hour)
+
+	assert.True(t, (&UserSession{ExpiresAt: future}).IsActive())
+	assert.False(t, (&UserSession{ExpiresAt: past}).IsActive())
+	assert.False(t, (&UserSession{ExpiresAt: future, RevokedAt: &past}).IsActive())
+}
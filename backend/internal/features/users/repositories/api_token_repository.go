@@ -0,0 +1,74 @@
+package user_repositories
+
+import (
+	"errors"
+	user_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ApiTokenRepository struct{}
+
+func (r *ApiTokenRepository) Save(apiToken *user_models.ApiToken) error {
+	db := storage.GetDb()
+
+	if apiToken.ID == uuid.Nil {
+		apiToken.ID = uuid.New()
+		return db.Create(apiToken).Error
+	}
+
+	return db.Save(apiToken).Error
+}
+
+func (r *ApiTokenRepository) FindByID(id uuid.UUID) (*user_models.ApiToken, error) {
+	var apiToken user_models.ApiToken
+
+	if err := storage.GetDb().Where("id = ?", id).First(&apiToken).Error; err != nil {
+		return nil, err
+	}
+
+	return &apiToken, nil
+}
+
+// FindByTokenHash returns nil if there is no token with the hash
+func (r *ApiTokenRepository) FindByTokenHash(tokenHash string) (*user_models.ApiToken, error) {
+	var apiToken user_models.ApiToken
+
+	if err := storage.
+		GetDb().
+		Where("token_hash = ?", tokenHash).
+		First(&apiToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &apiToken, nil
+}
+
+func (r *ApiTokenRepository) FindByUserID(userID uuid.UUID) ([]*user_models.ApiToken, error) {
+	var apiTokens []*user_models.ApiToken
+
+	if err := storage.
+		GetDb().
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&apiTokens).Error; err != nil {
+		return nil, err
+	}
+
+	return apiTokens, nil
+}
+
+func (r *ApiTokenRepository) UpdateLastUsedAt(id uuid.UUID, lastUsedAt time.Time) error {
+	return storage.
+		GetDb().
+		Model(&user_models.ApiToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", lastUsedAt).Error
+}
@@ -66,3 +66,14 @@ func (r *UserRepository) UpdateUserPassword(userID uuid.UUID, hashedPassword str
 			"password_creation_time": time.Now().UTC(),
 		}).Error
 }
+
+func (r *UserRepository) UpdateUserTotp(user *user_models.User) error {
+	return storage.GetDb().Model(&user_models.User{}).
+		Where("id = ?", user.ID).
+		Updates(map[string]any{
+			"is_totp_enabled":           user.IsTotpEnabled,
+			"totp_secret":               user.TotpSecret,
+			"totp_last_used_step":       user.TotpLastUsedStep,
+			"totp_recovery_code_hashes": user.TotpRecoveryCodeHashes,
+		}).Error
+}
@@ -0,0 +1,140 @@
+package user_repositories
+
+import (
+	"errors"
+	user_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UserSessionRepository struct{}
+
+func (r *UserSessionRepository) Save(session *user_models.UserSession) error {
+	db := storage.GetDb()
+
+	if session.ID == uuid.Nil {
+		session.ID = uuid.New()
+		return db.Create(session).Error
+	}
+
+	return db.Save(session).Error
+}
+
+// FindByID returns nil if there is no session with the ID
+func (r *UserSessionRepository) FindByID(id uuid.UUID) (*user_models.UserSession, error) {
+	var session user_models.UserSession
+
+	if err := storage.GetDb().Where("id = ?", id).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// FindByRefreshTokenHash looks up both the current and the previous refresh
+// token, so reuse of rotated token can be detected. Returns nil if not found
+func (r *UserSessionRepository) FindByRefreshTokenHash(
+	refreshTokenHash string,
+) (*user_models.UserSession, error) {
+	var session user_models.UserSession
+
+	if err := storage.
+		GetDb().
+		Where(
+			"refresh_token_hash = ? OR previous_refresh_token_hash = ?",
+			refreshTokenHash,
+			refreshTokenHash,
+		).
+		First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// RotateRefreshToken saves the rotated session only if its refresh token is
+// still the expected one. Returns false if a concurrent refresh rotated it
+// first, so only one of requests with the same token gets a new one
+func (r *UserSessionRepository) RotateRefreshToken(
+	session *user_models.UserSession,
+	expectedRefreshTokenHash string,
+) (bool, error) {
+	result := storage.
+		GetDb().
+		Model(&user_models.UserSession{}).
+		Where(
+			"id = ? AND refresh_token_hash = ? AND revoked_at IS NULL",
+			session.ID,
+			expectedRefreshTokenHash,
+		).
+		Updates(map[string]any{
+			"refresh_token_hash":          session.RefreshTokenHash,
+			"previous_refresh_token_hash": session.PreviousRefreshTokenHash,
+			"ip_address":                  session.IPAddress,
+			"user_agent":                  session.UserAgent,
+			"last_used_at":                session.LastUsedAt,
+			"expires_at":                  session.ExpiresAt,
+			"refreshed_at":                session.RefreshedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *UserSessionRepository) FindActiveByUserID(
+	userID uuid.UUID,
+) ([]*user_models.UserSession, error) {
+	var sessions []*user_models.UserSession
+
+	if err := storage.
+		GetDb().
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now().UTC()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *UserSessionRepository) UpdateLastUsedAt(id uuid.UUID, lastUsedAt time.Time) error {
+	return storage.
+		GetDb().
+		Model(&user_models.UserSession{}).
+		Where("id = ?", id).
+		Update("last_used_at", lastUsedAt).Error
+}
+
+func (r *UserSessionRepository) RevokeByUserID(userID uuid.UUID, revokedAt time.Time) error {
+	return storage.
+		GetDb().
+		Model(&user_models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt).Error
+}
+
+// DeleteInactiveByUserID removes expired and revoked sessions, they
+// are useless once refresh tokens of them are rejected
+func (r *UserSessionRepository) DeleteInactiveByUserID(userID uuid.UUID) error {
+	return storage.
+		GetDb().
+		Where(
+			"user_id = ? AND (revoked_at IS NOT NULL OR expires_at <= ?)",
+			userID,
+			time.Now().UTC(),
+		).
+		Delete(&user_models.UserSession{}).Error
+}
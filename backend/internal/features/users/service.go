@@ -1,6 +1,9 @@
 package users
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -9,14 +12,36 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
+	"postgresus-backend/internal/config"
 	user_enums "postgresus-backend/internal/features/users/enums"
 	user_models "postgresus-backend/internal/features/users/models"
 	user_repositories "postgresus-backend/internal/features/users/repositories"
 )
 
+var errPasswordLoginDisabled = errors.New("password login is disabled, please sign in with SSO")
+
 type UserService struct {
-	userRepository      *user_repositories.UserRepository
-	secretKeyRepository *user_repositories.SecretKeyRepository
+	userRepository        *user_repositories.UserRepository
+	secretKeyRepository   *user_repositories.SecretKeyRepository
+	apiTokenRepository    *user_repositories.ApiTokenRepository
+	userSessionRepository *user_repositories.UserSessionRepository
+
+	signUpListener     UserSignUpListener
+	userActionListener UserActionListener
+}
+
+func (s *UserService) SetUserSignUpListener(signUpListener UserSignUpListener) {
+	s.signUpListener = signUpListener
+}
+
+func (s *UserService) SetUserActionListener(userActionListener UserActionListener) {
+	s.userActionListener = userActionListener
+}
+
+func (s *UserService) notifyUserAction(event *UserActionEvent) {
+	if s.userActionListener != nil {
+		s.userActionListener.OnUserAction(event)
+	}
 }
 
 func (s *UserService) IsAnyUserExist() (bool, error) {
@@ -24,13 +49,28 @@ func (s *UserService) IsAnyUserExist() (bool, error) {
 }
 
 func (s *UserService) SignUp(request *SignUpRequest) error {
+	if config.GetEnv().IsPasswordLoginDisabled {
+		return errPasswordLoginDisabled
+	}
+
 	isAnyUserExists, err := s.userRepository.IsAnyUserExist()
 	if err != nil {
 		return fmt.Errorf("failed to check if any user exists: %w", err)
 	}
 
+	// the first user becomes admin, others
+	// join workspaces only by invitation
+	role := user_enums.UserRoleAdmin
+	invitationToken := request.InvitationToken
+
 	if isAnyUserExists {
-		return errors.New("admin user already registered")
+		if invitationToken == nil || *invitationToken == "" {
+			return errors.New("sign up is allowed only by invitation")
+		}
+
+		role = user_enums.UserRoleMember
+	} else {
+		invitationToken = nil
 	}
 
 	existingUser, err := s.userRepository.GetUserByEmail(request.Email)
@@ -38,6 +78,12 @@ func (s *UserService) SignUp(request *SignUpRequest) error {
 		return errors.New("user with this email already exists")
 	}
 
+	if s.signUpListener != nil {
+		if err := s.signUpListener.OnBeforeUserSignUp(request.Email, invitationToken); err != nil {
+			return err
+		}
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
@@ -49,17 +95,86 @@ func (s *UserService) SignUp(request *SignUpRequest) error {
 		HashedPassword:       string(hashedPassword),
 		PasswordCreationTime: time.Now().UTC(),
 		CreatedAt:            time.Now().UTC(),
-		Role:                 user_enums.UserRoleAdmin,
+		Role:                 role,
 	}
 
 	if err := s.userRepository.CreateUser(user); err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 
+	if s.signUpListener != nil {
+		if err := s.signUpListener.OnUserSignedUp(user, invitationToken); err != nil {
+			return fmt.Errorf("failed to set up workspace of the user: %w", err)
+		}
+	}
+
 	return nil
 }
 
-func (s *UserService) SignIn(request *SignInRequest) (*SignInResponse, error) {
+// GetOrCreateExternalUser returns user authenticated by external identity
+// provider, creating it on the first sign in. Such users have random
+// password, so they cannot sign in by password
+func (s *UserService) GetOrCreateExternalUser(email string) (*user_models.User, error) {
+	existingUser, err := s.userRepository.GetUserByEmail(email)
+	if err == nil && existingUser != nil {
+		return existingUser, nil
+	}
+
+	isAnyUserExists, err := s.userRepository.IsAnyUserExist()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if any user exists: %w", err)
+	}
+
+	passwordBytes := make([]byte, 32)
+	if _, err := rand.Read(passwordBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword(
+		[]byte(hex.EncodeToString(passwordBytes)),
+		bcrypt.DefaultCost,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	role := user_enums.UserRoleMember
+	if !isAnyUserExists {
+		role = user_enums.UserRoleAdmin
+	}
+
+	user := &user_models.User{
+		ID:                   uuid.New(),
+		Email:                email,
+		HashedPassword:       string(hashedPassword),
+		PasswordCreationTime: time.Now().UTC(),
+		CreatedAt:            time.Now().UTC(),
+		Role:                 role,
+	}
+
+	if err := s.userRepository.CreateUser(user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	// workspaces of others are granted by the
+	// provider groups, the first user gets own one
+	if !isAnyUserExists && s.signUpListener != nil {
+		if err := s.signUpListener.OnUserSignedUp(user, nil); err != nil {
+			return nil, fmt.Errorf("failed to set up workspace of the user: %w", err)
+		}
+	}
+
+	return user, nil
+}
+
+func (s *UserService) SignIn(
+	request *SignInRequest,
+	client *SessionClient,
+) (*SignInResponse, error) {
+	if config.GetEnv().IsPasswordLoginDisabled {
+		return nil, errPasswordLoginDisabled
+	}
+
 	user, err := s.userRepository.GetUserByEmail(request.Email)
 	if err != nil {
 		return nil, errors.New("user with this email does not exist")
@@ -70,10 +185,24 @@ func (s *UserService) SignIn(request *SignInRequest) (*SignInResponse, error) {
 		return nil, errors.New("password is incorrect")
 	}
 
-	return s.GenerateAccessToken(user)
+	if user.IsTotpEnabled {
+		if request.TwoFactorCode == "" {
+			return nil, ErrTwoFactorCodeRequired
+		}
+
+		if err := s.verifyTwoFactorCode(user, request.TwoFactorCode); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GenerateAccessToken(user, client)
 }
 
 func (s *UserService) GetUserFromToken(token string) (*user_models.User, error) {
+	if IsApiToken(token) {
+		return s.getUserFromApiToken(token)
+	}
+
 	secretKey, err := s.secretKeyRepository.GetSecretKey()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get secret key: %w", err)
@@ -114,6 +243,18 @@ func (s *UserService) GetUserFromToken(token string) (*user_models.User, error)
 			return nil, errors.New("invalid token claims: missing password creation time")
 		}
 
+		sessionID, ok := claims["sid"].(string)
+		if !ok {
+			return nil, errors.New("invalid token claims: missing session, please sign in again")
+		}
+
+		session, err := s.getActiveSession(user, sessionID)
+		if err != nil {
+			return nil, err
+		}
+
+		user.Session = session
+
 		return user, nil
 	}
 
@@ -140,6 +281,10 @@ func (s *UserService) ChangePassword(newPassword string) error {
 		return fmt.Errorf("failed to update password: %w", err)
 	}
 
+	if err := s.userSessionRepository.RevokeByUserID(user.ID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
 	return nil
 }
 
@@ -147,29 +292,48 @@ func (s *UserService) GetFirstUser() (*user_models.User, error) {
 	return s.userRepository.GetFirstUser()
 }
 
-func (s *UserService) GenerateAccessToken(user *user_models.User) (*SignInResponse, error) {
-	secretKey, err := s.secretKeyRepository.GetSecretKey()
+// getUserFromApiToken returns owner of the API token with the
+// token attached, so services can apply its database restriction
+func (s *UserService) getUserFromApiToken(token string) (*user_models.User, error) {
+	apiToken, err := s.GetActiveApiToken(token)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get secret key: %w", err)
+		return nil, err
+	}
+
+	user, err := s.userRepository.GetUserByID(apiToken.UserID.String())
+	if err != nil {
+		return nil, err
 	}
 
-	tenYearsExpiration := time.Now().UTC().Add(time.Hour * 24 * 365 * 10)
+	// the column is updated at most once per minute to
+	// avoid a write on every request of busy pipelines
+	now := time.Now().UTC()
+	if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) > time.Minute {
+		if err := s.apiTokenRepository.UpdateLastUsedAt(apiToken.ID, now); err != nil {
+			return nil, fmt.Errorf("failed to update API token usage: %w", err)
+		}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":                  user.ID,
-		"exp":                  tenYearsExpiration.Unix(),
-		"iat":                  time.Now().UTC().Unix(),
-		"role":                 string(user.Role),
-		"passwordCreationTime": user.PasswordCreationTime.Unix(),
-	})
+		apiToken.LastUsedAt = &now
+	}
 
-	tokenString, err := token.SignedString([]byte(secretKey))
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+	user.ApiToken = apiToken
+
+	return user, nil
+}
+
+// generateSecretToken returns random token for API tokens and refresh tokens
+func generateSecretToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
 	}
 
-	return &SignInResponse{
-		UserID: user.ID,
-		Token:  tokenString,
-	}, nil
+	return hex.EncodeToString(tokenBytes), nil
+}
+
+// hashToken is used to store tokens at rest. Tokens are random,
+// so unsalted SHA-256 is enough
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
 }
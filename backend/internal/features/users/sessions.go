@@ -0,0 +1,267 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+
+	user_models "postgresus-backend/internal/features/users/models"
+)
+
+const (
+	accessTokenLifetime = 15 * time.Minute
+	// session is prolonged on every refresh, so only
+	// devices unused for the period are signed out
+	sessionLifetime = 30 * 24 * time.Hour
+	// concurrent refreshes with the same token (e.g. by several browser
+	// tabs) present the rotated token shortly after the rotation, such
+	// reuse is rejected without revoking the session
+	refreshTokenReuseGracePeriod = 30 * time.Second
+	maxUserAgentLength           = 512
+)
+
+var (
+	errSessionManagement       = errors.New("sessions can be managed only by signed in user")
+	errRefreshTokenJustRotated = errors.New(
+		"refresh token was just rotated by another request, please use the new one",
+	)
+)
+
+// GenerateAccessToken starts a new session of the user and returns
+// short-lived access token with refresh token of the session
+func (s *UserService) GenerateAccessToken(
+	user *user_models.User,
+	client *SessionClient,
+) (*SignInResponse, error) {
+	// sign in is a good moment to clean up sessions
+	// which cannot be used anymore
+	if err := s.userSessionRepository.DeleteInactiveByUserID(user.ID); err != nil {
+		return nil, fmt.Errorf("failed to clean up sessions: %w", err)
+	}
+
+	refreshToken, err := generateSecretToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	session := &user_models.UserSession{
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(sessionLifetime),
+	}
+	session.IPAddress, session.UserAgent = client.getDetails()
+
+	if err := s.userSessionRepository.Save(session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return s.issueTokens(user, session, refreshToken)
+}
+
+// RefreshToken rotates refresh token of the session and issues new access
+// token. Reuse of already rotated refresh token revokes the session unless
+// the token was rotated by a concurrent refresh within the grace period
+func (s *UserService) RefreshToken(
+	refreshToken string,
+	client *SessionClient,
+) (*SignInResponse, error) {
+	refreshTokenHash := hashToken(refreshToken)
+
+	session, err := s.userSessionRepository.FindByRefreshTokenHash(refreshTokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if session == nil || !session.IsActive() {
+		return nil, errors.New("session is expired or revoked, please sign in again")
+	}
+
+	now := time.Now().UTC()
+
+	if session.RefreshTokenHash != refreshTokenHash {
+		if session.RefreshedAt != nil &&
+			now.Sub(*session.RefreshedAt) < refreshTokenReuseGracePeriod {
+			return nil, errRefreshTokenJustRotated
+		}
+
+		session.RevokedAt = &now
+		if err := s.userSessionRepository.Save(session); err != nil {
+			return nil, fmt.Errorf("failed to revoke session: %w", err)
+		}
+
+		return nil, errors.New("refresh token was already used, please sign in again")
+	}
+
+	user, err := s.userRepository.GetUserByID(session.UserID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	newRefreshToken, err := generateSecretToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	session.PreviousRefreshTokenHash = &refreshTokenHash
+	session.RefreshTokenHash = hashToken(newRefreshToken)
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(sessionLifetime)
+	session.RefreshedAt = &now
+	session.IPAddress, session.UserAgent = client.getDetails()
+
+	isRotated, err := s.userSessionRepository.RotateRefreshToken(session, refreshTokenHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	if !isRotated {
+		return nil, errRefreshTokenJustRotated
+	}
+
+	return s.issueTokens(user, session, newRefreshToken)
+}
+
+func (s *UserService) GetSessions(user *user_models.User) ([]*user_models.UserSession, error) {
+	if user.Session == nil {
+		return nil, errSessionManagement
+	}
+
+	sessions, err := s.userSessionRepository.FindActiveByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		session.IsCurrent = session.ID == user.Session.ID
+	}
+
+	return sessions, nil
+}
+
+func (s *UserService) RevokeSession(user *user_models.User, sessionID uuid.UUID) error {
+	if user.Session == nil {
+		return errSessionManagement
+	}
+
+	session, err := s.userSessionRepository.FindByID(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if session == nil || session.UserID != user.ID {
+		return errors.New("session not found")
+	}
+
+	if session.RevokedAt != nil {
+		return nil
+	}
+
+	revokedAt := time.Now().UTC()
+	session.RevokedAt = &revokedAt
+
+	return s.userSessionRepository.Save(session)
+}
+
+// RevokeAllSessions signs the user out on all devices, including the current one
+func (s *UserService) RevokeAllSessions(user *user_models.User) error {
+	if user.Session == nil {
+		return errSessionManagement
+	}
+
+	return s.userSessionRepository.RevokeByUserID(user.ID, time.Now().UTC())
+}
+
+func (s *UserService) SignOut(user *user_models.User) error {
+	if user.Session == nil {
+		return errSessionManagement
+	}
+
+	return s.RevokeSession(user, user.Session.ID)
+}
+
+func (s *UserService) getActiveSession(
+	user *user_models.User,
+	sessionID string,
+) (*user_models.UserSession, error) {
+	parsedSessionID, err := uuid.Parse(sessionID)
+	if err != nil {
+		return nil, errors.New("invalid token claims: invalid session")
+	}
+
+	session, err := s.userSessionRepository.FindByID(parsedSessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session == nil || session.UserID != user.ID || !session.IsActive() {
+		return nil, errors.New("session is expired or revoked, please sign in again")
+	}
+
+	// the column is updated at most once per minute
+	// to avoid a write on every request
+	now := time.Now().UTC()
+	if now.Sub(session.LastUsedAt) > time.Minute {
+		if err := s.userSessionRepository.UpdateLastUsedAt(session.ID, now); err != nil {
+			return nil, fmt.Errorf("failed to update session usage: %w", err)
+		}
+
+		session.LastUsedAt = now
+	}
+
+	return session, nil
+}
+
+func (s *UserService) issueTokens(
+	user *user_models.User,
+	session *user_models.UserSession,
+	refreshToken string,
+) (*SignInResponse, error) {
+	secretKey, err := s.secretKeyRepository.GetSecretKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret key: %w", err)
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(accessTokenLifetime)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":                  user.ID,
+		"sid":                  session.ID,
+		"exp":                  expiresAt.Unix(),
+		"iat":                  now.Unix(),
+		"role":                 string(user.Role),
+		"passwordCreationTime": user.PasswordCreationTime.Unix(),
+	})
+
+	tokenString, err := token.SignedString([]byte(secretKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &SignInResponse{
+		UserID:       user.ID,
+		Token:        tokenString,
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (c *SessionClient) getDetails() (string, string) {
+	if c == nil {
+		return "", ""
+	}
+
+	userAgent := c.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	return c.IPAddress, userAgent
+}
@@ -0,0 +1,115 @@
+package users
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RefreshToken_TokenRotated(t *testing.T) {
+	signInResponse := CreateTestUser()
+
+	refreshResponse, err := userService.RefreshToken(signInResponse.RefreshToken, &SessionClient{})
+	assert.NoError(t, err)
+	assert.NotEqual(t, signInResponse.RefreshToken, refreshResponse.RefreshToken)
+
+	// new tokens belong to the same session
+	oldTokenUser, err := userService.GetUserFromToken(signInResponse.Token)
+	assert.NoError(t, err)
+	newTokenUser, err := userService.GetUserFromToken(refreshResponse.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, oldTokenUser.Session.ID, newTokenUser.Session.ID)
+
+	nextRefreshResponse, err := userService.RefreshToken(
+		refreshResponse.RefreshToken,
+		&SessionClient{},
+	)
+	assert.NoError(t, err)
+	assert.NotEqual(t, refreshResponse.RefreshToken, nextRefreshResponse.RefreshToken)
+}
+
+func Test_RefreshToken_WithReusedToken_SessionRevoked(t *testing.T) {
+	signInResponse := CreateTestUser()
+
+	refreshResponse, err := userService.RefreshToken(signInResponse.RefreshToken, &SessionClient{})
+	assert.NoError(t, err)
+
+	// the token is reused after the grace period of concurrent refreshes
+	session, err := userSessionRepository.FindByRefreshTokenHash(
+		hashToken(refreshResponse.RefreshToken),
+	)
+	assert.NoError(t, err)
+	refreshedAt := time.Now().UTC().Add(-2 * refreshTokenReuseGracePeriod)
+	session.RefreshedAt = &refreshedAt
+	assert.NoError(t, userSessionRepository.Save(session))
+
+	_, err = userService.RefreshToken(signInResponse.RefreshToken, &SessionClient{})
+	assert.ErrorContains(t, err, "refresh token was already used")
+
+	// the whole session is revoked, including tokens issued by the rotation
+	_, err = userService.RefreshToken(refreshResponse.RefreshToken, &SessionClient{})
+	assert.ErrorContains(t, err, "session is expired or revoked")
+
+	_, err = userService.GetUserFromToken(refreshResponse.Token)
+	assert.ErrorContains(t, err, "session is expired or revoked")
+}
+
+func Test_RefreshToken_ConcurrentRefreshWithSameToken_SessionNotRevoked(t *testing.T) {
+	signInResponse := CreateTestUser()
+
+	refreshResponse, err := userService.RefreshToken(signInResponse.RefreshToken, &SessionClient{})
+	assert.NoError(t, err)
+
+	// another browser tab refreshes with the same token right after
+	_, err = userService.RefreshToken(signInResponse.RefreshToken, &SessionClient{})
+	assert.ErrorIs(t, err, errRefreshTokenJustRotated)
+
+	_, err = userService.GetUserFromToken(refreshResponse.Token)
+	assert.NoError(t, err)
+
+	_, err = userService.RefreshToken(refreshResponse.RefreshToken, &SessionClient{})
+	assert.NoError(t, err)
+}
+
+func Test_RevokeSession_AccessTokenRejected(t *testing.T) {
+	signInResponse := CreateTestUser()
+
+	user, err := userService.GetUserFromToken(signInResponse.Token)
+	assert.NoError(t, err)
+
+	err = userService.RevokeSession(user, user.Session.ID)
+	assert.NoError(t, err)
+
+	_, err = userService.GetUserFromToken(signInResponse.Token)
+	assert.ErrorContains(t, err, "session is expired or revoked")
+
+	_, err = userService.RefreshToken(signInResponse.RefreshToken, &SessionClient{})
+	assert.ErrorContains(t, err, "session is expired or revoked")
+}
+
+func Test_RevokeAllSessions_AllSessionsSignedOut(t *testing.T) {
+	firstSignInResponse := CreateTestUser()
+
+	user, err := userService.GetUserFromToken(firstSignInResponse.Token)
+	assert.NoError(t, err)
+
+	// sign in on another device
+	secondSignInResponse, err := userService.GenerateAccessToken(user, &SessionClient{})
+	assert.NoError(t, err)
+
+	sessions, err := userService.GetSessions(user)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	err = userService.RevokeAllSessions(user)
+	assert.NoError(t, err)
+
+	for _, signInResponse := range []*SignInResponse{firstSignInResponse, secondSignInResponse} {
+		_, err = userService.GetUserFromToken(signInResponse.Token)
+		assert.ErrorContains(t, err, "session is expired or revoked")
+
+		_, err = userService.RefreshToken(signInResponse.RefreshToken, &SessionClient{})
+		assert.ErrorContains(t, err, "session is expired or revoked")
+	}
+}
@@ -1,5 +1,13 @@
 package users
 
+import (
+	user_enums "postgresus-backend/internal/features/users/enums"
+	user_models "postgresus-backend/internal/features/users/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
 func GetTestUser() *SignInResponse {
 	isAnyUserExists, err := userService.IsAnyUserExist()
 	if err != nil {
@@ -22,7 +30,30 @@ func GetTestUser() *SignInResponse {
 		panic(err)
 	}
 
-	signInResponse, err := userService.GenerateAccessToken(user)
+	signInResponse, err := userService.GenerateAccessToken(user, &SessionClient{})
+	if err != nil {
+		panic(err)
+	}
+
+	return signInResponse
+}
+
+// CreateTestUser creates one more user without workspaces. Only the first
+// user signs up without invitation, so the user is created directly
+func CreateTestUser() *SignInResponse {
+	user := &user_models.User{
+		ID:                   uuid.New(),
+		Email:                "test-" + uuid.New().String() + "@test.com",
+		PasswordCreationTime: time.Now().UTC(),
+		CreatedAt:            time.Now().UTC(),
+		Role:                 user_enums.UserRoleMember,
+	}
+
+	if err := userRepository.CreateUser(user); err != nil {
+		panic(err)
+	}
+
+	signInResponse, err := userService.GenerateAccessToken(user, &SessionClient{})
 	if err != nil {
 		panic(err)
 	}
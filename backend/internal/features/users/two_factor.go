@@ -0,0 +1,242 @@
+package users
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"postgresus-backend/internal/config"
+	user_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/util/totp"
+)
+
+const (
+	totpIssuer         = "Postgresus"
+	recoveryCodesCount = 10
+)
+
+// ErrTwoFactorCodeRequired is returned on sign in when password is correct,
+// but the user has two-factor authentication and the code is not passed
+var ErrTwoFactorCodeRequired = errors.New("two-factor authentication code is required")
+
+var errTwoFactorManagement = errors.New(
+	"two-factor authentication can be managed only by signed in user",
+)
+
+func (s *UserService) GetTwoFactorStatus(user *user_models.User) *TwoFactorStatusResponse {
+	return &TwoFactorStatusResponse{
+		IsEnabled:         user.IsTotpEnabled,
+		IsRequired:        config.GetEnv().IsTwoFactorRequired,
+		RecoveryCodesLeft: len(splitRecoveryCodeHashes(user.TotpRecoveryCodeHashes)),
+	}
+}
+
+// SetUpTwoFactor generates a new TOTP secret. It is not checked on sign
+// in until the user confirms it with a code via EnableTwoFactor
+func (s *UserService) SetUpTwoFactor(user *user_models.User) (*SetUpTwoFactorResponse, error) {
+	if user.Session == nil {
+		return nil, errTwoFactorManagement
+	}
+
+	if user.IsTotpEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	user.TotpSecret = &secret
+	user.TotpLastUsedStep = 0
+	user.TotpRecoveryCodeHashes = ""
+
+	if err := s.userRepository.UpdateUserTotp(user); err != nil {
+		return nil, fmt.Errorf("failed to save TOTP secret: %w", err)
+	}
+
+	return &SetUpTwoFactorResponse{
+		Secret:          secret,
+		ProvisioningUri: totp.GetProvisioningUri(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+func (s *UserService) EnableTwoFactor(
+	user *user_models.User,
+	code string,
+) (*RecoveryCodesResponse, error) {
+	if user.Session == nil {
+		return nil, errTwoFactorManagement
+	}
+
+	if user.IsTotpEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	if user.TotpSecret == nil {
+		return nil, errors.New("set up two-factor authentication first")
+	}
+
+	step, ok := totp.Validate(*user.TotpSecret, code, time.Now().UTC())
+	if !ok {
+		return nil, errors.New("two-factor authentication code is incorrect")
+	}
+
+	recoveryCodes, recoveryCodeHashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	user.IsTotpEnabled = true
+	user.TotpLastUsedStep = step
+	user.TotpRecoveryCodeHashes = recoveryCodeHashes
+
+	if err := s.userRepository.UpdateUserTotp(user); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// DisableTwoFactor accepts both TOTP and recovery code, so the user
+// who lost the phone can disable it and enroll again
+func (s *UserService) DisableTwoFactor(user *user_models.User, code string) error {
+	if user.Session == nil {
+		return errTwoFactorManagement
+	}
+
+	if !user.IsTotpEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if config.GetEnv().IsTwoFactorRequired {
+		return errors.New("two-factor authentication is required and cannot be disabled")
+	}
+
+	if err := s.verifyTwoFactorCode(user, code); err != nil {
+		return err
+	}
+
+	return s.resetTwoFactor(user)
+}
+
+func (s *UserService) RegenerateRecoveryCodes(
+	user *user_models.User,
+	code string,
+) (*RecoveryCodesResponse, error) {
+	if user.Session == nil {
+		return nil, errTwoFactorManagement
+	}
+
+	if !user.IsTotpEnabled {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	if err := s.verifyTwoFactorCode(user, code); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, recoveryCodeHashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	user.TotpRecoveryCodeHashes = recoveryCodeHashes
+
+	if err := s.userRepository.UpdateUserTotp(user); err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// ResetTwoFactor disables two-factor authentication of the user
+// without a code. It is used from CLI for locked out admins
+func (s *UserService) ResetTwoFactor(email string) error {
+	user, err := s.userRepository.GetUserByEmail(email)
+	if err != nil {
+		return fmt.Errorf("user with email %s does not exist", email)
+	}
+
+	return s.resetTwoFactor(user)
+}
+
+// verifyTwoFactorCode checks TOTP code or one of recovery codes.
+// Both can be used only once
+func (s *UserService) verifyTwoFactorCode(user *user_models.User, code string) error {
+	if !user.IsTotpEnabled || user.TotpSecret == nil {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if step, ok := totp.Validate(*user.TotpSecret, code, time.Now().UTC()); ok {
+		if step <= user.TotpLastUsedStep {
+			return errors.New("the code is already used, please wait for the next one")
+		}
+
+		user.TotpLastUsedStep = step
+
+		return s.userRepository.UpdateUserTotp(user)
+	}
+
+	recoveryCodeHash := hashToken(normalizeRecoveryCode(code))
+	recoveryCodeHashes := splitRecoveryCodeHashes(user.TotpRecoveryCodeHashes)
+
+	index := slices.Index(recoveryCodeHashes, recoveryCodeHash)
+	if index == -1 {
+		return errors.New("two-factor authentication code is incorrect")
+	}
+
+	user.TotpRecoveryCodeHashes = strings.Join(slices.Delete(recoveryCodeHashes, index, index+1), ",")
+
+	return s.userRepository.UpdateUserTotp(user)
+}
+
+func (s *UserService) resetTwoFactor(user *user_models.User) error {
+	user.IsTotpEnabled = false
+	user.TotpSecret = nil
+	user.TotpLastUsedStep = 0
+	user.TotpRecoveryCodeHashes = ""
+
+	if err := s.userRepository.UpdateUserTotp(user); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	return nil
+}
+
+// generateRecoveryCodes returns codes to show to the user
+// once and comma separated hashes of them to store
+func generateRecoveryCodes() ([]string, string, error) {
+	recoveryCodes := make([]string, 0, recoveryCodesCount)
+	recoveryCodeHashes := make([]string, 0, recoveryCodesCount)
+
+	for range recoveryCodesCount {
+		codeBytes := make([]byte, 5)
+		if _, err := rand.Read(codeBytes); err != nil {
+			return nil, "", fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		code := hex.EncodeToString(codeBytes)
+
+		recoveryCodes = append(recoveryCodes, code[:5]+"-"+code[5:])
+		recoveryCodeHashes = append(recoveryCodeHashes, hashToken(code))
+	}
+
+	return recoveryCodes, strings.Join(recoveryCodeHashes, ","), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+func splitRecoveryCodeHashes(recoveryCodeHashes string) []string {
+	if recoveryCodeHashes == "" {
+		return []string{}
+	}
+
+	return strings.Split(recoveryCodeHashes, ",")
+}
@@ -0,0 +1,175 @@
+package users
+
+import (
+	"net/http"
+	user_enums "postgresus-backend/internal/features/users/enums"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorController struct {
+	userService *UserService
+}
+
+func (c *TwoFactorController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/users/2fa", c.GetStatus)
+	router.POST("/users/2fa/setup", c.SetUp)
+	router.POST("/users/2fa/enable", c.Enable)
+	router.POST("/users/2fa/disable", c.Disable)
+	router.POST("/users/2fa/recovery-codes", c.RegenerateRecoveryCodes)
+}
+
+// GetStatus
+// @Summary Get two-factor authentication status
+// @Description Get whether TOTP is enabled for the current user and how many recovery codes are left
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Success 200 {object} TwoFactorStatusResponse
+// @Failure 401
+// @Router /users/2fa [get]
+func (c *TwoFactorController) GetStatus(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.userService.GetTwoFactorStatus(user))
+}
+
+// SetUp
+// @Summary Set up two-factor authentication
+// @Description Generate TOTP secret and provisioning URI for authenticator app. It is enabled after confirmation by a code
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Success 200 {object} SetUpTwoFactorResponse
+// @Failure 400
+// @Failure 401
+// @Router /users/2fa/setup [post]
+func (c *TwoFactorController) SetUp(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := c.userService.SetUpTwoFactor(user)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Enable
+// @Summary Enable two-factor authentication
+// @Description Confirm TOTP secret by a code from authenticator app. Returns recovery codes, they are shown only once
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param request body TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400
+// @Failure 401
+// @Router /users/2fa/enable [post]
+func (c *TwoFactorController) Enable(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	response, err := c.userService.EnableTwoFactor(user, request.Code)
+	c.userService.notifyUserAction(
+		newUserActionEvent(ctx, user_enums.UserActionEnableTwoFactor, user, nil, err),
+	)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Disable
+// @Summary Disable two-factor authentication
+// @Description Disable two-factor authentication by TOTP or recovery code
+// @Tags users
+// @Accept json
+// @Param Authorization header string true "JWT token"
+// @Param request body TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Router /users/2fa/disable [post]
+func (c *TwoFactorController) Disable(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	err = c.userService.DisableTwoFactor(user, request.Code)
+	c.userService.notifyUserAction(
+		newUserActionEvent(ctx, user_enums.UserActionDisableTwoFactor, user, nil, err),
+	)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled successfully"})
+}
+
+// RegenerateRecoveryCodes
+// @Summary Regenerate recovery codes
+// @Description Replace recovery codes with new ones. Old codes stop working
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param request body TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400
+// @Failure 401
+// @Router /users/2fa/recovery-codes [post]
+func (c *TwoFactorController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	response, err := c.userService.RegenerateRecoveryCodes(user, request.Code)
+	c.userService.notifyUserAction(
+		newUserActionEvent(ctx, user_enums.UserActionRegenerateRecoveryCodes, user, nil, err),
+	)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
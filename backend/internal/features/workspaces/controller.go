@@ -0,0 +1,394 @@
+package workspaces
+
+import (
+	"net/http"
+	"postgresus-backend/internal/features/users"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WorkspaceController struct {
+	workspaceService *WorkspaceService
+	userService      *users.UserService
+}
+
+func (c *WorkspaceController) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/workspaces", c.CreateWorkspace)
+	router.GET("/workspaces", c.GetWorkspaces)
+	router.PUT("/workspaces/:id", c.UpdateWorkspace)
+	router.GET("/workspaces/:id/members", c.GetMembers)
+	router.PUT("/workspaces/:id/members/:userId", c.ChangeMemberRole)
+	router.DELETE("/workspaces/:id/members/:userId", c.RemoveMember)
+	router.POST("/workspaces/:id/invitations", c.InviteMember)
+	router.GET("/workspaces/:id/invitations", c.GetInvitations)
+	router.DELETE("/workspaces/:id/invitations/:invitationId", c.DeleteInvitation)
+	router.POST("/workspaces/invitations/accept", c.AcceptInvitation)
+}
+
+// CreateWorkspace
+// @Summary Create a workspace
+// @Description Create a workspace owned by the current user
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param request body SaveWorkspaceRequest true "Workspace data"
+// @Success 200 {object} Workspace
+// @Failure 400
+// @Failure 401
+// @Router /workspaces [post]
+func (c *WorkspaceController) CreateWorkspace(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request SaveWorkspaceRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace, err := c.workspaceService.CreateWorkspace(user, &request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workspace)
+}
+
+// GetWorkspaces
+// @Summary Get workspaces
+// @Description Get all workspaces the current user is a member of
+// @Tags workspaces
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Success 200 {array} WorkspaceResponse
+// @Failure 400
+// @Failure 401
+// @Router /workspaces [get]
+func (c *WorkspaceController) GetWorkspaces(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspaces, err := c.workspaceService.GetWorkspaces(user)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workspaces)
+}
+
+// UpdateWorkspace
+// @Summary Update a workspace
+// @Description Rename the workspace. Requires owner role
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "Workspace ID"
+// @Param request body SaveWorkspaceRequest true "Workspace data"
+// @Success 200 {object} Workspace
+// @Failure 400
+// @Failure 401
+// @Router /workspaces/{id} [put]
+func (c *WorkspaceController) UpdateWorkspace(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	var request SaveWorkspaceRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace, err := c.workspaceService.UpdateWorkspace(user, id, &request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workspace)
+}
+
+// GetMembers
+// @Summary Get workspace members
+// @Description Get members of the workspace with their roles
+// @Tags workspaces
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "Workspace ID"
+// @Success 200 {array} WorkspaceMember
+// @Failure 400
+// @Failure 401
+// @Router /workspaces/{id}/members [get]
+func (c *WorkspaceController) GetMembers(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	members, err := c.workspaceService.GetMembers(user, id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, members)
+}
+
+// ChangeMemberRole
+// @Summary Change role of a member
+// @Description Change workspace role of the member. Only owners can grant or revoke owner role
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "Workspace ID"
+// @Param userId path string true "User ID"
+// @Param request body ChangeMemberRoleRequest true "New role"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Router /workspaces/{id}/members/{userId} [put]
+func (c *WorkspaceController) ChangeMemberRole(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	memberUserID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	var request ChangeMemberRoleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.workspaceService.ChangeMemberRole(user, id, memberUserID, &request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "member role changed successfully"})
+}
+
+// RemoveMember
+// @Summary Remove a member
+// @Description Remove the member from the workspace. Members can remove themselves
+// @Tags workspaces
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "Workspace ID"
+// @Param userId path string true "User ID"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Router /workspaces/{id}/members/{userId} [delete]
+func (c *WorkspaceController) RemoveMember(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	memberUserID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	if err := c.workspaceService.RemoveMember(user, id, memberUserID); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "member removed successfully"})
+}
+
+// InviteMember
+// @Summary Invite a member
+// @Description Create an invitation to the workspace. The token is returned only once
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "Workspace ID"
+// @Param request body InviteMemberRequest true "Invitation data"
+// @Success 200 {object} InviteMemberResponse
+// @Failure 400
+// @Failure 401
+// @Router /workspaces/{id}/invitations [post]
+func (c *WorkspaceController) InviteMember(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	var request InviteMemberRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := c.workspaceService.InviteMember(user, id, &request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetInvitations
+// @Summary Get invitations
+// @Description Get not expired invitations to the workspace
+// @Tags workspaces
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "Workspace ID"
+// @Success 200 {array} WorkspaceInvitation
+// @Failure 400
+// @Failure 401
+// @Router /workspaces/{id}/invitations [get]
+func (c *WorkspaceController) GetInvitations(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	invitations, err := c.workspaceService.GetInvitations(user, id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, invitations)
+}
+
+// DeleteInvitation
+// @Summary Delete an invitation
+// @Description Revoke the invitation to the workspace
+// @Tags workspaces
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "Workspace ID"
+// @Param invitationId path string true "Invitation ID"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Router /workspaces/{id}/invitations/{invitationId} [delete]
+func (c *WorkspaceController) DeleteInvitation(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	invitationID, err := uuid.Parse(ctx.Param("invitationId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation ID"})
+		return
+	}
+
+	if err := c.workspaceService.DeleteInvitation(user, id, invitationID); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "invitation deleted successfully"})
+}
+
+// AcceptInvitation
+// @Summary Accept an invitation
+// @Description Join the workspace by invitation sent to email of the current user
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param request body AcceptInvitationRequest true "Invitation token"
+// @Success 200 {object} Workspace
+// @Failure 400
+// @Failure 401
+// @Router /workspaces/invitations/accept [post]
+func (c *WorkspaceController) AcceptInvitation(ctx *gin.Context) {
+	user, err := c.userService.GetUserFromToken(ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request AcceptInvitationRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace, err := c.workspaceService.AcceptInvitation(user, request.Token)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workspace)
+}
@@ -0,0 +1,28 @@
+package workspaces
+
+import (
+	"postgresus-backend/internal/features/users"
+	"postgresus-backend/internal/util/logger"
+)
+
+var workspaceRepository = &WorkspaceRepository{}
+var workspaceService = &WorkspaceService{
+	workspaceRepository,
+	logger.GetLogger(),
+}
+var workspaceController = &WorkspaceController{
+	workspaceService,
+	users.GetUserService(),
+}
+
+func SetupDependencies() {
+	users.GetUserService().SetUserSignUpListener(workspaceService)
+}
+
+func GetWorkspaceService() *WorkspaceService {
+	return workspaceService
+}
+
+func GetWorkspaceController() *WorkspaceController {
+	return workspaceController
+}
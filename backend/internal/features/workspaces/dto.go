@@ -0,0 +1,40 @@
+package workspaces
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SaveWorkspaceRequest struct {
+	Name                string `json:"name"                binding:"required"`
+	IsTwoFactorRequired bool   `json:"isTwoFactorRequired"`
+}
+
+type WorkspaceResponse struct {
+	ID                  uuid.UUID     `json:"id"`
+	Name                string        `json:"name"`
+	CreatedAt           time.Time     `json:"createdAt"`
+	IsTwoFactorRequired bool          `json:"isTwoFactorRequired"`
+	Role                WorkspaceRole `json:"role"`
+}
+
+type InviteMemberRequest struct {
+	Email string        `json:"email" binding:"required,email"`
+	Role  WorkspaceRole `json:"role"  binding:"required"`
+}
+
+type InviteMemberResponse struct {
+	Invitation *WorkspaceInvitation `json:"invitation"`
+	// token is shown once: pass it to the invited user
+	// to sign up or to accept the invitation
+	Token string `json:"token"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ChangeMemberRoleRequest struct {
+	Role WorkspaceRole `json:"role" binding:"required"`
+}
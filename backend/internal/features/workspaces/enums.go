@@ -0,0 +1,31 @@
+package workspaces
+
+type WorkspaceRole string
+
+const (
+	// owners manage the workspace itself, including other owners
+	WorkspaceRoleOwner WorkspaceRole = "OWNER"
+	// admins manage databases, storages, notifiers and members
+	WorkspaceRoleAdmin WorkspaceRole = "ADMIN"
+	// operators run backups and restores of existing databases
+	WorkspaceRoleOperator WorkspaceRole = "OPERATOR"
+	// viewers only read configuration and history
+	WorkspaceRoleViewer WorkspaceRole = "VIEWER"
+)
+
+var workspaceRoleLevels = map[WorkspaceRole]int{
+	WorkspaceRoleViewer:   1,
+	WorkspaceRoleOperator: 2,
+	WorkspaceRoleAdmin:    3,
+	WorkspaceRoleOwner:    4,
+}
+
+func (r WorkspaceRole) IsValid() bool {
+	_, ok := workspaceRoleLevels[r]
+	return ok
+}
+
+// IsAtLeast reports whether the role grants everything the other role does
+func (r WorkspaceRole) IsAtLeast(other WorkspaceRole) bool {
+	return r.IsValid() && workspaceRoleLevels[r] >= workspaceRoleLevels[other]
+}
@@ -0,0 +1,21 @@
+package workspaces
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WorkspaceRole_IsAtLeast_ComparesRoleLevels(t *testing.T) {
+	assert.True(t, WorkspaceRoleOwner.IsAtLeast(WorkspaceRoleAdmin))
+	assert.True(t, WorkspaceRoleAdmin.IsAtLeast(WorkspaceRoleAdmin))
+	assert.True(t, WorkspaceRoleOperator.IsAtLeast(WorkspaceRoleViewer))
+	assert.False(t, WorkspaceRoleViewer.IsAtLeast(WorkspaceRoleOperator))
+	assert.False(t, WorkspaceRoleAdmin.IsAtLeast(WorkspaceRoleOwner))
+}
+
+func Test_WorkspaceRole_WithUnknownRole_IsInvalid(t *testing.T) {
+	assert.False(t, WorkspaceRole("SUPERUSER").IsValid())
+	assert.False(t, WorkspaceRole("SUPERUSER").IsAtLeast(WorkspaceRoleViewer))
+	assert.True(t, WorkspaceRoleViewer.IsValid())
+}
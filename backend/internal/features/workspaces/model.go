@@ -0,0 +1,53 @@
+package workspaces
+
+import (
+	users_models "postgresus-backend/internal/features/users/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Workspace owns databases, storages and notifiers. Users access
+// them through membership with a role
+type Workspace struct {
+	ID        uuid.UUID `json:"id"        gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	Name      string    `json:"name"      gorm:"column:name;type:text;not null"`
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at;not null;default:now()"`
+	// members without TOTP have no access to the workspace
+	IsTwoFactorRequired bool `json:"isTwoFactorRequired" gorm:"column:is_two_factor_required;not null;default:false"`
+}
+
+func (Workspace) TableName() string {
+	return "workspaces"
+}
+
+type WorkspaceMember struct {
+	ID          uuid.UUID          `json:"id"          gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	WorkspaceID uuid.UUID          `json:"workspaceId" gorm:"column:workspace_id;type:uuid;not null"`
+	UserID      uuid.UUID          `json:"userId"      gorm:"column:user_id;type:uuid;not null"`
+	User        *users_models.User `json:"user"        gorm:"foreignKey:UserID"`
+	Role        WorkspaceRole      `json:"role"        gorm:"column:role;type:text;not null"`
+	CreatedAt   time.Time          `json:"createdAt"   gorm:"column:created_at;not null;default:now()"`
+}
+
+func (WorkspaceMember) TableName() string {
+	return "workspace_members"
+}
+
+// WorkspaceInvitation lets the invited email join the workspace, either
+// by signing up or by accepting it with an existing account. Only hash
+// of the token is stored: the token itself is shown once to the inviter
+type WorkspaceInvitation struct {
+	ID              uuid.UUID     `json:"id"              gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	WorkspaceID     uuid.UUID     `json:"workspaceId"     gorm:"column:workspace_id;type:uuid;not null"`
+	Email           string        `json:"email"           gorm:"column:email;type:text;not null"`
+	Role            WorkspaceRole `json:"role"            gorm:"column:role;type:text;not null"`
+	TokenHash       string        `json:"-"               gorm:"column:token_hash;type:text;not null"`
+	InvitedByUserID uuid.UUID     `json:"invitedByUserId" gorm:"column:invited_by_user_id;type:uuid;not null"`
+	ExpiresAt       time.Time     `json:"expiresAt"       gorm:"column:expires_at;not null"`
+	CreatedAt       time.Time     `json:"createdAt"       gorm:"column:created_at;not null;default:now()"`
+}
+
+func (WorkspaceInvitation) TableName() string {
+	return "workspace_invitations"
+}
@@ -0,0 +1,184 @@
+package workspaces
+
+import (
+	"errors"
+	"postgresus-backend/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WorkspaceRepository struct{}
+
+func (r *WorkspaceRepository) Save(workspace *Workspace) error {
+	db := storage.GetDb()
+
+	if workspace.ID == uuid.Nil {
+		workspace.ID = uuid.New()
+		return db.Create(workspace).Error
+	}
+
+	return db.Save(workspace).Error
+}
+
+func (r *WorkspaceRepository) FindByID(id uuid.UUID) (*Workspace, error) {
+	var workspace Workspace
+
+	if err := storage.GetDb().Where("id = ?", id).First(&workspace).Error; err != nil {
+		return nil, err
+	}
+
+	return &workspace, nil
+}
+
+// FindOldest returns the first created workspace or nil if there is none
+func (r *WorkspaceRepository) FindOldest() (*Workspace, error) {
+	var workspace Workspace
+
+	if err := storage.GetDb().Order("created_at ASC").First(&workspace).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &workspace, nil
+}
+
+// FindByUserID returns workspaces of the user, the oldest membership first
+func (r *WorkspaceRepository) FindByUserID(userID uuid.UUID) ([]*WorkspaceResponse, error) {
+	var workspaces []*WorkspaceResponse
+
+	if err := storage.
+		GetDb().
+		Table("workspaces").
+		Select(
+			"workspaces.id, workspaces.name, workspaces.created_at, "+
+				"workspaces.is_two_factor_required, workspace_members.role",
+		).
+		Joins("JOIN workspace_members ON workspace_members.workspace_id = workspaces.id").
+		Where("workspace_members.user_id = ?", userID).
+		Order("workspace_members.created_at ASC").
+		Scan(&workspaces).Error; err != nil {
+		return nil, err
+	}
+
+	return workspaces, nil
+}
+
+func (r *WorkspaceRepository) SaveMember(member *WorkspaceMember) error {
+	db := storage.GetDb()
+
+	if member.ID == uuid.Nil {
+		member.ID = uuid.New()
+		return db.Omit("User").Create(member).Error
+	}
+
+	return db.Omit("User").Save(member).Error
+}
+
+// FindMember returns nil if the user is not a member of the workspace
+func (r *WorkspaceRepository) FindMember(
+	workspaceID uuid.UUID,
+	userID uuid.UUID,
+) (*WorkspaceMember, error) {
+	var member WorkspaceMember
+
+	if err := storage.
+		GetDb().
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+func (r *WorkspaceRepository) FindMembers(workspaceID uuid.UUID) ([]*WorkspaceMember, error) {
+	var members []*WorkspaceMember
+
+	if err := storage.
+		GetDb().
+		Preload("User").
+		Where("workspace_id = ?", workspaceID).
+		Order("created_at ASC").
+		Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (r *WorkspaceRepository) CountMembersByRole(
+	workspaceID uuid.UUID,
+	role WorkspaceRole,
+) (int64, error) {
+	var count int64
+
+	if err := storage.
+		GetDb().
+		Model(&WorkspaceMember{}).
+		Where("workspace_id = ? AND role = ?", workspaceID, role).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *WorkspaceRepository) DeleteMember(id uuid.UUID) error {
+	return storage.GetDb().Delete(&WorkspaceMember{}, "id = ?", id).Error
+}
+
+func (r *WorkspaceRepository) SaveInvitation(invitation *WorkspaceInvitation) error {
+	db := storage.GetDb()
+
+	if invitation.ID == uuid.Nil {
+		invitation.ID = uuid.New()
+		return db.Create(invitation).Error
+	}
+
+	return db.Save(invitation).Error
+}
+
+func (r *WorkspaceRepository) FindInvitationByTokenHash(
+	tokenHash string,
+) (*WorkspaceInvitation, error) {
+	var invitation WorkspaceInvitation
+
+	if err := storage.
+		GetDb().
+		Where("token_hash = ?", tokenHash).
+		First(&invitation).Error; err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// FindActiveInvitations returns not expired invitations of the workspace
+func (r *WorkspaceRepository) FindActiveInvitations(
+	workspaceID uuid.UUID,
+) ([]*WorkspaceInvitation, error) {
+	var invitations []*WorkspaceInvitation
+
+	if err := storage.
+		GetDb().
+		Where("workspace_id = ? AND expires_at > ?", workspaceID, time.Now().UTC()).
+		Order("created_at DESC").
+		Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+func (r *WorkspaceRepository) DeleteInvitation(id uuid.UUID) error {
+	return storage.GetDb().Delete(&WorkspaceInvitation{}, "id = ?", id).Error
+}
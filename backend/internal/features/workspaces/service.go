@@ -0,0 +1,562 @@
+package workspaces
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"postgresus-backend/internal/config"
+	users_models "postgresus-backend/internal/features/users/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultWorkspaceName = "Default workspace"
+	invitationTTL        = 7 * 24 * time.Hour
+)
+
+var (
+	errTwoFactorRequired = errors.New(
+		"the workspace requires two-factor authentication, please enable it in your profile",
+	)
+	errEnableTwoFactorFirst = errors.New(
+		"enable two-factor authentication for yourself before requiring it",
+	)
+)
+
+type WorkspaceService struct {
+	workspaceRepository *WorkspaceRepository
+	logger              *slog.Logger
+}
+
+func (s *WorkspaceService) CreateWorkspace(
+	user *users_models.User,
+	request *SaveWorkspaceRequest,
+) (*Workspace, error) {
+	if request.IsTwoFactorRequired && !user.IsTotpEnabled {
+		return nil, errEnableTwoFactorFirst
+	}
+
+	workspace, err := s.createWorkspace(user.ID, request.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.IsTwoFactorRequired {
+		workspace.IsTwoFactorRequired = true
+
+		if err := s.workspaceRepository.Save(workspace); err != nil {
+			return nil, err
+		}
+	}
+
+	return workspace, nil
+}
+
+func (s *WorkspaceService) UpdateWorkspace(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+	request *SaveWorkspaceRequest,
+) (*Workspace, error) {
+	if err := s.CheckRole(user, workspaceID, WorkspaceRoleOwner); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(request.Name) == "" {
+		return nil, errors.New("workspace name is required")
+	}
+
+	// otherwise the owner would lose access right away
+	if request.IsTwoFactorRequired && !user.IsTotpEnabled {
+		return nil, errEnableTwoFactorFirst
+	}
+
+	workspace, err := s.workspaceRepository.FindByID(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace.Name = strings.TrimSpace(request.Name)
+	workspace.IsTwoFactorRequired = request.IsTwoFactorRequired
+
+	if err := s.workspaceRepository.Save(workspace); err != nil {
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+func (s *WorkspaceService) GetWorkspaces(user *users_models.User) ([]*WorkspaceResponse, error) {
+	return s.workspaceRepository.FindByUserID(user.ID)
+}
+
+func (s *WorkspaceService) GetMembers(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+) ([]*WorkspaceMember, error) {
+	if err := s.CheckRole(user, workspaceID, WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+
+	return s.workspaceRepository.FindMembers(workspaceID)
+}
+
+func (s *WorkspaceService) InviteMember(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+	request *InviteMemberRequest,
+) (*InviteMemberResponse, error) {
+	if err := s.checkRoleToGrant(user, workspaceID, request.Role); err != nil {
+		return nil, err
+	}
+
+	token, err := generateInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &WorkspaceInvitation{
+		WorkspaceID:     workspaceID,
+		Email:           strings.ToLower(strings.TrimSpace(request.Email)),
+		Role:            request.Role,
+		TokenHash:       hashInvitationToken(token),
+		InvitedByUserID: user.ID,
+		ExpiresAt:       time.Now().UTC().Add(invitationTTL),
+		CreatedAt:       time.Now().UTC(),
+	}
+
+	if err := s.workspaceRepository.SaveInvitation(invitation); err != nil {
+		return nil, err
+	}
+
+	return &InviteMemberResponse{
+		Invitation: invitation,
+		Token:      token,
+	}, nil
+}
+
+func (s *WorkspaceService) GetInvitations(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+) ([]*WorkspaceInvitation, error) {
+	if err := s.CheckRole(user, workspaceID, WorkspaceRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	return s.workspaceRepository.FindActiveInvitations(workspaceID)
+}
+
+func (s *WorkspaceService) DeleteInvitation(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+	invitationID uuid.UUID,
+) error {
+	if err := s.CheckRole(user, workspaceID, WorkspaceRoleAdmin); err != nil {
+		return err
+	}
+
+	invitations, err := s.workspaceRepository.FindActiveInvitations(workspaceID)
+	if err != nil {
+		return err
+	}
+
+	for _, invitation := range invitations {
+		if invitation.ID == invitationID {
+			return s.workspaceRepository.DeleteInvitation(invitationID)
+		}
+	}
+
+	return errors.New("invitation not found")
+}
+
+// AcceptInvitation adds existing user to the workspace of the invitation
+func (s *WorkspaceService) AcceptInvitation(
+	user *users_models.User,
+	token string,
+) (*Workspace, error) {
+	invitation, err := s.findValidInvitation(user.Email, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.acceptInvitation(user.ID, invitation); err != nil {
+		return nil, err
+	}
+
+	return s.workspaceRepository.FindByID(invitation.WorkspaceID)
+}
+
+func (s *WorkspaceService) ChangeMemberRole(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+	memberUserID uuid.UUID,
+	request *ChangeMemberRoleRequest,
+) error {
+	if err := s.checkRoleToGrant(user, workspaceID, request.Role); err != nil {
+		return err
+	}
+
+	member, err := s.getMember(workspaceID, memberUserID)
+	if err != nil {
+		return err
+	}
+
+	// only owners may take the owner role away
+	if err := s.checkRoleToGrant(user, workspaceID, member.Role); err != nil {
+		return err
+	}
+
+	if member.Role == WorkspaceRoleOwner && request.Role != WorkspaceRoleOwner {
+		if err := s.checkNotLastOwner(workspaceID); err != nil {
+			return err
+		}
+	}
+
+	member.Role = request.Role
+
+	return s.workspaceRepository.SaveMember(member)
+}
+
+// RemoveMember removes the member from the workspace. Any member can
+// leave the workspace, others are removed by admins and owners
+func (s *WorkspaceService) RemoveMember(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+	memberUserID uuid.UUID,
+) error {
+	member, err := s.getMember(workspaceID, memberUserID)
+	if err != nil {
+		return err
+	}
+
+	if memberUserID != user.ID {
+		if err := s.checkRoleToGrant(user, workspaceID, member.Role); err != nil {
+			return err
+		}
+	}
+
+	if member.Role == WorkspaceRoleOwner {
+		if err := s.checkNotLastOwner(workspaceID); err != nil {
+			return err
+		}
+	}
+
+	return s.workspaceRepository.DeleteMember(member.ID)
+}
+
+// CheckRole returns error unless the user is a member of the
+// workspace with the role or a higher one
+func (s *WorkspaceService) CheckRole(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+	minRole WorkspaceRole,
+) error {
+	member, err := s.workspaceRepository.FindMember(workspaceID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if member == nil {
+		return errors.New("you have not access to this workspace")
+	}
+
+	if !member.Role.IsAtLeast(minRole) {
+		return fmt.Errorf(
+			"%s role or higher is required, but you are %s of this workspace",
+			strings.ToLower(string(minRole)),
+			strings.ToLower(string(member.Role)),
+		)
+	}
+
+	if user.IsTotpEnabled {
+		return nil
+	}
+
+	workspace, err := s.workspaceRepository.FindByID(workspaceID)
+	if err != nil {
+		return err
+	}
+
+	if IsTwoFactorMissing(user, workspace.IsTwoFactorRequired) {
+		return errTwoFactorRequired
+	}
+
+	return nil
+}
+
+// GetWorkspaceIDs returns IDs of all workspaces the user is a member of,
+// except ones requiring two-factor authentication the user has not enabled
+func (s *WorkspaceService) GetWorkspaceIDs(user *users_models.User) ([]uuid.UUID, error) {
+	workspaces, err := s.workspaceRepository.FindByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceIDs := make([]uuid.UUID, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		if IsTwoFactorMissing(user, workspace.IsTwoFactorRequired) {
+			continue
+		}
+
+		workspaceIDs = append(workspaceIDs, workspace.ID)
+	}
+
+	return workspaceIDs, nil
+}
+
+// GetDefaultWorkspaceID returns the oldest workspace of the user. It is
+// used for resources created without explicit workspace
+func (s *WorkspaceService) GetDefaultWorkspaceID(userID uuid.UUID) (uuid.UUID, error) {
+	workspaces, err := s.workspaceRepository.FindByUserID(userID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if len(workspaces) == 0 {
+		return uuid.Nil, errors.New("user is not a member of any workspace")
+	}
+
+	return workspaces[0].ID, nil
+}
+
+// SyncMemberRoleInOldestWorkspace grants the role in the oldest workspace
+// or revokes membership when role is nil. It is used for roles managed by
+// external identity provider, so owners are never changed: they are
+// managed only inside Postgresus
+func (s *WorkspaceService) SyncMemberRoleInOldestWorkspace(
+	userID uuid.UUID,
+	role *WorkspaceRole,
+) error {
+	workspace, err := s.workspaceRepository.FindOldest()
+	if err != nil {
+		return err
+	}
+
+	if workspace == nil {
+		return errors.New("there is no workspace to add the user to")
+	}
+
+	member, err := s.workspaceRepository.FindMember(workspace.ID, userID)
+	if err != nil {
+		return err
+	}
+
+	if member != nil && member.Role == WorkspaceRoleOwner {
+		return nil
+	}
+
+	if role == nil {
+		if member == nil {
+			return nil
+		}
+
+		return s.workspaceRepository.DeleteMember(member.ID)
+	}
+
+	if !role.IsValid() {
+		return fmt.Errorf("invalid workspace role: %s", *role)
+	}
+
+	if member == nil {
+		member = &WorkspaceMember{
+			WorkspaceID: workspace.ID,
+			UserID:      userID,
+			CreatedAt:   time.Now().UTC(),
+		}
+	} else if member.Role == *role {
+		return nil
+	}
+
+	member.Role = *role
+
+	return s.workspaceRepository.SaveMember(member)
+}
+
+// OnBeforeUserSignUp allows sign up of everyone except the first user
+// only by a valid invitation sent to the same email
+func (s *WorkspaceService) OnBeforeUserSignUp(email string, invitationToken *string) error {
+	if invitationToken == nil {
+		return nil
+	}
+
+	_, err := s.findValidInvitation(email, *invitationToken)
+	return err
+}
+
+// OnUserSignedUp adds the user to the workspace of the invitation. The
+// first user, who signs up without invitation, gets own workspace
+func (s *WorkspaceService) OnUserSignedUp(
+	user *users_models.User,
+	invitationToken *string,
+) error {
+	if invitationToken == nil {
+		_, err := s.createWorkspace(user.ID, defaultWorkspaceName)
+		return err
+	}
+
+	invitation, err := s.findValidInvitation(user.Email, *invitationToken)
+	if err != nil {
+		return err
+	}
+
+	return s.acceptInvitation(user.ID, invitation)
+}
+
+func (s *WorkspaceService) createWorkspace(userID uuid.UUID, name string) (*Workspace, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, errors.New("workspace name is required")
+	}
+
+	workspace := &Workspace{
+		Name:      strings.TrimSpace(name),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.workspaceRepository.Save(workspace); err != nil {
+		return nil, err
+	}
+
+	if err := s.workspaceRepository.SaveMember(&WorkspaceMember{
+		WorkspaceID: workspace.ID,
+		UserID:      userID,
+		Role:        WorkspaceRoleOwner,
+		CreatedAt:   time.Now().UTC(),
+	}); err != nil {
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+// checkRoleToGrant requires admin role to manage members and owner
+// role to grant, revoke or remove the owner role
+func (s *WorkspaceService) checkRoleToGrant(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+	role WorkspaceRole,
+) error {
+	if !role.IsValid() {
+		return fmt.Errorf("invalid workspace role: %s", role)
+	}
+
+	if role == WorkspaceRoleOwner {
+		return s.CheckRole(user, workspaceID, WorkspaceRoleOwner)
+	}
+
+	return s.CheckRole(user, workspaceID, WorkspaceRoleAdmin)
+}
+
+func (s *WorkspaceService) checkNotLastOwner(workspaceID uuid.UUID) error {
+	ownersCount, err := s.workspaceRepository.CountMembersByRole(workspaceID, WorkspaceRoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if ownersCount <= 1 {
+		return errors.New("workspace must have at least one owner")
+	}
+
+	return nil
+}
+
+func (s *WorkspaceService) getMember(
+	workspaceID uuid.UUID,
+	userID uuid.UUID,
+) (*WorkspaceMember, error) {
+	member, err := s.workspaceRepository.FindMember(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if member == nil {
+		return nil, errors.New("user is not a member of this workspace")
+	}
+
+	return member, nil
+}
+
+func (s *WorkspaceService) findValidInvitation(
+	email string,
+	token string,
+) (*WorkspaceInvitation, error) {
+	invitation, err := s.workspaceRepository.FindInvitationByTokenHash(hashInvitationToken(token))
+	if err != nil {
+		return nil, errors.New("invitation is not found")
+	}
+
+	if time.Now().UTC().After(invitation.ExpiresAt) {
+		return nil, errors.New("invitation is expired")
+	}
+
+	if !strings.EqualFold(invitation.Email, strings.TrimSpace(email)) {
+		return nil, errors.New("invitation is sent to another email")
+	}
+
+	return invitation, nil
+}
+
+func (s *WorkspaceService) acceptInvitation(
+	userID uuid.UUID,
+	invitation *WorkspaceInvitation,
+) error {
+	member, err := s.workspaceRepository.FindMember(invitation.WorkspaceID, userID)
+	if err != nil {
+		return err
+	}
+
+	if member == nil {
+		member = &WorkspaceMember{
+			WorkspaceID: invitation.WorkspaceID,
+			UserID:      userID,
+			Role:        invitation.Role,
+			CreatedAt:   time.Now().UTC(),
+		}
+	} else if !member.Role.IsAtLeast(invitation.Role) {
+		member.Role = invitation.Role
+	}
+
+	if err := s.workspaceRepository.SaveMember(member); err != nil {
+		return err
+	}
+
+	s.logger.Info(
+		"User joined workspace by invitation",
+		"userId",
+		userID,
+		"workspaceId",
+		invitation.WorkspaceID,
+		"role",
+		member.Role,
+	)
+
+	return s.workspaceRepository.DeleteInvitation(invitation.ID)
+}
+
+func generateInvitationToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+
+	return hex.EncodeToString(tokenBytes), nil
+}
+
+func hashInvitationToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// IsTwoFactorMissing tells whether the user has no access because two-factor
+// authentication is required globally or by the workspace, but not enabled
+func IsTwoFactorMissing(user *users_models.User, isRequiredByWorkspace bool) bool {
+	if user.IsTotpEnabled {
+		return false
+	}
+
+	return isRequiredByWorkspace || config.GetEnv().IsTwoFactorRequired
+}
@@ -0,0 +1,46 @@
+package workspaces
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetTestWorkspaceID returns the default workspace of the user. Tests
+// do not register sign up listener, so the workspace is created if missing
+func GetTestWorkspaceID(userID uuid.UUID) uuid.UUID {
+	workspaceID, err := workspaceService.GetDefaultWorkspaceID(userID)
+	if err == nil {
+		return workspaceID
+	}
+
+	workspace, err := workspaceService.createWorkspace(userID, defaultWorkspaceName)
+	if err != nil {
+		panic(err)
+	}
+
+	return workspace.ID
+}
+
+// CreateTestWorkspace creates one more workspace owned by the user
+func CreateTestWorkspace(userID uuid.UUID) uuid.UUID {
+	workspace, err := workspaceService.createWorkspace(userID, "Test workspace "+uuid.New().String())
+	if err != nil {
+		panic(err)
+	}
+
+	return workspace.ID
+}
+
+func AddTestMember(workspaceID uuid.UUID, userID uuid.UUID, role WorkspaceRole) {
+	member := &WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		Role:        role,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	if err := workspaceRepository.SaveMember(member); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,273 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Stream format (AES-256-GCM, chunked):
+//
+//	header: magic (8 bytes) | nonce prefix (7 bytes)
+//	chunk:  length (4 bytes, high bit marks the last chunk) | ciphertext
+//
+// Each chunk nonce is prefix | counter (4 bytes) | last flag (1 byte),
+// so reordered, truncated or extended streams fail authentication.
+const (
+	streamMagic       = "PGRSENC1"
+	streamChunkSize   = 64 * 1024
+	noncePrefixSize   = 7
+	lastChunkFlag     = uint32(1) << 31
+	streamHeaderSize  = len(streamMagic) + noncePrefixSize
+	maxCiphertextSize = streamChunkSize + 16
+)
+
+var ErrInvalidStream = errors.New("encrypted stream is corrupted or the key is wrong")
+
+type encryptingWriter struct {
+	writer      io.Writer
+	aead        cipher.AEAD
+	noncePrefix []byte
+	counter     uint32
+	buf         []byte
+	isClosed    bool
+
+	isHeaderWritten bool
+}
+
+type decryptingReader struct {
+	reader      io.Reader
+	aead        cipher.AEAD
+	noncePrefix []byte
+	counter     uint32
+	plaintext   []byte
+	isFinished  bool
+}
+
+type decryptingReadCloser struct {
+	io.Reader
+	closer io.Closer
+}
+
+// NewEncryptingWriter returns a writer that encrypts everything written to it
+// into w. Nothing is written to w before the first chunk is ready, so w may be
+// a pipe whose reader is not started yet. Close must be called to flush the
+// last chunk; it does not close w.
+func NewEncryptingWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	noncePrefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(noncePrefix); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return &encryptingWriter{
+		writer:      w,
+		aead:        aead,
+		noncePrefix: noncePrefix,
+		buf:         make([]byte, 0, streamChunkSize),
+	}, nil
+}
+
+// NewDecryptingReader returns a reader that decrypts a stream produced by
+// NewEncryptingWriter
+func NewDecryptingReader(r io.Reader, key []byte) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read encryption header: %w", err)
+	}
+
+	if string(header[:len(streamMagic)]) != streamMagic {
+		return nil, errors.New("file is not an encrypted backup")
+	}
+
+	return &decryptingReader{
+		reader:      r,
+		aead:        aead,
+		noncePrefix: header[len(streamMagic):],
+	}, nil
+}
+
+// NewDecryptingReadCloser is NewDecryptingReader that closes rc on Close
+func NewDecryptingReadCloser(rc io.ReadCloser, key []byte) (io.ReadCloser, error) {
+	reader, err := NewDecryptingReader(rc, key)
+	if err != nil {
+		_ = rc.Close()
+		return nil, err
+	}
+
+	return &decryptingReadCloser{reader, rc}, nil
+}
+
+func (w *encryptingWriter) Write(p []byte) (int, error) {
+	if w.isClosed {
+		return 0, errors.New("write to closed encrypting writer")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		n := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+
+		// keep a full chunk buffered until more data arrives, so that
+		// the last chunk is never empty unless the whole stream is
+		if len(w.buf) == cap(w.buf) && len(p) > 0 {
+			if err := w.writeChunk(false); err != nil {
+				return written, err
+			}
+		}
+	}
+
+	return written, nil
+}
+
+func (w *encryptingWriter) Close() error {
+	if w.isClosed {
+		return nil
+	}
+	w.isClosed = true
+
+	return w.writeChunk(true)
+}
+
+func (r *decryptingReader) Read(p []byte) (int, error) {
+	for len(r.plaintext) == 0 {
+		if r.isFinished {
+			return 0, io.EOF
+		}
+
+		if err := r.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.plaintext)
+	r.plaintext = r.plaintext[n:]
+
+	return n, nil
+}
+
+func (r *decryptingReadCloser) Close() error {
+	return r.closer.Close()
+}
+
+func (w *encryptingWriter) writeChunk(isLast bool) error {
+	if w.counter == ^uint32(0) {
+		return errors.New("encrypted stream is too large")
+	}
+
+	if !w.isHeaderWritten {
+		header := append([]byte(streamMagic), w.noncePrefix...)
+		if _, err := w.writer.Write(header); err != nil {
+			return err
+		}
+
+		w.isHeaderWritten = true
+	}
+
+	ciphertext := w.aead.Seal(nil, buildNonce(w.noncePrefix, w.counter, isLast), w.buf, nil)
+
+	length := uint32(len(ciphertext))
+	if isLast {
+		length |= lastChunkFlag
+	}
+
+	lengthBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(lengthBytes, length)
+
+	if _, err := w.writer.Write(lengthBytes); err != nil {
+		return err
+	}
+
+	if _, err := w.writer.Write(ciphertext); err != nil {
+		return err
+	}
+
+	w.counter++
+	w.buf = w.buf[:0]
+
+	return nil
+}
+
+func (r *decryptingReader) readChunk() error {
+	lengthBytes := make([]byte, 4)
+	if _, err := io.ReadFull(r.reader, lengthBytes); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("%w: unexpected end of stream", ErrInvalidStream)
+		}
+
+		return err
+	}
+
+	length := binary.BigEndian.Uint32(lengthBytes)
+	isLast := length&lastChunkFlag != 0
+	length &^= lastChunkFlag
+
+	if length > maxCiphertextSize {
+		return fmt.Errorf("%w: chunk is too large", ErrInvalidStream)
+	}
+
+	ciphertext := make([]byte, length)
+	if _, err := io.ReadFull(r.reader, ciphertext); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("%w: unexpected end of stream", ErrInvalidStream)
+		}
+
+		return err
+	}
+
+	plaintext, err := r.aead.Open(
+		ciphertext[:0],
+		buildNonce(r.noncePrefix, r.counter, isLast),
+		ciphertext,
+		nil,
+	)
+	if err != nil {
+		return ErrInvalidStream
+	}
+
+	r.counter++
+	r.plaintext = plaintext
+	r.isFinished = isLast
+
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes", KeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func buildNonce(prefix []byte, counter uint32, isLast bool) []byte {
+	nonce := make([]byte, 0, noncePrefixSize+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+
+	if isLast {
+		return append(nonce, 1)
+	}
+
+	return append(nonce, 0)
+}
@@ -0,0 +1,102 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EncryptAndDecryptStream_ContentIsEqual(t *testing.T) {
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+
+	sizes := []int{0, 1, streamChunkSize - 1, streamChunkSize, streamChunkSize + 1, 3*streamChunkSize + 17}
+
+	for _, size := range sizes {
+		content := make([]byte, size)
+		_, err := rand.Read(content)
+		assert.NoError(t, err)
+
+		encrypted := encryptBytes(t, key, content)
+		assert.NotEqual(t, content, encrypted)
+
+		reader, err := NewDecryptingReader(bytes.NewReader(encrypted), key)
+		assert.NoError(t, err)
+
+		decrypted, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, content, decrypted, "size %d", size)
+	}
+}
+
+func Test_DecryptStreamWithWrongKey_ReturnsError(t *testing.T) {
+	key, _ := GenerateKey()
+	otherKey, _ := GenerateKey()
+
+	encrypted := encryptBytes(t, key, []byte("pg_dump output"))
+
+	reader, err := NewDecryptingReader(bytes.NewReader(encrypted), otherKey)
+	assert.NoError(t, err)
+
+	_, err = io.ReadAll(reader)
+	assert.ErrorIs(t, err, ErrInvalidStream)
+}
+
+func Test_DecryptTruncatedStream_ReturnsError(t *testing.T) {
+	key, _ := GenerateKey()
+
+	content := make([]byte, 2*streamChunkSize+100)
+	encrypted := encryptBytes(t, key, content)
+
+	// cut the stream exactly after the first chunk
+	firstChunkEnd := streamHeaderSize + 4 + maxCiphertextSize
+	reader, err := NewDecryptingReader(bytes.NewReader(encrypted[:firstChunkEnd]), key)
+	assert.NoError(t, err)
+
+	_, err = io.ReadAll(reader)
+	assert.ErrorIs(t, err, ErrInvalidStream)
+}
+
+func Test_DecryptTamperedStream_ReturnsError(t *testing.T) {
+	key, _ := GenerateKey()
+
+	encrypted := encryptBytes(t, key, []byte("some backup content"))
+	encrypted[len(encrypted)-1] ^= 0xFF
+
+	reader, err := NewDecryptingReader(bytes.NewReader(encrypted), key)
+	assert.NoError(t, err)
+
+	_, err = io.ReadAll(reader)
+	assert.ErrorIs(t, err, ErrInvalidStream)
+}
+
+func Test_EncryptAndDecryptPayload_ContentIsEqual(t *testing.T) {
+	key, _ := GenerateKey()
+
+	encrypted, err := Encrypt(key, []byte("secret"))
+	assert.NoError(t, err)
+
+	decrypted, err := Decrypt(key, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), decrypted)
+
+	otherKey, _ := GenerateKey()
+	_, err = Decrypt(otherKey, encrypted)
+	assert.Error(t, err)
+}
+
+func encryptBytes(t *testing.T, key []byte, content []byte) []byte {
+	var buf bytes.Buffer
+
+	writer, err := NewEncryptingWriter(&buf, key)
+	assert.NoError(t, err)
+
+	_, err = writer.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
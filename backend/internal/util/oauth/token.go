@@ -0,0 +1,144 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+// TokenRefresher keeps the token of a storage in JSON. Expired or rejected
+// access tokens are refreshed with the refresh token, and the listener is
+// notified so the new token is saved (providers may rotate refresh tokens)
+type TokenRefresher struct {
+	Config *oauth2.Config
+
+	// token of the storage, replaced with the refreshed one
+	TokenJSON *string
+
+	// called after TokenJSON holds the refreshed token
+	RefreshListener func() error
+
+	Logger *slog.Logger
+}
+
+// Do sends the request with the access token. If the token is rejected,
+// it is refreshed and the request is sent once again, so newRequest
+// must build the request with a fresh body each time
+func (r *TokenRefresher) Do(newRequest func() (*http.Request, error)) (*http.Response, error) {
+	accessToken, err := r.GetAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := sendRequest(newRequest, accessToken)
+	if err != nil || response.StatusCode != http.StatusUnauthorized {
+		return response, err
+	}
+	_ = response.Body.Close()
+
+	// token may be revoked before its expiry time
+	token, err := r.RefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token after auth error: %w", err)
+	}
+
+	return sendRequest(newRequest, token.AccessToken)
+}
+
+// GetAccessToken returns the stored access token or refreshes
+// it beforehand if the token is expired
+func (r *TokenRefresher) GetAccessToken() (string, error) {
+	token, err := ParseToken(*r.TokenJSON)
+	if err != nil {
+		return "", err
+	}
+
+	if token.Valid() {
+		return token.AccessToken, nil
+	}
+
+	token, err = r.RefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	return token.AccessToken, nil
+}
+
+// RefreshToken gets new access token, updates TokenJSON and notifies
+// the listener. Failed save is only logged, because the new token is
+// still used until restart
+func (r *TokenRefresher) RefreshToken() (*oauth2.Token, error) {
+	token, err := ParseToken(*r.TokenJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.RefreshToken == "" {
+		return nil, errors.New("no refresh token available in stored token")
+	}
+
+	// token without access token is always refreshed by the token source
+	newToken, err := r.Config.
+		TokenSource(context.Background(), &oauth2.Token{RefreshToken: token.RefreshToken}).
+		Token()
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant" {
+			return nil, fmt.Errorf(
+				"refresh token has expired or was revoked, please authorize the app again: %w",
+				err,
+			)
+		}
+
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	// some providers do not return refresh token on refresh
+	if newToken.RefreshToken == "" {
+		newToken.RefreshToken = token.RefreshToken
+	}
+
+	newTokenJSON, err := json.Marshal(newToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal refreshed token: %w", err)
+	}
+
+	*r.TokenJSON = string(newTokenJSON)
+
+	if r.RefreshListener != nil {
+		if err := r.RefreshListener(); err != nil {
+			r.Logger.Error("Failed to save refreshed token", "error", err)
+		}
+	}
+
+	return newToken, nil
+}
+
+func ParseToken(tokenJSON string) (*oauth2.Token, error) {
+	var token oauth2.Token
+	if err := json.Unmarshal([]byte(tokenJSON), &token); err != nil {
+		return nil, fmt.Errorf("invalid token JSON format: %w", err)
+	}
+
+	return &token, nil
+}
+
+func sendRequest(
+	newRequest func() (*http.Request, error),
+	accessToken string,
+) (*http.Response, error) {
+	request, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+
+	return http.DefaultClient.Do(request)
+}
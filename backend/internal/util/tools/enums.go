@@ -18,8 +18,11 @@ const (
 type PostgresqlExecutable string
 
 const (
-	PostgresqlExecutablePgDump PostgresqlExecutable = "pg_dump"
-	PostgresqlExecutablePsql   PostgresqlExecutable = "psql"
+	PostgresqlExecutablePgDump       PostgresqlExecutable = "pg_dump"
+	PostgresqlExecutablePsql         PostgresqlExecutable = "psql"
+	PostgresqlExecutablePgBasebackup PostgresqlExecutable = "pg_basebackup"
+	PostgresqlExecutablePgReceivewal PostgresqlExecutable = "pg_receivewal"
+	PostgresqlExecutablePgDumpall    PostgresqlExecutable = "pg_dumpall"
 )
 
 func GetPostgresqlVersionEnum(version string) PostgresqlVersion {
@@ -54,3 +57,26 @@ func IsBackupDbVersionHigherThanRestoreDbVersion(
 
 	return backupDbVersionInt > restoreDbVersionInt
 }
+
+// MysqlFlavor selects client tools: MariaDB dump is not fully
+// compatible with MySQL 8 servers and vice versa
+type MysqlFlavor string
+
+const (
+	MysqlFlavorMysql   MysqlFlavor = "MYSQL"
+	MysqlFlavorMariadb MysqlFlavor = "MARIADB"
+)
+
+type MysqlExecutable string
+
+const (
+	MysqlExecutableDump   MysqlExecutable = "dump"
+	MysqlExecutableClient MysqlExecutable = "client"
+)
+
+type MongodbExecutable string
+
+const (
+	MongodbExecutableDump    MongodbExecutable = "mongodump"
+	MongodbExecutableRestore MongodbExecutable = "mongorestore"
+)
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	env_utils "postgresus-backend/internal/util/env"
+)
+
+// GetMongodbExecutable returns the full path to mongodump or mongorestore
+// of MongoDB Database Tools. On Windows, automatically appends .exe extension.
+func GetMongodbExecutable(
+	executable MongodbExecutable,
+	envMode env_utils.EnvMode,
+	mongodbInstallDir string,
+) string {
+	executableName := string(executable)
+
+	if runtime.GOOS == "windows" {
+		executableName += ".exe"
+	}
+
+	return filepath.Join(getMongodbBasePath(envMode, mongodbInstallDir), executableName)
+}
+
+// VerifyMongodbInstallation checks that MongoDB Database Tools are
+// installed. As with MySQL tools, missing tools are reported without
+// stopping the app: they are needed only for MongoDB databases.
+// In development: ./tools/mongodb/bin
+// In production: /usr/bin
+func VerifyMongodbInstallation(
+	logger *slog.Logger,
+	envMode env_utils.EnvMode,
+	mongodbInstallDir string,
+) {
+	executables := []MongodbExecutable{MongodbExecutableDump, MongodbExecutableRestore}
+
+	for _, executable := range executables {
+		cmdPath := GetMongodbExecutable(executable, envMode, mongodbInstallDir)
+
+		if _, err := os.Stat(cmdPath); os.IsNotExist(err) {
+			logger.Warn(
+				"MongoDB tool not found, backups of such databases will fail. Read ./tools/readme.md for details",
+				"path",
+				cmdPath,
+			)
+			continue
+		}
+
+		logger.Info("MongoDB tool found", "path", cmdPath)
+	}
+}
+
+func getMongodbBasePath(envMode env_utils.EnvMode, mongodbInstallDir string) string {
+	if envMode == env_utils.EnvModeDevelopment {
+		return filepath.Join(mongodbInstallDir, "bin")
+	}
+
+	// mongodb-database-tools package installs into /usr/bin
+	return "/usr/bin"
+}
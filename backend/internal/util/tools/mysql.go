@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	env_utils "postgresus-backend/internal/util/env"
+)
+
+// GetMysqlExecutable returns the full path to the dump or client
+// executable of the flavor: mysqldump and mysql for MySQL,
+// mariadb-dump and mariadb for MariaDB. On Windows, automatically
+// appends .exe extension.
+func GetMysqlExecutable(
+	flavor MysqlFlavor,
+	executable MysqlExecutable,
+	envMode env_utils.EnvMode,
+	mysqlInstallDir string,
+) string {
+	executableName := getMysqlExecutableName(flavor, executable)
+
+	if runtime.GOOS == "windows" {
+		executableName += ".exe"
+	}
+
+	return filepath.Join(getMysqlBasePath(flavor, envMode, mysqlInstallDir), executableName)
+}
+
+// VerifyMysqlInstallation checks that MySQL and MariaDB client tools are
+// installed. Unlike PostgreSQL tools, they are needed only for MySQL
+// databases, so missing tools are reported without stopping the app.
+// In development: ./tools/mysql/{mysql,mariadb}/bin
+// In production: /usr/local/mysql/bin for MySQL and /usr/bin for MariaDB
+func VerifyMysqlInstallation(
+	logger *slog.Logger,
+	envMode env_utils.EnvMode,
+	mysqlInstallDir string,
+) {
+	flavors := []MysqlFlavor{MysqlFlavorMysql, MysqlFlavorMariadb}
+	executables := []MysqlExecutable{MysqlExecutableDump, MysqlExecutableClient}
+
+	for _, flavor := range flavors {
+		for _, executable := range executables {
+			cmdPath := GetMysqlExecutable(flavor, executable, envMode, mysqlInstallDir)
+
+			if _, err := os.Stat(cmdPath); os.IsNotExist(err) {
+				logger.Warn(
+					"MySQL client tool not found, backups of such databases will fail. Read ./tools/readme.md for details",
+					"flavor",
+					flavor,
+					"path",
+					cmdPath,
+				)
+				continue
+			}
+
+			logger.Info("MySQL client tool found", "flavor", flavor, "path", cmdPath)
+		}
+	}
+}
+
+func getMysqlExecutableName(flavor MysqlFlavor, executable MysqlExecutable) string {
+	if flavor == MysqlFlavorMariadb {
+		if executable == MysqlExecutableDump {
+			return "mariadb-dump"
+		}
+
+		return "mariadb"
+	}
+
+	if executable == MysqlExecutableDump {
+		return "mysqldump"
+	}
+
+	return "mysql"
+}
+
+func getMysqlBasePath(
+	flavor MysqlFlavor,
+	envMode env_utils.EnvMode,
+	mysqlInstallDir string,
+) string {
+	if envMode == env_utils.EnvModeDevelopment {
+		if flavor == MysqlFlavorMariadb {
+			return filepath.Join(mysqlInstallDir, "mariadb", "bin")
+		}
+
+		return filepath.Join(mysqlInstallDir, "mysql", "bin")
+	}
+
+	// packaged MariaDB client provides mysqldump too, so
+	// MySQL client tools are installed into separate directory
+	if flavor == MysqlFlavorMariadb {
+		return "/usr/bin"
+	}
+
+	return "/usr/local/mysql/bin"
+}
@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// secret of RFC 6238 test vectors for SHA1
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).
+	EncodeToString([]byte("12345678901234567890"))
+
+func Test_GenerateCode_WithRfcTestVectors_ReturnsExpectedCodes(t *testing.T) {
+	// RFC 6238 appendix B lists 8 digit codes, 6 digits are the last ones
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unixTime, expectedCode := range cases {
+		code, err := GenerateCode(rfcSecret, time.Unix(unixTime, 0))
+
+		assert.NoError(t, err)
+		assert.Equal(t, expectedCode, code, "time %d", unixTime)
+	}
+}
+
+func Test_Validate_WithClockDrift_AcceptsNeighbourStepsOnly(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+
+	now := time.Now()
+	code, err := GenerateCode(secret, now)
+	assert.NoError(t, err)
+
+	step, ok := Validate(secret, code, now.Add(StepPeriod))
+	assert.True(t, ok)
+	assert.Equal(t, getStep(now), step)
+
+	_, ok = Validate(secret, code, now.Add(3*StepPeriod))
+	assert.False(t, ok)
+
+	_, ok = Validate(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func Test_GetProvisioningUri_ContainsSecretAndIssuer(t *testing.T) {
+	uri := GetProvisioningUri("Postgresus", "admin@example.com", "JBSWY3DPEHPK3PXP")
+
+	parsedUri, err := url.Parse(uri)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "otpauth", parsedUri.Scheme)
+	assert.Equal(t, "totp", parsedUri.Host)
+	assert.Equal(t, "/Postgresus:admin@example.com", parsedUri.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", parsedUri.Query().Get("secret"))
+	assert.Equal(t, "Postgresus", parsedUri.Query().Get("issuer"))
+}
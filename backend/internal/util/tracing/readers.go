@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CountingReader counts bytes read through it
+type CountingReader struct {
+	Reader    io.Reader
+	BytesRead int64
+}
+
+func (r *CountingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.BytesRead += int64(n)
+
+	return n, err
+}
+
+// spanReadCloser keeps the span open while the file is read, so the
+// span covers the whole transfer rather than opening of the stream
+type spanReadCloser struct {
+	reader    io.ReadCloser
+	span      trace.Span
+	startedAt time.Time
+	bytesRead int64
+	isClosed  bool
+}
+
+// NewSpanReadCloser returns a reader which ends the span with
+// throughput attributes when it is closed
+func NewSpanReadCloser(reader io.ReadCloser, span trace.Span) io.ReadCloser {
+	return &spanReadCloser{
+		reader:    reader,
+		span:      span,
+		startedAt: time.Now().UTC(),
+	}
+}
+
+func (r *spanReadCloser) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.bytesRead += int64(n)
+
+	return n, err
+}
+
+func (r *spanReadCloser) Close() error {
+	err := r.reader.Close()
+
+	if !r.isClosed {
+		r.isClosed = true
+		SetThroughput(r.span, r.bytesRead, time.Since(r.startedAt))
+		EndSpan(r.span, err)
+	}
+
+	return err
+}
@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "postgresus"
+
+// Setup enables export of spans via OTLP over HTTP. The exporter is
+// configured by standard OTEL_EXPORTER_OTLP_* variables, service name
+// and resource attributes by OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES.
+// Until Setup is called spans are no-op
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	traceResource, err := resource.New(
+		ctx,
+		resource.WithAttributes(attribute.String("service.name", tracerName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(traceResource),
+	)
+	otel.SetTracerProvider(tracerProvider)
+
+	return tracerProvider.Shutdown, nil
+}
+
+func StartSpan(
+	ctx context.Context,
+	name string,
+	attributes ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// EndSpan marks the span as failed if there is an error and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// SetThroughput sets amount of transferred bytes and the transfer speed
+func SetThroughput(span trace.Span, bytes int64, elapsed time.Duration) {
+	span.SetAttributes(attribute.Int64("bytes", bytes))
+
+	if elapsed > 0 {
+		span.SetAttributes(
+			attribute.Float64("throughput_bytes_per_second", float64(bytes)/elapsed.Seconds()),
+		)
+	}
+}
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Create backup encryption keys table
+CREATE TABLE backup_encryption_keys (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    encrypted_key   TEXT NOT NULL,
+    is_active       BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_backup_encryption_keys_is_active ON backup_encryption_keys (is_active);
+
+ALTER TABLE backup_configs
+    ADD COLUMN encryption TEXT NOT NULL DEFAULT 'NONE';
+
+ALTER TABLE backups
+    ADD COLUMN encryption         TEXT NOT NULL DEFAULT 'NONE',
+    ADD COLUMN encryption_key_id  UUID;
+
+ALTER TABLE backups
+    ADD CONSTRAINT fk_backups_encryption_key_id
+    FOREIGN KEY (encryption_key_id)
+    REFERENCES backup_encryption_keys (id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE backups DROP CONSTRAINT IF EXISTS fk_backups_encryption_key_id;
+
+ALTER TABLE backups
+    DROP COLUMN encryption,
+    DROP COLUMN encryption_key_id;
+
+ALTER TABLE backup_configs
+    DROP COLUMN encryption;
+
+DROP TABLE IF EXISTS backup_encryption_keys;
+
+-- +goose StatementEnd
@@ -0,0 +1,76 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Create WAL segments table
+CREATE TABLE wal_segments (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    database_id         UUID NOT NULL,
+    storage_id          UUID NOT NULL,
+    file_name           TEXT NOT NULL,
+    timeline            INT NOT NULL,
+    is_history          BOOLEAN NOT NULL DEFAULT FALSE,
+    size_bytes          BIGINT NOT NULL,
+    encryption          TEXT NOT NULL DEFAULT 'NONE',
+    encryption_key_id   UUID,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE wal_segments
+    ADD CONSTRAINT fk_wal_segments_database_id
+    FOREIGN KEY (database_id)
+    REFERENCES databases (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE wal_segments
+    ADD CONSTRAINT fk_wal_segments_storage_id
+    FOREIGN KEY (storage_id)
+    REFERENCES storages (id);
+
+ALTER TABLE wal_segments
+    ADD CONSTRAINT fk_wal_segments_encryption_key_id
+    FOREIGN KEY (encryption_key_id)
+    REFERENCES backup_encryption_keys (id);
+
+CREATE UNIQUE INDEX idx_wal_segments_database_id_file_name
+    ON wal_segments (database_id, file_name);
+
+ALTER TABLE backup_configs
+    ADD COLUMN backup_type TEXT NOT NULL DEFAULT 'LOGICAL';
+
+ALTER TABLE backups
+    ADD COLUMN type                 TEXT NOT NULL DEFAULT 'LOGICAL',
+    ADD COLUMN wal_start_lsn        TEXT,
+    ADD COLUMN wal_stop_lsn         TEXT,
+    ADD COLUMN wal_start_segment    TEXT,
+    ADD COLUMN wal_stop_segment     TEXT;
+
+ALTER TABLE restores
+    ADD COLUMN target_time      TIMESTAMPTZ,
+    ADD COLUMN target_lsn       TEXT,
+    ADD COLUMN data_directory   TEXT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE restores
+    DROP COLUMN target_time,
+    DROP COLUMN target_lsn,
+    DROP COLUMN data_directory;
+
+ALTER TABLE backups
+    DROP COLUMN type,
+    DROP COLUMN wal_start_lsn,
+    DROP COLUMN wal_stop_lsn,
+    DROP COLUMN wal_start_segment,
+    DROP COLUMN wal_stop_segment;
+
+ALTER TABLE backup_configs
+    DROP COLUMN backup_type;
+
+DROP INDEX IF EXISTS idx_wal_segments_database_id_file_name;
+
+DROP TABLE IF EXISTS wal_segments;
+
+-- +goose StatementEnd
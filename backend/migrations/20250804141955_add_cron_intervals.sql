@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE intervals
+    ADD COLUMN cron_expression  TEXT,
+    ADD COLUMN timezone         TEXT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE intervals
+    DROP COLUMN cron_expression,
+    DROP COLUMN timezone;
+
+-- +goose StatementEnd
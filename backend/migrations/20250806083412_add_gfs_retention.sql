@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE backup_configs
+    ADD COLUMN retention_policy_type  TEXT NOT NULL DEFAULT 'TIME_PERIOD',
+    ADD COLUMN gfs_keep_last          INT NOT NULL DEFAULT 0,
+    ADD COLUMN gfs_keep_daily         INT NOT NULL DEFAULT 0,
+    ADD COLUMN gfs_keep_weekly        INT NOT NULL DEFAULT 0,
+    ADD COLUMN gfs_keep_monthly       INT NOT NULL DEFAULT 0,
+    ADD COLUMN gfs_keep_yearly        INT NOT NULL DEFAULT 0;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE backup_configs
+    DROP COLUMN retention_policy_type,
+    DROP COLUMN gfs_keep_last,
+    DROP COLUMN gfs_keep_daily,
+    DROP COLUMN gfs_keep_weekly,
+    DROP COLUMN gfs_keep_monthly,
+    DROP COLUMN gfs_keep_yearly;
+
+-- +goose StatementEnd
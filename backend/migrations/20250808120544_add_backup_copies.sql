@@ -0,0 +1,65 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Create backup config secondary storages table
+CREATE TABLE backup_config_secondary_storages (
+    database_id  UUID NOT NULL,
+    storage_id   UUID NOT NULL,
+    PRIMARY KEY (database_id, storage_id)
+);
+
+ALTER TABLE backup_config_secondary_storages
+    ADD CONSTRAINT fk_backup_config_secondary_storages_database_id
+    FOREIGN KEY (database_id)
+    REFERENCES backup_configs (database_id)
+    ON DELETE CASCADE;
+
+ALTER TABLE backup_config_secondary_storages
+    ADD CONSTRAINT fk_backup_config_secondary_storages_storage_id
+    FOREIGN KEY (storage_id)
+    REFERENCES storages (id);
+
+CREATE INDEX idx_backup_config_secondary_storages_storage_id
+    ON backup_config_secondary_storages (storage_id);
+
+-- Create backup copies table
+CREATE TABLE backup_copies (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    backup_id       UUID NOT NULL,
+    storage_id      UUID NOT NULL,
+    status          TEXT NOT NULL,
+    fail_message    TEXT,
+    attempts_count  INT NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at    TIMESTAMPTZ
+);
+
+ALTER TABLE backup_copies
+    ADD CONSTRAINT fk_backup_copies_backup_id
+    FOREIGN KEY (backup_id)
+    REFERENCES backups (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE backup_copies
+    ADD CONSTRAINT fk_backup_copies_storage_id
+    FOREIGN KEY (storage_id)
+    REFERENCES storages (id);
+
+CREATE UNIQUE INDEX idx_backup_copies_backup_id_storage_id
+    ON backup_copies (backup_id, storage_id);
+
+CREATE INDEX idx_backup_copies_status ON backup_copies (status);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_backup_copies_status;
+DROP INDEX IF EXISTS idx_backup_copies_backup_id_storage_id;
+DROP TABLE IF EXISTS backup_copies;
+
+DROP INDEX IF EXISTS idx_backup_config_secondary_storages_storage_id;
+DROP TABLE IF EXISTS backup_config_secondary_storages;
+
+-- +goose StatementEnd
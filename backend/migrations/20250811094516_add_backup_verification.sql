@@ -0,0 +1,73 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE backups
+    ADD COLUMN checksum              TEXT,
+    ADD COLUMN verification_status   TEXT,
+    ADD COLUMN verification_message  TEXT,
+    ADD COLUMN verified_at           TIMESTAMPTZ,
+    ADD COLUMN is_test_restored      BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_backups_status_verification_status
+    ON backups (status, verification_status);
+
+ALTER TABLE backup_configs
+    ADD COLUMN is_test_restore_enabled   BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN test_restore_interval_id  UUID,
+    ADD COLUMN test_restore_sanity_sql   TEXT;
+
+ALTER TABLE backup_configs
+    ADD CONSTRAINT fk_backup_config_test_restore_interval_id
+    FOREIGN KEY (test_restore_interval_id)
+    REFERENCES intervals (id);
+
+ALTER TABLE postgresql_databases
+    ADD COLUMN backup_config_id UUID;
+
+ALTER TABLE postgresql_databases
+    ADD CONSTRAINT fk_postgresql_databases_backup_config_id
+    FOREIGN KEY (backup_config_id)
+    REFERENCES backup_configs (database_id)
+    ON DELETE CASCADE;
+
+CREATE INDEX idx_postgresql_databases_backup_config_id
+    ON postgresql_databases (backup_config_id);
+
+-- notify about corrupted backups everyone who is notified about failed ones
+UPDATE backup_configs
+SET send_notifications_on = send_notifications_on || ',BACKUP_VERIFICATION_FAILED'
+WHERE send_notifications_on LIKE '%BACKUP_FAILED%';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+UPDATE backup_configs
+SET send_notifications_on = TRIM(BOTH ',' FROM REPLACE(
+    REPLACE(send_notifications_on, 'BACKUP_VERIFICATION_FAILED', ''),
+    ',,',
+    ','
+));
+
+DROP INDEX IF EXISTS idx_postgresql_databases_backup_config_id;
+DELETE FROM postgresql_databases WHERE backup_config_id IS NOT NULL;
+ALTER TABLE postgresql_databases
+    DROP COLUMN backup_config_id;
+
+ALTER TABLE backup_configs
+    DROP CONSTRAINT IF EXISTS fk_backup_config_test_restore_interval_id;
+ALTER TABLE backup_configs
+    DROP COLUMN is_test_restore_enabled,
+    DROP COLUMN test_restore_interval_id,
+    DROP COLUMN test_restore_sanity_sql;
+
+DROP INDEX IF EXISTS idx_backups_status_verification_status;
+ALTER TABLE backups
+    DROP COLUMN checksum,
+    DROP COLUMN verification_status,
+    DROP COLUMN verification_message,
+    DROP COLUMN verified_at,
+    DROP COLUMN is_test_restored;
+
+-- +goose StatementEnd
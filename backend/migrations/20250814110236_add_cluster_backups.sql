@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE postgresql_databases
+    ADD COLUMN is_cluster_mode    BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN include_databases  TEXT,
+    ADD COLUMN exclude_databases  TEXT;
+
+-- Create backup members table
+CREATE TABLE backup_members (
+    id              UUID PRIMARY KEY,
+    backup_id       UUID NOT NULL,
+    database_name   TEXT NOT NULL,
+    backup_size_mb  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    checksum        TEXT
+);
+
+ALTER TABLE backup_members
+    ADD CONSTRAINT fk_backup_members_backup_id
+    FOREIGN KEY (backup_id)
+    REFERENCES backups (id)
+    ON DELETE CASCADE;
+
+CREATE INDEX idx_backup_members_backup_id ON backup_members (backup_id);
+
+ALTER TABLE restores
+    ADD COLUMN is_restore_globals  BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN cluster_databases   JSONB;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE restores
+    DROP COLUMN is_restore_globals,
+    DROP COLUMN cluster_databases;
+
+DROP INDEX IF EXISTS idx_backup_members_backup_id;
+DROP TABLE IF EXISTS backup_members;
+
+ALTER TABLE postgresql_databases
+    DROP COLUMN is_cluster_mode,
+    DROP COLUMN include_databases,
+    DROP COLUMN exclude_databases;
+
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE backup_configs
+    ADD COLUMN include_schemas     JSONB,
+    ADD COLUMN exclude_schemas     JSONB,
+    ADD COLUMN include_tables      JSONB,
+    ADD COLUMN exclude_tables      JSONB,
+    ADD COLUMN exclude_table_data  JSONB;
+
+ALTER TABLE restores
+    ADD COLUMN restore_objects  JSONB;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE restores
+    DROP COLUMN restore_objects;
+
+ALTER TABLE backup_configs
+    DROP COLUMN include_schemas,
+    DROP COLUMN exclude_schemas,
+    DROP COLUMN include_tables,
+    DROP COLUMN exclude_tables,
+    DROP COLUMN exclude_table_data;
+
+-- +goose StatementEnd
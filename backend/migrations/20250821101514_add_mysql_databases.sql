@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE mysql_databases (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    database_id UUID,
+    restore_id  UUID,
+    flavor      TEXT NOT NULL,
+    host        TEXT NOT NULL,
+    port        INT NOT NULL,
+    username    TEXT NOT NULL,
+    password    TEXT NOT NULL,
+    database    TEXT,
+    is_https    BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+ALTER TABLE mysql_databases
+    ADD CONSTRAINT uk_mysql_databases_database_id
+    UNIQUE (database_id);
+
+ALTER TABLE mysql_databases
+    ADD CONSTRAINT fk_mysql_databases_database_id
+    FOREIGN KEY (database_id)
+    REFERENCES databases (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE mysql_databases
+    ADD CONSTRAINT fk_mysql_databases_restore_id
+    FOREIGN KEY (restore_id)
+    REFERENCES restores (id)
+    ON DELETE CASCADE;
+
+CREATE INDEX idx_mysql_databases_database_id ON mysql_databases (database_id);
+CREATE INDEX idx_mysql_databases_restore_id ON mysql_databases (restore_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS mysql_databases;
+
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE mongodb_databases (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    database_id   UUID,
+    restore_id    UUID,
+    host          TEXT NOT NULL,
+    port          INT NOT NULL,
+    username      TEXT NOT NULL,
+    password      TEXT NOT NULL,
+    database      TEXT,
+    auth_database TEXT NOT NULL DEFAULT 'admin',
+    replica_set   TEXT,
+    is_https      BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+ALTER TABLE mongodb_databases
+    ADD CONSTRAINT uk_mongodb_databases_database_id
+    UNIQUE (database_id);
+
+ALTER TABLE mongodb_databases
+    ADD CONSTRAINT fk_mongodb_databases_database_id
+    FOREIGN KEY (database_id)
+    REFERENCES databases (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE mongodb_databases
+    ADD CONSTRAINT fk_mongodb_databases_restore_id
+    FOREIGN KEY (restore_id)
+    REFERENCES restores (id)
+    ON DELETE CASCADE;
+
+CREATE INDEX idx_mongodb_databases_database_id ON mongodb_databases (database_id);
+CREATE INDEX idx_mongodb_databases_restore_id ON mongodb_databases (restore_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS mongodb_databases;
+
+-- +goose StatementEnd
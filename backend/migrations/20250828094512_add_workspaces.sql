@@ -0,0 +1,135 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE workspaces (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE workspace_members (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL,
+    user_id      UUID NOT NULL,
+    role         TEXT NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE workspace_members
+    ADD CONSTRAINT uk_workspace_members_workspace_id_user_id
+    UNIQUE (workspace_id, user_id);
+
+ALTER TABLE workspace_members
+    ADD CONSTRAINT fk_workspace_members_workspace_id
+    FOREIGN KEY (workspace_id)
+    REFERENCES workspaces (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE workspace_members
+    ADD CONSTRAINT fk_workspace_members_user_id
+    FOREIGN KEY (user_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE;
+
+CREATE INDEX idx_workspace_members_user_id ON workspace_members (user_id);
+
+CREATE TABLE workspace_invitations (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id       UUID NOT NULL,
+    email              TEXT NOT NULL,
+    role               TEXT NOT NULL,
+    token_hash         TEXT NOT NULL,
+    invited_by_user_id UUID NOT NULL,
+    expires_at         TIMESTAMPTZ NOT NULL,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE workspace_invitations
+    ADD CONSTRAINT uk_workspace_invitations_token_hash
+    UNIQUE (token_hash);
+
+ALTER TABLE workspace_invitations
+    ADD CONSTRAINT fk_workspace_invitations_workspace_id
+    FOREIGN KEY (workspace_id)
+    REFERENCES workspaces (id)
+    ON DELETE CASCADE;
+
+CREATE INDEX idx_workspace_invitations_workspace_id ON workspace_invitations (workspace_id);
+
+-- every existing user gets own workspace with all their resources.
+-- Workspace reuses ID of the user to link resources without mapping table
+INSERT INTO workspaces (id, name, created_at)
+SELECT id, 'Default workspace', created_at FROM users;
+
+INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+SELECT id, id, 'OWNER', created_at FROM users;
+
+ALTER TABLE databases ADD COLUMN workspace_id UUID;
+ALTER TABLE storages ADD COLUMN workspace_id UUID;
+ALTER TABLE notifiers ADD COLUMN workspace_id UUID;
+
+UPDATE databases SET workspace_id = user_id
+WHERE user_id IN (SELECT id FROM workspaces);
+
+UPDATE storages SET workspace_id = user_id
+WHERE user_id IN (SELECT id FROM workspaces);
+
+UPDATE notifiers SET workspace_id = user_id
+WHERE user_id IN (SELECT id FROM workspaces);
+
+-- storages and notifiers have no foreign key to users, so
+-- resources of removed users go to the oldest workspace
+UPDATE databases SET workspace_id = (SELECT id FROM workspaces ORDER BY created_at LIMIT 1)
+WHERE workspace_id IS NULL;
+
+UPDATE storages SET workspace_id = (SELECT id FROM workspaces ORDER BY created_at LIMIT 1)
+WHERE workspace_id IS NULL;
+
+UPDATE notifiers SET workspace_id = (SELECT id FROM workspaces ORDER BY created_at LIMIT 1)
+WHERE workspace_id IS NULL;
+
+ALTER TABLE databases ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE storages ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE notifiers ALTER COLUMN workspace_id SET NOT NULL;
+
+ALTER TABLE databases
+    ADD CONSTRAINT fk_databases_workspace_id
+    FOREIGN KEY (workspace_id)
+    REFERENCES workspaces (id);
+
+ALTER TABLE storages
+    ADD CONSTRAINT fk_storages_workspace_id
+    FOREIGN KEY (workspace_id)
+    REFERENCES workspaces (id);
+
+ALTER TABLE notifiers
+    ADD CONSTRAINT fk_notifiers_workspace_id
+    FOREIGN KEY (workspace_id)
+    REFERENCES workspaces (id);
+
+CREATE INDEX idx_databases_workspace_id ON databases (workspace_id);
+CREATE INDEX idx_storages_workspace_id ON storages (workspace_id);
+CREATE INDEX idx_notifiers_workspace_id ON notifiers (workspace_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_notifiers_workspace_id;
+DROP INDEX IF EXISTS idx_storages_workspace_id;
+DROP INDEX IF EXISTS idx_databases_workspace_id;
+
+ALTER TABLE notifiers DROP CONSTRAINT IF EXISTS fk_notifiers_workspace_id;
+ALTER TABLE storages DROP CONSTRAINT IF EXISTS fk_storages_workspace_id;
+ALTER TABLE databases DROP CONSTRAINT IF EXISTS fk_databases_workspace_id;
+
+ALTER TABLE notifiers DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE storages DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE databases DROP COLUMN IF EXISTS workspace_id;
+
+DROP TABLE IF EXISTS workspace_invitations;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
+
+-- +goose StatementEnd
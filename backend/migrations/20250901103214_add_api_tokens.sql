@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE api_tokens (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      UUID NOT NULL,
+    name         TEXT NOT NULL,
+    token_prefix TEXT NOT NULL,
+    token_hash   TEXT NOT NULL,
+    scopes       TEXT NOT NULL,
+    database_ids TEXT NOT NULL DEFAULT '',
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE api_tokens
+    ADD CONSTRAINT uk_api_tokens_token_hash
+    UNIQUE (token_hash);
+
+ALTER TABLE api_tokens
+    ADD CONSTRAINT fk_api_tokens_user_id
+    FOREIGN KEY (user_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE;
+
+CREATE INDEX idx_api_tokens_user_id ON api_tokens (user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_api_tokens_user_id;
+DROP TABLE IF EXISTS api_tokens;
+
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE user_sessions (
+    id                          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id                     UUID NOT NULL,
+    refresh_token_hash          TEXT NOT NULL,
+    previous_refresh_token_hash TEXT,
+    ip_address                  TEXT NOT NULL DEFAULT '',
+    user_agent                  TEXT NOT NULL DEFAULT '',
+    created_at                  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at                TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at                  TIMESTAMPTZ NOT NULL,
+    revoked_at                  TIMESTAMPTZ
+);
+
+ALTER TABLE user_sessions
+    ADD CONSTRAINT uk_user_sessions_refresh_token_hash
+    UNIQUE (refresh_token_hash);
+
+ALTER TABLE user_sessions
+    ADD CONSTRAINT fk_user_sessions_user_id
+    FOREIGN KEY (user_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE;
+
+CREATE INDEX idx_user_sessions_user_id ON user_sessions (user_id);
+CREATE INDEX idx_user_sessions_previous_refresh_token_hash
+    ON user_sessions (previous_refresh_token_hash);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_user_sessions_previous_refresh_token_hash;
+DROP INDEX IF EXISTS idx_user_sessions_user_id;
+DROP TABLE IF EXISTS user_sessions;
+
+-- +goose StatementEnd
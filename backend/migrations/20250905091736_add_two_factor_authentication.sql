@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE users
+    ADD COLUMN is_totp_enabled           BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN totp_secret               TEXT,
+    ADD COLUMN totp_last_used_step       BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN totp_recovery_code_hashes TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE workspaces
+    ADD COLUMN is_two_factor_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE workspaces DROP COLUMN IF EXISTS is_two_factor_required;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS totp_recovery_code_hashes,
+    DROP COLUMN IF EXISTS totp_last_used_step,
+    DROP COLUMN IF EXISTS totp_secret,
+    DROP COLUMN IF EXISTS is_totp_enabled;
+
+-- +goose StatementEnd
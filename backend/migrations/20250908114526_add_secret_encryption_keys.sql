@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE secret_encryption_keys (
+    id            UUID PRIMARY KEY,
+    encrypted_key TEXT NOT NULL,
+    is_active     BOOLEAN NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_secret_encryption_keys_active
+    ON secret_encryption_keys (is_active)
+    WHERE is_active;
+
+-- encrypted password is longer than 255 characters allowed before
+ALTER TABLE email_notifiers
+    ALTER COLUMN smtp_password TYPE TEXT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE email_notifiers
+    ALTER COLUMN smtp_password TYPE VARCHAR(255);
+
+DROP INDEX IF EXISTS idx_secret_encryption_keys_active;
+DROP TABLE IF EXISTS secret_encryption_keys;
+
+-- +goose StatementEnd
@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- no foreign keys: records outlive users, workspaces and targets
+CREATE TABLE audit_logs (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_type    TEXT NOT NULL,
+    actor_user_id UUID,
+    actor_email   TEXT,
+    api_token_id  UUID,
+    action        TEXT NOT NULL,
+    target_type   TEXT NOT NULL,
+    target_id     UUID,
+    target_name   TEXT,
+    workspace_id  UUID,
+    changes       JSONB,
+    source_ip     TEXT,
+    result        TEXT NOT NULL,
+    error_message TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_logs_created_at
+    ON audit_logs (created_at);
+
+CREATE INDEX idx_audit_logs_workspace_id_created_at
+    ON audit_logs (workspace_id, created_at);
+
+CREATE INDEX idx_audit_logs_target
+    ON audit_logs (target_type, target_id);
+
+CREATE FUNCTION prevent_audit_logs_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_append_only
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION prevent_audit_logs_change();
+
+CREATE TRIGGER audit_logs_no_truncate
+    BEFORE TRUNCATE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION prevent_audit_logs_change();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs;
+DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+DROP FUNCTION IF EXISTS prevent_audit_logs_change();
+
+DROP INDEX IF EXISTS idx_audit_logs_target;
+DROP INDEX IF EXISTS idx_audit_logs_workspace_id_created_at;
+DROP INDEX IF EXISTS idx_audit_logs_created_at;
+DROP TABLE IF EXISTS audit_logs;
+
+-- +goose StatementEnd
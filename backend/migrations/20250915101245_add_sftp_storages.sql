@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Create SFTP storages table
+CREATE TABLE sftp_storages (
+    storage_id             UUID PRIMARY KEY,
+    host                   TEXT NOT NULL,
+    port                   INTEGER NOT NULL DEFAULT 22,
+    username               TEXT NOT NULL,
+    password               TEXT,
+    private_key            TEXT,
+    private_key_passphrase TEXT,
+    host_key               TEXT NOT NULL,
+    path                   TEXT
+);
+
+ALTER TABLE sftp_storages
+    ADD CONSTRAINT fk_sftp_storages_storage
+    FOREIGN KEY (storage_id)
+    REFERENCES storages (id)
+    ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS sftp_storages;
+
+-- +goose StatementEnd
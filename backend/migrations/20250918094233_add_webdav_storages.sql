@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Create WebDAV storages table
+CREATE TABLE webdav_storages (
+    storage_id         UUID PRIMARY KEY,
+    url                TEXT NOT NULL,
+    username           TEXT NOT NULL,
+    password           TEXT NOT NULL,
+    path               TEXT,
+    use_chunked_upload BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+ALTER TABLE webdav_storages
+    ADD CONSTRAINT fk_webdav_storages_storage
+    FOREIGN KEY (storage_id)
+    REFERENCES storages (id)
+    ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS webdav_storages;
+
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Create Dropbox storages table
+CREATE TABLE dropbox_storages (
+    storage_id    UUID PRIMARY KEY,
+    client_id     TEXT NOT NULL,
+    client_secret TEXT NOT NULL,
+    token_json    TEXT NOT NULL,
+    path          TEXT
+);
+
+ALTER TABLE dropbox_storages
+    ADD CONSTRAINT fk_dropbox_storages_storage
+    FOREIGN KEY (storage_id)
+    REFERENCES storages (id)
+    ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+
+-- Create OneDrive storages table
+CREATE TABLE onedrive_storages (
+    storage_id    UUID PRIMARY KEY,
+    client_id     TEXT NOT NULL,
+    client_secret TEXT NOT NULL,
+    token_json    TEXT NOT NULL,
+    path          TEXT
+);
+
+ALTER TABLE onedrive_storages
+    ADD CONSTRAINT fk_onedrive_storages_storage
+    FOREIGN KEY (storage_id)
+    REFERENCES storages (id)
+    ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS onedrive_storages;
+DROP TABLE IF EXISTS dropbox_storages;
+
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE user_sessions
+    ADD COLUMN refreshed_at TIMESTAMPTZ;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE user_sessions
+    DROP COLUMN refreshed_at;
+
+-- +goose StatementEnd
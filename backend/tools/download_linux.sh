@@ -101,6 +101,53 @@ for version in $versions; do
     fi
 done
 
+echo
+echo "Installing MySQL and MariaDB client tools..."
+
+MYSQL_DIR="$(pwd)/mysql"
+MYSQL_VERSION="8.4.6"
+
+# MariaDB client tools from the system repository
+$SUDO apt-get install -y -qq mariadb-client xz-utils
+mkdir -p "$MYSQL_DIR/mariadb/bin"
+ln -sf "$(command -v mariadb-dump)" "$MYSQL_DIR/mariadb/bin/mariadb-dump"
+ln -sf "$(command -v mariadb)" "$MYSQL_DIR/mariadb/bin/mariadb"
+
+# MySQL client tools from the official archive, as packaged MariaDB
+# client replaces mysqldump of the system
+mysql_archive="mysql-$MYSQL_VERSION-linux-glibc2.17-$(uname -m)-minimal"
+wget -q -O "/tmp/$mysql_archive.tar.xz" \
+    "https://dev.mysql.com/get/Downloads/MySQL-8.4/$mysql_archive.tar.xz"
+tar -xJf "/tmp/$mysql_archive.tar.xz" -C /tmp
+mkdir -p "$MYSQL_DIR/mysql/bin"
+cp "/tmp/$mysql_archive/bin/mysqldump" "/tmp/$mysql_archive/bin/mysql" "$MYSQL_DIR/mysql/bin/"
+rm -rf "/tmp/$mysql_archive" "/tmp/$mysql_archive.tar.xz"
+
+echo "MySQL client tools are available in: $MYSQL_DIR/mysql/bin"
+echo "MariaDB client tools are available in: $MYSQL_DIR/mariadb/bin"
+
+echo
+echo "Installing MongoDB Database Tools..."
+
+MONGODB_DIR="$(pwd)/mongodb"
+MONGODB_TOOLS_VERSION="100.12.2"
+
+if [ "$(uname -m)" = "aarch64" ]; then
+    mongodb_platform="ubuntu2204-arm64"
+else
+    mongodb_platform="ubuntu2204-x86_64"
+fi
+
+mongodb_archive="mongodb-database-tools-$mongodb_platform-$MONGODB_TOOLS_VERSION"
+wget -q -O "/tmp/$mongodb_archive.tgz" \
+    "https://fastdl.mongodb.org/tools/db/$mongodb_archive.tgz"
+tar -xzf "/tmp/$mongodb_archive.tgz" -C /tmp
+mkdir -p "$MONGODB_DIR/bin"
+cp "/tmp/$mongodb_archive/bin/mongodump" "/tmp/$mongodb_archive/bin/mongorestore" "$MONGODB_DIR/bin/"
+rm -rf "/tmp/$mongodb_archive" "/tmp/$mongodb_archive.tgz"
+
+echo "MongoDB tools are available in: $MONGODB_DIR/bin"
+
 echo
 echo "Usage example:"
 echo "  $POSTGRES_DIR/postgresql-15/bin/pg_dump --version" 
\ No newline at end of file